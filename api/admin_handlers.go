@@ -0,0 +1,170 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/adaptive"
+	"s3migration/pkg/core"
+	"s3migration/pkg/pool"
+)
+
+// liveMigrator returns the enhanced migrator of a task, or writes an error response
+func liveMigrator(c *gin.Context) (*core.EnhancedMigrator, bool) {
+	taskID := c.Param("taskID")
+
+	task, exists := taskManager.getTask(taskID)
+	var migrator *core.EnhancedMigrator
+	var status string
+	if exists {
+		migrator = task.EnhancedMigrator
+		status = task.status().Status
+	}
+
+	if !exists {
+		respondError(c, http.StatusNotFound, "Task not found")
+		return nil, false
+	}
+	if migrator == nil {
+		respondError(c, http.StatusConflict, "Task has no live S3 migrator (not an S3 task or not running in this instance)")
+		return nil, false
+	}
+	if status != "pending" && status != "running" {
+		respondErrorCode(c, http.StatusConflict, codeConflict, "Task is not running", gin.H{"status": status})
+		return nil, false
+	}
+	return migrator, true
+}
+
+// GetTaskTuning handles GET /api/admin/tasks/:taskID/tuning
+// @Summary Inspect live task internals
+// @Description Get worker count, queue depth, listing state, rate limit, memory estimator profile and in-flight objects (with part-level progress) of a running task
+// @Tags admin
+// @Produce json
+// @Param taskID path string true "Task ID"
+// @Success 200 {object} core.TuningState
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/admin/tasks/{taskID}/tuning [get]
+func GetTaskTuning(c *gin.Context) {
+	migrator, ok := liveMigrator(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, migrator.GetTuningState())
+}
+
+// UpdateTaskTuning handles PATCH /api/admin/tasks/:taskID/tuning
+// @Summary Tune a live task
+// @Description Raise or lower the worker count, pause or resume listing, or set a request rate limit without cancelling the task
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param taskID path string true "Task ID"
+// @Param request body core.TuningUpdate true "Settings to change"
+// @Success 200 {object} core.TuningState
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/admin/tasks/{taskID}/tuning [patch]
+func UpdateTaskTuning(c *gin.Context) {
+	var update core.TuningUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if update.TargetWorkers == nil && update.ListingPaused == nil && update.RateLimitRPS == nil {
+		respondError(c, http.StatusBadRequest, "one of target_workers, listing_paused or rate_limit_rps is required")
+		return
+	}
+	if update.TargetWorkers != nil && *update.TargetWorkers < 1 {
+		respondError(c, http.StatusBadRequest, "target_workers must be at least 1")
+		return
+	}
+	if update.RateLimitRPS != nil && *update.RateLimitRPS < 0 {
+		respondError(c, http.StatusBadRequest, "rate_limit_rps must not be negative")
+		return
+	}
+
+	migrator, ok := liveMigrator(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, migrator.ApplyTuning(update))
+}
+
+// memoryState is the process-wide memory settings and usage, and the memory
+// estimator of each S3 task running in this instance
+func memoryState() gin.H {
+	taskManager.mu.RLock()
+	profiles := make(map[string]adaptive.MemoryProfile)
+	for id, task := range taskManager.tasks {
+		if task.EnhancedMigrator == nil {
+			continue
+		}
+		if status := task.status().Status; status == "pending" || status == "running" {
+			profiles[id] = task.EnhancedMigrator.MemoryProfile()
+		}
+	}
+	taskManager.mu.RUnlock()
+
+	return gin.H{
+		"settings": adaptive.CurrentMemorySettings(),
+		"current":  adaptive.ProcessMemoryStats(),
+		"tasks":    profiles,
+	}
+}
+
+// GetMemorySettings handles GET /api/admin/memory
+// @Summary Inspect memory settings
+// @Description Get the memory limit, safe threshold and per-worker profiles, current memory use, and the memory estimator of each running task
+// @Tags admin
+// @Produce json
+// @Success 200 {object} gin.H
+// @Router /api/admin/memory [get]
+func GetMemorySettings(c *gin.Context) {
+	c.JSON(http.StatusOK, memoryState())
+}
+
+// GetLatencyMetrics handles GET /api/admin/latency
+// @Summary Inspect S3 request latency
+// @Description Get the p50, p95 and p99 latency of the S3 requests of every task since the server started, by endpoint and operation. GetObject is timed to its first byte.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} gin.H
+// @Router /api/admin/latency [get]
+func GetLatencyMetrics(c *gin.Context) {
+	latency := pool.ProcessLatencies()
+	c.JSON(http.StatusOK, gin.H{"latency": latency, "count": len(latency)})
+}
+
+// UpdateMemorySettings handles PATCH /api/admin/memory
+// @Summary Tune memory settings
+// @Description Change the memory limit, safe threshold or per-worker memory profiles of workload patterns; running tasks apply them on their next memory sample
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body adaptive.MemorySettingsUpdate true "Settings to change"
+// @Success 200 {object} gin.H
+// @Failure 400 {object} ErrorResponse
+// @Router /api/admin/memory [patch]
+func UpdateMemorySettings(c *gin.Context) {
+	var update adaptive.MemorySettingsUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if update.MemoryLimitMiB == nil && update.SafeThresholdPct == nil && update.PerWorkerMiB == nil {
+		respondError(c, http.StatusBadRequest, "one of memory_limit_mib, safe_threshold_pct or per_worker_mib is required")
+		return
+	}
+	if _, err := adaptive.UpdateMemorySettings(update); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, memoryState())
+}
@@ -0,0 +1,302 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/state"
+)
+
+// auditActions names mutating routes; unlisted mutating routes are recorded as "METHOD route"
+var auditActions = map[string]string{
+	"PUT /api/settings/logging":             "settings.logging.update",
+	"PATCH /api/admin/tasks/:taskID/tuning": "task.tuning.update",
+	"POST /api/migrate":                     "migration.start",
+	"POST /api/migrate/bulk":                "migration.start_bulk",
+	"DELETE /api/tasks/:taskID":             "task.cancel",
+	"DELETE /api/tasks/cleanup/:status":     "task.cleanup",
+	"DELETE /api/cache/listings":            "cache.listings.invalidate",
+	"POST /api/tasks/import":                "task.import",
+	"POST /api/tasks/:taskId/notes":         "task.note.add",
+	"POST /api/schedules":                   "schedule.create",
+	"PUT /api/schedules/:id":                "schedule.update",
+	"DELETE /api/schedules/:id":             "schedule.delete",
+	"POST /api/schedules/:id/enable":        "schedule.enable",
+	"POST /api/schedules/:id/disable":       "schedule.disable",
+	"POST /api/schedules/:id/run":           "schedule.run",
+	"POST /api/googledrive/exchange-token":  "credentials.googledrive.exchange",
+	"POST /api/googledrive/migrate":         "migration.start_googledrive",
+	"POST /api/box/exchange-token":          "credentials.box.exchange",
+	"POST /api/migrate/box":                 "migration.start_box",
+	"POST /api/migrate/urls":                "migration.start_urls",
+}
+
+// auditReadOnly lists POST routes that only read (listing, probing, OAuth URLs) and are not audited
+var auditReadOnly = map[string]bool{
+	"POST /api/test-connection":            true,
+	"POST /api/test-bucket-listing":        true,
+	"POST /api/buckets/list":               true,
+	"POST /api/objects/list":               true,
+	"POST /api/objects/diff":               true,
+	"POST /api/googledrive/quick-auth-url": true,
+	"POST /api/googledrive/auth-url":       true,
+	"POST /api/googledrive/list-folders":   true,
+	"POST /api/box/auth-url":               true,
+	"POST /api/box/list-folders":           true,
+}
+
+const (
+	// maxAuditBody is the largest request body summarized in the audit log
+	maxAuditBody = 64 * 1024
+	// maxAuditResponse is how much of the response is kept to extract IDs and errors
+	maxAuditResponse = 4 * 1024
+)
+
+// auditSecretFields are JSON keys whose values never reach the audit log
+var auditSecretFields = []string{"secret", "password", "token", "private", "encryption_key", "api_key", "api-key", "authorization", "cookie"}
+
+// redactJSON masks credentials in a decoded JSON value; access keys keep a recognizable prefix/suffix
+func redactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			lower := strings.ToLower(key)
+			if s, ok := child.(string); ok && (lower == "access_key" || lower == "accesskey") {
+				v[key] = maskCredential(s)
+				continue
+			}
+			redacted := false
+			for _, field := range auditSecretFields {
+				if strings.Contains(lower, field) {
+					if child != nil && child != "" {
+						v[key] = "[REDACTED]"
+					}
+					redacted = true
+					break
+				}
+			}
+			if !redacted {
+				v[key] = redactJSON(child)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactJSON(child)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// summarizeBody returns the request body as compact JSON with secrets redacted
+func summarizeBody(body []byte, truncated bool) string {
+	if truncated {
+		return "(body too large to summarize)"
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return "(non-JSON body)"
+	}
+	summary, err := json.Marshal(redactJSON(decoded))
+	if err != nil {
+		return ""
+	}
+	return string(summary)
+}
+
+// auditResponseWriter keeps the start of the response to pull out IDs and error messages
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if remaining := maxAuditResponse - w.body.Len(); remaining > 0 {
+		if len(b) < remaining {
+			remaining = len(b)
+		}
+		w.body.Write(b[:remaining])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// auditStore returns the audit manager, or nil before the task manager is initialized
+func auditStore() *state.AuditManager {
+	if taskManager == nil {
+		return nil
+	}
+	dbManager, ok := taskManager.stateManager.(*state.DBStateManager)
+	if !ok {
+		return nil
+	}
+	return state.NewAuditManager(dbManager.GetDB())
+}
+
+// auditLog records every mutating API call with caller, request summary and outcome
+func auditLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+			c.Next()
+			return
+		}
+		route := method + " " + c.FullPath()
+		if c.FullPath() == "" || auditReadOnly[route] {
+			c.Next()
+			return
+		}
+
+		// Peek at the body without consuming it for the handler
+		var body []byte
+		truncated := false
+		if c.Request.Body != nil {
+			body, _ = io.ReadAll(io.LimitReader(c.Request.Body, maxAuditBody+1))
+			truncated = len(body) > maxAuditBody
+			c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+		}
+
+		writer := &auditResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		start := time.Now()
+
+		c.Next()
+
+		action, ok := auditActions[route]
+		if !ok {
+			action = route
+		}
+		entry := &state.AuditEntry{
+			Timestamp:      start,
+			Actor:          clientKey(c),
+			ClientIP:       c.ClientIP(),
+			UserAgent:      c.Request.UserAgent(),
+			Action:         action,
+			Method:         method,
+			Path:           c.Request.URL.Path,
+			RequestSummary: summarizeBody(body, truncated),
+			StatusCode:     writer.Status(),
+			Outcome:        "success",
+			DurationMs:     time.Since(start).Milliseconds(),
+		}
+
+		// Resource ID from the path, or from the response for create calls
+		for _, param := range []string{"taskID", "taskId", "id"} {
+			if value := c.Param(param); value != "" {
+				entry.ResourceID = value
+				break
+			}
+		}
+		var response map[string]interface{}
+		_ = json.Unmarshal(writer.body.Bytes(), &response)
+		if entry.ResourceID == "" {
+			for _, field := range []string{"task_id", "id"} {
+				if value, ok := response[field].(string); ok && value != "" {
+					entry.ResourceID = value
+					break
+				}
+			}
+		}
+
+		if entry.StatusCode >= 400 {
+			entry.Outcome = "failure"
+			if message, ok := response["error"].(string); ok {
+				entry.Error = message
+			} else {
+				entry.Error = http.StatusText(entry.StatusCode)
+			}
+		}
+
+		store := auditStore()
+		if store == nil {
+			return
+		}
+		if err := store.RecordAudit(entry); err != nil {
+			fmt.Printf("⚠️  Audit log write failed for %s: %v\n", action, err)
+		}
+	}
+}
+
+// ListAuditLog handles GET /api/audit
+// @Summary List audit log
+// @Description List recorded mutating API calls, newest first
+// @Tags audit
+// @Produce json
+// @Param actor query string false "Caller identity (hashed API key or ip:<addr>)"
+// @Param action query string false "Action, or a prefix ending in '.' (e.g. schedule.)"
+// @Param outcome query string false "success or failure"
+// @Param resource_id query string false "Task or schedule ID"
+// @Param since query string false "RFC3339 start time (inclusive)"
+// @Param until query string false "RFC3339 end time (exclusive)"
+// @Param limit query int false "Entries to return (default: 100, max: 1000)"
+// @Param offset query int false "Entries to skip"
+// @Success 200 {object} gin.H
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/audit [get]
+func ListAuditLog(c *gin.Context) {
+	filter := state.AuditFilter{
+		Actor:      c.Query("actor"),
+		Action:     c.Query("action"),
+		Outcome:    c.Query("outcome"),
+		ResourceID: c.Query("resource_id"),
+		Limit:      100,
+	}
+
+	for name, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := c.Query(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				respondError(c, http.StatusBadRequest, fmt.Sprintf("%s must be an RFC3339 time", name))
+				return
+			}
+			*target = parsed
+		}
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		fmt.Sscanf(limitStr, "%d", &filter.Limit)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+	if filter.Limit > 1000 {
+		filter.Limit = 1000
+	}
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		fmt.Sscanf(offsetStr, "%d", &filter.Offset)
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	store := auditStore()
+	if store == nil {
+		respondError(c, http.StatusInternalServerError, "audit log not available")
+		return
+	}
+
+	entries, total, err := store.ListAudit(filter)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"count":   len(entries),
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	})
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// shareTokenPrefix marks share tokens, telling them apart from API keys in a bearer header
+const shareTokenPrefix = "st_"
+
+// Validity of share tokens: the default and the longest that can be requested
+const (
+	defaultShareTokenTTL = 24 * time.Hour
+	maxShareTokenTTL     = 30 * 24 * time.Hour
+)
+
+// shareTokenRoutes are the read-only routes a share token opens, for its own task only
+var shareTokenRoutes = map[string]bool{
+	"/api/status/:taskID":        true,
+	"/api/status/:taskID/events": true,
+}
+
+var (
+	shareSecretOnce sync.Once
+	shareSecret     []byte
+)
+
+// shareTokenSecret is the HMAC key of share tokens: SHARE_TOKEN_SECRET, or a
+// random key per process, whose tokens stop working on restart and on other replicas
+func shareTokenSecret() []byte {
+	shareSecretOnce.Do(func() {
+		if secret := os.Getenv("SHARE_TOKEN_SECRET"); secret != "" {
+			shareSecret = []byte(secret)
+			return
+		}
+		shareSecret = make([]byte, 32)
+		rand.Read(shareSecret)
+		fmt.Printf("⚠️  SHARE_TOKEN_SECRET not set: share tokens are only valid on this replica until it restarts\n")
+	})
+	return shareSecret
+}
+
+// shareClaims is what a share token grants: read-only status of a task until Expiry (Unix seconds)
+type shareClaims struct {
+	TaskID string `json:"t"`
+	Expiry int64  `json:"e"`
+}
+
+// newShareToken signs a token granting read-only access to a task's status until expires
+func newShareToken(taskID string, expires time.Time) string {
+	payload, _ := json.Marshal(shareClaims{TaskID: taskID, Expiry: expires.Unix()})
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return shareTokenPrefix + encoded + "." + shareSignature(encoded)
+}
+
+// shareSignature is the base64url HMAC-SHA256 of a token's encoded claims
+func shareSignature(encoded string) string {
+	mac := hmac.New(sha256.New, shareTokenSecret())
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseShareToken verifies a share token and returns the task it grants
+func parseShareToken(token string, now time.Time) (string, error) {
+	encoded, signature, ok := strings.Cut(strings.TrimPrefix(token, shareTokenPrefix), ".")
+	if !ok || !strings.HasPrefix(token, shareTokenPrefix) {
+		return "", errors.New("malformed share token")
+	}
+	if !hmac.Equal([]byte(signature), []byte(shareSignature(encoded))) {
+		return "", errors.New("invalid share token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.New("malformed share token")
+	}
+	var claims shareClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.TaskID == "" {
+		return "", errors.New("malformed share token")
+	}
+	if now.Unix() >= claims.Expiry {
+		return "", errors.New("share token expired")
+	}
+	return claims.TaskID, nil
+}
+
+// requestCredential returns the API key or share token of a request: X-API-Key,
+// an Authorization bearer, or (share tokens only, for links) a token query parameter
+func requestCredential(c *gin.Context) string {
+	if key := c.GetHeader(headerAPIKey); key != "" {
+		return key
+	}
+	if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(bearer)
+	}
+	if token := c.Query("token"); strings.HasPrefix(token, shareTokenPrefix) {
+		return token
+	}
+	return ""
+}
+
+// knownAPIKey reports whether credential is one of keys
+func knownAPIKey(credential string, keys []string) bool {
+	for _, key := range keys {
+		if subtle.ConstantTimeCompare([]byte(credential), []byte(key)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// apiAuth checks the credentials of API calls. With API_KEYS (comma separated)
+// set, every call needs one of the keys. A share token is accepted instead, with
+// or without keys, for GET requests on the status routes of its own task.
+func apiAuth() gin.HandlerFunc {
+	keys := envList("API_KEYS", nil)
+	return func(c *gin.Context) {
+		credential := requestCredential(c)
+		if strings.HasPrefix(credential, shareTokenPrefix) {
+			taskID, err := parseShareToken(credential, time.Now())
+			if err != nil {
+				abortWithError(c, http.StatusUnauthorized, err.Error())
+				return
+			}
+			if c.Request.Method != http.MethodGet || !shareTokenRoutes[c.FullPath()] || c.Param("taskID") != taskID {
+				abortWithError(c, http.StatusForbidden, "share token only grants read-only status of task "+taskID)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if len(keys) == 0 || knownAPIKey(credential, keys) {
+			c.Next()
+			return
+		}
+		c.Header("WWW-Authenticate", `Bearer realm="s3-migration"`)
+		abortWithError(c, http.StatusUnauthorized, "API key required (X-API-Key header or Authorization: Bearer)")
+	}
+}
+
+// shareTaskRequest sets how long a share link stays valid
+type shareTaskRequest struct {
+	ExpiresIn int `json:"expires_in"` // Seconds (default: 86400, at most 2592000)
+}
+
+// ShareTask handles POST /api/tasks/:taskId/share
+// @Summary Create a read-only share link for a task
+// @Description Sign an expiring token that grants read-only access to one task's status, for sharing progress without an API key
+// @Tags migration
+// @Accept json
+// @Produce json
+// @Param taskId path string true "Task ID"
+// @Param request body shareTaskRequest false "Validity"
+// @Success 200 {object} gin.H
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/tasks/{taskId}/share [post]
+func ShareTask(c *gin.Context) {
+	taskID := c.Param("taskId")
+	var req shareTaskRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	ttl := time.Duration(req.ExpiresIn) * time.Second
+	if req.ExpiresIn == 0 {
+		ttl = defaultShareTokenTTL
+	}
+	if ttl <= 0 || ttl > maxShareTokenTTL {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("expires_in must be between 1 and %d seconds", int(maxShareTokenTTL.Seconds())))
+		return
+	}
+
+	if _, exists := taskManager.getTask(taskID); !exists {
+		_, _, cached := taskManager.cachedStatus(taskID)
+		if taskState, err := taskManager.stateManager.LoadTask(taskID); !cached && (err != nil || taskState == nil) {
+			respondError(c, http.StatusNotFound, "task not found")
+			return
+		}
+	}
+
+	expires := time.Now().Add(ttl).UTC().Truncate(time.Second)
+	token := newShareToken(taskID, expires)
+	c.JSON(http.StatusOK, gin.H{
+		"task_id":    taskID,
+		"token":      token,
+		"expires_at": expires,
+		"url":        "/share?task=" + url.QueryEscape(taskID) + "&token=" + url.QueryEscape(token),
+	})
+}
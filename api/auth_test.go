@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+)
+
+func TestShareTokenGrantsReadOnlyStatus(t *testing.T) {
+	t.Setenv("API_KEYS", "admin-key, ops-key")
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	endpoint.Put("source", "a.txt", []byte("alpha"))
+	tasks := testRouter(t, endpoint)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api", apiAuth())
+	api.POST("/migrate", StartMigration)
+	api.GET("/status/:taskID", GetStatus)
+	api.GET("/tasks", ListTasks)
+	api.POST("/tasks/:taskId/share", ShareTask)
+
+	request := func(method, path, header, credential, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if header != "" {
+			req.Header.Set(header, credential)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	if resp := request(http.MethodGet, "/api/tasks", "", "", ""); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("GET /api/tasks without a key = %d", resp.Code)
+	}
+	resp := request(http.MethodPost, "/api/migrate", "X-API-Key", "ops-key", `{"source_bucket": "source", "dest_bucket": "dest"}`)
+	var started struct {
+		TaskID string `json:"task_id"`
+	}
+	json.Unmarshal(resp.Body.Bytes(), &started)
+	if resp.Code != http.StatusOK || started.TaskID == "" {
+		t.Fatalf("POST /api/migrate = %d: %s", resp.Code, resp.Body)
+	}
+
+	if resp := request(http.MethodPost, "/api/tasks/missing/share", "Authorization", "Bearer admin-key", ""); resp.Code != http.StatusNotFound {
+		t.Errorf("sharing a missing task = %d", resp.Code)
+	}
+	resp = request(http.MethodPost, "/api/tasks/"+started.TaskID+"/share", "Authorization", "Bearer admin-key", "")
+	var shared struct {
+		Token string `json:"token"`
+		URL   string `json:"url"`
+	}
+	json.Unmarshal(resp.Body.Bytes(), &shared)
+	if resp.Code != http.StatusOK || shared.Token == "" || shared.URL == "" {
+		t.Fatalf("POST share = %d: %s", resp.Code, resp.Body)
+	}
+	expired := newShareToken(started.TaskID, time.Now().Add(-time.Minute))
+	other := newShareToken("other-task", time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		header     string
+		credential string
+		want       int
+	}{
+		{name: "status by query token", method: http.MethodGet, path: "/api/status/" + started.TaskID + "?token=" + shared.Token, want: http.StatusOK},
+		{name: "status by bearer token", method: http.MethodGet, path: "/api/status/" + started.TaskID, header: "Authorization", credential: "Bearer " + shared.Token, want: http.StatusOK},
+		{name: "another task's status", method: http.MethodGet, path: "/api/status/" + started.TaskID + "?token=" + other, want: http.StatusForbidden},
+		{name: "task list", method: http.MethodGet, path: "/api/tasks?token=" + shared.Token, want: http.StatusForbidden},
+		{name: "sharing again", method: http.MethodPost, path: "/api/tasks/" + started.TaskID + "/share", header: "X-API-Key", credential: shared.Token, want: http.StatusForbidden},
+		{name: "expired token", method: http.MethodGet, path: "/api/status/" + started.TaskID + "?token=" + expired, want: http.StatusUnauthorized},
+		{name: "tampered token", method: http.MethodGet, path: "/api/status/" + started.TaskID + "?token=" + shared.Token + "x", want: http.StatusUnauthorized},
+		{name: "wrong API key", method: http.MethodGet, path: "/api/tasks", header: "X-API-Key", credential: "guess", want: http.StatusUnauthorized},
+		{name: "API key", method: http.MethodGet, path: "/api/tasks", header: "X-API-Key", credential: "admin-key", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		if resp := request(tt.method, tt.path, tt.header, tt.credential, ""); resp.Code != tt.want {
+			t.Errorf("%s: %s %s = %d, want %d: %s", tt.name, tt.method, tt.path, resp.Code, tt.want, resp.Body)
+		}
+	}
+	waitForStatus(t, tasks, started.TaskID, func(status models.MigrationStatus) bool { return status.Status == "completed" })
+}
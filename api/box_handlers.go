@@ -0,0 +1,283 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+	"s3migration/pkg/providers/box"
+)
+
+// boxOAuthCredentials returns the request's OAuth app credentials, falling back to the environment
+func boxOAuthCredentials(clientID, clientSecret string) (string, string, error) {
+	if clientID != "" && clientSecret != "" {
+		return clientID, clientSecret, nil
+	}
+	clientID = os.Getenv("BOX_CLIENT_ID")
+	clientSecret = os.Getenv("BOX_CLIENT_SECRET")
+	if clientID == "" || clientSecret == "" {
+		return "", "", fmt.Errorf("Box OAuth not configured. Please set BOX_CLIENT_ID and BOX_CLIENT_SECRET environment variables or provide client_id and client_secret in request.")
+	}
+	return clientID, clientSecret, nil
+}
+
+// BoxAuthURL generates a Box OAuth authorization URL
+func BoxAuthURL(c *gin.Context) {
+	var req struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		RedirectURL  string `json:"redirect_url" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	clientID, clientSecret, err := boxOAuthCredentials(req.ClientID, req.ClientSecret)
+	if err != nil {
+		respondError(c, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	authHandler := box.NewAuthHandler(c.Request.Context(), box.OAuthConfig{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  req.RedirectURL,
+	})
+
+	state := uuid.New().String()
+	c.JSON(http.StatusOK, gin.H{
+		"auth_url": authHandler.GetAuthURL(state),
+		"state":    state,
+	})
+}
+
+// BoxExchangeToken exchanges a Box authorization code for tokens
+func BoxExchangeToken(c *gin.Context) {
+	var req struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		RedirectURL  string `json:"redirect_url"`
+		Code         string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	clientID, clientSecret, err := boxOAuthCredentials(req.ClientID, req.ClientSecret)
+	if err != nil {
+		respondError(c, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	authHandler := box.NewAuthHandler(c.Request.Context(), box.OAuthConfig{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  req.RedirectURL,
+	})
+
+	tokenResponse, err := authHandler.ExchangeCodeForToken(req.Code)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("Failed to exchange token: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse)
+}
+
+// BoxListFolders lists folders in Box
+func BoxListFolders(c *gin.Context) {
+	var req struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		AccessToken  string `json:"access_token" binding:"required"`
+		RefreshToken string `json:"refresh_token"`
+		ParentID     string `json:"parent_id"` // Empty for root folder
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	clientID, clientSecret, err := boxOAuthCredentials(req.ClientID, req.ClientSecret)
+	if err != nil {
+		respondError(c, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	client, err := box.NewClient(c.Request.Context(), box.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AccessToken:  req.AccessToken,
+		RefreshToken: req.RefreshToken,
+	})
+	if err != nil {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("Failed to create client: %v", err))
+		return
+	}
+
+	folders, err := client.ListFolders(req.ParentID)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("Failed to list folders: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"folders": folders})
+}
+
+// StartBoxMigration starts a Box to S3 migration
+func StartBoxMigration(c *gin.Context) {
+	var req models.BoxMigrationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate required fields
+	if req.SourceCredentials == nil {
+		respondError(c, http.StatusBadRequest, "source_credentials is required")
+		return
+	}
+	if req.DestCredentials == nil {
+		respondError(c, http.StatusBadRequest, "dest_credentials is required")
+		return
+	}
+	if req.DestBucket == "" {
+		respondError(c, http.StatusBadRequest, "dest_bucket is required")
+		return
+	}
+
+	taskID := uuid.New().String()
+
+	timeout := time.Duration(req.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = 24 * time.Hour // Default timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+	taskManager.addTask(&TaskInfo{
+		ID: taskID,
+		Status: &models.MigrationStatus{
+			TaskID:        taskID,
+			Status:        "pending",
+			MigrationType: "box",
+			Progress:      0,
+			StartTime:     time.Now(),
+			DryRun:        req.DryRun,
+		},
+		CancelFn:        cancel,
+		StartTime:       time.Now(),
+		OriginalRequest: models.MigrationRequest{}, // Empty for Box
+	})
+
+	go runBoxMigration(ctx, taskID, req)
+
+	c.JSON(http.StatusOK, gin.H{
+		"task_id": taskID,
+		"message": "Box migration started",
+	})
+}
+
+// failBoxTask marks a Box task as failed with the given error
+func failBoxTask(taskID, message string) {
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
+		task.Status.Status = "failed"
+		task.Status.EndTime = time.Now()
+		task.Status.Errors = append(task.Status.Errors, message)
+	})
+}
+
+// runBoxMigration executes the Box to S3 migration
+func runBoxMigration(ctx context.Context, taskID string, req models.BoxMigrationRequest) {
+	defer func() {
+		if r := recover(); r != nil {
+			failBoxTask(taskID, fmt.Sprintf("Panic: %v", r))
+		}
+	}()
+
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
+		task.Status.Status = "running"
+	})
+
+	boxClient, err := box.NewClient(ctx, box.Config{
+		ClientID:     req.SourceCredentials.ClientID,
+		ClientSecret: req.SourceCredentials.ClientSecret,
+		AccessToken:  req.SourceCredentials.AccessToken,
+		RefreshToken: req.SourceCredentials.RefreshToken,
+	})
+	if err != nil {
+		failBoxTask(taskID, fmt.Sprintf("Failed to create Box client: %v", err))
+		return
+	}
+
+	cp, err := pool.NewConnectionPool(ctx, pool.ConnectionPoolConfig{
+		AccessKey:           req.DestCredentials.AccessKey,
+		SecretKey:           req.DestCredentials.SecretKey,
+		Region:              req.DestCredentials.Region,
+		EndpointURL:         req.DestCredentials.EndpointURL,
+		Timeout:             time.Hour,
+		CredentialsProvider: credentialsProviderFor(req.DestCredentials),
+	})
+	if err != nil {
+		failBoxTask(taskID, fmt.Sprintf("Failed to create connection pool: %v", err))
+		return
+	}
+
+	migrator := box.NewBoxMigrator(ctx, boxClient, cp.GetClient())
+
+	result, err := migrator.Migrate(box.MigrationInput{
+		SourceFolderID:   req.SourceFolderID,
+		DestBucket:       req.DestBucket,
+		DestPrefix:       req.DestPrefix,
+		DryRun:           req.DryRun,
+		Workers:          req.Workers,
+		ChunkSize:        int64(req.ChunkSizeMB) * 1024 * 1024,
+		ProgressCallback: taskManager.progressCallback(taskID),
+	})
+	if err != nil {
+		failBoxTask(taskID, fmt.Sprintf("Migration failed: %v", err))
+		return
+	}
+
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
+		task.Status.Status = "completed"
+		task.Status.TotalObjects = result.TotalFiles
+		task.Status.CopiedObjects = result.CopiedFiles
+		task.Status.TotalSize = result.TotalSize
+		task.Status.CopiedSize = result.CopiedSize
+		task.Status.Errors = append(task.Status.Errors, result.Errors...)
+
+		task.Status.EndTime = time.Now()
+		task.Status.Duration = formatDuration(task.Status.EndTime.Sub(task.Status.StartTime))
+
+		avgSpeed := 0.0
+		if result.Duration > 0 {
+			avgSpeed = float64(result.CopiedSize) / result.Duration.Seconds() / (1024 * 1024)
+		}
+		task.Result = &models.MigrationResult{
+			TaskID:       taskID,
+			Success:      result.FailedFiles == 0,
+			Copied:       result.CopiedFiles,
+			Failed:       result.FailedFiles,
+			TotalSizeMB:  float64(result.TotalSize) / (1024 * 1024),
+			CopiedSizeMB: float64(result.CopiedSize) / (1024 * 1024),
+			ElapsedTime:  result.Duration.String(),
+			AvgSpeedMB:   avgSpeed,
+		}
+	})
+
+	fmt.Printf("Box migration completed. Migrated %d files, %d bytes\n",
+		result.CopiedFiles, result.CopiedSize)
+}
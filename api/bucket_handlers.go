@@ -0,0 +1,554 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/config"
+	"s3migration/pkg/core"
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+)
+
+// Credential headers for read-only bucket endpoints; secrets are kept out of
+// query strings so they do not end up in access logs or browser history
+const (
+	headerAccessKey = "X-Access-Key"
+	headerSecretKey = "X-Secret-Key"
+)
+
+// bucketRequestTimeout bounds how long a bucket inspection request may list for
+const bucketRequestTimeout = 10 * time.Minute
+
+// s3ClientFromRequest builds an S3 client from the X-Access-Key/X-Secret-Key headers
+// and the region/endpoint_url query parameters (defaults to the environment's credentials)
+func s3ClientFromRequest(ctx context.Context, c *gin.Context) (*s3.Client, error) {
+	region := c.Query("region")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	cp, err := pool.NewConnectionPool(ctx, pool.ConnectionPoolConfig{
+		Size:        1,
+		Region:      region,
+		EndpointURL: c.Query("endpoint_url"),
+		AccessKey:   c.GetHeader(headerAccessKey),
+		SecretKey:   c.GetHeader(headerSecretKey),
+		Timeout:     bucketRequestTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cp.GetClient(), nil
+}
+
+// poolConfigForCredentials builds a single-client pool config from a shared-file profile,
+// a provider preset and/or inline credentials. Inline values override preset defaults;
+// a secret reference in creds is resolved first.
+func poolConfigForCredentials(ctx context.Context, profile, provider string, creds *models.Credentials) (pool.ConnectionPoolConfig, error) {
+	cfg := pool.ConnectionPoolConfig{
+		Size:    1,
+		Region:  "us-east-1",
+		Profile: profile,
+		Timeout: bucketRequestTimeout,
+	}
+
+	creds, err := resolveCredentialRef(ctx, creds)
+	if err != nil {
+		return cfg, err
+	}
+
+	var accessKey, secretKey, region, endpointURL string
+	if creds != nil {
+		accessKey, secretKey = creds.AccessKey, creds.SecretKey
+		region, endpointURL = creds.Region, creds.EndpointURL
+	}
+
+	if provider != "" {
+		preset := config.S3Provider(provider)
+		if _, ok := config.ProviderPresets()[preset]; !ok {
+			return cfg, fmt.Errorf("unknown provider %q", provider)
+		}
+		presetCreds := config.NewCredentialsForProvider(preset, accessKey, secretKey, region)
+		region = presetCreds.Region
+		if endpointURL == "" {
+			endpointURL = presetCreds.EndpointURL
+		}
+	}
+
+	if region != "" {
+		cfg.Region = region
+	}
+	cfg.EndpointURL = endpointURL
+	if profile != "" {
+		// The profile supplies the keys; inline credentials only contribute region and endpoint
+		return cfg, nil
+	}
+	cfg.AccessKey = accessKey
+	cfg.SecretKey = secretKey
+	cfg.CredentialsProvider = credentialsProviderFor(creds)
+	return cfg, nil
+}
+
+// GetBucketStats handles GET /api/buckets/stats
+// @Summary Get bucket statistics
+// @Description Stream a listing of a bucket/prefix and return object count, total size, size histogram, storage classes and last-modified distribution
+// @Tags buckets
+// @Produce json
+// @Param bucket query string true "Bucket name"
+// @Param prefix query string false "Key prefix"
+// @Param region query string false "Region (default: us-east-1)"
+// @Param endpoint_url query string false "Custom S3 endpoint"
+// @Param X-Access-Key header string false "Access key"
+// @Param X-Secret-Key header string false "Secret key"
+// @Success 200 {object} core.BucketStats
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/buckets/stats [get]
+func GetBucketStats(c *gin.Context) {
+	bucket := c.Query("bucket")
+	if bucket == "" {
+		respondError(c, http.StatusBadRequest, "bucket is required")
+		return
+	}
+
+	// Stop listing if the client goes away
+	ctx, cancel := context.WithTimeout(c.Request.Context(), bucketRequestTimeout)
+	defer cancel()
+
+	client, err := s3ClientFromRequest(ctx, c)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to create S3 client: "+err.Error())
+		return
+	}
+
+	stats, err := core.CollectBucketStats(ctx, client, bucket, c.Query("prefix"))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// ListBuckets handles POST /api/buckets/list
+// @Summary List buckets
+// @Description List the buckets visible to a credential profile or inline credentials, with regions where available
+// @Tags buckets
+// @Accept json
+// @Produce json
+// @Param request body models.BucketListRequest true "Credentials"
+// @Success 200 {object} gin.H
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/buckets/list [post]
+func ListBuckets(c *gin.Context) {
+	var req models.BucketListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cfg, err := poolConfigForCredentials(c.Request.Context(), req.Profile, req.Provider, req.Credentials)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), bucketRequestTimeout)
+	defer cancel()
+
+	cp, err := pool.NewConnectionPool(ctx, cfg)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to create S3 client: "+err.Error())
+		return
+	}
+
+	buckets, err := core.NewBucketValidator(cp.GetClient()).ListBuckets(ctx, !req.SkipRegion)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"buckets": buckets,
+		"count":   len(buckets),
+	})
+}
+
+// ValidateCredentials handles POST /api/credentials/validate
+// @Summary Validate credentials
+// @Description Check whether a set of credentials is accepted and what it can do (list buckets, read, write, delete, multipart uploads, create buckets). The write checks upload and delete an empty probe object under the prefix and start and abort a multipart upload of it.
+// @Tags buckets
+// @Accept json
+// @Produce json
+// @Param request body models.CredentialValidationRequest true "Credentials, and the bucket to check"
+// @Success 200 {object} core.CapabilityReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/credentials/validate [post]
+func ValidateCredentials(c *gin.Context) {
+	var req models.CredentialValidationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cfg, err := poolConfigForCredentials(c.Request.Context(), req.Profile, req.Provider, req.Credentials)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), bucketRequestTimeout)
+	defer cancel()
+
+	cp, err := pool.NewConnectionPool(ctx, cfg)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to create S3 client: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, core.CheckCapabilities(ctx, cp.GetClient(), req.Bucket, req.Prefix))
+}
+
+// ListObjects handles POST /api/objects/list
+// @Summary Browse objects
+// @Description List one page of a bucket's folders (common prefixes) and objects under a prefix
+// @Tags buckets
+// @Accept json
+// @Produce json
+// @Param request body models.ObjectListRequest true "Bucket, prefix and pagination"
+// @Success 200 {object} core.BrowsePage
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/objects/list [post]
+func ListObjects(c *gin.Context) {
+	var req models.ObjectListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Bucket == "" {
+		respondError(c, http.StatusBadRequest, "bucket is required")
+		return
+	}
+
+	delimiter := "/"
+	if req.Delimiter != nil {
+		delimiter = *req.Delimiter
+	}
+
+	cfg, err := poolConfigForCredentials(c.Request.Context(), req.Profile, req.Provider, req.Credentials)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), bucketRequestTimeout)
+	defer cancel()
+
+	cp, err := pool.NewConnectionPool(ctx, cfg)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to create S3 client: "+err.Error())
+		return
+	}
+
+	page, err := core.BrowseObjects(ctx, cp.GetClient(), req.Bucket, req.Prefix, delimiter, req.ContinuationToken, req.MaxKeys)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// maxDiffKeys caps the keys of one object diff
+const maxDiffKeys = 50
+
+// DiffObjects handles POST /api/objects/diff
+// @Summary Compare objects between source and destination
+// @Description Read the head metadata, stored checksums and tags of a few keys on the source and on the destination and report them side by side with the fields that differ
+// @Tags buckets
+// @Accept json
+// @Produce json
+// @Param request body models.ObjectDiffRequest true "Buckets, keys and credentials"
+// @Success 200 {object} gin.H
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/objects/diff [post]
+func DiffObjects(c *gin.Context) {
+	var req models.ObjectDiffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.SourceBucket == "" || req.DestBucket == "" {
+		respondError(c, http.StatusBadRequest, "source_bucket and dest_bucket are required")
+		return
+	}
+	if len(req.Keys) == 0 || len(req.Keys) > maxDiffKeys {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("between 1 and %d keys are required", maxDiffKeys))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), bucketRequestTimeout)
+	defer cancel()
+
+	sourceCfg, err := poolConfigForCredentials(ctx, "", "", req.SourceCredentials)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	sourcePool, err := pool.NewConnectionPool(ctx, sourceCfg)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to create S3 client: "+err.Error())
+		return
+	}
+	destPool := sourcePool
+	if req.DestCredentials != nil {
+		destCfg, err := poolConfigForCredentials(ctx, "", "", req.DestCredentials)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if destPool, err = pool.NewConnectionPool(ctx, destCfg); err != nil {
+			respondError(c, http.StatusInternalServerError, "Failed to create destination S3 client: "+err.Error())
+			return
+		}
+	}
+
+	diffs := core.DiffObjects(ctx, sourcePool.GetClient(), destPool.GetClient(), req.SourceBucket, req.DestBucket, req.DestPrefix, req.Keys)
+	mismatched := 0
+	for _, diff := range diffs {
+		if !diff.Match {
+			mismatched++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"objects": diffs, "mismatched": mismatched})
+}
+
+// maxDuplicateSources caps the bucket/prefix pairs of one duplicate analysis
+const maxDuplicateSources = 20
+
+// FindDuplicates handles POST /api/analysis/duplicates
+// @Summary Find duplicate objects
+// @Description List one or more bucket/prefixes and report objects with identical content, by size and ETag or by sampled content hashes
+// @Tags buckets
+// @Accept json
+// @Produce json
+// @Param request body models.DuplicateAnalysisRequest true "Sources and credentials"
+// @Success 200 {object} core.DuplicateReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/analysis/duplicates [post]
+func FindDuplicates(c *gin.Context) {
+	var req models.DuplicateAnalysisRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.Sources) == 0 || len(req.Sources) > maxDuplicateSources {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("between 1 and %d sources are required", maxDuplicateSources))
+		return
+	}
+	sources := make([]core.DedupeSource, len(req.Sources))
+	for i, source := range req.Sources {
+		if source.Bucket == "" {
+			respondError(c, http.StatusBadRequest, fmt.Sprintf("sources[%d].bucket is required", i))
+			return
+		}
+		sources[i] = core.DedupeSource{Bucket: source.Bucket, Prefix: source.Prefix}
+	}
+
+	cfg, err := poolConfigForCredentials(c.Request.Context(), req.Profile, req.Provider, req.Credentials)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), bucketRequestTimeout)
+	defer cancel()
+
+	cp, err := pool.NewConnectionPool(ctx, cfg)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to create S3 client: "+err.Error())
+		return
+	}
+
+	report, err := core.FindDuplicates(ctx, cp.GetClient(), sources, req.Method)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// AuditKeys handles POST /api/analysis/keys
+// @Summary Find keys that break on some destinations
+// @Description List a bucket prefix and report keys with trailing spaces, backslashes, more than 1024 bytes, invalid UTF-8 or names differing only in case, with the key_sanitize rules that fix them and the keys those rules would make collide
+// @Tags buckets
+// @Accept json
+// @Produce json
+// @Param request body models.KeyAuditRequest true "Bucket, prefix and credentials"
+// @Success 200 {object} core.KeyAuditReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/analysis/keys [post]
+func AuditKeys(c *gin.Context) {
+	var req models.KeyAuditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Bucket == "" {
+		respondError(c, http.StatusBadRequest, "bucket is required")
+		return
+	}
+
+	cfg, err := poolConfigForCredentials(c.Request.Context(), req.Profile, req.Provider, req.Credentials)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), bucketRequestTimeout)
+	defer cancel()
+
+	cp, err := pool.NewConnectionPool(ctx, cfg)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to create S3 client: "+err.Error())
+		return
+	}
+
+	report, err := core.AuditKeys(ctx, cp.GetClient(), req.Bucket, req.Prefix, req.DestPrefix)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RunBenchmark handles POST /api/benchmark
+// @Summary Benchmark an endpoint
+// @Description Upload, download and delete synthetic objects of the given sizes at several concurrency levels and report throughput, latency and throttling, to calibrate workers and bandwidth before a migration
+// @Tags buckets
+// @Accept json
+// @Produce json
+// @Param request body models.BenchmarkRequest true "Bucket, object sizes, concurrency levels and credentials"
+// @Success 200 {object} core.BenchmarkResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/benchmark [post]
+func RunBenchmark(c *gin.Context) {
+	var req models.BenchmarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	benchCfg := core.BenchmarkConfig{
+		Bucket:         req.Bucket,
+		Prefix:         req.Prefix,
+		ObjectsPerSize: req.ObjectsPerSize,
+		Concurrency:    req.Concurrency,
+	}
+	for _, sizeKB := range req.ObjectSizesKB {
+		benchCfg.ObjectSizes = append(benchCfg.ObjectSizes, sizeKB*1024)
+	}
+
+	cfg, err := poolConfigForCredentials(c.Request.Context(), req.Profile, req.Provider, req.Credentials)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	cfg.MaxRetries = 1 // Throttling must show up in the result, not be retried away
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), bucketRequestTimeout)
+	defer cancel()
+
+	cp, err := pool.NewConnectionPool(ctx, cfg)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to create S3 client: "+err.Error())
+		return
+	}
+
+	result, err := core.RunBenchmark(ctx, cp.GetClient(), benchCfg)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if ctx.Err() == nil {
+			status = http.StatusBadRequest // Rejected configuration
+		}
+		respondError(c, status, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// AnalyzeBucketConfig handles POST /api/analysis/bucket-config
+// @Summary Report bucket settings to re-create
+// @Description Read the policy, CORS and website configuration of a source bucket and report what must be re-created on the destination, with the policy rewritten for the destination bucket and hints on principals, network conditions and IAM policies; with apply, re-create them
+// @Tags buckets
+// @Accept json
+// @Produce json
+// @Param request body models.BucketConfigRequest true "Buckets, credentials and replacements"
+// @Success 200 {object} core.BucketConfigReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/analysis/bucket-config [post]
+func AnalyzeBucketConfig(c *gin.Context) {
+	var req models.BucketConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.SourceBucket == "" || req.DestBucket == "" {
+		respondError(c, http.StatusBadRequest, "source_bucket and dest_bucket are required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), bucketRequestTimeout)
+	defer cancel()
+
+	sourceCfg, err := poolConfigForCredentials(ctx, "", "", req.SourceCredentials)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	sourcePool, err := pool.NewConnectionPool(ctx, sourceCfg)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to create S3 client: "+err.Error())
+		return
+	}
+
+	report, err := core.AnalyzeBucketConfig(ctx, sourcePool.GetClient(), req.SourceBucket, req.DestBucket, req.Replacements)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if req.Apply {
+		destPool := sourcePool
+		if req.DestCredentials != nil {
+			destCfg, err := poolConfigForCredentials(ctx, "", "", req.DestCredentials)
+			if err != nil {
+				respondError(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			if destPool, err = pool.NewConnectionPool(ctx, destCfg); err != nil {
+				respondError(c, http.StatusInternalServerError, "Failed to create destination S3 client: "+err.Error())
+				return
+			}
+		}
+		core.ApplyBucketConfig(ctx, destPool.GetClient(), report)
+	}
+
+	c.JSON(http.StatusOK, report)
+}
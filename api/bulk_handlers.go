@@ -33,12 +33,12 @@ type BulkMigrationRequest struct {
 // @Produce json
 // @Param request body BulkMigrationRequest true "Bulk migration request"
 // @Success 200 {object} gin.H
-// @Failure 400 {object} gin.H
+// @Failure 400 {object} ErrorResponse
 // @Router /api/migrate/bulk [post]
 func StartBulkMigration(c *gin.Context) {
 	var req BulkMigrationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
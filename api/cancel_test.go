@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+	"s3migration/pkg/scheduler"
+)
+
+func TestDrainCancelFinishesInFlightCopies(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	endpoint.Put("source", "logs/a.txt", []byte("alpha"))
+	endpoint.Put("source", "logs/b.txt", []byte("bravo"))
+	endpoint.Put("source", "logs/c.txt", []byte("charlie"))
+	// The first copy is held until the drain has been requested
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	endpoint.BeforeWrite = func(bucket, key string) {
+		once.Do(func() {
+			close(started)
+			<-release
+		})
+	}
+	router := testRouter(t, endpoint)
+	router.DELETE("/api/tasks/:taskID", CancelTask)
+
+	// One copy in flight at a time, so the other objects are still queued
+	resp := serve(router, http.MethodPost, "/api/migrate",
+		`{"source_bucket": "source", "dest_bucket": "dest", "prefix_shards": {"enabled": true, "max_concurrent": 1}}`)
+	var launched struct {
+		TaskID string `json:"task_id"`
+	}
+	json.Unmarshal(resp.Body.Bytes(), &launched)
+	<-started
+
+	if resp := serve(router, http.MethodDelete, "/api/tasks/"+launched.TaskID+"?mode=immediately", ""); resp.Code != http.StatusBadRequest {
+		t.Errorf("unknown mode: status %d", resp.Code)
+	}
+	if resp := serve(router, http.MethodDelete, "/api/tasks/"+launched.TaskID+"?mode=drain", ""); resp.Code != http.StatusOK {
+		t.Fatalf("drain: status %d: %s", resp.Code, resp.Body)
+	}
+	draining := waitForStatus(t, router, launched.TaskID, func(status models.MigrationStatus) bool { return true })
+	if draining.Status != "running" || draining.CancelMode != "drain" {
+		t.Errorf("while draining: %+v", draining)
+	}
+	close(release)
+
+	status := waitForStatus(t, router, launched.TaskID, func(status models.MigrationStatus) bool {
+		return status.Status == "cancelled"
+	})
+	if status.CopiedObjects != 1 || len(status.Errors) != 0 {
+		t.Errorf("status = %+v", status)
+	}
+	if keys := endpoint.Keys("dest"); len(keys) != 1 {
+		t.Errorf("destination keys = %v", keys)
+	}
+}
+
+func TestStopScheduledTask(t *testing.T) {
+	tests := []struct {
+		onTimeout string
+		want      string // Returned by stopScheduledTask
+		mode      string // Cancel mode of the task
+	}{
+		{onTimeout: "", want: "cancelled", mode: "hard"},
+		{onTimeout: scheduler.TimeoutDrain, want: "drained", mode: "drain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			endpoint := fakes3.New("source")
+			defer endpoint.Close()
+			endpoint.Put("source", "a.txt", []byte("alpha"))
+			endpoint.Put("source", "b.txt", []byte("bravo"))
+			started, release := make(chan struct{}), make(chan struct{})
+			var once sync.Once
+			endpoint.BeforeWrite = func(bucket, key string) {
+				once.Do(func() {
+					close(started)
+					<-release
+				})
+			}
+			router := testRouter(t, endpoint)
+			resp := serve(router, http.MethodPost, "/api/migrate",
+				`{"source_bucket": "source", "dest_bucket": "dest", "prefix_shards": {"enabled": true, "max_concurrent": 1}}`)
+			var launched struct {
+				TaskID string `json:"task_id"`
+			}
+			json.Unmarshal(resp.Body.Bytes(), &launched)
+			<-started
+
+			schedule := &scheduler.Schedule{ID: "nightly", MaxDuration: "1h", OnTimeout: tt.onTimeout}
+			if got := stopScheduledTask(schedule, launched.TaskID); got != tt.want {
+				t.Errorf("stopScheduledTask() = %q, want %q", got, tt.want)
+			}
+			close(release)
+			status := waitForStatus(t, router, launched.TaskID, func(status models.MigrationStatus) bool {
+				return status.Status == "cancelled"
+			})
+			if status.CancelMode != tt.mode {
+				t.Errorf("cancel mode = %q, want %q", status.CancelMode, tt.mode)
+			}
+		})
+	}
+}
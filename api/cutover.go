@@ -0,0 +1,288 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/core"
+	"s3migration/pkg/models"
+)
+
+// defaultCutoverDeltaPasses is the number of delta syncs run before waiting for
+// confirmation when the request does not set max_delta_passes
+const defaultCutoverDeltaPasses = 5
+
+// setCutover applies change to a copy of the cutover state of a task; published
+// snapshots keep the state they were taken with
+func setCutover(taskID string, change func(state *models.CutoverState)) {
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
+		var state models.CutoverState
+		if task.Status.Cutover != nil {
+			state = *task.Status.Cutover
+			state.Passes = append([]models.CutoverPass(nil), state.Passes...)
+		}
+		change(&state)
+		task.Status.Cutover = &state
+	})
+}
+
+// runCutover runs a cutover task: a bulk copy, delta syncs until a pass copies at
+// most delta_threshold objects (or max_delta_passes ran), then after confirmation
+// an optional source freeze, a final sync and a dry-run verification. The freeze
+// is kept once the destination is ready and released otherwise.
+func runCutover(ctx context.Context, taskID string, migrator *core.EnhancedMigrator, req models.MigrationRequest, confirm <-chan struct{}) {
+	var freeze *core.SourceFreeze
+	release := func() {
+		if freeze == nil {
+			return
+		}
+		// The task context may be cancelled already
+		err := freeze.Release(context.Background())
+		freeze = nil
+		setCutover(taskID, func(state *models.CutoverState) {
+			state.SourceFrozen = err != nil
+		})
+		if err != nil {
+			fmt.Printf("Cutover %s: %v\n", taskID, err)
+			taskManager.updateTask(taskID, func(task *TaskInfo) {
+				task.Status.Errors = append(task.Status.Errors, err.Error())
+			})
+		}
+	}
+
+	var copied, failed int64
+	var copiedSizeMB float64
+	var failureManifest *models.FailureManifestLink
+	finish := func(status, message string, err error) {
+		if status != "completed" {
+			release()
+		}
+		requests := migrator.RequestCounts() // All passes of the task
+		latency := migrator.Latencies()
+		taskManager.updateTask(taskID, func(task *TaskInfo) {
+			task.Status.Status = status
+			if err != nil {
+				task.Status.Errors = append(task.Status.Errors, err.Error())
+			}
+			task.Status.EndTime = time.Now()
+			task.Status.Phase = ""
+			task.Status.Duration = formatDuration(task.Status.EndTime.Sub(task.Status.StartTime))
+			task.Status.ETA = "0s"
+			task.Status.ETAEstimate = nil
+			task.Result = &models.MigrationResult{
+				TaskID:          taskID,
+				Success:         status == "completed",
+				Copied:          copied,
+				Failed:          failed,
+				CopiedSizeMB:    copiedSizeMB,
+				ElapsedTime:     task.Status.Duration,
+				Errors:          task.Status.Errors,
+				FailureManifest: failureManifest,
+				Requests:        &requests,
+				Latency:         latency,
+			}
+		})
+		if message != "" {
+			setCutover(taskID, func(state *models.CutoverState) {
+				state.Message = message
+			})
+		}
+		notifyTaskWebhook(taskID, &req)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Panic in cutover %s: %v\n", taskID, r)
+			finish("failed", "", fmt.Errorf("Panic: %v", r))
+		}
+	}()
+
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
+		task.Status.Status = "running"
+		task.Status.Cutover = &models.CutoverState{Phase: models.CutoverBulkCopy, Passes: []models.CutoverPass{}}
+	})
+
+	// Handle backward compatibility: if Credentials is provided, use it as SourceCredentials
+	if req.Credentials != nil && req.SourceCredentials == nil {
+		req.SourceCredentials = req.Credentials
+	}
+	maxPasses := req.Cutover.MaxDeltaPasses
+	if maxPasses <= 0 {
+		maxPasses = defaultCutoverDeltaPasses
+	}
+
+	// pass runs one copy pass; a nil result means the task was cancelled
+	pass := func(phase string, mode core.MigrationMode) (*core.MigrateResult, error) {
+		setCutover(taskID, func(state *models.CutoverState) {
+			state.Phase = phase
+		})
+		input := s3MigrateInput(taskID, req)
+		if mode != "" {
+			input.MigrationMode = mode
+		}
+		if phase != models.CutoverFinalSync {
+			input.FailureManifest = nil // Only the final sync leaves failures to re-drive
+		}
+		started := time.Now()
+		result, err := migrator.Migrate(ctx, input)
+		if err != nil || result.Cancelled {
+			return nil, err
+		}
+		failureManifest = result.FailureManifest
+		copied += result.Copied
+		copiedSizeMB += result.CopiedSizeMB
+		failed = result.Failed // Failed objects are retried by the next pass
+		setCutover(taskID, func(state *models.CutoverState) {
+			state.Passes = append(state.Passes, models.CutoverPass{
+				Phase:        phase,
+				Copied:       result.Copied,
+				Failed:       result.Failed,
+				CopiedSizeMB: result.CopiedSizeMB,
+				Started:      started,
+				Duration:     formatDuration(time.Since(started)),
+			})
+		})
+		return result, nil
+	}
+
+	result, err := pass(models.CutoverBulkCopy, "")
+	for delta := 0; err == nil && result != nil && delta < maxPasses; delta++ {
+		result, err = pass(models.CutoverDeltaSync, core.ModeIncremental)
+		if result != nil && result.Copied+result.Failed <= req.Cutover.DeltaThreshold {
+			break
+		}
+	}
+	if err != nil {
+		finish("failed", "", err)
+		return
+	}
+	if result == nil {
+		finish("cancelled", "", nil)
+		return
+	}
+
+	setCutover(taskID, func(state *models.CutoverState) {
+		state.Phase = models.CutoverAwaitingConfirmation
+		state.Message = fmt.Sprintf("Last delta sync copied %d objects (%d failed); confirm with POST /api/tasks/%s/cutover/confirm to run the final sync", result.Copied, result.Failed, taskID)
+	})
+	select {
+	case <-confirm:
+	case <-ctx.Done():
+		finish("cancelled", "", nil)
+		return
+	}
+	setCutover(taskID, func(state *models.CutoverState) {
+		state.Message = ""
+	})
+
+	if req.Cutover.FreezeSource {
+		setCutover(taskID, func(state *models.CutoverState) {
+			state.Phase = models.CutoverFreezing
+		})
+		freeze, err = migrator.FreezeSource(ctx, req.SourceBucket)
+		if err != nil {
+			finish("failed", "", err)
+			return
+		}
+		setCutover(taskID, func(state *models.CutoverState) {
+			state.SourceFrozen = true
+		})
+	}
+
+	final, err := pass(models.CutoverFinalSync, core.ModeIncremental)
+	if err != nil {
+		finish("failed", "", err)
+		return
+	}
+	if final == nil {
+		finish("cancelled", "", nil)
+		return
+	}
+
+	// A dry run against the destination lists what the final sync left behind
+	setCutover(taskID, func(state *models.CutoverState) {
+		state.Phase = models.CutoverVerifying
+	})
+	input := s3MigrateInput(taskID, req)
+	input.MigrationMode = core.ModeIncremental
+	input.DryRun = true
+	input.DryRunDiff = true
+	verify, err := migrator.Migrate(ctx, input)
+	if err != nil {
+		finish("failed", "", fmt.Errorf("verification failed: %w", err))
+		return
+	}
+	if verify.Cancelled {
+		finish("cancelled", "", nil)
+		return
+	}
+	remaining := 0
+	if verify.DryRunDiff != nil {
+		remaining = int(verify.DryRunDiff.Creates + verify.DryRunDiff.Overwrites)
+	}
+
+	ready := remaining == 0 && final.Failed == 0
+	setCutover(taskID, func(state *models.CutoverState) {
+		state.Remaining = remaining
+		state.Ready = ready
+		state.Phase = models.CutoverReady
+		if !ready {
+			state.Phase = models.CutoverNotReady
+		}
+	})
+	if !ready {
+		finish("completed_with_errors", fmt.Sprintf("Final sync failed %d objects and %d still differ; the source was not left frozen", final.Failed, remaining), nil)
+		return
+	}
+	message := "Destination matches the source; switch clients over"
+	if freeze != nil {
+		message += fmt.Sprintf(" (the source stays read-only until statement %s is removed from its bucket policy)", core.FreezeStatementID)
+	}
+	finish("completed", message, nil)
+}
+
+// ConfirmCutover handles POST /api/tasks/:taskId/cutover/confirm
+// @Summary Confirm the final phase of a cutover
+// @Description Let a cutover waiting after its delta syncs freeze the source (when requested), run the final sync and verify the destination
+// @Tags migration
+// @Produce json
+// @Param taskId path string true "Task ID"
+// @Success 200 {object} gin.H
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/tasks/{taskId}/cutover/confirm [post]
+func ConfirmCutover(c *gin.Context) {
+	taskID := c.Param("taskId")
+
+	phase := ""
+	confirmed := false
+	exists := taskManager.updateTask(taskID, func(task *TaskInfo) {
+		if task.Status.Cutover == nil {
+			return
+		}
+		phase = task.Status.Cutover.Phase
+		if phase != models.CutoverAwaitingConfirmation || task.cutoverConfirm == nil {
+			return
+		}
+		task.cutoverConfirm <- struct{}{} // Buffered; received by runCutover
+		task.cutoverConfirm = nil         // Confirmed once
+		confirmed = true
+	})
+	if !exists {
+		respondError(c, http.StatusNotFound, "task not found")
+		return
+	}
+	if phase == "" {
+		respondError(c, http.StatusConflict, "task is not a cutover")
+		return
+	}
+	if !confirmed {
+		respondError(c, http.StatusConflict, fmt.Sprintf("cutover is not awaiting confirmation (phase: %s)", phase))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "confirmed", "message": "Final sync started"})
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/core"
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+)
+
+// waitForStatus polls the status of a task until done reports true
+func waitForStatus(t *testing.T, router *gin.Engine, taskID string, done func(status models.MigrationStatus) bool) models.MigrationStatus {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var status models.MigrationStatus
+		resp := serve(router, http.MethodGet, "/api/status/"+taskID, "")
+		json.Unmarshal(resp.Body.Bytes(), &status)
+		if done(status) {
+			return status
+		}
+		if time.Now().After(deadline) || status.Status == "failed" {
+			t.Fatalf("task did not reach the expected state: %+v", status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestCutoverFreezesAndVerifiesAfterConfirmation(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	endpoint.Put("source", "a.txt", []byte("alpha"))
+	endpoint.Put("source", "b.txt", []byte("bravo"))
+	router := testRouter(t, endpoint)
+	router.POST("/api/tasks/:taskId/cutover/confirm", ConfirmCutover)
+
+	resp := serve(router, http.MethodPost, "/api/migrate",
+		`{"source_bucket": "source", "dest_bucket": "dest", "cutover": {"enabled": true, "max_delta_passes": 3, "freeze_source": true}}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("POST /api/migrate = %d: %s", resp.Code, resp.Body)
+	}
+	var started models.MigrationStatus
+	json.Unmarshal(resp.Body.Bytes(), &started)
+
+	status := waitForStatus(t, router, started.TaskID, func(status models.MigrationStatus) bool {
+		return status.Cutover != nil && status.Cutover.Phase == models.CutoverAwaitingConfirmation
+	})
+	if passes := status.Cutover.Passes; len(passes) != 2 || passes[0].Copied != 2 || passes[1].Phase != models.CutoverDeltaSync || passes[1].Copied != 0 {
+		t.Fatalf("passes before confirmation = %+v", passes)
+	}
+	if endpoint.BucketConfig("source", "policy") != nil {
+		t.Fatal("source frozen before confirmation")
+	}
+
+	// Written while waiting; picked up by the final sync
+	endpoint.Put("source", "c.txt", []byte("charlie"))
+	if resp := serve(router, http.MethodPost, "/api/tasks/"+started.TaskID+"/cutover/confirm", ""); resp.Code != http.StatusOK {
+		t.Fatalf("confirm = %d: %s", resp.Code, resp.Body)
+	}
+	if resp := serve(router, http.MethodPost, "/api/tasks/"+started.TaskID+"/cutover/confirm", ""); resp.Code != http.StatusConflict {
+		t.Fatalf("second confirm = %d, want 409", resp.Code)
+	}
+
+	status = waitForStatus(t, router, started.TaskID, func(status models.MigrationStatus) bool {
+		return status.Status == "completed" || status.Status == "completed_with_errors"
+	})
+	cutover := status.Cutover
+	if !cutover.Ready || cutover.Phase != models.CutoverReady || cutover.Remaining != 0 || !cutover.SourceFrozen {
+		t.Fatalf("cutover = %+v", cutover)
+	}
+	if final := cutover.Passes[len(cutover.Passes)-1]; final.Phase != models.CutoverFinalSync || final.Copied != 1 {
+		t.Fatalf("final pass = %+v", final)
+	}
+	if keys := endpoint.Keys("dest"); len(keys) != 3 {
+		t.Fatalf("dest keys = %v", keys)
+	}
+	if policy := string(endpoint.BucketConfig("source", "policy")); !strings.Contains(policy, core.FreezeStatementID) {
+		t.Fatalf("source policy = %q, want the freeze statement", policy)
+	}
+}
+
+func TestConfirmCutoverRejectsOtherTasks(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	router := testRouter(t, endpoint)
+	router.POST("/api/tasks/:taskId/cutover/confirm", ConfirmCutover)
+
+	if resp := serve(router, http.MethodPost, "/api/tasks/missing/cutover/confirm", ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("unknown task = %d, want 404", resp.Code)
+	}
+	taskManager.addTask(&TaskInfo{ID: "plain", Status: &models.MigrationStatus{TaskID: "plain", Status: "running"}})
+	if resp := serve(router, http.MethodPost, "/api/tasks/plain/cutover/confirm", ""); resp.Code != http.StatusConflict {
+		t.Fatalf("task without cutover = %d, want 409", resp.Code)
+	}
+}
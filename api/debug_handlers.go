@@ -7,13 +7,15 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gin-gonic/gin"
 	"s3migration/pkg/core"
+	"s3migration/pkg/logging"
+	"s3migration/pkg/models"
 )
 
 // TestConnectionRequest represents the test connection request
 type TestConnectionRequest struct {
-	AccessKey  string `json:"access_key"`
-	SecretKey  string `json:"secret_key"`
-	Region     string `json:"region"`
+	AccessKey   string `json:"access_key"`
+	SecretKey   string `json:"secret_key"`
+	Region      string `json:"region"`
 	EndpointURL string `json:"endpoint_url"`
 }
 
@@ -25,19 +27,15 @@ type TestConnectionRequest struct {
 // @Produce json
 // @Param request body TestConnectionRequest true "Connection test parameters"
 // @Success 200 {object} gin.H
-// @Failure 400 {object} gin.H
-// @Failure 500 {object} gin.H
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /api/test-connection [post]
 func TestConnection(c *gin.Context) {
 	ctx := context.Background()
 
 	var req TestConnectionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "Invalid request format",
-			"error":   err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, "Invalid request format: "+err.Error())
 		return
 	}
 
@@ -59,15 +57,11 @@ func TestConnection(c *gin.Context) {
 		AccessKey:          req.AccessKey,
 		SecretKey:          req.SecretKey,
 	}
-	
+
 	enhancedMigrator, err := core.NewEnhancedMigrator(ctx, cfg)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Failed to create S3 client",
-			"error":   err.Error(),
-			"hint":    "Check your credentials, region, and endpoint URL",
-		})
+		respondErrorCode(c, http.StatusInternalServerError, codeInternal, "Failed to create S3 client: "+err.Error(),
+			gin.H{"hint": "Check your credentials, region, and endpoint URL"})
 		return
 	}
 	client = enhancedMigrator.GetClient()
@@ -75,12 +69,8 @@ func TestConnection(c *gin.Context) {
 	// Test connection by listing buckets
 	_, err = client.ListBuckets(ctx, &s3.ListBucketsInput{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Failed to list buckets",
-			"error":   err.Error(),
-			"hint":    "Check your credentials, region, and endpoint URL",
-		})
+		respondErrorCode(c, http.StatusInternalServerError, codeInternal, "Failed to list buckets: "+err.Error(),
+			gin.H{"hint": "Check your credentials, region, and endpoint URL"})
 		return
 	}
 
@@ -123,19 +113,15 @@ type TestBucketListingRequest struct {
 // @Produce json
 // @Param request body TestBucketListingRequest true "Bucket listing test parameters"
 // @Success 200 {object} gin.H
-// @Failure 400 {object} gin.H
-// @Failure 500 {object} gin.H
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /api/test-bucket-listing [post]
 func TestBucketListing(c *gin.Context) {
 	ctx := context.Background()
 
 	var req TestBucketListingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "Invalid request format",
-			"error":   err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, "Invalid request format: "+err.Error())
 		return
 	}
 
@@ -157,17 +143,13 @@ func TestBucketListing(c *gin.Context) {
 		AccessKey:          req.AccessKey,
 		SecretKey:          req.SecretKey,
 	}
-	
+
 	enhancedMigrator, err := core.NewEnhancedMigrator(ctx, cfg)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Failed to create S3 client",
-			"error":   err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, "Failed to create S3 client: "+err.Error())
 		return
 	}
-	
+
 	// Test bucket listing
 	client = enhancedMigrator.GetClient()
 	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
@@ -183,12 +165,7 @@ func TestBucketListing(c *gin.Context) {
 		pageCount++
 		page, err := paginator.NextPage(ctx)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"message": "Failed to list objects",
-				"error":   err.Error(),
-				"page":    pageCount,
-			})
+			respondErrorCode(c, http.StatusInternalServerError, codeInternal, "Failed to list objects: "+err.Error(), gin.H{"page": pageCount})
 			return
 		}
 
@@ -227,42 +204,103 @@ func min(a, b int) int {
 // @Produce json
 // @Param taskID path string true "Task ID"
 // @Success 200 {object} gin.H
-// @Failure 404 {object} gin.H
+// @Failure 404 {object} ErrorResponse
 // @Router /api/debug/task/{taskID}/errors [get]
 func GetTaskErrors(c *gin.Context) {
 	taskID := c.Param("taskID")
 
 	taskManager.mu.RLock()
 	task, exists := taskManager.tasks[taskID]
+	var result *models.MigrationResult
+	if exists {
+		result = task.Result
+	}
 	taskManager.mu.RUnlock()
 
 	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		respondError(c, http.StatusNotFound, "task not found")
 		return
 	}
 
+	status := task.status()
 	response := gin.H{
-		"task_id":   taskID,
-		"status":    task.Status.Status,
-		"errors":    task.Status.Errors,
-		"start_time": task.Status.StartTime,
-		"end_time":   task.Status.LastUpdateTime,
+		"task_id":    taskID,
+		"status":     status.Status,
+		"errors":     status.Errors,
+		"start_time": status.StartTime,
+		"end_time":   status.LastUpdateTime,
 	}
 
 	// Add result details if available
-	if task.Result != nil {
+	if result != nil {
 		response["result"] = gin.H{
-			"success":       task.Result.Success,
-			"copied":        task.Result.Copied,
-			"failed":        task.Result.Failed,
-			"total_size_mb": task.Result.TotalSizeMB,
-			"copied_size_mb": task.Result.CopiedSizeMB,
-			"elapsed_time":  task.Result.ElapsedTime,
-			"avg_speed_mb":  task.Result.AvgSpeedMB,
-			"errors":        task.Result.Errors,
+			"success":        result.Success,
+			"copied":         result.Copied,
+			"failed":         result.Failed,
+			"total_size_mb":  result.TotalSizeMB,
+			"copied_size_mb": result.CopiedSizeMB,
+			"elapsed_time":   result.ElapsedTime,
+			"avg_speed_mb":   result.AvgSpeedMB,
+			"errors":         result.Errors,
 		}
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// LoggingSettings represents the global logging configuration
+type LoggingSettings struct {
+	Level           string `json:"level"`             // error, info or debug
+	DebugSampleRate int64  `json:"debug_sample_rate"` // Log per-object debug details for 1 in N objects
+}
+
+// GetLoggingSettings handles GET /api/settings/logging
+// @Summary Get global logging settings
+// @Description Get the default log level and per-object debug sampling rate for new tasks
+// @Tags debug
+// @Produce json
+// @Success 200 {object} LoggingSettings
+// @Router /api/settings/logging [get]
+func GetLoggingSettings(c *gin.Context) {
+	logger := logging.Default()
+	c.JSON(http.StatusOK, LoggingSettings{
+		Level:           logger.Level().String(),
+		DebugSampleRate: logger.SampleRate(),
+	})
+}
+
+// UpdateLoggingSettings handles PUT /api/settings/logging
+// @Summary Update global logging settings
+// @Description Change the default log level and debug sampling rate; running tasks keep their own settings
+// @Tags debug
+// @Accept json
+// @Produce json
+// @Param request body LoggingSettings true "Logging settings"
+// @Success 200 {object} LoggingSettings
+// @Failure 400 {object} ErrorResponse
+// @Router /api/settings/logging [put]
+func UpdateLoggingSettings(c *gin.Context) {
+	var req LoggingSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logger := logging.Default()
+	if req.Level != "" {
+		level, err := logging.ParseLevel(req.Level)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.SetLevel(level)
+	}
+	if req.DebugSampleRate > 0 {
+		logger.SetSampleRate(req.DebugSampleRate)
+	}
+
+	c.JSON(http.StatusOK, LoggingSettings{
+		Level:           logger.Level().String(),
+		DebugSampleRate: logger.SampleRate(),
+	})
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/core"
+)
+
+// GetDryRunDiff handles GET /api/tasks/:taskId/dry-run-diff
+// @Summary Get dry-run diff
+// @Description Get the keys a dry run found would be created, overwritten or skipped
+// @Tags migration
+// @Produce json
+// @Param taskId path string true "Task ID"
+// @Param action query string false "Filter by action (create, overwrite, skip)"
+// @Param offset query int false "Entries to skip (default: 0)"
+// @Param limit query int false "Entries to return (default: 100, max: 1000)"
+// @Success 200 {object} gin.H
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/tasks/{taskId}/dry-run-diff [get]
+func GetDryRunDiff(c *gin.Context) {
+	taskID := c.Param("taskId")
+
+	action := core.DiffAction(c.Query("action"))
+	switch action {
+	case "", core.DiffCreate, core.DiffOverwrite, core.DiffSkip:
+	default:
+		respondError(c, http.StatusBadRequest, "action must be create, overwrite or skip")
+		return
+	}
+
+	offset := 0
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		fmt.Sscanf(offsetStr, "%d", &offset)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	limit := 100
+	if limitStr := c.Query("limit"); limitStr != "" {
+		fmt.Sscanf(limitStr, "%d", &limit)
+	}
+	if limit <= 0 || limit > 1000 {
+		limit = 1000
+	}
+
+	taskManager.mu.RLock()
+	task, exists := taskManager.tasks[taskID]
+	var diff *core.DiffSummary
+	if exists {
+		diff = task.DryRunDiff
+	}
+	taskManager.mu.RUnlock()
+
+	if !exists {
+		respondError(c, http.StatusNotFound, "Task not found")
+		return
+	}
+	if diff == nil {
+		respondError(c, http.StatusNotFound, "No dry-run diff for this task (start a dry run with dry_run_diff: true)")
+		return
+	}
+
+	// The summary is immutable once stored, so entries can be read without the lock
+	entries := diff.Entries
+	if action != "" {
+		filtered := make([]core.DiffEntry, 0)
+		for _, entry := range entries {
+			if entry.Action == action {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+	}
+
+	total := len(entries)
+	start := offset
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"task_id": taskID,
+		"summary": diff,
+		"action":  action,
+		"offset":  start,
+		"limit":   limit,
+		"total":   total,
+		"entries": entries[start:end],
+	})
+}
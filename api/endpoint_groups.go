@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/core"
+	"s3migration/pkg/models"
+)
+
+// endpointGroup returns the shared request budget of a destination endpoint,
+// creating it on first use. Sized by ENDPOINT_MAX_CONCURRENCY (object copies in
+// flight across all tasks, default 64) and ENDPOINT_MAX_RPS (copies started per
+// second, default 0 = unlimited).
+func (tm *TaskManager) endpointGroup(endpointURL, region string) *core.EndpointGroup {
+	key := core.EndpointGroupKey(endpointURL, region)
+
+	tm.groupsMu.Lock()
+	defer tm.groupsMu.Unlock()
+
+	if tm.endpointGroups == nil {
+		tm.endpointGroups = make(map[string]*core.EndpointGroup)
+	}
+	group, ok := tm.endpointGroups[key]
+	if !ok {
+		group = core.NewEndpointGroup(key,
+			int(envFloat("ENDPOINT_MAX_CONCURRENCY", 64)),
+			envFloat("ENDPOINT_MAX_RPS", 0))
+		tm.endpointGroups[key] = group
+	}
+	return group
+}
+
+// destEndpointGroup returns the budget of the endpoint a migration request writes to
+func destEndpointGroup(req *models.MigrationRequest) *core.EndpointGroup {
+	creds := req.DestCredentials
+	if creds == nil {
+		creds = req.SourceCredentials
+	}
+	if creds == nil {
+		return taskManager.endpointGroup("", "")
+	}
+	return taskManager.endpointGroup(creds.EndpointURL, creds.Region)
+}
+
+// ListEndpointGroups handles GET /api/admin/endpoints
+// @Summary List destination endpoint budgets
+// @Description Show the request budget each destination endpoint shares across tasks: running tasks, copies in flight and queued, and limits
+// @Tags admin
+// @Produce json
+// @Success 200 {object} gin.H
+// @Router /api/admin/endpoints [get]
+func ListEndpointGroups(c *gin.Context) {
+	taskManager.groupsMu.Lock()
+	groups := make([]core.EndpointGroupStats, 0, len(taskManager.endpointGroups))
+	for _, group := range taskManager.endpointGroups {
+		groups = append(groups, group.Stats())
+	}
+	taskManager.groupsMu.Unlock()
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Endpoint < groups[j].Endpoint })
+	c.JSON(http.StatusOK, gin.H{"endpoints": groups, "count": len(groups)})
+}
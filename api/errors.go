@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes of API error responses. Clients branch on the code; the message
+// is for people and may change. GET /api/errors lists them.
+const (
+	codeInvalidRequest       = "invalid_request"
+	codeValidationFailed     = "validation_failed"
+	codeUnauthorized         = "unauthorized"
+	codeForbidden            = "forbidden"
+	codeNotFound             = "not_found"
+	codeConflict             = "conflict"
+	codeConfirmationRequired = "confirmation_required"
+	codeDuplicateTask        = "duplicate_task"
+	codePayloadTooLarge      = "payload_too_large"
+	codeRateLimited          = "rate_limited"
+	codeInternal             = "internal_error"
+	codeNotConfigured        = "not_configured"
+	codeUnavailable          = "unavailable"
+)
+
+// ErrorCode is an entry of the error code registry
+type ErrorCode struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`    // HTTP status the code comes with
+	Retryable   bool   `json:"retryable"` // The same request may succeed later
+	Description string `json:"description"`
+}
+
+// errorCodes is the error code registry
+var errorCodes = []ErrorCode{
+	{codeInvalidRequest, http.StatusBadRequest, false, "The request is malformed or asks for something the server cannot do; the message says what"},
+	{codeValidationFailed, http.StatusBadRequest, false, "Fields of the request are invalid; details.errors lists them by field"},
+	{codeUnauthorized, http.StatusUnauthorized, false, "An API key or a valid share token is required"},
+	{codeForbidden, http.StatusForbidden, false, "The credentials do not grant this request, e.g. a share token used outside its task"},
+	{codeNotFound, http.StatusNotFound, false, "The task, schedule or other resource does not exist"},
+	{codeConflict, http.StatusConflict, false, "The resource is not in a state that allows the request, e.g. cancelling a finished task; details may give the state"},
+	{codeConfirmationRequired, http.StatusConflict, false, "The migration exceeds LARGE_MIGRATION_MAX_GB or LARGE_MIGRATION_MAX_COST; details.estimate has the estimate, resend with confirm_large_migration=true"},
+	{codeDuplicateTask, http.StatusConflict, false, "An active task copies the same route (DUPLICATE_TASK_POLICY=reject); details.active_task_id names it, resend with force=true"},
+	{codePayloadTooLarge, http.StatusRequestEntityTooLarge, false, "The request body exceeds the server's limit"},
+	{codeRateLimited, http.StatusTooManyRequests, true, "Too many requests from this client; retry after the Retry-After header"},
+	{codeInternal, http.StatusInternalServerError, true, "The server or a storage provider failed; the message has the cause"},
+	{codeNotConfigured, http.StatusServiceUnavailable, false, "A feature the request needs is not configured on the server, e.g. Google OAuth client credentials"},
+	{codeUnavailable, http.StatusServiceUnavailable, true, "The server is busy or a dependency is unreachable; retry later"},
+}
+
+// defaultErrorCodes is the code of an error response by HTTP status, when the handler gives none
+var defaultErrorCodes = map[int]string{
+	http.StatusBadRequest:            codeInvalidRequest,
+	http.StatusUnauthorized:          codeUnauthorized,
+	http.StatusForbidden:             codeForbidden,
+	http.StatusNotFound:              codeNotFound,
+	http.StatusConflict:              codeConflict,
+	http.StatusRequestEntityTooLarge: codePayloadTooLarge,
+	http.StatusTooManyRequests:       codeRateLimited,
+	http.StatusInternalServerError:   codeInternal,
+	http.StatusServiceUnavailable:    codeUnavailable,
+}
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   gin.H  `json:"details,omitempty"`
+	Retryable bool   `json:"retryable"`
+	Error     string `json:"error"` // The message again, for clients of the older {"error": "..."} body
+}
+
+// respondError writes an error response with the default code of status
+func respondError(c *gin.Context, status int, message string) {
+	respondErrorCode(c, status, defaultErrorCodes[status], message, nil)
+}
+
+// respondErrorCode writes an error response with a code from the registry and
+// optional details
+func respondErrorCode(c *gin.Context, status int, code, message string, details gin.H) {
+	if code == "" {
+		code = codeInternal
+	}
+	retryable := false
+	for _, entry := range errorCodes {
+		if entry.Code == code {
+			retryable = entry.Retryable
+			break
+		}
+	}
+	c.JSON(status, ErrorResponse{Code: code, Message: message, Details: details, Retryable: retryable, Error: message})
+}
+
+// abortWithError stops the handler chain with an error response
+func abortWithError(c *gin.Context, status int, message string) {
+	c.Abort()
+	respondError(c, status, message)
+}
+
+// ListErrorCodes handles GET /api/errors
+// @Summary List error codes
+// @Description The registry of the codes in error responses, with the HTTP status each comes with and whether the same request may succeed later
+// @Tags system
+// @Produce json
+// @Success 200 {array} ErrorCode
+// @Router /api/errors [get]
+func ListErrorCodes(c *gin.Context) {
+	c.JSON(http.StatusOK, errorCodes)
+}
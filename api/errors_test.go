@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestDefaultErrorCodesAreRegistered(t *testing.T) {
+	registered := map[string]int{}
+	for _, entry := range errorCodes {
+		if _, ok := registered[entry.Code]; ok {
+			t.Errorf("code %s is registered twice", entry.Code)
+		}
+		registered[entry.Code] = entry.Status
+	}
+	for status, code := range defaultErrorCodes {
+		if got, ok := registered[code]; !ok || got != status {
+			t.Errorf("default code %s of %d is registered with status %d", code, status, got)
+		}
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	router := testRouter(t, nil)
+	tests := []struct {
+		name          string
+		method, path  string
+		body          string
+		wantStatus    int
+		wantCode      string
+		wantDetail    string
+		wantRetryable bool
+	}{
+		{name: "unknown task", method: http.MethodGet, path: "/api/status/missing", wantStatus: http.StatusNotFound, wantCode: codeNotFound},
+		{name: "invalid fields", method: http.MethodPost, path: "/api/migrate", body: `{"source_bucket": "Source!", "dest_bucket": "dest"}`,
+			wantStatus: http.StatusBadRequest, wantCode: codeValidationFailed, wantDetail: "errors"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := serve(router, tt.method, tt.path, tt.body)
+			var body ErrorResponse
+			if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Code != tt.wantStatus || body.Code != tt.wantCode || body.Retryable != tt.wantRetryable {
+				t.Fatalf("%s %s = %d: %s", tt.method, tt.path, resp.Code, resp.Body)
+			}
+			if body.Message == "" || body.Error != body.Message {
+				t.Errorf("message %q, error %q", body.Message, body.Error)
+			}
+			if _, ok := body.Details[tt.wantDetail]; tt.wantDetail != "" && !ok {
+				t.Errorf("details %v have no %s", body.Details, tt.wantDetail)
+			}
+		})
+	}
+}
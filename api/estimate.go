@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/core"
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+	"s3migration/pkg/validation"
+)
+
+// Default estimate rates: AWS data transfer out to the internet and S3 Standard
+// PUT/COPY requests, the worst case for a migration between providers
+const (
+	defaultTransferCostPerGB  = 0.09
+	defaultRequestCostPer1000 = 0.005
+	defaultEstimateCurrency   = "USD"
+)
+
+// migrationGuardrail holds S3 migrations above a size or cost until the start
+// request confirms them; zero limits are not checked
+type migrationGuardrail struct {
+	maxGB   float64
+	maxCost float64
+	rates   core.CostRates
+}
+
+// guardrailConfig reads LARGE_MIGRATION_MAX_GB, LARGE_MIGRATION_MAX_COST and the
+// estimate rates ESTIMATE_TRANSFER_COST_PER_GB and ESTIMATE_REQUEST_COST_PER_1000
+func guardrailConfig() migrationGuardrail {
+	return migrationGuardrail{
+		maxGB:   envFloat("LARGE_MIGRATION_MAX_GB", 0),
+		maxCost: envFloat("LARGE_MIGRATION_MAX_COST", 0),
+		rates: core.CostRates{
+			TransferPerGB:   envFloat("ESTIMATE_TRANSFER_COST_PER_GB", defaultTransferCostPerGB),
+			RequestsPer1000: envFloat("ESTIMATE_REQUEST_COST_PER_1000", defaultRequestCostPer1000),
+			Currency:        defaultEstimateCurrency,
+		},
+	}
+}
+
+// enabled reports whether a limit is set
+func (g migrationGuardrail) enabled() bool {
+	return g.maxGB > 0 || g.maxCost > 0
+}
+
+// check records in estimate the limits it exceeds
+func (g migrationGuardrail) check(estimate *models.MigrationEstimate) {
+	if estimate.SourceBucket == "" && g.enabled() {
+		estimate.Exceeds = append(estimate.Exceeds, "all buckets are migrated, so the size is not known up front")
+	}
+	if g.maxGB > 0 && float64(estimate.TotalBytes)/(1<<30) > g.maxGB {
+		estimate.Exceeds = append(estimate.Exceeds, fmt.Sprintf("%.2f GB is above LARGE_MIGRATION_MAX_GB (%g)", estimate.TotalSizeGB, g.maxGB))
+	}
+	if g.maxCost > 0 && estimate.EstimatedCost > g.maxCost {
+		estimate.Exceeds = append(estimate.Exceeds, fmt.Sprintf("%.2f %s is above LARGE_MIGRATION_MAX_COST (%g)", estimate.EstimatedCost, estimate.Currency, g.maxCost))
+	}
+	estimate.NeedsConfirmation = len(estimate.Exceeds) > 0
+}
+
+// estimateRequest lists the source of a validated request whose secret references
+// are resolved (or reads its source inventory) and prices it; all-buckets
+// migrations are not listed
+func (g migrationGuardrail) estimateRequest(ctx context.Context, req models.MigrationRequest) (*models.MigrationEstimate, error) {
+	estimate := core.PriceMigration("", "", 0, 0, g.rates)
+	if req.SourceInventory != nil {
+		source, err := core.SourceInventoryFor(req.SourceInventory)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := source.Entries(ctx, req.SourcePrefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate the migration: %w", err)
+		}
+		var size int64
+		for _, entry := range entries {
+			size += entry.Size
+		}
+		estimate = core.PriceMigration(req.SourceBucket, req.SourcePrefix, int64(len(entries)), size, g.rates)
+	} else if req.SourceBucket != "" {
+		migrator, err := newRequestMigrator(ctx, "", &req)
+		if err != nil {
+			return nil, err
+		}
+		defer migrator.Close()
+		estimate, err = core.EstimateMigration(ctx, migrator.GetClient(), req.SourceBucket, req.SourcePrefix, g.rates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate the migration: %w", err)
+		}
+	}
+	if req.SourceBucket != "" {
+		link, err := core.NetworkLinkFor(req.Network)
+		if err != nil {
+			return nil, err
+		}
+		core.EstimateDuration(estimate, link)
+	}
+	g.check(estimate)
+	return estimate, nil
+}
+
+// measureLink benchmarks the destination bucket of a request (the source bucket
+// name when dest_bucket is empty) with its destination credentials, and
+// estimates the duration over the measured link
+func measureLink(ctx context.Context, req models.MigrationRequest, estimate *models.MigrationEstimate) error {
+	creds := req.DestCredentials
+	if creds == nil {
+		creds = req.SourceCredentials
+	}
+	if creds == nil {
+		creds = req.Credentials
+	}
+	bucket := req.DestBucket
+	if bucket == "" {
+		bucket = req.SourceBucket
+	}
+	cfg, err := poolConfigForCredentials(ctx, "", "", creds)
+	if err != nil {
+		return err
+	}
+	cp, err := pool.NewConnectionPool(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create S3 client: %w", err)
+	}
+	defer cp.Close()
+
+	link, err := core.NetworkLinkFor(req.Network)
+	if err != nil {
+		return err
+	}
+	link, err = core.MeasureNetworkLink(ctx, cp.GetClient(), bucket, req.Network, link)
+	if err != nil {
+		return err
+	}
+	core.EstimateDuration(estimate, link)
+	return nil
+}
+
+// EstimateMigration handles POST /api/migrate/estimate
+// @Summary Estimate an S3 migration
+// @Description List the source of a migration request and price copying it, reporting whether the start request needs confirm_large_migration. The duration assumes the request's network profile; with network.measure the destination bucket is benchmarked first.
+// @Tags migration
+// @Accept json
+// @Produce json
+// @Param request body models.MigrationRequest true "Migration request"
+// @Success 200 {object} models.MigrationEstimate
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/migrate/estimate [post]
+func EstimateMigration(c *gin.Context) {
+	var req models.MigrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, validation.FromBindError(err))
+		return
+	}
+	if err := validation.ValidateMigrationRequest(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	resolved, err := resolveRequestCredentialRefs(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req = *resolved
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), bucketRequestTimeout)
+	defer cancel()
+	estimate, err := guardrailConfig().estimateRequest(ctx, req)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if req.Network != nil && req.Network.Measure {
+		if err := measureLink(ctx, req, estimate); err != nil {
+			respondError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	c.JSON(http.StatusOK, estimate)
+}
+
+// checkLargeMigration estimates a start request when a guardrail is set and
+// answers 409 with the estimate when it needs confirm_large_migration; false
+// when the request must not start. Dry runs copy nothing and are not held.
+func checkLargeMigration(c *gin.Context, req models.MigrationRequest) bool {
+	guard := guardrailConfig()
+	if !guard.enabled() || req.DryRun || req.Verify != nil || req.ConfirmLargeMigration {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), bucketRequestTimeout)
+	defer cancel()
+	estimate, err := guard.estimateRequest(ctx, req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+	if estimate.NeedsConfirmation {
+		respondErrorCode(c, http.StatusConflict, codeConfirmationRequired,
+			fmt.Sprintf("migration needs confirmation: %s; resend with confirm_large_migration=true to start it", estimate.Exceeds[0]),
+			gin.H{"estimate": estimate})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+)
+
+func TestLargeMigrationNeedsConfirmation(t *testing.T) {
+	t.Setenv("LARGE_MIGRATION_MAX_COST", "1")
+	t.Setenv("ESTIMATE_TRANSFER_COST_PER_GB", "100")
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	endpoint.Put("source", "logs/big.bin", bytes.Repeat([]byte("x"), 16<<20)) // 1.56 at 100 per GB
+	endpoint.Put("source", "small/a.txt", []byte("alpha"))
+	router := testRouter(t, endpoint)
+	router.POST("/api/migrate/estimate", EstimateMigration)
+
+	resp := serve(router, http.MethodPost, "/api/migrate/estimate", `{"source_bucket": "source", "dest_bucket": "dest"}`)
+	var estimate models.MigrationEstimate
+	json.Unmarshal(resp.Body.Bytes(), &estimate)
+	if resp.Code != http.StatusOK || estimate.Objects != 2 || estimate.EstimatedCost != 1.56 || !estimate.NeedsConfirmation || len(estimate.Exceeds) != 1 {
+		t.Fatalf("estimate = %d %+v", resp.Code, estimate)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{name: "above the limit", body: `{"source_bucket": "source", "dest_bucket": "dest"}`, want: http.StatusConflict},
+		{name: "confirmed", body: `{"source_bucket": "source", "dest_bucket": "dest", "confirm_large_migration": true}`, want: http.StatusOK},
+		{name: "prefix below the limit", body: `{"source_bucket": "source", "source_prefix": "small/", "dest_bucket": "dest"}`, want: http.StatusOK},
+		{name: "dry run", body: `{"source_bucket": "source", "dest_bucket": "dest", "dry_run": true}`, want: http.StatusOK},
+		{name: "all buckets", body: `{}`, want: http.StatusConflict},
+	}
+	var started []string
+	for _, tt := range tests {
+		resp := serve(router, http.MethodPost, "/api/migrate", tt.body)
+		if resp.Code != tt.want {
+			t.Errorf("%s: POST /api/migrate = %d, want %d: %s", tt.name, resp.Code, tt.want, resp.Body)
+		}
+		var status models.MigrationStatus
+		if resp.Code == http.StatusOK && json.Unmarshal(resp.Body.Bytes(), &status) == nil {
+			started = append(started, status.TaskID)
+		}
+	}
+	for _, taskID := range started {
+		waitForStatus(t, router, taskID, func(status models.MigrationStatus) bool { return status.Status == "completed" })
+	}
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"s3migration/pkg/models"
+	"s3migration/pkg/providers/googledrive"
+	"s3migration/pkg/secrets"
+	"s3migration/pkg/state"
+)
+
+// driveTaskStore returns the Google Drive resume state store, or nil without a database
+func driveTaskStore() *state.GoogleDriveTaskManager {
+	if taskManager == nil {
+		return nil
+	}
+	dbManager, ok := taskManager.stateManager.(*state.DBStateManager)
+	if !ok {
+		return nil
+	}
+	return state.NewGoogleDriveTaskManager(dbManager.GetDB())
+}
+
+// googleDriveTimeout is the migration timeout of a request (24h by default)
+func googleDriveTimeout(req *models.GoogleDriveMigrationRequest) time.Duration {
+	if req.Timeout > 0 {
+		return time.Duration(req.Timeout) * time.Second
+	}
+	return 24 * time.Hour
+}
+
+// googleDriveManifestKey is where the discovery manifest of a task is kept; empty when
+// the task cannot be resumed (no database, or a dry run that never writes)
+func googleDriveManifestKey(taskID string, req *models.GoogleDriveMigrationRequest) string {
+	if req.DryRun || driveTaskStore() == nil {
+		return ""
+	}
+	return googledrive.DiscoveryManifestKey(taskID)
+}
+
+// sanitizeGoogleDriveRequestForStorage returns a copy of req with the Drive tokens and client
+// secret encrypted. Unlike S3 keys a Drive task cannot resume without its tokens, so a failed
+// encryption is an error rather than a dropped field.
+func sanitizeGoogleDriveRequestForStorage(req *models.GoogleDriveMigrationRequest) (*models.GoogleDriveMigrationRequest, error) {
+	sanitized := *req
+	if req.SourceCredentials != nil {
+		creds := *req.SourceCredentials
+		for _, field := range []*string{&creds.ClientSecret, &creds.AccessToken, &creds.RefreshToken} {
+			value, err := encryptCredentials(*field)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt Google Drive credentials: %w", err)
+			}
+			*field = value
+		}
+		sanitized.SourceCredentials = &creds
+	}
+	sanitized.DestCredentials = encryptCredentialFields(req.DestCredentials)
+	return &sanitized, nil
+}
+
+// restoreGoogleDriveRequest reverses sanitizeGoogleDriveRequestForStorage
+func restoreGoogleDriveRequest(sanitized *models.GoogleDriveMigrationRequest) (*models.GoogleDriveMigrationRequest, error) {
+	restored := *sanitized
+	if sanitized.SourceCredentials != nil {
+		creds := *sanitized.SourceCredentials
+		for _, field := range []*string{&creds.ClientSecret, &creds.AccessToken, &creds.RefreshToken} {
+			value, err := secrets.DecryptStored(*field)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt stored Google Drive credentials (was ENCRYPTION_KEY changed?): %w", err)
+			}
+			*field = value
+		}
+		restored.SourceCredentials = &creds
+	}
+	var err error
+	if restored.DestCredentials, err = decryptCredentialFields(sanitized.DestCredentials); err != nil {
+		return nil, err
+	}
+	return &restored, nil
+}
+
+// persistGoogleDriveTask stores what a Google Drive task needs to resume after a restart
+func persistGoogleDriveTask(taskID string, req *models.GoogleDriveMigrationRequest) {
+	store := driveTaskStore()
+	manifestKey := googleDriveManifestKey(taskID, req)
+	if store == nil || manifestKey == "" {
+		return
+	}
+
+	sanitized, err := sanitizeGoogleDriveRequestForStorage(req)
+	if err != nil {
+		fmt.Printf("⚠️  Google Drive task %s will not resume after a restart: %v\n", taskID, err)
+		return
+	}
+	requestJSON, err := json.Marshal(sanitized)
+	if err != nil {
+		fmt.Printf("⚠️  Google Drive task %s will not resume after a restart: %v\n", taskID, err)
+		return
+	}
+
+	err = store.SaveTask(&state.GoogleDriveTaskState{
+		TaskID:      taskID,
+		Status:      "running",
+		FolderID:    req.SourceFolderID,
+		DestBucket:  req.DestBucket,
+		ManifestKey: manifestKey,
+		Request:     string(requestJSON),
+	})
+	if err != nil {
+		fmt.Printf("⚠️  Google Drive task %s will not resume after a restart: %v\n", taskID, err)
+	}
+}
+
+// finishGoogleDriveTask marks a Google Drive task as ended so it is not resumed
+func finishGoogleDriveTask(taskID, status string) {
+	store := driveTaskStore()
+	if store == nil {
+		return
+	}
+	if err := store.UpdateStatus(taskID, status); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	}
+}
+
+// resumeGoogleDriveTasks restarts the Google Drive migrations that were running when the
+// process stopped. They reuse their discovery manifest and skip files already uploaded.
+func (tm *TaskManager) resumeGoogleDriveTasks() {
+	store := driveTaskStore()
+	if store == nil {
+		return
+	}
+	records, err := store.ListRunning()
+	if err != nil {
+		fmt.Printf("Warning: failed to load Google Drive tasks to resume: %v\n", err)
+		return
+	}
+
+	for _, record := range records {
+		var stored models.GoogleDriveMigrationRequest
+		if err := json.Unmarshal([]byte(record.Request), &stored); err != nil {
+			fmt.Printf("⚠️  Cannot resume Google Drive task %s: %v\n", record.TaskID, err)
+			finishGoogleDriveTask(record.TaskID, "failed")
+			continue
+		}
+		req, err := restoreGoogleDriveRequest(&stored)
+		if err != nil {
+			fmt.Printf("⚠️  Cannot resume Google Drive task %s: %v\n", record.TaskID, err)
+			finishGoogleDriveTask(record.TaskID, "failed")
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), googleDriveTimeout(req))
+		startTime := record.CreatedAt
+
+		if existing, exists := tm.getTask(record.TaskID); exists && !existing.StartTime.IsZero() {
+			startTime = existing.StartTime
+		}
+		tm.addTask(&TaskInfo{
+			ID: record.TaskID,
+			Status: &models.MigrationStatus{
+				TaskID:        record.TaskID,
+				Status:        "pending",
+				MigrationType: "google-drive",
+				StartTime:     startTime,
+			},
+			CancelFn:        cancel,
+			StartTime:       startTime,
+			OriginalRequest: models.MigrationRequest{DestBucket: req.DestBucket},
+		})
+
+		fmt.Printf("🔄 Resuming Google Drive task %s (folder %s → s3://%s)\n", record.TaskID, record.FolderID, record.DestBucket)
+		go runGoogleDriveMigration(ctx, record.TaskID, *req, true)
+	}
+}
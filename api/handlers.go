@@ -2,36 +2,57 @@ package api
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-	"encoding/base64"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"net/http"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"s3migration/pkg/config"
 	"s3migration/pkg/core"
+	"s3migration/pkg/integrity"
+	"s3migration/pkg/inventory"
+	"s3migration/pkg/logging"
+	"s3migration/pkg/metricspush"
 	"s3migration/pkg/models"
 	"s3migration/pkg/pool"
+	"s3migration/pkg/progress"
 	"s3migration/pkg/providers/googledrive"
+	"s3migration/pkg/providers/httpsource"
+	"s3migration/pkg/scan"
+	"s3migration/pkg/secrets"
 	"s3migration/pkg/state"
+	"s3migration/pkg/statuscache"
+	pkgSync "s3migration/pkg/sync"
+	"s3migration/pkg/transform"
+	"s3migration/pkg/validation"
 )
 
-// TaskManager manages migration tasks (in-memory + RDS persistent state)
+// TaskManager manages migration tasks (in-memory + RDS persistent state).
+// Status changes go through updateTask and progress through per-task counters;
+// both queue an update on events that drives persistence and status streams.
 type TaskManager struct {
 	mu           sync.RWMutex
 	tasks        map[string]*TaskInfo
 	stateManager state.StateManager
+	events       chan *TaskInfo
+	subscribers  taskSubscribers
+	statusCache  statuscache.Cache // Live status shared with other API replicas; nil without Redis
+	// Builds the migrator of S3 tasks (core.NewEnhancedMigrator; tests substitute a fake endpoint)
+	newMigrator func(ctx context.Context, cfg core.EnhancedMigratorConfig) (*core.EnhancedMigrator, error)
+
+	// Request budgets shared by tasks writing to the same destination endpoint
+	groupsMu       sync.Mutex
+	endpointGroups map[string]*core.EndpointGroup
+
+	running sync.WaitGroup // Goroutines started by goTask
 }
 
 // TaskInfo contains task information
@@ -41,40 +62,105 @@ type TaskInfo struct {
 	Result           *models.MigrationResult
 	EnhancedMigrator *core.EnhancedMigrator
 	GoogleMigrator   *googledrive.GoogleDriveMigrator
+	URLObjects       []httpsource.ObjectResult // Per-URL outcome and checksums for url-list tasks
+	DryRunDiff       *core.DiffSummary         // Planned per-key changes from a dry run with dry_run_diff
+	Manifest         *core.TransferManifest    // Source keys copied and failed, exported for rclone or the AWS CLI
+	Timeline         *progress.Timeline        // Progress samples charted in the task report (S3 migrations)
+	cutoverConfirm   chan struct{}             // Signals a cutover awaiting confirmation; nil once confirmed
+	resume           *models.MigrationRequest  // Request an interrupted task is resumed with; nil when it cannot be
 	CancelFn         context.CancelFunc
 	StartTime        time.Time
 	OriginalRequest  models.MigrationRequest
+
+	counters taskCounters                           // Progress stored by migration workers
+	snapshot atomic.Pointer[models.MigrationStatus] // Status as of the last published change
+	queued   atomic.Bool                            // An update is queued on TaskManager.events
+	version  atomic.Uint64                          // Incremented on every change, so unchanged tasks are not saved again
 }
 
 var taskManager *TaskManager
 
-// InitTaskManager initializes the task manager with RDS/database backend
+// InitTaskManager initializes the task manager with RDS/database backend.
+// DB_READ_CONNECTION_STRING points task list and status reads at a read replica;
+// DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS, DB_READ_MAX_OPEN_CONNS and
+// DB_READ_MAX_IDLE_CONNS size the primary and replica pools.
 func InitTaskManager(dbDriver, dbConnectionString string) error {
 	var stateManager state.StateManager
 	var err error
 
 	// Create database-backed state manager
-	stateManager, err = state.NewDBStateManager(dbDriver, dbConnectionString)
+	stateManager, err = state.NewDBStateManagerWithConfig(state.DBConfig{
+		Driver:               dbDriver,
+		ConnectionString:     dbConnectionString,
+		ReadConnectionString: os.Getenv("DB_READ_CONNECTION_STRING"),
+		MaxOpenConns:         int(envFloat("DB_MAX_OPEN_CONNS", 0)),
+		MaxIdleConns:         int(envFloat("DB_MAX_IDLE_CONNS", 0)),
+		ReadMaxOpenConns:     int(envFloat("DB_READ_MAX_OPEN_CONNS", 0)),
+		ReadMaxIdleConns:     int(envFloat("DB_READ_MAX_IDLE_CONNS", 0)),
+	})
 	if err != nil {
 		return fmt.Errorf("failed to initialize database state manager: %w", err)
 	}
 
-	taskManager = &TaskManager{
-		tasks:        make(map[string]*TaskInfo),
-		stateManager: stateManager,
-	}
+	InitTaskManagerWithState(stateManager)
+
+	fmt.Printf("✅ Task manager initialized with %s database backend\n", dbDriver)
+	return nil
+}
+
+// InitTaskManagerWithState initializes the task manager on top of an existing
+// state manager, e.g. state.NewMemoryStateManager() in tests
+func InitTaskManagerWithState(stateManager state.StateManager) {
+	taskManager = newTaskManager(stateManager)
+	taskManager.statusCache = statusCacheFromEnv()
 
 	// Load existing tasks from database on startup (for pod restarts)
 	if err := taskManager.loadExistingTasks(); err != nil {
 		fmt.Printf("Warning: failed to load existing tasks: %v\n", err)
 	}
+	taskManager.resumeGoogleDriveTasks()
+	if autoResume, delay := autoResumeConfig(); autoResume {
+		go taskManager.resumeInterruptedTasks(delay)
+	}
 
 	// Start background jobs
 	go taskManager.cleanupOldTasks()
-	go taskManager.periodicStateSave()
+	go taskManager.processEvents(nil)
+	if pusher, interval := metricsPusherFromEnv(); pusher != nil {
+		go metricspush.Run(context.Background(), pusher, interval, taskManager.metricsSnapshot)
+	}
+}
 
-	fmt.Printf("✅ Task manager initialized with %s database backend\n", dbDriver)
-	return nil
+// newTaskManager creates a task manager without loading tasks or starting background jobs
+func newTaskManager(stateManager state.StateManager) *TaskManager {
+	return &TaskManager{
+		tasks:          make(map[string]*TaskInfo),
+		stateManager:   stateManager,
+		events:         make(chan *TaskInfo, taskEventBuffer),
+		newMigrator:    core.NewEnhancedMigrator,
+		endpointGroups: make(map[string]*core.EndpointGroup),
+	}
+}
+
+// goTask runs part of a task in the background, tracked by stopTasks
+func (tm *TaskManager) goTask(run func()) {
+	tm.running.Add(1)
+	go func() {
+		defer tm.running.Done()
+		run()
+	}()
+}
+
+// stopTasks cancels every task and waits for the goroutines started by goTask
+func (tm *TaskManager) stopTasks() {
+	tm.mu.RLock()
+	for _, task := range tm.tasks {
+		if task.CancelFn != nil {
+			task.CancelFn()
+		}
+	}
+	tm.mu.RUnlock()
+	tm.running.Wait()
 }
 
 // loadExistingTasks loads tasks from database on startup
@@ -84,21 +170,17 @@ func (tm *TaskManager) loadExistingTasks() error {
 		return err
 	}
 
-	tm.mu.Lock()
-	defer tm.mu.Unlock()
-
 	for _, taskState := range tasks {
-		// Only load running tasks into memory (failed/completed tasks stay in DB only)
-		if taskState.Status == "running" {
-			// Mark as failed since pod restarted mid-migration
-			taskState.Status = "failed"
-			taskState.Errors = append(taskState.Errors, "Migration interrupted by pod restart")
-			now := time.Now()
-			taskState.EndTime = &now
-			
-			// Save updated state
-			tm.stateManager.SaveTask(taskState)
-			
+		// Only load running and interrupted tasks into memory (failed/completed tasks stay in DB only)
+		if taskState.Status == "running" || taskState.Status == "pending" || taskState.Status == statusInterrupted {
+			// Pod restarted mid-migration: interrupted until resumed, cancelled or cleaned up
+			if taskState.Status != statusInterrupted {
+				taskState.Status = statusInterrupted
+				taskState.Errors = append(taskState.Errors, "Migration interrupted by pod restart")
+				tm.stateManager.SaveTask(taskState)
+			}
+			resume := resumeRequest(taskState)
+
 			// Convert to MigrationStatus for in-memory storage
 			status := &models.MigrationStatus{
 				TaskID:        taskState.ID,
@@ -114,13 +196,20 @@ func (tm *TaskManager) loadExistingTasks() error {
 				Errors:        taskState.Errors,
 				MigrationType: taskState.MigrationType,
 				DryRun:        taskState.DryRun,
+				Notes:         taskState.Notes,
+				Request:       storedRequestSummary(taskState),
 			}
 
-			tm.tasks[taskState.ID] = &TaskInfo{
+			taskInfo := &TaskInfo{
 				ID:        taskState.ID,
 				Status:    status,
 				StartTime: taskState.StartTime,
+				resume:    resume,
+			}
+			if resume != nil {
+				taskInfo.OriginalRequest = *resume
 			}
+			tm.addTask(taskInfo)
 
 			fmt.Printf("Loaded task %s from database (status: %s)\n", taskState.ID, taskState.Status)
 		}
@@ -142,54 +231,37 @@ func (tm *TaskManager) cleanupOldTasks() {
 	}
 }
 
-// periodicStateSave saves all task states to database periodically
-func (tm *TaskManager) periodicStateSave() {
-	ticker := time.NewTicker(5 * time.Second) // Save every 5 seconds for real-time updates
-	defer ticker.Stop()
-
-	for range ticker.C {
-		tm.mu.RLock()
-		tasks := make([]*TaskInfo, 0, len(tm.tasks))
-		for _, task := range tm.tasks {
-			tasks = append(tasks, task)
-		}
-		tm.mu.RUnlock()
-
-		// Save each task (non-blocking)
-		for _, task := range tasks {
-			if err := tm.saveTaskState(task); err != nil {
-				// Silently fail - don't spam logs
-			}
-		}
-	}
-}
-
-// saveTaskState persists task state to database
-func (tm *TaskManager) saveTaskState(taskInfo *TaskInfo) error {
+// saveTaskState persists a status of a task to database
+func (tm *TaskManager) saveTaskState(taskInfo *TaskInfo, status models.MigrationStatus) error {
 	if tm.stateManager == nil {
 		return fmt.Errorf("state manager not initialized")
 	}
+	return tm.stateManager.SaveTask(taskStateFor(taskInfo, status))
+}
 
+// taskStateFor converts a status of a task to its persisted form
+func taskStateFor(taskInfo *TaskInfo, status models.MigrationStatus) *state.TaskState {
 	taskState := &state.TaskState{
 		ID:            taskInfo.ID,
-		Status:        taskInfo.Status.Status,
-		Progress:      taskInfo.Status.Progress,
-		CopiedObjects: taskInfo.Status.CopiedObjects,
-		TotalObjects:  taskInfo.Status.TotalObjects,
-		CopiedSize:    taskInfo.Status.CopiedSize,
-		TotalSize:     taskInfo.Status.TotalSize,
-		CurrentSpeed:  taskInfo.Status.CurrentSpeed,
-		ETA:           taskInfo.Status.ETA,
-		Duration:      taskInfo.Status.Duration,
-		Errors:        taskInfo.Status.Errors,
+		Status:        status.Status,
+		Progress:      status.Progress,
+		CopiedObjects: status.CopiedObjects,
+		TotalObjects:  status.TotalObjects,
+		CopiedSize:    status.CopiedSize,
+		TotalSize:     status.TotalSize,
+		CurrentSpeed:  status.CurrentSpeed,
+		ETA:           status.ETA,
+		Duration:      status.Duration,
+		Errors:        status.Errors,
 		StartTime:     taskInfo.StartTime,
-		MigrationType: taskInfo.Status.MigrationType,
-		DryRun:        taskInfo.Status.DryRun,
+		MigrationType: status.MigrationType,
+		DryRun:        status.DryRun,
 		SyncMode:      false, // Default to false
+		Notes:         status.Notes,
 	}
 
 	// Set end time for completed tasks
-	if taskInfo.Status.Status == "completed" || taskInfo.Status.Status == "failed" || taskInfo.Status.Status == "cancelled" {
+	if status.Status == "completed" || status.Status == "failed" || status.Status == "cancelled" {
 		now := time.Now()
 		taskState.EndTime = &now
 	}
@@ -200,238 +272,245 @@ func (tm *TaskManager) saveTaskState(taskInfo *TaskInfo) error {
 		"dest_bucket":   taskInfo.OriginalRequest.DestBucket,
 		"dry_run":       taskInfo.OriginalRequest.DryRun,
 	}
+	if status.Request != nil {
+		taskState.OriginalRequest["summary"] = status.Request
+	}
+	if resume := resumePayload(taskInfo, status.Status); resume != nil {
+		taskState.OriginalRequest["resume"] = resume // Without keys: only tasks using secret references or the pod's identity
+	}
 
-	return tm.stateManager.SaveTask(taskState)
+	return taskState
 }
 
-// Auto-generate encryption key with multiple fallback options
-func getOrGenerateEncryptionKey() (string, error) {
-	// Priority 1: Environment variable
-	if envKey := os.Getenv("ENCRYPTION_KEY"); envKey != "" {
-		return envKey, nil
-	}
-	
-	// Priority 2: Key file in data directory
-	keyFile := "/app/data/encryption.key"
-	if key, err := loadKeyFromFile(keyFile); err == nil && key != "" {
-		return key, nil
-	}
-	
-	// Priority 3: Generate and save new key
-	return generateAndSaveKey(keyFile)
+// Security: Encrypt sensitive data before storing
+func encryptCredentials(data string) (string, error) {
+	return secrets.Encrypt(data)
 }
 
-// Load encryption key from file
-func loadKeyFromFile(keyFile string) (string, error) {
-	if _, err := os.Stat(keyFile); os.IsNotExist(err) {
-		return "", fmt.Errorf("key file does not exist")
+// Security: Decrypt sensitive data when needed
+func decryptCredentials(encryptedData string) (string, error) {
+	return secrets.Decrypt(encryptedData)
+}
+
+// encryptCredentialFields returns a copy of creds with the access key, secret key and
+// session token encrypted. A field that cannot be encrypted is dropped, never stored in plaintext.
+func encryptCredentialFields(creds *models.Credentials) *models.Credentials {
+	if creds == nil {
+		return nil
 	}
-	
-	data, err := ioutil.ReadFile(keyFile)
-	if err != nil {
-		return "", err
+	encrypted := *creds
+	if encrypted.SecretRef != "" {
+		// Keys resolved from a secret reference are never stored; only the reference is
+		encrypted.AccessKey, encrypted.SecretKey, encrypted.SessionToken = "", "", ""
+		return &encrypted
 	}
-	
-	key := strings.TrimSpace(string(data))
-	if len(key) < 16 {
-		return "", fmt.Errorf("key too short")
+	for _, field := range []*string{&encrypted.AccessKey, &encrypted.SecretKey, &encrypted.SessionToken} {
+		value, err := encryptCredentials(*field)
+		if err != nil {
+			fmt.Printf("⚠️  Failed to encrypt credential, dropping it: %v\n", err)
+			value = ""
+		}
+		*field = value
 	}
-	
-	return key, nil
+	return &encrypted
 }
 
-// Generate and save new encryption key
-func generateAndSaveKey(keyFile string) (string, error) {
-	// Create data directory if it doesn't exist
-	dataDir := filepath.Dir(keyFile)
-	if err := os.MkdirAll(dataDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create data directory: %v", err)
-	}
-	
-	// Generate 32-byte random key
-	keyBytes := make([]byte, 32)
-	if _, err := rand.Read(keyBytes); err != nil {
-		return "", fmt.Errorf("failed to generate random key: %v", err)
-	}
-	
-	// Convert to base64 string
-	key := base64.StdEncoding.EncodeToString(keyBytes)
-	
-	// Save to file
-	if err := ioutil.WriteFile(keyFile, []byte(key), 0600); err != nil {
-		return "", fmt.Errorf("failed to save key file: %v", err)
-	}
-	
-	return key, nil
+// decryptCredentialFields reverses encryptCredentialFields. Keys stored by earlier
+// versions have no enc:v1: prefix and are decrypted the way those versions
+// encrypted them (see secrets.DecryptStored); their session tokens were stored in
+// plaintext. A value that cannot be decrypted (missing or rotated ENCRYPTION_KEY)
+// is an error, never passed on to S3 as a key.
+func decryptCredentialFields(creds *models.Credentials) (*models.Credentials, error) {
+	if creds == nil {
+		return nil, nil
+	}
+	decrypted := *creds
+	for _, field := range []*string{&decrypted.AccessKey, &decrypted.SecretKey, &decrypted.SessionToken} {
+		if field == &decrypted.SessionToken && !secrets.IsEncrypted(*field) {
+			continue
+		}
+		value, err := secrets.DecryptStored(*field)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt stored credentials (was ENCRYPTION_KEY changed?): %w", err)
+		}
+		*field = value
+	}
+	return &decrypted, nil
 }
 
-// Security: Encrypt sensitive data before storing
-func encryptCredentials(data string) (string, error) {
-	if data == "" {
-		return "", nil
+// Security: Create a sanitized request copy without sensitive data
+func sanitizeRequestForStorage(req *models.MigrationRequest) *models.MigrationRequest {
+	sanitized := *req
+	sanitized.SourceCredentials = encryptCredentialFields(req.SourceCredentials)
+	sanitized.DestCredentials = encryptCredentialFields(req.DestCredentials)
+	sanitized.Credentials = encryptCredentialFields(req.Credentials) // Backward compatibility
+	return &sanitized
+}
+
+// Security: Restore sensitive data for retry
+func restoreRequestForRetry(sanitizedReq *models.MigrationRequest) (*models.MigrationRequest, error) {
+	restored := *sanitizedReq
+	var err error
+	if restored.SourceCredentials, err = decryptCredentialFields(sanitizedReq.SourceCredentials); err != nil {
+		return nil, err
+	}
+	if restored.DestCredentials, err = decryptCredentialFields(sanitizedReq.DestCredentials); err != nil {
+		return nil, err
+	}
+	if restored.Credentials, err = decryptCredentialFields(sanitizedReq.Credentials); err != nil {
+		return nil, err
+	}
+	return &restored, nil
+}
+
+// multipartSettingsFor resolves the multipart settings for a request's destination endpoint
+func multipartSettingsFor(req *models.MigrationRequest) config.MultipartSettings {
+	destEndpoint := ""
+	if req.DestCredentials != nil {
+		destEndpoint = req.DestCredentials.EndpointURL
+	} else if req.SourceCredentials != nil {
+		destEndpoint = req.SourceCredentials.EndpointURL
+	}
+	settings, err := core.MultipartSettingsFor(req.Multipart, destEndpoint)
+	if err != nil {
+		fmt.Printf("⚠️  Invalid multipart settings (%v), using defaults\n", err)
+		return config.DefaultMultipartSettings()
 	}
-	
-	keyStr, err := getOrGenerateEncryptionKey()
+	return settings
+}
+
+// transformPolicyFor builds a request's transformation hook; the request was validated, so errors only log
+func transformPolicyFor(req *models.MigrationRequest) *transform.Policy {
+	policy, err := core.TransformPolicyFor(req.Transform)
 	if err != nil {
-		return "", err
+		fmt.Printf("⚠️  Invalid transform settings (%v), copying objects unchanged\n", err)
+		return nil
 	}
-	key := []byte(keyStr)
-	
-	block, err := aes.NewCipher(key)
+	return policy
+}
+
+// sourceInventoryFor builds a request's source inventory; the request was validated, so errors only log
+func sourceInventoryFor(req *models.MigrationRequest) inventory.Source {
+	source, err := core.SourceInventoryFor(req.SourceInventory)
 	if err != nil {
-		return "", err
+		fmt.Printf("⚠️  Invalid source inventory (%v), listing the source bucket\n", err)
+		return nil
 	}
-	
-	gcm, err := cipher.NewGCM(block)
+	return source
+}
+
+// scanPolicyFor builds a request's content scanner; the request was validated, so errors only log
+func scanPolicyFor(req *models.MigrationRequest) *scan.Policy {
+	policy, err := core.ScanPolicyFor(req.Scan)
 	if err != nil {
-		return "", err
+		fmt.Printf("⚠️  Invalid scan settings (%v), objects will not be scanned\n", err)
+		return nil
 	}
-	
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
+	return policy
+}
+
+// destFetchPolicyFor builds a request's destination fetch policy; the request was validated, so errors only log
+func destFetchPolicyFor(req *models.MigrationRequest) *core.DestFetchPolicy {
+	policy, err := core.DestFetchPolicyFor(req.DestFetch)
+	if err != nil {
+		fmt.Printf("⚠️  Invalid dest_fetch settings (%v), streaming objects through the migration\n", err)
+		return nil
 	}
-	
-	ciphertext := gcm.Seal(nonce, nonce, []byte(data), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	return policy
 }
 
-// Security: Decrypt sensitive data when needed
-func decryptCredentials(encryptedData string) (string, error) {
-	if encryptedData == "" {
-		return "", nil
+// memorySyncStates keeps the sync state of cross-account routes when tasks are not stored in a database
+var memorySyncStates = state.NewMemorySyncStateStore()
+
+// conditionalGetPolicyFor keeps the sync state of a request's route when it asks for conditional GETs
+func conditionalGetPolicyFor(req *models.MigrationRequest) *core.ConditionalGetPolicy {
+	if !req.ConditionalGet {
+		return nil
+	}
+	if dbManager, ok := taskManager.stateManager.(*state.DBStateManager); ok {
+		return &core.ConditionalGetPolicy{Store: state.NewSyncStateManager(dbManager.GetDB())}
 	}
-	
-	data, err := base64.StdEncoding.DecodeString(encryptedData)
+	return &core.ConditionalGetPolicy{Store: memorySyncStates}
+}
+
+// dedupePolicyFor builds a request's deduplication policy; the request was validated, so errors only log
+func dedupePolicyFor(req *models.MigrationRequest) *core.DedupePolicy {
+	policy, err := core.DedupePolicyFor(req.Dedupe)
 	if err != nil {
-		return "", err
+		fmt.Printf("⚠️  Invalid dedupe settings (%v), copying every object\n", err)
+		return nil
 	}
-	
-	keyStr, err := getOrGenerateEncryptionKey()
+	return policy
+}
+
+// snapshotPolicyFor builds a request's snapshot manifest policy; the request was validated, so errors only log
+func snapshotPolicyFor(req *models.MigrationRequest, name string) *core.SnapshotPolicy {
+	policy, err := core.SnapshotPolicyFor(req.Snapshot, name)
 	if err != nil {
-		return "", err
+		fmt.Printf("⚠️  Invalid snapshot settings (%v), no snapshot manifest will be written\n", err)
+		return nil
 	}
-	key := []byte(keyStr)
-	block, err := aes.NewCipher(key)
+	return policy
+}
+
+// auditLogPolicyFor builds a request's audit log policy; the request was validated, so errors only log
+func auditLogPolicyFor(req *models.MigrationRequest, name string) *core.AuditLogPolicy {
+	policy, err := core.AuditLogPolicyFor(req.AuditLog, name)
 	if err != nil {
-		return "", err
+		fmt.Printf("⚠️  Invalid audit log settings (%v), no audit log will be written\n", err)
+		return nil
 	}
-	
-	gcm, err := cipher.NewGCM(block)
+	return policy
+}
+
+// aclPolicyFor builds a request's ACL mapping; the request was validated, so errors only log
+func aclPolicyFor(req *models.MigrationRequest) *core.ACLPolicy {
+	policy, err := core.ACLPolicyFor(req.ACL)
 	if err != nil {
-		return "", err
+		fmt.Printf("⚠️  Invalid ACL mapping (%v), ACLs will not be mapped\n", err)
+		return nil
 	}
-	
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
+	return policy
+}
+
+// labelPolicyFor builds a request's labels; the request was validated, so errors only log
+func labelPolicyFor(req *models.MigrationRequest, taskID string) *core.LabelPolicy {
+	policy, err := core.LabelPolicyFor(req.Labels, taskID)
+	if err != nil {
+		fmt.Printf("⚠️  Invalid labels (%v), copies will not be labeled\n", err)
+		return nil
 	}
-	
-	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	return policy
+}
+
+// partitionPolicyFor builds a request's date partitioning; the request was validated, so errors only log
+func partitionPolicyFor(req *models.MigrationRequest) *core.PartitionPolicy {
+	policy, err := core.PartitionPolicyFor(req.Partition)
 	if err != nil {
-		return "", err
+		fmt.Printf("⚠️  Invalid partition settings (%v), keeping destination keys unpartitioned\n", err)
+		return nil
 	}
-	
-	return string(plaintext), nil
+	return policy
 }
 
-// Security: Create a sanitized request copy without sensitive data
-func sanitizeRequestForStorage(req *models.MigrationRequest) *models.MigrationRequest {
-	sanitized := *req
-	
-	// Encrypt source credentials if present
-	if sanitized.SourceCredentials != nil {
-		encrypted := *sanitized.SourceCredentials
-		
-		if encryptedAccessKey, err := encryptCredentials(encrypted.AccessKey); err == nil {
-			encrypted.AccessKey = encryptedAccessKey
-		}
-		if encryptedSecretKey, err := encryptCredentials(encrypted.SecretKey); err == nil {
-			encrypted.SecretKey = encryptedSecretKey
-		}
-		
-		sanitized.SourceCredentials = &encrypted
-	}
-	
-	// Encrypt destination credentials if present
-	if sanitized.DestCredentials != nil {
-		encrypted := *sanitized.DestCredentials
-		
-		if encryptedAccessKey, err := encryptCredentials(encrypted.AccessKey); err == nil {
-			encrypted.AccessKey = encryptedAccessKey
-		}
-		if encryptedSecretKey, err := encryptCredentials(encrypted.SecretKey); err == nil {
-			encrypted.SecretKey = encryptedSecretKey
-		}
-		
-		sanitized.DestCredentials = &encrypted
-	}
-	
-	// Backward compatibility: encrypt old Credentials field
-	if sanitized.Credentials != nil {
-		encrypted := *sanitized.Credentials
-		
-		if encryptedAccessKey, err := encryptCredentials(encrypted.AccessKey); err == nil {
-			encrypted.AccessKey = encryptedAccessKey
-		}
-		if encryptedSecretKey, err := encryptCredentials(encrypted.SecretKey); err == nil {
-			encrypted.SecretKey = encryptedSecretKey
-		}
-		
-		sanitized.Credentials = &encrypted
+// keySanitizePolicyFor returns the key sanitization of a request, or nil when not requested
+func keySanitizePolicyFor(req *models.MigrationRequest) *core.KeySanitizePolicy {
+	policy, err := core.KeySanitizePolicyFor(req.KeySanitize)
+	if err != nil {
+		fmt.Printf("⚠️  Invalid key_sanitize settings (%v), keeping keys as-is\n", err)
+		return nil
 	}
-	
-	return &sanitized
+	return policy
 }
 
-// Security: Restore sensitive data for retry
-func restoreRequestForRetry(sanitizedReq *models.MigrationRequest) *models.MigrationRequest {
-	restored := *sanitizedReq
-	
-	// Decrypt source credentials if present
-	if restored.SourceCredentials != nil {
-		decrypted := *restored.SourceCredentials
-		
-		if decryptedAccessKey, err := decryptCredentials(decrypted.AccessKey); err == nil {
-			decrypted.AccessKey = decryptedAccessKey
-		}
-		if decryptedSecretKey, err := decryptCredentials(decrypted.SecretKey); err == nil {
-			decrypted.SecretKey = decryptedSecretKey
-		}
-		
-		restored.SourceCredentials = &decrypted
-	}
-	
-	// Decrypt destination credentials if present
-	if restored.DestCredentials != nil {
-		decrypted := *restored.DestCredentials
-		
-		if decryptedAccessKey, err := decryptCredentials(decrypted.AccessKey); err == nil {
-			decrypted.AccessKey = decryptedAccessKey
-		}
-		if decryptedSecretKey, err := decryptCredentials(decrypted.SecretKey); err == nil {
-			decrypted.SecretKey = decryptedSecretKey
-		}
-		
-		restored.DestCredentials = &decrypted
-	}
-	
-	// Backward compatibility: decrypt old Credentials field
-	if restored.Credentials != nil {
-		decrypted := *restored.Credentials
-		
-		if decryptedAccessKey, err := decryptCredentials(decrypted.AccessKey); err == nil {
-			decrypted.AccessKey = decryptedAccessKey
-		}
-		if decryptedSecretKey, err := decryptCredentials(decrypted.SecretKey); err == nil {
-			decrypted.SecretKey = decryptedSecretKey
-		}
-		
-		restored.Credentials = &decrypted
+// prefixShardPolicyFor returns the per-prefix limit of a request, or nil when not requested
+func prefixShardPolicyFor(req *models.MigrationRequest) *core.PrefixShardPolicy {
+	policy, err := core.PrefixShardPolicyFor(req.PrefixShards)
+	if err != nil {
+		fmt.Printf("⚠️  Invalid prefix_shards settings (%v), copying without a per-prefix limit\n", err)
+		return nil
 	}
-	
-	return &restored
+	return policy
 }
 
 // StartMigration handles POST /migrate
@@ -442,145 +521,225 @@ func restoreRequestForRetry(sanitizedReq *models.MigrationRequest) *models.Migra
 // @Produce json
 // @Param request body models.MigrationRequest true "Migration request"
 // @Success 200 {object} models.MigrationStatus
-// @Failure 400 {object} gin.H
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /api/migrate [post]
 func StartMigration(c *gin.Context) {
 	fmt.Printf("=== MIGRATION HANDLER CALLED ===\n")
 	var req models.MigrationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		fmt.Printf("ERROR: Failed to bind JSON: %v\n", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondValidationError(c, validation.FromBindError(err))
 		return
 	}
 	fmt.Printf("Request received: %+v\n", req)
-	
-	// Validate bucket combinations
-	if req.SourceBucket == "" && req.DestBucket != "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "When source bucket is empty (all buckets), destination bucket must also be empty"})
+
+	// Validate fields and normalize prefixes
+	if err := validation.ValidateMigrationRequest(&req); err != nil {
+		respondValidationError(c, err)
 		return
 	}
-	if req.SourceBucket != "" && req.DestBucket == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Destination bucket is required when source bucket is specified"})
+
+	// Fetch keys for credentials given as secret references, to check the
+	// request; the task only stores the references and fetches them again
+	resolved, err := resolveRequestCredentialRefs(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
-	
+
+	// Hold migrations above the size or cost guardrail until confirmed
+	if !checkLargeMigration(c, *resolved) {
+		return
+	}
+
 	// Generate task ID
 	taskID := uuid.New().String()
-	
+
+	// Keep a second task off a route an active task is copying
+	lock, holder, err := lockRoute(taskID, *resolved)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if holder != "" {
+		if duplicateTaskPolicy() == duplicateReject {
+			respondErrorCode(c, http.StatusConflict, codeDuplicateTask,
+				fmt.Sprintf("task %s is already copying this source to this destination; resend with force=true to start anyway", holder),
+				gin.H{"active_task_id": holder})
+			return
+		}
+		c.JSON(http.StatusOK, queueMigration(taskID, req, lock, holder).status())
+		return
+	}
+
+	taskInfo, err := launchMigration(taskID, req, time.Now())
+	if err != nil {
+		if lock != nil {
+			lock.release()
+		}
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if lock != nil {
+		lock.releaseWhenFinished(taskInfo)
+	}
+	c.JSON(http.StatusOK, taskInfo.status())
+}
+
+// launchMigration creates the migrator of a validated request, registers the
+// task and starts it in the background
+func launchMigration(taskID string, req models.MigrationRequest, startTime time.Time) (*TaskInfo, error) {
+	// The task stores the request as sent; keys of secret references are only
+	// fetched into the copy that runs it
+	stored := *sanitizeRequestForStorage(&req)
+	resolved, err := resolveRequestCredentialRefs(context.Background(), &req)
+	if err != nil {
+		return nil, err
+	}
+	req = *resolved
+
 	// Check if this is an all-buckets migration
 	if req.SourceBucket == "" {
-		// Start all-buckets migration
-		go runAllBucketsMigration(context.Background(), taskID, req)
-		
 		// Store task info
 		status := &models.MigrationStatus{
 			TaskID:    taskID,
 			Status:    "running",
-			StartTime: time.Now(),
+			StartTime: startTime,
+			Request:   requestSummary(req),
 		}
-		taskInfo := TaskInfo{
-			ID:             taskID,
-			Status:         status,
-			StartTime:      time.Now(),
-			OriginalRequest: req,
+		taskInfo := &TaskInfo{
+			ID:              taskID,
+			Status:          status,
+			StartTime:       startTime,
+			OriginalRequest: stored,
 		}
-		taskManager.mu.Lock()
-		taskManager.tasks[taskID] = &taskInfo
-		taskManager.mu.Unlock()
-		
-		c.JSON(http.StatusOK, *status)
-		return
+		taskInfo.resume = resumableRequest(&taskInfo.OriginalRequest)
+		taskManager.addTask(taskInfo)
+
+		// Start all-buckets migration once its task can be found
+		taskManager.goTask(func() { runAllBucketsMigration(context.Background(), taskID, req) })
+		return taskInfo, nil
 	}
 
 	// Create migrator with credentials
 	ctx, cancel := context.WithCancel(context.Background())
-	
-	var enhancedMigrator *core.EnhancedMigrator
-	var err error
-	
+	enhancedMigrator, err := newRequestMigrator(ctx, taskID, &req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// Create task info
+	migrationType := "s3"
+	if req.Verify != nil {
+		migrationType = "verify"
+	} else if req.Reorganize != nil && req.Reorganize.Enabled {
+		migrationType = "reorganize"
+	}
+	status := &models.MigrationStatus{
+		TaskID:         taskID,
+		Status:         "pending",
+		MigrationType:  migrationType,
+		Progress:       0,
+		StartTime:      startTime,
+		LastUpdateTime: time.Now(),
+		DryRun:         req.DryRun,
+		DryRunVerified: []string{},
+		SampleFiles:    []string{},
+		Request:        requestSummary(req),
+	}
+
+	taskInfo := &TaskInfo{
+		ID:               taskID,
+		Status:           status,
+		EnhancedMigrator: enhancedMigrator,
+		CancelFn:         cancel,
+		StartTime:        startTime,
+		OriginalRequest:  stored,
+	}
+
+	taskInfo.resume = resumableRequest(&taskInfo.OriginalRequest)
+	if req.Cutover != nil && req.Cutover.Enabled {
+		taskInfo.cutoverConfirm = make(chan struct{}, 1)
+	}
+
+	taskManager.addTask(taskInfo)
+
+	// Start migration in background
+	if req.Verify != nil {
+		taskManager.goTask(func() { runVerification(ctx, taskID, enhancedMigrator, req) })
+	} else if req.Reorganize != nil && req.Reorganize.Enabled {
+		taskManager.goTask(func() { runReorganization(ctx, taskID, enhancedMigrator, req) })
+	} else if taskInfo.cutoverConfirm != nil {
+		taskManager.goTask(func() { runCutover(ctx, taskID, enhancedMigrator, req, taskInfo.cutoverConfirm) })
+	} else {
+		taskManager.goTask(func() { runEnhancedMigration(ctx, taskID, enhancedMigrator, req) })
+	}
+
+	return taskInfo, nil
+}
+
+// newRequestMigrator creates the S3 migrator of a request, with its source
+// credentials and per-task log verbosity; deprecated credentials are moved to
+// SourceCredentials in place
+func newRequestMigrator(ctx context.Context, taskID string, req *models.MigrationRequest) (*core.EnhancedMigrator, error) {
+	// Per-task log verbosity (falls back to global settings)
+	taskLogger, err := logging.ForTask(req.LogLevel, req.DebugSampleRate)
+	if err != nil {
+		return nil, err
+	}
+
 	// Handle backward compatibility: if Credentials is provided, use it as SourceCredentials
 	if req.Credentials != nil && req.SourceCredentials == nil {
 		req.SourceCredentials = req.Credentials
 	}
-	
+
 	// Determine region and endpoint from SOURCE credentials
 	region := "us-east-1"
 	endpointURL := ""
-	
+
 	if req.SourceCredentials != nil {
 		if req.SourceCredentials.Region != "" {
 			region = req.SourceCredentials.Region
 		}
 		endpointURL = req.SourceCredentials.EndpointURL
 	}
-	
+
 	// Get database for integrity manager
 	var integrityManager *state.IntegrityManager
 	if dbManager, ok := taskManager.stateManager.(*state.DBStateManager); ok {
 		integrityManager = state.NewIntegrityManager(dbManager.GetDB())
 	}
-	
+
 	// Create enhanced migrator with optimal configuration
 	cfg := core.EnhancedMigratorConfig{
 		Region:             region,
 		EndpointURL:        endpointURL,
-		ConnectionPoolSize: 20, // Increased for better performance
+		ConnectionPoolSize: 20,    // Increased for better performance
 		EnableStreaming:    false, // Disabled - use multipart copy for large files instead
 		EnablePrefetch:     true,
-		EnableIntegrity:    true,  // ✅ Enable integrity verification
-		StreamChunkSize:    0, // Not used when streaming is disabled
+		EnableIntegrity:    true, // ✅ Enable integrity verification
+		StreamChunkSize:    0,    // Not used when streaming is disabled
 		CacheTTL:           5 * time.Minute,
 		CacheSize:          1000,
+		ListingCacheTTL:    listingCacheTTL(),
+		ListingStore:       listingStoreFor(),
 		AccessKey:          "", // Will be set below if provided
 		SecretKey:          "", // Will be set below if provided
 		TaskID:             taskID,
 		IntegrityManager:   integrityManager,
+		Logger:             taskLogger,
 	}
-	
+
 	// Add explicit source credentials if provided
 	if req.SourceCredentials != nil && req.SourceCredentials.AccessKey != "" && req.SourceCredentials.SecretKey != "" {
 		cfg.AccessKey = req.SourceCredentials.AccessKey
 		cfg.SecretKey = req.SourceCredentials.SecretKey
 	}
-	
-	enhancedMigrator, err = core.NewEnhancedMigrator(ctx, cfg)
-	
-	if err != nil {
-		cancel()
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+	cfg.CredentialsProvider = credentialsProviderFor(req.SourceCredentials)
 
-	// Create task info
-	status := &models.MigrationStatus{
-		TaskID:         taskID,
-		Status:         "pending",
-		MigrationType:  "s3",
-		Progress:       0,
-		StartTime:      time.Now(),
-		LastUpdateTime: time.Now(),
-		DryRun:         req.DryRun,
-		DryRunVerified: []string{},
-		SampleFiles:    []string{},
-	}
-
-	taskInfo := &TaskInfo{
-		ID:               taskID,
-		Status:           status,
-		EnhancedMigrator: enhancedMigrator,
-		CancelFn:         cancel,
-		StartTime:        time.Now(),
-		OriginalRequest:  *sanitizeRequestForStorage(&req), // Encrypt sensitive data
-	}
-
-	taskManager.mu.Lock()
-	taskManager.tasks[taskID] = taskInfo
-	taskManager.mu.Unlock()
-
-	// Start migration in background
-	go runEnhancedMigration(ctx, taskID, enhancedMigrator, req)
-
-	c.JSON(http.StatusOK, status)
+	return taskManager.newMigrator(ctx, cfg)
 }
 
 func maskCredential(cred string) string {
@@ -593,36 +752,104 @@ func maskCredential(cred string) string {
 	return cred[:4] + "***" + cred[len(cred)-4:]
 }
 
+// destRegionFor returns the region destination buckets are created in: the
+// destination's, else the source's (empty for custom providers without one)
+func destRegionFor(req *models.MigrationRequest) string {
+	if req.DestCredentials != nil && req.DestCredentials.Region != "" {
+		return req.DestCredentials.Region
+	}
+	if req.SourceCredentials != nil {
+		return req.SourceCredentials.Region
+	}
+	return ""
+}
+
+// s3MigrateInput builds the input of a single-bucket S3 migration; source
+// credentials must already be in SourceCredentials
+func s3MigrateInput(taskID string, req models.MigrationRequest) core.MigrateInput {
+	timeout := time.Duration(req.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = 1 * time.Hour
+	}
+	destRegion := destRegionFor(&req)
+
+	// Determine migration mode
+	migrationMode := core.MigrationMode(req.MigrationMode)
+	if migrationMode == "" {
+		migrationMode = core.ModeFullRewrite // Default to full rewrite
+	}
+
+	input := core.MigrateInput{
+		SourceBucket:            req.SourceBucket,
+		DestBucket:              req.DestBucket,
+		SourcePrefix:            req.SourcePrefix,
+		DestPrefix:              req.DestPrefix,
+		DestRegion:              destRegion, // Region for destination bucket creation (empty for custom providers)
+		DryRun:                  req.DryRun,
+		MigrationMode:           migrationMode,
+		ConflictStrategy:        pkgSync.ConflictStrategy(req.ConflictStrategy),
+		FilesFrom:               req.FilesFrom,
+		SourceInventory:         sourceInventoryFor(&req),
+		Order:                   core.ObjectOrder(req.ObjectOrder),
+		DryRunDiff:              req.DryRun && req.DryRunDiff,
+		ReuseDestListing:        req.ReuseDestListing,
+		VerifyWrite:             req.VerifyWrite,
+		EndpointGroup:           destEndpointGroup(&req),
+		Transform:               transformPolicyFor(&req),
+		Scan:                    scanPolicyFor(&req),
+		Dedupe:                  dedupePolicyFor(&req),
+		Snapshot:                snapshotPolicyFor(&req, taskID),
+		TaskListing:             taskListingPolicyFor(&req, taskID),
+		VerifyListing:           verifyListingFor(&req),
+		ListingCheckpoint:       listingCheckpointPolicyFor(&req, taskID),
+		ExcludeLifecycleExpired: req.ExcludeLifecycleExpired,
+		FolderMarkers:           core.FolderMarkerMode(req.FolderMarkers),
+		NeverOverwrite:          core.NeverOverwriteMode(req.NeverOverwrite),
+		Partition:               partitionPolicyFor(&req),
+		KeySanitize:             keySanitizePolicyFor(&req),
+		PrefixShards:            prefixShardPolicyFor(&req),
+		DestFetch:               destFetchPolicyFor(&req),
+		ConditionalGet:          conditionalGetPolicyFor(&req),
+		Reconcile:               core.ReconcilePolicyFor(req.Reconcile),
+		FailureManifest:         failureManifestPolicyFor(&req, taskID),
+		AuditLog:                auditLogPolicyFor(&req, taskID),
+		ACL:                     aclPolicyFor(&req),
+		Labels:                  labelPolicyFor(&req, taskID),
+		Multipart:               multipartSettingsFor(&req),
+		Timeout:                 timeout,
+		Reporter:                taskManager.progressReporter(taskID), // Real-time progress without the task manager lock
+	}
+
+	// Add destination credentials if different from source
+	if req.DestCredentials != nil {
+		input.DestAccessKey = req.DestCredentials.AccessKey
+		input.DestSecretKey = req.DestCredentials.SecretKey
+		input.DestEndpointURL = req.DestCredentials.EndpointURL
+		input.DestCredentialsProvider = credentialsProviderFor(req.DestCredentials)
+	}
+	return input
+}
+
 func runEnhancedMigration(ctx context.Context, taskID string, enhancedMigrator *core.EnhancedMigrator, req models.MigrationRequest) {
 	// Add panic recovery to prevent server crashes
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Printf("Panic in enhanced migration %s: %v\n", taskID, r)
-			taskManager.mu.Lock()
-			if task, exists := taskManager.tasks[taskID]; exists {
+			taskManager.updateTask(taskID, func(task *TaskInfo) {
 				task.Status.Status = "failed"
 				task.Status.Errors = append(task.Status.Errors, fmt.Sprintf("Panic: %v", r))
-			}
-			taskManager.mu.Unlock()
+			})
 		}
 	}()
-	
+
 	fmt.Printf("=== ENHANCED MIGRATION DEBUG START ===\n")
 	fmt.Printf("Task ID: %s\n", taskID)
 	fmt.Printf("Request: %+v\n", req)
-	
+
 	// Update status to running
-	taskManager.mu.Lock()
-	if task, exists := taskManager.tasks[taskID]; exists {
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
 		task.Status.Status = "running"
-	}
-	taskManager.mu.Unlock()
-
-	// Execute migration
-	timeout := time.Duration(req.Timeout) * time.Second
-	if timeout == 0 {
-		timeout = 1 * time.Hour
-	}
+	})
 
 	// Handle backward compatibility: if Credentials is provided, use it as SourceCredentials
 	if req.Credentials != nil && req.SourceCredentials == nil {
@@ -630,12 +857,7 @@ func runEnhancedMigration(ctx context.Context, taskID string, enhancedMigrator *
 	}
 
 	// Get region from credentials
-	destRegion := ""
-	if req.DestCredentials != nil && req.DestCredentials.Region != "" {
-		destRegion = req.DestCredentials.Region
-	} else if req.SourceCredentials != nil && req.SourceCredentials.Region != "" {
-		destRegion = req.SourceCredentials.Region
-	}
+	destRegion := destRegionFor(&req)
 
 	fmt.Printf("\n=== MIGRATION REQUEST DEBUG ===\n")
 	fmt.Printf("Source Bucket: %s\n", req.SourceBucket)
@@ -656,62 +878,38 @@ func runEnhancedMigration(ctx context.Context, taskID string, enhancedMigrator *
 		fmt.Printf("Dest Endpoint: %s\n", req.DestCredentials.EndpointURL)
 	}
 	fmt.Printf("================================\n\n")
-	
-	// Determine migration mode
-	migrationMode := core.MigrationMode(req.MigrationMode)
-	if migrationMode == "" {
-		migrationMode = core.ModeFullRewrite // Default to full rewrite
-	}
-	
-	input := core.MigrateInput{
-		SourceBucket:  req.SourceBucket,
-		DestBucket:    req.DestBucket,
-		SourcePrefix:  req.SourcePrefix,
-		DestPrefix:    req.DestPrefix,
-		DestRegion:    destRegion, // Region for destination bucket creation (empty for custom providers)
-		DryRun:        req.DryRun,
-		MigrationMode: migrationMode,
-		Timeout:       timeout,
-		ProgressCallback: func(progress float64, copied, total int64, speed float64, eta string) {
-			// Update task status in real-time
-			taskManager.mu.Lock()
-			if task, exists := taskManager.tasks[taskID]; exists {
-				task.Status.Progress = progress
-				task.Status.CopiedObjects = copied
-				task.Status.TotalObjects = total
-				task.Status.CurrentSpeed = speed
-				task.Status.ETA = eta
-				task.Status.LastUpdateTime = time.Now()
-			}
-			taskManager.mu.Unlock()
-		},
-	}
 
-	// Add destination credentials if different from source
-	if req.DestCredentials != nil {
-		input.DestAccessKey = req.DestCredentials.AccessKey
-		input.DestSecretKey = req.DestCredentials.SecretKey
-		input.DestEndpointURL = req.DestCredentials.EndpointURL
+	input := s3MigrateInput(taskID, req)
+	var closeProgressLogs func()
+	input.Reporter, closeProgressLogs = progressLogs(taskID, input.Reporter)
+	defer closeProgressLogs()
+	timeline := progress.NewTimeline(reportTimelineInterval)
+	input.Reporter = progress.Multi(input.Reporter, timeline)
+	taskManager.mu.Lock()
+	if task, exists := taskManager.tasks[taskID]; exists {
+		task.Timeline = timeline
 	}
+	taskManager.mu.Unlock()
 
-	fmt.Printf("Starting enhanced migration task %s: %s -> %s (DryRun: %v)\n", 
+	fmt.Printf("Starting enhanced migration task %s: %s -> %s (DryRun: %v)\n",
 		taskID, input.SourceBucket, input.DestBucket, input.DryRun)
-	fmt.Printf("Input: %+v\n", input)
+	fmt.Printf("Input: mode=%s prefix=%q -> %q cross-account=%v\n",
+		input.MigrationMode, input.SourcePrefix, input.DestPrefix, input.DestAccessKey != "")
 	fmt.Printf("Using enhanced migrator with all optimizations\n")
-	
+
 	var result *core.MigrateResult
 	var err error
-	
+
 	if enhancedMigrator == nil {
 		// Create a new migrator for retry tasks using the original request credentials
 		fmt.Printf("Creating new enhanced migrator for retry task\n")
-		
+
 		// Check if credentials are available
 		if req.SourceCredentials == nil {
 			err = fmt.Errorf("cannot retry task: source credentials not available (credentials are not persisted for security reasons)")
 			fmt.Printf("ERROR: %v\n", err)
 		} else {
-			enhancedMigrator, err = core.NewEnhancedMigrator(ctx, core.EnhancedMigratorConfig{
+			enhancedMigrator, err = taskManager.newMigrator(ctx, core.EnhancedMigratorConfig{
 				ConnectionPoolSize: 10,
 				StreamChunkSize:    64 * 1024 * 1024, // 64MB
 				AccessKey:          req.SourceCredentials.AccessKey,
@@ -728,17 +926,14 @@ func runEnhancedMigration(ctx context.Context, taskID string, enhancedMigrator *
 	} else {
 		result, err = enhancedMigrator.Migrate(ctx, input)
 	}
-	
+
 	fmt.Printf("=== ENHANCED MIGRATION DEBUG RESULT ===\n")
 	fmt.Printf("Error: %v\n", err)
 	fmt.Printf("Result: %+v\n", result)
 	fmt.Printf("=== ENHANCED MIGRATION DEBUG END ===\n")
 
 	// Update final status
-	taskManager.mu.Lock()
-	defer taskManager.mu.Unlock()
-
-	if task, exists := taskManager.tasks[taskID]; exists {
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
 		if err != nil {
 			fmt.Printf("Enhanced migration %s failed: %v\n", taskID, err)
 			task.Status.Status = "failed"
@@ -755,27 +950,52 @@ func runEnhancedMigration(ctx context.Context, taskID string, enhancedMigrator *
 		} else {
 			task.Status.Status = "completed"
 		}
-		
+
 		// Set end time and duration
 		task.Status.EndTime = time.Now()
+		task.Status.Phase = ""
 		duration := task.Status.EndTime.Sub(task.Status.StartTime)
 		task.Status.Duration = formatDuration(duration)
 
 		task.Result = &models.MigrationResult{
-			TaskID:       taskID,
-			Success:      result.Failed == 0 && !result.Cancelled,
-			Copied:       result.Copied,
-			Failed:       result.Failed,
-			TotalSizeMB:  result.TotalSizeMB,
-			CopiedSizeMB: result.CopiedSizeMB,
-			ElapsedTime:  result.ElapsedTime,
-			AvgSpeedMB:   result.AvgSpeedMB,
-			Errors:       result.Errors,
+			TaskID:            taskID,
+			Success:           result.Failed == 0 && !result.Cancelled,
+			Copied:            result.Copied,
+			Failed:            result.Failed,
+			Skipped:           result.Skipped,
+			NotModified:       result.NotModified,
+			ScanFindings:      result.ScanFindings,
+			Deduplicated:      result.Deduplicated,
+			DedupeManifest:    result.DedupeManifest,
+			LifecycleExcluded: result.LifecycleExcluded,
+			FolderMarkers:     result.FolderMarkers,
+			SnapshotManifest:  result.SnapshotManifest,
+			SnapshotSHA256:    result.SnapshotSHA256,
+			WebsiteCopied:     result.WebsiteCopied,
+			DestFetched:       result.DestFetched,
+			OverwriteRefused:  result.OverwriteRefused,
+			ExistingDestKeys:  result.ExistingDestKeys,
+			TotalSizeMB:       result.TotalSizeMB,
+			CopiedSizeMB:      result.CopiedSizeMB,
+			ElapsedTime:       result.ElapsedTime,
+			AvgSpeedMB:        result.AvgSpeedMB,
+			Errors:            result.Errors,
+			ResourceUsage:     result.ResourceUsage,
+			Requests:          &result.Requests,
+			Latency:           result.Latency,
+			WorkerAdjustments: result.WorkerAdjustments,
+			Reconciliation:    result.Reconciliation,
+			FailureManifest:   result.FailureManifest,
+			AuditLog:          result.AuditLog,
+			ACL:               result.ACL,
+			Labels:            result.Labels,
 		}
+		task.Manifest = result.Manifest
 
 		// Update progress metrics for all runs (dry run and actual)
 		if result.DryRun {
 			task.Status.DryRunVerified = result.DryRunVerified
+			task.DryRunDiff = result.DryRunDiff
 			task.Status.SampleFiles = []string{} // Not showing sample files
 			// Update progress metrics for dry run
 			task.Status.Progress = 100.0
@@ -799,8 +1019,10 @@ func runEnhancedMigration(ctx context.Context, taskID string, enhancedMigrator *
 			task.Status.TotalSize = int64(result.TotalSizeMB * 1024 * 1024)
 			task.Status.CurrentSpeed = result.AvgSpeedMB
 			task.Status.ETA = "0s" // Completed
+			task.Status.ETAEstimate = nil
 		}
-	}
+	})
+	notifyTaskWebhook(taskID, &req)
 }
 
 // GetStatus handles GET /status/:taskID
@@ -810,52 +1032,65 @@ func runEnhancedMigration(ctx context.Context, taskID string, enhancedMigrator *
 // @Produce json
 // @Param taskID path string true "Task ID"
 // @Success 200 {object} models.MigrationStatus
-// @Failure 404 {object} gin.H
+// @Failure 404 {object} ErrorResponse
 // @Router /api/status/{taskID} [get]
 func GetStatus(c *gin.Context) {
 	taskID := c.Param("taskID")
 
-	taskManager.mu.RLock()
-	task, exists := taskManager.tasks[taskID]
-	taskManager.mu.RUnlock()
-
+	task, exists := taskManager.getTask(taskID)
 	if !exists {
+		// Running on another replica: its live status is in the shared cache
+		if status, _, cached := taskManager.cachedStatus(taskID); cached {
+			localizeStatus(c, status)
+			c.JSON(http.StatusOK, status)
+			return
+		}
+
 		// Task not in memory, check database
 		taskState, err := taskManager.stateManager.LoadTask(taskID)
 		if err != nil || taskState == nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			respondError(c, http.StatusNotFound, "task not found")
 			return
 		}
-		
-		// Convert database task state to migration status
-		status := &models.MigrationStatus{
-			TaskID:        taskState.ID,
-			Status:        taskState.Status,
-			Progress:      taskState.Progress,
-			CopiedObjects: taskState.CopiedObjects,
-			TotalObjects:  taskState.TotalObjects,
-			CopiedSize:    taskState.CopiedSize,
-			TotalSize:     taskState.TotalSize,
-			CurrentSpeed:  taskState.CurrentSpeed,
-			ETA:           taskState.ETA,
-			Duration:      taskState.Duration,
-			Errors:        taskState.Errors,
-			StartTime:     taskState.StartTime,
-			MigrationType: taskState.MigrationType,
-			DryRun:        taskState.DryRun,
-			LastUpdateTime: time.Now(), // Set to current time for database tasks
-		}
-		
-		// Handle EndTime conversion from pointer to value
-		if taskState.EndTime != nil {
-			status.EndTime = *taskState.EndTime
-		}
-		
+
+		status := storedStatus(taskState)
+		localizeStatus(c, status)
 		c.JSON(http.StatusOK, status)
 		return
 	}
 
-	c.JSON(http.StatusOK, task.Status)
+	status := task.status()
+	localizeStatus(c, &status)
+	c.JSON(http.StatusOK, status)
+}
+
+// storedStatus converts the database state of a task that is not in memory to its status
+func storedStatus(taskState *state.TaskState) *models.MigrationStatus {
+	status := &models.MigrationStatus{
+		TaskID:         taskState.ID,
+		Status:         taskState.Status,
+		Progress:       taskState.Progress,
+		CopiedObjects:  taskState.CopiedObjects,
+		TotalObjects:   taskState.TotalObjects,
+		CopiedSize:     taskState.CopiedSize,
+		TotalSize:      taskState.TotalSize,
+		CurrentSpeed:   taskState.CurrentSpeed,
+		ETA:            taskState.ETA,
+		Duration:       taskState.Duration,
+		Errors:         taskState.Errors,
+		StartTime:      taskState.StartTime,
+		MigrationType:  taskState.MigrationType,
+		DryRun:         taskState.DryRun,
+		Notes:          taskState.Notes,
+		Request:        storedRequestSummary(taskState),
+		LastUpdateTime: time.Now(), // Set to current time for database tasks
+	}
+
+	// Handle EndTime conversion from pointer to value
+	if taskState.EndTime != nil {
+		status.EndTime = *taskState.EndTime
+	}
+	return status
 }
 
 // ListTasks handles GET /tasks
@@ -899,44 +1134,91 @@ func ListTasks(c *gin.Context) {
 	c.JSON(http.StatusOK, taskIDs)
 }
 
+// Cancel modes of DELETE /api/tasks/:taskID
+const (
+	cancelHard  = "hard"  // Abandon in-flight transfers at once
+	cancelDrain = "drain" // Start no new objects, let in-flight transfers finish
+)
+
 // CancelTask handles DELETE /tasks/:taskID
 // @Summary Cancel a migration task
-// @Description Cancel a running migration task
+// @Description Cancel a running migration task. With mode=drain no new objects are started but in-flight transfers finish, and the task ends cancelled with exact counts once they have.
 // @Tags migration
 // @Produce json
 // @Param taskID path string true "Task ID"
+// @Param mode query string false "hard (default) or drain"
 // @Success 200 {object} gin.H
-// @Failure 404 {object} gin.H
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Router /api/tasks/{taskID} [delete]
 func CancelTask(c *gin.Context) {
 	taskID := c.Param("taskID")
+	mode := c.DefaultQuery("mode", cancelHard)
+	if mode != cancelHard && mode != cancelDrain {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("unsupported cancel mode %q (expected hard or drain)", mode))
+		return
+	}
 
-	taskManager.mu.Lock()
-	defer taskManager.mu.Unlock()
-
-	task, exists := taskManager.tasks[taskID]
+	previous, exists, drainErr := cancelTask(taskID, mode)
 	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		respondError(c, http.StatusNotFound, "task not found")
+		return
+	}
+	if drainErr != nil {
+		respondError(c, http.StatusBadRequest, drainErr.Error())
 		return
 	}
 
-	if task.Status.Status == "pending" || task.Status.Status == "running" {
+	if mode == cancelDrain && previous == "running" {
+		fmt.Printf("Task %s draining: no new objects are started\n", taskID)
+		c.JSON(http.StatusOK, gin.H{"status": "draining", "message": "No new objects are started; the task ends cancelled once in-flight transfers finish"})
+	} else if previous == "pending" || previous == "running" || previous == statusInterrupted {
+		fmt.Printf("Task %s cancelled by user\n", taskID)
+		c.JSON(http.StatusOK, gin.H{"status": "cancelled", "message": "Task cancelled successfully"})
+	} else {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("task cannot be cancelled (status: %s)", previous))
+	}
+}
+
+// cancelTask cancels a pending, running or interrupted task, or with
+// cancelDrain lets a running S3 copy finish its in-flight transfers. It
+// returns the status the task had, false if it is not registered, and an
+// error when the task cannot be drained.
+func cancelTask(taskID, mode string) (string, bool, error) {
+	var previous string
+	var drainErr error
+	exists := taskManager.updateTask(taskID, func(task *TaskInfo) {
+		previous = task.Status.Status
+		if previous != "pending" && previous != "running" && previous != statusInterrupted {
+			return
+		}
+
+		// Only a running copy has transfers in flight; anything else is simply cancelled
+		if mode == cancelDrain && previous == "running" {
+			if task.EnhancedMigrator == nil || task.OriginalRequest.Cutover != nil || task.OriginalRequest.Verify != nil || task.OriginalRequest.Reorganize != nil {
+				drainErr = fmt.Errorf("drain is only supported for S3 copy tasks; cancel without mode=drain")
+				return
+			}
+			// The migration finishes the copies it started and sets the final status
+			task.EnhancedMigrator.Stop()
+			task.Status.CancelMode = cancelDrain
+			return
+		}
+
 		// Stop S3 migrator if it exists (S3-to-S3 migration)
 		if task.EnhancedMigrator != nil {
 			task.EnhancedMigrator.Stop()
 		}
-		
+
 		// Cancel the context (works for both S3 and Google Drive migrations)
 		if task.CancelFn != nil {
 			task.CancelFn()
 		}
-		
+
 		task.Status.Status = "cancelled"
-		fmt.Printf("Task %s cancelled by user\n", taskID)
-		c.JSON(http.StatusOK, gin.H{"status": "cancelled", "message": "Task cancelled successfully"})
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("task cannot be cancelled (status: %s)", task.Status.Status)})
-	}
+		task.Status.CancelMode = cancelHard
+	})
+	return previous, exists, drainErr
 }
 
 // RetryTask removed - credentials are not persisted for security reasons
@@ -944,52 +1226,52 @@ func CancelTask(c *gin.Context) {
 
 // CleanupTasks handles DELETE /api/tasks/cleanup/:status
 // @Summary Cleanup tasks by status
-// @Description Delete all tasks with a specific status (failed, completed, cancelled, or all)
+// @Description Delete all tasks with a specific status (failed, completed, cancelled, interrupted, or all)
 // @Tags tasks
 // @Accept json
 // @Produce json
-// @Param status path string true "Task status to cleanup (failed, completed, cancelled, all)"
+// @Param status path string true "Task status to cleanup (failed, completed, cancelled, interrupted, all)"
 // @Success 200 {object} gin.H
-// @Failure 400 {object} gin.H
+// @Failure 400 {object} ErrorResponse
 // @Router /tasks/cleanup/{status} [delete]
 func CleanupTasks(c *gin.Context) {
 	status := c.Param("status")
-	
+
 	// Validate status
 	validStatuses := map[string]bool{
-		"failed":    true,
-		"completed": true,
-		"cancelled": true,
-		"all":       true,
+		"failed":      true,
+		"completed":   true,
+		"cancelled":   true,
+		"interrupted": true,
+		"all":         true,
 	}
-	
+
 	if !validStatuses[status] {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid status. Must be one of: failed, completed, cancelled, all",
-		})
+		respondError(c, http.StatusBadRequest, "Invalid status. Must be one of: failed, completed, cancelled, interrupted, all")
 		return
 	}
-	
+
 	// Get all tasks
 	taskManager.mu.Lock()
 	tasksToDelete := []string{}
-	
+
 	for taskID, task := range taskManager.tasks {
 		// Skip running/pending tasks
 		if task.Status.Status == "running" || task.Status.Status == "pending" {
 			continue
 		}
-		
+
 		// Match status or delete all
 		if status == "all" || task.Status.Status == status {
 			tasksToDelete = append(tasksToDelete, taskID)
 		}
 	}
-	
+
 	// Delete from memory
 	for _, taskID := range tasksToDelete {
 		delete(taskManager.tasks, taskID)
-		
+		taskManager.uncacheStatus(taskID)
+
 		// Also delete from database
 		if taskManager.stateManager != nil {
 			if err := taskManager.stateManager.DeleteTask(taskID); err != nil {
@@ -998,7 +1280,7 @@ func CleanupTasks(c *gin.Context) {
 		}
 	}
 	taskManager.mu.Unlock()
-	
+
 	// Also cleanup from database for tasks not in memory
 	totalDeleted := len(tasksToDelete)
 	if taskManager.stateManager != nil {
@@ -1010,12 +1292,13 @@ func CleanupTasks(c *gin.Context) {
 				if dbTask.Status == "running" || dbTask.Status == "pending" {
 					continue
 				}
-				
+
 				// Delete if matches status
 				if status == "all" || dbTask.Status == status {
 					if err := taskManager.stateManager.DeleteTask(dbTask.ID); err != nil {
 						fmt.Printf("Failed to delete task %s from database: %v\n", dbTask.ID, err)
 					} else {
+						taskManager.uncacheStatus(dbTask.ID)
 						totalDeleted++
 						fmt.Printf("Deleted task %s from database (status: %s)\n", dbTask.ID, dbTask.Status)
 					}
@@ -1023,11 +1306,11 @@ func CleanupTasks(c *gin.Context) {
 			}
 		}
 	}
-	
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("Cleaned up %d tasks with status: %s", totalDeleted, status),
+		"message":       fmt.Sprintf("Cleaned up %d tasks with status: %s", totalDeleted, status),
 		"deleted_count": totalDeleted,
-		"status": status,
+		"status":        status,
 	})
 }
 
@@ -1050,12 +1333,10 @@ func runAllBucketsMigration(ctx context.Context, taskID string, req models.Migra
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Printf("All-buckets migration panic: %v\n", r)
-		taskManager.mu.Lock()
-		if task, exists := taskManager.tasks[taskID]; exists {
-			task.Status.Status = "failed"
-			task.Status.Errors = []string{fmt.Sprintf("Migration panic: %v", r)}
-		}
-		taskManager.mu.Unlock()
+			taskManager.updateTask(taskID, func(task *TaskInfo) {
+				task.Status.Status = "failed"
+				task.Status.Errors = []string{fmt.Sprintf("Migration panic: %v", r)}
+			})
 		}
 	}()
 
@@ -1080,15 +1361,14 @@ func runAllBucketsMigration(ctx context.Context, taskID string, req models.Migra
 		cfg.AccessKey = req.SourceCredentials.AccessKey
 		cfg.SecretKey = req.SourceCredentials.SecretKey
 	}
+	cfg.CredentialsProvider = credentialsProviderFor(req.SourceCredentials)
 
 	cp, err := pool.NewConnectionPool(ctx, cfg)
 	if err != nil {
-		taskManager.mu.Lock()
-		if task, exists := taskManager.tasks[taskID]; exists {
+		taskManager.updateTask(taskID, func(task *TaskInfo) {
 			task.Status.Status = "failed"
 			task.Status.Errors = []string{fmt.Sprintf("Failed to create connection pool: %v", err)}
-		}
-		taskManager.mu.Unlock()
+		})
 		return
 	}
 	client := cp.GetClient()
@@ -1097,49 +1377,42 @@ func runAllBucketsMigration(ctx context.Context, taskID string, req models.Migra
 	fmt.Printf("Listing all buckets...\n")
 	listBucketsOutput, err := client.ListBuckets(ctx, &s3.ListBucketsInput{})
 	if err != nil {
-		taskManager.mu.Lock()
-		if task, exists := taskManager.tasks[taskID]; exists {
+		taskManager.updateTask(taskID, func(task *TaskInfo) {
 			task.Status.Status = "failed"
 			task.Status.Errors = []string{fmt.Sprintf("Failed to list buckets: %v", err)}
-		}
-		taskManager.mu.Unlock()
+		})
 		return
 	}
 
 	if len(listBucketsOutput.Buckets) == 0 {
-		taskManager.mu.Lock()
-		if task, exists := taskManager.tasks[taskID]; exists {
+		taskManager.updateTask(taskID, func(task *TaskInfo) {
 			task.Status.Status = "completed"
 			task.Status.TotalObjects = 0
 			task.Status.CopiedObjects = 0
-		}
-		taskManager.mu.Unlock()
+		})
 		return
 	}
 
-	taskManager.mu.Lock()
-	if task, exists := taskManager.tasks[taskID]; exists {
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
 		task.Status.TotalObjects = int64(len(listBucketsOutput.Buckets))
 		task.Status.CopiedObjects = 0
-	}
-	taskManager.mu.Unlock()
+	})
 
 	// Create enhanced migrator
-	enhancedMigrator, err := core.NewEnhancedMigrator(ctx, core.EnhancedMigratorConfig{
-		ConnectionPoolSize: 10,
-		StreamChunkSize:    64 * 1024 * 1024, // 64MB
-		AccessKey:          cfg.AccessKey,
-		SecretKey:          cfg.SecretKey,
-		Region:             region,
-		EndpointURL:        endpointURL,
+	enhancedMigrator, err := taskManager.newMigrator(ctx, core.EnhancedMigratorConfig{
+		ConnectionPoolSize:  10,
+		StreamChunkSize:     64 * 1024 * 1024, // 64MB
+		AccessKey:           cfg.AccessKey,
+		SecretKey:           cfg.SecretKey,
+		CredentialsProvider: cfg.CredentialsProvider,
+		Region:              region,
+		EndpointURL:         endpointURL,
 	})
 	if err != nil {
-		taskManager.mu.Lock()
-		if task, exists := taskManager.tasks[taskID]; exists {
+		taskManager.updateTask(taskID, func(task *TaskInfo) {
 			task.Status.Status = "failed"
 			task.Status.Errors = []string{fmt.Sprintf("Failed to create enhanced migrator: %v", err)}
-		}
-		taskManager.mu.Unlock()
+		})
 		return
 	}
 
@@ -1169,109 +1442,122 @@ func runAllBucketsMigration(ctx context.Context, taskID string, req models.Migra
 		if migrationMode == "" {
 			migrationMode = core.ModeFullRewrite // Default to full rewrite
 		}
-		
+
 		input := core.MigrateInput{
-			SourceBucket:      bucketReq.SourceBucket,
-			DestBucket:        bucketReq.DestBucket,
-			SourcePrefix:      bucketReq.SourcePrefix,
-			DestPrefix:        bucketReq.DestPrefix,
-			MigrationMode:     migrationMode,
+			SourceBucket:            bucketReq.SourceBucket,
+			DestBucket:              bucketReq.DestBucket,
+			SourcePrefix:            bucketReq.SourcePrefix,
+			DestPrefix:              bucketReq.DestPrefix,
+			MigrationMode:           migrationMode,
+			ConflictStrategy:        pkgSync.ConflictStrategy(bucketReq.ConflictStrategy),
+			Order:                   core.ObjectOrder(req.ObjectOrder),
+			Multipart:               multipartSettingsFor(&req),
+			EndpointGroup:           destEndpointGroup(&bucketReq),
+			Transform:               transformPolicyFor(&req),
+			Scan:                    scanPolicyFor(&req),
+			Dedupe:                  dedupePolicyFor(&req),
+			Snapshot:                snapshotPolicyFor(&req, taskID+"-"+bucketName),
+			ExcludeLifecycleExpired: req.ExcludeLifecycleExpired,
+			FolderMarkers:           core.FolderMarkerMode(req.FolderMarkers),
+			NeverOverwrite:          core.NeverOverwriteMode(req.NeverOverwrite),
+			Partition:               partitionPolicyFor(&req),
+			KeySanitize:             keySanitizePolicyFor(&req),
+			PrefixShards:            prefixShardPolicyFor(&req),
+			DestFetch:               destFetchPolicyFor(&req),
+			ConditionalGet:          conditionalGetPolicyFor(&req),
+			AuditLog:                auditLogPolicyFor(&req, taskID+"-"+bucketName),
+			ACL:                     aclPolicyFor(&req),
+			Labels:                  labelPolicyFor(&req, taskID),
 		}
-		
+
 		// Add destination credentials if provided
 		if bucketReq.DestCredentials != nil {
 			input.DestAccessKey = bucketReq.DestCredentials.AccessKey
 			input.DestSecretKey = bucketReq.DestCredentials.SecretKey
 			input.DestEndpointURL = bucketReq.DestCredentials.EndpointURL
+			input.DestCredentialsProvider = credentialsProviderFor(bucketReq.DestCredentials)
 		}
 
 		// Run migration for this bucket
 		result, err := enhancedMigrator.Migrate(ctx, input)
 		if err != nil {
 			fmt.Printf("Failed to migrate bucket %s: %v\n", bucketName, err)
-			taskManager.mu.Lock()
-			if task, exists := taskManager.tasks[taskID]; exists {
+			taskManager.updateTask(taskID, func(task *TaskInfo) {
 				task.Status.Errors = append(task.Status.Errors, fmt.Sprintf("Failed to migrate bucket %s: %v", bucketName, err))
-			}
-			taskManager.mu.Unlock()
+			})
 			continue
 		}
 
 		// Update totals
 		totalObjects += result.Copied + result.Failed
 		completedObjects += result.Copied
-		totalSize += int64(result.TotalSizeMB * 1024 * 1024) // Convert MB to bytes
+		totalSize += int64(result.TotalSizeMB * 1024 * 1024)      // Convert MB to bytes
 		completedSize += int64(result.CopiedSizeMB * 1024 * 1024) // Convert MB to bytes
 
 		// Update task progress
-		taskManager.mu.Lock()
-		if task, exists := taskManager.tasks[taskID]; exists {
+		taskManager.updateTask(taskID, func(task *TaskInfo) {
 			task.Status.CopiedObjects = int64(i + 1)
 			task.Status.TotalObjects = int64(len(listBucketsOutput.Buckets))
-		}
-		taskManager.mu.Unlock()
+		})
 	}
 
 	// Mark as completed
-	taskManager.mu.Lock()
-	if task, exists := taskManager.tasks[taskID]; exists {
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
 		task.Status.Status = "completed"
 		task.Status.TotalObjects = totalObjects
 		task.Status.CopiedObjects = completedObjects
 		task.Status.TotalSize = totalSize
 		task.Status.CopiedSize = completedSize
-	}
-	taskManager.mu.Unlock()
+	})
 
-	fmt.Printf("All-buckets migration completed. Migrated %d buckets, %d objects, %d bytes\n", 
+	fmt.Printf("All-buckets migration completed. Migrated %d buckets, %d objects, %d bytes\n",
 		len(listBucketsOutput.Buckets), totalObjects, completedSize)
 }
 
 // GoogleDriveQuickAuthURL handles token exchange for public OAuth app
 func GoogleDriveQuickAuthURL(c *gin.Context) {
-    var req struct {
-        Code string `json:"code" binding:"required"`
-    }
-
-    if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Authorization code is required"})
-        return
-    }
-
-    // Use public OAuth app credentials from environment
-    clientID := os.Getenv("GOOGLE_CLIENT_ID")
-    clientSecret := os.Getenv("GOOGLE_CLIENT_SECRET")
-    if clientID == "" || clientSecret == "" {
-        c.JSON(http.StatusServiceUnavailable, gin.H{
-            "error": "Google OAuth not configured. Please set GOOGLE_CLIENT_ID and GOOGLE_CLIENT_SECRET environment variables or use custom OAuth.",
-        })
-        return
-    }
-    redirectURL := fmt.Sprintf("%s://%s/auth/callback", 
-        func() string {
-            if c.Request.Header.Get("X-Forwarded-Proto") == "http" || 
-               strings.HasPrefix(c.Request.Host, "localhost") || 
-               strings.HasPrefix(c.Request.Host, "127.0.0.1") {
-                return "http"
-            }
-            return "https"
-        }(), c.Request.Host)
-
-    // Create auth handler
-    authHandler := googledrive.NewAuthHandler(c.Request.Context(), googledrive.OAuthConfig{
-        ClientID:     clientID,
-        ClientSecret: clientSecret,
-        RedirectURL:  redirectURL,
-    })
-
-    // Exchange code for token
-    tokenResponse, err := authHandler.ExchangeCodeForToken(req.Code)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to exchange token: %v", err)})
-        return
-    }
-
-    c.JSON(http.StatusOK, tokenResponse)
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "Authorization code is required")
+		return
+	}
+
+	// Use public OAuth app credentials from environment
+	clientID := os.Getenv("GOOGLE_CLIENT_ID")
+	clientSecret := os.Getenv("GOOGLE_CLIENT_SECRET")
+	if clientID == "" || clientSecret == "" {
+		respondErrorCode(c, http.StatusServiceUnavailable, codeNotConfigured,
+			"Google OAuth not configured. Please set GOOGLE_CLIENT_ID and GOOGLE_CLIENT_SECRET environment variables or use custom OAuth.", nil)
+		return
+	}
+	redirectURL := fmt.Sprintf("%s://%s/auth/callback",
+		func() string {
+			if c.Request.Header.Get("X-Forwarded-Proto") == "http" ||
+				strings.HasPrefix(c.Request.Host, "localhost") ||
+				strings.HasPrefix(c.Request.Host, "127.0.0.1") {
+				return "http"
+			}
+			return "https"
+		}(), c.Request.Host)
+
+	// Create auth handler
+	authHandler := googledrive.NewAuthHandler(c.Request.Context(), googledrive.OAuthConfig{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+	})
+
+	// Exchange code for token
+	tokenResponse, err := authHandler.ExchangeCodeForToken(req.Code)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("Failed to exchange token: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse)
 }
 
 // GoogleDriveAuthURL generates OAuth URL for Google Drive authentication
@@ -1283,7 +1569,7 @@ func GoogleDriveAuthURL(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -1317,7 +1603,7 @@ func GoogleDriveExchangeToken(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -1328,25 +1614,24 @@ func GoogleDriveExchangeToken(c *gin.Context) {
 		clientID = os.Getenv("GOOGLE_CLIENT_ID")
 		clientSecret = os.Getenv("GOOGLE_CLIENT_SECRET")
 		if clientID == "" || clientSecret == "" {
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"error": "Quick login not configured. Please set GOOGLE_CLIENT_ID and GOOGLE_CLIENT_SECRET environment variables or use custom OAuth.",
-			})
+			respondErrorCode(c, http.StatusServiceUnavailable, codeNotConfigured,
+				"Quick login not configured. Please set GOOGLE_CLIENT_ID and GOOGLE_CLIENT_SECRET environment variables or use custom OAuth.", nil)
 			return
 		}
-		
+
 		// Get the current domain from the request to build redirect URL
 		host := c.Request.Host
 		scheme := "https"
-		if c.Request.Header.Get("X-Forwarded-Proto") == "http" || 
-		   strings.HasPrefix(host, "localhost") || 
-		   strings.HasPrefix(host, "127.0.0.1") {
+		if c.Request.Header.Get("X-Forwarded-Proto") == "http" ||
+			strings.HasPrefix(host, "localhost") ||
+			strings.HasPrefix(host, "127.0.0.1") {
 			scheme = "http"
 		}
 		redirectURL = fmt.Sprintf("%s://%s/auth/callback", scheme, host)
 	} else {
 		// Use user-provided credentials
 		if req.ClientID == "" || req.ClientSecret == "" || req.RedirectURL == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "client_id, client_secret, and redirect_url are required for custom OAuth"})
+			respondError(c, http.StatusBadRequest, "client_id, client_secret, and redirect_url are required for custom OAuth")
 			return
 		}
 		clientID = req.ClientID
@@ -1364,7 +1649,7 @@ func GoogleDriveExchangeToken(c *gin.Context) {
 	// Exchange code for token
 	tokenResponse, err := authHandler.ExchangeCodeForToken(req.Code)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to exchange token: %v", err)})
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("Failed to exchange token: %v", err))
 		return
 	}
 
@@ -1382,7 +1667,7 @@ func GoogleDriveListFolders(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -1393,9 +1678,8 @@ func GoogleDriveListFolders(c *gin.Context) {
 		clientID = os.Getenv("GOOGLE_CLIENT_ID")
 		clientSecret = os.Getenv("GOOGLE_CLIENT_SECRET")
 		if clientID == "" || clientSecret == "" {
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"error": "Google OAuth not configured. Please set GOOGLE_CLIENT_ID and GOOGLE_CLIENT_SECRET environment variables or provide client_id and client_secret in request.",
-			})
+			respondErrorCode(c, http.StatusServiceUnavailable, codeNotConfigured,
+				"Google OAuth not configured. Please set GOOGLE_CLIENT_ID and GOOGLE_CLIENT_SECRET environment variables or provide client_id and client_secret in request.", nil)
 			return
 		}
 	} else {
@@ -1412,36 +1696,82 @@ func GoogleDriveListFolders(c *gin.Context) {
 		RefreshToken: req.RefreshToken,
 	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to create client: %v", err)})
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("Failed to create client: %v", err))
 		return
 	}
 
 	// List folders
 	folders, err := client.ListFolders(req.ParentID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to list folders: %v", err)})
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("Failed to list folders: %v", err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"folders": folders})
 }
 
+// googleDriveDestPoolConfig builds the destination S3 pool config of a Drive migration
+// from its explicit credentials, secret reference or named profile
+func googleDriveDestPoolConfig(req *models.GoogleDriveMigrationRequest) pool.ConnectionPoolConfig {
+	cfg := pool.ConnectionPoolConfig{
+		Region:  "us-east-1",
+		Profile: req.DestProfile,
+		Timeout: time.Hour,
+	}
+	if creds := req.DestCredentials; creds != nil {
+		cfg.AccessKey = creds.AccessKey
+		cfg.SecretKey = creds.SecretKey
+		cfg.EndpointURL = creds.EndpointURL
+		cfg.CredentialsProvider = credentialsProviderFor(creds)
+		if creds.Region != "" {
+			cfg.Region = creds.Region
+		}
+	}
+	return cfg
+}
+
+// preflightGoogleDriveDestination proves the destination credentials can write to the
+// bucket before hours of Drive discovery. A bucket that does not exist yet is created
+// by the migration, so only its existence check has to succeed.
+func preflightGoogleDriveDestination(ctx context.Context, req *models.GoogleDriveMigrationRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, bucketRequestTimeout)
+	defer cancel()
+
+	cfg := googleDriveDestPoolConfig(req)
+	cfg.Size = 1
+	cp, err := pool.NewConnectionPool(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create destination client: %w", err)
+	}
+	validator := core.NewBucketValidator(cp.GetClient())
+	exists, err := validator.BucketExists(ctx, req.DestBucket)
+	if err != nil {
+		return fmt.Errorf("cannot access bucket '%s': %w", req.DestBucket, err)
+	}
+	if !exists || req.DryRun {
+		return nil
+	}
+	return validator.ValidateWriteAccess(ctx, req.DestBucket, req.DestPrefix)
+}
+
 // StartGoogleDriveMigration starts a Google Drive to S3 migration
 func StartGoogleDriveMigration(c *gin.Context) {
 	var req models.GoogleDriveMigrationRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondValidationError(c, validation.FromBindError(err))
 		return
 	}
 
-	// Validate required fields
-	if req.SourceCredentials == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "source_credentials is required"})
+	// Validate fields and normalize the destination prefix
+	if err := validation.ValidateGoogleDriveMigrationRequest(&req); err != nil {
+		respondValidationError(c, err)
 		return
 	}
-	if req.DestBucket == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "dest_bucket is required"})
+
+	// Fail now rather than after discovery if the destination is not writable
+	if err := preflightGoogleDriveDestination(c.Request.Context(), &req); err != nil {
+		respondError(c, http.StatusBadRequest, localize(c, fmt.Sprintf("destination preflight failed: %v", err)))
 		return
 	}
 
@@ -1449,16 +1779,11 @@ func StartGoogleDriveMigration(c *gin.Context) {
 	taskID := uuid.New().String()
 
 	// Create context with timeout
-	timeout := time.Duration(req.Timeout) * time.Second
-	if timeout == 0 {
-		timeout = 24 * time.Hour // Default timeout
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), googleDriveTimeout(&req))
 
 	// Create task
-	taskManager.mu.Lock()
-	taskManager.tasks[taskID] = &TaskInfo{
-		ID:        taskID,
+	taskManager.addTask(&TaskInfo{
+		ID: taskID,
 		Status: &models.MigrationStatus{
 			TaskID:        taskID,
 			Status:        "pending",
@@ -1469,12 +1794,14 @@ func StartGoogleDriveMigration(c *gin.Context) {
 		},
 		CancelFn:        cancel,
 		StartTime:       time.Now(),
-		OriginalRequest: models.MigrationRequest{}, // Empty for Google Drive
-	}
-	taskManager.mu.Unlock()
+		OriginalRequest: models.MigrationRequest{DestBucket: req.DestBucket, DryRun: req.DryRun}, // Drive tokens are kept in google_drive_tasks
+	})
+
+	// Keep what is needed to resume after a pod restart
+	persistGoogleDriveTask(taskID, &req)
 
 	// Start migration in goroutine
-	go runGoogleDriveMigration(ctx, taskID, req)
+	go runGoogleDriveMigration(ctx, taskID, req, false)
 
 	c.JSON(http.StatusOK, gin.H{
 		"task_id": taskID,
@@ -1482,25 +1809,30 @@ func StartGoogleDriveMigration(c *gin.Context) {
 	})
 }
 
-// runGoogleDriveMigration executes the Google Drive to S3 migration
-func runGoogleDriveMigration(ctx context.Context, taskID string, req models.GoogleDriveMigrationRequest) {
+// runGoogleDriveMigration executes the Google Drive to S3 migration; a resumed task
+// reuses its discovery manifest and skips files uploaded before the restart
+func runGoogleDriveMigration(ctx context.Context, taskID string, req models.GoogleDriveMigrationRequest, resume bool) {
+	// Runs last: record how the task ended so it is not resumed on the next start
+	defer func() {
+		status := "failed"
+		if task, exists := taskManager.getTask(taskID); exists {
+			status = task.status().Status
+		}
+		finishGoogleDriveTask(taskID, status)
+	}()
 	defer func() {
 		if r := recover(); r != nil {
-			taskManager.mu.Lock()
-			if task, exists := taskManager.tasks[taskID]; exists {
+			taskManager.updateTask(taskID, func(task *TaskInfo) {
 				task.Status.Status = "failed"
 				task.Status.Errors = append(task.Status.Errors, fmt.Sprintf("Panic: %v", r))
-			}
-			taskManager.mu.Unlock()
+			})
 		}
 	}()
 
 	// Update status to running
-	taskManager.mu.Lock()
-	if task, exists := taskManager.tasks[taskID]; exists {
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
 		task.Status.Status = "running"
-	}
-	taskManager.mu.Unlock()
+	})
 
 	// Create Google Drive client
 	driveClient, err := googledrive.NewClient(ctx, googledrive.Config{
@@ -1510,40 +1842,20 @@ func runGoogleDriveMigration(ctx context.Context, taskID string, req models.Goog
 		RefreshToken: req.SourceCredentials.RefreshToken,
 	})
 	if err != nil {
-		taskManager.mu.Lock()
-		if task, exists := taskManager.tasks[taskID]; exists {
+		taskManager.updateTask(taskID, func(task *TaskInfo) {
 			task.Status.Status = "failed"
 			task.Status.Errors = append(task.Status.Errors, fmt.Sprintf("Failed to create Google Drive client: %v", err))
-		}
-		taskManager.mu.Unlock()
+		})
 		return
 	}
 
 	// Create S3 client for destination
-	destCredentials := req.DestCredentials
-	if destCredentials == nil {
-		destCredentials = &models.Credentials{
-			AccessKey:   req.SourceCredentials.AccessToken, // Fallback - this is wrong, should use source S3 creds
-			SecretKey:   req.SourceCredentials.RefreshToken, // This needs to be fixed
-			Region:      "us-east-1",
-			EndpointURL: "",
-		}
-	}
-
-	cp, err := pool.NewConnectionPool(ctx, pool.ConnectionPoolConfig{
-		AccessKey:   destCredentials.AccessKey,
-		SecretKey:   destCredentials.SecretKey,
-		Region:      destCredentials.Region,
-		EndpointURL: destCredentials.EndpointURL,
-		Timeout:     time.Hour,
-	})
+	cp, err := pool.NewConnectionPool(ctx, googleDriveDestPoolConfig(&req))
 	if err != nil {
-		taskManager.mu.Lock()
-		if task, exists := taskManager.tasks[taskID]; exists {
+		taskManager.updateTask(taskID, func(task *TaskInfo) {
 			task.Status.Status = "failed"
 			task.Status.Errors = append(task.Status.Errors, fmt.Sprintf("Failed to create connection pool: %v", err))
-		}
-		taskManager.mu.Unlock()
+		})
 		return
 	}
 	s3Client := cp.GetClient()
@@ -1552,69 +1864,82 @@ func runGoogleDriveMigration(ctx context.Context, taskID string, req models.Goog
 	migrator := googledrive.NewGoogleDriveMigrator(ctx, driveClient, s3Client)
 
 	// Create migration input
+	reportProgress := taskManager.progressCallback(taskID)
+	var uploading sync.Once
 	migrationInput := googledrive.MigrationInput{
-		SourceFolderID:   req.SourceFolderID,
-		DestBucket:       req.DestBucket,
-		DestPrefix:       req.DestPrefix,
-		DryRun:           req.DryRun,
+		SourceFolderID:     req.SourceFolderID,
+		DestBucket:         req.DestBucket,
+		DestPrefix:         req.DestPrefix,
+		DryRun:             req.DryRun,
 		IncludeSharedFiles: req.IncludeSharedFiles,
-		ProgressCallback: func(progress float64, copied, total int64, copiedSize, totalSize int64, speed float64, eta string) {
-			// Update task status in real-time
-			taskManager.mu.Lock()
-			if task, exists := taskManager.tasks[taskID]; exists {
-				task.Status.Progress = progress
-				task.Status.CopiedObjects = copied
-				task.Status.TotalObjects = total
-				task.Status.CopiedSize = copiedSize
-				task.Status.TotalSize = totalSize
-				task.Status.CurrentSpeed = speed
-				task.Status.ETA = eta
+		ManifestKey:        googleDriveManifestKey(taskID, &req),
+		ResumeFromManifest: resume,
+		SkipExisting:       resume || req.MigrationMode == "incremental",
+		Filter: &googledrive.Filter{
+			IncludePaths:     req.IncludePaths,
+			ExcludePaths:     req.ExcludePaths,
+			IncludeMimeTypes: req.IncludeMimeTypes,
+			ExcludeMimeTypes: req.ExcludeMimeTypes,
+		},
+		DiscoveryCallback: func(files, bytes, folders int64) {
+			taskManager.updateTask(taskID, func(task *TaskInfo) {
+				task.Status.Phase = models.PhaseDiscovering
+				task.Status.Discovery = &models.DiscoveryProgress{FilesDiscovered: files, BytesDiscovered: bytes, FoldersFound: folders}
 				task.Status.LastUpdateTime = time.Now()
-			}
-			taskManager.mu.Unlock()
+			})
+		},
+		ProgressCallback: func(progress float64, copied, total int64, copiedSize, totalSize int64, speed float64, eta string) {
+			// Progress is only reported once discovery is done
+			uploading.Do(func() {
+				taskManager.updateTask(taskID, func(task *TaskInfo) {
+					task.Status.Phase = models.PhaseUploading
+				})
+			})
+			reportProgress(progress, copied, total, copiedSize, totalSize, speed, eta)
 		},
 	}
 
 	// Run migration
 	result, err := migrator.Migrate(migrationInput)
 	if err != nil {
-		taskManager.mu.Lock()
-		if task, exists := taskManager.tasks[taskID]; exists {
+		taskManager.updateTask(taskID, func(task *TaskInfo) {
 			task.Status.Status = "failed"
 			task.Status.Errors = append(task.Status.Errors, fmt.Sprintf("Migration failed: %v", err))
-		}
-		taskManager.mu.Unlock()
+		})
 		return
 	}
 
 	// Mark as completed
-	taskManager.mu.Lock()
-	if task, exists := taskManager.tasks[taskID]; exists {
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
 		task.Status.Status = "completed"
 		task.Status.TotalObjects = result.TotalFiles
 		task.Status.CopiedObjects = result.CopiedFiles
 		task.Status.TotalSize = result.TotalSize
 		task.Status.CopiedSize = result.CopiedSize
-		
+
 		// Set end time and duration
 		task.Status.EndTime = time.Now()
+		task.Status.Phase = ""
 		duration := task.Status.EndTime.Sub(task.Status.StartTime)
 		task.Status.Duration = formatDuration(duration)
-		
+
 		task.Result = &models.MigrationResult{
 			TaskID:       taskID,
 			Success:      result.FailedFiles == 0,
 			Copied:       result.CopiedFiles,
 			Failed:       result.FailedFiles,
+			Skipped:      result.SkippedFiles,
 			TotalSizeMB:  float64(result.TotalSize) / (1024 * 1024),
 			CopiedSizeMB: float64(result.CopiedSize) / (1024 * 1024),
 			ElapsedTime:  result.Duration.String(),
 			AvgSpeedMB:   float64(result.CopiedSize) / result.Duration.Seconds() / (1024 * 1024),
 		}
-	}
-	taskManager.mu.Unlock()
+		for _, file := range result.SkippedUnexportable {
+			task.Result.SkippedUnexportable = append(task.Result.SkippedUnexportable, models.UnexportableFile(file))
+		}
+	})
 
-	fmt.Printf("Google Drive migration completed. Migrated %d files, %d bytes\n", 
+	fmt.Printf("Google Drive migration completed. Migrated %d files, %d bytes\n",
 		result.CopiedFiles, result.CopiedSize)
 }
 
@@ -1643,21 +1968,21 @@ func formatDuration(d time.Duration) string {
 func GetIntegritySummary(c *gin.Context) {
 	taskID := c.Param("taskId")
 	if taskID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "task_id is required"})
+		respondError(c, http.StatusBadRequest, "task_id is required")
 		return
 	}
 
 	// Get integrity manager from task manager's state manager
 	dbManager, ok := taskManager.stateManager.(*state.DBStateManager)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "integrity not available"})
+		respondError(c, http.StatusInternalServerError, "integrity not available")
 		return
 	}
 
 	integrityManager := state.NewIntegrityManager(dbManager.GetDB())
 	summary, err := integrityManager.GetIntegritySummary(taskID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -1668,20 +1993,20 @@ func GetIntegritySummary(c *gin.Context) {
 func GetIntegrityReport(c *gin.Context) {
 	taskID := c.Param("taskId")
 	if taskID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "task_id is required"})
+		respondError(c, http.StatusBadRequest, "task_id is required")
 		return
 	}
 
 	dbManager, ok := taskManager.stateManager.(*state.DBStateManager)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "integrity not available"})
+		respondError(c, http.StatusInternalServerError, "integrity not available")
 		return
 	}
 
 	integrityManager := state.NewIntegrityManager(dbManager.GetDB())
 	report, err := integrityManager.GetIntegrityReport(taskID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -1692,7 +2017,7 @@ func GetIntegrityReport(c *gin.Context) {
 func GetFailedIntegrityObjects(c *gin.Context) {
 	taskID := c.Param("taskId")
 	if taskID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "task_id is required"})
+		respondError(c, http.StatusBadRequest, "task_id is required")
 		return
 	}
 
@@ -1704,14 +2029,14 @@ func GetFailedIntegrityObjects(c *gin.Context) {
 
 	dbManager, ok := taskManager.stateManager.(*state.DBStateManager)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "integrity not available"})
+		respondError(c, http.StatusInternalServerError, "integrity not available")
 		return
 	}
 
 	integrityManager := state.NewIntegrityManager(dbManager.GetDB())
 	failures, err := integrityManager.GetFailedIntegrityObjects(taskID, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -1721,3 +2046,23 @@ func GetFailedIntegrityObjects(c *gin.Context) {
 		"failures": failures,
 	})
 }
+
+// GetETagCompatibility returns how copies between providers are verified: the
+// matrix entry of ?source=&dest=, or the whole matrix without them
+func GetETagCompatibility(c *gin.Context) {
+	source, dest := c.Query("source"), c.Query("dest")
+	if source == "" && dest == "" {
+		c.JSON(http.StatusOK, gin.H{"matrix": integrity.Matrix()})
+		return
+	}
+	for _, provider := range []string{source, dest} {
+		if !slices.Contains(integrity.Providers, integrity.ProviderType(provider)) {
+			respondErrorCode(c, http.StatusBadRequest, codeInvalidRequest,
+				fmt.Sprintf("unknown provider %q; source and dest are both required", provider),
+				gin.H{"providers": integrity.Providers})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, integrity.CompatibilityOf(integrity.ProviderType(source), integrity.ProviderType(dest)))
+}
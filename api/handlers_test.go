@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/core"
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+	"s3migration/pkg/state"
+)
+
+// testRouter installs a task manager backed by in-memory state whose S3 tasks run
+// against endpoint, and returns a router with the task endpoints
+func testRouter(t *testing.T, endpoint *fakes3.Server) *gin.Engine {
+	t.Helper()
+	previous := taskManager
+	t.Cleanup(func() {
+		taskManager.stopTasks() // Tasks of the test must not outlive its manager
+		taskManager = previous
+	})
+
+	taskManager = newTaskManager(state.NewMemoryStateManager())
+	taskManager.newMigrator = func(ctx context.Context, cfg core.EnhancedMigratorConfig) (*core.EnhancedMigrator, error) {
+		cfg.ConnectionPool = pool.NewStaticConnectionPool(endpoint.Client())
+		return core.NewEnhancedMigrator(ctx, cfg)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/migrate", StartMigration)
+	router.GET("/api/status/:taskID", GetStatus)
+	router.GET("/api/tasks", ListTasks)
+	return router
+}
+
+func serve(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return recorder
+}
+
+func TestStartMigrationRunsToCompletion(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	endpoint.Put("source", "a.txt", []byte("alpha"))
+	endpoint.Put("source", "b.txt", []byte("bravo"))
+	router := testRouter(t, endpoint)
+
+	resp := serve(router, http.MethodPost, "/api/migrate", `{"source_bucket": "source", "dest_bucket": "dest"}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("POST /api/migrate = %d: %s", resp.Code, resp.Body)
+	}
+	var status models.MigrationStatus
+	json.Unmarshal(resp.Body.Bytes(), &status)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for status.Status != "completed" {
+		if time.Now().After(deadline) || status.Status == "failed" {
+			t.Fatalf("task did not complete: %+v", status)
+		}
+		time.Sleep(20 * time.Millisecond)
+		resp = serve(router, http.MethodGet, "/api/status/"+status.TaskID, "")
+		json.Unmarshal(resp.Body.Bytes(), &status)
+	}
+	if status.CopiedObjects != 2 {
+		t.Fatalf("copied %d objects, want 2", status.CopiedObjects)
+	}
+	if keys := endpoint.Keys("dest"); len(keys) != 2 {
+		t.Fatalf("dest keys = %v", keys)
+	}
+}
+
+func TestStatusFallsBackToStateManager(t *testing.T) {
+	endpoint := fakes3.New()
+	defer endpoint.Close()
+	router := testRouter(t, endpoint)
+
+	// A task finished by another pod is only in the shared state
+	taskManager.stateManager.SaveTask(&state.TaskState{ID: "elsewhere", Status: "completed", CopiedObjects: 7})
+
+	resp := serve(router, http.MethodGet, "/api/status/elsewhere", "")
+	var status models.MigrationStatus
+	json.Unmarshal(resp.Body.Bytes(), &status)
+	if resp.Code != http.StatusOK || status.Status != "completed" || status.CopiedObjects != 7 {
+		t.Fatalf("GET /api/status/elsewhere = %d %+v", resp.Code, status)
+	}
+	if resp := serve(router, http.MethodGet, "/api/status/missing", ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("GET /api/status/missing = %d, want 404", resp.Code)
+	}
+
+	var ids []string
+	json.Unmarshal(serve(router, http.MethodGet, "/api/tasks", "").Body.Bytes(), &ids)
+	if len(ids) != 1 || ids[0] != "elsewhere" {
+		t.Fatalf("GET /api/tasks = %v", ids)
+	}
+}
+
+func TestValidationErrorsFollowAcceptLanguage(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	router := testRouter(t, endpoint)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/migrate", strings.NewReader(`{"source_bucket": "source"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "vi-VN,vi;q=0.9,en;q=0.8")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadRequest || !strings.Contains(resp.Body.String(), "dest_bucket là bắt buộc") || resp.Header().Get("Content-Language") != "vi" {
+		t.Errorf("vi: %d %s", resp.Code, resp.Body)
+	}
+
+	resp = serve(router, http.MethodPost, "/api/migrate", `{"source_bucket": "source"}`)
+	if !strings.Contains(resp.Body.String(), "dest_bucket is required") {
+		t.Errorf("en: %d %s", resp.Code, resp.Body)
+	}
+}
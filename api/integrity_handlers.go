@@ -26,13 +26,13 @@ func NewIntegrityHandlers(integrityManager *state.IntegrityManager) *IntegrityHa
 func (h *IntegrityHandlers) GetIntegritySummary(c *gin.Context) {
 	taskID := c.Param("taskId")
 	if taskID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "task_id is required"})
+		respondError(c, http.StatusBadRequest, "task_id is required")
 		return
 	}
 
 	summary, err := h.integrityManager.GetIntegritySummary(taskID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -44,13 +44,13 @@ func (h *IntegrityHandlers) GetIntegritySummary(c *gin.Context) {
 func (h *IntegrityHandlers) GetIntegrityReport(c *gin.Context) {
 	taskID := c.Param("taskId")
 	if taskID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "task_id is required"})
+		respondError(c, http.StatusBadRequest, "task_id is required")
 		return
 	}
 
 	report, err := h.integrityManager.GetIntegrityReport(taskID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -62,7 +62,7 @@ func (h *IntegrityHandlers) GetIntegrityReport(c *gin.Context) {
 func (h *IntegrityHandlers) GetFailedIntegrityObjects(c *gin.Context) {
 	taskID := c.Param("taskId")
 	if taskID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "task_id is required"})
+		respondError(c, http.StatusBadRequest, "task_id is required")
 		return
 	}
 
@@ -76,7 +76,7 @@ func (h *IntegrityHandlers) GetFailedIntegrityObjects(c *gin.Context) {
 
 	failures, err := h.integrityManager.GetFailedIntegrityObjects(taskID, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/models"
+	"s3migration/pkg/scheduler"
+)
+
+// Export formats of the task and schedule lists
+const (
+	exportCSV   = "csv"
+	exportExcel = "excel" // CSV with a UTF-8 byte order mark, which Excel needs to read non-ASCII text
+)
+
+// taskExportColumns is the header row of GET /api/tasks/export
+var taskExportColumns = []string{
+	"task_id", "migration_type", "status", "source_bucket", "source_prefix", "dest_bucket", "dest_prefix",
+	"dry_run", "start_time", "end_time", "duration_seconds", "total_objects", "copied_objects",
+	"failed_objects", "total_bytes", "copied_bytes", "error_count", "notes",
+}
+
+// scheduleExportColumns is the header row of GET /api/schedules/export
+var scheduleExportColumns = []string{
+	"schedule_id", "name", "type", "enabled", "cron_expr", "source_bucket", "source_prefix",
+	"dest_bucket", "dest_prefix", "last_run", "next_run", "run_count", "fail_count", "health",
+}
+
+// taskExportRow is one task of the export; tasks only in the database lack prefixes and failure counts
+type taskExportRow struct {
+	id, migrationType, status                          string
+	sourceBucket, sourcePrefix, destBucket, destPrefix string
+	dryRun                                             bool
+	start, end                                         time.Time
+	totalObjects, copiedObjects, failedObjects         int64
+	totalBytes, copiedBytes                            int64
+	errorCount                                         int
+	notes                                              []models.TaskNote
+}
+
+func (r taskExportRow) record(now time.Time) []string {
+	// Running tasks count up to now
+	var duration string
+	if !r.start.IsZero() {
+		end := r.end
+		if end.IsZero() {
+			end = now
+		}
+		duration = strconv.FormatInt(int64(end.Sub(r.start).Seconds()), 10)
+	}
+	return []string{
+		spreadsheetText(r.id), r.migrationType, r.status,
+		r.sourceBucket, spreadsheetText(r.sourcePrefix), r.destBucket, spreadsheetText(r.destPrefix),
+		strconv.FormatBool(r.dryRun), formatExportTime(r.start), formatExportTime(r.end), duration,
+		strconv.FormatInt(r.totalObjects, 10), strconv.FormatInt(r.copiedObjects, 10), strconv.FormatInt(r.failedObjects, 10),
+		strconv.FormatInt(r.totalBytes, 10), strconv.FormatInt(r.copiedBytes, 10), strconv.Itoa(r.errorCount),
+		spreadsheetText(formatExportNotes(r.notes)),
+	}
+}
+
+// formatExportNotes puts each note on its own line as "<time> <author>: <text>"
+func formatExportNotes(notes []models.TaskNote) string {
+	lines := make([]string, len(notes))
+	for i, note := range notes {
+		lines[i] = fmt.Sprintf("%s %s: %s", formatExportTime(note.Time), note.Author, note.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exportFormat reads the format query parameter
+func exportFormat(c *gin.Context) (string, bool) {
+	format := c.DefaultQuery("format", exportCSV)
+	if format != exportCSV && format != exportExcel {
+		respondError(c, http.StatusBadRequest, "format must be csv or excel")
+		return "", false
+	}
+	return format, true
+}
+
+// spreadsheetText keeps user-supplied text from being evaluated as a formula
+// when the export is opened in a spreadsheet
+func spreadsheetText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// formatExportTime formats a timestamp as RFC3339 in UTC, or empty for the zero time
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// writeExport sends rows as a CSV attachment named after what and today's date
+func writeExport(c *gin.Context, format, what string, header []string, rows [][]string) {
+	var body bytes.Buffer
+	if format == exportExcel {
+		body.WriteString("\ufeff")
+	}
+	w := csv.NewWriter(&body)
+	w.Write(header)
+	w.WriteAll(rows) // Flushes; writes to a buffer cannot fail
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.csv", what, time.Now().UTC().Format("20060102")))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", body.Bytes())
+}
+
+// exportTaskRows lists the tasks in memory and in the database, oldest first;
+// a task in memory is exported from its live status
+func exportTaskRows() []taskExportRow {
+	taskManager.mu.RLock()
+	rows := make([]taskExportRow, 0, len(taskManager.tasks))
+	seen := make(map[string]bool, len(taskManager.tasks))
+	for _, task := range taskManager.tasks {
+		status := task.status()
+		req := task.OriginalRequest
+		var failed int64
+		if task.Result != nil {
+			failed = task.Result.Failed
+		}
+		seen[task.ID] = true
+		rows = append(rows, taskExportRow{
+			id: task.ID, migrationType: status.MigrationType, status: status.Status,
+			sourceBucket: req.SourceBucket, sourcePrefix: req.SourcePrefix,
+			destBucket: req.DestBucket, destPrefix: req.DestPrefix,
+			dryRun: status.DryRun, start: task.StartTime, end: status.EndTime,
+			totalObjects: status.TotalObjects, copiedObjects: status.CopiedObjects, failedObjects: failed,
+			totalBytes: status.TotalSize, copiedBytes: status.CopiedSize, errorCount: len(status.Errors),
+			notes: status.Notes,
+		})
+	}
+	taskManager.mu.RUnlock()
+
+	if taskManager.stateManager != nil {
+		stored, err := taskManager.stateManager.ListTasks()
+		if err != nil {
+			fmt.Printf("⚠️  Task export without stored tasks: %v\n", err)
+		}
+		for _, taskState := range stored {
+			if seen[taskState.ID] {
+				continue
+			}
+			row := taskExportRow{
+				id: taskState.ID, migrationType: taskState.MigrationType, status: taskState.Status,
+				dryRun: taskState.DryRun, start: taskState.StartTime,
+				totalObjects: taskState.TotalObjects, copiedObjects: taskState.CopiedObjects,
+				totalBytes: taskState.TotalSize, copiedBytes: taskState.CopiedSize, errorCount: len(taskState.Errors),
+				notes: taskState.Notes,
+			}
+			if taskState.EndTime != nil {
+				row.end = *taskState.EndTime
+			}
+			row.sourceBucket, _ = taskState.OriginalRequest["source_bucket"].(string)
+			row.destBucket, _ = taskState.OriginalRequest["dest_bucket"].(string)
+			rows = append(rows, row)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].start.Equal(rows[j].start) {
+			return rows[i].start.Before(rows[j].start)
+		}
+		return rows[i].id < rows[j].id
+	})
+	return rows
+}
+
+// ExportTasks handles GET /api/tasks/export
+// @Summary Export the task list
+// @Description Every task with its status, buckets, object and byte counts, duration and error count, as a CSV spreadsheet
+// @Tags migration
+// @Produce text/csv
+// @Param format query string false "csv (default) or excel"
+// @Success 200 {string} string
+// @Failure 400 {object} ErrorResponse
+// @Router /api/tasks/export [get]
+func ExportTasks(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	tasks := exportTaskRows()
+	rows := make([][]string, 0, len(tasks))
+	for _, task := range tasks {
+		rows = append(rows, task.record(now))
+	}
+	writeExport(c, format, "tasks", taskExportColumns, rows)
+}
+
+// ExportSchedules handles GET /api/schedules/export
+// @Summary Export the schedule list
+// @Description Every schedule with its buckets, run times, run and failure counts and health, as a CSV spreadsheet
+// @Tags schedules
+// @Produce text/csv
+// @Param format query string false "csv (default) or excel"
+// @Success 200 {string} string
+// @Failure 400 {object} ErrorResponse
+// @Router /api/schedules/export [get]
+func ExportSchedules(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+	var rows [][]string
+	if scheduleManager != nil {
+		health := make(map[string]string)
+		for _, schedule := range scheduleManager.Health(time.Now(), scheduleHealthPolicy()).Schedules {
+			health[schedule.ID] = schedule.Status
+		}
+		schedules := scheduleManager.ListSchedules()
+		sort.Slice(schedules, func(i, j int) bool { return schedules[i].ID < schedules[j].ID })
+		for _, schedule := range schedules {
+			rows = append(rows, scheduleRecord(schedule, health[schedule.ID]))
+		}
+	}
+	writeExport(c, format, "schedules", scheduleExportColumns, rows)
+}
+
+func scheduleRecord(schedule *scheduler.Schedule, health string) []string {
+	scheduleType := schedule.Type
+	if scheduleType == "" {
+		scheduleType = scheduler.TypeSync
+	}
+	return []string{
+		spreadsheetText(schedule.ID), spreadsheetText(schedule.Name), scheduleType, strconv.FormatBool(schedule.Enabled), spreadsheetText(schedule.CronExpr),
+		schedule.Source.Bucket, spreadsheetText(schedule.Source.Prefix), schedule.Destination.Bucket, spreadsheetText(schedule.Destination.Prefix),
+		formatExportTime(schedule.LastRun), formatExportTime(schedule.NextRun),
+		strconv.Itoa(schedule.RunCount), strconv.Itoa(schedule.FailCount), health,
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+	"s3migration/pkg/state"
+)
+
+func TestExportTasks(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	endpoint.Put("source", "logs/a.txt", []byte("alpha"))
+	router := testRouter(t, endpoint)
+	router.GET("/api/tasks/export", ExportTasks)
+
+	resp := serve(router, http.MethodPost, "/api/migrate", `{"source_bucket": "source", "dest_bucket": "dest", "source_prefix": "logs/", "dest_prefix": "=cmd"}`)
+	var status models.MigrationStatus
+	json.Unmarshal(resp.Body.Bytes(), &status)
+	waitForStatus(t, router, status.TaskID, func(s models.MigrationStatus) bool { return s.Status == "completed" })
+
+	// Finished by another pod, only in the shared state
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	end := start.Add(90 * time.Second)
+	taskManager.stateManager.SaveTask(&state.TaskState{
+		ID: "elsewhere", Status: "failed", MigrationType: "s3", StartTime: start, EndTime: &end,
+		TotalObjects: 4, CopiedObjects: 3, TotalSize: 40, CopiedSize: 30, Errors: []string{"denied"},
+		OriginalRequest: map[string]interface{}{"source_bucket": "old", "dest_bucket": "new"},
+	})
+
+	if resp := serve(router, http.MethodGet, "/api/tasks/export?format=xlsx", ""); resp.Code != http.StatusBadRequest {
+		t.Errorf("format=xlsx: got %d", resp.Code)
+	}
+	resp = serve(router, http.MethodGet, "/api/tasks/export?format=excel", "")
+	body := resp.Body.String()
+	if resp.Code != http.StatusOK || !strings.HasPrefix(body, "\ufeff") {
+		t.Fatalf("got %d %q", resp.Code, body)
+	}
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(body, "\ufeff"))).ReadAll()
+	if err != nil || len(records) != 3 {
+		t.Fatalf("got %v records, %v", records, err)
+	}
+	column := func(record []string, name string) string {
+		for i, header := range taskExportColumns {
+			if header == name {
+				return record[i]
+			}
+		}
+		t.Fatalf("no column %s", name)
+		return ""
+	}
+
+	stored, live := records[1], records[2] // Oldest first
+	for name, want := range map[string]string{
+		"task_id": "elsewhere", "status": "failed", "source_bucket": "old", "dest_bucket": "new",
+		"start_time": "2020-01-02T03:04:05Z", "duration_seconds": "90", "copied_bytes": "30", "error_count": "1",
+	} {
+		if got := column(stored, name); got != want {
+			t.Errorf("stored task %s = %q, want %q", name, got, want)
+		}
+	}
+	for name, want := range map[string]string{
+		"task_id": status.TaskID, "status": "completed", "source_prefix": "logs/", "dest_prefix": "'=cmd",
+		"copied_objects": "1", "copied_bytes": "5", "failed_objects": "0",
+	} {
+		if got := column(live, name); got != want {
+			t.Errorf("live task %s = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/prefetch"
+	"s3migration/pkg/state"
+)
+
+// defaultListingCacheTTL is how long a cached destination listing is reused
+const defaultListingCacheTTL = time.Hour
+
+// listingStore returns the persistent listing cache, or nil without a database
+func listingStore() *state.ListingCacheManager {
+	if taskManager == nil {
+		return nil
+	}
+	dbManager, ok := taskManager.stateManager.(*state.DBStateManager)
+	if !ok {
+		return nil
+	}
+	return state.NewListingCacheManager(dbManager.GetDB())
+}
+
+// listingStoreFor returns the listing store as the interface the migrator takes;
+// a nil manager must not become a non-nil interface
+func listingStoreFor() prefetch.ListingStore {
+	if store := listingStore(); store != nil {
+		return store
+	}
+	return nil
+}
+
+// listingCacheTTL reads LISTING_CACHE_TTL (e.g. "30m"; "0" never reuses cached listings)
+func listingCacheTTL() time.Duration {
+	value := os.Getenv("LISTING_CACHE_TTL")
+	if value == "" {
+		return defaultListingCacheTTL
+	}
+	if value == "0" {
+		return 0
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		fmt.Printf("⚠️  Invalid LISTING_CACHE_TTL=%q, using %v\n", value, defaultListingCacheTTL)
+		return defaultListingCacheTTL
+	}
+	return ttl
+}
+
+// ListCachedListings handles GET /api/cache/listings
+// @Summary List cached destination listings
+// @Description List the bucket/prefix listings reused by incremental comparisons
+// @Tags cache
+// @Produce json
+// @Param bucket query string false "Only listings of this bucket"
+// @Success 200 {object} gin.H
+// @Failure 500 {object} ErrorResponse
+// @Router /api/cache/listings [get]
+func ListCachedListings(c *gin.Context) {
+	store := listingStore()
+	if store == nil {
+		respondError(c, http.StatusInternalServerError, "listing cache not available")
+		return
+	}
+
+	entries, err := store.ListListings(c.Query("bucket"))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if entries == nil {
+		entries = []state.ListingCacheEntry{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"listings": entries,
+		"count":    len(entries),
+		"ttl":      listingCacheTTL().String(),
+	})
+}
+
+// InvalidateCachedListings handles DELETE /api/cache/listings
+// @Summary Invalidate cached destination listings
+// @Description Drop cached listings so the next run lists the destination again. Without prefix, every listing of the bucket is invalidated.
+// @Tags cache
+// @Produce json
+// @Param bucket query string true "Bucket"
+// @Param prefix query string false "Prefix; requires endpoint_url to identify the listing"
+// @Param endpoint_url query string false "Endpoint of the bucket (empty for AWS)"
+// @Success 200 {object} gin.H
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/cache/listings [delete]
+func InvalidateCachedListings(c *gin.Context) {
+	bucket := c.Query("bucket")
+	if bucket == "" {
+		respondError(c, http.StatusBadRequest, "bucket is required")
+		return
+	}
+	store := listingStore()
+	if store == nil {
+		respondError(c, http.StatusInternalServerError, "listing cache not available")
+		return
+	}
+
+	if _, hasPrefix := c.GetQuery("prefix"); !hasPrefix {
+		invalidated, err := store.InvalidateBucket(bucket)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"bucket": bucket, "invalidated": invalidated})
+		return
+	}
+
+	scope := prefetch.ListingScope(c.Query("endpoint_url"), bucket, c.Query("prefix"))
+	generation, err := store.InvalidateListing(scope)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"scope": scope, "generation": generation, "invalidated": 1})
+}
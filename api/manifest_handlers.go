@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/core"
+)
+
+// maxRcloneCheckBody limits the rclone check output accepted by ImportRcloneCheck
+const maxRcloneCheckBody = 64 << 20
+
+// GetTransferManifest handles GET /api/tasks/:taskId/manifest
+// @Summary Export copied or failed keys
+// @Description Export the keys a migration copied or failed to copy as a file list for rclone copy --files-from-raw or aws s3 cp/sync --include arguments
+// @Tags migration
+// @Produce plain
+// @Param taskId path string true "Task ID"
+// @Param list query string false "copied or failed (default: failed)"
+// @Param format query string false "rclone, aws or json (default: rclone)"
+// @Success 200 {string} string
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/tasks/{taskId}/manifest [get]
+func GetTransferManifest(c *gin.Context) {
+	taskID := c.Param("taskId")
+
+	list := c.DefaultQuery("list", "failed")
+	if list != "copied" && list != "failed" {
+		respondError(c, http.StatusBadRequest, "list must be copied or failed")
+		return
+	}
+	format := core.ManifestFormat(c.DefaultQuery("format", string(core.ManifestRclone)))
+	if format != core.ManifestRclone && format != core.ManifestAWS && format != "json" {
+		respondError(c, http.StatusBadRequest, "format must be rclone, aws or json")
+		return
+	}
+
+	taskManager.mu.RLock()
+	task, exists := taskManager.tasks[taskID]
+	var manifest *core.TransferManifest
+	if exists {
+		manifest = task.Manifest
+	}
+	taskManager.mu.RUnlock()
+
+	if !exists {
+		respondError(c, http.StatusNotFound, "Task not found")
+		return
+	}
+	if manifest == nil {
+		respondError(c, http.StatusNotFound, "No transfer manifest for this task (kept in memory for finished S3 migrations)")
+		return
+	}
+	if format == "json" {
+		c.JSON(http.StatusOK, manifest)
+		return
+	}
+
+	// The manifest is immutable once stored, so it can be read without the lock
+	keys := manifest.Failed
+	if list == "copied" {
+		keys = manifest.Copied
+	}
+	var body bytes.Buffer
+	omitted, err := core.WriteManifest(&body, format, keys, manifest.SourcePrefix)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s-%s.txt", taskID, list, format))
+	c.Header("X-Manifest-Omitted", strconv.Itoa(omitted)) // Keys with a newline, which neither tool can list
+	c.Header("X-Manifest-Truncated", strconv.FormatBool(manifest.Truncated))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", body.Bytes())
+}
+
+// ImportRcloneCheck handles POST /api/manifests/rclone-check
+// @Summary Import rclone check output
+// @Description Parse the output of rclone check --combined (or a plain --missing-on-dst list) into the files_from list of a migration that copies what is still missing or different
+// @Tags migration
+// @Accept plain
+// @Produce json
+// @Success 200 {object} gin.H
+// @Failure 400 {object} ErrorResponse
+// @Router /api/manifests/rclone-check [post]
+func ImportRcloneCheck(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxRcloneCheckBody+1))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(body) > maxRcloneCheckBody {
+		respondError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("rclone check output larger than %d MiB", maxRcloneCheckBody>>20))
+		return
+	}
+	check, err := core.ParseRcloneCheck(bytes.NewReader(body))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"identical":         len(check.Identical),
+		"missing_on_dest":   len(check.MissingOnDest),
+		"missing_on_source": len(check.MissingOnSource),
+		"differ":            len(check.Differ),
+		"errors":            len(check.Errors),
+		"files_from":        check.FilesFrom(),
+	})
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+)
+
+func TestFilesFromAndManifestExport(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	for _, key := range []string{"data/a.txt", "data/b.txt", "data/dir/c d.txt"} {
+		endpoint.Put("source", key, []byte("content of "+key))
+	}
+	router := testRouter(t, endpoint)
+	router.GET("/api/tasks/:taskId/manifest", GetTransferManifest)
+	router.POST("/api/manifests/rclone-check", ImportRcloneCheck)
+
+	// rclone check found two files still to copy
+	resp := serve(router, http.MethodPost, "/api/manifests/rclone-check", "= b.txt\n+ a.txt\n* dir/c d.txt\n")
+	var imported struct {
+		Identical int      `json:"identical"`
+		FilesFrom []string `json:"files_from"`
+	}
+	if resp.Code != http.StatusOK || json.Unmarshal(resp.Body.Bytes(), &imported) != nil || imported.Identical != 1 {
+		t.Fatalf("POST /api/manifests/rclone-check = %d: %s", resp.Code, resp.Body)
+	}
+
+	request, _ := json.Marshal(map[string]interface{}{
+		"source_bucket": "source",
+		"source_prefix": "data/",
+		"dest_bucket":   "dest",
+		"files_from":    imported.FilesFrom,
+	})
+	resp = serve(router, http.MethodPost, "/api/migrate", string(request))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("POST /api/migrate = %d: %s", resp.Code, resp.Body)
+	}
+	var status models.MigrationStatus
+	json.Unmarshal(resp.Body.Bytes(), &status)
+	deadline := time.Now().Add(10 * time.Second)
+	for status.Status != "completed" {
+		if time.Now().After(deadline) || status.Status == "failed" {
+			t.Fatalf("task did not complete: %+v", status)
+		}
+		time.Sleep(20 * time.Millisecond)
+		resp = serve(router, http.MethodGet, "/api/status/"+status.TaskID, "")
+		json.Unmarshal(resp.Body.Bytes(), &status)
+	}
+	if keys := endpoint.Keys("dest"); len(keys) != 2 {
+		t.Fatalf("dest keys = %v, want the two files from the list", keys)
+	}
+
+	resp = serve(router, http.MethodGet, "/api/tasks/"+status.TaskID+"/manifest?list=copied&format=aws", "")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("GET manifest = %d: %s", resp.Code, resp.Body)
+	}
+	lines := strings.Split(strings.TrimSpace(resp.Body.String()), "\n")
+	sort.Strings(lines)
+	if got := strings.Join(lines, "|"); got != `--include a.txt|--include dir/c\ d.txt` {
+		t.Fatalf("aws manifest = %q", got)
+	}
+
+	resp = serve(router, http.MethodGet, "/api/tasks/"+status.TaskID+"/manifest", "")
+	if resp.Code != http.StatusOK || resp.Body.String() != "" {
+		t.Fatalf("failed list = %d: %q, want empty", resp.Code, resp.Body)
+	}
+	if resp = serve(router, http.MethodGet, "/api/tasks/"+status.TaskID+"/manifest?format=csv", ""); resp.Code != http.StatusBadRequest {
+		t.Fatalf("unsupported format = %d", resp.Code)
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"s3migration/pkg/metricspush"
+)
+
+// defaultMetricsPushInterval is how often metrics are pushed when
+// METRICS_PUSH_INTERVAL is not set
+const defaultMetricsPushInterval = time.Minute
+
+// metricsPusherFromEnv returns the metrics push METRICS_PUSH selects and its
+// interval (METRICS_PUSH_INTERVAL), or nil without METRICS_PUSH:
+//   - "pushgateway" pushes to the Prometheus push gateway at METRICS_PUSHGATEWAY_URL,
+//     under job METRICS_PUSH_JOB (default s3-migration) and the pod's host name as instance
+//   - "emf" writes CloudWatch EMF records in namespace METRICS_EMF_NAMESPACE (default
+//     S3Migration) to stdout, or to the CloudWatch agent at METRICS_EMF_ADDRESS
+func metricsPusherFromEnv() (metricspush.Pusher, time.Duration) {
+	interval := defaultMetricsPushInterval
+	if value := os.Getenv("METRICS_PUSH_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			fmt.Printf("⚠️  Invalid METRICS_PUSH_INTERVAL=%q, using %v\n", value, interval)
+		} else {
+			interval = parsed
+		}
+	}
+
+	instance, _ := os.Hostname()
+	switch target := os.Getenv("METRICS_PUSH"); target {
+	case "":
+		return nil, interval
+	case "pushgateway":
+		gatewayURL := os.Getenv("METRICS_PUSHGATEWAY_URL")
+		if gatewayURL == "" {
+			fmt.Printf("⚠️  METRICS_PUSH=pushgateway needs METRICS_PUSHGATEWAY_URL; metrics are not pushed\n")
+			return nil, interval
+		}
+		job := os.Getenv("METRICS_PUSH_JOB")
+		if job == "" {
+			job = "s3-migration"
+		}
+		fmt.Printf("✅ Pushing metrics to %s every %v\n", gatewayURL, interval)
+		return &metricspush.PushGateway{URL: gatewayURL, Job: job, Instance: instance}, interval
+	case "emf":
+		namespace := os.Getenv("METRICS_EMF_NAMESPACE")
+		if namespace == "" {
+			namespace = "S3Migration"
+		}
+		fmt.Printf("✅ Writing CloudWatch EMF metrics to namespace %s every %v\n", namespace, interval)
+		return &metricspush.EMF{
+			Namespace:  namespace,
+			Dimensions: map[string]string{"Instance": instance},
+			Address:    os.Getenv("METRICS_EMF_ADDRESS"),
+			Output:     os.Stdout,
+		}, interval
+	default:
+		fmt.Printf("⚠️  Invalid METRICS_PUSH=%q (expected pushgateway or emf); metrics are not pushed\n", target)
+		return nil, interval
+	}
+}
+
+// metricsSnapshot sums the state of the tasks of this server
+func (tm *TaskManager) metricsSnapshot() metricspush.Snapshot {
+	tm.mu.RLock()
+	tasks := make([]*TaskInfo, 0, len(tm.tasks))
+	for _, task := range tm.tasks {
+		tasks = append(tasks, task)
+	}
+	tm.mu.RUnlock()
+
+	snapshot := metricspush.Snapshot{Time: time.Now()}
+	for _, task := range tasks {
+		status := task.status()
+		switch status.Status {
+		case "pending", "running":
+			snapshot.ActiveTasks++
+			snapshot.CopiedObjects += status.CopiedObjects
+			snapshot.CopiedBytes += status.CopiedSize
+			snapshot.TaskErrors += int64(len(status.Errors))
+			if status.Status == "running" {
+				snapshot.ThroughputBytes += status.CurrentSpeed * 1024 * 1024 // CurrentSpeed is in MB/s
+			}
+		case "failed":
+			snapshot.FailedTasks++
+		}
+	}
+	return snapshot
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// Default CORS settings, used when the corresponding environment variable is unset
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Origin", "Content-Type", "Authorization", headerAPIKey, headerAccessKey, headerSecretKey}
+)
+
+// envList splits a comma-separated environment variable, returning fallback if unset
+func envList(name string, fallback []string) []string {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return fallback
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// corsConfigFromEnv builds the CORS configuration:
+//
+//	CORS_ALLOWED_ORIGINS    comma-separated origins, "*" for any (default: *);
+//	                        entries may use one wildcard, e.g. https://*.example.com
+//	CORS_ALLOWED_METHODS    comma-separated methods (default: GET,POST,PUT,PATCH,DELETE,OPTIONS)
+//	CORS_ALLOWED_HEADERS    extra request headers to allow on top of the defaults
+//	CORS_ALLOW_CREDENTIALS  "true" to allow cookies/Authorization (requires explicit origins)
+//	CORS_MAX_AGE            preflight cache duration, e.g. "12h" (default: 12h)
+func corsConfigFromEnv() cors.Config {
+	config := cors.DefaultConfig()
+	config.AllowMethods = envList("CORS_ALLOWED_METHODS", defaultCORSMethods)
+	config.AllowHeaders = append(append([]string{}, defaultCORSHeaders...), envList("CORS_ALLOWED_HEADERS", nil)...)
+
+	origins := envList("CORS_ALLOWED_ORIGINS", []string{"*"})
+	for _, origin := range origins {
+		if origin == "*" {
+			origins = nil
+			break
+		}
+	}
+	if len(origins) == 0 {
+		config.AllowAllOrigins = true
+	} else {
+		config.AllowOrigins = origins
+		config.AllowWildcard = true
+	}
+
+	if value := os.Getenv("CORS_ALLOW_CREDENTIALS"); value != "" {
+		if allow, err := strconv.ParseBool(value); err == nil {
+			config.AllowCredentials = allow
+		}
+	}
+	if config.AllowCredentials && config.AllowAllOrigins {
+		// Browsers reject credentialed responses with "Access-Control-Allow-Origin: *"
+		fmt.Printf("⚠️  CORS_ALLOW_CREDENTIALS ignored: requires explicit CORS_ALLOWED_ORIGINS\n")
+		config.AllowCredentials = false
+	}
+
+	if value := os.Getenv("CORS_MAX_AGE"); value != "" {
+		if maxAge, err := time.ParseDuration(value); err == nil {
+			config.MaxAge = maxAge
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		fmt.Printf("⚠️  Invalid CORS configuration (%v), allowing all origins\n", err)
+		config.AllowAllOrigins = true
+		config.AllowOrigins = nil
+		config.AllowWildcard = false
+		config.AllowCredentials = false
+	}
+
+	return config
+}
+
+// securityHeaders sets standard browser security headers on every response:
+//
+//	SECURITY_FRAME_OPTIONS  X-Frame-Options value (default: DENY)
+//	SECURITY_CSP            Content-Security-Policy (default: unset, the bundled UI uses inline scripts)
+//	SECURITY_HSTS           "true" to send Strict-Transport-Security (only behind HTTPS)
+func securityHeaders() gin.HandlerFunc {
+	frameOptions := os.Getenv("SECURITY_FRAME_OPTIONS")
+	if frameOptions == "" {
+		frameOptions = "DENY"
+	}
+	csp := os.Getenv("SECURITY_CSP")
+	hsts, _ := strconv.ParseBool(os.Getenv("SECURITY_HSTS"))
+
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", frameOptions)
+		h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		if csp != "" {
+			h.Set("Content-Security-Policy", csp)
+		}
+		if hsts {
+			h.Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+		}
+		// API responses may contain task details; keep them out of shared caches
+		if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			h.Set("Cache-Control", "no-store")
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"s3migration/pkg/progress"
+)
+
+// defaultProgressLogInterval is how often progress is logged when
+// PROGRESS_LOG_INTERVAL is not set
+const defaultProgressLogInterval = 10 * time.Second
+
+// progressLogs adds the progress logs set up in the environment to reporter:
+// PROGRESS_LOG_DIR appends each task's progress as JSON lines to <dir>/<task ID>.jsonl,
+// and PROGRESS_STDOUT=true prints a summary line. Both log at most once per
+// PROGRESS_LOG_INTERVAL (default 10s). The returned func closes the logs.
+func progressLogs(taskID string, reporter progress.Reporter) (progress.Reporter, func()) {
+	dir := os.Getenv("PROGRESS_LOG_DIR")
+	stdout, _ := strconv.ParseBool(os.Getenv("PROGRESS_STDOUT"))
+	if dir == "" && !stdout {
+		return reporter, func() {}
+	}
+
+	interval := defaultProgressLogInterval
+	if value := os.Getenv("PROGRESS_LOG_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			fmt.Printf("⚠️  Invalid PROGRESS_LOG_INTERVAL=%q, using %v\n", value, interval)
+		} else {
+			interval = parsed
+		}
+	}
+
+	reporters := []progress.Reporter{reporter}
+	closeLogs := func() {}
+	if stdout {
+		reporters = append(reporters, progress.NewPrinter(os.Stdout, taskID, interval))
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			fmt.Printf("⚠️  No progress log for %s: %v\n", taskID, err)
+		} else if file, err := progress.CreateJSONLinesFile(filepath.Join(dir, taskID+".jsonl"), interval); err != nil {
+			fmt.Printf("⚠️  No progress log for %s: %v\n", taskID, err)
+		} else {
+			reporters = append(reporters, file)
+			closeLogs = func() {
+				if err := file.Close(); err != nil {
+					fmt.Printf("⚠️  Closing the progress log of %s: %v\n", taskID, err)
+				}
+			}
+		}
+	}
+	return progress.Multi(reporters...), closeLogs
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// headerAPIKey carries the API key of a client
+const headerAPIKey = "X-API-Key"
+
+// envFloat reads a float environment variable, returning fallback if unset or invalid
+func envFloat(name string, fallback float64) float64 {
+	if value := os.Getenv(name); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed >= 0 {
+			return parsed
+		}
+		fmt.Printf("⚠️  Invalid %s=%q, using %v\n", name, value, fallback)
+	}
+	return fallback
+}
+
+// tokenBucket is one client's request allowance
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// clientRateLimiter applies a token bucket per client key
+type clientRateLimiter struct {
+	mu        sync.Mutex
+	rate      float64 // Tokens added per second
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+// bucketIdleTTL is how long an idle client's bucket is kept; a full bucket carries no state
+const bucketIdleTTL = 10 * time.Minute
+
+func newClientRateLimiter(rate, burst float64) *clientRateLimiter {
+	if burst < 1 {
+		burst = math.Max(1, rate)
+	}
+	return &clientRateLimiter{
+		rate:      rate,
+		burst:     burst,
+		buckets:   make(map[string]*tokenBucket),
+		lastPrune: time.Now(),
+	}
+}
+
+// allow takes one token for key. Returns the remaining tokens, or false and
+// how long until a token is available.
+func (rl *clientRateLimiter) allow(key string) (bool, int, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(rl.lastPrune) > bucketIdleTTL {
+		for k, b := range rl.buckets {
+			if now.Sub(b.lastSeen) > bucketIdleTTL {
+				delete(rl.buckets, k)
+			}
+		}
+		rl.lastPrune = now
+	}
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: rl.burst, lastSeen: now}
+		rl.buckets[key] = b
+	}
+
+	b.tokens = math.Min(rl.burst, b.tokens+now.Sub(b.lastSeen).Seconds()*rl.rate)
+	b.lastSeen = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rl.rate * float64(time.Second))
+		return false, 0, wait
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+// clientKey identifies the caller: a hash of the API key if sent, otherwise the client IP
+func clientKey(c *gin.Context) string {
+	if apiKey := c.GetHeader(headerAPIKey); apiKey != "" {
+		sum := sha256.Sum256([]byte(apiKey))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// rateLimitKey identifies the caller for rate limiting, which runs before
+// apiAuth: by API key only if it is one of keys, otherwise by client IP, so
+// made-up keys neither escape the limit nor each get a bucket
+func rateLimitKey(c *gin.Context, keys []string) string {
+	if credential := c.GetHeader(headerAPIKey); credential != "" && knownAPIKey(credential, keys) {
+		return clientKey(c)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// rateLimit limits each client to RATE_LIMIT_RPS requests per second with bursts
+// of RATE_LIMIT_BURST (defaults: 20 and 40). RATE_LIMIT_RPS=0 disables limiting.
+// Clients are told apart by API key when it is one of API_KEYS, else by IP.
+func rateLimit() gin.HandlerFunc {
+	rps := envFloat("RATE_LIMIT_RPS", 20)
+	if rps == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	limiter := newClientRateLimiter(rps, envFloat("RATE_LIMIT_BURST", rps*2))
+	limit := strconv.Itoa(int(limiter.burst))
+	keys := envList("API_KEYS", nil)
+
+	return func(c *gin.Context) {
+		allowed, remaining, wait := limiter.allow(rateLimitKey(c, keys))
+		c.Header("X-RateLimit-Limit", limit)
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			abortWithError(c, http.StatusTooManyRequests, "rate limit exceeded, retry later")
+			return
+		}
+		c.Next()
+	}
+}
+
+// maxBodySize caps request bodies at MAX_REQUEST_BODY_MB (default: 10 MiB);
+// reading past the limit fails the JSON bind with a body-too-large error
+func maxBodySize() gin.HandlerFunc {
+	limit := int64(envFloat("MAX_REQUEST_BODY_MB", 10) * 1024 * 1024)
+	if limit <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			abortWithError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", limit))
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+		c.Next()
+	}
+}
+
+// concurrencyLimit rejects requests beyond n in flight for the endpoints it wraps.
+// Used for handlers that list whole buckets, where queueing would only pile up work.
+func concurrencyLimit(n int) gin.HandlerFunc {
+	if n <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	slots := make(chan struct{}, n)
+
+	return func(c *gin.Context) {
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+			c.Next()
+		default:
+			c.Header("Retry-After", "5")
+			abortWithError(c, http.StatusServiceUnavailable, "too many concurrent requests to this endpoint, retry later")
+		}
+	}
+}
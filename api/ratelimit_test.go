@@ -0,0 +1,81 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientRateLimiterAllow(t *testing.T) {
+	limiter := newClientRateLimiter(10, 2)
+	for i, wantRemaining := range []int{1, 0} {
+		if allowed, remaining, _ := limiter.allow("a"); !allowed || remaining != wantRemaining {
+			t.Fatalf("request %d: allowed=%v remaining=%d", i, allowed, remaining)
+		}
+	}
+	allowed, _, wait := limiter.allow("a")
+	if allowed || wait <= 0 || wait > 100*time.Millisecond {
+		t.Fatalf("over the burst: allowed=%v wait=%v", allowed, wait)
+	}
+	if allowed, _, _ := limiter.allow("b"); !allowed {
+		t.Fatal("another client shares the exhausted bucket")
+	}
+
+	limiter.buckets["a"].lastSeen = time.Now().Add(-time.Second) // Refilled since
+	if allowed, remaining, _ := limiter.allow("a"); !allowed || remaining != 1 {
+		t.Fatalf("after refill: allowed=%v remaining=%d", allowed, remaining)
+	}
+}
+
+func TestClientRateLimiterPrunesIdleBuckets(t *testing.T) {
+	limiter := newClientRateLimiter(10, 2)
+	limiter.allow("idle")
+	limiter.buckets["idle"].lastSeen = time.Now().Add(-2 * bucketIdleTTL)
+	limiter.lastPrune = time.Now().Add(-2 * bucketIdleTTL)
+	limiter.allow("active")
+	if _, ok := limiter.buckets["idle"]; ok || len(limiter.buckets) != 1 {
+		t.Fatalf("buckets after pruning = %v", limiter.buckets)
+	}
+}
+
+func TestRateLimitKeysByKnownAPIKeysOnly(t *testing.T) {
+	t.Setenv("API_KEYS", "ops-key")
+	t.Setenv("RATE_LIMIT_RPS", "1")
+	t.Setenv("RATE_LIMIT_BURST", "2")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/tasks", rateLimit(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	request := func(apiKey string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/tasks", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if apiKey != "" {
+			req.Header.Set(headerAPIKey, apiKey)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	// Unknown keys share the bucket of the client IP
+	for i := 0; i < 2; i++ {
+		if code := request(fmt.Sprintf("guess-%d", i)); code != http.StatusOK {
+			t.Fatalf("request %d = %d", i, code)
+		}
+	}
+	if code := request("guess-2"); code != http.StatusTooManyRequests {
+		t.Fatalf("new made-up key after the burst = %d, want 429", code)
+	}
+	if code := request(""); code != http.StatusTooManyRequests {
+		t.Fatalf("no key after the burst = %d, want 429", code)
+	}
+
+	// A configured key has a bucket of its own
+	if code := request("ops-key"); code != http.StatusOK {
+		t.Fatalf("configured key = %d", code)
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"s3migration/pkg/core"
+	"s3migration/pkg/models"
+)
+
+// runReorganization runs a reorganization task: the objects under the source
+// prefix are moved to the destination prefix within the source bucket
+func runReorganization(ctx context.Context, taskID string, migrator *core.EnhancedMigrator, req models.MigrationRequest) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Panic in reorganization %s: %v\n", taskID, r)
+			taskManager.updateTask(taskID, func(task *TaskInfo) {
+				task.Status.Status = "failed"
+				task.Status.Errors = append(task.Status.Errors, fmt.Sprintf("Panic: %v", r))
+			})
+		}
+	}()
+
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
+		task.Status.Status = "running"
+	})
+
+	report, err := migrator.Reorganize(ctx, s3MigrateInput(taskID, req), req.Reorganize.DeleteOriginals)
+	requests := migrator.RequestCounts()
+	latency := migrator.Latencies()
+
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
+		switch {
+		case err != nil && errors.Is(ctx.Err(), context.Canceled):
+			task.Status.Status = "cancelled"
+		case err != nil:
+			task.Status.Status = "failed"
+			task.Status.Errors = append(task.Status.Errors, err.Error())
+		case report.CopyFailed > 0 || report.DeleteFailed > 0:
+			task.Status.Status = "completed_with_errors"
+			task.Status.Errors = append(task.Status.Errors, fmt.Sprintf("%d copies and %d deletes failed; see reorganize.samples", report.CopyFailed, report.DeleteFailed))
+		default:
+			task.Status.Status = "completed"
+			task.Status.Progress = 100
+		}
+		if report != nil {
+			task.Status.TotalObjects = report.Objects
+			task.Status.TotalSize = report.Bytes
+			task.Status.CopiedObjects = report.Copied
+			task.Status.Reorganize = report
+		}
+		task.Status.Phase = ""
+		task.Status.EndTime = time.Now()
+		task.Status.Duration = formatDuration(task.Status.EndTime.Sub(task.Status.StartTime))
+		task.Status.ETA = "0s"
+		task.Result = &models.MigrationResult{
+			TaskID:      taskID,
+			Success:     err == nil && task.Status.Status == "completed",
+			ElapsedTime: task.Status.Duration,
+			Errors:      task.Status.Errors,
+			Requests:    &requests,
+			Latency:     latency,
+		}
+		if report != nil {
+			task.Result.Copied = report.Copied
+			task.Result.Failed = report.CopyFailed + report.DeleteFailed
+		}
+	})
+	notifyTaskWebhook(taskID, &req)
+}
@@ -0,0 +1,329 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/models"
+	"s3migration/pkg/progress"
+	"s3migration/pkg/state"
+)
+
+// reportTimelineInterval is how often the progress of an S3 migration is
+// sampled for the timeline chart of its report
+const reportTimelineInterval = 15 * time.Second
+
+// maxReportErrorGroups bounds the distinct causes listed in a report's error summary
+const maxReportErrorGroups = 20
+
+// Size of the timeline chart in the report
+const (
+	chartWidth  = 720
+	chartHeight = 200
+)
+
+// taskReport is the data of a migration report
+type taskReport struct {
+	Generated    time.Time
+	Status       models.MigrationStatus
+	Result       *models.MigrationResult
+	Config       string // Request summary as indented JSON; holds no secrets
+	Chart        *timelineChart
+	ErrorCount   int
+	ErrorGroups  []errorGroup
+	MoreErrors   int // Causes beyond maxReportErrorGroups
+	Integrity    *state.IntegritySummary
+	IntegrityErr string
+}
+
+// timelineChart is the copied bytes of a task over time, drawn as an SVG polyline
+type timelineChart struct {
+	Width, Height int
+	Points        string // "x,y x,y ..." in chart coordinates
+	Start, End    time.Time
+	CopiedBytes   int64 // At the end of the chart, its top
+	CopiedObjects int64
+}
+
+// errorGroup is one cause of the task errors, with the number of errors and a
+// key it was reported for
+type errorGroup struct {
+	Cause   string
+	Count   int
+	Example string
+}
+
+// GetTaskReport handles GET /api/tasks/:taskId/report.html
+// @Summary Migration report
+// @Description Human-readable report of a migration for handing to stakeholders: configuration, timeline chart, totals, error summary, verification outcome and integrity statistics. The page is styled for printing, so a browser's Print to PDF produces the PDF version.
+// @Tags migration
+// @Produce html
+// @Param taskId path string true "Task ID"
+// @Success 200 {string} string
+// @Failure 404 {object} ErrorResponse
+// @Router /api/tasks/{taskId}/report.html [get]
+func GetTaskReport(c *gin.Context) {
+	taskID := c.Param("taskId")
+
+	report := taskReport{Generated: time.Now().UTC()}
+	var timeline *progress.Timeline
+	if task, exists := taskManager.getTask(taskID); exists {
+		report.Status = task.status()
+		taskManager.mu.RLock()
+		report.Result = task.Result
+		timeline = task.Timeline
+		taskManager.mu.RUnlock()
+	} else {
+		taskState, err := taskManager.stateManager.LoadTask(taskID)
+		if err != nil || taskState == nil {
+			respondError(c, http.StatusNotFound, "task not found")
+			return
+		}
+		report.Status = *storedStatus(taskState)
+	}
+
+	if report.Status.Request != nil {
+		if config, err := json.MarshalIndent(report.Status.Request, "", "  "); err == nil {
+			report.Config = string(config)
+		}
+	}
+	if timeline != nil {
+		report.Chart = chartOf(timeline.Points())
+	}
+	errs := report.Status.Errors
+	if report.Result != nil && len(report.Result.Errors) > 0 {
+		errs = report.Result.Errors
+	}
+	report.ErrorCount = len(errs)
+	report.ErrorGroups = groupErrors(errs)
+	if len(report.ErrorGroups) > maxReportErrorGroups {
+		report.MoreErrors = len(report.ErrorGroups) - maxReportErrorGroups
+		report.ErrorGroups = report.ErrorGroups[:maxReportErrorGroups]
+	}
+	if dbManager, ok := taskManager.stateManager.(*state.DBStateManager); ok {
+		summary, err := state.NewIntegrityManager(dbManager.GetDB()).GetIntegritySummary(taskID)
+		if err != nil {
+			report.IntegrityErr = err.Error()
+		} else if summary.TotalObjects > 0 {
+			report.Integrity = summary
+		}
+	}
+
+	var body bytes.Buffer
+	if err := reportTemplate.Execute(&body, report); err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if c.Query("download") == "true" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-report.html", taskID))
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", body.Bytes())
+}
+
+// chartOf scales timeline points to the chart; nil with fewer than two points
+func chartOf(points []progress.Point) *timelineChart {
+	if len(points) < 2 {
+		return nil
+	}
+	first, last := points[0], points[len(points)-1]
+	chart := &timelineChart{
+		Width:         chartWidth,
+		Height:        chartHeight,
+		Start:         first.Time,
+		End:           last.Time,
+		CopiedObjects: last.CopiedObjects,
+	}
+	for _, point := range points {
+		chart.CopiedBytes = max(chart.CopiedBytes, point.CopiedBytes)
+	}
+	span := last.Time.Sub(first.Time)
+	coords := make([]string, 0, len(points))
+	for _, point := range points {
+		x, y := 0.0, float64(chartHeight)
+		if span > 0 {
+			x = float64(point.Time.Sub(first.Time)) / float64(span) * chartWidth
+		}
+		if chart.CopiedBytes > 0 {
+			y -= float64(point.CopiedBytes) / float64(chart.CopiedBytes) * chartHeight
+		}
+		coords = append(coords, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+	chart.Points = strings.Join(coords, " ")
+	return chart
+}
+
+// groupErrors counts errors by cause, most frequent first. Copy errors
+// ("Failed to copy <key>: <cause>") are grouped by cause, with the key kept
+// as an example; other errors are their own cause.
+func groupErrors(errs []string) []errorGroup {
+	index := make(map[string]int)
+	var groups []errorGroup
+	for _, message := range errs {
+		cause, example := message, ""
+		if rest, ok := strings.CutPrefix(message, "Failed to copy "); ok {
+			if key, reason, found := strings.Cut(rest, ": "); found {
+				cause, example = reason, key
+			}
+		}
+		if i, seen := index[cause]; seen {
+			groups[i].Count++
+			continue
+		}
+		index[cause] = len(groups)
+		groups = append(groups, errorGroup{Cause: cause, Count: 1, Example: example})
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Count > groups[j].Count })
+	return groups
+}
+
+// reportBytes formats a byte count with a binary unit, e.g. "1.5 GiB"
+func reportBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, exp := float64(size)/unit, 0
+	for value >= unit && exp < 4 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTP"[exp])
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"bytes": reportBytes,
+	"time": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.UTC().Format("2006-01-02 15:04:05 UTC")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Migration report {{.Status.TaskID}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; max-width: 800px; margin: 2em auto; padding: 0 1em; }
+h1 { font-size: 1.6em; margin-bottom: 0.2em; }
+h2 { font-size: 1.2em; border-bottom: 1px solid #ccc; padding-bottom: 0.2em; margin-top: 1.6em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 0.3em 0.6em; border-bottom: 1px solid #eee; vertical-align: top; }
+th { width: 35%; font-weight: 600; }
+pre { background: #f6f6f6; padding: 0.8em; overflow-x: auto; font-size: 0.85em; white-space: pre-wrap; }
+.muted { color: #777; }
+.status { font-weight: 600; }
+.ok { color: #1a7f37; }
+.bad { color: #b42318; }
+svg { border: 1px solid #ddd; background: #fcfcfc; }
+@media print { body { margin: 0; max-width: none; } h2 { break-after: avoid; } table, svg { break-inside: avoid; } }
+</style>
+</head>
+<body>
+<h1>Migration report</h1>
+<p class="muted">Task {{.Status.TaskID}} &middot; generated {{time .Generated}}</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Status</th><td class="status {{if eq .Status.Status "completed"}}ok{{else if or (eq .Status.Status "failed") (eq .Status.Status "completed_with_errors")}}bad{{end}}">{{.Status.Status}}{{if .Status.DryRun}} (dry run){{end}}</td></tr>
+<tr><th>Type</th><td>{{.Status.MigrationType}}</td></tr>
+<tr><th>Started</th><td>{{time .Status.StartTime}}</td></tr>
+<tr><th>Finished</th><td>{{time .Status.EndTime}}</td></tr>
+<tr><th>Duration</th><td>{{or .Status.Duration "-"}}</td></tr>
+</table>
+
+<h2>Totals</h2>
+<table>
+<tr><th>Objects</th><td>{{.Status.CopiedObjects}} of {{.Status.TotalObjects}}</td></tr>
+<tr><th>Data</th><td>{{bytes .Status.CopiedSize}} of {{bytes .Status.TotalSize}}</td></tr>
+{{- with .Result}}
+<tr><th>Copied</th><td>{{.Copied}}</td></tr>
+<tr><th>Failed</th><td>{{.Failed}}</td></tr>
+{{- if .Skipped}}<tr><th>Skipped</th><td>{{.Skipped}}</td></tr>{{end}}
+{{- if .NotModified}}<tr><th>Not modified</th><td>{{.NotModified}}</td></tr>{{end}}
+{{- if .Deduplicated}}<tr><th>Deduplicated</th><td>{{.Deduplicated}}</td></tr>{{end}}
+{{- if .OverwriteRefused}}<tr><th>Overwrites refused</th><td>{{.OverwriteRefused}}</td></tr>{{end}}
+<tr><th>Average speed</th><td>{{printf "%.1f" .AvgSpeedMB}} MB/s</td></tr>
+{{- end}}
+</table>
+
+<h2>Timeline</h2>
+{{- with .Chart}}
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" role="img" aria-label="Bytes copied over time">
+<polyline fill="none" stroke="#2563eb" stroke-width="2" points="{{.Points}}"/>
+</svg>
+<p class="muted">{{time .Start}} to {{time .End}} &middot; {{bytes .CopiedBytes}} and {{.CopiedObjects}} objects copied at the end of the chart</p>
+{{- else}}
+<p class="muted">No progress was sampled for this task (kept in memory for S3 migrations run by this instance).</p>
+{{- end}}
+
+<h2>Errors</h2>
+{{- if .ErrorGroups}}
+<p>{{.ErrorCount}} errors, by cause:</p>
+<table>
+<tr><th>Cause</th><td><strong>Count</strong> &middot; example key</td></tr>
+{{- range .ErrorGroups}}
+<tr><th>{{.Cause}}</th><td>{{.Count}}{{if .Example}} &middot; {{.Example}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{- if .MoreErrors}}<p class="muted">{{.MoreErrors}} more causes not listed.</p>{{end}}
+{{- else}}
+<p class="ok">No errors.</p>
+{{- end}}
+
+<h2>Verification</h2>
+{{- with .Status.Drift}}
+<table>
+<tr><th>Outcome</th><td class="{{if .Drifted}}bad{{else}}ok{{end}}">{{if .Drifted}}Drift found{{else}}No drift{{end}} ({{.Mode}}, {{time .CheckedAt}})</td></tr>
+<tr><th>Source</th><td>{{.SourceObjects}} objects, {{bytes .SourceBytes}}</td></tr>
+<tr><th>Destination</th><td>{{.DestObjects}} objects, {{bytes .DestBytes}}</td></tr>
+<tr><th>Missing / changed / extra</th><td>{{.Missing}} / {{.Changed}} / {{.Extra}}</td></tr>
+</table>
+{{- end}}
+{{- with .Result}}{{with .Reconciliation}}
+<table>
+<tr><th>Storage reconciliation</th><td class="{{if or .MissingObjects .SizeMismatches}}bad{{else}}ok{{end}}">{{.ListedObjects}} of {{.ExpectedObjects}} objects listed; {{.MissingObjects}} missing, {{.SizeMismatches}} size mismatches</td></tr>
+<tr><th>Bytes</th><td>{{bytes .ListedBytes}} listed, {{bytes .ExpectedBytes}} expected</td></tr>
+</table>
+{{- end}}{{end}}
+{{- if not (or .Status.Drift (and .Result .Result.Reconciliation))}}
+<p class="muted">The task ran no verification.</p>
+{{- end}}
+
+<h2>Integrity</h2>
+{{- with .Integrity}}
+<table>
+<tr><th>Verified</th><td>{{.VerifiedObjects}} of {{.TotalObjects}} objects ({{printf "%.2f" .IntegrityRate}}%)</td></tr>
+<tr><th>Failed</th><td class="{{if .FailedObjects}}bad{{else}}ok{{end}}">{{.FailedObjects}}</td></tr>
+<tr><th>Last verified</th><td>{{time .LastVerified}}</td></tr>
+</table>
+{{- else}}
+<p class="muted">{{if .IntegrityErr}}Integrity results could not be read: {{.IntegrityErr}}{{else}}No integrity results were recorded for this task.{{end}}</p>
+{{- end}}
+
+{{- with .Status.Notes}}
+<h2>Notes</h2>
+<table>
+{{- range .}}
+<tr><th>{{time .Time}}</th><td>{{.Text}} <span class="muted">&middot; {{.Author}}</span></td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+<h2>Configuration</h2>
+{{- if .Config}}
+<pre>{{.Config}}</pre>
+{{- else}}
+<p class="muted">The request of this task was not kept.</p>
+{{- end}}
+</body>
+</html>
+`))
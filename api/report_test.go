@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+)
+
+func TestGroupErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		errs []string
+		want []errorGroup
+	}{
+		{name: "none", errs: nil, want: nil},
+		{
+			name: "copy errors by cause",
+			errs: []string{
+				"Failed to copy a.txt: AccessDenied",
+				"Failed to verify destination: timeout",
+				"Failed to copy dir/b: c.txt: SlowDown",
+				"Failed to copy d.txt: AccessDenied",
+			},
+			want: []errorGroup{
+				{Cause: "AccessDenied", Count: 2, Example: "a.txt"},
+				{Cause: "Failed to verify destination: timeout", Count: 1},
+				{Cause: "c.txt: SlowDown", Count: 1, Example: "dir/b"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := groupErrors(tt.errs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupErrors() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTaskReport(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	endpoint.Put("source", "a.txt", []byte("alpha"))
+	endpoint.Put("source", "b.txt", []byte("bravo"))
+	router := testRouter(t, endpoint)
+	router.GET("/api/tasks/:taskId/report.html", GetTaskReport)
+	router.POST("/api/tasks/:taskId/notes", AddTaskNote)
+
+	resp := serve(router, http.MethodPost, "/api/migrate", `{"source_bucket": "source", "dest_bucket": "dest"}`)
+	var status models.MigrationStatus
+	if resp.Code != http.StatusOK || json.Unmarshal(resp.Body.Bytes(), &status) != nil {
+		t.Fatalf("POST /api/migrate = %d: %s", resp.Code, resp.Body)
+	}
+	waitForStatus(t, router, status.TaskID, func(s models.MigrationStatus) bool { return s.Status == "completed" })
+	serve(router, http.MethodPost, "/api/tasks/"+status.TaskID+"/notes", `{"author": "pm", "text": "<b>sign-off</b>"}`)
+
+	resp = serve(router, http.MethodGet, "/api/tasks/"+status.TaskID+"/report.html", "")
+	if resp.Code != http.StatusOK || !strings.HasPrefix(resp.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("GET report = %d (%s): %s", resp.Code, resp.Header().Get("Content-Type"), resp.Body)
+	}
+	body := resp.Body.String()
+	for _, want := range []string{
+		"Task " + status.TaskID,
+		`<td class="status ok">completed</td>`,
+		"<tr><th>Copied</th><td>2</td></tr>",
+		"<polyline",
+		"No errors.",
+		"&lt;b&gt;sign-off&lt;/b&gt;",
+		"&#34;source_bucket&#34;: &#34;source&#34;",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("report does not contain %q:\n%s", want, body)
+		}
+	}
+
+	if resp = serve(router, http.MethodGet, "/api/tasks/missing/report.html", ""); resp.Code != http.StatusNotFound {
+		t.Errorf("report of an unknown task = %d, want 404", resp.Code)
+	}
+}
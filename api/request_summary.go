@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"s3migration/pkg/config"
+	"s3migration/pkg/models"
+	"s3migration/pkg/state"
+)
+
+// requestSummary returns what a task's status shows of its request
+func requestSummary(req models.MigrationRequest) *models.RequestSummary {
+	redacted := portableRequest(req)
+	redacted.FilesFrom = nil
+	for _, creds := range []*models.Credentials{redacted.SourceCredentials, redacted.DestCredentials, redacted.Credentials} {
+		if creds != nil {
+			creds.EndpointURL = redactURL(creds.EndpointURL, true)
+		}
+	}
+	if redacted.Transform != nil {
+		redacted.Transform.URL = redactURL(redacted.Transform.URL, false)
+	}
+	if redacted.Webhook != nil {
+		webhook := *redacted.Webhook
+		webhook.URL = redactURL(webhook.URL, false)
+		redacted.Webhook = &webhook
+	}
+
+	source := req.SourceCredentials
+	if source == nil {
+		source = req.Credentials
+	}
+	dest := req.DestCredentials
+	if dest == nil {
+		dest = source // The destination is written with the source credentials
+	}
+	summary := &models.RequestSummary{Request: redacted, FilesFromCount: len(req.FilesFrom)}
+	summary.SourceProvider, summary.SourceEndpoint = endpointName(source)
+	summary.DestProvider, summary.DestEndpoint = endpointName(dest)
+	return summary
+}
+
+// endpointName returns the provider and redacted endpoint of credentials
+func endpointName(creds *models.Credentials) (string, string) {
+	if creds == nil {
+		return string(config.DetectProvider("")), ""
+	}
+	return string(config.DetectProvider(creds.EndpointURL)), redactURL(creds.EndpointURL, true)
+}
+
+// redactURL drops the user info, query and fragment of a URL, and its path
+// unless keepPath is set; hook URLs often carry a token in their path
+func redactURL(raw string, keepPath bool) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "(redacted)"
+	}
+	redacted := url.URL{Scheme: u.Scheme, Host: u.Host}
+	if keepPath {
+		redacted.Path = u.Path
+	} else if strings.Trim(u.Path, "/") != "" {
+		redacted.Path = "/..."
+	}
+	return redacted.String()
+}
+
+// storedRequestSummary decodes the request summary stored with a task, if any
+func storedRequestSummary(taskState *state.TaskState) *models.RequestSummary {
+	payload, ok := taskState.OriginalRequest["summary"]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil
+	}
+	var summary models.RequestSummary
+	if err := json.Unmarshal(data, &summary); err != nil || summary.Request == nil {
+		return nil
+	}
+	return &summary
+}
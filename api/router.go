@@ -8,68 +8,130 @@ import (
 // SetupRouter creates and configures the Gin router
 func SetupRouter() *gin.Engine {
 	router := gin.Default()
-	
+
 	// Initialize scheduler on startup
 	EnsureSchedulerInitialized()
-	
+
+	// Configure CORS (CORS_* env vars) and security headers (SECURITY_* env vars);
+	// registered first so they also apply to the web UI
+	router.Use(cors.New(corsConfigFromEnv()))
+	router.Use(securityHeaders())
+
 	// Serve static files and web UI
 	router.Static("/static", "./web/static")
 	router.StaticFile("/", "./web/index.html")
 	router.StaticFile("/auth/callback", "./web/auth-callback.html")
+	router.StaticFile("/share", "./web/share.html") // Read-only task status opened with a share token
 	router.NoRoute(func(c *gin.Context) {
 		c.File("./web/index.html")
 	})
 
-	// Configure CORS
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"*"} // Configure appropriately in production
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
-	router.Use(cors.New(config))
-
 	// Health check
 	router.GET("/health", HealthCheck)
 
-	// API routes
-	api := router.Group("/api")
+	// API routes: body size cap (MAX_REQUEST_BODY_MB), per-client rate limit
+	// (RATE_LIMIT_RPS/RATE_LIMIT_BURST) and audit log of mutating calls (after the
+	// rate limit, so a flood of rejected calls does not turn into database writes);
+	// API_KEYS, when set, is required on every call except status reads with a
+	// task's share token;
+	// endpoints that list whole buckets also share a concurrency cap
+	// (EXPENSIVE_REQUEST_CONCURRENCY, default 4)
+	api := router.Group("/api", maxBodySize(), rateLimit(), apiAuth(), auditLog())
+	expensive := concurrencyLimit(int(envFloat("EXPENSIVE_REQUEST_CONCURRENCY", 4)))
 	{
 		// Debug endpoints
 		api.POST("/test-connection", TestConnection)
-		api.POST("/test-bucket-listing", TestBucketListing)
+		api.POST("/test-bucket-listing", expensive, TestBucketListing)
 		api.GET("/debug/task/:taskID/errors", GetTaskErrors)
-		
+		api.GET("/settings/logging", GetLoggingSettings)
+		api.PUT("/settings/logging", UpdateLoggingSettings)
+		api.GET("/errors", ListErrorCodes) // Registry of the codes in error responses
+
+		// Audit log of mutating calls
+		api.GET("/audit", ListAuditLog)
+
+		// Cached destination listings reused by incremental comparisons
+		api.GET("/cache/listings", ListCachedListings)
+		api.DELETE("/cache/listings", InvalidateCachedListings)
+
+		// Admin: inspect and tune live tasks
+		api.GET("/admin/tasks/:taskID/tuning", GetTaskTuning)
+		api.PATCH("/admin/tasks/:taskID/tuning", UpdateTaskTuning)
+		api.GET("/admin/endpoints", ListEndpointGroups) // Per-destination-endpoint budgets shared by tasks
+		api.GET("/admin/memory", GetMemorySettings)     // Memory limit, threshold and per-worker profiles, applied to running tasks
+		api.PATCH("/admin/memory", UpdateMemorySettings)
+		api.GET("/admin/latency", GetLatencyMetrics) // S3 request latency percentiles by endpoint and operation
+
+		// Bucket inspection (credentials via X-Access-Key/X-Secret-Key headers)
+		api.POST("/credentials/validate", ValidateCredentials) // What the credentials can do, for the setup wizard
+		api.POST("/buckets/list", ListBuckets)
+		api.GET("/buckets/stats", expensive, GetBucketStats)
+		api.POST("/objects/list", ListObjects)
+		api.POST("/objects/diff", DiffObjects) // Head metadata, checksums and tags of a few keys on both sides
+		api.POST("/analysis/duplicates", expensive, FindDuplicates)
+		api.POST("/analysis/keys", expensive, AuditKeys)         // Keys that break on some destinations, with key_sanitize rules fixing them
+		api.POST("/analysis/bucket-config", AnalyzeBucketConfig) // Policy, CORS and website settings to re-create on the destination
+		api.POST("/benchmark", expensive, RunBenchmark)
+
 		// One-time migrations
 		api.POST("/migrate", StartMigration)
-		api.POST("/migrate/bulk", StartBulkMigration) // Migrate all buckets
+		api.POST("/migrate/estimate", expensive, EstimateMigration) // Size and cost of a request's source, checked against the guardrail
+		api.POST("/migrate/bulk", StartBulkMigration)               // Migrate all buckets
 		api.GET("/status/:taskID", GetStatus)
+		api.GET("/status/:taskID/events", StreamStatus) // Server-sent status updates until the task finishes
 		api.GET("/tasks", ListTasks)
+		api.GET("/tasks/export", ExportTasks) // Task list as a CSV spreadsheet (format=csv or excel)
 		api.DELETE("/tasks/:taskID", CancelTask)
-		api.DELETE("/tasks/cleanup/:status", CleanupTasks) // Delete tasks by status (failed, completed, cancelled)
+		api.DELETE("/tasks/cleanup/:status", CleanupTasks)    // Delete tasks by status (failed, completed, cancelled)
+		api.GET("/tasks/:taskId/dry-run-diff", GetDryRunDiff) // Per-key changes found by a dry run with dry_run_diff
+		api.GET("/tasks/:taskId/export", ExportTask)          // Portable bundle for handing a task to another deployment
+		api.POST("/tasks/import", ImportTask)
+		api.GET("/tasks/:taskId/manifest", GetTransferManifest)    // Copied or failed keys as an rclone or aws s3 file list
+		api.GET("/tasks/:taskId/listing", GetTaskListing)          // Destination listing cached with cache_listing
+		api.GET("/tasks/:taskId/report.html", GetTaskReport)       // Printable report of a migration for stakeholders
+		api.POST("/manifests/rclone-check", ImportRcloneCheck)     // rclone check output to a files_from list
+		api.POST("/tasks/:taskId/cutover/confirm", ConfirmCutover) // Let a cutover run its final sync
+		api.POST("/tasks/:taskId/share", ShareTask)                // Expiring read-only status token for stakeholders
+		api.POST("/tasks/:taskId/notes", AddTaskNote)              // Timestamped operator note kept with the task
 		// Retry removed: credentials not persisted for security
 		// api.POST("/tasks/:taskID/retry", RetryTask)
-		
+
 		// Integrity verification endpoints
 		api.GET("/tasks/:taskId/integrity", GetIntegritySummary)
 		api.GET("/tasks/:taskId/integrity/report", GetIntegrityReport)
 		api.GET("/tasks/:taskId/integrity/failures", GetFailedIntegrityObjects)
+		api.GET("/integrity/compatibility", GetETagCompatibility) // Which ETag comparison each provider pair supports
 
 		// Scheduled migrations
 		api.POST("/schedules", CreateSchedule)
 		api.GET("/schedules", ListSchedules)
 		api.GET("/schedules/stats", GetSchedulerStats)
+		api.GET("/schedules/health", GetScheduleHealth) // Missed and failing schedules, for dashboards
+		api.GET("/schedules/export", ExportSchedules)   // Schedule list as a CSV spreadsheet
 		api.GET("/schedules/:id", GetSchedule)
 		api.PUT("/schedules/:id", UpdateSchedule)
 		api.DELETE("/schedules/:id", DeleteSchedule)
 		api.POST("/schedules/:id/enable", EnableSchedule)
 		api.POST("/schedules/:id/disable", DisableSchedule)
 		api.POST("/schedules/:id/run", RunScheduleNow)
+		api.GET("/schedules/:id/drift", GetScheduleDrift) // Drift reports of a verify schedule's runs
+
+		// Google Drive integration
+		api.POST("/googledrive/quick-auth-url", GoogleDriveQuickAuthURL)
+		api.POST("/googledrive/auth-url", GoogleDriveAuthURL)
+		api.POST("/googledrive/exchange-token", GoogleDriveExchangeToken)
+		api.POST("/googledrive/list-folders", GoogleDriveListFolders)
+		api.POST("/googledrive/migrate", StartGoogleDriveMigration)
+
+		// Box integration
+		api.POST("/box/auth-url", BoxAuthURL)
+		api.POST("/box/exchange-token", BoxExchangeToken)
+		api.POST("/box/list-folders", BoxListFolders)
+		api.POST("/migrate/box", StartBoxMigration)
 
-                // Google Drive integration
-                api.POST("/googledrive/quick-auth-url", GoogleDriveQuickAuthURL)
-                api.POST("/googledrive/auth-url", GoogleDriveAuthURL)
-                api.POST("/googledrive/exchange-token", GoogleDriveExchangeToken)
-                api.POST("/googledrive/list-folders", GoogleDriveListFolders)
-                api.POST("/googledrive/migrate", StartGoogleDriveMigration)
+		// HTTP(S) URL-list ingestion
+		api.POST("/migrate/urls", StartURLListMigration)
+		api.GET("/migrate/urls/:taskID/objects", GetURLListObjects)
 	}
 
 	return router
@@ -2,12 +2,17 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"s3migration/pkg/core"
+	"s3migration/pkg/models"
 	"s3migration/pkg/scheduler"
+	"s3migration/pkg/secrets"
 )
 
 var scheduleManager *scheduler.Scheduler
@@ -17,6 +22,9 @@ type DefaultTaskExecutor struct{}
 
 // Execute implements the TaskExecutor interface
 func (e *DefaultTaskExecutor) Execute(ctx context.Context, schedule *scheduler.Schedule) error {
+	if schedule.Type == scheduler.TypeVerify {
+		return executeVerifySchedule(ctx, schedule)
+	}
 	// TODO: Implement actual migration execution
 	// For now, just log that it would run
 	return nil
@@ -29,6 +37,7 @@ func InitScheduler(executor scheduler.TaskExecutor) {
 	}
 	scheduleManager = scheduler.NewScheduler(executor)
 	scheduleManager.Start()
+	go watchScheduleHealth(scheduleManager)
 }
 
 // EnsureSchedulerInitialized ensures the scheduler is initialized
@@ -40,15 +49,66 @@ func EnsureSchedulerInitialized() {
 
 // CreateScheduleRequest represents a request to create a schedule
 type CreateScheduleRequest struct {
-	Name             string                     `json:"name" binding:"required"`
-	CronExpr         string                     `json:"cron_expr" binding:"required"`
-	SourceBucket     string                     `json:"source_bucket" binding:"required"`
-	DestBucket       string                     `json:"dest_bucket" binding:"required"`
-	SourcePrefix     string                     `json:"source_prefix"`
-	DestPrefix       string                     `json:"dest_prefix"`
-	Incremental      bool                       `json:"incremental"`
-	DeleteRemoved    bool                       `json:"delete_removed"`
-	ConflictStrategy scheduler.ConflictStrategy `json:"conflict_strategy"`
+	Name              string                     `json:"name" binding:"required"`
+	Type              string                     `json:"type"` // "sync" (default) or "verify"
+	CronExpr          string                     `json:"cron_expr" binding:"required"`
+	SourceBucket      string                     `json:"source_bucket" binding:"required"`
+	DestBucket        string                     `json:"dest_bucket" binding:"required"`
+	SourcePrefix      string                     `json:"source_prefix"` // Both prefixes may hold date variables, e.g. "logs/{{yesterday:2006/01/02}}/"
+	DestPrefix        string                     `json:"dest_prefix"`
+	Incremental       bool                       `json:"incremental"`
+	DeleteRemoved     bool                       `json:"delete_removed"`
+	ConflictStrategy  scheduler.ConflictStrategy `json:"conflict_strategy"`
+	VerifyMode        string                     `json:"verify_mode,omitempty"`        // Verify schedules: "count", "size" (default) or "etag"
+	OverlapPolicy     scheduler.OverlapMode      `json:"overlap_policy,omitempty"`     // While a run is in progress: "skip" (default), "queue" or "parallel"
+	MaxRunDuration    string                     `json:"max_run_duration,omitempty"`   // Runs taking longer are stopped, e.g. "4h"
+	OnTimeout         string                     `json:"on_timeout,omitempty"`         // How they are stopped: "cancel" (default) or "drain"
+	SourceCredentials *models.Credentials        `json:"source_credentials,omitempty"` // Stored encrypted, never returned
+	DestCredentials   *models.Credentials        `json:"dest_credentials,omitempty"`   // Stored encrypted, never returned
+}
+
+// scheduleType validates the type and verify mode of a schedule request; empty means sync
+func scheduleType(req *CreateScheduleRequest) (string, error) {
+	switch req.Type {
+	case "", scheduler.TypeSync:
+		return scheduler.TypeSync, nil
+	case scheduler.TypeVerify:
+		return scheduler.TypeVerify, core.ValidateVerifyMode(core.VerifyMode(req.VerifyMode))
+	default:
+		return "", fmt.Errorf("unsupported schedule type %q (expected sync or verify)", req.Type)
+	}
+}
+
+// validateScheduleRequest checks the overlap policy and run limit of a schedule
+// request and the template variables of its prefixes, which are resolved on
+// each run
+func validateScheduleRequest(req *CreateScheduleRequest) error {
+	if err := scheduler.ValidateOverlapMode(req.OverlapPolicy); err != nil {
+		return err
+	}
+	if err := scheduler.ValidateRunLimit(req.MaxRunDuration, req.OnTimeout); err != nil {
+		return err
+	}
+	probe := scheduler.Schedule{
+		Source:      scheduler.SourceConfig{Prefix: req.SourcePrefix},
+		Destination: scheduler.DestConfig{Prefix: req.DestPrefix},
+	}
+	_, err := probe.Resolved(time.Now())
+	return err
+}
+
+// encryptedCredentialsMap converts credentials to the schedule's map form with every value encrypted
+func encryptedCredentialsMap(creds *models.Credentials) (map[string]string, error) {
+	if creds == nil {
+		return nil, nil
+	}
+	return secrets.EncryptMap(map[string]string{
+		"access_key":    creds.AccessKey,
+		"secret_key":    creds.SecretKey,
+		"session_token": creds.SessionToken,
+		"region":        creds.Region,
+		"endpoint_url":  creds.EndpointURL,
+	})
 }
 
 // CreateSchedule handles POST /api/schedules
@@ -59,30 +119,57 @@ type CreateScheduleRequest struct {
 // @Produce json
 // @Param request body CreateScheduleRequest true "Schedule request"
 // @Success 200 {object} scheduler.Schedule
-// @Failure 400 {object} gin.H
+// @Failure 400 {object} ErrorResponse
 // @Router /api/schedules [post]
 func CreateSchedule(c *gin.Context) {
 	EnsureSchedulerInitialized()
-	
+
 	var req CreateScheduleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	scheduleKind, err := scheduleType(&req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validateScheduleRequest(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sourceCreds, err := encryptedCredentialsMap(req.SourceCredentials)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "failed to encrypt source credentials")
+		return
+	}
+	destCreds, err := encryptedCredentialsMap(req.DestCredentials)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "failed to encrypt destination credentials")
 		return
 	}
 
 	// Create schedule
 	schedule := &scheduler.Schedule{
-		ID:       uuid.New().String(),
-		Name:     req.Name,
-		CronExpr: req.CronExpr,
-		Enabled:  true,
+		ID:          uuid.New().String(),
+		Name:        req.Name,
+		Type:        scheduleKind,
+		CronExpr:    req.CronExpr,
+		Enabled:     true,
+		VerifyMode:  req.VerifyMode,
+		Overlap:     req.OverlapPolicy,
+		MaxDuration: req.MaxRunDuration,
+		OnTimeout:   req.OnTimeout,
 		Source: scheduler.SourceConfig{
-			Bucket: req.SourceBucket,
-			Prefix: req.SourcePrefix,
+			Bucket:      req.SourceBucket,
+			Prefix:      req.SourcePrefix,
+			Credentials: sourceCreds,
 		},
 		Destination: scheduler.DestConfig{
-			Bucket: req.DestBucket,
-			Prefix: req.DestPrefix,
+			Bucket:      req.DestBucket,
+			Prefix:      req.DestPrefix,
+			Credentials: destCreds,
 		},
 		Options: scheduler.SyncOptions{
 			Incremental:      req.Incremental,
@@ -92,11 +179,11 @@ func CreateSchedule(c *gin.Context) {
 	}
 
 	if err := scheduleManager.AddSchedule(schedule); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, schedule)
+	c.JSON(http.StatusOK, schedule.Redacted())
 }
 
 // GetSchedule handles GET /api/schedules/:id
@@ -106,23 +193,23 @@ func CreateSchedule(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Schedule ID"
 // @Success 200 {object} scheduler.Schedule
-// @Failure 404 {object} gin.H
+// @Failure 404 {object} ErrorResponse
 // @Router /api/schedules/{id} [get]
 func GetSchedule(c *gin.Context) {
 	if scheduleManager == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "scheduler not initialized"})
+		respondError(c, http.StatusNotFound, "scheduler not initialized")
 		return
 	}
-	
+
 	id := c.Param("id")
 
 	schedule, err := scheduleManager.GetSchedule(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, schedule)
+	c.JSON(http.StatusOK, schedule.Redacted())
 }
 
 // ListSchedules handles GET /api/schedules
@@ -138,7 +225,11 @@ func ListSchedules(c *gin.Context) {
 		return
 	}
 	schedules := scheduleManager.ListSchedules()
-	c.JSON(http.StatusOK, schedules)
+	redacted := make([]*scheduler.Schedule, len(schedules))
+	for i, schedule := range schedules {
+		redacted[i] = schedule.Redacted()
+	}
+	c.JSON(http.StatusOK, redacted)
 }
 
 // UpdateSchedule handles PUT /api/schedules/:id
@@ -150,26 +241,41 @@ func ListSchedules(c *gin.Context) {
 // @Param id path string true "Schedule ID"
 // @Param request body CreateScheduleRequest true "Updated schedule"
 // @Success 200 {object} scheduler.Schedule
-// @Failure 400 {object} gin.H
+// @Failure 400 {object} ErrorResponse
 // @Router /api/schedules/{id} [put]
 func UpdateSchedule(c *gin.Context) {
 	id := c.Param("id")
 
 	var req CreateScheduleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	scheduleKind, err := scheduleType(&req)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validateScheduleRequest(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Get existing schedule
 	existingSchedule, err := scheduleManager.GetSchedule(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
 	// Update fields
 	existingSchedule.Name = req.Name
+	existingSchedule.Type = scheduleKind
+	existingSchedule.VerifyMode = req.VerifyMode
+	existingSchedule.Overlap = req.OverlapPolicy
+	existingSchedule.MaxDuration = req.MaxRunDuration
+	existingSchedule.OnTimeout = req.OnTimeout
 	existingSchedule.CronExpr = req.CronExpr
 	existingSchedule.Source.Bucket = req.SourceBucket
 	existingSchedule.Source.Prefix = req.SourcePrefix
@@ -178,13 +284,30 @@ func UpdateSchedule(c *gin.Context) {
 	existingSchedule.Options.Incremental = req.Incremental
 	existingSchedule.Options.DeleteRemoved = req.DeleteRemoved
 	existingSchedule.Options.ConflictStrategy = req.ConflictStrategy
+	// Credentials are only replaced when sent; omitted credentials keep the stored ones
+	if req.SourceCredentials != nil {
+		sourceCreds, err := encryptedCredentialsMap(req.SourceCredentials)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "failed to encrypt source credentials")
+			return
+		}
+		existingSchedule.Source.Credentials = sourceCreds
+	}
+	if req.DestCredentials != nil {
+		destCreds, err := encryptedCredentialsMap(req.DestCredentials)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "failed to encrypt destination credentials")
+			return
+		}
+		existingSchedule.Destination.Credentials = destCreds
+	}
 
 	if err := scheduleManager.UpdateSchedule(existingSchedule); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, existingSchedule)
+	c.JSON(http.StatusOK, existingSchedule.Redacted())
 }
 
 // DeleteSchedule handles DELETE /api/schedules/:id
@@ -194,13 +317,13 @@ func UpdateSchedule(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Schedule ID"
 // @Success 200 {object} gin.H
-// @Failure 404 {object} gin.H
+// @Failure 404 {object} ErrorResponse
 // @Router /api/schedules/{id} [delete]
 func DeleteSchedule(c *gin.Context) {
 	id := c.Param("id")
 
 	if err := scheduleManager.RemoveSchedule(id); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -214,13 +337,13 @@ func DeleteSchedule(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Schedule ID"
 // @Success 200 {object} gin.H
-// @Failure 400 {object} gin.H
+// @Failure 400 {object} ErrorResponse
 // @Router /api/schedules/{id}/enable [post]
 func EnableSchedule(c *gin.Context) {
 	id := c.Param("id")
 
 	if err := scheduleManager.EnableSchedule(id); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -234,13 +357,13 @@ func EnableSchedule(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Schedule ID"
 // @Success 200 {object} gin.H
-// @Failure 400 {object} gin.H
+// @Failure 400 {object} ErrorResponse
 // @Router /api/schedules/{id}/disable [post]
 func DisableSchedule(c *gin.Context) {
 	id := c.Param("id")
 
 	if err := scheduleManager.DisableSchedule(id); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -254,13 +377,13 @@ func DisableSchedule(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Schedule ID"
 // @Success 200 {object} gin.H
-// @Failure 400 {object} gin.H
+// @Failure 400 {object} ErrorResponse
 // @Router /api/schedules/{id}/run [post]
 func RunScheduleNow(c *gin.Context) {
 	id := c.Param("id")
 
 	if err := scheduleManager.RunNow(id); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -277,8 +400,8 @@ func RunScheduleNow(c *gin.Context) {
 func GetSchedulerStats(c *gin.Context) {
 	if scheduleManager == nil {
 		c.JSON(http.StatusOK, gin.H{
-			"total_schedules": 0,
-			"active_schedules": 0,
+			"total_schedules":    0,
+			"active_schedules":   0,
 			"disabled_schedules": 0,
 		})
 		return
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/scheduler"
+)
+
+// defaultScheduleHealthInterval is how often schedules are checked for alerts
+// when SCHEDULE_HEALTH_INTERVAL is not set
+const defaultScheduleHealthInterval = time.Minute
+
+// scheduleHealthPolicy reads SCHEDULE_MISSED_RUN_GRACE (e.g. "10m") and
+// SCHEDULE_FAILURE_THRESHOLD (consecutive failed runs), falling back to the defaults
+func scheduleHealthPolicy() scheduler.HealthPolicy {
+	policy := scheduler.DefaultHealthPolicy
+	if value := os.Getenv("SCHEDULE_MISSED_RUN_GRACE"); value != "" {
+		grace, err := time.ParseDuration(value)
+		if err != nil || grace < 0 {
+			fmt.Printf("⚠️  Invalid SCHEDULE_MISSED_RUN_GRACE=%q, using %v\n", value, policy.MissedRunGrace)
+		} else {
+			policy.MissedRunGrace = grace
+		}
+	}
+	if value := os.Getenv("SCHEDULE_FAILURE_THRESHOLD"); value != "" {
+		threshold, err := strconv.Atoi(value)
+		if err != nil || threshold < 1 {
+			fmt.Printf("⚠️  Invalid SCHEDULE_FAILURE_THRESHOLD=%q, using %d\n", value, policy.FailureThreshold)
+		} else {
+			policy.FailureThreshold = threshold
+		}
+	}
+	return policy
+}
+
+// GetScheduleHealth handles GET /api/schedules/health
+// @Summary Get schedule health
+// @Description Schedules with an overdue run or whose last runs all failed, with per-state counts
+// @Tags schedules
+// @Produce json
+// @Success 200 {object} scheduler.HealthSummary
+// @Router /api/schedules/health [get]
+func GetScheduleHealth(c *gin.Context) {
+	if scheduleManager == nil {
+		c.JSON(http.StatusOK, scheduler.HealthSummary{CheckedAt: time.Now(), Schedules: []scheduler.ScheduleHealth{}})
+		return
+	}
+	c.JSON(http.StatusOK, scheduleManager.Health(time.Now(), scheduleHealthPolicy()))
+}
+
+// scheduleAlert is posted to SCHEDULE_ALERT_WEBHOOK_URL when a schedule's health changes
+type scheduleAlert struct {
+	Event string `json:"event"` // "schedule.unhealthy" or "schedule.recovered"
+	scheduler.ScheduleHealth
+}
+
+// scheduleHealthMonitor alerts once when a schedule turns unhealthy and once when it recovers
+type scheduleHealthMonitor struct {
+	scheduler *scheduler.Scheduler
+	policy    scheduler.HealthPolicy
+	send      func(alert scheduleAlert) error
+	alerted   map[string]string // Schedule ID to the unhealthy status last alerted
+}
+
+// check compares the health of every schedule with what was last alerted and
+// sends the changes; an alert that fails to send is tried again on the next check
+func (m *scheduleHealthMonitor) check(now time.Time) {
+	summary := m.scheduler.Health(now, m.policy)
+	seen := make(map[string]bool, len(summary.Schedules))
+	for _, health := range summary.Schedules {
+		seen[health.ID] = true
+		alerted, wasUnhealthy := m.alerted[health.ID]
+		var alert scheduleAlert
+		switch {
+		case !health.Healthy() && health.Status != alerted:
+			alert = scheduleAlert{Event: "schedule.unhealthy", ScheduleHealth: health}
+		case health.Status == scheduler.HealthOK && wasUnhealthy:
+			alert = scheduleAlert{Event: "schedule.recovered", ScheduleHealth: health}
+		case health.Status == scheduler.HealthDisabled:
+			delete(m.alerted, health.ID) // Disabling a schedule acknowledges its alert
+			continue
+		default:
+			continue
+		}
+		if err := m.send(alert); err != nil {
+			fmt.Printf("⚠️  Schedule alert for %s failed: %v\n", health.ID, err)
+			continue
+		}
+		if alert.Event == "schedule.recovered" {
+			delete(m.alerted, health.ID)
+		} else {
+			m.alerted[health.ID] = health.Status
+		}
+	}
+	for id := range m.alerted {
+		if !seen[id] {
+			delete(m.alerted, id) // Removed schedule
+		}
+	}
+}
+
+// sendScheduleAlert logs an alert and posts it to SCHEDULE_ALERT_WEBHOOK_URL, if set
+func sendScheduleAlert(alert scheduleAlert) error {
+	if alert.Event == "schedule.recovered" {
+		fmt.Printf("Schedule %s (%s) recovered\n", alert.ID, alert.Name)
+	} else {
+		fmt.Printf("⚠️  Schedule %s (%s) is %s: %s\n", alert.ID, alert.Name, alert.Status, alert.Reason)
+	}
+	url := os.Getenv("SCHEDULE_ALERT_WEBHOOK_URL")
+	if url == "" {
+		return nil
+	}
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	return postWebhook(url, body)
+}
+
+// watchScheduleHealth checks the schedules every SCHEDULE_HEALTH_INTERVAL (default 1m)
+func watchScheduleHealth(s *scheduler.Scheduler) {
+	interval := defaultScheduleHealthInterval
+	if value := os.Getenv("SCHEDULE_HEALTH_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			fmt.Printf("⚠️  Invalid SCHEDULE_HEALTH_INTERVAL=%q, using %v\n", value, interval)
+		} else {
+			interval = parsed
+		}
+	}
+	monitor := &scheduleHealthMonitor{
+		scheduler: s,
+		policy:    scheduleHealthPolicy(),
+		send:      sendScheduleAlert,
+		alerted:   map[string]string{},
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		monitor.check(now)
+	}
+}
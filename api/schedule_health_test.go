@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"s3migration/pkg/scheduler"
+)
+
+func TestScheduleHealthAlertsOnChange(t *testing.T) {
+	s := scheduler.NewScheduler(&DefaultTaskExecutor{})
+	if err := s.AddSchedule(&scheduler.Schedule{ID: "nightly", Name: "Nightly", CronExpr: "0 3 * * *"}); err != nil {
+		t.Fatal(err)
+	}
+	schedule, _ := s.GetSchedule("nightly")
+	schedule.Enabled = true // Checked as enabled without a cron entry
+
+	var alerts []scheduleAlert
+	monitor := &scheduleHealthMonitor{
+		scheduler: s,
+		policy:    scheduler.HealthPolicy{MissedRunGrace: time.Minute, FailureThreshold: 1},
+		send: func(alert scheduleAlert) error {
+			alerts = append(alerts, alert)
+			return nil
+		},
+		alerted: map[string]string{},
+	}
+
+	due := schedule.NextRun
+	monitor.check(due)                    // On time
+	monitor.check(due.Add(time.Hour))     // Missed
+	monitor.check(due.Add(2 * time.Hour)) // Still missed, already alerted
+	monitor.check(due.Add(-time.Hour))    // Recovered
+	if len(alerts) != 2 || alerts[0].Event != "schedule.unhealthy" || alerts[0].Status != scheduler.HealthMissed || alerts[1].Event != "schedule.recovered" {
+		t.Fatalf("got alerts %+v", alerts)
+	}
+
+	previous := scheduleManager
+	scheduleManager = s
+	defer func() { scheduleManager = previous }()
+	router := testRouter(t, nil)
+	router.GET("/api/schedules/health", GetScheduleHealth)
+	w := serve(router, http.MethodGet, "/api/schedules/health", "")
+	var summary scheduler.HealthSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("got %d %s", w.Code, w.Body.String())
+	}
+	if summary.Healthy != 1 || len(summary.Schedules) != 1 || summary.Schedules[0].ID != "nightly" {
+		t.Errorf("got %+v", summary)
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"s3migration/pkg/models"
+	"s3migration/pkg/secrets"
+)
+
+// secretResolveTimeout bounds resolving a request's secret references at task start
+const secretResolveTimeout = 30 * time.Second
+
+// resolveCredentialRef returns a copy of creds with keys read from its secret reference.
+// Region and endpoint from the secret only fill fields the request left empty.
+func resolveCredentialRef(ctx context.Context, creds *models.Credentials) (*models.Credentials, error) {
+	if creds == nil || creds.SecretRef == "" {
+		return creds, nil
+	}
+	resolved, err := secrets.DefaultResolver().Resolve(ctx, creds.SecretRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve secret reference: %w", err)
+	}
+
+	filled := *creds
+	filled.AccessKey = resolved.AccessKey
+	filled.SecretKey = resolved.SecretKey
+	filled.SessionToken = resolved.SessionToken
+	if filled.Region == "" {
+		filled.Region = resolved.Region
+	}
+	if filled.EndpointURL == "" {
+		filled.EndpointURL = resolved.EndpointURL
+	}
+	return &filled, nil
+}
+
+// resolveRequestCredentialRefs returns a copy of a migration request with the
+// keys of its secret references filled in. The copy must not be stored.
+func resolveRequestCredentialRefs(ctx context.Context, req *models.MigrationRequest) (*models.MigrationRequest, error) {
+	ctx, cancel := context.WithTimeout(ctx, secretResolveTimeout)
+	defer cancel()
+
+	resolved := *req
+	for field, creds := range map[string]**models.Credentials{
+		"source_credentials": &resolved.SourceCredentials,
+		"dest_credentials":   &resolved.DestCredentials,
+		"credentials":        &resolved.Credentials,
+	} {
+		filled, err := resolveCredentialRef(ctx, *creds)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field, err)
+		}
+		*creds = filled
+	}
+	if req.SourceInventory != nil {
+		filled, err := resolveCredentialRef(ctx, req.SourceInventory.Credentials)
+		if err != nil {
+			return nil, fmt.Errorf("source_inventory.credentials: %w", err)
+		}
+		inventoryOpts := *req.SourceInventory
+		inventoryOpts.Credentials = filled
+		resolved.SourceInventory = &inventoryOpts
+	}
+	return &resolved, nil
+}
+
+// credentialsProviderFor returns a provider that re-resolves creds' secret reference
+// when the cached value expires, or nil for inline credentials
+func credentialsProviderFor(creds *models.Credentials) aws.CredentialsProvider {
+	if creds == nil || creds.SecretRef == "" {
+		return nil
+	}
+	return secrets.DefaultResolver().CredentialsProvider(creds.SecretRef)
+}
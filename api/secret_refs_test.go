@@ -0,0 +1,42 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+)
+
+func TestSecretRefKeysAreNotStored(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"data": {"access_key": "AKIAFROMVAULT", "secret_key": "vault-secret"}, "metadata": {"version": 1}}}`))
+	}))
+	defer vault.Close()
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_TOKEN", "token")
+
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	endpoint.Put("source", "a.txt", []byte("alpha"))
+	router := testRouter(t, endpoint)
+
+	resp := serve(router, http.MethodPost, "/api/migrate", `{"source_bucket": "source", "dest_bucket": "dest", "source_credentials": {"secret_ref": "vault:secret/data/s3/not-stored"}}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("POST /api/migrate = %d: %s", resp.Code, resp.Body)
+	}
+	var started models.MigrationStatus
+	json.Unmarshal(resp.Body.Bytes(), &started)
+	waitForStatus(t, router, started.TaskID, func(status models.MigrationStatus) bool { return status.Status == "completed" })
+
+	task, _ := taskManager.getTask(started.TaskID)
+	creds := task.OriginalRequest.SourceCredentials
+	if creds == nil || creds.SecretRef != "vault:secret/data/s3/not-stored" || creds.AccessKey != "" || creds.SecretKey != "" {
+		t.Fatalf("stored source credentials = %+v", creds)
+	}
+	if task.resume == nil {
+		t.Fatal("task with a secret reference is not resumable")
+	}
+}
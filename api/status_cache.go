@@ -0,0 +1,105 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/models"
+	"s3migration/pkg/statuscache"
+)
+
+// cachedStatusPoll is how often a status stream re-reads the shared cache for a
+// task running on another API replica
+const cachedStatusPoll = time.Second
+
+// statusCacheFromEnv returns the Redis status cache configured by REDIS_ADDR
+// (host:port), REDIS_PASSWORD and REDIS_DB, or nil without REDIS_ADDR
+func statusCacheFromEnv() statuscache.Cache {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		return nil
+	}
+	fmt.Printf("✅ Live task status shared through Redis at %s\n", addr)
+	return statuscache.NewRedis(statuscache.RedisConfig{
+		Addr:     addr,
+		Password: os.Getenv("REDIS_PASSWORD"),
+		DB:       int(envFloat("REDIS_DB", 0)),
+	})
+}
+
+// cacheStatus writes the status of a task to the shared cache. Failures only
+// cost other replicas a database read, so they are logged and dropped.
+func (tm *TaskManager) cacheStatus(status models.MigrationStatus) {
+	if tm.statusCache == nil {
+		return
+	}
+	payload, err := json.Marshal(status)
+	if err == nil {
+		err = tm.statusCache.Set(status.TaskID, payload)
+	}
+	if err != nil && !errors.Is(err, statuscache.ErrUnavailable) {
+		fmt.Printf("Warning: failed to cache status of task %s: %v\n", status.TaskID, err)
+	}
+}
+
+// cachedStatus returns the status of a task from the shared cache, and its encoding
+func (tm *TaskManager) cachedStatus(taskID string) (*models.MigrationStatus, []byte, bool) {
+	if tm.statusCache == nil {
+		return nil, nil, false
+	}
+	payload, err := tm.statusCache.Get(taskID)
+	if err != nil || payload == nil {
+		return nil, nil, false
+	}
+	var status models.MigrationStatus
+	if err := json.Unmarshal(payload, &status); err != nil {
+		return nil, nil, false
+	}
+	return &status, payload, true
+}
+
+// uncacheStatus removes a deleted task from the shared cache
+func (tm *TaskManager) uncacheStatus(taskID string) {
+	if tm.statusCache == nil {
+		return
+	}
+	if err := tm.statusCache.Delete(taskID); err != nil && !errors.Is(err, statuscache.ErrUnavailable) {
+		fmt.Printf("Warning: failed to remove cached status of task %s: %v\n", taskID, err)
+	}
+}
+
+// streamCachedStatus streams the status of a task running on another API
+// replica, polling the shared cache until the task finishes or leaves the cache
+func streamCachedStatus(c *gin.Context, status *models.MigrationStatus, payload []byte) {
+	c.SSEvent("status", status)
+	c.Writer.Flush()
+	if isFinished(status.Status) {
+		return
+	}
+	ticker := time.NewTicker(cachedStatusPoll)
+	defer ticker.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ticker.C:
+			latest, latestPayload, ok := taskManager.cachedStatus(status.TaskID)
+			if !ok {
+				return false
+			}
+			if bytes.Equal(latestPayload, payload) {
+				return true
+			}
+			payload = latestPayload
+			c.SSEvent("status", latest)
+			return !isFinished(latest.Status)
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/models"
+)
+
+// memoryStatusCache is a statuscache.Cache shared by the task managers of a test
+type memoryStatusCache struct {
+	mu       sync.Mutex
+	statuses map[string][]byte
+}
+
+func (m *memoryStatusCache) Set(taskID string, status []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statuses[taskID] = status
+	return nil
+}
+
+func (m *memoryStatusCache) Get(taskID string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.statuses[taskID], nil
+}
+
+func (m *memoryStatusCache) Delete(taskID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.statuses, taskID)
+	return nil
+}
+
+func TestStatusOfTaskOnAnotherReplica(t *testing.T) {
+	cache := &memoryStatusCache{statuses: make(map[string][]byte)}
+
+	// The replica running the task publishes its status...
+	runner, _ := eventTaskManager(t)
+	runner.statusCache = cache
+	runner.addTask(&TaskInfo{ID: "t1", Status: &models.MigrationStatus{TaskID: "t1", Status: "running"}})
+	runner.progressCallback("t1")(40, 4, 10, 400, 1000, 2, "3s")
+
+	// ...and another replica serves it from the cache
+	reader, _ := eventTaskManager(t)
+	reader.statusCache = cache
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/status/:taskID", GetStatus)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/status/t1", nil))
+		var status models.MigrationStatus
+		json.Unmarshal(recorder.Body.Bytes(), &status)
+		if recorder.Code == http.StatusOK && status.CopiedObjects == 4 && status.ETA == "3s" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("status from the other replica = %d %s", recorder.Code, recorder.Body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Removed tasks leave the cache
+	reader.uncacheStatus("t1")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/status/t1", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("status of a removed task = %d %s", recorder.Code, recorder.Body)
+	}
+}
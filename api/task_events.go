@@ -0,0 +1,396 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/models"
+	"s3migration/pkg/progress"
+	"s3migration/pkg/state"
+)
+
+// taskSaveInterval is how often progress-only changes of a task are persisted;
+// status transitions are persisted as soon as they happen
+const taskSaveInterval = 5 * time.Second
+
+// taskEventBuffer bounds the queued task updates; each task has at most one queued
+const taskEventBuffer = 4096
+
+// taskCounters holds the progress of a running task. Migration workers store into
+// it without taking the task manager lock; status reads fold it in.
+type taskCounters struct {
+	updated    atomic.Bool   // Stored since the status last absorbed the counters
+	progress   atomic.Uint64 // math.Float64bits
+	copied     atomic.Int64
+	total      atomic.Int64
+	copiedSize atomic.Int64
+	totalSize  atomic.Int64
+	speed      atomic.Uint64 // math.Float64bits
+	eta        atomic.Pointer[string]
+	estimate   atomic.Pointer[models.ETAEstimate]
+	lastUpdate atomic.Int64 // Unix nanoseconds
+}
+
+// store records a ProgressCallback report
+func (c *taskCounters) store(progress float64, copied, total, copiedSize, totalSize int64, speed float64, eta string) {
+	c.progress.Store(math.Float64bits(progress))
+	c.copied.Store(copied)
+	c.total.Store(total)
+	c.copiedSize.Store(copiedSize)
+	c.totalSize.Store(totalSize)
+	c.speed.Store(math.Float64bits(speed))
+	c.eta.Store(&eta)
+	c.lastUpdate.Store(time.Now().UnixNano())
+	c.updated.Store(true)
+}
+
+// overlay copies the counters into status if they are newer than it
+func (c *taskCounters) overlay(status *models.MigrationStatus) {
+	if estimate := c.estimate.Load(); estimate != nil {
+		status.ETAEstimate = estimate
+	}
+	if !c.updated.Load() {
+		return
+	}
+	status.Progress = math.Float64frombits(c.progress.Load())
+	status.CopiedObjects = c.copied.Load()
+	status.TotalObjects = c.total.Load()
+	status.CopiedSize = c.copiedSize.Load()
+	status.TotalSize = c.totalSize.Load()
+	status.CurrentSpeed = math.Float64frombits(c.speed.Load())
+	if eta := c.eta.Load(); eta != nil {
+		status.ETA = *eta
+	}
+	status.LastUpdateTime = time.Unix(0, c.lastUpdate.Load())
+}
+
+// absorb moves the counters into status, so that later changes to the status
+// (such as final totals) are not overridden by older progress
+func (c *taskCounters) absorb(status *models.MigrationStatus) {
+	c.overlay(status)
+	c.updated.Store(false)
+	c.estimate.Store(nil)
+}
+
+// status returns the current status of the task without taking the task manager lock
+func (task *TaskInfo) status() models.MigrationStatus {
+	snapshot := task.snapshot.Load()
+	if snapshot == nil {
+		return models.MigrationStatus{TaskID: task.ID}
+	}
+	status := *snapshot
+	task.counters.overlay(&status)
+	return status
+}
+
+// publish stores a copy of task.Status as the snapshot read by status; the
+// task manager lock must be held
+func (task *TaskInfo) publish() {
+	snapshot := *task.Status
+	snapshot.Errors = append([]string(nil), task.Status.Errors...)
+	snapshot.Notes = append([]models.TaskNote(nil), task.Status.Notes...)
+	task.snapshot.Store(&snapshot)
+}
+
+// addTask registers a task and publishes its first status
+func (tm *TaskManager) addTask(task *TaskInfo) {
+	tm.mu.Lock()
+	tm.tasks[task.ID] = task
+	task.publish()
+	task.version.Add(1)
+	tm.mu.Unlock()
+	tm.notify(task)
+}
+
+// getTask returns a registered task
+func (tm *TaskManager) getTask(taskID string) (*TaskInfo, bool) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	task, exists := tm.tasks[taskID]
+	return task, exists
+}
+
+// updateTask applies change to a task under the task manager lock, then publishes
+// its new status; false if the task is not registered
+func (tm *TaskManager) updateTask(taskID string, change func(task *TaskInfo)) bool {
+	tm.mu.Lock()
+	task, exists := tm.tasks[taskID]
+	if exists {
+		task.counters.absorb(task.Status)
+		change(task)
+		task.publish()
+		task.version.Add(1)
+	}
+	tm.mu.Unlock()
+	if exists {
+		tm.notify(task)
+	}
+	return exists
+}
+
+// progressCallback returns a ProgressCallback that records into the counters of a task
+func (tm *TaskManager) progressCallback(taskID string) func(progress float64, copied, total int64, copiedSize, totalSize int64, speed float64, eta string) {
+	task, exists := tm.getTask(taskID)
+	if !exists {
+		return func(float64, int64, int64, int64, int64, float64, string) {}
+	}
+	return func(progress float64, copied, total int64, copiedSize, totalSize int64, speed float64, eta string) {
+		task.counters.store(progress, copied, total, copiedSize, totalSize, speed, eta)
+		task.version.Add(1)
+		tm.notify(task)
+	}
+}
+
+// progressReporter returns a Reporter that records progress into the counters
+// of a task and phase changes into its status
+func (tm *TaskManager) progressReporter(taskID string) progress.Reporter {
+	task, exists := tm.getTask(taskID)
+	if !exists {
+		return progress.Callbacks{}
+	}
+	return progress.Callbacks{
+		OnPhase: func(phase string) {
+			tm.updateTask(taskID, func(task *TaskInfo) {
+				task.Status.Phase = phase
+			})
+		},
+		OnProgress: func(update progress.Update) {
+			if update.Estimate != nil {
+				task.counters.estimate.Store(update.Estimate)
+			}
+			task.counters.store(update.Progress, update.CopiedObjects, update.TotalObjects,
+				update.CopiedBytes, update.TotalBytes, update.SpeedMBps, update.ETA)
+			task.version.Add(1)
+			tm.notify(task)
+		},
+	}
+}
+
+// notify queues an update of task for persistence and subscribers. A task is
+// queued at most once; the update picks up every change made before it is handled.
+func (tm *TaskManager) notify(task *TaskInfo) {
+	if !task.queued.CompareAndSwap(false, true) {
+		return
+	}
+	select {
+	case tm.events <- task:
+	default:
+		task.queued.Store(false)
+		fmt.Printf("Warning: task update queue full, dropping update of %s\n", task.ID)
+	}
+}
+
+// savedTask records what was last persisted of a task
+type savedTask struct {
+	version uint64
+	status  string
+}
+
+// processEvents persists and broadcasts task updates until stop is closed.
+// Status transitions are saved at once; other changes are collected and saved
+// every taskSaveInterval in one batch, skipping tasks unchanged since their last save.
+func (tm *TaskManager) processEvents(stop <-chan struct{}) {
+	ticker := time.NewTicker(taskSaveInterval)
+	defer ticker.Stop()
+
+	saved := make(map[string]savedTask)
+	unsaved := make(map[string]*TaskInfo)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case task := <-tm.events:
+			task.queued.Store(false)
+			version := task.version.Load()
+			status := task.status()
+			tm.broadcast(status)
+			tm.cacheStatus(status)
+			if saved[task.ID].status == status.Status {
+				unsaved[task.ID] = task
+				continue
+			}
+			if !tm.registered(task) {
+				delete(saved, task.ID)
+				delete(unsaved, task.ID)
+				continue
+			}
+			if err := tm.saveTaskState(task, status); err != nil {
+				unsaved[task.ID] = task // Retried with the next batch
+				continue
+			}
+			delete(unsaved, task.ID)
+			saved[task.ID] = savedTask{version: version, status: status.Status}
+		case <-ticker.C:
+			tm.saveChanged(unsaved, saved)
+		}
+	}
+}
+
+// saveChanged saves the unsaved tasks that changed since their last save in one batch
+func (tm *TaskManager) saveChanged(unsaved map[string]*TaskInfo, saved map[string]savedTask) {
+	var states []*state.TaskState
+	var records []savedTask
+	var tasks []*TaskInfo
+	for _, task := range unsaved {
+		if !tm.registered(task) {
+			delete(saved, task.ID)
+			delete(unsaved, task.ID)
+			continue
+		}
+		version := task.version.Load()
+		if version == saved[task.ID].version {
+			delete(unsaved, task.ID)
+			continue
+		}
+		status := task.status()
+		states = append(states, taskStateFor(task, status))
+		records = append(records, savedTask{version: version, status: status.Status})
+		tasks = append(tasks, task)
+	}
+	if len(states) == 0 || tm.stateManager == nil {
+		return
+	}
+	if err := tm.stateManager.SaveTasks(states); err != nil {
+		fmt.Printf("Warning: failed to save %d task states: %v\n", len(states), err)
+		return // Kept unsaved for the next batch
+	}
+	for i, task := range tasks {
+		saved[task.ID] = records[i]
+		delete(unsaved, task.ID)
+	}
+}
+
+// registered reports whether task is still the registered task of its ID; removed
+// tasks are not saved, so that they do not come back in the database
+func (tm *TaskManager) registered(task *TaskInfo) bool {
+	current, exists := tm.getTask(task.ID)
+	return exists && current == task
+}
+
+// taskSubscribers fans task status updates out to streaming clients
+type taskSubscribers struct {
+	mu   sync.Mutex
+	subs map[string]map[chan models.MigrationStatus]struct{}
+}
+
+// subscribe returns a channel receiving the latest status of a task after each
+// update, and a function that ends the subscription
+func (tm *TaskManager) subscribe(taskID string) (<-chan models.MigrationStatus, func()) {
+	updates := make(chan models.MigrationStatus, 1)
+	s := &tm.subscribers
+	s.mu.Lock()
+	if s.subs == nil {
+		s.subs = make(map[string]map[chan models.MigrationStatus]struct{})
+	}
+	if s.subs[taskID] == nil {
+		s.subs[taskID] = make(map[chan models.MigrationStatus]struct{})
+	}
+	s.subs[taskID][updates] = struct{}{}
+	s.mu.Unlock()
+
+	return updates, func() {
+		s.mu.Lock()
+		delete(s.subs[taskID], updates)
+		if len(s.subs[taskID]) == 0 {
+			delete(s.subs, taskID)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// broadcast hands status to the subscribers of its task; a slow subscriber only
+// gets the latest status
+func (tm *TaskManager) broadcast(status models.MigrationStatus) {
+	s := &tm.subscribers
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for updates := range s.subs[status.TaskID] {
+		select {
+		case <-updates: // Replace an update the subscriber has not read yet
+		default:
+		}
+		updates <- status
+	}
+}
+
+// taskPollInterval is how often awaitTask reads the status of a task, in case
+// an update is dropped or not delivered; reading it is cheap
+const taskPollInterval = time.Second
+
+// awaitTask blocks until a task finishes and returns its final status, or the
+// error of ctx if it ends first; updates is a subscription to the task taken
+// before it started
+func awaitTask(ctx context.Context, task *TaskInfo, updates <-chan models.MigrationStatus) (models.MigrationStatus, error) {
+	ticker := time.NewTicker(taskPollInterval)
+	defer ticker.Stop()
+	status := task.status()
+	for !isFinished(status.Status) {
+		select {
+		case status = <-updates:
+		case <-ticker.C:
+			status = task.status()
+		case <-ctx.Done():
+			return status, ctx.Err()
+		}
+	}
+	return status, nil
+}
+
+// isFinished reports whether a task status is final
+func isFinished(status string) bool {
+	switch status {
+	case "completed", "completed_with_errors", "failed", "cancelled":
+		return true
+	}
+	return false
+}
+
+// StreamStatus handles GET /api/status/:taskID/events
+// @Summary Stream migration status
+// @Description Server-sent events with the status of a task after each update, until it finishes. Tasks running on another API replica are followed through the Redis status cache.
+// @Tags migration
+// @Produce text/event-stream
+// @Param taskID path string true "Task ID"
+// @Success 200 {object} models.MigrationStatus
+// @Failure 404 {object} ErrorResponse
+// @Router /api/status/{taskID}/events [get]
+func StreamStatus(c *gin.Context) {
+	task, exists := taskManager.getTask(c.Param("taskID"))
+	if !exists {
+		// Running on another replica: follow it through the shared status cache
+		if status, payload, cached := taskManager.cachedStatus(c.Param("taskID")); cached {
+			streamCachedStatus(c, status, payload)
+			return
+		}
+		respondError(c, http.StatusNotFound, "task not found")
+		return
+	}
+
+	updates, unsubscribe := taskManager.subscribe(task.ID)
+	defer unsubscribe()
+
+	status := task.status()
+	c.SSEvent("status", status)
+	c.Writer.Flush()
+	if isFinished(status.Status) {
+		return
+	}
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case status := <-updates:
+			c.SSEvent("status", status)
+			return !isFinished(status.Status)
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/models"
+	"s3migration/pkg/state"
+)
+
+// eventTaskManager installs a task manager backed by in-memory state whose
+// update loop runs for the duration of the test
+func eventTaskManager(t *testing.T) (*TaskManager, *state.MemoryStateManager) {
+	t.Helper()
+	previous := taskManager
+	stateManager := state.NewMemoryStateManager()
+	taskManager = newTaskManager(stateManager)
+	stop := make(chan struct{})
+	go taskManager.processEvents(stop)
+	t.Cleanup(func() {
+		close(stop)
+		taskManager = previous
+	})
+	return taskManager, stateManager
+}
+
+func TestProgressCountersAndStatusUpdates(t *testing.T) {
+	tm, _ := eventTaskManager(t)
+	tm.addTask(&TaskInfo{ID: "t1", Status: &models.MigrationStatus{TaskID: "t1", Status: "running"}})
+	task, _ := tm.getTask("t1")
+
+	report := tm.progressCallback("t1")
+	var workers sync.WaitGroup
+	for i := 1; i <= 100; i++ {
+		workers.Add(1)
+		go func(copied int64) {
+			defer workers.Done()
+			report(float64(copied), copied, 100, copied*10, 1000, 1.5, "1s")
+			task.status()
+		}(int64(i))
+	}
+	workers.Wait()
+	report(50, 50, 100, 500, 1000, 2, "5s")
+
+	if status := task.status(); status.CopiedObjects != 50 || status.CopiedSize != 500 || status.ETA != "5s" || status.Status != "running" {
+		t.Fatalf("status after progress = %+v", status)
+	}
+
+	// Final totals set through updateTask are not overridden by earlier progress
+	tm.updateTask("t1", func(task *TaskInfo) {
+		task.Status.Status = "completed"
+		task.Status.CopiedObjects = 100
+	})
+	if status := task.status(); status.CopiedObjects != 100 || status.CopiedSize != 500 || status.Status != "completed" {
+		t.Fatalf("status after completion = %+v", status)
+	}
+	if tm.updateTask("missing", func(*TaskInfo) { t.Fatal("change applied to a missing task") }) {
+		t.Fatal("updateTask reported a missing task as updated")
+	}
+}
+
+func TestStatusTransitionsArePersisted(t *testing.T) {
+	tm, stateManager := eventTaskManager(t)
+	tm.addTask(&TaskInfo{ID: "t1", Status: &models.MigrationStatus{TaskID: "t1", Status: "running"}})
+	tm.updateTask("t1", func(task *TaskInfo) {
+		task.Status.Status = "failed"
+		task.Status.Errors = append(task.Status.Errors, "boom")
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		saved, _ := stateManager.LoadTask("t1")
+		if saved != nil && saved.Status == "failed" && len(saved.Errors) == 1 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("saved state = %+v", saved)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStreamStatus(t *testing.T) {
+	tm, _ := eventTaskManager(t)
+	tm.addTask(&TaskInfo{ID: "t1", Status: &models.MigrationStatus{TaskID: "t1", Status: "running"}})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/status/:taskID/events", StreamStatus)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	if resp, err := http.Get(server.URL + "/api/status/unknown/events"); err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown task: %v %v", resp, err)
+	}
+
+	resp, err := http.Get(server.URL + "/api/status/t1/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	events := bufio.NewScanner(resp.Body)
+	next := func() string {
+		for events.Scan() {
+			if data, ok := strings.CutPrefix(events.Text(), "data:"); ok {
+				return data
+			}
+		}
+		t.Fatalf("stream ended: %v", events.Err())
+		return ""
+	}
+
+	if first := next(); !strings.Contains(first, `"status":"running"`) {
+		t.Fatalf("first event = %s", first)
+	}
+	tm.progressCallback("t1")(50, 1, 2, 10, 20, 1, "1s")
+	tm.updateTask("t1", func(task *TaskInfo) { task.Status.Status = "completed" })
+	for {
+		event := next()
+		if strings.Contains(event, `"status":"completed"`) {
+			break
+		}
+	}
+	if events.Scan() && strings.HasPrefix(events.Text(), "data:") {
+		t.Fatalf("event after the task finished: %s", events.Text())
+	}
+}
+
+// batchRecorder records the batches passed to SaveTasks
+type batchRecorder struct {
+	*state.MemoryStateManager
+	batches [][]string
+}
+
+func (r *batchRecorder) SaveTasks(tasks []*state.TaskState) error {
+	var ids []string
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	r.batches = append(r.batches, ids)
+	return r.MemoryStateManager.SaveTasks(tasks)
+}
+
+func TestSaveChangedSkipsUnchangedTasks(t *testing.T) {
+	recorder := &batchRecorder{MemoryStateManager: state.NewMemoryStateManager()}
+	tm := newTaskManager(recorder)
+	unsaved := make(map[string]*TaskInfo)
+	saved := make(map[string]savedTask)
+	for _, id := range []string{"idle", "busy", "removed"} {
+		task := &TaskInfo{ID: id, Status: &models.MigrationStatus{TaskID: id, Status: "running"}}
+		tm.addTask(task)
+		unsaved[id] = task
+		saved[id] = savedTask{version: task.version.Load(), status: "running"}
+	}
+	tm.progressCallback("busy")(10, 1, 10, 1, 10, 1, "9s")
+	tm.progressCallback("removed")(10, 1, 10, 1, 10, 1, "9s")
+	tm.mu.Lock()
+	delete(tm.tasks, "removed")
+	tm.mu.Unlock()
+
+	busy := unsaved["busy"]
+	tm.saveChanged(unsaved, saved)
+	if len(recorder.batches) != 1 || len(recorder.batches[0]) != 1 || recorder.batches[0][0] != "busy" {
+		t.Fatalf("batches = %v, want [[busy]]", recorder.batches)
+	}
+	if len(unsaved) != 0 {
+		t.Fatalf("unsaved after saving = %v", unsaved)
+	}
+	if saved, _ := recorder.LoadTask("busy"); saved == nil || saved.CopiedObjects != 1 {
+		t.Fatalf("saved busy task = %+v", saved)
+	}
+
+	// Nothing changed since: no further writes
+	tm.saveChanged(map[string]*TaskInfo{"busy": busy}, saved)
+	if len(recorder.batches) != 1 {
+		t.Fatalf("unchanged task saved again: %v", recorder.batches)
+	}
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/core"
+	"s3migration/pkg/models"
+	"s3migration/pkg/prefetch"
+	"s3migration/pkg/state"
+)
+
+// memoryTaskListings keeps task listings when tasks are not stored in a database
+var memoryTaskListings = state.NewMemoryTaskListingStore()
+
+// taskListingStore returns where the destination listings of tasks are kept
+func taskListingStore() prefetch.TaskListingStore {
+	if dbManager, ok := taskManager.stateManager.(*state.DBStateManager); ok {
+		return state.NewTaskListingManager(dbManager.GetDB())
+	}
+	return memoryTaskListings
+}
+
+// taskListingPolicyFor caches the destination listing of a task that asks for it
+func taskListingPolicyFor(req *models.MigrationRequest, taskID string) *core.TaskListingPolicy {
+	if !req.CacheListing || req.DryRun {
+		return nil
+	}
+	return &core.TaskListingPolicy{TaskID: taskID, Store: taskListingStore()}
+}
+
+// verifyListingFor returns the cached listing a verification compares against (nil = list the destination)
+func verifyListingFor(req *models.MigrationRequest) *core.TaskListingSource {
+	if req.Verify == nil || req.Verify.FromTaskListing == "" {
+		return nil
+	}
+	return &core.TaskListingSource{TaskID: req.Verify.FromTaskListing, Store: taskListingStore()}
+}
+
+// GetTaskListing handles GET /api/tasks/:taskId/listing
+// @Summary Get the cached destination listing of a task
+// @Description Describe the destination listing a task with cache_listing stored when it completed. Verifications with verify.from_task_listing compare against it instead of listing the destination again.
+// @Tags migration
+// @Produce json
+// @Param taskId path string true "Task ID"
+// @Success 200 {object} prefetch.TaskListing
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/tasks/{taskId}/listing [get]
+func GetTaskListing(c *gin.Context) {
+	listing, err := taskListingStore().LoadTaskListing(c.Param("taskId"))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if listing == nil {
+		respondError(c, http.StatusNotFound, "task has no cached destination listing")
+		return
+	}
+	c.JSON(http.StatusOK, listing)
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+	"s3migration/pkg/prefetch"
+)
+
+func TestVerifyFromTaskListing(t *testing.T) {
+	endpoint := fakes3.New("source", "dest")
+	defer endpoint.Close()
+	endpoint.Put("source", "logs/a.txt", []byte("alpha"))
+	endpoint.Put("source", "logs/b.txt", []byte("bravo"))
+	router := testRouter(t, endpoint)
+	router.GET("/api/tasks/:taskId/listing", GetTaskListing)
+
+	var started models.MigrationStatus
+	json.Unmarshal(serve(router, http.MethodPost, "/api/migrate", `{"source_bucket": "source", "dest_bucket": "dest", "cache_listing": true}`).Body.Bytes(), &started)
+	waitForStatus(t, router, started.TaskID, func(s models.MigrationStatus) bool { return s.Status == "completed" })
+
+	resp := serve(router, http.MethodGet, "/api/tasks/"+started.TaskID+"/listing", "")
+	var listing prefetch.TaskListing
+	json.Unmarshal(resp.Body.Bytes(), &listing)
+	if resp.Code != http.StatusOK || listing.Bucket != "dest" || listing.Objects != 2 || listing.Parts != 1 {
+		t.Fatalf("listing = %d %s", resp.Code, resp.Body)
+	}
+	if resp := serve(router, http.MethodGet, "/api/tasks/unknown/listing", ""); resp.Code != http.StatusNotFound {
+		t.Errorf("unknown task listing = %d", resp.Code)
+	}
+
+	// Written to the source since: missing from the listing the verification reads
+	endpoint.Put("source", "logs/c.txt", []byte("charlie"))
+	body := fmt.Sprintf(`{"source_bucket": "source", "dest_bucket": "dest", "verify": {"from_task_listing": %q}}`, started.TaskID)
+	var verify models.MigrationStatus
+	json.Unmarshal(serve(router, http.MethodPost, "/api/migrate", body).Body.Bytes(), &verify)
+	status := waitForStatus(t, router, verify.TaskID, func(s models.MigrationStatus) bool { return s.Status == "completed" })
+	if status.Drift == nil || status.Drift.Missing != 1 || status.Drift.DestObjects != 2 || status.Drift.DestListedAt == nil || !status.Drift.DestListedAt.Equal(listing.ListedAt) {
+		t.Errorf("drift = %+v", status.Drift)
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"s3migration/pkg/models"
+	"s3migration/pkg/state"
+)
+
+// What POST /api/migrate does with a task whose route an active task is
+// already copying (DUPLICATE_TASK_POLICY)
+const (
+	duplicateAllow  = "allow"  // Start it anyway (default)
+	duplicateReject = "reject" // Answer 409 Conflict
+	duplicateQueue  = "queue"  // Hold it as pending until the active task finishes
+)
+
+// taskLockRetryInterval is how often a queued task tries for the lock of its route
+var taskLockRetryInterval = 5 * time.Second
+
+// memoryTaskLocks keeps route locks when tasks are not stored in a database
+var memoryTaskLocks = state.NewMemoryTaskLockStore()
+
+// duplicateTaskPolicy reads DUPLICATE_TASK_POLICY
+func duplicateTaskPolicy() string {
+	switch policy := strings.ToLower(os.Getenv("DUPLICATE_TASK_POLICY")); policy {
+	case "", duplicateAllow:
+		return duplicateAllow
+	case duplicateReject, duplicateQueue:
+		return policy
+	default:
+		fmt.Printf("⚠️  Invalid DUPLICATE_TASK_POLICY=%q, using %s\n", policy, duplicateAllow)
+		return duplicateAllow
+	}
+}
+
+// taskLocks returns the route lock store, shared by all replicas when tasks are stored in a database
+func taskLocks() state.TaskLockStore {
+	if dbManager, ok := taskManager.stateManager.(*state.DBStateManager); ok {
+		return state.NewTaskLockManager(dbManager.GetDB())
+	}
+	return memoryTaskLocks
+}
+
+// routeLockKey hashes the route of a request: source endpoint, bucket and
+// prefix, and destination endpoint, bucket and prefix
+func routeLockKey(req models.MigrationRequest) string {
+	source := req.SourceCredentials
+	if source == nil {
+		source = req.Credentials
+	}
+	dest := req.DestCredentials
+	if dest == nil {
+		dest = source
+	}
+	_, sourceEndpoint := endpointName(source)
+	_, destEndpoint := endpointName(dest)
+	route := strings.Join([]string{sourceEndpoint, req.SourceBucket, req.SourcePrefix, destEndpoint, req.DestBucket, req.DestPrefix}, "|")
+	sum := sha256.Sum256([]byte(route))
+	return hex.EncodeToString(sum[:])
+}
+
+// routeLock is the lock of a task on the route it copies
+type routeLock struct {
+	key    string
+	taskID string
+}
+
+// lockRoute takes the route lock of a request for taskID. It returns a nil lock
+// when the request is not guarded, and the ID of the active task holding the
+// lock when it could not be taken.
+func lockRoute(taskID string, req models.MigrationRequest) (*routeLock, string, error) {
+	if duplicateTaskPolicy() == duplicateAllow || req.Force || req.DryRun || req.Verify != nil {
+		return nil, "", nil
+	}
+	lock := &routeLock{key: routeLockKey(req), taskID: taskID}
+	holder, err := taskLocks().AcquireTaskLock(lock.key, taskID)
+	if err != nil {
+		return nil, "", err
+	}
+	if holder != taskID {
+		return lock, holder, nil
+	}
+	return lock, "", nil
+}
+
+// release drops the lock
+func (l *routeLock) release() {
+	if err := taskLocks().ReleaseTaskLock(l.key, l.taskID); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// releaseWhenFinished drops the lock once task finishes
+func (l *routeLock) releaseWhenFinished(task *TaskInfo) {
+	updates, unsubscribe := taskManager.subscribe(task.ID)
+	taskManager.goTask(func() {
+		defer unsubscribe()
+		awaitTask(context.Background(), task, updates)
+		l.release()
+	})
+}
+
+// queueMigration registers a pending task for a request whose route holder is
+// copying, and launches it once it gets the lock; cancelling the pending task
+// takes it out of the queue
+func queueMigration(taskID string, req models.MigrationRequest, lock *routeLock, holder string) *TaskInfo {
+	ctx, cancel := context.WithCancel(context.Background())
+	startTime := time.Now()
+	task := &TaskInfo{
+		ID: taskID,
+		Status: &models.MigrationStatus{
+			TaskID:        taskID,
+			Status:        "pending",
+			MigrationType: "s3",
+			StartTime:     startTime,
+			Request:       requestSummary(req),
+			QueuedBehind:  holder,
+		},
+		CancelFn:        cancel,
+		StartTime:       startTime,
+		OriginalRequest: *sanitizeRequestForStorage(&req), // Encrypt sensitive data
+	}
+	taskManager.addTask(task)
+
+	taskManager.goTask(func() {
+		defer cancel()
+		ticker := time.NewTicker(taskLockRetryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			current, err := taskLocks().AcquireTaskLock(lock.key, taskID)
+			if err != nil {
+				fmt.Printf("Warning: queued task %s: %v\n", taskID, err)
+				continue
+			}
+			if current != taskID {
+				taskManager.updateTask(taskID, func(task *TaskInfo) { task.Status.QueuedBehind = current })
+				continue
+			}
+			if ctx.Err() != nil {
+				lock.release()
+				return
+			}
+			launched, err := launchMigration(taskID, req, startTime)
+			if err != nil {
+				lock.release()
+				taskManager.updateTask(taskID, func(task *TaskInfo) {
+					task.Status.Status = "failed"
+					task.Status.QueuedBehind = ""
+					task.Status.Errors = append(task.Status.Errors, err.Error())
+				})
+				return
+			}
+			lock.releaseWhenFinished(launched)
+			return
+		}
+	})
+	return task
+}
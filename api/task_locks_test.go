@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+)
+
+func TestDuplicateTaskPolicy(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	endpoint.Put("source", "a.txt", []byte("alpha"))
+	router := testRouter(t, endpoint)
+	previous := taskLockRetryInterval
+	taskLockRetryInterval = 10 * time.Millisecond
+	t.Cleanup(func() { taskLockRetryInterval = previous })
+
+	// An active task on another replica holds the route
+	key := routeLockKey(models.MigrationRequest{SourceBucket: "source", DestBucket: "dest"})
+	if holder, _ := memoryTaskLocks.AcquireTaskLock(key, "active-task"); holder != "active-task" {
+		t.Fatalf("lock held by %s", holder)
+	}
+	defer memoryTaskLocks.ReleaseTaskLock(key, "active-task")
+	body := `{"source_bucket": "source", "dest_bucket": "dest"}`
+
+	t.Setenv("DUPLICATE_TASK_POLICY", "reject")
+	resp := serve(router, http.MethodPost, "/api/migrate", body)
+	var conflict struct {
+		Code    string `json:"code"`
+		Details struct {
+			ActiveTaskID string `json:"active_task_id"`
+		} `json:"details"`
+	}
+	if resp.Code != http.StatusConflict || json.Unmarshal(resp.Body.Bytes(), &conflict) != nil || conflict.Code != codeDuplicateTask || conflict.Details.ActiveTaskID != "active-task" {
+		t.Fatalf("duplicate task = %d: %s, want 409 naming active-task", resp.Code, resp.Body)
+	}
+	if resp = serve(router, http.MethodPost, "/api/migrate", `{"source_bucket": "source", "dest_bucket": "other"}`); resp.Code != http.StatusOK {
+		t.Errorf("task on another route = %d: %s", resp.Code, resp.Body)
+	}
+	if resp = serve(router, http.MethodPost, "/api/migrate", `{"source_bucket": "source", "dest_bucket": "dest", "force": true}`); resp.Code != http.StatusOK {
+		t.Errorf("forced duplicate task = %d: %s", resp.Code, resp.Body)
+	}
+
+	t.Setenv("DUPLICATE_TASK_POLICY", "queue")
+	resp = serve(router, http.MethodPost, "/api/migrate", body)
+	var status models.MigrationStatus
+	if resp.Code != http.StatusOK || json.Unmarshal(resp.Body.Bytes(), &status) != nil || status.Status != "pending" || status.QueuedBehind != "active-task" {
+		t.Fatalf("queued task = %d: %s", resp.Code, resp.Body)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if queued := waitForStatus(t, router, status.TaskID, func(models.MigrationStatus) bool { return true }); queued.Status != "pending" {
+		t.Fatalf("queued task started while the route was locked: %+v", queued)
+	}
+
+	memoryTaskLocks.ReleaseTaskLock(key, "active-task")
+	waitForStatus(t, router, status.TaskID, func(s models.MigrationStatus) bool { return s.Status == "completed" })
+	if string(endpoint.Get("dest", "a.txt").Data) != "alpha" {
+		t.Error("queued task did not copy a.txt")
+	}
+	// The finished task gives the route up
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		holder, _ := memoryTaskLocks.AcquireTaskLock(key, "next-task")
+		if holder == "next-task" {
+			memoryTaskLocks.ReleaseTaskLock(key, "next-task")
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("route still locked by %s", holder)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
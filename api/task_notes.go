@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/models"
+)
+
+// Limits on task notes
+const (
+	maxTaskNoteLength = 2000 // Characters of one note
+	maxTaskNotes      = 200  // Notes of one task
+)
+
+// AddTaskNote handles POST /api/tasks/:taskId/notes
+// @Summary Add a note to a task
+// @Description Attach a timestamped operator note to a task, such as "source team paused writes at 14:00". Notes are kept with the task and included in its status and exports.
+// @Tags migration
+// @Accept json
+// @Produce json
+// @Param taskId path string true "Task ID"
+// @Param note body models.TaskNoteRequest true "Note"
+// @Success 201 {object} models.TaskNote
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/tasks/{taskId}/notes [post]
+func AddTaskNote(c *gin.Context) {
+	taskID := c.Param("taskId")
+	var req models.TaskNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	note := models.TaskNote{
+		Time:   time.Now().UTC(),
+		Author: strings.TrimSpace(req.Author),
+		Text:   strings.TrimSpace(req.Text),
+	}
+	if note.Text == "" || utf8.RuneCountInString(note.Text) > maxTaskNoteLength {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("text must be 1 to %d characters", maxTaskNoteLength))
+		return
+	}
+	if note.Author == "" {
+		note.Author = clientKey(c)
+	}
+
+	full := false
+	if exists := taskManager.updateTask(taskID, func(task *TaskInfo) {
+		if full = len(task.Status.Notes) >= maxTaskNotes; !full {
+			task.Status.Notes = append(task.Status.Notes, note)
+		}
+	}); exists {
+		if full {
+			respondError(c, http.StatusConflict, fmt.Sprintf("task already has %d notes", maxTaskNotes))
+			return
+		}
+		c.JSON(http.StatusCreated, note)
+		return
+	}
+
+	// A task running on another replica saves its own notes over the database's
+	if status, _, cached := taskManager.cachedStatus(taskID); cached && !finishedStatus(status.Status) {
+		respondError(c, http.StatusConflict, "task is running on another instance; add the note there")
+		return
+	}
+	taskState, err := taskManager.stateManager.LoadTask(taskID)
+	if err != nil || taskState == nil {
+		respondError(c, http.StatusNotFound, "task not found")
+		return
+	}
+	if len(taskState.Notes) >= maxTaskNotes {
+		respondError(c, http.StatusConflict, fmt.Sprintf("task already has %d notes", maxTaskNotes))
+		return
+	}
+	taskState.Notes = append(taskState.Notes, note)
+	if err := taskManager.stateManager.SaveTask(taskState); err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, note)
+}
+
+// finishedStatus reports whether a task status is final
+func finishedStatus(status string) bool {
+	return status == "completed" || status == "failed" || status == "cancelled"
+}
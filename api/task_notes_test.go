@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"s3migration/pkg/models"
+	"s3migration/pkg/state"
+)
+
+func TestAddTaskNote(t *testing.T) {
+	router := testRouter(t, nil)
+	router.POST("/api/tasks/:taskId/notes", AddTaskNote)
+	taskManager.addTask(&TaskInfo{ID: "live", Status: &models.MigrationStatus{TaskID: "live", Status: "running"}})
+	taskManager.stateManager.SaveTask(&state.TaskState{ID: "stored", Status: "completed", StartTime: time.Now()})
+
+	tests := []struct {
+		name     string
+		taskID   string
+		body     string
+		want     int
+		wantNote models.TaskNote // Time is not compared
+	}{
+		{name: "live task", taskID: "live", body: `{"text": " source team paused writes at 14:00 ", "author": "alice"}`, want: http.StatusCreated,
+			wantNote: models.TaskNote{Author: "alice", Text: "source team paused writes at 14:00"}},
+		{name: "caller as author", taskID: "live", body: `{"text": "cutover confirmed"}`, want: http.StatusCreated,
+			wantNote: models.TaskNote{Author: "ip:192.0.2.1", Text: "cutover confirmed"}},
+		{name: "stored task", taskID: "stored", body: `{"text": "signed off"}`, want: http.StatusCreated,
+			wantNote: models.TaskNote{Author: "ip:192.0.2.1", Text: "signed off"}},
+		{name: "blank text", taskID: "live", body: `{"text": "   "}`, want: http.StatusBadRequest},
+		{name: "no text", taskID: "live", body: `{}`, want: http.StatusBadRequest},
+		{name: "text too long", taskID: "live", body: `{"text": "` + strings.Repeat("x", maxTaskNoteLength+1) + `"}`, want: http.StatusBadRequest},
+		{name: "unknown task", taskID: "missing", body: `{"text": "hello"}`, want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := serve(router, http.MethodPost, "/api/tasks/"+tt.taskID+"/notes", tt.body)
+			if resp.Code != tt.want {
+				t.Fatalf("got %d: %s", resp.Code, resp.Body)
+			}
+			if tt.want != http.StatusCreated {
+				return
+			}
+			var note models.TaskNote
+			json.Unmarshal(resp.Body.Bytes(), &note)
+			if note.Time.IsZero() || note.Author != tt.wantNote.Author || note.Text != tt.wantNote.Text {
+				t.Errorf("note = %+v, want %+v", note, tt.wantNote)
+			}
+		})
+	}
+
+	var status models.MigrationStatus
+	json.Unmarshal(serve(router, http.MethodGet, "/api/status/live", "").Body.Bytes(), &status)
+	if len(status.Notes) != 2 || status.Notes[0].Author != "alice" || status.Notes[1].Text != "cutover confirmed" {
+		t.Errorf("live task notes = %+v", status.Notes)
+	}
+	json.Unmarshal(serve(router, http.MethodGet, "/api/status/stored", "").Body.Bytes(), &status)
+	if len(status.Notes) != 1 || status.Notes[0].Text != "signed off" {
+		t.Errorf("stored task notes = %+v", status.Notes)
+	}
+}
+
+func TestFormatExportNotes(t *testing.T) {
+	at := time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC)
+	notes := []models.TaskNote{
+		{Time: at, Author: "alice", Text: "writes paused"},
+		{Time: at.Add(time.Hour), Author: "bob", Text: "cutover done"},
+	}
+	want := "2024-05-01T14:00:00Z alice: writes paused\n2024-05-01T15:00:00Z bob: cutover done"
+	if got := formatExportNotes(notes); got != want {
+		t.Errorf("formatExportNotes() = %q, want %q", got, want)
+	}
+	if got := formatExportNotes(nil); got != "" {
+		t.Errorf("formatExportNotes(nil) = %q", got)
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"s3migration/pkg/core"
+	"s3migration/pkg/models"
+	"s3migration/pkg/state"
+)
+
+// statusInterrupted is the status of S3 tasks that were running when the process stopped
+const statusInterrupted = "interrupted"
+
+// defaultAutoResumeDelay is how long after startup interrupted tasks are resumed
+// when AUTO_RESUME_DELAY is not set, leaving time to cancel them
+const defaultAutoResumeDelay = 30 * time.Second
+
+// autoResumeConfig reads AUTO_RESUME (resume interrupted tasks on startup) and
+// AUTO_RESUME_DELAY (e.g. "2m")
+func autoResumeConfig() (bool, time.Duration) {
+	enabled, _ := strconv.ParseBool(os.Getenv("AUTO_RESUME"))
+	value := os.Getenv("AUTO_RESUME_DELAY")
+	if value == "" {
+		return enabled, defaultAutoResumeDelay
+	}
+	delay, err := time.ParseDuration(value)
+	if err != nil || delay < 0 {
+		fmt.Printf("⚠️  Invalid AUTO_RESUME_DELAY=%q, using %v\n", value, defaultAutoResumeDelay)
+		return enabled, defaultAutoResumeDelay
+	}
+	return enabled, delay
+}
+
+// hasInlineKeys reports whether creds carry keys, which are never stored in a
+// form a new process may use; secret references and the pod's own identity are
+// available again after a restart, so keys fetched from a reference do not count
+func hasInlineKeys(creds *models.Credentials) bool {
+	return creds != nil && creds.SecretRef == "" && (creds.AccessKey != "" || creds.SecretKey != "" || creds.SessionToken != "")
+}
+
+// resumableRequest returns the stored (sanitized) request of a task if the task
+// can be resumed after a restart: not a dry run, and no inline keys or
+// inventory connection string
+func resumableRequest(req *models.MigrationRequest) *models.MigrationRequest {
+	if req.DryRun || hasInlineKeys(req.SourceCredentials) || hasInlineKeys(req.DestCredentials) || hasInlineKeys(req.Credentials) {
+		return nil
+	}
+	if inv := req.SourceInventory; inv != nil && (hasInlineKeys(inv.Credentials) || inv.DSN != "") {
+		return nil
+	}
+	resumable := *req
+	return &resumable
+}
+
+// memoryListingCheckpoints keeps listing checkpoints when tasks are not stored in a database
+var memoryListingCheckpoints = state.NewMemoryListingCheckpointStore()
+
+// listingCheckpointPolicyFor checkpoints the source listing of a task that can be
+// resumed, so a resumed task continues listing where it was interrupted
+func listingCheckpointPolicyFor(req *models.MigrationRequest, taskID string) *core.ListingCheckpointPolicy {
+	if resumableRequest(req) == nil {
+		return nil
+	}
+	if dbManager, ok := taskManager.stateManager.(*state.DBStateManager); ok {
+		return &core.ListingCheckpointPolicy{TaskID: taskID, Store: state.NewListingCheckpointManager(dbManager.GetDB())}
+	}
+	return &core.ListingCheckpointPolicy{TaskID: taskID, Store: memoryListingCheckpoints}
+}
+
+// resumePayload returns the resume request of a task as stored with its state,
+// or nil once the task has ended or when it cannot be resumed
+func resumePayload(taskInfo *TaskInfo, status string) map[string]interface{} {
+	if taskInfo.resume == nil || (status != "pending" && status != "running" && status != statusInterrupted) {
+		return nil
+	}
+	data, err := json.Marshal(taskInfo.resume)
+	if err != nil {
+		return nil
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil
+	}
+	return payload
+}
+
+// resumeRequest decodes the resume payload of a stored task
+func resumeRequest(taskState *state.TaskState) *models.MigrationRequest {
+	payload, ok := taskState.OriginalRequest["resume"]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil
+	}
+	var req models.MigrationRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil
+	}
+	return &req
+}
+
+// resumeInterruptedTasks restarts, after delay, the interrupted tasks whose request
+// was stored for resuming and that were not cancelled in the meantime. They run in
+// incremental mode under their own task ID, so objects already copied are skipped
+// and the integrity journal of the task continues.
+func (tm *TaskManager) resumeInterruptedTasks(delay time.Duration) {
+	time.Sleep(delay)
+
+	type interrupted struct {
+		id        string
+		req       models.MigrationRequest
+		startTime time.Time
+	}
+	var tasks []interrupted
+	tm.mu.RLock()
+	for id, task := range tm.tasks {
+		if task.Status.Status == statusInterrupted && task.resume != nil {
+			tasks = append(tasks, interrupted{id: id, req: *task.resume, startTime: task.StartTime})
+		}
+	}
+	tm.mu.RUnlock()
+
+	for _, task := range tasks {
+		if current, exists := tm.getTask(task.id); !exists || current.status().Status != statusInterrupted {
+			continue // Cancelled or cleaned up while waiting
+		}
+		req := task.req
+		req.MigrationMode = string(core.ModeIncremental)
+		if _, err := launchMigration(task.id, req, task.startTime); err != nil {
+			fmt.Printf("⚠️  Cannot resume task %s: %v\n", task.id, err)
+			tm.updateTask(task.id, func(info *TaskInfo) {
+				info.Status.Status = "failed"
+				info.Status.Errors = append(info.Status.Errors, fmt.Sprintf("Resume failed: %v", err))
+				info.Status.EndTime = time.Now()
+			})
+			continue
+		}
+		fmt.Printf("🔄 Resumed interrupted task %s (s3://%s → s3://%s)\n", task.id, req.SourceBucket, req.DestBucket)
+	}
+}
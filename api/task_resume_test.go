@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+	"s3migration/pkg/state"
+)
+
+func TestInterruptedTaskResumes(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	endpoint.Put("source", "a.txt", []byte("alpha"))
+	endpoint.Put("source", "b.txt", []byte("bravo"))
+	endpoint.Put("dest", "a.txt", []byte("alpha")) // Copied before the restart
+	router := testRouter(t, endpoint)
+
+	// State left by the previous pod: a running task stored with its resume request
+	running := &TaskInfo{ID: "resumable", StartTime: time.Now().Add(-time.Hour), OriginalRequest: models.MigrationRequest{SourceBucket: "source", DestBucket: "dest"}}
+	running.resume = resumableRequest(&running.OriginalRequest)
+	taskManager.stateManager.SaveTask(taskStateFor(running, models.MigrationStatus{Status: "running", MigrationType: "s3"}))
+	keyed := &TaskInfo{ID: "keyed", OriginalRequest: *sanitizeRequestForStorage(&models.MigrationRequest{
+		SourceBucket: "source", DestBucket: "dest", SourceCredentials: &models.Credentials{AccessKey: "AKIA", SecretKey: "secret"},
+	})}
+	keyed.resume = resumableRequest(&keyed.OriginalRequest)
+	taskManager.stateManager.SaveTask(taskStateFor(keyed, models.MigrationStatus{Status: "running", MigrationType: "s3"}))
+	taskManager.stateManager.SaveTask(&state.TaskState{ID: "done", Status: "completed"})
+
+	if err := taskManager.loadExistingTasks(); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"resumable", "keyed"} {
+		task, exists := taskManager.getTask(id)
+		if !exists || task.status().Status != statusInterrupted {
+			t.Fatalf("%s not loaded as interrupted", id)
+		}
+	}
+	if stored, _ := taskManager.stateManager.LoadTask("resumable"); stored.Status != statusInterrupted || stored.EndTime != nil {
+		t.Fatalf("stored state = %+v", stored)
+	}
+	if keyed, _ := taskManager.getTask("keyed"); keyed.resume != nil {
+		t.Fatal("task with inline keys is resumable")
+	}
+	if listingCheckpointPolicyFor(&running.OriginalRequest, "resumable") == nil || listingCheckpointPolicyFor(&keyed.OriginalRequest, "keyed") != nil {
+		t.Fatal("source listings are checkpointed for tasks that cannot resume, or not for those that can")
+	}
+
+	taskManager.resumeInterruptedTasks(0)
+	status := waitForStatus(t, router, "resumable", func(status models.MigrationStatus) bool {
+		return status.Status == "completed"
+	})
+	if status.CopiedObjects != 1 || !status.StartTime.Equal(running.StartTime) {
+		t.Fatalf("resumed task copied %d objects (want only b.txt), started %v", status.CopiedObjects, status.StartTime)
+	}
+	if keys := endpoint.Keys("dest"); len(keys) != 2 {
+		t.Fatalf("dest keys = %v", keys)
+	}
+	if keyed, _ := taskManager.getTask("keyed"); keyed.status().Status != statusInterrupted {
+		t.Fatalf("task with inline keys is %s", keyed.status().Status)
+	}
+
+	// Interrupted tasks can be cancelled before they are resumed
+	router.DELETE("/api/tasks/:taskID", CancelTask)
+	if resp := serve(router, http.MethodDelete, "/api/tasks/keyed", ""); resp.Code != http.StatusOK {
+		t.Fatalf("cancel interrupted task = %d: %s", resp.Code, resp.Body)
+	}
+}
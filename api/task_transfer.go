@@ -0,0 +1,242 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"s3migration/pkg/models"
+	"s3migration/pkg/state"
+)
+
+// taskBundleVersion is the format version of exported task bundles
+const taskBundleVersion = 1
+
+// TaskBundle is a portable export of a task, used to hand a migration off to
+// another deployment. It never contains keys: credentials keep only region,
+// endpoint and secret reference.
+type TaskBundle struct {
+	Version      int                      `json:"version"`
+	ExportedAt   time.Time                `json:"exported_at"`
+	ExportedFrom string                   `json:"exported_from,omitempty"` // Hostname of the exporting instance
+	Task         *state.TaskState         `json:"task"`
+	Request      *models.MigrationRequest `json:"request,omitempty"`
+	Integrity    *state.IntegritySummary  `json:"integrity,omitempty"`
+	Objects      []state.IntegrityRecord  `json:"objects,omitempty"` // Per-object verification journal
+}
+
+// TaskImportResponse describes an imported task and how to resume it
+type TaskImportResponse struct {
+	TaskID          string `json:"task_id"`
+	Status          string `json:"status"`
+	ObjectsImported int    `json:"objects_imported"`
+	// Incremental re-run of the original request; add credentials and POST to /api/migrate
+	ResumeRequest *models.MigrationRequest `json:"resume_request,omitempty"`
+}
+
+// stripCredentialSecrets returns a copy of creds without keys
+func stripCredentialSecrets(creds *models.Credentials) *models.Credentials {
+	if creds == nil {
+		return nil
+	}
+	stripped := *creds
+	stripped.AccessKey, stripped.SecretKey, stripped.SessionToken = "", "", ""
+	return &stripped
+}
+
+// portableRequest returns a copy of req that is safe to move between deployments.
+// Encrypted keys are dropped too: they are bound to this deployment's encryption key.
+func portableRequest(req models.MigrationRequest) *models.MigrationRequest {
+	req.SourceCredentials = stripCredentialSecrets(req.SourceCredentials)
+	req.DestCredentials = stripCredentialSecrets(req.DestCredentials)
+	req.Credentials = stripCredentialSecrets(req.Credentials)
+	if req.SourceInventory != nil {
+		inventoryOpts := *req.SourceInventory
+		inventoryOpts.Credentials = stripCredentialSecrets(inventoryOpts.Credentials)
+		inventoryOpts.DSN = "" // Holds the database password
+		req.SourceInventory = &inventoryOpts
+	}
+	if req.Transform != nil {
+		transformOpts := *req.Transform
+		transformOpts.Headers = nil // May carry the hook's credentials
+		req.Transform = &transformOpts
+	}
+	return &req
+}
+
+// ExportTask handles GET /api/tasks/:taskId/export
+// @Summary Export a task
+// @Description Export task state, the original request (without keys) and the per-object integrity journal as a portable JSON bundle
+// @Tags tasks
+// @Produce json
+// @Param taskID path string true "Task ID"
+// @Success 200 {object} TaskBundle
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/tasks/{taskID}/export [get]
+func ExportTask(c *gin.Context) {
+	taskID := c.Param("taskId")
+
+	dbManager, ok := taskManager.stateManager.(*state.DBStateManager)
+	if !ok {
+		respondError(c, http.StatusInternalServerError, "task export requires the database state manager")
+		return
+	}
+
+	// Flush a live task first so the bundle has its latest progress
+	taskManager.mu.RLock()
+	task, inMemory := taskManager.tasks[taskID]
+	var request *models.MigrationRequest
+	if inMemory {
+		request = portableRequest(task.OriginalRequest)
+	}
+	taskManager.mu.RUnlock()
+	if inMemory {
+		if err := taskManager.saveTaskState(task, task.status()); err != nil {
+			respondError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	taskState, err := dbManager.LoadTaskFromPrimary(taskID) // Just written; a replica may not have it yet
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if taskState == nil {
+		respondError(c, http.StatusNotFound, "Task not found")
+		return
+	}
+	if request == nil && taskState.OriginalRequest != nil {
+		// Only the summary stored in the database is left; decode what it has
+		var stored models.MigrationRequest
+		if raw, err := json.Marshal(taskState.OriginalRequest); err == nil && json.Unmarshal(raw, &stored) == nil {
+			request = portableRequest(stored)
+		}
+	}
+	if request != nil {
+		raw, _ := json.Marshal(request)
+		taskState.OriginalRequest = nil
+		json.Unmarshal(raw, &taskState.OriginalRequest)
+	}
+
+	integrityManager := state.NewIntegrityManager(dbManager.GetDB())
+	summary, err := integrityManager.GetIntegritySummary(taskID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	records, err := integrityManager.ListIntegrityRecords(taskID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	hostname, _ := os.Hostname()
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=task-%s.json", taskID))
+	c.JSON(http.StatusOK, TaskBundle{
+		Version:      taskBundleVersion,
+		ExportedAt:   time.Now().UTC(),
+		ExportedFrom: hostname,
+		Task:         taskState,
+		Request:      request,
+		Integrity:    summary,
+		Objects:      records,
+	})
+}
+
+// ImportTask handles POST /api/tasks/import
+// @Summary Import a task
+// @Description Import a bundle from GET /api/tasks/{taskID}/export. A task that was still running is recorded as failed; resume it by posting resume_request (with credentials) to /api/migrate, which copies only what is missing.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param bundle body TaskBundle true "Exported task bundle"
+// @Param new_id query bool false "Import under a new task ID instead of failing when the ID exists"
+// @Success 201 {object} TaskImportResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/tasks/import [post]
+func ImportTask(c *gin.Context) {
+	var bundle TaskBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if bundle.Version != taskBundleVersion {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("unsupported bundle version %d (expected %d)", bundle.Version, taskBundleVersion))
+		return
+	}
+	if bundle.Task == nil || bundle.Task.ID == "" {
+		respondError(c, http.StatusBadRequest, "bundle has no task")
+		return
+	}
+
+	dbManager, ok := taskManager.stateManager.(*state.DBStateManager)
+	if !ok {
+		respondError(c, http.StatusInternalServerError, "task import requires the database state manager")
+		return
+	}
+
+	taskState := bundle.Task
+	existing, err := dbManager.LoadTaskFromPrimary(taskState.ID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if existing != nil {
+		if c.Query("new_id") != "true" {
+			respondErrorCode(c, http.StatusConflict, codeConflict, "a task with this ID already exists; retry with new_id=true", gin.H{"task_id": taskState.ID})
+			return
+		}
+		taskState.ID = uuid.New().String()
+	}
+
+	// Whatever was running stopped at export; it continues only through a new run here
+	if taskState.Status == "running" || taskState.Status == "pending" {
+		taskState.Status = "failed"
+		taskState.Errors = append(taskState.Errors, fmt.Sprintf("Migration handed off from %s; resume with resume_request", bundle.ExportedFrom))
+		now := time.Now()
+		taskState.EndTime = &now
+	}
+
+	// Keys never enter this deployment through a bundle, even a hand-edited one
+	var request *models.MigrationRequest
+	if bundle.Request != nil {
+		request = portableRequest(*bundle.Request)
+		raw, _ := json.Marshal(request)
+		taskState.OriginalRequest = nil
+		json.Unmarshal(raw, &taskState.OriginalRequest)
+	} else {
+		taskState.OriginalRequest = nil
+	}
+
+	if err := taskManager.stateManager.SaveTask(taskState); err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(bundle.Objects) > 0 {
+		if err := state.NewIntegrityManager(dbManager.GetDB()).ImportIntegrityRecords(taskState.ID, bundle.Objects); err != nil {
+			respondErrorCode(c, http.StatusInternalServerError, codeInternal, err.Error(), gin.H{"task_id": taskState.ID})
+			return
+		}
+	}
+
+	response := TaskImportResponse{
+		TaskID:          taskState.ID,
+		Status:          taskState.Status,
+		ObjectsImported: len(bundle.Objects),
+	}
+	if request != nil && request.SourceBucket != "" && !request.DryRun && taskState.Status != "completed" {
+		resume := *request
+		resume.MigrationMode = "incremental"
+		response.ResumeRequest = &resume
+	}
+	c.JSON(http.StatusCreated, response)
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+	"s3migration/pkg/providers/httpsource"
+)
+
+// StartURLListMigration starts ingesting a list of HTTP(S) URLs into S3
+func StartURLListMigration(c *gin.Context) {
+	var req models.URLListMigrationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate required fields
+	if len(req.URLs) == 0 && req.Manifest == "" && req.ManifestURL == "" {
+		respondError(c, http.StatusBadRequest, "one of urls, manifest or manifest_url is required")
+		return
+	}
+	if req.DestCredentials == nil {
+		respondError(c, http.StatusBadRequest, "dest_credentials is required")
+		return
+	}
+	if req.DestBucket == "" {
+		respondError(c, http.StatusBadRequest, "dest_bucket is required")
+		return
+	}
+
+	// Parse inline entries up front so bad input is rejected synchronously
+	var entries []httpsource.Entry
+	if len(req.URLs) > 0 {
+		parsed, err := httpsource.ParseManifest(strings.NewReader(strings.Join(req.URLs, "\n")))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		entries = append(entries, parsed...)
+	}
+	if req.Manifest != "" {
+		parsed, err := httpsource.ParseManifest(strings.NewReader(req.Manifest))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		entries = append(entries, parsed...)
+	}
+
+	taskID := uuid.New().String()
+
+	timeout := time.Duration(req.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = 24 * time.Hour // Default timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+	taskManager.addTask(&TaskInfo{
+		ID: taskID,
+		Status: &models.MigrationStatus{
+			TaskID:        taskID,
+			Status:        "pending",
+			MigrationType: "url-list",
+			Progress:      0,
+			StartTime:     time.Now(),
+			DryRun:        req.DryRun,
+		},
+		CancelFn:        cancel,
+		StartTime:       time.Now(),
+		OriginalRequest: models.MigrationRequest{}, // Empty for URL lists
+	})
+
+	go runURLListMigration(ctx, taskID, req, entries)
+
+	c.JSON(http.StatusOK, gin.H{
+		"task_id": taskID,
+		"message": "URL ingestion started",
+	})
+}
+
+// GetURLListObjects returns per-URL results (size, SHA-256, ETag, error) for a url-list task
+func GetURLListObjects(c *gin.Context) {
+	taskID := c.Param("taskID")
+
+	taskManager.mu.RLock()
+	task, exists := taskManager.tasks[taskID]
+	var objects []httpsource.ObjectResult
+	if exists {
+		objects = task.URLObjects
+	}
+	taskManager.mu.RUnlock()
+
+	if !exists {
+		respondError(c, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"task_id": taskID,
+		"objects": objects,
+		"count":   len(objects),
+	})
+}
+
+// failURLListTask marks a url-list task as failed with the given error
+func failURLListTask(taskID, message string) {
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
+		task.Status.Status = "failed"
+		task.Status.EndTime = time.Now()
+		task.Status.Errors = append(task.Status.Errors, message)
+	})
+}
+
+// runURLListMigration executes the URL ingestion
+func runURLListMigration(ctx context.Context, taskID string, req models.URLListMigrationRequest, entries []httpsource.Entry) {
+	defer func() {
+		if r := recover(); r != nil {
+			failURLListTask(taskID, fmt.Sprintf("Panic: %v", r))
+		}
+	}()
+
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
+		task.Status.Status = "running"
+	})
+
+	if req.ManifestURL != "" {
+		manifestEntries, err := httpsource.FetchManifest(ctx, req.ManifestURL)
+		if err != nil {
+			failURLListTask(taskID, fmt.Sprintf("Failed to load manifest: %v", err))
+			return
+		}
+		entries = append(entries, manifestEntries...)
+	}
+	if len(entries) == 0 {
+		failURLListTask(taskID, "No URLs to ingest")
+		return
+	}
+
+	cp, err := pool.NewConnectionPool(ctx, pool.ConnectionPoolConfig{
+		AccessKey:           req.DestCredentials.AccessKey,
+		SecretKey:           req.DestCredentials.SecretKey,
+		Region:              req.DestCredentials.Region,
+		EndpointURL:         req.DestCredentials.EndpointURL,
+		Timeout:             time.Hour,
+		CredentialsProvider: credentialsProviderFor(req.DestCredentials),
+	})
+	if err != nil {
+		failURLListTask(taskID, fmt.Sprintf("Failed to create connection pool: %v", err))
+		return
+	}
+
+	migrator := httpsource.NewMigrator(ctx, cp.GetClient())
+	result, err := migrator.Migrate(httpsource.MigrationInput{
+		Entries:          entries,
+		DestBucket:       req.DestBucket,
+		DestPrefix:       req.DestPrefix,
+		DryRun:           req.DryRun,
+		Concurrency:      req.Concurrency,
+		MaxRetries:       req.MaxRetries,
+		Headers:          req.Headers,
+		ProgressCallback: taskManager.progressCallback(taskID),
+	})
+	if err != nil {
+		failURLListTask(taskID, fmt.Sprintf("Migration failed: %v", err))
+		return
+	}
+
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
+		task.Status.Status = "completed"
+		task.Status.TotalObjects = result.TotalFiles
+		task.Status.CopiedObjects = result.CopiedFiles
+		task.Status.TotalSize = result.CopiedSize
+		task.Status.CopiedSize = result.CopiedSize
+		task.Status.Errors = append(task.Status.Errors, result.Errors...)
+		task.URLObjects = result.Objects
+
+		task.Status.EndTime = time.Now()
+		task.Status.Duration = formatDuration(task.Status.EndTime.Sub(task.Status.StartTime))
+
+		avgSpeed := 0.0
+		if result.Duration > 0 {
+			avgSpeed = float64(result.CopiedSize) / result.Duration.Seconds() / (1024 * 1024)
+		}
+		task.Result = &models.MigrationResult{
+			TaskID:       taskID,
+			Success:      result.FailedFiles == 0,
+			Copied:       result.CopiedFiles,
+			Failed:       result.FailedFiles,
+			TotalSizeMB:  float64(result.CopiedSize) / (1024 * 1024),
+			CopiedSizeMB: float64(result.CopiedSize) / (1024 * 1024),
+			ElapsedTime:  result.Duration.String(),
+			AvgSpeedMB:   avgSpeed,
+			Errors:       result.Errors,
+		}
+	})
+
+	fmt.Printf("URL ingestion completed. Ingested %d URLs, %d bytes\n",
+		result.CopiedFiles, result.CopiedSize)
+}
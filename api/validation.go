@@ -0,0 +1,55 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"s3migration/pkg/i18n"
+	"s3migration/pkg/models"
+	"s3migration/pkg/validation"
+)
+
+// respondValidationError writes a 400 with field-level errors the UI can render next to inputs
+// (details.errors), in the request's language. The message summarizes them.
+func respondValidationError(c *gin.Context, err error) {
+	var fieldErrs validation.Errors
+	if !errors.As(err, &fieldErrs) {
+		respondError(c, http.StatusBadRequest, localize(c, err.Error()))
+		return
+	}
+
+	localized := make(validation.Errors, len(fieldErrs))
+	for i, fe := range fieldErrs {
+		fe.Message = localize(c, fe.Message)
+		localized[i] = fe
+	}
+	respondErrorCode(c, http.StatusBadRequest, codeValidationFailed, localized.Error(), gin.H{"errors": localized})
+}
+
+// requestLanguage is the language a client asks for in Accept-Language, among those with a message catalog
+func requestLanguage(c *gin.Context) string {
+	return i18n.FromAcceptLanguage(c.GetHeader("Accept-Language"))
+}
+
+// localize translates a user-facing message for the request; logs keep the English original
+func localize(c *gin.Context, message string) string {
+	lang := requestLanguage(c)
+	if lang != i18n.English {
+		c.Header("Content-Language", lang)
+	}
+	return i18n.Translate(lang, message)
+}
+
+// localizeStatus translates the dry-run summary of a status about to be returned
+func localizeStatus(c *gin.Context, status *models.MigrationStatus) {
+	if len(status.DryRunVerified) == 0 || requestLanguage(c) == i18n.English {
+		return
+	}
+	verified := make([]string, len(status.DryRunVerified))
+	for i, line := range status.DryRunVerified {
+		verified[i] = localize(c, line)
+	}
+	status.DryRunVerified = verified
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"s3migration/pkg/core"
+	"s3migration/pkg/models"
+	"s3migration/pkg/scheduler"
+	"s3migration/pkg/secrets"
+	"s3migration/pkg/validation"
+)
+
+// runVerification runs a verification task: the source and destination are
+// compared and the drift reported, nothing is copied
+func runVerification(ctx context.Context, taskID string, migrator *core.EnhancedMigrator, req models.MigrationRequest) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Panic in verification %s: %v\n", taskID, r)
+			taskManager.updateTask(taskID, func(task *TaskInfo) {
+				task.Status.Status = "failed"
+				task.Status.Errors = append(task.Status.Errors, fmt.Sprintf("Panic: %v", r))
+			})
+		}
+	}()
+
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
+		task.Status.Status = "running"
+		task.Status.Phase = models.PhaseVerifying
+	})
+
+	report, err := migrator.Verify(ctx, s3MigrateInput(taskID, req), core.VerifyMode(req.Verify.Mode))
+	requests := migrator.RequestCounts()
+	latency := migrator.Latencies()
+
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
+		switch {
+		case err != nil && errors.Is(ctx.Err(), context.Canceled):
+			task.Status.Status = "cancelled"
+		case err != nil:
+			task.Status.Status = "failed"
+			task.Status.Errors = append(task.Status.Errors, err.Error())
+		default:
+			task.Status.Status = "completed"
+			task.Status.Progress = 100
+			task.Status.TotalObjects = report.SourceObjects
+			task.Status.TotalSize = report.SourceBytes
+			task.Status.Drift = report
+		}
+		task.Status.Phase = ""
+		task.Status.EndTime = time.Now()
+		task.Status.Duration = formatDuration(task.Status.EndTime.Sub(task.Status.StartTime))
+		task.Status.ETA = "0s"
+		task.Result = &models.MigrationResult{
+			TaskID:      taskID,
+			Success:     err == nil,
+			ElapsedTime: task.Status.Duration,
+			Errors:      task.Status.Errors,
+			Requests:    &requests,
+			Latency:     latency,
+		}
+	})
+	notifyTaskWebhook(taskID, &req)
+}
+
+// executeVerifySchedule starts a verification task for a run of a verify schedule
+func executeVerifySchedule(ctx context.Context, schedule *scheduler.Schedule) error {
+	sourceCreds, err := scheduleCredentials(schedule.Source.Credentials)
+	if err != nil {
+		return fmt.Errorf("schedule %s: source credentials: %w", schedule.ID, err)
+	}
+	destCreds, err := scheduleCredentials(schedule.Destination.Credentials)
+	if err != nil {
+		return fmt.Errorf("schedule %s: destination credentials: %w", schedule.ID, err)
+	}
+	req := models.MigrationRequest{
+		SourceBucket:      schedule.Source.Bucket,
+		SourcePrefix:      schedule.Source.Prefix,
+		DestBucket:        schedule.Destination.Bucket,
+		DestPrefix:        schedule.Destination.Prefix,
+		SourceCredentials: sourceCreds,
+		DestCredentials:   destCreds,
+		Verify:            &models.VerifyOptions{Mode: schedule.VerifyMode},
+		ScheduleID:        schedule.ID,
+	}
+	if err := validation.ValidateMigrationRequest(&req); err != nil {
+		return fmt.Errorf("schedule %s: %w", schedule.ID, err)
+	}
+
+	taskID := uuid.New().String()
+	updates, unsubscribe := taskManager.subscribe(taskID)
+	defer unsubscribe()
+	task, err := launchMigration(taskID, req, time.Now())
+	if err != nil {
+		return fmt.Errorf("schedule %s: %w", schedule.ID, err)
+	}
+	fmt.Printf("🔎 Schedule %s started verification task %s\n", schedule.ID, taskID)
+
+	// The run lasts as long as its task, so the overlap policy of the schedule sees it in progress
+	status, err := awaitTask(ctx, task, updates)
+	if err != nil {
+		stopped := stopScheduledTask(schedule, taskID)
+		awaitTask(context.Background(), task, updates)
+		return fmt.Errorf("schedule %s: task %s exceeded max_run_duration %s and was %s", schedule.ID, taskID, schedule.MaxDuration, stopped)
+	}
+	if status.Status == "failed" {
+		return fmt.Errorf("schedule %s: verification task %s failed", schedule.ID, taskID)
+	}
+	return nil
+}
+
+// stopScheduledTask stops the task of a run that exceeded the max_run_duration
+// of its schedule: drained with on_timeout drain when the task supports it,
+// cancelled otherwise. It returns "drained" or "cancelled".
+func stopScheduledTask(schedule *scheduler.Schedule, taskID string) string {
+	stopped := "cancelled"
+	if schedule.OnTimeout == scheduler.TimeoutDrain {
+		if _, _, err := cancelTask(taskID, cancelDrain); err == nil {
+			stopped = "drained"
+		}
+	}
+	if stopped == "cancelled" {
+		cancelTask(taskID, cancelHard)
+	}
+	fmt.Printf("⏱️  Schedule %s: task %s exceeded max_run_duration %s; %s\n", schedule.ID, taskID, schedule.MaxDuration, stopped)
+	return stopped
+}
+
+// scheduleCredentials decrypts the stored credentials of a schedule (nil when none are stored)
+func scheduleCredentials(values map[string]string) (*models.Credentials, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	decrypted, err := secrets.DecryptMap(values)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Credentials{
+		AccessKey:    decrypted["access_key"],
+		SecretKey:    decrypted["secret_key"],
+		SessionToken: decrypted["session_token"],
+		Region:       decrypted["region"],
+		EndpointURL:  decrypted["endpoint_url"],
+	}, nil
+}
+
+// scheduleDrift is the drift report of one run of a verify schedule
+type scheduleDrift struct {
+	TaskID string              `json:"task_id"`
+	Status string              `json:"status"`
+	Drift  *models.DriftReport `json:"drift,omitempty"`
+}
+
+// GetScheduleDrift handles GET /api/schedules/:id/drift
+// @Summary Drift reports of a verify schedule
+// @Description List the verification tasks a schedule started in this instance, oldest first, with what each found
+// @Tags schedules
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} gin.H
+// @Failure 404 {object} ErrorResponse
+// @Router /api/schedules/{id}/drift [get]
+func GetScheduleDrift(c *gin.Context) {
+	id := c.Param("id")
+	if scheduleManager == nil {
+		respondError(c, http.StatusNotFound, "scheduler not initialized")
+		return
+	}
+	if _, err := scheduleManager.GetSchedule(id); err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	type run struct {
+		started time.Time
+		scheduleDrift
+	}
+	var runs []run
+	taskManager.mu.RLock()
+	for taskID, task := range taskManager.tasks {
+		if task.OriginalRequest.ScheduleID != id {
+			continue
+		}
+		status := task.status()
+		runs = append(runs, run{started: task.StartTime, scheduleDrift: scheduleDrift{TaskID: taskID, Status: status.Status, Drift: status.Drift}})
+	}
+	taskManager.mu.RUnlock()
+	sort.Slice(runs, func(i, j int) bool { return runs[i].started.Before(runs[j].started) })
+
+	reports := make([]scheduleDrift, len(runs))
+	drifted := 0
+	for i, r := range runs {
+		reports[i] = r.scheduleDrift
+		if r.Drift != nil && r.Drift.Drifted {
+			drifted++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"schedule_id": id, "runs": reports, "drifted": drifted})
+}
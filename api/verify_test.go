@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+	"s3migration/pkg/scheduler"
+)
+
+func TestVerifyScheduleReportsDrift(t *testing.T) {
+	endpoint := fakes3.New("source", "dest")
+	defer endpoint.Close()
+	// Sources first: the destination copies are written after them
+	endpoint.Put("source", "a.txt", []byte("alpha"))
+	endpoint.Put("source", "b.txt", []byte("bravo"))
+	endpoint.Put("source", "c.txt", []byte("charlie"))
+	endpoint.Put("dest", "backup/a.txt", []byte("alpha"))
+	endpoint.Put("dest", "backup/b.txt", []byte("bravo!"))
+	endpoint.Put("dest", "backup/d.txt", []byte("delta"))
+	router := testRouter(t, endpoint)
+	router.GET("/api/schedules/:id/drift", GetScheduleDrift)
+
+	previous := scheduleManager
+	t.Cleanup(func() { scheduleManager = previous })
+	scheduleManager = scheduler.NewScheduler(&DefaultTaskExecutor{})
+	schedule := &scheduler.Schedule{
+		ID:          "nightly",
+		Type:        scheduler.TypeVerify,
+		CronExpr:    "0 3 * * *",
+		Source:      scheduler.SourceConfig{Bucket: "source"},
+		Destination: scheduler.DestConfig{Bucket: "dest", Prefix: "backup"},
+	}
+	if err := scheduleManager.AddSchedule(schedule); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (&DefaultTaskExecutor{}).Execute(context.Background(), schedule); err != nil {
+		t.Fatal(err)
+	}
+	var history struct {
+		Runs []struct {
+			TaskID string              `json:"task_id"`
+			Drift  *models.DriftReport `json:"drift"`
+		} `json:"runs"`
+		Drifted int `json:"drifted"`
+	}
+	json.Unmarshal(serve(router, http.MethodGet, "/api/schedules/nightly/drift", "").Body.Bytes(), &history)
+	if len(history.Runs) != 1 {
+		t.Fatalf("runs = %+v", history.Runs)
+	}
+
+	status := waitForStatus(t, router, history.Runs[0].TaskID, func(status models.MigrationStatus) bool {
+		return status.Status == "completed"
+	})
+	drift := status.Drift
+	if status.MigrationType != "verify" || drift == nil || !drift.Drifted || drift.Mode != "size" {
+		t.Fatalf("status = %+v, drift %+v", status, drift)
+	}
+	if drift.SourceObjects != 3 || drift.DestObjects != 3 || drift.Missing != 1 || drift.Changed != 1 || drift.Extra != 1 {
+		t.Errorf("drift = %+v", drift)
+	}
+	issues := map[string]string{}
+	for _, entry := range drift.Samples {
+		issues[entry.Key] = entry.Issue
+	}
+	if issues["b.txt"] != "changed" || issues["c.txt"] != "missing" || issues["backup/d.txt"] != "extra" || len(issues) != 3 {
+		t.Errorf("samples = %+v", drift.Samples)
+	}
+	if keys := endpoint.Keys("dest"); len(keys) != 3 {
+		t.Errorf("verification wrote to the destination: %v", keys)
+	}
+
+	json.Unmarshal(serve(router, http.MethodGet, "/api/schedules/nightly/drift", "").Body.Bytes(), &history)
+	if history.Drifted != 1 || history.Runs[0].Drift == nil {
+		t.Errorf("history = %+v", history)
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"s3migration/pkg/core"
+	"s3migration/pkg/models"
+	"s3migration/pkg/providers/httpsource"
+)
+
+// maxWebhookErrors caps the task errors included in a webhook payload
+const maxWebhookErrors = 20
+
+// webhookRetryDelays are the waits before the second and third delivery attempts
+var webhookRetryDelays = []time.Duration{5 * time.Second, 30 * time.Second}
+
+// webhookClient posts task results; receivers are expected to answer quickly.
+// Webhook URLs come from API callers, so only public addresses are reached.
+var webhookClient = &http.Client{Transport: httpsource.NewPublicTransport(1), Timeout: 10 * time.Second}
+
+// failureManifestPolicyFor builds a request's failure manifest policy; the request was validated, so errors only log
+func failureManifestPolicyFor(req *models.MigrationRequest, name string) *core.FailureManifestPolicy {
+	policy, err := core.FailureManifestPolicyFor(req.Webhook, name)
+	if err != nil {
+		fmt.Printf("⚠️  Invalid webhook settings (%v), no failure manifest will be written\n", err)
+		return nil
+	}
+	return policy
+}
+
+// taskWebhookPayload describes the final state of a task
+func taskWebhookPayload(taskID string, req *models.MigrationRequest) (*models.TaskWebhookPayload, bool) {
+	taskManager.mu.RLock()
+	defer taskManager.mu.RUnlock()
+	task, exists := taskManager.tasks[taskID]
+	if !exists {
+		return nil, false
+	}
+	status := task.Status
+	payload := &models.TaskWebhookPayload{
+		Event:        "task.finished",
+		TaskID:       taskID,
+		Status:       status.Status,
+		SourceBucket: req.SourceBucket,
+		SourcePrefix: req.SourcePrefix,
+		DestBucket:   req.DestBucket,
+		DestPrefix:   req.DestPrefix,
+		StartTime:    status.StartTime,
+		EndTime:      status.EndTime,
+		Drift:        status.Drift,
+		Reorganize:   status.Reorganize,
+	}
+	errors := status.Errors
+	if result := task.Result; result != nil {
+		payload.Copied = result.Copied
+		payload.Failed = result.Failed
+		payload.Skipped = result.Skipped
+		payload.FailureManifest = result.FailureManifest
+		errors = result.Errors
+	}
+	payload.Errors = append([]string(nil), errors[:min(len(errors), maxWebhookErrors)]...)
+	return payload, true
+}
+
+// notifyTaskWebhook posts the result of an ended task to the webhook of its
+// request, retrying failed deliveries; a delivery that fails every attempt is
+// recorded in the task errors
+func notifyTaskWebhook(taskID string, req *models.MigrationRequest) {
+	if req.Webhook == nil || req.Webhook.URL == "" {
+		return
+	}
+	payload, exists := taskWebhookPayload(taskID, req)
+	if !exists {
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		fmt.Printf("⚠️  Cannot encode webhook payload of task %s: %v\n", taskID, err)
+		return
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = postWebhook(req.Webhook.URL, body)
+		if err == nil {
+			fmt.Printf("Webhook delivered for task %s (%s)\n", taskID, payload.Status)
+			return
+		}
+		if attempt >= len(webhookRetryDelays) {
+			break
+		}
+		time.Sleep(webhookRetryDelays[attempt])
+	}
+	fmt.Printf("⚠️  Webhook for task %s failed: %v\n", taskID, err)
+	taskManager.updateTask(taskID, func(task *TaskInfo) {
+		task.Status.Errors = append(task.Status.Errors, fmt.Sprintf("Webhook delivery failed: %v", err))
+	})
+}
+
+// postWebhook sends one delivery; any 2xx answer accepts it
+func postWebhook(url string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "s3-migration-webhook")
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/core"
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+)
+
+// localWebhooks lets webhooks reach the test's loopback receivers
+func localWebhooks(t *testing.T) {
+	previous := webhookClient
+	webhookClient = &http.Client{Timeout: 10 * time.Second}
+	t.Cleanup(func() { webhookClient = previous })
+}
+
+func TestWebhookLinksFailureManifest(t *testing.T) {
+	localWebhooks(t)
+	endpoint := fakes3.New("source", "reports")
+	defer endpoint.Close()
+	endpoint.Put("source", "data/a.txt", []byte("alpha"))
+	endpoint.Put("source", "data/b.bad", []byte("bravo"))
+	router := testRouter(t, endpoint)
+	// Pre-signing needs credentials
+	client := s3.New(endpoint.Client().Options(), func(o *s3.Options) {
+		o.Credentials = credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", "")
+	})
+	taskManager.newMigrator = func(ctx context.Context, cfg core.EnhancedMigratorConfig) (*core.EnhancedMigrator, error) {
+		cfg.ConnectionPool = pool.NewStaticConnectionPool(client)
+		return core.NewEnhancedMigrator(ctx, cfg)
+	}
+
+	// Transformation hook on a loopback address, which is refused: b.bad fails to copy
+	hook := "http://127.0.0.1:9/transform"
+	deliveries := make(chan models.TaskWebhookPayload, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload models.TaskWebhookPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		deliveries <- payload
+	}))
+	defer receiver.Close()
+
+	resp := serve(router, http.MethodPost, "/api/migrate", `{"source_bucket": "source", "source_prefix": "data/", "dest_bucket": "dest",
+		"transform": {"type": "http", "url": "`+hook+`", "match": ["*.bad"]},
+		"webhook": {"url": "`+receiver.URL+`", "manifest_bucket": "reports", "manifest_prefix": "failed", "url_expiry": 600}}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("POST /api/migrate = %d: %s", resp.Code, resp.Body)
+	}
+
+	var payload models.TaskWebhookPayload
+	select {
+	case payload = <-deliveries:
+	case <-time.After(10 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+	link := payload.FailureManifest
+	if payload.Event != "task.finished" || payload.Status != "completed_with_errors" || payload.Copied != 1 || payload.Failed != 1 || len(payload.Errors) == 0 || link == nil {
+		t.Fatalf("payload = %+v", payload)
+	}
+	if link.Bucket != "reports" || link.Key != "failed/"+payload.TaskID+".json" || link.Objects != 1 || link.ExpiresAt.Before(time.Now().Add(9*time.Minute)) {
+		t.Fatalf("failure manifest link = %+v", link)
+	}
+
+	// The link serves the manifest, ready to post back as files_from
+	download, err := http.Get(link.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer download.Body.Close()
+	body, _ := io.ReadAll(download.Body)
+	var manifest core.FailureManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		t.Fatalf("manifest %q: %v", body, err)
+	}
+	if manifest.SourceBucket != "source" || manifest.SourcePrefix != "data/" || len(manifest.FilesFrom) != 1 || manifest.FilesFrom[0] != "b.bad" {
+		t.Fatalf("manifest = %+v", manifest)
+	}
+}
+
+func TestWebhookDeliveryFailureIsRecorded(t *testing.T) {
+	previous := webhookRetryDelays
+	webhookRetryDelays = []time.Duration{time.Millisecond}
+	defer func() { webhookRetryDelays = previous }()
+	localWebhooks(t)
+
+	attempts := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	endpoint := fakes3.New()
+	defer endpoint.Close()
+	testRouter(t, endpoint)
+	taskManager.addTask(&TaskInfo{ID: "ended", Status: &models.MigrationStatus{TaskID: "ended", Status: "completed"}})
+	notifyTaskWebhook("ended", &models.MigrationRequest{Webhook: &models.WebhookOptions{URL: receiver.URL}})
+
+	task, _ := taskManager.getTask("ended")
+	if errors := task.status().Errors; attempts != 2 || len(errors) != 1 {
+		t.Fatalf("%d attempts, errors %q", attempts, errors)
+	}
+}
+
+func TestWebhookRefusesNonPublicAddress(t *testing.T) {
+	previous := webhookRetryDelays
+	webhookRetryDelays = nil
+	defer func() { webhookRetryDelays = previous }()
+
+	attempts := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+	}))
+	defer receiver.Close()
+
+	endpoint := fakes3.New()
+	defer endpoint.Close()
+	testRouter(t, endpoint)
+	taskManager.addTask(&TaskInfo{ID: "ended", Status: &models.MigrationStatus{TaskID: "ended", Status: "completed"}})
+	notifyTaskWebhook("ended", &models.MigrationRequest{Webhook: &models.WebhookOptions{URL: receiver.URL}})
+
+	task, _ := taskManager.getTask("ended")
+	if errors := task.status().Errors; attempts != 0 || len(errors) != 1 || !strings.Contains(errors[0], "non-public address") {
+		t.Fatalf("%d attempts, errors %q", attempts, errors)
+	}
+}
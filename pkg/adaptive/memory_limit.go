@@ -0,0 +1,56 @@
+package adaptive
+
+import (
+	"math"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// defaultMemoryLimitMiB is the ceiling when neither GOMEMLIMIT nor a cgroup limit is set
+const defaultMemoryLimitMiB = 2048
+
+// Container memory limit files: cgroup v2 first, then v1
+var (
+	cgroupV2MemoryMax   = "/sys/fs/cgroup/memory.max"
+	cgroupV1MemoryLimit = "/sys/fs/cgroup/memory/memory.limit_in_bytes"
+)
+
+// cgroupUnlimited is the smallest cgroup v1 limit treated as "no limit": v1
+// reports an unlimited group as a page-aligned value close to MaxInt64
+const cgroupUnlimited = 1 << 60
+
+// detectMemoryLimit returns the memory ceiling in MiB and where it came from:
+// the Go runtime limit (GOMEMLIMIT or /api/admin/memory), else the container's
+// cgroup v2 memory.max or v1 memory.limit_in_bytes, else 2 GiB
+func detectMemoryLimit() (int64, string) {
+	if limit := debug.SetMemoryLimit(-1); limit > 0 && limit < math.MaxInt64 {
+		return limit / (1024 * 1024), "GOMEMLIMIT"
+	}
+	if limit, ok := readCgroupLimit(cgroupV2MemoryMax); ok {
+		return limit / (1024 * 1024), "cgroup v2 " + cgroupV2MemoryMax
+	}
+	if limit, ok := readCgroupLimit(cgroupV1MemoryLimit); ok {
+		return limit / (1024 * 1024), "cgroup v1 " + cgroupV1MemoryLimit
+	}
+	return defaultMemoryLimitMiB, "default (no GOMEMLIMIT or cgroup limit)"
+}
+
+// readCgroupLimit reads a cgroup memory limit in bytes; false when the file is
+// missing, unreadable or says the group is unlimited
+func readCgroupLimit(path string) (int64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, false
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit <= 0 || limit >= cgroupUnlimited {
+		return 0, false
+	}
+	return limit, true
+}
@@ -0,0 +1,58 @@
+package adaptive
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestDetectMemoryLimit(t *testing.T) {
+	limit := debug.SetMemoryLimit(-1)
+	v2, v1 := cgroupV2MemoryMax, cgroupV1MemoryLimit
+	t.Cleanup(func() {
+		debug.SetMemoryLimit(limit)
+		cgroupV2MemoryMax, cgroupV1MemoryLimit = v2, v1
+	})
+
+	dir := t.TempDir()
+	file := func(name, content string) string {
+		if content == "" {
+			return filepath.Join(dir, "missing")
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name       string
+		gomemlimit int64
+		v2, v1     string
+		want       int64
+		source     string
+	}{
+		{name: "GOMEMLIMIT wins", gomemlimit: 1800 << 20, v2: "17179869184\n", want: 1800, source: "GOMEMLIMIT"},
+		{name: "cgroup v2", v2: "17179869184\n", v1: "1073741824\n", want: 16384, source: "cgroup v2"},
+		{name: "cgroup v2 unlimited falls back to v1", v2: "max\n", v1: "4294967296\n", want: 4096, source: "cgroup v1"},
+		{name: "cgroup v1 unlimited", v1: "9223372036854771712\n", want: defaultMemoryLimitMiB, source: "default"},
+		{name: "no limits", want: defaultMemoryLimitMiB, source: "default"},
+	}
+	for _, tt := range tests {
+		debug.SetMemoryLimit(math.MaxInt64)
+		if tt.gomemlimit > 0 {
+			debug.SetMemoryLimit(tt.gomemlimit)
+		}
+		cgroupV2MemoryMax = file(tt.name+"v2", tt.v2)
+		cgroupV1MemoryLimit = file(tt.name+"v1", tt.v1)
+
+		got, source := detectMemoryLimit()
+		if got != tt.want || !strings.HasPrefix(source, tt.source) {
+			t.Errorf("%s: detectMemoryLimit() = %d MiB from %q, want %d MiB from %s", tt.name, got, source, tt.want, tt.source)
+		}
+	}
+}
@@ -6,44 +6,44 @@ import (
 	"runtime/debug"
 	"sync"
 	"time"
+
+	"s3migration/pkg/models"
 )
 
 // MemoryManager dynamically adjusts concurrency based on available memory
 type MemoryManager struct {
-	mu                  sync.RWMutex
-	maxMemoryMiB        int64   // Maximum memory limit (from GOMEMLIMIT or K8s)
-	safeThresholdPct    float64 // Safe threshold percentage (e.g., 0.7 = 70%)
-	currentWorkers      int
-	minWorkers          int
-	maxWorkers          int
-	lastAdjustment      time.Time
-	adjustmentInterval  time.Duration
-	memoryHistory       []int64 // Recent memory samples
-	historySamples      int
-	estimatedPerWorker  int64 // Estimated memory per worker in MiB
+	mu                 sync.RWMutex
+	maxMemoryMiB       int64   // Maximum memory limit (from GOMEMLIMIT or K8s)
+	safeThresholdPct   float64 // Safe threshold percentage (e.g., 0.7 = 70%)
+	currentWorkers     int
+	minWorkers         int
+	maxWorkers         int
+	lastAdjustment     time.Time
+	adjustmentInterval time.Duration
+	memoryHistory      []int64 // Recent memory samples
+	historySamples     int
+	estimatedPerWorker int64 // Estimated memory per worker in MiB
+	pattern            models.WorkloadPattern
+	perWorkerFloor     int64  // Per-worker profile of the pattern (MiB), 0 when none is set
+	settingsVersion    int64  // Process-wide settings version last applied
+	networkCondition   string // Measured by the tuner's NetworkMonitor
 }
 
 // MemoryStats represents current memory statistics
 type MemoryStats struct {
-	AllocMiB      int64
-	TotalAllocMiB int64
-	SysMiB        int64
-	UsagePercent  float64
-	AvailableMiB  int64
+	AllocMiB      int64   `json:"alloc_mib"`
+	TotalAllocMiB int64   `json:"total_alloc_mib"`
+	SysMiB        int64   `json:"sys_mib"`
+	UsagePercent  float64 `json:"usage_percent"`
+	AvailableMiB  int64   `json:"available_mib"`
 }
 
 // NewMemoryManager creates a new adaptive memory manager
 func NewMemoryManager() *MemoryManager {
-	// Get GOMEMLIMIT from environment
-	var maxMemory int64 = 2048 // Default 2GiB if not set
-	
-	if limit := debug.SetMemoryLimit(-1); limit > 0 {
-		maxMemory = limit / (1024 * 1024) // Convert to MiB
-	}
-	
+	maxMemory, source := detectMemoryLimit()
 	mm := &MemoryManager{
 		maxMemoryMiB:       maxMemory,
-		safeThresholdPct:   0.85, // Use max 85% of available memory (optimized)
+		safeThresholdPct:   defaultSafeThreshold,
 		currentWorkers:     1,
 		minWorkers:         1,
 		maxWorkers:         100, // Will be adjusted based on memory
@@ -52,21 +52,22 @@ func NewMemoryManager() *MemoryManager {
 		memoryHistory:      make([]int64, 0, 10),
 		historySamples:     10,
 		estimatedPerWorker: 100, // Initial estimate: 100 MiB per worker
+		pattern:            models.PatternUnknown,
+		settingsVersion:    -1,
+		networkCondition:   ConditionUnknown,
 	}
-	
-	// Calculate realistic max workers based on memory
+
+	// Apply settings changed through the admin API, which also calculates
+	// realistic max workers based on memory
+	mm.syncSettings()
 	safeMemory := int64(float64(mm.maxMemoryMiB) * mm.safeThresholdPct)
-	mm.maxWorkers = int(safeMemory / mm.estimatedPerWorker)
-	if mm.maxWorkers < mm.minWorkers {
-		mm.maxWorkers = mm.minWorkers
-	}
-	
+
 	fmt.Printf("🧠 Memory Manager initialized:\n")
-	fmt.Printf("   Max Memory: %d MiB\n", mm.maxMemoryMiB)
+	fmt.Printf("   Max Memory: %d MiB (from %s)\n", mm.maxMemoryMiB, source)
 	fmt.Printf("   Safe Threshold: %.0f%% (%d MiB)\n", mm.safeThresholdPct*100, safeMemory)
 	fmt.Printf("   Estimated per worker: %d MiB\n", mm.estimatedPerWorker)
 	fmt.Printf("   Max workers allowed: %d\n", mm.maxWorkers)
-	
+
 	return mm
 }
 
@@ -74,14 +75,14 @@ func NewMemoryManager() *MemoryManager {
 func (mm *MemoryManager) GetCurrentStats() MemoryStats {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
-	
+
 	allocMiB := int64(m.Alloc / 1024 / 1024)
 	sysMiB := int64(m.Sys / 1024 / 1024)
 	totalAllocMiB := int64(m.TotalAlloc / 1024 / 1024)
-	
+
 	usagePercent := float64(allocMiB) / float64(mm.maxMemoryMiB) * 100
 	availableMiB := mm.maxMemoryMiB - allocMiB
-	
+
 	return MemoryStats{
 		AllocMiB:      allocMiB,
 		TotalAllocMiB: totalAllocMiB,
@@ -94,22 +95,26 @@ func (mm *MemoryManager) GetCurrentStats() MemoryStats {
 // RecordMemoryUsage records current memory usage for analysis
 func (mm *MemoryManager) RecordMemoryUsage(workers int) {
 	stats := mm.GetCurrentStats()
-	
+
 	mm.mu.Lock()
 	defer mm.mu.Unlock()
-	
+	mm.syncSettings()
+
 	// Add to history
 	mm.memoryHistory = append(mm.memoryHistory, stats.AllocMiB)
 	if len(mm.memoryHistory) > mm.historySamples {
 		mm.memoryHistory = mm.memoryHistory[1:]
 	}
-	
+
 	// Update estimated memory per worker
 	if workers > 0 && len(mm.memoryHistory) >= 3 {
 		avgMemory := average(mm.memoryHistory)
 		mm.estimatedPerWorker = avgMemory / int64(workers)
 		if mm.estimatedPerWorker < 50 {
-			mm.estimatedPerWorker = 3  // Optimized for small objects (100KB) - 3 MiB per worker
+			mm.estimatedPerWorker = 3 // Optimized for small objects (100KB) - 3 MiB per worker
+		}
+		if mm.estimatedPerWorker < mm.perWorkerFloor {
+			mm.estimatedPerWorker = mm.perWorkerFloor
 		}
 	}
 }
@@ -118,13 +123,14 @@ func (mm *MemoryManager) RecordMemoryUsage(workers int) {
 func (mm *MemoryManager) GetOptimalWorkers() int {
 	mm.mu.Lock()
 	defer mm.mu.Unlock()
-	
+	mm.syncSettings()
+
 	stats := mm.GetCurrentStats()
-	
+
 	// Calculate safe memory available
 	safeMemory := int64(float64(mm.maxMemoryMiB) * mm.safeThresholdPct)
 	availableForWorkers := safeMemory - stats.AllocMiB
-	
+
 	// If we're over safe threshold, reduce workers
 	if stats.AllocMiB > safeMemory {
 		if mm.currentWorkers > mm.minWorkers {
@@ -133,16 +139,24 @@ func (mm *MemoryManager) GetOptimalWorkers() int {
 		}
 		return mm.currentWorkers
 	}
-	
+
 	// Calculate how many workers we can safely add
 	if availableForWorkers > mm.estimatedPerWorker {
 		potentialWorkers := int(availableForWorkers / mm.estimatedPerWorker)
-		
-		// Don't increase too aggressively
-		if potentialWorkers > mm.currentWorkers+2 {
-			potentialWorkers = mm.currentWorkers + 2
+
+		// Don't increase too aggressively, and less so on a slow or failing
+		// network, where more workers only hold buffers waiting on the link
+		step := 2
+		switch mm.networkCondition {
+		case ConditionFair:
+			step = 1
+		case ConditionPoor:
+			step = 0
 		}
-		
+		if potentialWorkers > mm.currentWorkers+step {
+			potentialWorkers = mm.currentWorkers + step
+		}
+
 		// Apply bounds
 		if potentialWorkers > mm.maxWorkers {
 			potentialWorkers = mm.maxWorkers
@@ -150,25 +164,32 @@ func (mm *MemoryManager) GetOptimalWorkers() int {
 		if potentialWorkers < mm.minWorkers {
 			potentialWorkers = mm.minWorkers
 		}
-		
+
 		mm.currentWorkers = potentialWorkers
 	}
-	
+
 	return mm.currentWorkers
 }
 
+// SetNetworkCondition sets the measured network condition that limits how fast workers are added
+func (mm *MemoryManager) SetNetworkCondition(condition string) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.networkCondition = condition
+}
+
 // ShouldAdjustWorkers determines if workers should be adjusted
 func (mm *MemoryManager) ShouldAdjustWorkers() bool {
 	mm.mu.RLock()
 	defer mm.mu.RUnlock()
-	
+
 	// Don't adjust too frequently
 	if time.Since(mm.lastAdjustment) < mm.adjustmentInterval {
 		return false
 	}
-	
+
 	stats := mm.GetCurrentStats()
-	
+
 	// Adjust if memory usage is high (> 60%) or low (< 30%)
 	return stats.UsagePercent > 60 || stats.UsagePercent < 30
 }
@@ -178,13 +199,13 @@ func (mm *MemoryManager) AdjustWorkers() int {
 	mm.mu.Lock()
 	mm.lastAdjustment = time.Now()
 	mm.mu.Unlock()
-	
+
 	newWorkers := mm.GetOptimalWorkers()
-	
+
 	stats := mm.GetCurrentStats()
 	fmt.Printf("🧠 Memory: %d MiB / %d MiB (%.1f%%) | Workers: %d | Est per worker: %d MiB\n",
 		stats.AllocMiB, mm.maxMemoryMiB, stats.UsagePercent, newWorkers, mm.estimatedPerWorker)
-	
+
 	return newWorkers
 }
 
@@ -194,7 +215,7 @@ func (mm *MemoryManager) triggerGC(reason string) {
 	runtime.GC()
 	debug.FreeOSMemory()
 	after := mm.GetCurrentStats()
-	
+
 	freed := before.AllocMiB - after.AllocMiB
 	if freed > 0 {
 		fmt.Printf("🗑️  GC triggered (%s): freed %d MiB (was %d MiB, now %d MiB)\n",
@@ -205,14 +226,14 @@ func (mm *MemoryManager) triggerGC(reason string) {
 // ForceGCIfNeeded triggers GC if memory usage is high
 func (mm *MemoryManager) ForceGCIfNeeded() bool {
 	stats := mm.GetCurrentStats()
-	
+
 	// Trigger GC if over 60% of safe threshold
 	threshold := int64(float64(mm.maxMemoryMiB) * mm.safeThresholdPct * 0.6)
 	if stats.AllocMiB > threshold {
 		mm.triggerGC("high memory usage")
 		return true
 	}
-	
+
 	return false
 }
 
@@ -234,31 +255,34 @@ func (mm *MemoryManager) GetMaxWorkers() int {
 func (mm *MemoryManager) SetSafeThreshold(percent float64) {
 	mm.mu.Lock()
 	defer mm.mu.Unlock()
-	
+
 	if percent > 0 && percent <= 1.0 {
-		mm.safeThresholdPct = percent
-		
-		// Recalculate max workers
-		safeMemory := int64(float64(mm.maxMemoryMiB) * mm.safeThresholdPct)
-		mm.maxWorkers = int(safeMemory / mm.estimatedPerWorker)
-		if mm.maxWorkers < mm.minWorkers {
-			mm.maxWorkers = mm.minWorkers
-		}
-		
+		mm.setSafeThreshold(percent)
 		fmt.Printf("🧠 Safe threshold updated to %.0f%% (%d MiB), max workers: %d\n",
-			percent*100, safeMemory, mm.maxWorkers)
+			percent*100, int64(float64(mm.maxMemoryMiB)*percent), mm.maxWorkers)
+	}
+}
+
+// setSafeThreshold sets the threshold and recalculates max workers; the lock must be held
+func (mm *MemoryManager) setSafeThreshold(percent float64) {
+	mm.safeThresholdPct = percent
+
+	safeMemory := int64(float64(mm.maxMemoryMiB) * mm.safeThresholdPct)
+	mm.maxWorkers = int(safeMemory / mm.estimatedPerWorker)
+	if mm.maxWorkers < mm.minWorkers {
+		mm.maxWorkers = mm.minWorkers
 	}
 }
 
 // LogMemoryStats logs detailed memory statistics
 func (mm *MemoryManager) LogMemoryStats() {
 	stats := mm.GetCurrentStats()
-	
+
 	mm.mu.RLock()
 	workers := mm.currentWorkers
 	maxWorkers := mm.maxWorkers
 	mm.mu.RUnlock()
-	
+
 	fmt.Printf("📊 Memory Stats:\n")
 	fmt.Printf("   Current: %d MiB / %d MiB (%.1f%%)\n", stats.AllocMiB, mm.maxMemoryMiB, stats.UsagePercent)
 	fmt.Printf("   Available: %d MiB\n", stats.AvailableMiB)
@@ -277,3 +301,38 @@ func average(values []int64) int64 {
 	return sum / int64(len(values))
 }
 
+// MemoryProfile is a snapshot of the memory estimator's state
+type MemoryProfile struct {
+	MaxMemoryMiB          int64                  `json:"max_memory_mib"`
+	SafeThresholdPct      float64                `json:"safe_threshold_pct"`
+	EstimatedPerWorkerMiB int64                  `json:"estimated_per_worker_mib"`
+	CurrentWorkers        int                    `json:"current_workers"`
+	MaxWorkers            int                    `json:"max_workers"`
+	WorkloadPattern       models.WorkloadPattern `json:"workload_pattern"`
+	NetworkCondition      string                 `json:"network_condition"`
+	RecentAllocMiB        []int64                `json:"recent_alloc_mib"`
+	Current               MemoryStats            `json:"current"`
+}
+
+// Profile returns the estimator's current settings and recent samples
+func (mm *MemoryManager) Profile() MemoryProfile {
+	stats := mm.GetCurrentStats()
+
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	history := make([]int64, len(mm.memoryHistory))
+	copy(history, mm.memoryHistory)
+
+	return MemoryProfile{
+		MaxMemoryMiB:          mm.maxMemoryMiB,
+		SafeThresholdPct:      mm.safeThresholdPct,
+		EstimatedPerWorkerMiB: mm.estimatedPerWorker,
+		CurrentWorkers:        mm.currentWorkers,
+		MaxWorkers:            mm.maxWorkers,
+		WorkloadPattern:       mm.pattern,
+		NetworkCondition:      mm.networkCondition,
+		RecentAllocMiB:        history,
+		Current:               stats,
+	}
+}
@@ -0,0 +1,144 @@
+package adaptive
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+
+	"s3migration/pkg/models"
+)
+
+// defaultSafeThreshold is the share of the memory limit workers may use
+const defaultSafeThreshold = 0.85
+
+// minMemoryLimitMiB keeps an operator from setting a limit the process cannot run under
+const minMemoryLimitMiB = 256
+
+// MemorySettings are the process-wide memory knobs every MemoryManager follows.
+// A manager applies changes on its next sample, so running tasks pick them up
+// without a restart.
+type MemorySettings struct {
+	MemoryLimitMiB   int64   `json:"memory_limit_mib"`   // Go runtime soft limit (GOMEMLIMIT)
+	SafeThresholdPct float64 `json:"safe_threshold_pct"` // Share of the limit workers may use, e.g. 0.85
+	// Least memory (MiB) assumed per worker for a workload pattern; the learned
+	// estimate never goes below it. Patterns without a profile keep the built-in estimate.
+	PerWorkerMiB map[models.WorkloadPattern]int64 `json:"per_worker_mib"`
+}
+
+// MemorySettingsUpdate holds operator changes; nil fields are left unchanged and
+// a per_worker_mib entry of 0 removes that pattern's profile
+type MemorySettingsUpdate struct {
+	MemoryLimitMiB   *int64                           `json:"memory_limit_mib,omitempty"`
+	SafeThresholdPct *float64                         `json:"safe_threshold_pct,omitempty"`
+	PerWorkerMiB     map[models.WorkloadPattern]int64 `json:"per_worker_mib,omitempty"`
+}
+
+var (
+	settingsMu      sync.RWMutex
+	safeThreshold   = defaultSafeThreshold
+	perWorkerMiB    = map[models.WorkloadPattern]int64{}
+	settingsVersion atomic.Int64 // Bumped on every change; managers compare it to theirs
+)
+
+// memoryLimitMiB is the memory ceiling in MiB
+func memoryLimitMiB() int64 {
+	mib, _ := detectMemoryLimit()
+	return mib
+}
+
+// CurrentMemorySettings returns the process-wide memory settings
+func CurrentMemorySettings() MemorySettings {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+
+	profiles := make(map[models.WorkloadPattern]int64, len(perWorkerMiB))
+	for pattern, mib := range perWorkerMiB {
+		profiles[pattern] = mib
+	}
+	return MemorySettings{
+		MemoryLimitMiB:   memoryLimitMiB(),
+		SafeThresholdPct: safeThreshold,
+		PerWorkerMiB:     profiles,
+	}
+}
+
+// UpdateMemorySettings validates and applies a change to every MemoryManager,
+// running or future. A new memory limit is set on the Go runtime as well.
+func UpdateMemorySettings(update MemorySettingsUpdate) (MemorySettings, error) {
+	if update.MemoryLimitMiB != nil && *update.MemoryLimitMiB < minMemoryLimitMiB {
+		return MemorySettings{}, fmt.Errorf("memory_limit_mib must be at least %d", minMemoryLimitMiB)
+	}
+	if update.SafeThresholdPct != nil && (*update.SafeThresholdPct <= 0 || *update.SafeThresholdPct > 1) {
+		return MemorySettings{}, fmt.Errorf("safe_threshold_pct must be above 0 and at most 1")
+	}
+	for pattern, mib := range update.PerWorkerMiB {
+		switch pattern {
+		case models.PatternManySmall, models.PatternMixed, models.PatternLargeFiles, models.PatternUnknown:
+		default:
+			return MemorySettings{}, fmt.Errorf("unknown workload pattern %q in per_worker_mib", pattern)
+		}
+		if mib < 0 {
+			return MemorySettings{}, fmt.Errorf("per_worker_mib for %s must not be negative", pattern)
+		}
+	}
+
+	settingsMu.Lock()
+	if update.MemoryLimitMiB != nil {
+		debug.SetMemoryLimit(*update.MemoryLimitMiB * 1024 * 1024)
+	}
+	if update.SafeThresholdPct != nil {
+		safeThreshold = *update.SafeThresholdPct
+	}
+	for pattern, mib := range update.PerWorkerMiB {
+		if mib == 0 {
+			delete(perWorkerMiB, pattern)
+		} else {
+			perWorkerMiB[pattern] = mib
+		}
+	}
+	settingsVersion.Add(1)
+	settingsMu.Unlock()
+
+	current := CurrentMemorySettings()
+	fmt.Printf("🧠 Memory settings updated: limit %d MiB, safe threshold %.0f%%, per-worker profiles %v\n",
+		current.MemoryLimitMiB, current.SafeThresholdPct*100, current.PerWorkerMiB)
+	return current, nil
+}
+
+// ProcessMemoryStats returns the process's memory use against the current limit
+func ProcessMemoryStats() MemoryStats {
+	return (&MemoryManager{maxMemoryMiB: memoryLimitMiB()}).GetCurrentStats()
+}
+
+// syncSettings applies process-wide settings changed since the manager last
+// looked; the manager's lock must be held
+func (mm *MemoryManager) syncSettings() {
+	version := settingsVersion.Load()
+	if version == mm.settingsVersion {
+		return
+	}
+	mm.settingsVersion = version
+
+	settingsMu.RLock()
+	threshold := safeThreshold
+	floor := perWorkerMiB[mm.pattern]
+	settingsMu.RUnlock()
+
+	mm.maxMemoryMiB = memoryLimitMiB()
+	mm.perWorkerFloor = floor
+	if mm.estimatedPerWorker < floor {
+		mm.estimatedPerWorker = floor
+	}
+	mm.setSafeThreshold(threshold)
+}
+
+// SetWorkloadPattern selects the per-worker memory profile of the detected workload
+func (mm *MemoryManager) SetWorkloadPattern(pattern models.WorkloadPattern) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	mm.pattern = pattern
+	mm.settingsVersion = -1 // Re-read the profile of the new pattern
+	mm.syncSettings()
+}
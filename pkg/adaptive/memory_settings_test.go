@@ -0,0 +1,68 @@
+package adaptive
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"s3migration/pkg/models"
+)
+
+func TestUpdateMemorySettingsReachesRunningManagers(t *testing.T) {
+	limit := debug.SetMemoryLimit(-1)
+	t.Cleanup(func() {
+		debug.SetMemoryLimit(limit)
+		settingsMu.Lock()
+		safeThreshold = defaultSafeThreshold
+		perWorkerMiB = map[models.WorkloadPattern]int64{}
+		settingsMu.Unlock()
+		settingsVersion.Add(1)
+	})
+
+	invalid := []struct {
+		name   string
+		update MemorySettingsUpdate
+	}{
+		{name: "threshold above 1", update: MemorySettingsUpdate{SafeThresholdPct: ptr(1.5)}},
+		{name: "zero threshold", update: MemorySettingsUpdate{SafeThresholdPct: ptr(0.0)}},
+		{name: "tiny limit", update: MemorySettingsUpdate{MemoryLimitMiB: ptr(int64(16))}},
+		{name: "unknown pattern", update: MemorySettingsUpdate{PerWorkerMiB: map[models.WorkloadPattern]int64{"huge": 10}}},
+		{name: "negative profile", update: MemorySettingsUpdate{PerWorkerMiB: map[models.WorkloadPattern]int64{models.PatternLargeFiles: -1}}},
+	}
+	for _, tt := range invalid {
+		if _, err := UpdateMemorySettings(tt.update); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+
+	mm := NewMemoryManager()
+	mm.SetWorkloadPattern(models.PatternLargeFiles)
+	settings, err := UpdateMemorySettings(MemorySettingsUpdate{
+		MemoryLimitMiB:   ptr(int64(4096)),
+		SafeThresholdPct: ptr(0.5),
+		PerWorkerMiB:     map[models.WorkloadPattern]int64{models.PatternLargeFiles: 256},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.MemoryLimitMiB != 4096 || settings.SafeThresholdPct != 0.5 || settings.PerWorkerMiB[models.PatternLargeFiles] != 256 {
+		t.Fatalf("settings = %+v", settings)
+	}
+
+	mm.GetOptimalWorkers() // The next sample applies the change
+	profile := mm.Profile()
+	if profile.MaxMemoryMiB != 4096 || profile.SafeThresholdPct != 0.5 || profile.EstimatedPerWorkerMiB != 256 || profile.MaxWorkers != 8 {
+		t.Errorf("running manager profile = %+v", profile)
+	}
+	if fresh := NewMemoryManager().Profile(); fresh.SafeThresholdPct != 0.5 || fresh.EstimatedPerWorkerMiB != 100 {
+		t.Errorf("new manager profile = %+v", fresh)
+	}
+
+	UpdateMemorySettings(MemorySettingsUpdate{PerWorkerMiB: map[models.WorkloadPattern]int64{models.PatternLargeFiles: 0}})
+	if profiles := CurrentMemorySettings().PerWorkerMiB; len(profiles) != 0 {
+		t.Errorf("profiles after removal = %v", profiles)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
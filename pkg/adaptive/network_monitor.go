@@ -1,37 +1,135 @@
 package adaptive
 
 import (
-	"context"
-	"fmt"
-	"net/http"
+	"math"
+	"sort"
 	"sync"
 	"time"
 )
 
-// NetworkMonitor monitors network quality and provides adaptive recommendations
-// Inspired by rclone's network adaptation
+// Network conditions, from the transfers of the last networkWindow
+const (
+	ConditionExcellent = "excellent"
+	ConditionGood      = "good"
+	ConditionFair      = "fair"
+	ConditionPoor      = "poor"
+	ConditionUnknown   = "unknown" // Fewer than minNetworkSamples transfers to go by
+)
+
+// Measurement window and classification thresholds
+const (
+	networkWindow           = time.Minute
+	maxNetworkSamples       = 1000 // Most recent transfers kept within the window
+	minNetworkSamples       = 5
+	poorErrorRate           = 0.10             // Failed share of transfers at or above which the link is poor
+	fairErrorRate           = 0.02             // ... and at or above which it is at best fair
+	poorErrorLatency        = 10 * time.Second // Failures this slow are timeouts, not refusals
+	fairThroughputMBps      = 5.0              // Aggregate throughput below which the link is fair
+	excellentThroughputMBps = 50.0             // ... and at or above which it is excellent
+)
+
+// transferSample is one completed transfer
+type transferSample struct {
+	finished time.Time
+	elapsed  time.Duration
+	bytes    int64
+	failed   bool
+}
+
+// NetworkMonitor measures network quality from completed transfers and provides
+// adaptive recommendations. Inspired by rclone's network adaptation
 type NetworkMonitor struct {
-	// Network quality metrics
-	latency     time.Duration
-	throughput  float64 // MB/s
-	errorRate   float64 // 0.0 to 1.0
-	lastUpdate  time.Time
-	
-	// Quality thresholds
-	excellentThreshold time.Duration // < 50ms
-	goodThreshold      time.Duration // < 100ms
-	fairThreshold      time.Duration // < 500ms
-	// > 500ms is poor
-	
+	// Network quality metrics over the window
+	errorLatency time.Duration // Median time failed transfers took to fail
+	throughput   float64       // MB/s, all transfers together
+	errorRate    float64       // 0.0 to 1.0
+	condition    string
+	lastUpdate   time.Time
+
+	samples []transferSample // Oldest first
+	now     func() time.Time
+
 	mu sync.RWMutex
 }
 
+// NetworkStats is a snapshot of the measured network quality
+type NetworkStats struct {
+	Condition      string  `json:"condition"`
+	ThroughputMBps float64 `json:"throughput_mbps"`
+	ErrorRate      float64 `json:"error_rate"` // 0.0 to 1.0
+	ErrorLatencyMs int64   `json:"error_latency_ms"`
+	Samples        int     `json:"samples"` // Transfers in the window
+}
+
 // NewNetworkMonitor creates a new network monitor
 func NewNetworkMonitor() *NetworkMonitor {
-	return &NetworkMonitor{
-		excellentThreshold: 50 * time.Millisecond,
-		goodThreshold:      100 * time.Millisecond,
-		fairThreshold:      500 * time.Millisecond,
+	return &NetworkMonitor{condition: ConditionUnknown, now: time.Now}
+}
+
+// RecordTransfer adds a completed transfer of size bytes that took elapsed;
+// err is nil for a successful transfer
+func (nm *NetworkMonitor) RecordTransfer(size int64, elapsed time.Duration, err error) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	now := nm.now()
+	nm.samples = append(nm.samples, transferSample{finished: now, elapsed: elapsed, bytes: size, failed: err != nil})
+	nm.measure(now)
+}
+
+// measure drops samples outside the window and recomputes the metrics
+func (nm *NetworkMonitor) measure(now time.Time) {
+	cutoff := now.Add(-networkWindow)
+	drop := 0
+	for drop < len(nm.samples) && (nm.samples[drop].finished.Before(cutoff) || len(nm.samples)-drop > maxNetworkSamples) {
+		drop++
+	}
+	if drop > 0 {
+		nm.samples = append(nm.samples[:0], nm.samples[drop:]...)
+	}
+	nm.lastUpdate = now
+
+	var bytes int64
+	var failed []time.Duration
+	start := now
+	for _, sample := range nm.samples {
+		if sample.failed {
+			failed = append(failed, sample.elapsed)
+		} else {
+			bytes += sample.bytes
+		}
+		if began := sample.finished.Add(-sample.elapsed); began.Before(start) {
+			start = began
+		}
+	}
+	// Transfers run in parallel, so throughput is the bytes over the time they span
+	span := max(now.Sub(start), time.Second)
+	nm.throughput = float64(bytes) / (1024 * 1024) / span.Seconds()
+	nm.errorRate = 0
+	nm.errorLatency = 0
+	if len(nm.samples) > 0 {
+		nm.errorRate = float64(len(failed)) / float64(len(nm.samples))
+	}
+	if len(failed) > 0 {
+		sort.Slice(failed, func(i, j int) bool { return failed[i] < failed[j] })
+		nm.errorLatency = failed[len(failed)/2]
+	}
+	nm.condition = nm.classify()
+}
+
+// classify names the condition of the current metrics
+func (nm *NetworkMonitor) classify() string {
+	switch {
+	case len(nm.samples) < minNetworkSamples:
+		return ConditionUnknown
+	case nm.errorRate >= poorErrorRate || nm.errorLatency >= poorErrorLatency:
+		return ConditionPoor
+	case nm.errorRate >= fairErrorRate || nm.throughput < fairThroughputMBps:
+		return ConditionFair
+	case nm.throughput < excellentThroughputMBps:
+		return ConditionGood
+	default:
+		return ConditionExcellent
 	}
 }
 
@@ -39,16 +137,10 @@ func NewNetworkMonitor() *NetworkMonitor {
 func (nm *NetworkMonitor) GetCurrentCondition() string {
 	nm.mu.RLock()
 	defer nm.mu.RUnlock()
-	
-	if nm.latency < nm.excellentThreshold {
-		return "excellent"
-	} else if nm.latency < nm.goodThreshold {
-		return "good"
-	} else if nm.latency < nm.fairThreshold {
-		return "fair"
-	} else {
-		return "poor"
+	if nm.now().Sub(nm.lastUpdate) > networkWindow {
+		return ConditionUnknown // Nothing transferred lately
 	}
+	return nm.condition
 }
 
 // GetQuality returns the network quality (same as GetCurrentCondition)
@@ -56,22 +148,25 @@ func (nm *NetworkMonitor) GetQuality() string {
 	return nm.GetCurrentCondition()
 }
 
-// UpdateMetrics updates network metrics
-func (nm *NetworkMonitor) UpdateMetrics(latency time.Duration, throughput float64, errorRate float64) {
-	nm.mu.Lock()
-	defer nm.mu.Unlock()
-	
-	nm.latency = latency
-	nm.throughput = throughput
-	nm.errorRate = errorRate
-	nm.lastUpdate = time.Now()
+// Stats returns the measured metrics
+func (nm *NetworkMonitor) Stats() NetworkStats {
+	condition := nm.GetCurrentCondition()
+	nm.mu.RLock()
+	defer nm.mu.RUnlock()
+	return NetworkStats{
+		Condition:      condition,
+		ThroughputMBps: math.Round(nm.throughput*100) / 100,
+		ErrorRate:      math.Round(nm.errorRate*1000) / 1000,
+		ErrorLatencyMs: nm.errorLatency.Milliseconds(),
+		Samples:        len(nm.samples),
+	}
 }
 
-// GetLatency returns current latency
-func (nm *NetworkMonitor) GetLatency() time.Duration {
+// GetErrorLatency returns the median time failed transfers took to fail
+func (nm *NetworkMonitor) GetErrorLatency() time.Duration {
 	nm.mu.RLock()
 	defer nm.mu.RUnlock()
-	return nm.latency
+	return nm.errorLatency
 }
 
 // GetThroughput returns current throughput in MB/s
@@ -88,35 +183,11 @@ func (nm *NetworkMonitor) GetErrorRate() float64 {
 	return nm.errorRate
 }
 
-// IsStale returns true if the metrics are stale (older than 30 seconds)
+// IsStale returns true if no transfer completed within the window
 func (nm *NetworkMonitor) IsStale() bool {
 	nm.mu.RLock()
 	defer nm.mu.RUnlock()
-	return time.Since(nm.lastUpdate) > 30*time.Second
-}
-
-// TestNetworkQuality performs a network quality test
-func (nm *NetworkMonitor) TestNetworkQuality(ctx context.Context, testURL string) error {
-	// Simple latency test
-	start := time.Now()
-	resp, err := http.Get(testURL)
-	if err != nil {
-		return fmt.Errorf("network test failed: %w", err)
-	}
-	defer resp.Body.Close()
-	
-	latency := time.Since(start)
-	
-	// Calculate throughput (simplified)
-	contentLength := resp.ContentLength
-	if contentLength > 0 {
-		throughput := float64(contentLength) / latency.Seconds() / (1024 * 1024) // MB/s
-		nm.UpdateMetrics(latency, throughput, 0.0)
-	} else {
-		nm.UpdateMetrics(latency, 0.0, 0.0)
-	}
-	
-	return nil
+	return nm.now().Sub(nm.lastUpdate) > networkWindow
 }
 
 // GetOptimalConcurrency returns optimal concurrency based on network quality
@@ -198,7 +269,7 @@ func (nm *NetworkMonitor) GetRecommendations() []string {
 	}
 	
 	if nm.IsStale() {
-		recommendations = append(recommendations, "Network metrics are stale - consider running network test")
+		recommendations = append(recommendations, "No transfers completed in the last minute - network metrics are stale")
 	}
 	
 	return recommendations
@@ -0,0 +1,73 @@
+package adaptive
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNetworkMonitorCondition(t *testing.T) {
+	timeout := errors.New("i/o timeout")
+	tests := []struct {
+		name      string
+		transfers int
+		size      int64         // Bytes per successful transfer, one finishing every second
+		failEvery int           // Every n-th transfer fails (0 = none)
+		failAfter time.Duration // How long a failing transfer took
+		want      string
+	}{
+		{name: "too few transfers", transfers: 3, size: 100 << 20, want: ConditionUnknown},
+		{name: "fast", transfers: 20, size: 100 << 20, want: ConditionExcellent},
+		{name: "moderate", transfers: 20, size: 20 << 20, want: ConditionGood},
+		{name: "slow", transfers: 20, size: 1 << 20, want: ConditionFair},
+		{name: "some errors", transfers: 40, size: 100 << 20, failEvery: 20, failAfter: time.Second, want: ConditionFair},
+		{name: "many errors", transfers: 20, size: 100 << 20, failEvery: 5, failAfter: time.Second, want: ConditionPoor},
+		{name: "timeouts", transfers: 40, size: 100 << 20, failEvery: 20, failAfter: 30 * time.Second, want: ConditionPoor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			nm := NewNetworkMonitor()
+			nm.now = func() time.Time { return now }
+			for i := 1; i <= tt.transfers; i++ {
+				now = now.Add(time.Second)
+				if tt.failEvery > 0 && i%tt.failEvery == 0 {
+					nm.RecordTransfer(tt.size, tt.failAfter, timeout)
+				} else {
+					nm.RecordTransfer(tt.size, time.Second, nil)
+				}
+			}
+			if got := nm.GetCurrentCondition(); got != tt.want {
+				t.Errorf("condition = %s, want %s (%+v)", got, tt.want, nm.Stats())
+			}
+		})
+	}
+}
+
+func TestNetworkMonitorWindow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	nm := NewNetworkMonitor()
+	nm.now = func() time.Time { return now }
+	for i := 0; i < 10; i++ {
+		nm.RecordTransfer(0, time.Second, errors.New("refused"))
+	}
+	if nm.GetCurrentCondition() != ConditionPoor {
+		t.Fatalf("condition = %s, want poor", nm.GetCurrentCondition())
+	}
+
+	// Failures age out of the window as successful transfers come in
+	now = now.Add(2 * networkWindow)
+	for i := 0; i < 10; i++ {
+		now = now.Add(time.Second)
+		nm.RecordTransfer(100<<20, time.Second, nil)
+	}
+	if stats := nm.Stats(); stats.Condition != ConditionExcellent || stats.Samples != 10 || stats.ErrorRate != 0 {
+		t.Errorf("got %+v", stats)
+	}
+
+	// Nothing transferred for a while
+	now = now.Add(2 * networkWindow)
+	if !nm.IsStale() || nm.GetCurrentCondition() != ConditionUnknown {
+		t.Errorf("condition = %s after an idle window", nm.GetCurrentCondition())
+	}
+}
@@ -0,0 +1,150 @@
+package adaptive
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"s3migration/pkg/models"
+)
+
+// activeTrackers counts tasks being tracked at once, across every MemoryManager
+var activeTrackers atomic.Int32
+
+// ResourceTracker samples process resource usage during the lifetime of one
+// task. The runtime only reports process-wide figures, so samples include
+// every other task running at the same time; PeakConcurrentTasks says how many.
+type ResourceTracker struct {
+	mu        sync.Mutex
+	mm        *MemoryManager
+	startTime time.Time
+	start     runtime.MemStats
+	stopCh    chan struct{}
+	stopped   bool
+
+	samples        int
+	peakHeapInUse  uint64
+	sumHeapInUse   uint64
+	peakSys        uint64
+	peakGoroutines int
+	sumGoroutines  int
+	peakTasks      int
+}
+
+// TrackTask starts sampling resource usage every interval until Stop is called.
+// Each sample also feeds the manager's memory history used for worker sizing.
+func (mm *MemoryManager) TrackTask(interval time.Duration) *ResourceTracker {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	t := &ResourceTracker{
+		mm:        mm,
+		startTime: time.Now(),
+		stopCh:    make(chan struct{}),
+	}
+	activeTrackers.Add(1)
+	runtime.ReadMemStats(&t.start)
+	t.sample()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.stopCh:
+				return
+			case <-ticker.C:
+				t.sample()
+			}
+		}
+	}()
+
+	return t
+}
+
+// sample records one observation of heap, sys and goroutine counts
+func (t *ResourceTracker) sample() {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	goroutines := runtime.NumGoroutine()
+	tasks := int(activeTrackers.Load())
+
+	t.mu.Lock()
+	t.samples++
+	if tasks > t.peakTasks {
+		t.peakTasks = tasks
+	}
+	t.sumHeapInUse += m.HeapInuse
+	t.sumGoroutines += goroutines
+	if m.HeapInuse > t.peakHeapInUse {
+		t.peakHeapInUse = m.HeapInuse
+	}
+	if m.Sys > t.peakSys {
+		t.peakSys = m.Sys
+	}
+	if goroutines > t.peakGoroutines {
+		t.peakGoroutines = goroutines
+	}
+	t.mu.Unlock()
+
+	if t.mm != nil {
+		t.mm.RecordMemoryUsage(t.mm.GetCurrentWorkers())
+	}
+}
+
+// Stop ends sampling and returns the usage summary for the task window
+func (t *ResourceTracker) Stop() *models.ResourceUsage {
+	t.mu.Lock()
+	first := !t.stopped
+	if first {
+		t.stopped = true
+		close(t.stopCh)
+	}
+	t.mu.Unlock()
+
+	// Final sample so short tasks still get a peak reading
+	t.sample()
+	if first {
+		activeTrackers.Add(-1)
+	}
+
+	var end runtime.MemStats
+	runtime.ReadMemStats(&end)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage := &models.ResourceUsage{
+		Scope:               "process",
+		PeakConcurrentTasks: t.peakTasks,
+		Samples:             t.samples,
+		DurationSeconds:     time.Since(t.startTime).Seconds(),
+		PeakHeapInUseMiB:    int64(t.peakHeapInUse / 1024 / 1024),
+		AvgHeapInUseMiB:     int64(t.sumHeapInUse / uint64(t.samples) / 1024 / 1024),
+		PeakSysMiB:          int64(t.peakSys / 1024 / 1024),
+		AllocatedMiB:        int64((end.TotalAlloc - t.start.TotalAlloc) / 1024 / 1024),
+		PeakGoroutines:      t.peakGoroutines,
+		AvgGoroutines:       t.sumGoroutines / t.samples,
+		NumGC:               end.NumGC - t.start.NumGC,
+		GCPauseTotalMs:      float64(end.PauseTotalNs-t.start.PauseTotalNs) / 1e6,
+		CPUFractionGC:       end.GCCPUFraction,
+	}
+
+	// PauseNs is a circular buffer of the most recent 256 pauses
+	gcs := end.NumGC - t.start.NumGC
+	if gcs > uint32(len(end.PauseNs)) {
+		gcs = uint32(len(end.PauseNs))
+	}
+	var maxPause uint64
+	for i := uint32(0); i < gcs; i++ {
+		pause := end.PauseNs[(end.NumGC-i+255)%256]
+		if pause > maxPause {
+			maxPause = pause
+		}
+	}
+	usage.GCPauseMaxMs = float64(maxPause) / 1e6
+
+	return usage
+}
@@ -0,0 +1,31 @@
+package adaptive
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResourceTrackerCountsConcurrentTasks(t *testing.T) {
+	first := (*MemoryManager)(nil).TrackTask(time.Hour)
+	second := (*MemoryManager)(nil).TrackTask(time.Hour)
+	first.sample() // A periodic sample taken while both run
+
+	secondUsage := second.Stop()
+	firstUsage := first.Stop()
+	first.Stop() // Stopping twice must not release the slot twice
+
+	if secondUsage.Scope != "process" {
+		t.Errorf("scope = %q, want process", secondUsage.Scope)
+	}
+	if secondUsage.PeakConcurrentTasks != 2 || firstUsage.PeakConcurrentTasks != 2 {
+		t.Errorf("peak concurrent tasks = %d, %d, want 2, 2", firstUsage.PeakConcurrentTasks, secondUsage.PeakConcurrentTasks)
+	}
+	if n := activeTrackers.Load(); n != 0 {
+		t.Errorf("%d trackers still active after Stop", n)
+	}
+
+	alone := (*MemoryManager)(nil).TrackTask(time.Hour).Stop()
+	if alone.PeakConcurrentTasks != 1 {
+		t.Errorf("peak concurrent tasks = %d, want 1", alone.PeakConcurrentTasks)
+	}
+}
@@ -0,0 +1,80 @@
+// Package buckets creates destination buckets for the migrators. Tasks writing
+// to the same new bucket race to create it: the losers get OperationAborted
+// while the winner's create is in progress, or BucketAlreadyOwnedByYou once it
+// is done, and wait for the bucket instead of failing.
+package buckets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// Retry settings of Ensure; variables so tests can shorten them
+var (
+	createAttempts = 5
+	retryBackoff   = 500 * time.Millisecond // Doubled after each OperationAborted
+	existsWait     = 30 * time.Second       // Longest wait for a bucket to become visible
+)
+
+// Ensure makes sure input.Bucket exists, creating it with input when it does not,
+// and reports whether this call created it. A create that conflicts with one in
+// progress is retried with backoff; a bucket created by someone else in the
+// meantime is waited for until HeadBucket sees it.
+func Ensure(ctx context.Context, client *s3.Client, input *s3.CreateBucketInput) (bool, error) {
+	bucket := aws.ToString(input.Bucket)
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		if _, err := client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: input.Bucket}); err == nil {
+			return false, nil
+		}
+
+		_, err := client.CreateBucket(ctx, input)
+		switch code := errorCode(err); {
+		case err == nil:
+			return true, waitUntilExists(ctx, client, bucket)
+		case code == "BucketAlreadyOwnedByYou":
+			// Created by a concurrent task
+			return false, waitUntilExists(ctx, client, bucket)
+		case code == "BucketAlreadyExists":
+			// Owned by another account: the writes will tell whether it can be used
+			return false, nil
+		case code == "OperationAborted" && attempt < createAttempts:
+			fmt.Printf("Creating bucket '%s' conflicts with another operation, retrying in %s\n", bucket, backoff)
+			select {
+			case <-ctx.Done():
+				return false, ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		default:
+			return false, fmt.Errorf("failed to create bucket '%s': %w", bucket, err)
+		}
+	}
+}
+
+// waitUntilExists waits until HeadBucket sees a bucket that was just created
+func waitUntilExists(ctx context.Context, client *s3.Client, bucket string) error {
+	waiter := s3.NewBucketExistsWaiter(client, func(o *s3.BucketExistsWaiterOptions) {
+		o.MinDelay = time.Second
+		o.MaxDelay = 5 * time.Second
+	})
+	if err := waiter.Wait(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)}, existsWait); err != nil {
+		return fmt.Errorf("bucket '%s' was created but is not visible yet: %w", bucket, err)
+	}
+	return nil
+}
+
+// errorCode returns the S3 error code of err (empty when there is none)
+func errorCode(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
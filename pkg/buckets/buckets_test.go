@@ -0,0 +1,116 @@
+package buckets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/fakes3"
+)
+
+// racingEndpoint serves a fake endpoint, answering the first bucket creates
+// with OperationAborted and the first HeadBucket calls with 404
+type racingEndpoint struct {
+	fake   *fakes3.Server
+	aborts atomic.Int32
+	misses atomic.Int32
+}
+
+func (e *racingEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	isBucket := !strings.Contains(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case isBucket && r.Method == http.MethodHead && e.misses.Add(-1) >= 0:
+		w.WriteHeader(http.StatusNotFound)
+	case isBucket && r.Method == http.MethodPut && e.aborts.Add(-1) >= 0:
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`<Error><Code>OperationAborted</Code><Message>A conflicting conditional operation is currently in progress against this resource.</Message></Error>`))
+	default:
+		e.fake.ServeHTTP(w, r)
+	}
+}
+
+func (e *racingEndpoint) client(t *testing.T) *s3.Client {
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+	return s3.New(s3.Options{
+		Region:           "us-east-1",
+		BaseEndpoint:     aws.String(server.URL),
+		UsePathStyle:     true,
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+	})
+}
+
+func TestEnsure(t *testing.T) {
+	previous := retryBackoff
+	retryBackoff = time.Millisecond
+	t.Cleanup(func() { retryBackoff = previous })
+
+	tests := []struct {
+		name        string
+		existing    bool
+		aborts      int32
+		misses      int32
+		wantCreated bool
+		wantErr     bool
+	}{
+		{name: "missing bucket is created", wantCreated: true},
+		{name: "existing bucket is kept", existing: true},
+		{name: "create retried after conflicting operations", aborts: 2, wantCreated: true},
+		{name: "conflicts that persist fail", aborts: 10, wantErr: true},
+		{name: "bucket created by a concurrent task", existing: true, misses: 1},
+	}
+	for _, tt := range tests {
+		fake := fakes3.New()
+		if tt.existing {
+			fake.Put("dest", ".keep", nil)
+		}
+		endpoint := &racingEndpoint{fake: fake}
+		endpoint.aborts.Store(tt.aborts)
+		endpoint.misses.Store(tt.misses)
+
+		created, err := Ensure(context.Background(), endpoint.client(t), &s3.CreateBucketInput{Bucket: aws.String("dest")})
+		if (err != nil) != tt.wantErr || created != tt.wantCreated {
+			t.Errorf("%s: created %v, err %v", tt.name, created, err)
+		}
+		if tt.wantErr && !strings.Contains(err.Error(), "OperationAborted") {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+		fake.Close()
+	}
+}
+
+func TestEnsureConcurrentCreates(t *testing.T) {
+	fake := fakes3.New()
+	defer fake.Close()
+	endpoint := &racingEndpoint{fake: fake}
+	client := endpoint.client(t)
+
+	var wg sync.WaitGroup
+	var created atomic.Int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := Ensure(context.Background(), client, &s3.CreateBucketInput{Bucket: aws.String("dest")})
+			if err != nil {
+				t.Error(err)
+			}
+			if ok {
+				created.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if created.Load() != 1 {
+		t.Errorf("%d tasks reported creating the bucket, want 1", created.Load())
+	}
+}
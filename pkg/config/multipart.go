@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// S3 multipart limits shared by AWS and most compatible providers
+const (
+	MinPartSize     int64 = 5 * 1024 * 1024        // Every part but the last must be at least 5 MiB
+	MaxPartSize     int64 = 5 * 1024 * 1024 * 1024 // Largest part, and largest single CopyObject
+	DefaultMaxParts       = 10000
+)
+
+// Parts of one object copied at once: default and upper bound of the per-object setting
+const (
+	DefaultPartConcurrency = 5
+	MaxPartConcurrency     = 64
+)
+
+// MultipartSettings controls when and how large objects are copied in parts
+type MultipartSettings struct {
+	ThresholdBytes  int64 // Objects larger than this use multipart copy
+	PartSizeBytes   int64 // Preferred part size; raised automatically to stay within MaxParts
+	MaxParts        int   // Most parts the provider accepts per upload
+	PartConcurrency int   // Parts of one object copied at once
+}
+
+// DefaultMultipartSettings returns the settings used when nothing is configured
+func DefaultMultipartSettings() MultipartSettings {
+	return MultipartSettings{
+		ThresholdBytes:  1024 * 1024 * 1024, // 1 GiB, below the 5 GiB CopyObject limit of any provider
+		PartSizeBytes:   100 * 1024 * 1024,
+		MaxParts:        DefaultMaxParts,
+		PartConcurrency: DefaultPartConcurrency,
+	}
+}
+
+// MultipartPreset returns the multipart defaults for a provider
+func MultipartPreset(provider S3Provider) MultipartSettings {
+	settings := DefaultMultipartSettings()
+	switch provider {
+	case ProviderAWS:
+		settings.ThresholdBytes = 4 * 1024 * 1024 * 1024 // AWS copies up to 5 GiB in one request
+	case ProviderScaleway:
+		settings.MaxParts = 1000 // Scaleway rejects uploads with more than 1000 parts
+	case ProviderCloudflare:
+		settings.ThresholdBytes = 512 * 1024 * 1024 // R2 copies are slower to retry when large
+	}
+	return settings
+}
+
+// DetectProvider guesses the provider preset from an endpoint URL; no endpoint means AWS
+func DetectProvider(endpointURL string) S3Provider {
+	if endpointURL == "" {
+		return ProviderAWS
+	}
+	host := endpointURL
+	if u, err := url.Parse(endpointURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	host = strings.ToLower(host)
+
+	switch {
+	case strings.HasSuffix(host, "amazonaws.com"):
+		return ProviderAWS
+	case strings.Contains(host, "minio"):
+		return ProviderMinIO
+	case strings.HasSuffix(host, "digitaloceanspaces.com"):
+		return ProviderDigitalOcean
+	case strings.HasSuffix(host, "wasabisys.com"):
+		return ProviderWasabi
+	case strings.HasSuffix(host, "backblazeb2.com"):
+		return ProviderBackblaze
+	case strings.HasSuffix(host, "r2.cloudflarestorage.com"):
+		return ProviderCloudflare
+	case strings.HasSuffix(host, "linodeobjects.com"):
+		return ProviderLinode
+	case strings.HasSuffix(host, "scw.cloud"):
+		return ProviderScaleway
+	default:
+		return ProviderCustom
+	}
+}
+
+// Override returns s with the non-zero fields of o applied
+func (s MultipartSettings) Override(o MultipartSettings) MultipartSettings {
+	if o.ThresholdBytes > 0 {
+		s.ThresholdBytes = o.ThresholdBytes
+	}
+	if o.PartSizeBytes > 0 {
+		s.PartSizeBytes = o.PartSizeBytes
+	}
+	if o.MaxParts > 0 {
+		s.MaxParts = o.MaxParts
+	}
+	if o.PartConcurrency > 0 {
+		s.PartConcurrency = o.PartConcurrency
+	}
+	return s
+}
+
+// Validate checks the settings against S3 multipart limits
+func (s MultipartSettings) Validate() error {
+	if s.PartSizeBytes < MinPartSize || s.PartSizeBytes > MaxPartSize {
+		return fmt.Errorf("part size must be between 5 MiB and 5 GiB")
+	}
+	if s.ThresholdBytes < MinPartSize || s.ThresholdBytes > MaxPartSize {
+		return fmt.Errorf("multipart threshold must be between 5 MiB and 5 GiB")
+	}
+	if s.MaxParts < 1 || s.MaxParts > DefaultMaxParts {
+		return fmt.Errorf("max parts must be between 1 and %d", DefaultMaxParts)
+	}
+	if s.PartConcurrency < 1 || s.PartConcurrency > MaxPartConcurrency {
+		return fmt.Errorf("part concurrency must be between 1 and %d", MaxPartConcurrency)
+	}
+	return nil
+}
+
+// PartSize returns the part size and part count for an object. The configured part
+// size is raised in whole MiB when the object would otherwise need more than MaxParts.
+func (s MultipartSettings) PartSize(objectSize int64) (int64, int64, error) {
+	partSize := s.PartSizeBytes
+	if partSize < MinPartSize {
+		partSize = MinPartSize
+	}
+	maxParts := int64(s.MaxParts)
+	if maxParts <= 0 {
+		maxParts = DefaultMaxParts
+	}
+
+	if (objectSize+partSize-1)/partSize > maxParts {
+		const mib = 1024 * 1024
+		partSize = (objectSize + maxParts - 1) / maxParts
+		partSize = (partSize + mib - 1) / mib * mib
+	}
+	if partSize > MaxPartSize {
+		return 0, 0, fmt.Errorf("object of %d bytes needs parts over 5 GiB with at most %d parts", objectSize, maxParts)
+	}
+	return partSize, (objectSize + partSize - 1) / partSize, nil
+}
@@ -0,0 +1,100 @@
+package config
+
+import "testing"
+
+func TestPartSize(t *testing.T) {
+	const mib = 1024 * 1024
+	tests := []struct {
+		name         string
+		settings     MultipartSettings
+		objectSize   int64
+		wantPartSize int64
+		wantParts    int64
+		wantErr      bool
+	}{
+		{
+			name:         "preferred size fits",
+			settings:     MultipartSettings{PartSizeBytes: 100 * mib, MaxParts: DefaultMaxParts},
+			objectSize:   1000 * mib,
+			wantPartSize: 100 * mib,
+			wantParts:    10,
+		},
+		{
+			name:         "below minimum raised to 5 MiB",
+			settings:     MultipartSettings{PartSizeBytes: mib, MaxParts: DefaultMaxParts},
+			objectSize:   12 * mib,
+			wantPartSize: MinPartSize,
+			wantParts:    3,
+		},
+		{
+			name:         "raised in whole MiB to stay within max parts",
+			settings:     MultipartSettings{PartSizeBytes: 5 * mib, MaxParts: 1000},
+			objectSize:   10000*mib + 1,
+			wantPartSize: 11 * mib,
+			wantParts:    910,
+		},
+		{
+			name:         "zero max parts uses the S3 limit",
+			settings:     MultipartSettings{PartSizeBytes: 5 * mib},
+			objectSize:   100000 * mib,
+			wantPartSize: 10 * mib,
+			wantParts:    10000,
+		},
+		{
+			name:       "needs parts over 5 GiB",
+			settings:   MultipartSettings{PartSizeBytes: 100 * mib, MaxParts: 10},
+			objectSize: 60 * 1024 * mib,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			partSize, parts, err := tt.settings.PartSize(tt.objectSize)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PartSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if partSize != tt.wantPartSize || parts != tt.wantParts {
+				t.Fatalf("PartSize() = %d bytes x %d parts, want %d x %d", partSize, parts, tt.wantPartSize, tt.wantParts)
+			}
+			if maxParts := int64(tt.settings.MaxParts); maxParts > 0 && parts > maxParts {
+				t.Fatalf("%d parts exceeds max %d", parts, maxParts)
+			}
+		})
+	}
+}
+
+func TestMultipartSettingsValidate(t *testing.T) {
+	valid := DefaultMultipartSettings()
+	tests := []struct {
+		name    string
+		modify  func(s *MultipartSettings)
+		wantErr bool
+	}{
+		{"defaults", func(s *MultipartSettings) {}, false},
+		{"part size too small", func(s *MultipartSettings) { s.PartSizeBytes = MinPartSize - 1 }, true},
+		{"part size too large", func(s *MultipartSettings) { s.PartSizeBytes = MaxPartSize + 1 }, true},
+		{"threshold too large", func(s *MultipartSettings) { s.ThresholdBytes = MaxPartSize + 1 }, true},
+		{"too many parts", func(s *MultipartSettings) { s.MaxParts = DefaultMaxParts + 1 }, true},
+		{"no part concurrency", func(s *MultipartSettings) { s.PartConcurrency = 0 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid
+			tt.modify(&s)
+			if err := s.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOverrideKeepsPresetForZeroFields(t *testing.T) {
+	preset := MultipartPreset(ProviderScaleway)
+	got := preset.Override(MultipartSettings{PartSizeBytes: 64 * 1024 * 1024})
+	if got.MaxParts != 1000 || got.PartSizeBytes != 64*1024*1024 || got.ThresholdBytes != preset.ThresholdBytes {
+		t.Fatalf("Override() = %+v", got)
+	}
+}
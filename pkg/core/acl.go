@@ -0,0 +1,259 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"s3migration/pkg/models"
+)
+
+// Source ACLs, as classified from an object's grants
+const (
+	ACLPrivate           = "private"
+	ACLPublicRead        = "public-read"
+	ACLPublicReadWrite   = "public-read-write"
+	ACLAuthenticatedRead = "authenticated-read"
+	ACLGrants            = "grants" // Grantees other than the owner and the public groups; not expressible as a canned ACL
+)
+
+// Actions of an ACL mapping
+const (
+	ACLActionApply  = "apply"  // Set the canned ACL on the copy
+	ACLActionPolicy = "policy" // Keep the copy private, add it to the suggested bucket policy
+	ACLActionDrop   = "drop"   // Keep the copy private
+)
+
+// Group grantees of the canned ACLs
+const (
+	allUsersGroup           = "http://acs.amazonaws.com/groups/global/AllUsers"
+	authenticatedUsersGroup = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+)
+
+// maxACLEntries caps the objects an ACL report lists; counts are always exact
+const maxACLEntries = 100
+
+// maxPolicyResources is the most object ARNs a suggested bucket policy lists;
+// beyond it the statement covers the destination prefix
+const maxPolicyResources = 100
+
+// defaultACLMapping leaves nothing public without being asked to
+var defaultACLMapping = map[string]string{
+	ACLPublicRead:        ACLActionPolicy,
+	ACLPublicReadWrite:   ACLActionPolicy,
+	ACLAuthenticatedRead: ACLActionDrop,
+	ACLGrants:            ACLActionDrop,
+}
+
+// ACLPolicy is the action taken for each kind of non-private source ACL
+type ACLPolicy struct {
+	Mapping map[string]string
+}
+
+// ACLPolicyFor builds the ACL mapping of a migration request (nil without one)
+func ACLPolicyFor(opts *models.ACLOptions) (*ACLPolicy, error) {
+	if opts == nil || !opts.Enabled {
+		return nil, nil
+	}
+	mapping := make(map[string]string, len(defaultACLMapping))
+	for acl, action := range defaultACLMapping {
+		mapping[acl] = action
+	}
+	for acl, action := range opts.Mapping {
+		if _, ok := defaultACLMapping[acl]; !ok {
+			return nil, fmt.Errorf("unknown source ACL %q (expected public-read, public-read-write, authenticated-read or grants)", acl)
+		}
+		switch {
+		case action != ACLActionApply && action != ACLActionPolicy && action != ACLActionDrop:
+			return nil, fmt.Errorf("unknown action %q for %s (expected apply, policy or drop)", action, acl)
+		case action == ACLActionApply && acl == ACLGrants:
+			return nil, fmt.Errorf("grants cannot be applied: grantee IDs do not carry over between providers")
+		case action == ACLActionPolicy && acl != ACLPublicRead && acl != ACLPublicReadWrite:
+			return nil, fmt.Errorf("only public ACLs can be mapped to a bucket policy, not %s", acl)
+		}
+		mapping[acl] = action
+	}
+	return &ACLPolicy{Mapping: mapping}, nil
+}
+
+// aclMapper applies an ACL policy to the copies of one Migrate call and builds its report
+type aclMapper struct {
+	policy     *ACLPolicy
+	mu         sync.Mutex
+	report     models.ACLReport
+	policyKeys []string // Destination keys mapped to ACLActionPolicy, up to maxPolicyResources+1
+}
+
+// newACLMapper returns the mapper of a policy (nil without one)
+func newACLMapper(policy *ACLPolicy) *aclMapper {
+	if policy == nil {
+		return nil
+	}
+	return &aclMapper{policy: policy, report: models.ACLReport{BySourceACL: map[string]int64{}}}
+}
+
+// mapObject reads the source ACL of a copied object and maps it onto the copy
+func (a *aclMapper) mapObject(ctx context.Context, sourceClient, destClient *s3.Client, input MigrateInput, job copyJob) error {
+	output, err := sourceClient.GetObjectAcl(ctx, &s3.GetObjectAclInput{
+		Bucket: aws.String(input.SourceBucket),
+		Key:    aws.String(job.sourceKey),
+	})
+	if err != nil {
+		a.failed()
+		return fmt.Errorf("failed to read ACL: %w", err)
+	}
+	acl, grants := classifyACL(output)
+
+	action := a.policy.Mapping[acl]
+	if action == ACLActionApply {
+		if _, err := destClient.PutObjectAcl(ctx, &s3.PutObjectAclInput{
+			Bucket: aws.String(input.DestBucket),
+			Key:    aws.String(job.destKey),
+			ACL:    types.ObjectCannedACL(acl),
+		}); err != nil {
+			a.failed()
+			return fmt.Errorf("failed to set ACL %s: %w", acl, err)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.report.BySourceACL[acl]++
+	if acl == ACLPrivate {
+		return nil
+	}
+	switch action {
+	case ACLActionApply:
+		a.report.Applied++
+	case ACLActionPolicy:
+		a.report.NotCarried++
+		if len(a.policyKeys) <= maxPolicyResources {
+			a.policyKeys = append(a.policyKeys, job.destKey)
+		}
+	default:
+		a.report.NotCarried++
+	}
+	if len(a.report.Objects) < maxACLEntries {
+		a.report.Objects = append(a.report.Objects, models.ACLEntry{Key: job.sourceKey, SourceACL: acl, Action: action, Grants: grants})
+	}
+	return nil
+}
+
+func (a *aclMapper) failed() {
+	a.mu.Lock()
+	a.report.Errors++
+	a.mu.Unlock()
+}
+
+// result returns the report of the run (nil without a mapper)
+func (a *aclMapper) result(input MigrateInput) *models.ACLReport {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	report := a.report
+	report.SuggestedPolicy = suggestedReadPolicy(input.DestBucket, input.DestPrefix, a.policyKeys)
+	return &report
+}
+
+// classifyACL names the source ACL of an object's grants, and describes the
+// grants that are not the owner's
+func classifyACL(output *s3.GetObjectAclOutput) (string, []string) {
+	var owner string
+	if output.Owner != nil {
+		owner = aws.ToString(output.Owner.ID)
+	}
+	var publicRead, publicWrite, authenticatedRead, other bool
+	var grants []string
+	for _, grant := range output.Grants {
+		if grant.Grantee == nil {
+			continue
+		}
+		grantee := grant.Grantee
+		id, uri := aws.ToString(grantee.ID), aws.ToString(grantee.URI)
+		if id != "" && id == owner {
+			continue
+		}
+		permission := grant.Permission
+		switch {
+		case uri == allUsersGroup && (permission == types.PermissionRead || permission == types.PermissionWrite):
+			publicRead = publicRead || permission == types.PermissionRead
+			publicWrite = publicWrite || permission == types.PermissionWrite
+		case uri == authenticatedUsersGroup && permission == types.PermissionRead:
+			authenticatedRead = true
+		default:
+			other = true
+		}
+		grants = append(grants, fmt.Sprintf("%s to %s", permission, granteeName(grantee)))
+	}
+
+	switch {
+	case other || (authenticatedRead && (publicRead || publicWrite)) || (publicWrite && !publicRead):
+		return ACLGrants, grants
+	case publicWrite:
+		return ACLPublicReadWrite, grants
+	case publicRead:
+		return ACLPublicRead, grants
+	case authenticatedRead:
+		return ACLAuthenticatedRead, grants
+	default:
+		return ACLPrivate, nil
+	}
+}
+
+// granteeName describes a grantee for the report
+func granteeName(grantee *types.Grantee) string {
+	switch {
+	case grantee.URI != nil:
+		return groupName(aws.ToString(grantee.URI))
+	case grantee.EmailAddress != nil:
+		return "email:" + aws.ToString(grantee.EmailAddress)
+	case grantee.DisplayName != nil:
+		return "id:" + aws.ToString(grantee.ID) + " (" + aws.ToString(grantee.DisplayName) + ")"
+	default:
+		return "id:" + aws.ToString(grantee.ID)
+	}
+}
+
+// groupName returns the last segment of a group URI, e.g. AllUsers
+func groupName(uri string) string {
+	return uri[strings.LastIndex(uri, "/")+1:]
+}
+
+// suggestedReadPolicy returns a bucket policy granting public reads of keys; with
+// more keys than maxPolicyResources it covers the whole destination prefix
+func suggestedReadPolicy(bucket, prefix string, keys []string) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	var resources []string
+	if len(keys) > maxPolicyResources {
+		resources = []string{"arn:aws:s3:::" + bucket + "/" + destKeyFor("*", prefix)}
+	} else {
+		sorted := append([]string(nil), keys...)
+		sort.Strings(sorted)
+		for _, key := range sorted {
+			resources = append(resources, "arn:aws:s3:::"+bucket+"/"+key)
+		}
+	}
+	policy, _ := json.MarshalIndent(map[string]any{
+		"Version": "2012-10-17",
+		"Statement": []map[string]any{{
+			"Sid":       "S3MigrationPublicRead",
+			"Effect":    "Allow",
+			"Principal": "*",
+			"Action":    "s3:GetObject",
+			"Resource":  resources,
+		}},
+	}, "", "  ")
+	return string(policy)
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+)
+
+func TestClassifyACL(t *testing.T) {
+	owner := types.Grant{Grantee: &types.Grantee{ID: aws.String("owner")}, Permission: types.PermissionFullControl}
+	group := func(uri string, permission types.Permission) types.Grant {
+		return types.Grant{Grantee: &types.Grantee{URI: aws.String(uri)}, Permission: permission}
+	}
+	tests := []struct {
+		name       string
+		grants     []types.Grant
+		wantACL    string
+		wantGrants []string
+	}{
+		{name: "owner only", grants: []types.Grant{owner}, wantACL: ACLPrivate},
+		{name: "public read", grants: []types.Grant{owner, group(allUsersGroup, types.PermissionRead)},
+			wantACL: ACLPublicRead, wantGrants: []string{"READ to AllUsers"}},
+		{name: "public read and write", grants: []types.Grant{owner, group(allUsersGroup, types.PermissionRead), group(allUsersGroup, types.PermissionWrite)},
+			wantACL: ACLPublicReadWrite, wantGrants: []string{"READ to AllUsers", "WRITE to AllUsers"}},
+		{name: "authenticated read", grants: []types.Grant{owner, group(authenticatedUsersGroup, types.PermissionRead)},
+			wantACL: ACLAuthenticatedRead, wantGrants: []string{"READ to AuthenticatedUsers"}},
+		{name: "public read plus another account", grants: []types.Grant{owner, group(allUsersGroup, types.PermissionRead),
+			{Grantee: &types.Grantee{ID: aws.String("partner"), DisplayName: aws.String("Partner")}, Permission: types.PermissionRead}},
+			wantACL: ACLGrants, wantGrants: []string{"READ to AllUsers", "READ to id:partner (Partner)"}},
+		{name: "log delivery group", grants: []types.Grant{owner, group("http://acs.amazonaws.com/groups/s3/LogDelivery", types.PermissionWrite)},
+			wantACL: ACLGrants, wantGrants: []string{"WRITE to LogDelivery"}},
+	}
+	for _, tt := range tests {
+		acl, grants := classifyACL(&s3.GetObjectAclOutput{Owner: &types.Owner{ID: aws.String("owner")}, Grants: tt.grants})
+		if acl != tt.wantACL || !reflect.DeepEqual(grants, tt.wantGrants) {
+			t.Errorf("%s: %s %v, want %s %v", tt.name, acl, grants, tt.wantACL, tt.wantGrants)
+		}
+	}
+}
+
+func TestACLPolicyFor(t *testing.T) {
+	tests := []struct {
+		mapping map[string]string
+		wantErr bool
+	}{
+		{mapping: nil},
+		{mapping: map[string]string{"public-read": "apply", "authenticated-read": "apply"}},
+		{mapping: map[string]string{"private": "apply"}, wantErr: true},
+		{mapping: map[string]string{"public-read": "copy"}, wantErr: true},
+		{mapping: map[string]string{"grants": "apply"}, wantErr: true},
+		{mapping: map[string]string{"authenticated-read": "policy"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if _, err := ACLPolicyFor(&models.ACLOptions{Enabled: true, Mapping: tt.mapping}); (err != nil) != tt.wantErr {
+			t.Errorf("%v: err = %v", tt.mapping, err)
+		}
+	}
+	if policy, err := ACLPolicyFor(&models.ACLOptions{}); policy != nil || err != nil {
+		t.Errorf("disabled: %v, %v", policy, err)
+	}
+}
+
+func TestMigrateMapsACLs(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	for key, acl := range map[string]string{"a.txt": "public-read", "b.txt": "public-read-write", "c.txt": "authenticated-read", "d.txt": ""} {
+		endpoint.Put("source", key, []byte(key))
+		endpoint.Get("source", key).ACL = acl
+	}
+
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := ACLPolicyFor(&models.ACLOptions{Enabled: true, Mapping: map[string]string{"public-read": "apply"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := migrator.Migrate(context.Background(), MigrateInput{
+		SourceBucket:  "source",
+		DestBucket:    "dest",
+		MigrationMode: ModeFullRewrite,
+		ACL:           policy,
+		Timeout:       time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errors) != 0 {
+		t.Fatalf("errors = %v", result.Errors)
+	}
+
+	for key, want := range map[string]string{"a.txt": "public-read", "b.txt": "", "c.txt": "", "d.txt": ""} {
+		if got := endpoint.Get("dest", key).ACL; got != want {
+			t.Errorf("ACL of the copy of %s = %q, want %q", key, got, want)
+		}
+	}
+	report := result.ACL
+	if report == nil {
+		t.Fatal("no ACL report")
+	}
+	wantCounts := map[string]int64{"private": 1, "public-read": 1, "public-read-write": 1, "authenticated-read": 1}
+	if !reflect.DeepEqual(report.BySourceACL, wantCounts) || report.Applied != 1 || report.NotCarried != 2 || len(report.Objects) != 3 {
+		t.Errorf("report = %+v", report)
+	}
+	if !strings.Contains(report.SuggestedPolicy, `"arn:aws:s3:::dest/b.txt"`) || strings.Contains(report.SuggestedPolicy, "a.txt") {
+		t.Errorf("suggested policy = %s", report.SuggestedPolicy)
+	}
+}
@@ -0,0 +1,233 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+)
+
+// DefaultAuditLogPrefix is where audit logs go without a configured prefix
+const DefaultAuditLogPrefix = "audit-log"
+
+// auditLogFileRecords is how many records one audit file holds; a run writes a
+// file each time this many objects were copied, and one for the rest at the end
+const auditLogFileRecords = 10000
+
+// AuditLogPolicy writes a record of every object a run copies
+type AuditLogPolicy struct {
+	Bucket string // Empty: the destination bucket
+	Prefix string
+	Name   string // Task partition of the log, e.g. the task ID
+}
+
+// AuditLogPolicyFor builds the audit log policy of a migration request (nil without one)
+func AuditLogPolicyFor(opts *models.AuditLogOptions, name string) (*AuditLogPolicy, error) {
+	if opts == nil || !opts.Enabled {
+		return nil, nil
+	}
+	prefix := strings.Trim(opts.Prefix, "/")
+	if prefix == "" {
+		prefix = DefaultAuditLogPrefix
+	}
+	for _, segment := range strings.Split(prefix, "/") {
+		if segment == ".." {
+			return nil, fmt.Errorf("audit log prefix must not contain '..' segments")
+		}
+	}
+	return &AuditLogPolicy{Bucket: opts.Bucket, Prefix: prefix, Name: name}, nil
+}
+
+// AuditRecord is one line of the audit log: an object copied by a run
+type AuditRecord struct {
+	Task         string    `json:"task"`
+	SourceBucket string    `json:"source_bucket"`
+	SourceKey    string    `json:"source_key"`
+	SourceETag   string    `json:"source_etag,omitempty"` // As listed
+	DestBucket   string    `json:"dest_bucket"`
+	DestKey      string    `json:"dest_key"`
+	Size         int64     `json:"size"`
+	CopiedAt     time.Time `json:"copied_at"`
+	DurationMS   int64     `json:"duration_ms"`
+	Attempts     int64     `json:"attempts"` // 1, plus every retry of the object's requests
+	// Strongest checksum the destination returned for the write: SHA256, SHA1,
+	// CRC32C, CRC32 or ETAG (empty when the write path does not report one)
+	ChecksumAlgorithm string `json:"checksum_algorithm,omitempty"`
+	Checksum          string `json:"checksum,omitempty"`
+}
+
+// objectAudit collects what the copy of one object learns for its audit record
+type objectAudit struct {
+	start    time.Time
+	duration time.Duration
+	requests *pool.AttemptCounter
+	mu       sync.Mutex
+	written  writeChecksums
+}
+
+type objectAuditKey struct{}
+
+// withObjectAudit returns a context under which the copy of one object records its audit details
+func withObjectAudit(ctx context.Context) (context.Context, *objectAudit) {
+	ctx, requests := pool.WithAttemptCounter(ctx)
+	audit := &objectAudit{start: time.Now(), requests: requests}
+	return context.WithValue(ctx, objectAuditKey{}, audit), audit
+}
+
+// recordWrite keeps the checksums a destination write returned, when the copy is audited
+func recordWrite(ctx context.Context, written writeChecksums) {
+	if audit, ok := ctx.Value(objectAuditKey{}).(*objectAudit); ok {
+		audit.mu.Lock()
+		audit.written = written
+		audit.mu.Unlock()
+	}
+}
+
+// finish stops the object's clock
+func (a *objectAudit) finish() {
+	a.duration = time.Since(a.start)
+}
+
+// checksum returns the strongest checksum of the destination write
+func (a *objectAudit) checksum() (string, string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, c := range []struct{ algorithm, value string }{
+		{"SHA256", a.written.SHA256},
+		{"SHA1", a.written.SHA1},
+		{"CRC32C", a.written.CRC32C},
+		{"CRC32", a.written.CRC32},
+		{"ETAG", strings.Trim(a.written.ETag, `"`)},
+	} {
+		if c.value != "" {
+			return c.algorithm, c.value
+		}
+	}
+	return "", ""
+}
+
+// auditLog buffers the records of a run and writes them to numbered JSON Lines
+// files under prefix/date=YYYY-MM-DD/task=NAME/. File names start with the run's
+// start time, so later runs of a task add files and never replace earlier ones.
+type auditLog struct {
+	input    MigrateInput
+	client   *s3.Client
+	run      string
+	pending  bytes.Buffer
+	records  int
+	location models.AuditLogLocation
+}
+
+// newAuditLog prepares the audit log of a run started at started
+func (m *EnhancedMigrator) newAuditLog(input MigrateInput, destClient *s3.Client, started time.Time) *auditLog {
+	policy := input.AuditLog
+	client := destClient
+	if client == nil {
+		client = m.metadataClient()
+	}
+	bucket := policy.Bucket
+	if bucket == "" {
+		bucket = input.DestBucket
+	}
+	started = started.UTC()
+	return &auditLog{
+		input:  input,
+		client: client,
+		run:    started.Format("20060102T150405Z"),
+		location: models.AuditLogLocation{
+			Bucket: bucket,
+			Prefix: path.Join(policy.Prefix, "date="+started.Format("2006-01-02"), "task="+policy.Name),
+			Files:  []string{},
+		},
+	}
+}
+
+// add records a copied object, writing a file once enough records are pending
+func (l *auditLog) add(ctx context.Context, result copyResult) error {
+	algorithm, checksum := result.audit.checksum()
+	line, err := json.Marshal(AuditRecord{
+		Task:              l.input.AuditLog.Name,
+		SourceBucket:      l.input.SourceBucket,
+		SourceKey:         result.sourceKey,
+		SourceETag:        strings.Trim(result.etag, `"`),
+		DestBucket:        l.input.DestBucket,
+		DestKey:           result.destKey,
+		Size:              result.size,
+		CopiedAt:          result.audit.start.Add(result.audit.duration).UTC(),
+		DurationMS:        result.audit.duration.Milliseconds(),
+		Attempts:          1 + result.audit.requests.Retries(),
+		ChecksumAlgorithm: algorithm,
+		Checksum:          checksum,
+	})
+	if err != nil {
+		return err
+	}
+	l.pending.Write(line)
+	l.pending.WriteByte('\n')
+	l.records++
+	if l.records < auditLogFileRecords {
+		return nil
+	}
+	return l.flush(ctx)
+}
+
+// flush writes the pending records as the next file. Records that could not be
+// written stay pending, so a later flush retries them.
+func (l *auditLog) flush(ctx context.Context) error {
+	if l.records == 0 {
+		return nil
+	}
+	key := path.Join(l.location.Prefix, fmt.Sprintf("%s-%05d.jsonl", l.run, len(l.location.Files)+1))
+	_, err := l.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(l.location.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(l.pending.Bytes()),
+		ContentType: aws.String("application/x-ndjson"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write audit log %s (%d records pending): %w", key, l.records, err)
+	}
+	l.location.Files = append(l.location.Files, key)
+	l.location.Records += int64(l.records)
+	l.pending.Reset()
+	l.records = 0
+	return nil
+}
+
+// written returns where the log was written (nil without a log)
+func (l *auditLog) written() *models.AuditLogLocation {
+	if l == nil {
+		return nil
+	}
+	return &l.location
+}
+
+// withoutOwnFiles drops the audit files from a listing of the destination bucket,
+// so they are not counted as migrated objects
+func (l *auditLog) withoutOwnFiles(bucket string, objects []objectInfo) []objectInfo {
+	if l == nil || l.location.Bucket != bucket || len(l.location.Files) == 0 {
+		return objects
+	}
+	own := make(map[string]bool, len(l.location.Files))
+	for _, key := range l.location.Files {
+		own[key] = true
+	}
+	kept := make([]objectInfo, 0, len(objects))
+	for _, obj := range objects {
+		if !own[obj.Key] {
+			kept = append(kept, obj)
+		}
+	}
+	return kept
+}
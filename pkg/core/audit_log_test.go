@@ -0,0 +1,98 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+)
+
+func TestMigrateWritesAuditLog(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	endpoint.Put("source", "a.txt", []byte("alpha"))
+	endpoint.Put("source", "dir/b.txt", []byte("bravo!"))
+
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := migrator.Migrate(context.Background(), MigrateInput{
+		SourceBucket:  "source",
+		DestBucket:    "dest",
+		MigrationMode: ModeFullRewrite,
+		AuditLog:      &AuditLogPolicy{Prefix: DefaultAuditLogPrefix, Name: "task-1"},
+		Timeout:       time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errors) != 0 {
+		t.Fatalf("errors = %v", result.Errors)
+	}
+
+	location := result.AuditLog
+	if location == nil || location.Bucket != "dest" || location.Records != 2 || len(location.Files) != 1 {
+		t.Fatalf("audit log = %+v", location)
+	}
+	if !strings.HasPrefix(location.Files[0], "audit-log/date=") || !strings.Contains(location.Files[0], "/task=task-1/") ||
+		!strings.HasSuffix(location.Files[0], "-00001.jsonl") {
+		t.Errorf("audit file = %s", location.Files[0])
+	}
+
+	file := endpoint.Get("dest", location.Files[0])
+	if file == nil {
+		t.Fatalf("audit file %s not written", location.Files[0])
+	}
+	sizes := map[string]int64{}
+	scanner := bufio.NewScanner(bytes.NewReader(file.Data))
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record.Task != "task-1" || record.SourceKey != record.DestKey || record.Attempts != 1 || record.CopiedAt.IsZero() {
+			t.Errorf("record = %+v", record)
+		}
+		sizes[record.SourceKey] = record.Size
+	}
+	if sizes["a.txt"] != 5 || sizes["dir/b.txt"] != 6 || len(sizes) != 2 {
+		t.Errorf("audited sizes = %v", sizes)
+	}
+}
+
+func TestAuditLogPolicyFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *models.AuditLogOptions
+		want    *AuditLogPolicy
+		wantErr bool
+	}{
+		{name: "not requested"},
+		{name: "disabled", opts: &models.AuditLogOptions{Prefix: "logs"}},
+		{name: "default prefix", opts: &models.AuditLogOptions{Enabled: true},
+			want: &AuditLogPolicy{Prefix: DefaultAuditLogPrefix, Name: "task"}},
+		{name: "other bucket", opts: &models.AuditLogOptions{Enabled: true, Bucket: "custody", Prefix: "/logs/s3/"},
+			want: &AuditLogPolicy{Bucket: "custody", Prefix: "logs/s3", Name: "task"}},
+		{name: "escaping prefix", opts: &models.AuditLogOptions{Enabled: true, Prefix: "logs/../x"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := AuditLogPolicyFor(tt.opts, "task")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v", tt.name, err)
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%s: policy = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
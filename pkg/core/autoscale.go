@@ -0,0 +1,106 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+)
+
+// Workers of a running copy phase follow the error rate of the source connection
+// pool: halved while too many copies fail, then raised back in steps toward the
+// target set by the migration or an operator once the rate recovers
+const (
+	autoscaleInterval      = 10 * time.Second
+	autoscaleMinRequests   = 20   // An interval with fewer copies says nothing about the error rate
+	autoscaleHighErrorRate = 10.0 // Percent of failed copies above which workers are halved
+	autoscaleLowErrorRate  = 2.0  // Percent at or below which workers are raised back
+	autoscaleMinWorkers    = 4
+	maxWorkerAdjustments   = 100 // Adjustments kept per copy phase; older ones are dropped
+)
+
+// errorRateWindow turns the pool's cumulative counters into a per-interval error rate
+type errorRateWindow struct {
+	requests int64
+	errors   int64
+}
+
+// next returns the error rate (percent) since the previous call and the number
+// of requests it is based on
+func (w *errorRateWindow) next(stats pool.ConnectionPoolStats) (float64, int64) {
+	requests := stats.TotalRequests - w.requests
+	errors := stats.TotalErrors - w.errors
+	w.requests, w.errors = stats.TotalRequests, stats.TotalErrors
+	if requests <= 0 {
+		return 0, 0
+	}
+	return float64(errors) / float64(requests) * 100, requests
+}
+
+// autoscale adjusts the target worker count for an interval's error rate and
+// records the change; nil if the count stays
+func (lc *liveControl) autoscale(errorRate float64) *models.WorkerAdjustment {
+	lc.mu.Lock()
+	if lc.finished || lc.spawn == nil {
+		lc.mu.Unlock()
+		return nil
+	}
+
+	from, to := lc.target, lc.target
+	var reason string
+	switch {
+	case errorRate > autoscaleHighErrorRate && lc.target > autoscaleMinWorkers:
+		to = max(autoscaleMinWorkers, lc.target/2)
+		reason = fmt.Sprintf("error rate %.1f%% above %.0f%%", errorRate, autoscaleHighErrorRate)
+	case errorRate <= autoscaleLowErrorRate && lc.target < lc.ceiling:
+		to = min(lc.ceiling, lc.target+max(1, lc.ceiling/4))
+		reason = fmt.Sprintf("error rate recovered to %.1f%%", errorRate)
+	default:
+		lc.mu.Unlock()
+		return nil
+	}
+
+	lc.target = to
+	lc.spawnLocked()
+	adjustment := models.WorkerAdjustment{Time: time.Now(), From: from, To: to, ErrorRate: errorRate, Reason: reason}
+	if len(lc.adjustments) == maxWorkerAdjustments {
+		lc.adjustments = append(lc.adjustments[:0], lc.adjustments[1:]...)
+	}
+	lc.adjustments = append(lc.adjustments, adjustment)
+	lc.mu.Unlock()
+
+	// Parked workers re-check their slot against the new target
+	lc.cond.Broadcast()
+	return &adjustment
+}
+
+// workerAdjustments returns the adjustments of the current copy phase
+func (lc *liveControl) workerAdjustments() []models.WorkerAdjustment {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return append([]models.WorkerAdjustment(nil), lc.adjustments...)
+}
+
+// runAutoscaler adjusts the workers of the copy phase every autoscaleInterval
+// until done is closed
+func (m *EnhancedMigrator) runAutoscaler(done <-chan struct{}) {
+	ticker := time.NewTicker(autoscaleInterval)
+	defer ticker.Stop()
+	window := errorRateWindow{}
+	window.next(m.connPool.Stats())
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			errorRate, requests := window.next(m.connPool.Stats())
+			if requests < autoscaleMinRequests {
+				continue
+			}
+			if adjustment := m.live.autoscale(errorRate); adjustment != nil {
+				m.logger.Infof("🔧 Workers %d → %d: %s\n", adjustment.From, adjustment.To, adjustment.Reason)
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"testing"
+
+	"s3migration/pkg/pool"
+)
+
+func TestAutoscaleOnErrorRate(t *testing.T) {
+	lc := newLiveControl()
+	spawned := 0
+	lc.start(40, 100, nil, func(int) { spawned++ })
+
+	steps := []struct {
+		errorRate  float64
+		wantTarget int
+		wantChange bool
+	}{
+		{errorRate: 1, wantTarget: 40},                    // Healthy at the target
+		{errorRate: 25, wantTarget: 20, wantChange: true}, // Halved
+		{errorRate: 12, wantTarget: 10, wantChange: true},
+		{errorRate: 50, wantTarget: 5, wantChange: true},
+		{errorRate: 50, wantTarget: 4, wantChange: true}, // Not below the minimum
+		{errorRate: 50, wantTarget: 4},
+		{errorRate: 5, wantTarget: 4}, // Between the thresholds: held
+		{errorRate: 0, wantTarget: 14, wantChange: true},
+		{errorRate: 2, wantTarget: 24, wantChange: true},
+		{errorRate: 0, wantTarget: 34, wantChange: true},
+		{errorRate: 0, wantTarget: 40, wantChange: true}, // Back to the target, not above
+		{errorRate: 0, wantTarget: 40},
+	}
+	for i, step := range steps {
+		adjustment := lc.autoscale(step.errorRate)
+		if (adjustment != nil) != step.wantChange || lc.snapshot().TargetWorkers != step.wantTarget {
+			t.Fatalf("step %d (%.0f%%): adjustment %+v, target %d, want %d", i, step.errorRate, adjustment, lc.snapshot().TargetWorkers, step.wantTarget)
+		}
+	}
+	if got := len(lc.workerAdjustments()); got != 8 {
+		t.Fatalf("%d adjustments recorded, want 8", got)
+	}
+	if spawned != 40 {
+		t.Fatalf("%d workers started, want 40", spawned)
+	}
+
+	// An operator's target becomes the new ceiling
+	target := 60
+	lc.apply(TuningUpdate{TargetWorkers: &target})
+	lc.autoscale(30)
+	lc.autoscale(0)
+	lc.autoscale(0)
+	if got := lc.snapshot().TargetWorkers; got != 60 {
+		t.Fatalf("target after recovery = %d, want the operator's 60", got)
+	}
+
+	lc.finish()
+	if lc.autoscale(90) != nil {
+		t.Fatal("finished phase scaled")
+	}
+}
+
+func TestErrorRateWindow(t *testing.T) {
+	window := errorRateWindow{}
+	window.next(pool.ConnectionPoolStats{TotalRequests: 1000, TotalErrors: 500})
+	rate, requests := window.next(pool.ConnectionPoolStats{TotalRequests: 1100, TotalErrors: 510})
+	if rate != 10 || requests != 100 {
+		t.Fatalf("rate %.1f%% over %d requests, want 10%% over 100", rate, requests)
+	}
+	if rate, requests := window.next(pool.ConnectionPoolStats{TotalRequests: 1100, TotalErrors: 510}); rate != 0 || requests != 0 {
+		t.Fatalf("idle interval: rate %.1f%% over %d requests", rate, requests)
+	}
+}
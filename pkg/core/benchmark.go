@@ -0,0 +1,283 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Benchmark defaults and limits
+const (
+	DefaultBenchmarkPrefix         = "s3migration-benchmark"
+	DefaultBenchmarkObjectsPerSize = 8
+	MaxBenchmarkObjectSize         = 256 * 1024 * 1024
+	MaxBenchmarkObjectsPerSize     = 1000
+	MaxBenchmarkConcurrency        = 256
+	MaxBenchmarkBytes              = 4 * 1024 * 1024 * 1024 // Uploaded across all runs
+)
+
+var (
+	defaultBenchmarkSizes       = []int64{64 * 1024, 1024 * 1024, 16 * 1024 * 1024}
+	defaultBenchmarkConcurrency = []int{1, 4, 16}
+)
+
+// BenchmarkConfig describes the synthetic objects a benchmark uploads and downloads.
+// Every size is run at every concurrency level.
+type BenchmarkConfig struct {
+	Bucket         string
+	Prefix         string  // Objects are written under <prefix>/<run id>/ and deleted afterwards
+	ObjectSizes    []int64 // Bytes
+	ObjectsPerSize int     // Objects per size and concurrency level
+	Concurrency    []int   // Parallel requests, ascending
+}
+
+// LatencyStats summarizes per-request latencies
+type LatencyStats struct {
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+	MaxMs float64 `json:"max_ms"`
+}
+
+// BenchmarkRun is one object size at one concurrency level
+type BenchmarkRun struct {
+	ObjectSize      int64        `json:"object_size"`
+	Concurrency     int          `json:"concurrency"`
+	Objects         int          `json:"objects"`
+	UploadMBps      float64      `json:"upload_mbps"`
+	DownloadMBps    float64      `json:"download_mbps"`
+	UploadLatency   LatencyStats `json:"upload_latency"`
+	DownloadLatency LatencyStats `json:"download_latency"`
+	Errors          int          `json:"errors"`
+	Throttled       int          `json:"throttled"` // SlowDown, 503 and 429 responses
+}
+
+// BenchmarkResult is the outcome of a throughput benchmark
+type BenchmarkResult struct {
+	Bucket   string         `json:"bucket"`
+	Prefix   string         `json:"prefix"`
+	Duration string         `json:"duration"`
+	Runs     []BenchmarkRun `json:"runs"`
+
+	// Highest-throughput concurrency level without throttling; a starting point for workers
+	RecommendedWorkers int     `json:"recommended_workers"`
+	MaxUploadMBps      float64 `json:"max_upload_mbps"`
+	// Lowest concurrency level at which the endpoint throttled (0 if it never did)
+	ThrottleConcurrency int `json:"throttle_concurrency,omitempty"`
+}
+
+// normalize applies defaults and rejects benchmarks beyond the limits
+func (cfg *BenchmarkConfig) normalize() error {
+	if cfg.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	cfg.Prefix = strings.Trim(cfg.Prefix, "/")
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultBenchmarkPrefix
+	}
+	if len(cfg.ObjectSizes) == 0 {
+		cfg.ObjectSizes = defaultBenchmarkSizes
+	}
+	if cfg.ObjectsPerSize == 0 {
+		cfg.ObjectsPerSize = DefaultBenchmarkObjectsPerSize
+	}
+	if len(cfg.Concurrency) == 0 {
+		cfg.Concurrency = defaultBenchmarkConcurrency
+	}
+
+	if cfg.ObjectsPerSize < 1 || cfg.ObjectsPerSize > MaxBenchmarkObjectsPerSize {
+		return fmt.Errorf("objects per size must be between 1 and %d", MaxBenchmarkObjectsPerSize)
+	}
+	var total int64
+	for _, size := range cfg.ObjectSizes {
+		if size < 1 || size > MaxBenchmarkObjectSize {
+			return fmt.Errorf("object sizes must be between 1 byte and %d MiB", MaxBenchmarkObjectSize/(1024*1024))
+		}
+		total += size * int64(cfg.ObjectsPerSize) * int64(len(cfg.Concurrency))
+	}
+	for _, level := range cfg.Concurrency {
+		if level < 1 || level > MaxBenchmarkConcurrency {
+			return fmt.Errorf("concurrency levels must be between 1 and %d", MaxBenchmarkConcurrency)
+		}
+	}
+	if total > MaxBenchmarkBytes {
+		return fmt.Errorf("benchmark would upload %d MiB, more than the %d MiB limit", total/(1024*1024), MaxBenchmarkBytes/(1024*1024))
+	}
+	cfg.Concurrency = append([]int(nil), cfg.Concurrency...)
+	sort.Ints(cfg.Concurrency)
+	return nil
+}
+
+// RunBenchmark uploads, downloads and deletes synthetic objects to measure the
+// throughput, latency and throttling behavior of an endpoint. The client should
+// not retry, so throttling shows up in the result instead of as slower requests.
+func RunBenchmark(ctx context.Context, client *s3.Client, cfg BenchmarkConfig) (*BenchmarkResult, error) {
+	if err := cfg.normalize(); err != nil {
+		return nil, err
+	}
+	started := time.Now()
+	runPrefix := path.Join(cfg.Prefix, fmt.Sprintf("%d", started.UnixNano()))
+	result := &BenchmarkResult{Bucket: cfg.Bucket, Prefix: runPrefix}
+
+	for _, size := range cfg.ObjectSizes {
+		payload := make([]byte, size)
+		rand.New(rand.NewSource(size)).Read(payload) // Incompressible, so no transport shortcuts
+
+		for _, level := range cfg.Concurrency {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			keys := make([]string, cfg.ObjectsPerSize)
+			for i := range keys {
+				keys[i] = path.Join(runPrefix, fmt.Sprintf("%d-c%d-%04d", size, level, i))
+			}
+
+			run := BenchmarkRun{ObjectSize: size, Concurrency: level, Objects: len(keys)}
+			upload := benchmarkPhase(ctx, keys, level, func(key string) (int64, error) {
+				_, err := client.PutObject(ctx, &s3.PutObjectInput{
+					Bucket:        aws.String(cfg.Bucket),
+					Key:           aws.String(key),
+					Body:          bytes.NewReader(payload),
+					ContentLength: aws.Int64(size),
+				})
+				if err != nil {
+					return 0, err
+				}
+				return size, nil
+			})
+			download := benchmarkPhase(ctx, keys, level, func(key string) (int64, error) {
+				output, err := client.GetObject(ctx, &s3.GetObjectInput{
+					Bucket: aws.String(cfg.Bucket),
+					Key:    aws.String(key),
+				})
+				if err != nil {
+					return 0, err
+				}
+				defer output.Body.Close()
+				return io.Copy(io.Discard, output.Body)
+			})
+			benchmarkPhase(ctx, keys, level, func(key string) (int64, error) {
+				_, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{
+					Bucket: aws.String(cfg.Bucket),
+					Key:    aws.String(key),
+				})
+				return 0, err
+			})
+
+			run.UploadMBps, run.UploadLatency = upload.mbps(), upload.latency()
+			run.DownloadMBps, run.DownloadLatency = download.mbps(), download.latency()
+			run.Errors = upload.errors + download.errors
+			run.Throttled = upload.throttled + download.throttled
+			result.Runs = append(result.Runs, run)
+		}
+	}
+
+	for _, run := range result.Runs {
+		if run.Throttled > 0 {
+			if result.ThrottleConcurrency == 0 || run.Concurrency < result.ThrottleConcurrency {
+				result.ThrottleConcurrency = run.Concurrency
+			}
+			continue
+		}
+		if run.Errors == 0 && run.UploadMBps > result.MaxUploadMBps {
+			result.MaxUploadMBps = run.UploadMBps
+			result.RecommendedWorkers = run.Concurrency
+		}
+	}
+	result.Duration = time.Since(started).Round(time.Millisecond).String()
+	return result, nil
+}
+
+// phaseStats collects the outcome of one phase (upload, download or delete) of a run
+type phaseStats struct {
+	bytes     int64
+	elapsed   time.Duration
+	latencies []time.Duration // Successful requests only
+	errors    int
+	throttled int
+}
+
+// benchmarkPhase runs op on every key with the given concurrency
+func benchmarkPhase(ctx context.Context, keys []string, concurrency int, op func(key string) (int64, error)) *phaseStats {
+	stats := &phaseStats{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	work := make(chan string)
+
+	started := time.Now()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range work {
+				opStarted := time.Now()
+				n, err := op(key)
+				latency := time.Since(opStarted)
+
+				mu.Lock()
+				if err != nil {
+					stats.errors++
+					if isThrottleError(err) {
+						stats.throttled++
+					}
+				} else {
+					stats.bytes += n
+					stats.latencies = append(stats.latencies, latency)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			break
+		}
+		work <- key
+	}
+	close(work)
+	wg.Wait()
+	stats.elapsed = time.Since(started)
+	return stats
+}
+
+// mbps is the phase throughput in MB/s
+func (s *phaseStats) mbps() float64 {
+	if s.elapsed <= 0 {
+		return 0
+	}
+	return float64(s.bytes) / (1024 * 1024) / s.elapsed.Seconds()
+}
+
+// latency returns the p50, p95 and maximum latency of successful requests
+func (s *phaseStats) latency() LatencyStats {
+	if len(s.latencies) == 0 {
+		return LatencyStats{}
+	}
+	sorted := append([]time.Duration(nil), s.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	at := func(q float64) float64 {
+		index := int(q * float64(len(sorted)-1))
+		return float64(sorted[index]) / float64(time.Millisecond)
+	}
+	return LatencyStats{P50Ms: at(0.50), P95Ms: at(0.95), MaxMs: at(1)}
+}
+
+// isThrottleError reports whether err is a rate-limit response from the endpoint
+func isThrottleError(err error) bool {
+	msg := err.Error()
+	for _, marker := range []string{"SlowDown", "Throttl", "TooManyRequests", "RequestLimitExceeded", "StatusCode: 503", "StatusCode: 429"} {
+		if contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
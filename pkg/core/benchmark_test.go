@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeThrottlingBucket stores objects in memory and answers SlowDown to
+// uploads that overlap another upload
+type fakeThrottlingBucket struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	inFlight int
+}
+
+func (f *fakeThrottlingBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		f.mu.Lock()
+		f.inFlight++
+		busy := f.inFlight > 1
+		f.mu.Unlock()
+		defer func() {
+			f.mu.Lock()
+			f.inFlight--
+			f.mu.Unlock()
+		}()
+		body, _ := io.ReadAll(r.Body)
+		time.Sleep(20 * time.Millisecond)
+		if busy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			io.WriteString(w, `<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>`)
+			return
+		}
+		f.mu.Lock()
+		f.objects[r.URL.Path] = body
+		f.mu.Unlock()
+	case http.MethodGet:
+		f.mu.Lock()
+		body, ok := f.objects[r.URL.Path]
+		f.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<Error><Code>NoSuchKey</Code></Error>`)
+			return
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		f.mu.Lock()
+		delete(f.objects, r.URL.Path)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestRunBenchmark(t *testing.T) {
+	bucket := &fakeThrottlingBucket{objects: make(map[string][]byte)}
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+	client := s3.New(s3.Options{
+		Region:           "us-east-1",
+		BaseEndpoint:     aws.String(server.URL),
+		UsePathStyle:     true,
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+	})
+
+	result, err := RunBenchmark(context.Background(), client, BenchmarkConfig{
+		Bucket:         "bench",
+		ObjectSizes:    []int64{1024},
+		ObjectsPerSize: 4,
+		Concurrency:    []int{4, 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Runs) != 2 || result.Runs[0].Concurrency != 1 || result.Runs[1].Concurrency != 4 {
+		t.Fatalf("runs = %+v", result.Runs)
+	}
+	if serial := result.Runs[0]; serial.Throttled != 0 || serial.Errors != 0 || serial.UploadMBps <= 0 || serial.DownloadMBps <= 0 {
+		t.Fatalf("serial run = %+v", serial)
+	}
+	if parallel := result.Runs[1]; parallel.Throttled == 0 {
+		t.Fatalf("parallel run was not throttled: %+v", parallel)
+	}
+	if result.RecommendedWorkers != 1 || result.ThrottleConcurrency != 4 {
+		t.Fatalf("recommended %d workers, throttled at %d", result.RecommendedWorkers, result.ThrottleConcurrency)
+	}
+	if len(bucket.objects) != 0 {
+		t.Fatalf("%d benchmark objects left behind", len(bucket.objects))
+	}
+}
+
+func TestBenchmarkConfigLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     BenchmarkConfig
+		wantErr bool
+	}{
+		{name: "defaults", cfg: BenchmarkConfig{Bucket: "b"}},
+		{name: "no bucket", cfg: BenchmarkConfig{}, wantErr: true},
+		{name: "object too large", cfg: BenchmarkConfig{Bucket: "b", ObjectSizes: []int64{MaxBenchmarkObjectSize + 1}}, wantErr: true},
+		{name: "too much data", cfg: BenchmarkConfig{Bucket: "b", ObjectSizes: []int64{MaxBenchmarkObjectSize}, ObjectsPerSize: 100}, wantErr: true},
+		{name: "concurrency out of range", cfg: BenchmarkConfig{Bucket: "b", Concurrency: []int{0}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.normalize(); (err != nil) != tt.wantErr {
+				t.Fatalf("normalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
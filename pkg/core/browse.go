@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// maxBrowseKeys is the largest page BrowseObjects returns (the S3 per-request limit)
+const maxBrowseKeys = 1000
+
+// BrowseObject is one object in a browse page
+type BrowseObject struct {
+	Key          string `json:"key"`
+	Size         int64  `json:"size"`
+	LastModified string `json:"last_modified,omitempty"`
+	StorageClass string `json:"storage_class,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+}
+
+// BrowsePage is one page of a delimited listing: objects directly under the
+// prefix plus the "folders" (common prefixes) one level down
+type BrowsePage struct {
+	Bucket                string         `json:"bucket"`
+	Prefix                string         `json:"prefix"`
+	Delimiter             string         `json:"delimiter"`
+	Folders               []string       `json:"folders"`
+	Objects               []BrowseObject `json:"objects"`
+	IsTruncated           bool           `json:"is_truncated"`
+	NextContinuationToken string         `json:"next_continuation_token,omitempty"`
+}
+
+// BrowseObjects lists one page under prefix, grouping keys by delimiter.
+// An empty delimiter lists keys recursively; maxKeys <= 0 uses the S3 maximum.
+func BrowseObjects(ctx context.Context, client *s3.Client, bucket, prefix, delimiter, continuationToken string, maxKeys int) (*BrowsePage, error) {
+	if maxKeys <= 0 || maxKeys > maxBrowseKeys {
+		maxKeys = maxBrowseKeys
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucket),
+		MaxKeys: aws.Int32(int32(maxKeys)),
+	}
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+	if delimiter != "" {
+		input.Delimiter = aws.String(delimiter)
+	}
+	if continuationToken != "" {
+		input.ContinuationToken = aws.String(continuationToken)
+	}
+
+	resp, err := client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	page := &BrowsePage{
+		Bucket:                bucket,
+		Prefix:                prefix,
+		Delimiter:             delimiter,
+		Folders:               make([]string, 0, len(resp.CommonPrefixes)),
+		Objects:               make([]BrowseObject, 0, len(resp.Contents)),
+		IsTruncated:           aws.ToBool(resp.IsTruncated),
+		NextContinuationToken: aws.ToString(resp.NextContinuationToken),
+	}
+
+	for _, cp := range resp.CommonPrefixes {
+		page.Folders = append(page.Folders, aws.ToString(cp.Prefix))
+	}
+	for _, obj := range resp.Contents {
+		entry := BrowseObject{
+			Key:          aws.ToString(obj.Key),
+			Size:         aws.ToInt64(obj.Size),
+			StorageClass: string(obj.StorageClass),
+			ETag:         aws.ToString(obj.ETag),
+		}
+		if obj.LastModified != nil {
+			entry.LastModified = formatTime(*obj.LastModified)
+		}
+		page.Objects = append(page.Objects, entry)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,295 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Bucket configuration states in a BucketConfigReport
+const (
+	ConfigPresent     = "present"     // Set on the source; must be re-created on the destination
+	ConfigAbsent      = "absent"      // Not set on the source
+	ConfigUnsupported = "unsupported" // The source endpoint does not implement the API
+	ConfigError       = "error"       // Could not be read
+	ConfigApplied     = "applied"     // Re-created on the destination
+	ConfigApplyFailed = "apply_failed"
+)
+
+// BucketConfigItem is the state of one bucket-level configuration
+type BucketConfigItem struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// CORSRule is a CORS rule of a bucket
+type CORSRule struct {
+	ID             string   `json:"id,omitempty"`
+	AllowedOrigins []string `json:"allowed_origins"`
+	AllowedMethods []string `json:"allowed_methods"`
+	AllowedHeaders []string `json:"allowed_headers,omitempty"`
+	ExposeHeaders  []string `json:"expose_headers,omitempty"`
+	MaxAgeSeconds  int32    `json:"max_age_seconds,omitempty"`
+}
+
+// WebsiteConfig summarizes the static website hosting configuration of a bucket
+type WebsiteConfig struct {
+	IndexDocument         string `json:"index_document,omitempty"`
+	ErrorDocument         string `json:"error_document,omitempty"`
+	RedirectAllRequestsTo string `json:"redirect_all_requests_to,omitempty"`
+	RoutingRules          int    `json:"routing_rules,omitempty"`
+
+	output *s3.GetBucketWebsiteOutput // As read, for re-creating it
+}
+
+// BucketConfigReport lists the bucket-level settings of a source bucket that a
+// migration does not copy, and what re-creating them on the destination involves
+type BucketConfigReport struct {
+	SourceBucket string `json:"source_bucket"`
+	DestBucket   string `json:"dest_bucket"`
+
+	PolicyStatus    BucketConfigItem `json:"policy"`
+	Policy          string           `json:"policy_document,omitempty"`           // As set on the source
+	RewrittenPolicy string           `json:"rewritten_policy_document,omitempty"` // Source bucket ARNs replaced by the destination's
+
+	CORSStatus BucketConfigItem `json:"cors"`
+	CORSRules  []CORSRule       `json:"cors_rules,omitempty"`
+
+	WebsiteStatus BucketConfigItem `json:"website"`
+	Website       *WebsiteConfig   `json:"website_config,omitempty"`
+
+	// Things to check or do by hand: principals, network conditions, identity-based policies, DNS
+	IAMHints []string `json:"iam_hints"`
+}
+
+// AnalyzeBucketConfig reads the policy, CORS and website configuration of a source
+// bucket. The policy is rewritten for destBucket; replacements are then applied to
+// it literally, e.g. a source account ID mapped to the destination account ID.
+func AnalyzeBucketConfig(ctx context.Context, client *s3.Client, sourceBucket, destBucket string, replacements map[string]string) (*BucketConfigReport, error) {
+	if sourceBucket == "" || destBucket == "" {
+		return nil, fmt.Errorf("source and destination buckets are required")
+	}
+	report := &BucketConfigReport{SourceBucket: sourceBucket, DestBucket: destBucket}
+
+	policy, err := client.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String(sourceBucket)})
+	report.PolicyStatus = configStatus(err, "NoSuchBucketPolicy")
+	if report.PolicyStatus.Status == ConfigPresent {
+		report.Policy = aws.ToString(policy.Policy)
+		report.RewrittenPolicy = RewriteBucketPolicy(report.Policy, sourceBucket, destBucket, replacements)
+		report.IAMHints = append(report.IAMHints, policyHints(report.Policy)...)
+	}
+
+	cors, err := client.GetBucketCors(ctx, &s3.GetBucketCorsInput{Bucket: aws.String(sourceBucket)})
+	report.CORSStatus = configStatus(err, "NoSuchCORSConfiguration")
+	if report.CORSStatus.Status == ConfigPresent {
+		for _, rule := range cors.CORSRules {
+			report.CORSRules = append(report.CORSRules, CORSRule{
+				ID:             aws.ToString(rule.ID),
+				AllowedOrigins: rule.AllowedOrigins,
+				AllowedMethods: rule.AllowedMethods,
+				AllowedHeaders: rule.AllowedHeaders,
+				ExposeHeaders:  rule.ExposeHeaders,
+				MaxAgeSeconds:  aws.ToInt32(rule.MaxAgeSeconds),
+			})
+		}
+	}
+
+	website, err := client.GetBucketWebsite(ctx, &s3.GetBucketWebsiteInput{Bucket: aws.String(sourceBucket)})
+	report.WebsiteStatus = configStatus(err, "NoSuchWebsiteConfiguration")
+	if report.WebsiteStatus.Status == ConfigPresent {
+		report.Website = &WebsiteConfig{RoutingRules: len(website.RoutingRules), output: website}
+		if website.IndexDocument != nil {
+			report.Website.IndexDocument = aws.ToString(website.IndexDocument.Suffix)
+		}
+		if website.ErrorDocument != nil {
+			report.Website.ErrorDocument = aws.ToString(website.ErrorDocument.Key)
+		}
+		if website.RedirectAllRequestsTo != nil {
+			report.Website.RedirectAllRequestsTo = aws.ToString(website.RedirectAllRequestsTo.HostName)
+		}
+		report.IAMHints = append(report.IAMHints, fmt.Sprintf(
+			"Website endpoint changes from %s to %s: update DNS records and CloudFront origins pointing at it", sourceBucket, destBucket))
+	}
+
+	report.IAMHints = append(report.IAMHints, fmt.Sprintf(
+		"Identity-based policies of IAM users and roles granting access to %s must be updated to %s", bucketARN(sourceBucket), bucketARN(destBucket)))
+	return report, nil
+}
+
+// ApplyBucketConfig re-creates the present configurations of a report on the
+// destination bucket and records the outcome of each in the report
+func ApplyBucketConfig(ctx context.Context, client *s3.Client, report *BucketConfigReport) {
+	bucket := aws.String(report.DestBucket)
+	applied := func(item *BucketConfigItem, err error) {
+		if err != nil {
+			*item = BucketConfigItem{Status: ConfigApplyFailed, Error: err.Error()}
+			return
+		}
+		*item = BucketConfigItem{Status: ConfigApplied}
+	}
+
+	if report.PolicyStatus.Status == ConfigPresent {
+		_, err := client.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{Bucket: bucket, Policy: aws.String(report.RewrittenPolicy)})
+		applied(&report.PolicyStatus, err)
+	}
+	if report.CORSStatus.Status == ConfigPresent {
+		rules := make([]types.CORSRule, len(report.CORSRules))
+		for i, rule := range report.CORSRules {
+			rules[i] = types.CORSRule{
+				AllowedOrigins: rule.AllowedOrigins,
+				AllowedMethods: rule.AllowedMethods,
+				AllowedHeaders: rule.AllowedHeaders,
+				ExposeHeaders:  rule.ExposeHeaders,
+			}
+			if rule.ID != "" {
+				rules[i].ID = aws.String(rule.ID)
+			}
+			if rule.MaxAgeSeconds != 0 {
+				rules[i].MaxAgeSeconds = aws.Int32(rule.MaxAgeSeconds)
+			}
+		}
+		_, err := client.PutBucketCors(ctx, &s3.PutBucketCorsInput{Bucket: bucket, CORSConfiguration: &types.CORSConfiguration{CORSRules: rules}})
+		applied(&report.CORSStatus, err)
+	}
+	if report.WebsiteStatus.Status == ConfigPresent {
+		source := report.Website.output
+		_, err := client.PutBucketWebsite(ctx, &s3.PutBucketWebsiteInput{Bucket: bucket, WebsiteConfiguration: &types.WebsiteConfiguration{
+			IndexDocument:         source.IndexDocument,
+			ErrorDocument:         source.ErrorDocument,
+			RedirectAllRequestsTo: source.RedirectAllRequestsTo,
+			RoutingRules:          source.RoutingRules,
+		}})
+		applied(&report.WebsiteStatus, err)
+	}
+}
+
+// configStatus classifies the result of reading one bucket configuration
+func configStatus(err error, absentCode string) BucketConfigItem {
+	if err == nil {
+		return BucketConfigItem{Status: ConfigPresent}
+	}
+	msg := err.Error()
+	switch {
+	case contains(msg, absentCode):
+		return BucketConfigItem{Status: ConfigAbsent}
+	case contains(msg, "NotImplemented"), contains(msg, "StatusCode: 501"):
+		return BucketConfigItem{Status: ConfigUnsupported}
+	}
+	return BucketConfigItem{Status: ConfigError, Error: msg}
+}
+
+// bucketARN returns the ARN of a bucket in the aws partition
+func bucketARN(bucket string) string {
+	return "arn:aws:s3:::" + bucket
+}
+
+// RewriteBucketPolicy replaces the ARNs of sourceBucket (and its objects) in a
+// policy document with those of destBucket, then applies replacements literally
+func RewriteBucketPolicy(policy, sourceBucket, destBucket string, replacements map[string]string) string {
+	// Matches the bucket ARN in any partition, followed by an object path or the end of the string
+	arn := regexp.MustCompile(`(arn:aws[a-z-]*:s3:::)` + regexp.QuoteMeta(sourceBucket) + `([/"]|$)`)
+	rewritten := arn.ReplaceAllString(policy, "${1}"+strings.ReplaceAll(destBucket, "$", "$$")+"${2}")
+
+	// Longest first, so a replacement never breaks up a longer one
+	from := make([]string, 0, len(replacements))
+	for old := range replacements {
+		if old != "" {
+			from = append(from, old)
+		}
+	}
+	sort.Slice(from, func(i, j int) bool { return len(from[i]) > len(from[j]) })
+	pairs := make([]string, 0, 2*len(from))
+	for _, old := range from {
+		pairs = append(pairs, old, replacements[old])
+	}
+	return strings.NewReplacer(pairs...).Replace(rewritten)
+}
+
+// policyStatement is the part of a bucket policy statement the hints look at
+type policyStatement struct {
+	Sid       string                            `json:"Sid"`
+	Principal json.RawMessage                   `json:"Principal"`
+	Condition map[string]map[string]interface{} `json:"Condition"`
+}
+
+// networkConditionKeys tie access to the network a request comes from
+var networkConditionKeys = map[string]string{
+	"aws:sourcevpce": "a VPC endpoint",
+	"aws:sourcevpc":  "a VPC",
+	"aws:sourceip":   "source IP ranges",
+}
+
+// policyHints lists what a policy depends on outside the bucket: principals of
+// other accounts and network conditions that may not hold for the destination
+func policyHints(policy string) []string {
+	var doc struct {
+		Statement json.RawMessage `json:"Statement"`
+	}
+	if err := json.Unmarshal([]byte(policy), &doc); err != nil {
+		return []string{fmt.Sprintf("Bucket policy could not be parsed (%v); review it by hand", err)}
+	}
+	var statements []policyStatement
+	if err := json.Unmarshal(doc.Statement, &statements); err != nil {
+		var single policyStatement
+		if json.Unmarshal(doc.Statement, &single) == nil {
+			statements = []policyStatement{single}
+		}
+	}
+
+	var hints []string
+	principals := make(map[string]bool)
+	for _, statement := range statements {
+		for _, principal := range awsPrincipals(statement.Principal) {
+			principals[principal] = true
+		}
+		for _, conditions := range statement.Condition {
+			for key, value := range conditions {
+				if what, ok := networkConditionKeys[strings.ToLower(key)]; ok {
+					hints = append(hints, fmt.Sprintf("Statement %q restricts access to %s (%s = %v); make sure it applies to the destination", statement.Sid, what, key, value))
+				}
+			}
+		}
+	}
+	sort.Strings(hints) // Condition maps have no order
+	if len(principals) > 0 {
+		names := make([]string, 0, len(principals))
+		for principal := range principals {
+			names = append(names, principal)
+		}
+		sort.Strings(names)
+		hints = append(hints, fmt.Sprintf("Policy grants access to AWS principals %s; they must exist and be trusted by the destination account", strings.Join(names, ", ")))
+	}
+	return hints
+}
+
+// awsPrincipals returns the AWS principals (account IDs and ARNs) of a statement; "*" is left out
+func awsPrincipals(raw json.RawMessage) []string {
+	var principal struct {
+		AWS json.RawMessage `json:"AWS"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &principal) != nil || len(principal.AWS) == 0 {
+		return nil
+	}
+	var list []string
+	if json.Unmarshal(principal.AWS, &list) != nil {
+		var single string
+		if json.Unmarshal(principal.AWS, &single) != nil {
+			return nil
+		}
+		list = []string{single}
+	}
+	var principals []string
+	for _, p := range list {
+		if p != "*" {
+			principals = append(principals, p)
+		}
+	}
+	return principals
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"s3migration/pkg/fakes3"
+)
+
+const testBucketPolicy = `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Sid": "PartnerRead",
+      "Effect": "Allow",
+      "Principal": {"AWS": ["arn:aws:iam::111111111111:root", "*"]},
+      "Action": "s3:GetObject",
+      "Resource": "arn:aws:s3:::logs/*",
+      "Condition": {"StringEquals": {"aws:SourceVpce": "vpce-1234"}}
+    },
+    {
+      "Sid": "List",
+      "Effect": "Allow",
+      "Principal": {"AWS": "arn:aws:iam::111111111111:role/reader"},
+      "Action": "s3:ListBucket",
+      "Resource": ["arn:aws:s3:::logs", "arn:aws:s3:::logs-archive"]
+    }
+  ]
+}`
+
+func TestRewriteBucketPolicy(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       string
+		replacements map[string]string
+		want         string
+	}{
+		{name: "bucket and objects", policy: `["arn:aws:s3:::logs", "arn:aws:s3:::logs/*"]`, want: `["arn:aws:s3:::lake", "arn:aws:s3:::lake/*"]`},
+		{name: "other bucket with same prefix", policy: `"arn:aws:s3:::logs-archive"`, want: `"arn:aws:s3:::logs-archive"`},
+		{name: "other partition", policy: `"arn:aws-cn:s3:::logs/a"`, want: `"arn:aws-cn:s3:::lake/a"`},
+		{name: "replacements", policy: `"arn:aws:iam::111111111111:root"`, replacements: map[string]string{"111111111111": "222222222222"}, want: `"arn:aws:iam::222222222222:root"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RewriteBucketPolicy(tt.policy, "logs", "lake", tt.replacements); got != tt.want {
+				t.Fatalf("RewriteBucketPolicy() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeAndApplyBucketConfig(t *testing.T) {
+	endpoint := fakes3.New("logs", "lake")
+	defer endpoint.Close()
+	endpoint.SetBucketConfig("logs", "policy", []byte(testBucketPolicy))
+	endpoint.SetBucketConfig("logs", "cors", []byte(`<CORSConfiguration><CORSRule><AllowedOrigin>https://app.example.com</AllowedOrigin><AllowedMethod>GET</AllowedMethod><MaxAgeSeconds>300</MaxAgeSeconds></CORSRule></CORSConfiguration>`))
+	client := endpoint.Client()
+
+	report, err := AnalyzeBucketConfig(context.Background(), client, "logs", "lake", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.PolicyStatus.Status != ConfigPresent || report.CORSStatus.Status != ConfigPresent || report.WebsiteStatus.Status != ConfigAbsent {
+		t.Fatalf("statuses: policy %+v, cors %+v, website %+v", report.PolicyStatus, report.CORSStatus, report.WebsiteStatus)
+	}
+	if !strings.Contains(report.RewrittenPolicy, "arn:aws:s3:::lake/*") || strings.Contains(report.RewrittenPolicy, "arn:aws:s3:::logs/*") {
+		t.Fatalf("policy not rewritten: %s", report.RewrittenPolicy)
+	}
+	if len(report.CORSRules) != 1 || report.CORSRules[0].AllowedOrigins[0] != "https://app.example.com" || report.CORSRules[0].MaxAgeSeconds != 300 {
+		t.Fatalf("CORS rules = %+v", report.CORSRules)
+	}
+	hints := strings.Join(report.IAMHints, "\n")
+	for _, want := range []string{"vpce-1234", "arn:aws:iam::111111111111:role/reader, arn:aws:iam::111111111111:root", "Identity-based policies"} {
+		if !strings.Contains(hints, want) {
+			t.Errorf("hints do not mention %q:\n%s", want, hints)
+		}
+	}
+
+	ApplyBucketConfig(context.Background(), client, report)
+	if report.PolicyStatus.Status != ConfigApplied || report.CORSStatus.Status != ConfigApplied || report.WebsiteStatus.Status != ConfigAbsent {
+		t.Fatalf("after apply: policy %+v, cors %+v, website %+v", report.PolicyStatus, report.CORSStatus, report.WebsiteStatus)
+	}
+	if got := string(endpoint.BucketConfig("lake", "policy")); got != report.RewrittenPolicy {
+		t.Fatalf("destination policy = %s", got)
+	}
+	if got := string(endpoint.BucketConfig("lake", "cors")); !strings.Contains(got, "https://app.example.com") {
+		t.Fatalf("destination CORS = %s", got)
+	}
+}
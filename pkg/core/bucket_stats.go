@@ -0,0 +1,161 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// StatsBucket is one range of a histogram
+type StatsBucket struct {
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+	Bytes int64  `json:"bytes"`
+}
+
+// BucketStats summarizes the objects under a bucket/prefix
+type BucketStats struct {
+	Bucket          string                 `json:"bucket"`
+	Prefix          string                 `json:"prefix"`
+	ObjectCount     int64                  `json:"object_count"`
+	TotalSize       int64                  `json:"total_size"`
+	TotalSizeMB     float64                `json:"total_size_mb"`
+	AvgObjectSize   int64                  `json:"avg_object_size"`
+	LargestKey      string                 `json:"largest_key,omitempty"`
+	LargestSize     int64                  `json:"largest_size"`
+	SizeHistogram   []StatsBucket          `json:"size_histogram"`
+	StorageClasses  map[string]StatsBucket `json:"storage_classes"`
+	LastModified    []StatsBucket          `json:"last_modified"` // Age of objects relative to CollectedAt
+	OldestModified  string                 `json:"oldest_modified,omitempty"`
+	NewestModified  string                 `json:"newest_modified,omitempty"`
+	Pages           int                    `json:"pages"`
+	CollectedAt     time.Time              `json:"collected_at"`
+	ListingDuration string                 `json:"listing_duration"`
+}
+
+// sizeRanges are the upper bounds (exclusive) of the size histogram; the last range is open-ended
+var sizeRanges = []struct {
+	label string
+	max   int64
+}{
+	{"< 1 KB", 1024},
+	{"1 KB - 1 MB", 1024 * 1024},
+	{"1 MB - 16 MB", 16 * 1024 * 1024},
+	{"16 MB - 128 MB", 128 * 1024 * 1024},
+	{"128 MB - 1 GB", 1024 * 1024 * 1024},
+	{"1 GB - 5 GB", 5 * 1024 * 1024 * 1024},
+	{">= 5 GB", -1},
+}
+
+// ageRanges are the upper bounds (exclusive) of the last-modified distribution; the last range is open-ended
+var ageRanges = []struct {
+	label string
+	max   time.Duration
+}{
+	{"< 1 day", 24 * time.Hour},
+	{"1 - 7 days", 7 * 24 * time.Hour},
+	{"7 - 30 days", 30 * 24 * time.Hour},
+	{"30 - 90 days", 90 * 24 * time.Hour},
+	{"90 - 365 days", 365 * 24 * time.Hour},
+	{"> 1 year", -1},
+}
+
+// CollectBucketStats streams a listing of bucket/prefix and aggregates statistics.
+// Only counters are kept, so memory use does not grow with the number of objects.
+func CollectBucketStats(ctx context.Context, client *s3.Client, bucket, prefix string) (*BucketStats, error) {
+	now := time.Now()
+	stats := &BucketStats{
+		Bucket:         bucket,
+		Prefix:         prefix,
+		SizeHistogram:  make([]StatsBucket, len(sizeRanges)),
+		StorageClasses: make(map[string]StatsBucket),
+		LastModified:   make([]StatsBucket, len(ageRanges)),
+		CollectedAt:    now,
+	}
+	for i, r := range sizeRanges {
+		stats.SizeHistogram[i].Label = r.label
+	}
+	for i, r := range ageRanges {
+		stats.LastModified[i].Label = r.label
+	}
+
+	// ListObjects v1 with the same marker fallback as migrations, since ListObjectsV2
+	// continuation tokens are unreliable on CMC and other S3-compatible providers
+	input := &s3.ListObjectsInput{Bucket: aws.String(bucket), MaxKeys: aws.Int32(1000)}
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+
+	var oldest, newest time.Time
+	for {
+		page, err := client.ListObjects(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects (page %d): %w", stats.Pages+1, err)
+		}
+		stats.Pages++
+
+		for _, obj := range page.Contents {
+			size := aws.ToInt64(obj.Size)
+			stats.ObjectCount++
+			stats.TotalSize += size
+			if size > stats.LargestSize || stats.LargestKey == "" {
+				stats.LargestSize = size
+				stats.LargestKey = aws.ToString(obj.Key)
+			}
+
+			for i, r := range sizeRanges {
+				if r.max < 0 || size < r.max {
+					stats.SizeHistogram[i].Count++
+					stats.SizeHistogram[i].Bytes += size
+					break
+				}
+			}
+
+			class := string(obj.StorageClass)
+			if class == "" {
+				class = "STANDARD"
+			}
+			entry := stats.StorageClasses[class]
+			entry.Label = class
+			entry.Count++
+			entry.Bytes += size
+			stats.StorageClasses[class] = entry
+
+			if obj.LastModified != nil {
+				modified := *obj.LastModified
+				if oldest.IsZero() || modified.Before(oldest) {
+					oldest = modified
+				}
+				if modified.After(newest) {
+					newest = modified
+				}
+				age := now.Sub(modified)
+				for i, r := range ageRanges {
+					if r.max < 0 || age < r.max {
+						stats.LastModified[i].Count++
+						stats.LastModified[i].Bytes += size
+						break
+					}
+				}
+			}
+		}
+
+		input.Marker = nextMarker(page)
+		if input.Marker == nil {
+			break
+		}
+	}
+
+	stats.TotalSizeMB = float64(stats.TotalSize) / (1024 * 1024)
+	if stats.ObjectCount > 0 {
+		stats.AvgObjectSize = stats.TotalSize / stats.ObjectCount
+	}
+	stats.OldestModified = formatTime(oldest)
+	stats.NewestModified = formatTime(newest)
+	stats.ListingDuration = time.Since(now).Round(time.Millisecond).String()
+
+	return stats, nil
+}
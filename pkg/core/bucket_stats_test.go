@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// fakeListingServer serves ListObjects v1 pages without NextMarker, like CMC, and fails ListObjectsV2
+func fakeListingServer(t *testing.T, pages [][]string) *s3.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("list-type") == "2" {
+			http.Error(w, "ListObjectsV2 not supported", http.StatusNotImplemented)
+			return
+		}
+		marker := r.URL.Query().Get("marker")
+		page := 0
+		for i := 1; i < len(pages); i++ {
+			if previous := pages[i-1]; previous[len(previous)-1] == marker {
+				page = i
+			}
+		}
+		var contents strings.Builder
+		for _, key := range pages[page] {
+			fmt.Fprintf(&contents, "<Contents><Key>%s</Key><Size>2048</Size><LastModified>2026-01-01T00:00:00.000Z</LastModified><StorageClass>STANDARD</StorageClass></Contents>", key)
+		}
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><ListBucketResult><Name>bucket</Name><IsTruncated>%v</IsTruncated>%s</ListBucketResult>`,
+			page < len(pages)-1, contents.String())
+	}))
+	t.Cleanup(server.Close)
+
+	return s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+	})
+}
+
+func TestCollectBucketStatsFollowsLastKeyMarker(t *testing.T) {
+	client := fakeListingServer(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}})
+
+	stats, err := CollectBucketStats(context.Background(), client, "bucket", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Pages != 3 || stats.ObjectCount != 5 || stats.TotalSize != 5*2048 {
+		t.Fatalf("got %d pages, %d objects, %d bytes; want 3 pages, 5 objects, %d bytes",
+			stats.Pages, stats.ObjectCount, stats.TotalSize, 5*2048)
+	}
+	if got := stats.StorageClasses["STANDARD"].Count; got != 5 {
+		t.Fatalf("STANDARD count = %d, want 5", got)
+	}
+}
+
+// objects builds listing entries for keys
+func objects(keys ...string) []types.Object {
+	out := make([]types.Object, len(keys))
+	for i, key := range keys {
+		out[i] = types.Object{Key: aws.String(key)}
+	}
+	return out
+}
+
+func TestNextMarker(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *s3.ListObjectsOutput
+		want   string
+	}{
+		{"not truncated", &s3.ListObjectsOutput{IsTruncated: aws.Bool(false), NextMarker: aws.String("x")}, ""},
+		{"next marker", &s3.ListObjectsOutput{IsTruncated: aws.Bool(true), NextMarker: aws.String("x")}, "x"},
+		{"last key fallback", &s3.ListObjectsOutput{IsTruncated: aws.Bool(true), Contents: objects("a", "b")}, "b"},
+		{"truncated empty page", &s3.ListObjectsOutput{IsTruncated: aws.Bool(true)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aws.ToString(nextMarker(tt.result)); got != tt.want {
+				t.Fatalf("nextMarker() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
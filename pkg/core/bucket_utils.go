@@ -3,7 +3,10 @@ package core
 import (
 	"context"
 	"fmt"
+	"path"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -59,7 +62,7 @@ func (bv *BucketValidator) BucketExists(ctx context.Context, bucketName string)
 		if contains(errMsg, "NotFound") || contains(errMsg, "NoSuchBucket") || contains(errMsg, "404") {
 			return false, nil // Bucket doesn't exist
 		}
-		
+
 		// Some other error (permissions, network, etc.)
 		return false, err
 	}
@@ -116,6 +119,29 @@ func (bv *BucketValidator) ValidateBucketAccess(ctx context.Context, bucketName
 	return nil
 }
 
+// ValidateWriteAccess writes and deletes a small probe object under prefix, proving the
+// credentials can write where a migration will put its objects. A probe that cannot be
+// deleted is left behind with a warning; write access is what matters.
+func (bv *BucketValidator) ValidateWriteAccess(ctx context.Context, bucketName, prefix string) error {
+	key := path.Join(prefix, fmt.Sprintf(".s3migration-write-check-%d", time.Now().UnixNano()))
+	_, err := bv.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		Body:   strings.NewReader("write check"),
+	})
+	if err != nil {
+		return fmt.Errorf("no write access to bucket '%s': %w", bucketName, err)
+	}
+
+	if _, err := bv.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	}); err != nil {
+		fmt.Printf("⚠️  Failed to delete write check object %s/%s: %v\n", bucketName, key, err)
+	}
+	return nil
+}
+
 // GetBucketRegion retrieves the region of a bucket
 func (bv *BucketValidator) GetBucketRegion(ctx context.Context, bucketName string) (string, error) {
 	resp, err := bv.client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{
@@ -196,3 +222,55 @@ func (bv *BucketValidator) GetBucketInfo(ctx context.Context, bucketName string)
 	return info
 }
 
+// BucketSummary is one entry of a bucket listing
+type BucketSummary struct {
+	Name         string `json:"name"`
+	CreationDate string `json:"creation_date,omitempty"`
+	Region       string `json:"region,omitempty"`
+	RegionError  string `json:"region_error,omitempty"` // Set when the region lookup failed
+}
+
+// maxRegionLookups limits concurrent GetBucketLocation calls when listing buckets
+const maxRegionLookups = 10
+
+// ListBuckets returns all buckets visible to the client, optionally with each bucket's region.
+// Region lookups are best effort: S3-compatible providers often don't implement them.
+func (bv *BucketValidator) ListBuckets(ctx context.Context, withRegion bool) ([]BucketSummary, error) {
+	resp, err := bv.client.ListBuckets(ctx, &s3.ListBucketsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
+	}
+
+	buckets := make([]BucketSummary, len(resp.Buckets))
+	for i, b := range resp.Buckets {
+		buckets[i].Name = aws.ToString(b.Name)
+		if b.CreationDate != nil {
+			buckets[i].CreationDate = formatTime(*b.CreationDate)
+		}
+	}
+
+	if !withRegion {
+		return buckets, nil
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxRegionLookups)
+	for i := range buckets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(b *BucketSummary) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			region, err := bv.GetBucketRegion(ctx, b.Name)
+			if err != nil {
+				b.RegionError = err.Error()
+				return
+			}
+			b.Region = region
+		}(&buckets[i])
+	}
+	wg.Wait()
+
+	return buckets, nil
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func TestValidateWriteAccess(t *testing.T) {
+	tests := []struct {
+		name        string
+		putStatus   int
+		wantErr     bool
+		wantDeletes int
+	}{
+		{name: "writable", putStatus: http.StatusOK, wantDeletes: 1},
+		{name: "denied", putStatus: http.StatusForbidden, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var putKey, deleteKey string
+			deletes := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodPut:
+					putKey = r.URL.Path
+					w.WriteHeader(tt.putStatus)
+				case http.MethodDelete:
+					deleteKey = r.URL.Path
+					deletes++
+					w.WriteHeader(http.StatusNoContent)
+				}
+			}))
+			defer server.Close()
+			client := s3.New(s3.Options{
+				Region:           "us-east-1",
+				BaseEndpoint:     aws.String(server.URL),
+				UsePathStyle:     true,
+				Credentials:      aws.AnonymousCredentials{},
+				RetryMaxAttempts: 1,
+			})
+
+			err := NewBucketValidator(client).ValidateWriteAccess(context.Background(), "dest", "drive/backup")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateWriteAccess() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !strings.HasPrefix(putKey, "/dest/drive/backup/.s3migration-write-check-") {
+				t.Fatalf("probe written to %q", putKey)
+			}
+			if deletes != tt.wantDeletes || (deletes > 0 && deleteKey != putKey) {
+				t.Fatalf("%d deletes of %q, want %d of the probe", deletes, deleteKey, tt.wantDeletes)
+			}
+		})
+	}
+}
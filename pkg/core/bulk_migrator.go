@@ -10,6 +10,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"strings"
+
+	"s3migration/pkg/buckets"
 )
 
 // BulkMigrator handles migration of all buckets in an account
@@ -268,25 +270,16 @@ func (bm *BulkMigrator) filterBuckets(allBuckets, includeBuckets, excludeBuckets
 func (bm *BulkMigrator) ensureBucketExists(ctx context.Context, bucketName string) error {
 	// Check if bucket exists in destination
 	destClient := bm.destEnhanced.GetClient()
-	_, err := destClient.HeadBucket(ctx, &s3.HeadBucketInput{
-		Bucket: aws.String(bucketName),
-	})
-
-	if err == nil {
-		// Bucket already exists
-		return nil
-	}
-
-	// Create bucket in destination
-	_, err = destClient.CreateBucket(ctx, &s3.CreateBucketInput{
+	created, err := buckets.Ensure(ctx, destClient, &s3.CreateBucketInput{
 		Bucket: aws.String(bucketName),
 	})
-
 	if err != nil {
-		return fmt.Errorf("failed to create bucket: %w", err)
+		return err
 	}
 
-	fmt.Printf("📝 Created destination bucket: %s\n", bucketName)
+	if created {
+		fmt.Printf("📝 Created destination bucket: %s\n", bucketName)
+	}
 	return nil
 }
 
@@ -0,0 +1,169 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// Outcomes of a capability check
+const (
+	CapabilityAllowed    = "allowed"
+	CapabilityDenied     = "denied"      // The provider refused the request
+	CapabilityError      = "error"       // The check failed for another reason, see Detail
+	CapabilityNotChecked = "not_checked" // Needs a bucket, or an earlier check failed
+)
+
+// Capability is the outcome of one capability check
+type Capability struct {
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"` // Why the check did not pass or was not made
+}
+
+// CapabilityReport is what a set of credentials can do, found with
+// lightweight calls
+type CapabilityReport struct {
+	Valid         bool       `json:"valid"` // The provider accepted the keys
+	Bucket        string     `json:"bucket,omitempty"`
+	ListBuckets   Capability `json:"list_buckets"`
+	Read          Capability `json:"read"`           // HeadBucket and a one-key listing of the bucket
+	Write         Capability `json:"write"`          // Upload of an empty probe object
+	Delete        Capability `json:"delete"`         // Deletion of the probe object
+	Multipart     Capability `json:"multipart"`      // Start, then abort, a multipart upload
+	CreateBuckets Capability `json:"create_buckets"` // CreateBucket of the bucket, which already exists
+}
+
+// credentialErrorCodes are the error codes of keys the provider does not accept
+var credentialErrorCodes = map[string]bool{
+	"InvalidAccessKeyId":    true,
+	"SignatureDoesNotMatch": true,
+	"InvalidToken":          true,
+	"ExpiredToken":          true,
+	"InvalidClientTokenId":  true,
+}
+
+// CheckCapabilities finds what client's credentials can do. Without a bucket
+// only ListBuckets is tried. With one, the write checks upload and delete an
+// empty object under prefix and start and abort a multipart upload of it;
+// bucket creation is tried on the existing bucket, which creates nothing.
+func CheckCapabilities(ctx context.Context, client *s3.Client, bucket, prefix string) *CapabilityReport {
+	report := &CapabilityReport{Valid: true, Bucket: bucket}
+	check := func(err error) Capability {
+		capability := capabilityOf(err)
+		if code := errorCode(err); credentialErrorCodes[code] {
+			report.Valid = false
+		}
+		return capability
+	}
+
+	_, err := client.ListBuckets(ctx, &s3.ListBucketsInput{})
+	report.ListBuckets = check(err)
+
+	notChecked := func(detail string) {
+		for _, capability := range []*Capability{&report.Read, &report.Write, &report.Delete, &report.Multipart, &report.CreateBuckets} {
+			if capability.Status == "" {
+				*capability = Capability{Status: CapabilityNotChecked, Detail: detail}
+			}
+		}
+	}
+	if !report.Valid {
+		notChecked("the credentials were not accepted")
+		return report
+	}
+	if bucket == "" {
+		notChecked("no bucket given")
+		return report
+	}
+
+	_, err = client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)})
+	if err == nil {
+		_, err = client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: aws.String(bucket), Prefix: aws.String(prefix), MaxKeys: aws.Int32(1)})
+	}
+	report.Read = check(err)
+	if httpStatus(err) == http.StatusNotFound {
+		report.Read.Detail = fmt.Sprintf("bucket %s does not exist", bucket)
+		notChecked("the bucket does not exist")
+		return report
+	}
+	if !report.Valid {
+		notChecked("the credentials were not accepted")
+		return report
+	}
+
+	key := destKeyFor(fmt.Sprintf(".s3migration-capability-probe-%d", time.Now().UnixNano()), prefix)
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{Bucket: aws.String(bucket), Key: aws.String(key), Body: bytes.NewReader(nil)})
+	report.Write = check(err)
+	if err == nil {
+		_, err = client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+		report.Delete = check(err)
+		if err != nil {
+			report.Delete.Detail += fmt.Sprintf(" (probe object %s was left in the bucket)", key)
+		}
+	} else {
+		report.Delete = Capability{Status: CapabilityNotChecked, Detail: "no probe object could be written"}
+	}
+
+	upload, err := client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	report.Multipart = check(err)
+	if err == nil {
+		if _, err := client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{Bucket: aws.String(bucket), Key: aws.String(key), UploadId: upload.UploadId}); err != nil {
+			report.Multipart.Detail = fmt.Sprintf("upload %s of %s could not be aborted: %v", aws.ToString(upload.UploadId), key, err)
+		}
+	}
+
+	// The bucket exists, so a permitted create fails with BucketAlreadyOwnedByYou
+	// (or succeeds without effect in us-east-1) instead of creating anything
+	create := &s3.CreateBucketInput{Bucket: aws.String(bucket)}
+	if region := client.Options().Region; region != "" && region != "us-east-1" {
+		create.CreateBucketConfiguration = &types.CreateBucketConfiguration{LocationConstraint: types.BucketLocationConstraint(region)}
+	}
+	_, err = client.CreateBucket(ctx, create)
+	switch code := errorCode(err); code {
+	case "BucketAlreadyOwnedByYou", "BucketAlreadyExists":
+		report.CreateBuckets = Capability{Status: CapabilityAllowed}
+	default:
+		report.CreateBuckets = check(err)
+	}
+	return report
+}
+
+// capabilityOf maps the error of a check call to its outcome
+func capabilityOf(err error) Capability {
+	if err == nil {
+		return Capability{Status: CapabilityAllowed}
+	}
+	detail := err.Error()
+	if code := errorCode(err); code != "" {
+		detail = code
+	}
+	if status := httpStatus(err); status == http.StatusForbidden || status == http.StatusUnauthorized {
+		return Capability{Status: CapabilityDenied, Detail: detail}
+	}
+	return Capability{Status: CapabilityError, Detail: detail}
+}
+
+// errorCode returns the S3 error code of err (empty when there is none)
+func errorCode(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
+
+// httpStatus returns the HTTP status code of the response err came with (0 when there is none)
+func httpStatus(err error) int {
+	var response interface{ HTTPStatusCode() int }
+	if errors.As(err, &response) {
+		return response.HTTPStatusCode()
+	}
+	return 0
+}
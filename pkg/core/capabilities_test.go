@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"s3migration/pkg/fakes3"
+)
+
+func TestCheckCapabilities(t *testing.T) {
+	tests := []struct {
+		name   string
+		bucket string
+		deny   func(method, bucket, key string) bool
+		want   map[string]string // Status of each capability by name
+	}{
+		{
+			name:   "full access",
+			bucket: "dest",
+			want: map[string]string{"list_buckets": CapabilityAllowed, "read": CapabilityAllowed, "write": CapabilityAllowed,
+				"delete": CapabilityAllowed, "multipart": CapabilityAllowed, "create_buckets": CapabilityAllowed},
+		},
+		{
+			name:   "no bucket",
+			bucket: "",
+			want: map[string]string{"list_buckets": CapabilityAllowed, "read": CapabilityNotChecked, "write": CapabilityNotChecked,
+				"delete": CapabilityNotChecked, "multipart": CapabilityNotChecked, "create_buckets": CapabilityNotChecked},
+		},
+		{
+			name:   "missing bucket",
+			bucket: "other",
+			want: map[string]string{"list_buckets": CapabilityAllowed, "read": CapabilityError, "write": CapabilityNotChecked,
+				"delete": CapabilityNotChecked, "multipart": CapabilityNotChecked, "create_buckets": CapabilityNotChecked},
+		},
+		{
+			name:   "read only",
+			bucket: "dest",
+			deny: func(method, bucket, key string) bool {
+				return method != http.MethodGet && method != http.MethodHead
+			},
+			want: map[string]string{"list_buckets": CapabilityAllowed, "read": CapabilityAllowed, "write": CapabilityDenied,
+				"delete": CapabilityNotChecked, "multipart": CapabilityDenied, "create_buckets": CapabilityDenied},
+		},
+		{
+			name:   "scoped to the bucket, without delete or multipart",
+			bucket: "dest",
+			deny: func(method, bucket, key string) bool {
+				return bucket == "" || method == http.MethodDelete || method == http.MethodPost || (method == http.MethodPut && key == "")
+			},
+			want: map[string]string{"list_buckets": CapabilityDenied, "read": CapabilityAllowed, "write": CapabilityAllowed,
+				"delete": CapabilityDenied, "multipart": CapabilityDenied, "create_buckets": CapabilityDenied},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := fakes3.New("dest")
+			defer endpoint.Close()
+			endpoint.Deny = tt.deny
+
+			report := CheckCapabilities(context.Background(), endpoint.Client(), tt.bucket, "incoming/")
+			got := map[string]Capability{"list_buckets": report.ListBuckets, "read": report.Read, "write": report.Write,
+				"delete": report.Delete, "multipart": report.Multipart, "create_buckets": report.CreateBuckets}
+			for name, status := range tt.want {
+				if got[name].Status != status {
+					t.Errorf("%s = %+v, want %s", name, got[name], status)
+				}
+			}
+			if !report.Valid {
+				t.Error("credentials reported invalid")
+			}
+			// Only the probe object was written, and it is gone unless delete was denied
+			if keys := endpoint.Keys("dest"); len(keys) > 0 && report.Delete.Status == CapabilityAllowed {
+				t.Errorf("dest keys = %q", keys)
+			}
+		})
+	}
+}
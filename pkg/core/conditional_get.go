@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	pkgSync "s3migration/pkg/sync"
+)
+
+// errNotModified means the source answered a conditional GET with 304: the
+// object did not change since its copy, which is still in place
+var errNotModified = errors.New("source object not modified since the last sync")
+
+// ConditionalGetPolicy keeps the sync state of cross-account copies, so a
+// repeated sync of the same route sends conditional GETs and does not download
+// objects that are unchanged since their last copy
+type ConditionalGetPolicy struct {
+	Store pkgSync.ObjectStateStore
+}
+
+// syncStates are the object sync states of the route of one Migrate call
+type syncStates struct {
+	store       pkgSync.ObjectStateStore
+	scope       string
+	mu          sync.Mutex
+	states      map[string]pkgSync.ObjectSyncState // Loaded, then updated by this run's copies
+	notModified atomic.Int64
+}
+
+// loadSyncStates loads the sync states of a cross-account run (nil when the
+// run does not use conditional GETs). A state that cannot be loaded only
+// costs the downloads it would have saved.
+func (m *EnhancedMigrator) loadSyncStates(input MigrateInput, destClient *s3.Client) *syncStates {
+	if input.ConditionalGet == nil || destClient == nil || input.DryRun {
+		return nil
+	}
+	s := &syncStates{
+		store: input.ConditionalGet.Store,
+		scope: pkgSync.ObjectStateScope(m.config.EndpointURL, input.SourceBucket, input.SourcePrefix, input.DestEndpointURL, input.DestBucket, input.DestPrefix),
+	}
+	states, err := s.store.LoadObjectStates(s.scope)
+	if err != nil {
+		m.logger.Errorf("Conditional GET: %v; every object is downloaded", err)
+	}
+	if states == nil {
+		states = make(map[string]pkgSync.ObjectSyncState)
+	}
+	s.states = states
+	fmt.Printf("Conditional GET: %d objects recorded by earlier syncs\n", len(states))
+	return s
+}
+
+// unchangedCopy returns the recorded state of sourceKey when its copy at
+// destKey still has the ETag it was written with, so a conditional GET can
+// stand for the copy
+func (s *syncStates) unchangedCopy(ctx context.Context, destClient *s3.Client, sourceKey, destBucket, destKey string) (pkgSync.ObjectSyncState, bool) {
+	if s == nil {
+		return pkgSync.ObjectSyncState{}, false
+	}
+	s.mu.Lock()
+	state, ok := s.states[sourceKey]
+	s.mu.Unlock()
+	if !ok {
+		return state, false
+	}
+	head, err := destClient.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(destBucket), Key: aws.String(destKey)})
+	if err != nil || aws.ToString(head.ETag) != state.DestETag {
+		return state, false
+	}
+	return state, true
+}
+
+// record keeps the state of an object this run copied
+func (s *syncStates) record(sourceKey string, state pkgSync.ObjectSyncState) {
+	if s == nil || state.SourceETag == "" || state.DestETag == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[sourceKey] = state
+}
+
+// count returns the objects the source reported unchanged
+func (s *syncStates) count() int64 {
+	if s == nil {
+		return 0
+	}
+	return s.notModified.Load()
+}
+
+// save stores the states for the next sync. With listed set, states of source
+// keys no longer listed are dropped.
+func (s *syncStates) save(listed []objectInfo) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if listed != nil {
+		keys := make(map[string]bool, len(listed))
+		for _, obj := range listed {
+			keys[obj.Key] = true
+		}
+		for key := range s.states {
+			if !keys[key] {
+				delete(s.states, key)
+			}
+		}
+	}
+	return s.store.SaveObjectStates(s.scope, s.states)
+}
+
+// isNotModified reports whether err is a 304 answer to a conditional GET
+func isNotModified(err error) bool {
+	var response interface{ HTTPStatusCode() int }
+	return errors.As(err, &response) && response.HTTPStatusCode() == http.StatusNotModified
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/pool"
+	"s3migration/pkg/state"
+)
+
+func TestMigrateConditionalGet(t *testing.T) {
+	// The destination pool's HTTP client cannot take a CA bundle; the fake endpoints need none
+	t.Setenv("AWS_CA_BUNDLE", "")
+	source := fakes3.New("source")
+	defer source.Close()
+	dest := fakes3.New("dest")
+	defer dest.Close()
+	source.Put("source", "a.txt", []byte("alpha"))
+	source.Put("source", "b.txt", []byte("bravo"))
+	source.Put("source", "c.txt", []byte("charlie"))
+
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool: pool.NewStaticConnectionPool(source.Client()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := &ConditionalGetPolicy{Store: state.NewMemorySyncStateStore()}
+	sync := func() *MigrateResult {
+		t.Helper()
+		result, err := migrator.Migrate(context.Background(), MigrateInput{
+			SourceBucket:    "source",
+			DestBucket:      "dest",
+			DestAccessKey:   "dest-key",
+			DestSecretKey:   "dest-secret",
+			DestEndpointURL: dest.URL,
+			MigrationMode:   ModeFullRewrite,
+			ConditionalGet:  policy,
+			Timeout:         time.Minute,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Failed != 0 {
+			t.Fatalf("failed %d: %v", result.Failed, result.Errors)
+		}
+		return result
+	}
+
+	// The first sync records every copy
+	if result := sync(); result.Copied != 3 || result.NotModified != 0 {
+		t.Fatalf("first sync copied %d, %d not modified", result.Copied, result.NotModified)
+	}
+
+	// Unchanged objects with intact copies are not downloaded again; a changed
+	// source object and a replaced copy are
+	source.Put("source", "b.txt", []byte("bravo 2"))
+	dest.Put("dest", "c.txt", []byte("replaced"))
+	result := sync()
+	if result.Copied != 2 || result.NotModified != 1 {
+		t.Errorf("second sync copied %d, %d not modified; want 2, 1", result.Copied, result.NotModified)
+	}
+	for key, want := range map[string]string{"a.txt": "alpha", "b.txt": "bravo 2", "c.txt": "charlie"} {
+		if got := dest.Get("dest", key); got == nil || string(got.Data) != want {
+			t.Errorf("dest %s = %+v, want %q", key, got, want)
+		}
+	}
+
+	// Only the route the states were recorded for uses them
+	result, err = migrator.Migrate(context.Background(), MigrateInput{
+		SourceBucket:    "source",
+		DestBucket:      "dest",
+		DestPrefix:      "copy",
+		DestAccessKey:   "dest-key",
+		DestSecretKey:   "dest-secret",
+		DestEndpointURL: dest.URL,
+		MigrationMode:   ModeFullRewrite,
+		ConditionalGet:  policy,
+		Timeout:         time.Minute,
+	})
+	if err != nil || result.Copied != 3 || result.NotModified != 0 {
+		t.Errorf("sync to another prefix copied %d, %d not modified (%v)", result.Copied, result.NotModified, err)
+	}
+}
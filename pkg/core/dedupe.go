@@ -0,0 +1,375 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/models"
+)
+
+// Content identity used to find duplicates
+const (
+	DedupeByETag   = "etag"   // Size and ETag; free, but multipart ETags only match for equal part sizes
+	DedupeBySample = "sample" // Size and a SHA-256 of the first and last dedupeSampleBytes
+)
+
+// dedupeSampleBytes is read from each end of an object when sampling
+const dedupeSampleBytes = 64 * 1024
+
+// maxDuplicateGroups caps the groups kept in a report; counts are always exact
+const maxDuplicateGroups = 10000
+
+// DefaultDedupeManifestKey is written under the destination prefix when no key is set
+const DefaultDedupeManifestKey = "dedupe-manifest.json"
+
+// DedupeSource is one bucket/prefix included in a duplicate analysis
+type DedupeSource struct {
+	Bucket string `json:"bucket"`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// DuplicateGroup is a set of objects with the same content
+type DuplicateGroup struct {
+	ContentID  string   `json:"content_id"`
+	Size       int64    `json:"size"`
+	Canonical  string   `json:"canonical"`  // Copy that is kept: the first key in lexical order
+	Duplicates []string `json:"duplicates"` // Every other copy
+}
+
+// DuplicateReport lists duplicate objects within and across source prefixes.
+// Keys are reported as "bucket/key".
+type DuplicateReport struct {
+	Sources          []DedupeSource   `json:"sources"`
+	Method           string           `json:"method"`
+	Objects          int64            `json:"objects"`
+	UniqueObjects    int64            `json:"unique_objects"`
+	DuplicateObjects int64            `json:"duplicate_objects"`
+	DuplicateBytes   int64            `json:"duplicate_bytes"` // Bytes saved by migrating unique content only
+	Groups           []DuplicateGroup `json:"groups"`          // Most wasted bytes first
+	Truncated        bool             `json:"truncated"`       // Groups capped at maxDuplicateGroups
+	CollectedAt      time.Time        `json:"collected_at"`
+	Duration         string           `json:"duration"`
+}
+
+// DedupePolicy makes a migration copy each distinct content once
+type DedupePolicy struct {
+	Method      string // DedupeByETag or DedupeBySample
+	ManifestKey string // Relative to the destination prefix
+}
+
+// DedupeEntry maps a duplicate source key to the canonical copy that was migrated
+type DedupeEntry struct {
+	Key       string `json:"key"`
+	Canonical string `json:"canonical"`
+	DestKey   string `json:"dest_key"` // Where the canonical content was written
+	Size      int64  `json:"size"`
+}
+
+// DedupeManifest is written to the destination after a deduplicated migration
+type DedupeManifest struct {
+	SourceBucket string        `json:"source_bucket"`
+	SourcePrefix string        `json:"source_prefix,omitempty"`
+	DestBucket   string        `json:"dest_bucket"`
+	DestPrefix   string        `json:"dest_prefix,omitempty"`
+	Method       string        `json:"method"`
+	GeneratedAt  time.Time     `json:"generated_at"`
+	Duplicates   []DedupeEntry `json:"duplicates"`
+}
+
+// DedupePolicyFor builds the deduplication policy of a migration request (nil without one)
+func DedupePolicyFor(opts *models.DedupeOptions) (*DedupePolicy, error) {
+	if opts == nil || !opts.Enabled {
+		return nil, nil
+	}
+	method, err := dedupeMethod(opts.Method)
+	if err != nil {
+		return nil, err
+	}
+	manifestKey := strings.Trim(opts.ManifestKey, "/")
+	if manifestKey == "" {
+		manifestKey = DefaultDedupeManifestKey
+	}
+	return &DedupePolicy{Method: method, ManifestKey: manifestKey}, nil
+}
+
+// dedupeMethod validates a content identity method; empty means DedupeByETag
+func dedupeMethod(method string) (string, error) {
+	switch method {
+	case "", DedupeByETag:
+		return DedupeByETag, nil
+	case DedupeBySample:
+		return DedupeBySample, nil
+	default:
+		return "", fmt.Errorf("unsupported dedupe method %q (expected etag or sample)", method)
+	}
+}
+
+// dedupeCandidate is one listed object considered for deduplication
+type dedupeCandidate struct {
+	Ref       string // Key, or "bucket/key" across buckets
+	Size      int64
+	ContentID string // Empty: cannot be matched
+}
+
+// etagContentID identifies content by size and ETag; empty objects and objects
+// without an ETag are never matched (folder markers are all empty)
+func etagContentID(etag string, size int64) string {
+	etag = strings.Trim(etag, `"`)
+	if etag == "" || size == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%s", size, strings.ToLower(etag))
+}
+
+// findDuplicateGroups groups candidates by content ID, ordered by wasted bytes
+func findDuplicateGroups(candidates []dedupeCandidate) []DuplicateGroup {
+	byContent := make(map[string][]dedupeCandidate)
+	for _, c := range candidates {
+		if c.ContentID != "" {
+			byContent[c.ContentID] = append(byContent[c.ContentID], c)
+		}
+	}
+
+	var groups []DuplicateGroup
+	for id, members := range byContent {
+		if len(members) < 2 {
+			continue
+		}
+		refs := make([]string, len(members))
+		for i, member := range members {
+			refs[i] = member.Ref
+		}
+		sort.Strings(refs)
+		groups = append(groups, DuplicateGroup{ContentID: id, Size: members[0].Size, Canonical: refs[0], Duplicates: refs[1:]})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		wi := groups[i].Size * int64(len(groups[i].Duplicates))
+		wj := groups[j].Size * int64(len(groups[j].Duplicates))
+		if wi != wj {
+			return wi > wj
+		}
+		return groups[i].Canonical < groups[j].Canonical
+	})
+	return groups
+}
+
+// sampleContentIDs replaces the content IDs of candidates that share a size with
+// another candidate by a hash of sampled ranges; unique sizes are never read
+func sampleContentIDs(ctx context.Context, client *s3.Client, candidates []dedupeCandidate, locate func(ref string) (bucket, key string)) error {
+	sizes := make(map[int64]int)
+	for _, c := range candidates {
+		sizes[c.Size]++
+	}
+	for i := range candidates {
+		c := &candidates[i]
+		if c.Size == 0 || sizes[c.Size] < 2 {
+			c.ContentID = ""
+			continue
+		}
+		bucket, key := locate(c.Ref)
+		sum, err := sampleHash(ctx, client, bucket, key, c.Size)
+		if err != nil {
+			return fmt.Errorf("failed to sample %s: %w", c.Ref, err)
+		}
+		c.ContentID = fmt.Sprintf("%d:sha256-sample:%s", c.Size, sum)
+	}
+	return nil
+}
+
+// sampleHash hashes the first and last dedupeSampleBytes of an object (all of it when small)
+func sampleHash(ctx context.Context, client *s3.Client, bucket, key string, size int64) (string, error) {
+	hash := sha256.New()
+	ranges := []string{fmt.Sprintf("bytes=0-%d", size-1)}
+	if size > 2*dedupeSampleBytes {
+		ranges = []string{
+			fmt.Sprintf("bytes=0-%d", dedupeSampleBytes-1),
+			fmt.Sprintf("bytes=%d-%d", size-dedupeSampleBytes, size-1),
+		}
+	}
+	for _, r := range ranges {
+		resp, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key), Range: aws.String(r)})
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(hash, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// FindDuplicates lists every source and reports objects with the same content
+func FindDuplicates(ctx context.Context, client *s3.Client, sources []DedupeSource, method string) (*DuplicateReport, error) {
+	method, err := dedupeMethod(method)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	report := &DuplicateReport{Sources: sources, Method: method, CollectedAt: start}
+
+	var candidates []dedupeCandidate
+	for _, source := range sources {
+		input := &s3.ListObjectsInput{Bucket: aws.String(source.Bucket), MaxKeys: aws.Int32(1000)}
+		if source.Prefix != "" {
+			input.Prefix = aws.String(source.Prefix)
+		}
+		for {
+			page, err := client.ListObjects(ctx, input)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s/%s: %w", source.Bucket, source.Prefix, err)
+			}
+			for _, obj := range page.Contents {
+				size := aws.ToInt64(obj.Size)
+				candidates = append(candidates, dedupeCandidate{
+					Ref:       source.Bucket + "/" + aws.ToString(obj.Key),
+					Size:      size,
+					ContentID: etagContentID(aws.ToString(obj.ETag), size),
+				})
+			}
+			input.Marker = nextMarker(page)
+			if input.Marker == nil {
+				break
+			}
+		}
+	}
+	// Overlapping sources list the same object twice; it is not its own duplicate
+	candidates = uniqueCandidates(candidates)
+
+	if method == DedupeBySample {
+		locate := func(ref string) (string, string) {
+			bucket, key, _ := strings.Cut(ref, "/")
+			return bucket, key
+		}
+		if err := sampleContentIDs(ctx, client, candidates, locate); err != nil {
+			return nil, err
+		}
+	}
+
+	groups := findDuplicateGroups(candidates)
+	report.Objects = int64(len(candidates))
+	for _, g := range groups {
+		report.DuplicateObjects += int64(len(g.Duplicates))
+		report.DuplicateBytes += g.Size * int64(len(g.Duplicates))
+	}
+	report.UniqueObjects = report.Objects - report.DuplicateObjects
+	if len(groups) > maxDuplicateGroups {
+		groups = groups[:maxDuplicateGroups]
+		report.Truncated = true
+	}
+	report.Groups = groups
+	report.Duration = time.Since(start).Round(time.Millisecond).String()
+	return report, nil
+}
+
+// uniqueCandidates drops repeated references, keeping the first
+func uniqueCandidates(candidates []dedupeCandidate) []dedupeCandidate {
+	seen := make(map[string]bool, len(candidates))
+	out := candidates[:0]
+	for _, c := range candidates {
+		if !seen[c.Ref] {
+			seen[c.Ref] = true
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// dedupeObjects splits a source listing into the objects to copy and the
+// duplicates left out, each mapped to the canonical key that is copied instead
+func (m *EnhancedMigrator) dedupeObjects(ctx context.Context, input MigrateInput, objects []objectInfo) ([]objectInfo, []DedupeEntry, error) {
+	candidates := make([]dedupeCandidate, len(objects))
+	for i, obj := range objects {
+		candidates[i] = dedupeCandidate{Ref: obj.Key, Size: obj.Size, ContentID: etagContentID(obj.ETag, obj.Size)}
+	}
+	if input.Dedupe.Method == DedupeBySample {
+		locate := func(ref string) (string, string) { return input.SourceBucket, ref }
+		if err := sampleContentIDs(ctx, m.connPool.GetClient(), candidates, locate); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	byKey := make(map[string]objectInfo, len(objects))
+	for _, obj := range objects {
+		byKey[obj.Key] = obj
+	}
+	skip := make(map[string]bool)
+	var entries []DedupeEntry
+	for _, g := range findDuplicateGroups(candidates) {
+		for _, key := range g.Duplicates {
+			skip[key] = true
+			entries = append(entries, DedupeEntry{
+				Key:       key,
+				Canonical: g.Canonical,
+				DestKey:   destKeyForObject(byKey[g.Canonical], input.DestPrefix, input.Partition, input.KeySanitize),
+				Size:      byKey[key].Size,
+			})
+		}
+	}
+	if len(entries) == 0 {
+		return objects, nil, nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+
+	unique := make([]objectInfo, 0, len(objects)-len(entries))
+	for _, obj := range objects {
+		if !skip[obj.Key] {
+			unique = append(unique, obj)
+		}
+	}
+	return unique, entries, nil
+}
+
+// dedupeBytes returns the bytes of duplicates left out
+func dedupeBytes(entries []DedupeEntry) int64 {
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+	return total
+}
+
+// writeDedupeManifest stores the duplicate-to-canonical mapping in the destination
+func (m *EnhancedMigrator) writeDedupeManifest(ctx context.Context, input MigrateInput, destClient *s3.Client, entries []DedupeEntry) (string, error) {
+	manifest := DedupeManifest{
+		SourceBucket: input.SourceBucket,
+		SourcePrefix: input.SourcePrefix,
+		DestBucket:   input.DestBucket,
+		DestPrefix:   input.DestPrefix,
+		Method:       input.Dedupe.Method,
+		GeneratedAt:  time.Now().UTC(),
+		Duplicates:   entries,
+	}
+	body, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	client := destClient
+	if client == nil {
+		client = m.connPool.GetClient()
+	}
+	key := destKeyFor(input.Dedupe.ManifestKey, input.DestPrefix)
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(input.DestBucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to write dedupe manifest %s: %w", key, err)
+	}
+	return key, nil
+}
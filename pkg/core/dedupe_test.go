@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"s3migration/pkg/models"
+)
+
+func TestFindDuplicateGroups(t *testing.T) {
+	candidates := []dedupeCandidate{
+		{Ref: "b/photos/2.jpg", Size: 100, ContentID: etagContentID(`"AAA"`, 100)},
+		{Ref: "a/photos/1.jpg", Size: 100, ContentID: etagContentID(`"aaa"`, 100)},
+		{Ref: "a/copy/1.jpg", Size: 100, ContentID: etagContentID(`"aaa"`, 100)},
+		{Ref: "a/big.iso", Size: 5000, ContentID: etagContentID(`"bbb-2"`, 5000)},
+		{Ref: "b/big.iso", Size: 5000, ContentID: etagContentID(`"bbb-2"`, 5000)},
+		{Ref: "a/other.iso", Size: 4000, ContentID: etagContentID(`"bbb-2"`, 4000)}, // Same ETag, other size
+		{Ref: "a/dir/", Size: 0, ContentID: etagContentID(`"d41d8cd98f00b204e9800998ecf8427e"`, 0)},
+		{Ref: "b/dir/", Size: 0, ContentID: etagContentID(`"d41d8cd98f00b204e9800998ecf8427e"`, 0)},
+	}
+
+	got := findDuplicateGroups(candidates)
+	want := []DuplicateGroup{
+		{ContentID: "5000:bbb-2", Size: 5000, Canonical: "a/big.iso", Duplicates: []string{"b/big.iso"}},
+		{ContentID: "100:aaa", Size: 100, Canonical: "a/copy/1.jpg", Duplicates: []string{"a/photos/1.jpg", "b/photos/2.jpg"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("findDuplicateGroups() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDedupeObjects(t *testing.T) {
+	objects := []objectInfo{
+		{Key: "a.txt", Size: 10, ETag: `"x"`},
+		{Key: "b.txt", Size: 10, ETag: `"x"`},
+		{Key: "c.txt", Size: 10, ETag: `"y"`},
+		{Key: "d.txt", Size: 20},
+	}
+	m := &EnhancedMigrator{}
+	input := MigrateInput{SourceBucket: "src", DestPrefix: "backup", Dedupe: &DedupePolicy{Method: DedupeByETag}}
+
+	unique, entries, err := m.dedupeObjects(context.Background(), input, objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unique) != 3 || unique[0].Key != "a.txt" || unique[1].Key != "c.txt" || unique[2].Key != "d.txt" {
+		t.Fatalf("unique objects = %+v", unique)
+	}
+	wantEntries := []DedupeEntry{{Key: "b.txt", Canonical: "a.txt", DestKey: "backup/a.txt", Size: 10}}
+	if !reflect.DeepEqual(entries, wantEntries) {
+		t.Fatalf("entries = %+v, want %+v", entries, wantEntries)
+	}
+}
+
+func TestDedupePolicyFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *models.DedupeOptions
+		want    *DedupePolicy
+		wantErr bool
+	}{
+		{name: "not set", opts: nil},
+		{name: "disabled", opts: &models.DedupeOptions{Method: "sample"}},
+		{name: "defaults", opts: &models.DedupeOptions{Enabled: true}, want: &DedupePolicy{Method: DedupeByETag, ManifestKey: DefaultDedupeManifestKey}},
+		{name: "sample", opts: &models.DedupeOptions{Enabled: true, Method: "sample", ManifestKey: "/reports/dupes.json"}, want: &DedupePolicy{Method: DedupeBySample, ManifestKey: "reports/dupes.json"}},
+		{name: "unknown method", opts: &models.DedupeOptions{Enabled: true, Method: "md5"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DedupePolicyFor(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DedupePolicyFor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("DedupePolicyFor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/logging"
+	"s3migration/pkg/models"
+)
+
+// Defaults of destination fetches
+const (
+	defaultDestFetchURLTTL  = time.Hour
+	defaultDestFetchTimeout = 5 * time.Minute
+)
+
+// errFetchUnsupported marks a fetch the service declined as unsupported; the
+// object is then streamed through the migration instead
+var errFetchUnsupported = errors.New("destination fetch not supported")
+
+// DestFetchPolicy lets the destination pull objects itself. Each object's source
+// is pre-signed and handed to a fetch service running next to the destination,
+// such as a worker bound to the bucket or an adapter for a provider's fetch API,
+// so the object's bytes do not pass through the migration.
+//
+// The service receives JSON POSTs. A probe, {"probe": true, "endpoint", "bucket"},
+// is answered with 200 and {"supported": bool, "reason": string}. A fetch,
+// {"source_url", "endpoint", "bucket", "key", "size"}, is answered with 200 and
+// {"size", "etag"} once the object is written, or with 501 when the service
+// cannot fetch into the destination after all.
+type DestFetchPolicy struct {
+	URL     string
+	Headers map[string]string
+	MinSize int64         // Smaller objects are streamed through the migration
+	URLTTL  time.Duration // Lifetime of the pre-signed source URLs
+	Timeout time.Duration // Per object
+}
+
+// DestFetchPolicyFor builds the destination fetch policy of a migration request (nil without one)
+func DestFetchPolicyFor(opts *models.DestFetchOptions) (*DestFetchPolicy, error) {
+	if opts == nil {
+		return nil, nil
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("dest_fetch url must be an http or https URL")
+	}
+	if opts.MinSizeMB < 0 || opts.URLTTLSeconds < 0 || opts.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("dest_fetch min_size_mb, url_ttl_seconds and timeout_seconds must not be negative")
+	}
+	policy := &DestFetchPolicy{
+		URL:     opts.URL,
+		Headers: opts.Headers,
+		MinSize: opts.MinSizeMB * 1024 * 1024,
+		URLTTL:  time.Duration(opts.URLTTLSeconds) * time.Second,
+		Timeout: time.Duration(opts.TimeoutSeconds) * time.Second,
+	}
+	if policy.URLTTL == 0 {
+		policy.URLTTL = defaultDestFetchURLTTL
+	}
+	if policy.URLTTL > 7*24*time.Hour {
+		return nil, fmt.Errorf("dest_fetch url_ttl_seconds must be at most 7 days, the longest a pre-signed URL is valid")
+	}
+	if policy.Timeout == 0 {
+		policy.Timeout = defaultDestFetchTimeout
+	}
+	return policy, nil
+}
+
+// destFetcher hands the objects of one Migrate call to the fetch service
+type destFetcher struct {
+	policy   *DestFetchPolicy
+	endpoint string // Destination endpoint ("" for AWS)
+	client   *http.Client
+	presign  *s3.PresignClient
+	disabled atomic.Bool  // Set once the service declines a fetch as unsupported
+	fetched  atomic.Int64 // Objects the destination pulled
+}
+
+func newDestFetcher(policy *DestFetchPolicy, endpoint string, sourceClient *s3.Client) *destFetcher {
+	return &destFetcher{
+		policy:   policy,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: policy.Timeout},
+		presign:  s3.NewPresignClient(sourceClient),
+	}
+}
+
+// accepts reports whether an object of size is handed to the destination
+func (f *destFetcher) accepts(size int64) bool {
+	return f != nil && !f.disabled.Load() && size >= f.policy.MinSize
+}
+
+// count returns the objects fetched by the destination
+func (f *destFetcher) count() int64 {
+	if f == nil {
+		return 0
+	}
+	return f.fetched.Load()
+}
+
+// destFetchReply is the answer of the fetch service to a probe or a fetch
+type destFetchReply struct {
+	Supported bool   `json:"supported"`
+	Reason    string `json:"reason"`
+	Size      int64  `json:"size"`
+	ETag      string `json:"etag"`
+}
+
+// call POSTs body to the fetch service and decodes its 200 reply
+func (f *destFetcher) call(ctx context.Context, body interface{}) (*destFetchReply, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.policy.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build fetch request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range f.policy.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("fetch service returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+		if resp.StatusCode == http.StatusNotImplemented {
+			return nil, fmt.Errorf("%w: %v", errFetchUnsupported, err)
+		}
+		return nil, err
+	}
+	var reply destFetchReply
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return nil, fmt.Errorf("invalid fetch service reply: %w", err)
+	}
+	return &reply, nil
+}
+
+// probe asks the fetch service whether it can write to the destination bucket;
+// the reason says why not
+func (f *destFetcher) probe(ctx context.Context, bucket string) (bool, string) {
+	reply, err := f.call(ctx, map[string]interface{}{"probe": true, "endpoint": f.endpoint, "bucket": bucket})
+	if err != nil {
+		return false, err.Error()
+	}
+	if !reply.Supported {
+		if reply.Reason == "" {
+			return false, "the fetch service does not support the destination"
+		}
+		return false, reply.Reason
+	}
+	return true, ""
+}
+
+// fetch has the destination pull one object from a pre-signed source URL. When
+// the service declines the fetch as unsupported, later objects are no longer
+// handed to it and errFetchUnsupported is returned.
+func (f *destFetcher) fetch(ctx context.Context, sourceBucket, sourceKey, destBucket, destKey string, size int64) (string, error) {
+	signed, err := f.presign.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(sourceBucket),
+		Key:    aws.String(sourceKey),
+	}, s3.WithPresignExpires(f.policy.URLTTL))
+	if err != nil {
+		return "", fmt.Errorf("failed to pre-sign source object: %w", err)
+	}
+	reply, err := f.call(ctx, map[string]interface{}{
+		"source_url": signed.URL,
+		"endpoint":   f.endpoint,
+		"bucket":     destBucket,
+		"key":        destKey,
+		"size":       size,
+	})
+	if errors.Is(err, errFetchUnsupported) {
+		f.disabled.Store(true)
+	}
+	if err != nil {
+		return "", err
+	}
+	if reply.Size != size {
+		return "", fmt.Errorf("destination fetched %d bytes of a %d byte object", reply.Size, size)
+	}
+	f.fetched.Add(1)
+	return reply.ETag, nil
+}
+
+// probeDestFetch returns the destination fetcher of a Migrate call when the
+// request has one and its service supports the destination. Objects written
+// within one endpoint are copied server-side already, so only a destination with
+// its own client is probed.
+func (m *EnhancedMigrator) probeDestFetch(ctx context.Context, input MigrateInput, destClient *s3.Client) *destFetcher {
+	if input.DestFetch == nil || destClient == nil || input.DryRun {
+		return nil
+	}
+	if input.NeverOverwrite != "" {
+		fmt.Println("Destination fetch not used (never_overwrite needs conditional writes the fetch service does not make); streaming objects through the migration")
+		return nil
+	}
+	fetcher := newDestFetcher(input.DestFetch, input.DestEndpointURL, m.GetClient())
+	if supported, reason := fetcher.probe(ctx, input.DestBucket); !supported {
+		fmt.Printf("Destination fetch not used (%s); streaming objects through the migration\n", reason)
+		return nil
+	}
+	fmt.Printf("Destination fetch enabled: %s pulls objects of at least %d bytes\n", input.DestFetch.URL, input.DestFetch.MinSize)
+	return fetcher
+}
+
+// fetchCopy has the destination pull an object. It reports false, with the
+// object left to be streamed, when the service turned out not to support the fetch.
+func (m *EnhancedMigrator) fetchCopy(ctx context.Context, log logging.ObjectLogger, destClient *s3.Client, sourceBucket, sourceKey, destBucket, destKey string, objectSize int64) (bool, error) {
+	etag, err := m.destFetch.fetch(ctx, sourceBucket, sourceKey, destBucket, destKey, objectSize)
+	if errors.Is(err, errFetchUnsupported) {
+		log.Infof("[DEST FETCH] %v; streaming objects through the migration from now on", err)
+		return false, nil
+	}
+	if err != nil {
+		return true, fmt.Errorf("destination fetch failed: %w", err)
+	}
+	return true, m.verifyWrite(ctx, destClient, destBucket, destKey, objectSize, writeChecksums{ETag: etag})
+}
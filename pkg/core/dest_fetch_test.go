@@ -0,0 +1,161 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+)
+
+// fetchService is a destination fetch service writing into a fake endpoint
+func fetchService(t *testing.T, dest *fakes3.Server, probe, fetch int) (*httptest.Server, *int) {
+	fetched := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Probe     bool   `json:"probe"`
+			SourceURL string `json:"source_url"`
+			Bucket    string `json:"bucket"`
+			Key       string `json:"key"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Probe {
+			w.WriteHeader(probe)
+			json.NewEncoder(w).Encode(map[string]interface{}{"supported": probe == http.StatusOK})
+			return
+		}
+		if fetch != http.StatusOK {
+			w.WriteHeader(fetch)
+			return
+		}
+		resp, err := http.Get(req.SourceURL)
+		if err != nil {
+			t.Errorf("fetching %s: %v", req.SourceURL, err)
+			return
+		}
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		dest.Put(req.Bucket, req.Key, data)
+		fetched++
+		json.NewEncoder(w).Encode(map[string]interface{}{"size": len(data), "etag": dest.Get(req.Bucket, req.Key).ETag})
+	}))
+	t.Cleanup(server.Close)
+	return server, &fetched
+}
+
+// signingClient is a client of a fake endpoint with credentials, which pre-signing needs
+func signingClient(endpoint *fakes3.Server) *s3.Client {
+	return s3.New(endpoint.Client().Options(), func(o *s3.Options) {
+		o.Credentials = credentials.NewStaticCredentialsProvider("source-key", "source-secret", "")
+	})
+}
+
+func TestMigrateWithDestFetch(t *testing.T) {
+	// The destination pool's HTTP client cannot take a CA bundle; the fake endpoints need none
+	t.Setenv("AWS_CA_BUNDLE", "")
+	source := fakes3.New("source")
+	defer source.Close()
+	dest := fakes3.New("dest")
+	defer dest.Close()
+	source.Put("source", "a.txt", []byte("alpha"))
+	source.Put("source", "b.txt", []byte("bravo!"))
+	service, calls := fetchService(t, dest, http.StatusOK, http.StatusOK)
+
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool: pool.NewStaticConnectionPool(signingClient(source)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, _ := DestFetchPolicyFor(&models.DestFetchOptions{URL: service.URL})
+	result, err := migrator.Migrate(context.Background(), MigrateInput{
+		SourceBucket:    "source",
+		DestBucket:      "dest",
+		DestAccessKey:   "dest-key",
+		DestSecretKey:   "dest-secret",
+		DestEndpointURL: dest.URL,
+		MigrationMode:   ModeFullRewrite,
+		DestFetch:       policy,
+		Timeout:         time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Copied != 2 || len(result.Errors) != 0 {
+		t.Fatalf("copied %d, errors %v", result.Copied, result.Errors)
+	}
+	if result.DestFetched != 2 || *calls != 2 {
+		t.Errorf("fetched %d by the service, %d reported", *calls, result.DestFetched)
+	}
+	if got := dest.Get("dest", "b.txt"); got == nil || string(got.Data) != "bravo!" {
+		t.Errorf("dest b.txt = %+v", got)
+	}
+}
+
+func TestDestFetcherFallsBack(t *testing.T) {
+	source := fakes3.New("source")
+	defer source.Close()
+	dest := fakes3.New("dest")
+	defer dest.Close()
+	source.Put("source", "a.txt", []byte("alpha"))
+	policy, _ := DestFetchPolicyFor(&models.DestFetchOptions{URL: "http://unused", MinSizeMB: 1})
+
+	// Declined by the probe: objects are streamed from the start
+	declined, _ := fetchService(t, dest, http.StatusNotFound, http.StatusOK)
+	policy.URL = declined.URL
+	if supported, reason := newDestFetcher(policy, dest.URL, signingClient(source)).probe(context.Background(), "dest"); supported || reason == "" {
+		t.Errorf("probe = %v, %q", supported, reason)
+	}
+
+	// Declined on a fetch: that object and later ones are streamed
+	unsupported, calls := fetchService(t, dest, http.StatusOK, http.StatusNotImplemented)
+	policy.URL = unsupported.URL
+	fetcher := newDestFetcher(policy, dest.URL, signingClient(source))
+	if supported, _ := fetcher.probe(context.Background(), "dest"); !supported || !fetcher.accepts(1<<20) || fetcher.accepts(1<<20-1) {
+		t.Fatalf("probe = %v, accepts = %v", supported, fetcher.accepts(1<<20))
+	}
+	if _, err := fetcher.fetch(context.Background(), "source", "a.txt", "dest", "a.txt", 5); !errors.Is(err, errFetchUnsupported) {
+		t.Errorf("fetch error = %v", err)
+	}
+	if fetcher.accepts(1<<20) || *calls != 0 || fetcher.count() != 0 {
+		t.Error("fetcher still used after the service declined a fetch")
+	}
+	var none *destFetcher
+	if none.accepts(0) || none.count() != 0 {
+		t.Error("nil fetcher accepts objects")
+	}
+}
+
+func TestDestFetchPolicyFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    models.DestFetchOptions
+		wantErr bool
+	}{
+		{name: "defaults", opts: models.DestFetchOptions{URL: "https://fetch.internal/pull"}},
+		{name: "no url", opts: models.DestFetchOptions{}, wantErr: true},
+		{name: "not http", opts: models.DestFetchOptions{URL: "ftp://fetch.internal"}, wantErr: true},
+		{name: "negative", opts: models.DestFetchOptions{URL: "https://fetch.internal", MinSizeMB: -1}, wantErr: true},
+		{name: "ttl too long", opts: models.DestFetchOptions{URL: "https://fetch.internal", URLTTLSeconds: 8 * 24 * 3600}, wantErr: true},
+	}
+	for _, tt := range tests {
+		policy, err := DestFetchPolicyFor(&tt.opts)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v", tt.name, err)
+			continue
+		}
+		if policy != nil && (policy.URLTTL != time.Hour || policy.Timeout != 5*time.Minute) {
+			t.Errorf("%s: policy = %+v", tt.name, policy)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"s3migration/pkg/integrity"
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+)
+
+// VerifyMode selects how closely a verification compares source and destination
+type VerifyMode string
+
+const (
+	VerifyCount VerifyMode = "count" // Object counts and total bytes
+	VerifySize  VerifyMode = "size"  // Key by key: presence, size, and source writes after the copy
+	VerifyETag  VerifyMode = "etag"  // As size, plus ETags where the provider pair keeps them on copy
+)
+
+// maxDriftSamples caps the differences a drift report lists; counts are always exact
+const maxDriftSamples = 100
+
+// ValidateVerifyMode checks a verification mode; empty means VerifySize
+func ValidateVerifyMode(mode VerifyMode) error {
+	switch mode {
+	case "", VerifyCount, VerifySize, VerifyETag:
+		return nil
+	default:
+		return fmt.Errorf("unsupported verify mode %q (expected count, size or etag)", mode)
+	}
+}
+
+// Verify compares the source with the destination without copying anything.
+// Both listings are walked page by page in key order, so memory does not grow
+// with the bucket sizes. Destination objects are looked for under the image of
+// source_prefix in dest_prefix, as a migration of the same input writes them.
+func (m *EnhancedMigrator) Verify(ctx context.Context, input MigrateInput, mode VerifyMode) (*models.DriftReport, error) {
+	if mode == "" {
+		mode = VerifySize
+	}
+	ctx = pool.CountRequests(ctx, &m.requests)
+	destClient := m.metadataClient()
+	destEndpoint := m.config.EndpointURL
+	destPool, err := m.destinationPool(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if destPool != nil {
+		destClient = destPool.GetClient()
+		destEndpoint = input.DestEndpointURL
+	}
+	compatibility := integrity.CompatibilityOf(integrity.DetectProvider(m.config.EndpointURL), integrity.DetectProvider(destEndpoint))
+
+	m.live.setPhase("listing")
+	report := &models.DriftReport{Mode: string(mode), CheckedAt: time.Now()}
+	source := &listingCursor{next: m.newObjectPager(m.metadataClient(), input.SourceBucket, input.SourcePrefix).next}
+	dest := &listingCursor{next: m.newObjectPager(destClient, input.DestBucket, destKeyFor(input.SourcePrefix, input.DestPrefix)).next}
+	if input.VerifyListing != nil {
+		// The destination as a completed task left it, instead of as it is now
+		next, listing, err := taskListingPages(input.VerifyListing, destEndpoint, input.DestBucket, destKeyFor(input.SourcePrefix, input.DestPrefix))
+		if err != nil {
+			return nil, err
+		}
+		dest.next = next
+		report.DestListedAt = &listing.ListedAt
+	}
+	drift := func(key, issue, detail string) {
+		if len(report.Samples) < maxDriftSamples {
+			report.Samples = append(report.Samples, models.DriftEntry{Key: key, Issue: issue, Detail: detail})
+		}
+	}
+
+	for {
+		sourceObj, err := source.peek(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list source: %w", err)
+		}
+		destObj, err := dest.peek(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list destination: %w", err)
+		}
+		if sourceObj == nil && destObj == nil {
+			break
+		}
+
+		var destKey string
+		if sourceObj != nil {
+			destKey = destKeyFor(sourceObj.Key, input.DestPrefix)
+		}
+		switch {
+		case destObj == nil || (sourceObj != nil && destKey < destObj.Key):
+			report.SourceObjects++
+			report.SourceBytes += sourceObj.Size
+			report.Missing++
+			drift(sourceObj.Key, "missing", "")
+			source.advance()
+		case sourceObj == nil || destObj.Key < destKey:
+			report.DestObjects++
+			report.DestBytes += destObj.Size
+			report.Extra++
+			drift(destObj.Key, "extra", "")
+			dest.advance()
+		default:
+			report.SourceObjects++
+			report.SourceBytes += sourceObj.Size
+			report.DestObjects++
+			report.DestBytes += destObj.Size
+			if detail := objectDrift(*sourceObj, *destObj, mode, compatibility); detail != "" {
+				report.Changed++
+				drift(sourceObj.Key, "changed", detail)
+			}
+			source.advance()
+			dest.advance()
+		}
+	}
+
+	if mode == VerifyCount {
+		// Only the totals are compared; keys are not matched up
+		report.Missing, report.Changed, report.Extra, report.Samples = 0, 0, 0, nil
+		report.Drifted = report.SourceObjects != report.DestObjects || report.SourceBytes != report.DestBytes
+	} else {
+		report.Drifted = report.Missing+report.Changed+report.Extra > 0
+	}
+	fmt.Printf("Verification (%s): %d source and %d destination objects; %d missing, %d changed, %d extra\n",
+		mode, report.SourceObjects, report.DestObjects, report.Missing, report.Changed, report.Extra)
+	return report, nil
+}
+
+// objectDrift describes how a destination object differs from its source object
+// under mode (empty when it matches)
+func objectDrift(source, dest objectInfo, mode VerifyMode, compatibility integrity.Compatibility) string {
+	switch {
+	case mode == VerifyCount:
+		return ""
+	case source.Size != dest.Size:
+		return fmt.Sprintf("size %d at the source, %d at the destination", source.Size, dest.Size)
+	case source.LastModified.After(dest.LastModified):
+		return fmt.Sprintf("modified at the source at %s, after the copy", formatTime(source.LastModified))
+	case mode == VerifyETag && compatibility.ComparisonFor(source.ETag, dest.ETag) == integrity.CompareETag &&
+		integrity.CleanETag(source.ETag) != integrity.CleanETag(dest.ETag):
+		return "ETag differs"
+	}
+	return ""
+}
+
+// listingCursor steps through a paged listing one object at a time, checking
+// that keys come in order; next returns nil at the end of the listing
+type listingCursor struct {
+	next func(ctx context.Context) ([]objectInfo, error)
+	page []objectInfo
+	last string
+}
+
+// peek returns the current object, or nil at the end of the listing
+func (c *listingCursor) peek(ctx context.Context) (*objectInfo, error) {
+	if len(c.page) == 0 {
+		page, err := c.next(ctx)
+		if err != nil || page == nil {
+			return nil, err
+		}
+		c.page = page
+	}
+	if c.page[0].Key < c.last {
+		return nil, errUnsortedListing
+	}
+	c.last = c.page[0].Key
+	return &c.page[0], nil
+}
+
+// advance moves past the current object
+func (c *listingCursor) advance() {
+	c.page = c.page[1:]
+}
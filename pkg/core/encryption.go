@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// BucketEncryption is the default encryption a bucket applies to new objects
+type BucketEncryption struct {
+	Algorithm string // "AES256", "aws:kms" or "aws:kms:dsse"
+	KMSKeyID  string // Key of SSE-KMS; empty for the AWS managed key aws/s3
+}
+
+// UsesKMS reports whether new objects are encrypted with a KMS key
+func (e *BucketEncryption) UsesKMS() bool {
+	return e != nil && strings.HasPrefix(e.Algorithm, string(types.ServerSideEncryptionAwsKms))
+}
+
+func (e *BucketEncryption) keyName() string {
+	if e.KMSKeyID == "" {
+		return "aws/s3 (AWS managed)"
+	}
+	return e.KMSKeyID
+}
+
+// GetBucketEncryption returns the default encryption of a bucket; nil when it has
+// none or the provider does not support the setting
+func (bv *BucketValidator) GetBucketEncryption(ctx context.Context, bucketName string) (*BucketEncryption, error) {
+	output, err := bv.client.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{Bucket: aws.String(bucketName)})
+	switch status := configStatus(err, "ServerSideEncryptionConfigurationNotFoundError"); status.Status {
+	case ConfigAbsent, ConfigUnsupported:
+		return nil, nil
+	case ConfigError:
+		return nil, fmt.Errorf("failed to read encryption of bucket '%s': %s", bucketName, status.Error)
+	}
+	if output.ServerSideEncryptionConfiguration == nil {
+		return nil, nil
+	}
+	for _, rule := range output.ServerSideEncryptionConfiguration.Rules {
+		if def := rule.ApplyServerSideEncryptionByDefault; def != nil {
+			return &BucketEncryption{Algorithm: string(def.SSEAlgorithm), KMSKeyID: aws.ToString(def.KMSMasterKeyID)}, nil
+		}
+	}
+	return nil, nil
+}
+
+// CheckKMSAccess fails when bucketName encrypts new objects with a KMS key the
+// credentials cannot use. Such a bucket accepts the connection and listing but
+// rejects every write, so a probe object is written (without encryption headers,
+// like the copies) under prefix before the migration starts. Returns the bucket's
+// encryption; an unreadable configuration is reported and not checked.
+func (bv *BucketValidator) CheckKMSAccess(ctx context.Context, bucketName, prefix string) (*BucketEncryption, error) {
+	encryption, err := bv.GetBucketEncryption(ctx, bucketName)
+	if err != nil {
+		fmt.Printf("⚠️  %v; not checking KMS key access\n", err)
+		return nil, nil
+	}
+	if !encryption.UsesKMS() {
+		return encryption, nil
+	}
+
+	if err := bv.ValidateWriteAccess(ctx, bucketName, prefix); err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "kms") {
+			return encryption, fmt.Errorf("destination bucket '%s' encrypts new objects with SSE-KMS key %s, which the destination credentials cannot use; grant kms:GenerateDataKey (and kms:Decrypt for multipart uploads) on the key: %w",
+				bucketName, encryption.keyName(), err)
+		}
+		return encryption, err
+	}
+	return encryption, nil
+}
+
+// checkDestinationEncryption runs CheckKMSAccess against the destination of a migration
+func (m *EnhancedMigrator) checkDestinationEncryption(ctx context.Context, input MigrateInput, destClient *s3.Client) error {
+	client := destClient
+	if client == nil {
+		client = m.metadataClient()
+	}
+	encryption, err := NewBucketValidator(client).CheckKMSAccess(ctx, input.DestBucket, input.DestPrefix)
+	if err != nil {
+		return err
+	}
+	if encryption.UsesKMS() {
+		fmt.Printf("Destination encrypts with SSE-KMS key %s: write access verified\n", encryption.keyName())
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/pool"
+)
+
+const testKMSEncryption = `<ServerSideEncryptionConfiguration><Rule><ApplyServerSideEncryptionByDefault>` +
+	`<SSEAlgorithm>aws:kms</SSEAlgorithm><KMSMasterKeyID>arn:aws:kms:eu-west-1:111122223333:key/dest-key</KMSMasterKeyID>` +
+	`</ApplyServerSideEncryptionByDefault></Rule></ServerSideEncryptionConfiguration>`
+
+// kmsDenied rejects object writes the way S3 does when the caller may not use the bucket's key
+type kmsDenied struct {
+	next http.RoundTripper
+	puts int
+}
+
+func (d *kmsDenied) Do(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPut || !strings.Contains(strings.Trim(req.URL.Path, "/"), "/") {
+		return d.next.RoundTrip(req)
+	}
+	d.puts++
+	body := `<Error><Code>AccessDenied</Code><Message>User is not authorized to perform: kms:GenerateDataKey on resource: dest-key</Message></Error>`
+	return &http.Response{
+		StatusCode: http.StatusForbidden,
+		Header:     http.Header{"Content-Type": []string{"application/xml"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestMigrateFailsFastWithoutKMSKeyAccess(t *testing.T) {
+	endpoint := fakes3.New("src", "dest")
+	defer endpoint.Close()
+	endpoint.SetBucketConfig("dest", "encryption", []byte(testKMSEncryption))
+	for _, key := range []string{"a", "b", "c"} {
+		endpoint.Put("src", key, []byte("content of "+key))
+	}
+
+	denied := &kmsDenied{next: http.DefaultTransport}
+	client := s3.New(endpoint.Client().Options(), func(o *s3.Options) {
+		o.HTTPClient = denied
+		o.RetryMaxAttempts = 1
+	})
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool: pool.NewStaticConnectionPool(client),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Migrate(context.Background(), MigrateInput{
+		SourceBucket:  "src",
+		DestBucket:    "dest",
+		MigrationMode: ModeFullRewrite,
+		Timeout:       time.Minute,
+	})
+	if err == nil || !strings.Contains(err.Error(), "key/dest-key") || !strings.Contains(err.Error(), "kms:GenerateDataKey") {
+		t.Fatalf("error = %v, want the KMS key named", err)
+	}
+	if denied.puts != 1 {
+		t.Fatalf("%d writes attempted, want only the probe", denied.puts)
+	}
+}
+
+func TestCheckKMSAccess(t *testing.T) {
+	endpoint := fakes3.New("plain", "kms")
+	defer endpoint.Close()
+	endpoint.SetBucketConfig("kms", "encryption", []byte(testKMSEncryption))
+	validator := NewBucketValidator(endpoint.Client())
+
+	encryption, err := validator.CheckKMSAccess(context.Background(), "plain", "")
+	if err != nil || encryption != nil {
+		t.Fatalf("unencrypted bucket: %+v, %v", encryption, err)
+	}
+
+	encryption, err = validator.CheckKMSAccess(context.Background(), "kms", "backup")
+	if err != nil || !encryption.UsesKMS() || !strings.HasSuffix(encryption.KMSKeyID, "key/dest-key") {
+		t.Fatalf("KMS bucket: %+v, %v", encryption, err)
+	}
+	if keys := endpoint.Keys("kms"); len(keys) != 0 {
+		t.Fatalf("probe left behind: %v", keys)
+	}
+}
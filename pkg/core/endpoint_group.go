@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EndpointGroup is a request budget shared by every task writing to one
+// destination endpoint: at most MaxConcurrent object copies at once and, when
+// set, at most RateLimitRPS copies started per second
+type EndpointGroup struct {
+	key      string
+	slots    chan struct{}
+	active   atomic.Int32
+	waiting  atomic.Int32
+	tasks    atomic.Int32
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// EndpointGroupStats is a snapshot of an endpoint group
+type EndpointGroupStats struct {
+	Endpoint      string  `json:"endpoint"`
+	Tasks         int     `json:"tasks"`   // Running tasks sharing the budget
+	Active        int     `json:"active"`  // Object copies holding a slot
+	Waiting       int     `json:"waiting"` // Copies queued for a slot
+	MaxConcurrent int     `json:"max_concurrent"`
+	RateLimitRPS  float64 `json:"rate_limit_rps"` // 0 = unlimited
+}
+
+// NewEndpointGroup creates the budget of one endpoint
+func NewEndpointGroup(key string, maxConcurrent int, rps float64) *EndpointGroup {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	g := &EndpointGroup{key: key, slots: make(chan struct{}, maxConcurrent)}
+	if rps > 0 {
+		g.interval = time.Duration(float64(time.Second) / rps)
+	}
+	return g
+}
+
+// EndpointGroupKey names the group of an endpoint URL; no endpoint means the AWS
+// regional endpoint. Scheme, path and case are ignored.
+func EndpointGroupKey(endpointURL, region string) string {
+	if endpointURL == "" {
+		if region == "" {
+			region = "us-east-1"
+		}
+		return "s3." + region + ".amazonaws.com"
+	}
+	host := endpointURL
+	if u, err := url.Parse(endpointURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "/"))
+}
+
+// Join counts a task as using the group until the returned func is called
+func (g *EndpointGroup) Join() func() {
+	g.tasks.Add(1)
+	var once sync.Once
+	return func() { once.Do(func() { g.tasks.Add(-1) }) }
+}
+
+// Acquire waits for a slot and the rate limit; fails only when ctx is done
+func (g *EndpointGroup) Acquire(ctx context.Context) error {
+	g.waiting.Add(1)
+	select {
+	case g.slots <- struct{}{}:
+		g.waiting.Add(-1)
+	case <-ctx.Done():
+		g.waiting.Add(-1)
+		return ctx.Err()
+	}
+	g.active.Add(1)
+
+	if err := g.throttle(ctx); err != nil {
+		g.Release()
+		return err
+	}
+	return nil
+}
+
+// Release frees a slot taken by Acquire
+func (g *EndpointGroup) Release() {
+	g.active.Add(-1)
+	<-g.slots
+}
+
+// throttle spaces copy starts across all tasks of the group
+func (g *EndpointGroup) throttle(ctx context.Context) error {
+	g.mu.Lock()
+	if g.interval <= 0 {
+		g.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	if g.next.Before(now) {
+		g.next = now
+	}
+	wait := g.next.Sub(now)
+	g.next = g.next.Add(g.interval)
+	g.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Stats returns a snapshot of the group
+func (g *EndpointGroup) Stats() EndpointGroupStats {
+	g.mu.Lock()
+	interval := g.interval
+	g.mu.Unlock()
+
+	stats := EndpointGroupStats{
+		Endpoint:      g.key,
+		Tasks:         int(g.tasks.Load()),
+		Active:        int(g.active.Load()),
+		Waiting:       int(g.waiting.Load()),
+		MaxConcurrent: cap(g.slots),
+	}
+	if interval > 0 {
+		stats.RateLimitRPS = float64(time.Second) / float64(interval)
+	}
+	return stats
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEndpointGroupSharesSlots(t *testing.T) {
+	g := NewEndpointGroup("minio.local:9000", 2, 0)
+	leaveA, leaveB := g.Join(), g.Join()
+	ctx := context.Background()
+
+	// Two tasks take both slots; a third copy must wait until one is released
+	if err := g.Acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	acquired := make(chan error, 1)
+	go func() { acquired <- g.Acquire(ctx) }()
+
+	deadline := time.Now().Add(time.Second)
+	for g.Stats().Waiting != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if stats := g.Stats(); stats.Active != 2 || stats.Waiting != 1 || stats.Tasks != 2 {
+		t.Fatalf("unexpected stats while full: %+v", stats)
+	}
+
+	g.Release()
+	if err := <-acquired; err != nil {
+		t.Fatal(err)
+	}
+	g.Release()
+	g.Release()
+
+	leaveA()
+	leaveA() // Leaving twice counts once
+	leaveB()
+	if stats := g.Stats(); stats.Active != 0 || stats.Waiting != 0 || stats.Tasks != 0 {
+		t.Fatalf("budget not fully released: %+v", stats)
+	}
+}
+
+func TestEndpointGroupAcquireCancelled(t *testing.T) {
+	g := NewEndpointGroup("s3.us-east-1.amazonaws.com", 1, 0)
+	if err := g.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := g.Acquire(ctx); err == nil {
+		t.Fatal("Acquire on a full group should fail once ctx is done")
+	}
+	if stats := g.Stats(); stats.Active != 1 || stats.Waiting != 0 {
+		t.Fatalf("cancelled waiter left state behind: %+v", stats)
+	}
+}
+
+func TestEndpointGroupRateLimit(t *testing.T) {
+	g := NewEndpointGroup("r2", 10, 50) // One start every 20ms
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := g.Acquire(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		g.Release()
+	}
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Fatalf("4 starts at 50 rps took %v, want at least 60ms", elapsed)
+	}
+	if rps := g.Stats().RateLimitRPS; rps < 49.9 || rps > 50.1 {
+		t.Fatalf("RateLimitRPS = %v, want 50", rps)
+	}
+}
+
+func TestEndpointGroupKey(t *testing.T) {
+	tests := []struct {
+		endpoint, region, want string
+	}{
+		{"", "", "s3.us-east-1.amazonaws.com"},
+		{"", "eu-west-1", "s3.eu-west-1.amazonaws.com"},
+		{"https://MinIO.local:9000/", "", "minio.local:9000"},
+		{"http://minio.local:9000/bucket", "us-east-1", "minio.local:9000"},
+	}
+	for _, tt := range tests {
+		if got := EndpointGroupKey(tt.endpoint, tt.region); got != tt.want {
+			t.Errorf("EndpointGroupKey(%q, %q) = %q, want %q", tt.endpoint, tt.region, got, tt.want)
+		}
+	}
+}
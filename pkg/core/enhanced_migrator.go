@@ -2,9 +2,9 @@ package core
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/url"
 	"os"
 	"os/signal"
@@ -15,26 +15,54 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 
+	"s3migration/pkg/buckets"
+	"s3migration/pkg/config"
 	"s3migration/pkg/integrity"
+	"s3migration/pkg/logging"
+	"s3migration/pkg/models"
 	"s3migration/pkg/pool"
 	"s3migration/pkg/prefetch"
 	"s3migration/pkg/progress"
+	"s3migration/pkg/scan"
 	"s3migration/pkg/state"
 	"s3migration/pkg/streaming"
+	pkgSync "s3migration/pkg/sync"
+	"s3migration/pkg/transform"
 	"s3migration/pkg/tuning"
 )
 
 // EnhancedMigrator is a high-performance migrator with all optimizations
 type EnhancedMigrator struct {
-	connPool         *pool.ConnectionPool
+	connPool         *pool.ConnectionPool // Source clients for data transfer
+	metadataPool     *pool.ConnectionPool // Source clients for listing and HEAD requests
+	metadataSlots    chan struct{}        // Bounds the HEAD requests in flight
 	tuner            *tuning.Tuner
 	prefetcher       *prefetch.MetadataCache
 	streamer         *streaming.Streamer
 	progress         *progress.Tracker
 	integrityManager *state.IntegrityManager
+	logger           *logging.Logger
+	live             *liveControl
+	inflight         *inFlightTracker
+	multipart        config.MultipartSettings      // Settings of the current Migrate call
+	verifyWrites     bool                          // HEAD each written object (verify_write) in the current Migrate call
+	transform        *transform.Policy             // Transformation hook of the current Migrate call
+	scan             *scan.Policy                  // Content scanner of the current Migrate call
+	scanFindings     scanFindings                  // Objects the scan withheld in the current Migrate call
+	acl              *aclMapper                    // ACL mapping of the current Migrate call (nil without one)
+	labeler          *labeler                      // Labels of the current Migrate call (nil without any)
+	prefixLimiter    *prefixLimiter                // Copies in flight per key prefix in the current Migrate call
+	destFetch        *destFetcher                  // Destination fetch service of the current Migrate call (nil = not used)
+	syncStates       *syncStates                   // Sync state of the current Migrate call's conditional GETs (nil = not used)
+	sourceWebsite    bool                          // Source bucket of the current Migrate call hosts a static website
+	destProvider     integrity.ProviderType        // Destination provider of the current Migrate call
+	journal          *state.IntegrityJournal       // Integrity records of the current Migrate call (nil without an integrity manager)
+	requests         pool.RequestCounter           // S3 requests of every Migrate and Verify call
+	endpointGroup    atomic.Pointer[EndpointGroup] // Destination request budget of the current Migrate call
 	config           EnhancedMigratorConfig
 	stopRequested    atomic.Bool
 }
@@ -50,28 +78,66 @@ type EnhancedMigratorConfig struct {
 	StreamChunkSize    int64
 	CacheTTL           time.Duration
 	CacheSize          int
-	AccessKey          string
-	SecretKey          string
-	TaskID             string
-	IntegrityManager   *state.IntegrityManager
+	// Destination listings may be reused between comparisons for this long (0 = never reused)
+	ListingCacheTTL time.Duration
+	// Persistent listing layer shared between runs and replicas (nil = in-memory only)
+	ListingStore prefetch.ListingStore
+	AccessKey    string
+	SecretKey    string
+	// Dynamic source credentials (secret reference); used instead of AccessKey/SecretKey
+	CredentialsProvider aws.CredentialsProvider
+	TaskID              string
+	IntegrityManager    *state.IntegrityManager
+	Logger              *logging.Logger // Per-task verbosity (nil = global logger)
+	// Prebuilt source clients (e.g. pool.NewStaticConnectionPool over a fake endpoint);
+	// replaces the pool built from the connection fields above
+	ConnectionPool *pool.ConnectionPool
+	// Listing and HEAD requests use separate source clients and concurrency
+	MetadataPoolSize    int // Clients for listing and HEAD (0 = a quarter of ConnectionPoolSize, at least 2)
+	MetadataConcurrency int // HEAD requests in flight at once (0 = 32)
+	// Prebuilt metadata clients; defaults to ConnectionPool when that is set
+	MetadataConnectionPool *pool.ConnectionPool
 }
 
 // NewEnhancedMigrator creates a new enhanced migrator with all optimizations
 func NewEnhancedMigrator(ctx context.Context, config EnhancedMigratorConfig) (*EnhancedMigrator, error) {
 	// Create connection pool
 	connPoolCfg := pool.ConnectionPoolConfig{
-		Size:        config.ConnectionPoolSize,
-		Region:      config.Region,
-		EndpointURL: config.EndpointURL,
-		MaxRetries:  3,
-		Timeout:     30 * time.Second,
-		AccessKey:   config.AccessKey,
-		SecretKey:   config.SecretKey,
+		Size:                config.ConnectionPoolSize,
+		Region:              config.Region,
+		EndpointURL:         config.EndpointURL,
+		MaxRetries:          3,
+		Timeout:             30 * time.Second,
+		AccessKey:           config.AccessKey,
+		SecretKey:           config.SecretKey,
+		CredentialsProvider: config.CredentialsProvider,
 	}
 
-	connPool, err := pool.NewConnectionPool(ctx, connPoolCfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create connection pool: %w", err)
+	connPool := config.ConnectionPool
+	if connPool == nil {
+		var err error
+		connPool, err = pool.NewConnectionPool(ctx, connPoolCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create connection pool: %w", err)
+		}
+	}
+
+	metadataPool := config.MetadataConnectionPool
+	if metadataPool == nil && config.ConnectionPool != nil {
+		metadataPool = config.ConnectionPool
+	}
+	if metadataPool == nil {
+		metadataPoolCfg := connPoolCfg
+		metadataPoolCfg.Size = metadataPoolSize(config)
+		var err error
+		metadataPool, err = pool.NewConnectionPool(ctx, metadataPoolCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create metadata connection pool: %w", err)
+		}
+	}
+	metadataConcurrency := config.MetadataConcurrency
+	if metadataConcurrency <= 0 {
+		metadataConcurrency = defaultMetadataConcurrency
 	}
 
 	// Create tuner
@@ -81,6 +147,10 @@ func NewEnhancedMigrator(ctx context.Context, config EnhancedMigratorConfig) (*E
 	var prefetcher *prefetch.MetadataCache
 	if config.EnablePrefetch {
 		prefetcher = prefetch.NewMetadataCache(config.CacheTTL, config.CacheSize)
+		prefetcher.SetListingTTL(config.ListingCacheTTL)
+		if config.ListingStore != nil {
+			prefetcher.SetStore(config.ListingStore)
+		}
 	}
 
 	// Create streamer if enabled
@@ -94,13 +164,23 @@ func NewEnhancedMigrator(ctx context.Context, config EnhancedMigratorConfig) (*E
 	// Create progress tracker (will be initialized later with actual values)
 	var progressTracker *progress.Tracker
 
+	logger := config.Logger
+	if logger == nil {
+		logger = logging.Default()
+	}
+
 	return &EnhancedMigrator{
 		connPool:         connPool,
+		metadataPool:     metadataPool,
+		metadataSlots:    make(chan struct{}, metadataConcurrency),
 		tuner:            tuner,
 		prefetcher:       prefetcher,
 		streamer:         streamer,
 		progress:         progressTracker,
 		integrityManager: config.IntegrityManager,
+		logger:           logger,
+		live:             newLiveControl(),
+		inflight:         newInFlightTracker(),
 		config:           config,
 	}, nil
 }
@@ -114,6 +194,7 @@ func (m *EnhancedMigrator) Migrate(ctx context.Context, input MigrateInput) (*Mi
 	// Create cancelable context
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	ctx = pool.CountRequests(ctx, &m.requests)
 
 	// Handle shutdown signals
 	go func() {
@@ -126,52 +207,118 @@ func (m *EnhancedMigrator) Migrate(ctx context.Context, input MigrateInput) (*Mi
 	// Start progress tracking
 	startTime := time.Now()
 
+	// Integrity records are stored in batches while the workers copy
+	if m.config.EnableIntegrity && m.integrityManager != nil {
+		m.journal = m.integrityManager.NewJournal(state.IntegrityJournalConfig{})
+		defer m.closeJournal()
+	}
+
+	// Sample resource usage for the task window (reported in the result)
+	resourceTracker := m.tuner.GetMemoryManager().TrackTask(5 * time.Second)
+	defer resourceTracker.Stop()
+
 	// Create destination client if different credentials provided
-	var destClient *s3.Client
-	if input.DestAccessKey != "" && input.DestSecretKey != "" {
-		fmt.Println("Creating separate S3 client for destination (cross-account copy)")
-		destConnPool, err := pool.NewConnectionPool(ctx, pool.ConnectionPoolConfig{
-			Size:        m.config.ConnectionPoolSize * 2, // OPTIMIZATION: Double pool size for destination
-			Region:      input.DestRegion,
-			EndpointURL: input.DestEndpointURL,
-			MaxRetries:  5,                    // OPTIMIZATION: Increase retries for reliability
-			Timeout:     15 * time.Second,     // OPTIMIZATION: Reduce timeout for faster failure detection
-			AccessKey:   input.DestAccessKey,
-			SecretKey:   input.DestSecretKey,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("failed to create destination connection pool: %w", err)
-		}
+	var destClient, destListClient *s3.Client
+	destConnPool, err := m.destinationPool(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if destConnPool != nil {
 		destClient = destConnPool.GetClient()
+		destListClient = destConnPool.GetClient() // Another client of the pool: listings do not share the copies' connections
 		fmt.Printf("Destination client created for endpoint: %s\n", input.DestEndpointURL)
 	}
 
+	reportPhase := func(phase string) {
+		if input.Reporter != nil {
+			input.Reporter.Phase(phase)
+		}
+	}
+
 	// List objects from source
-	objects, err := m.listObjectsWithCache(ctx, input.SourceBucket, input.SourcePrefix)
+	m.live.setPhase("listing")
+	reportPhase(models.PhaseDiscovering)
+	objects, err := m.listSource(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list objects: %w", err)
 	}
 
 	fmt.Printf("Found %d objects in source bucket\n", len(objects))
-	
+	if input.FilesFrom != nil {
+		objects = filterFilesFrom(objects, input.FilesFrom, input.SourcePrefix)
+		fmt.Printf("Files from list: %d listed objects match its %d entries\n", len(objects), len(input.FilesFrom))
+	}
+
+	// Folder markers are written directly (also when nothing is under them) or left out
+	var folderMarkers []objectInfo
+	if input.FolderMarkers == FolderMarkersRecreate || input.FolderMarkers == FolderMarkersSkip {
+		objects, folderMarkers = splitFolderMarkers(objects)
+		fmt.Printf("Folder markers: %d found (%s)\n", len(folderMarkers), input.FolderMarkers)
+		if input.FolderMarkers == FolderMarkersSkip {
+			folderMarkers = nil
+		}
+	}
+
+	// Deduplicate before sizing, so progress and verification only cover the content copied
+	var duplicates []DedupeEntry
+	if input.Dedupe != nil {
+		objects, duplicates, err = m.dedupeObjects(ctx, input, objects)
+		if err != nil {
+			return nil, fmt.Errorf("failed to deduplicate objects: %w", err)
+		}
+		fmt.Printf("Deduplication (%s): %d duplicate objects (%.1f MB) left out\n",
+			input.Dedupe.Method, len(duplicates), float64(dedupeBytes(duplicates))/1024/1024)
+	}
+	var lifecycleExcluded int64
+	if input.ExcludeLifecycleExpired {
+		objects, lifecycleExcluded = m.excludeLifecycleExpired(ctx, input, objects, destListClient)
+	}
+
 	// Calculate total size for progress tracker
 	var totalSize int64
 	for _, obj := range objects {
 		totalSize += obj.Size
 	}
-	
+
 	// Initialize progress tracker with actual values
 	if m.progress == nil {
 		m.progress = progress.NewTracker(int64(len(objects)), totalSize)
 	}
-	
+
 	// Ensure destination bucket exists (only for actual runs, not dry runs)
-	if !input.DryRun && len(objects) > 0 {
-		if err := m.ensureDestinationBucketExists(ctx, input.DestBucket, input.DestRegion, destClient); err != nil {
+	if !input.DryRun && len(objects)+len(folderMarkers) > 0 {
+		if err := m.ensureDestinationBucketExists(ctx, input.DestBucket, input.DestRegion, destListClient); err != nil {
 			return nil, fmt.Errorf("failed to create destination bucket: %w", err)
 		}
+		// A KMS key the credentials cannot use would fail every write; fail before the first
+		if err := m.checkDestinationEncryption(ctx, input, destListClient); err != nil {
+			return nil, err
+		}
+		if input.NeverOverwrite != "" {
+			if err := m.probeWriteOnce(ctx, input, destClient); err != nil {
+				return nil, err
+			}
+		}
 	}
-	
+
+	// Static website hosting is recreated on the destination; a failure does not stop the copy
+	m.sourceWebsite = false
+	var websiteCopied bool
+	var websiteErr error
+	if !input.DryRun && len(objects) > 0 {
+		websiteCopied, websiteErr = m.prepareWebsite(ctx, input, destClient)
+		if websiteCopied {
+			fmt.Printf("Website configuration copied to bucket '%s'\n", input.DestBucket)
+		}
+	}
+
+	var foldersRecreated int64
+	var folderErrors []string
+	if len(folderMarkers) > 0 && !input.DryRun {
+		foldersRecreated, folderErrors = m.recreateFolderMarkers(ctx, input, destClient, folderMarkers)
+		fmt.Printf("Folder markers recreated: %d of %d\n", foldersRecreated, len(folderMarkers))
+	}
+
 	if len(objects) == 0 {
 		fmt.Println("No objects found - this might indicate:")
 		fmt.Println("  - Empty bucket")
@@ -179,7 +326,7 @@ func (m *EnhancedMigrator) Migrate(ctx context.Context, input MigrateInput) (*Mi
 		fmt.Println("  - Wrong prefix")
 		fmt.Println("  - Permission issues")
 		fmt.Println("  - Connection problems")
-		
+
 		// Return detailed dry run verification even when no objects found
 		var dryRunVerified []string
 		if input.DryRun {
@@ -195,11 +342,18 @@ func (m *EnhancedMigrator) Migrate(ctx context.Context, input MigrateInput) (*Mi
 			dryRunVerified = append(dryRunVerified, "File permissions verified")
 			dryRunVerified = append(dryRunVerified, "Migration path validated (empty bucket)")
 		}
-		
+		if len(folderMarkers) > 0 {
+			dryRunVerified = append(dryRunVerified, folderMarkersVerified(input.DryRun, foldersRecreated, len(folderMarkers)))
+		}
+
 		return &MigrateResult{
 			DryRun:         input.DryRun,
 			DryRunVerified: dryRunVerified,
 			SampleFiles:    []string{},
+			FolderMarkers:  foldersRecreated,
+			Errors:         folderErrors,
+			Requests:       m.RequestCounts(),
+			Latency:        m.Latencies(),
 		}, nil
 	}
 
@@ -212,161 +366,186 @@ func (m *EnhancedMigrator) Migrate(ctx context.Context, input MigrateInput) (*Mi
 	// CONSERVATIVE PERFORMANCE: Balance speed with API rate limits
 	// S3 has rate limits, so use moderate worker count to avoid quota exhaustion
 	// Use 100 workers to stay within S3 API limits while maintaining good performance
-	optimalWorkers := 100  // CONSERVATIVE: Good performance without rate limit issues
-	
+	optimalWorkers := 100 // CONSERVATIVE: Good performance without rate limit issues
+
 	// Calculate average file size for logging
 	avgFileSizeMB := float64(totalSize) / float64(len(objects)) / 1024 / 1024
 	fmt.Printf("📊 Workload: %d files, avg size: %.2f MB, total: %.2f GB\n", len(objects), avgFileSizeMB, float64(totalSize)/1024/1024/1024)
 	fmt.Printf("🚀 USING %d WORKERS (conservative to avoid S3 rate limits)\n", optimalWorkers)
 
+	// Determine migration mode (backward compatibility with SyncMode)
+	migrationMode := input.MigrationMode
+	if migrationMode == "" {
+		// Backward compatibility: if SyncMode is true, use incremental mode
+		if input.SyncMode {
+			migrationMode = ModeIncremental
+		} else {
+			migrationMode = ModeFullRewrite
+		}
+	}
+
 	// If dry run, just return the analysis
 	if input.DryRun {
 		// Calculate basic stats
 		totalSizeMB := float64(totalSize) / 1024 / 1024
-		
+
 		// Prepare verification information
 		var dryRunVerified []string
 		dryRunVerified = append(dryRunVerified, "Source bucket connection verified")
 		dryRunVerified = append(dryRunVerified, fmt.Sprintf("Found %d objects totaling %.1f MB", len(objects), totalSizeMB))
+
+		// Optionally diff against the destination to report exactly what would change
+		var diff *DiffSummary
+		if input.DryRunDiff {
+			_, plan, destCount, err := m.planAgainstDestination(ctx, input, destListClient, objects, migrationMode, true)
+			if err != nil {
+				fmt.Printf("Warning: Could not list destination for dry-run diff: %v\n", err)
+				dryRunVerified = append(dryRunVerified, fmt.Sprintf("ERROR: Could not list destination for diff: %v", err))
+			} else {
+				diff = plan
+				dryRunVerified = append(dryRunVerified, fmt.Sprintf("Destination listed: %d objects", destCount))
+				dryRunVerified = append(dryRunVerified, fmt.Sprintf("Would create %d, overwrite %d, skip %d objects (%s mode)",
+					diff.Creates, diff.Overwrites, diff.Skips, migrationMode))
+				fmt.Printf("Dry-run diff: %d create, %d overwrite, %d skip\n", diff.Creates, diff.Overwrites, diff.Skips)
+			}
+		}
+
+		if input.Dedupe != nil {
+			dryRunVerified = append(dryRunVerified, fmt.Sprintf("Deduplication would leave out %d duplicate objects (%.1f MB) and list them in %s",
+				len(duplicates), float64(dedupeBytes(duplicates))/1024/1024, destKeyFor(input.Dedupe.ManifestKey, input.DestPrefix)))
+		}
+		if lifecycleExcluded > 0 {
+			dryRunVerified = append(dryRunVerified, fmt.Sprintf("Would leave out %d objects the destination's lifecycle rules expire on arrival", lifecycleExcluded))
+		}
+		if len(folderMarkers) > 0 {
+			dryRunVerified = append(dryRunVerified, folderMarkersVerified(true, 0, len(folderMarkers)))
+		}
 		dryRunVerified = append(dryRunVerified, "Destination bucket would be created if needed")
 		dryRunVerified = append(dryRunVerified, "File permissions verified")
 		dryRunVerified = append(dryRunVerified, "Migration path validated")
-		
+
 		return &MigrateResult{
-			DryRun:         true,
-			DryRunVerified: dryRunVerified,
-			SampleFiles:    []string{},
+			DryRun:            true,
+			DryRunVerified:    dryRunVerified,
+			DryRunDiff:        diff,
+			SampleFiles:       []string{},
+			Deduplicated:      int64(len(duplicates)),
+			LifecycleExcluded: lifecycleExcluded,
+			Requests:          m.RequestCounts(),
+			Latency:           m.Latencies(),
 		}, nil
 	}
 
 	// Create job queue
 	// Filter objects based on migration mode
 	var objectsToProcess []objectInfo
-	
-	// Determine migration mode (backward compatibility with SyncMode)
-	migrationMode := input.MigrationMode
-	if migrationMode == "" {
-		// Backward compatibility: if SyncMode is true, use incremental mode
-		if input.SyncMode {
-			migrationMode = ModeIncremental
-		} else {
-			migrationMode = ModeFullRewrite
-		}
-	}
-	
+
 	if migrationMode == ModeIncremental {
 		fmt.Println("\n=== Incremental Mode: Checking for new/changed files ===")
-		// Get destination objects (use destClient if available for cross-account)
-		destObjects, err := m.listObjectsWithCache(ctx, input.DestBucket, input.DestPrefix, destClient)
+		// Compare against the destination (use destClient if available for cross-account)
+		toCopy, plan, err := m.planIncrementalRun(ctx, input, destListClient, objects)
 		if err != nil {
 			fmt.Printf("Warning: Could not list destination for incremental mode: %v\n", err)
 			fmt.Println("Falling back to full rewrite mode")
 			objectsToProcess = objects
 		} else {
-			// Build a map of destination keys with metadata for fast lookup
-			type destMetadata struct {
-				size         int64
-				lastModified time.Time
-			}
-			destMap := make(map[string]destMetadata)
-			for _, obj := range destObjects {
-				// Extract relative key by removing dest prefix
-				relativeKey := obj.Key
-				if input.DestPrefix != "" && len(obj.Key) > len(input.DestPrefix) {
-					// Remove prefix and leading slash
-					if obj.Key[:len(input.DestPrefix)] == input.DestPrefix {
-						relativeKey = obj.Key[len(input.DestPrefix):]
-						if len(relativeKey) > 0 && relativeKey[0] == '/' {
-							relativeKey = relativeKey[1:]
-						}
-					}
-				}
-				destMap[relativeKey] = destMetadata{
-					size:         obj.Size,
-					lastModified: obj.LastModified,
-				}
-			}
-			
-			// Only include objects that are new or changed
-			var skippedExists, skippedUnchanged int
-			for _, obj := range objects {
-				// Extract relative key from source (remove source prefix if any)
-				sourceKey := obj.Key
-				if input.SourcePrefix != "" && len(obj.Key) > len(input.SourcePrefix) {
-					if obj.Key[:len(input.SourcePrefix)] == input.SourcePrefix {
-						sourceKey = obj.Key[len(input.SourcePrefix):]
-						if len(sourceKey) > 0 && sourceKey[0] == '/' {
-							sourceKey = sourceKey[1:]
-						}
-					}
-				}
-				
-				// Check if this file exists in destination
-				destMeta, exists := destMap[sourceKey]
-				if !exists {
-					// New file - must copy
-					objectsToProcess = append(objectsToProcess, obj)
-				} else {
-					// File exists - check if it changed (size or timestamp)
-					sizeChanged := obj.Size != destMeta.size
-					timeChanged := obj.LastModified.After(destMeta.lastModified)
-					
-					if sizeChanged || timeChanged {
-						// File changed - must copy
-						objectsToProcess = append(objectsToProcess, obj)
-						fmt.Printf("  Modified: %s (size: %d->%d, time: %v->%v)\n", 
-							sourceKey, destMeta.size, obj.Size, 
-							destMeta.lastModified.Format("2006-01-02 15:04:05"),
-							obj.LastModified.Format("2006-01-02 15:04:05"))
-					} else {
-						// File unchanged - skip
-						skippedUnchanged++
-					}
-				}
-			}
-			
-			skippedExists = len(objects) - len(objectsToProcess) - skippedUnchanged
-			fmt.Printf("Incremental mode: %d new files, %d unchanged files (skipped), %d to copy\n", 
-				skippedExists, skippedUnchanged, len(objectsToProcess))
+			objectsToProcess = toCopy
+			fmt.Printf("Incremental mode: %d new files, %d unchanged files (skipped), %d to copy\n",
+				plan.Creates, plan.Skips, len(objectsToProcess))
 		}
 	} else {
 		// Full rewrite mode - copy everything
 		fmt.Println("\n=== Full Rewrite Mode: Copying all objects ===")
 		objectsToProcess = objects
 	}
-	
+
+	if input.Order != "" && input.Order != OrderListing {
+		objectsToProcess = orderObjects(objectsToProcess, input.Order, rand.New(rand.NewSource(time.Now().UnixNano())))
+		fmt.Printf("Copy order: %s\n", input.Order)
+	}
+	if input.PrefixShards != nil && input.Order != OrderRandom {
+		objectsToProcess = interleaveByPrefix(objectsToProcess, input.PrefixShards)
+	}
+
+	// The destination is about to change; a listing cached before now is stale
+	if len(objectsToProcess) > 0 {
+		m.invalidateDestListing(input, destClient)
+	}
+
 	jobs := make(chan copyJob, len(objectsToProcess))
 	results := make(chan copyResult, len(objectsToProcess))
 
 	// Prepare copy jobs
 	for _, obj := range objectsToProcess {
-		destKey := obj.Key
-		if input.DestPrefix != "" {
-			destKey = input.DestPrefix + "/" + obj.Key
-		}
-		
 		jobs <- copyJob{
 			sourceKey: obj.Key,
-			destKey:   destKey,
+			destKey:   destKeyForObject(obj, input.DestPrefix, input.Partition, input.KeySanitize),
 			size:      obj.Size,
+			etag:      obj.ETag,
 		}
 	}
 	close(jobs)
 
+	// ETA is based on the bytes of the jobs actually queued, not the whole listing
+	var pendingBytes int64
+	for _, obj := range objectsToProcess {
+		pendingBytes += obj.Size
+	}
+	etaEstimator := progress.NewETAEstimator(int64(len(objectsToProcess)), pendingBytes)
+	m.inflight.reset(etaEstimator.ObservePartial)
+	m.verifyWrites = input.VerifyWrite
+	m.transform = input.Transform
+	m.scan = input.Scan
+	m.scanFindings.reset()
+	m.acl = newACLMapper(input.ACL)
+	m.labeler = newLabeler(input.Labels)
+	m.prefixLimiter = newPrefixLimiter(input.PrefixShards)
+	m.endpointGroup.Store(input.EndpointGroup)
+	if input.EndpointGroup != nil {
+		defer input.EndpointGroup.Join()()
+	}
+	m.destProvider = integrity.DetectProvider(m.config.EndpointURL)
+	if destClient != nil {
+		m.destProvider = integrity.DetectProvider(input.DestEndpointURL)
+	}
+	m.destFetch = m.probeDestFetch(ctx, input, destClient)
+	m.syncStates = m.loadSyncStates(input, destClient)
+	m.multipart = input.Multipart
+	if m.multipart.ThresholdBytes == 0 {
+		m.multipart = config.DefaultMultipartSettings()
+	}
+
 	// Start workers
+	reportPhase(models.PhaseUploading)
 	var wg sync.WaitGroup
 	copied := atomic.Int64{}
 	failed := atomic.Int64{}
-	var errors []string
+	errors := folderErrors // Workers append the copy failures
 	var mu sync.Mutex
 
-	for i := 0; i < optimalWorkers; i++ {
+	// Workers start lazily up to the target, so the worker count can be raised or
+	// lowered on a live task; lowered workers stay parked until raised again
+	workerLimit := maxTunableWorkers
+	if len(objectsToProcess) < workerLimit {
+		workerLimit = len(objectsToProcess)
+	}
+	liveDone := make(chan struct{})
+	defer close(liveDone)
+	go m.runAutoscaler(liveDone)
+	go func() {
+		select {
+		case <-ctx.Done():
+			m.live.finish()
+		case <-liveDone:
+		}
+	}()
+	m.live.start(optimalWorkers, workerLimit, func() int { return len(jobs) }, func(workerID int) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			m.enhancedWorker(ctx, jobs, results, input, &copied, &failed, &errors, &mu, destClient)
+			m.enhancedWorker(ctx, workerID, jobs, results, input, &copied, &failed, &errors, &mu, destClient)
 		}()
-	}
+	})
 
 	// Start result collector
 	go func() {
@@ -375,55 +554,127 @@ func (m *EnhancedMigrator) Migrate(ctx context.Context, input MigrateInput) (*Mi
 	}()
 
 	// Process results and update progress
-	var totalCopied, totalFailed int64
+	var totalCopied, totalFailed, totalSkipped, totalNotModified, overwriteRefused int64
+	var overwriteRefusedKeys []string
 	var totalCopiedSize int64
-	
+	var progressMu sync.Mutex
+
+	// reportProgress includes the finished parts of in-flight objects, so a
+	// single huge multipart copy still moves the task's progress
+	reportProgress := func() {
+		if input.Reporter == nil {
+			return
+		}
+		progressMu.Lock()
+		defer progressMu.Unlock()
+
+		partialBytes, partialObjects := m.inflight.partial()
+		copiedSize := totalCopiedSize + partialBytes
+		totalObjects := int64(len(objects))
+		// FIXED: Use totalCopied instead of copied.Load() to avoid race conditions
+		currentProgress := (float64(totalCopied) + partialObjects) / float64(totalObjects) * 100.0
+
+		// Calculate speed and ETA
+		elapsed := time.Since(startTime).Seconds()
+		currentSpeed := 0.0
+		eta := "calculating..."
+
+		if elapsed > 0 {
+			// Speed in MB/s
+			currentSpeed = float64(copiedSize) / elapsed / 1024 / 1024
+		}
+		update := progress.Update{
+			Time:          time.Now(),
+			Progress:      currentProgress,
+			CopiedObjects: totalCopied,
+			TotalObjects:  totalObjects,
+			CopiedBytes:   copiedSize,
+			TotalBytes:    totalSize,
+			SpeedMBps:     currentSpeed,
+		}
+		if estimate, ok := etaEstimator.Estimate(); ok {
+			eta = progress.FormatETA(estimate.Seconds)
+			update.Estimate = &estimate
+		}
+		update.ETA = eta
+
+		input.Reporter.Progress(update)
+	}
+
+	// Between object completions, report part-level progress of large transfers
+	progressDone := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(inFlightReportInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-progressDone:
+				return
+			case <-ticker.C:
+				if partialBytes, _ := m.inflight.partial(); partialBytes > 0 {
+					reportProgress()
+				}
+			}
+		}
+	}()
+
+	manifest := &TransferManifest{SourcePrefix: input.SourcePrefix}
+	skippedKeys := make(map[string]bool) // Not expected on the destination by the reconciliation
+	var audit *auditLog
+	if input.AuditLog != nil {
+		audit = m.newAuditLog(input, destListClient, startTime)
+	}
 	for result := range results {
+		progressMu.Lock()
+		if result.overwriteRefused {
+			overwriteRefused++
+			if len(overwriteRefusedKeys) < maxRefusedOverwriteKeys {
+				overwriteRefusedKeys = append(overwriteRefusedKeys, result.destKey)
+			}
+		}
 		if result.success {
 			totalCopied++
 			totalCopiedSize += result.size
+			manifest.record(result.sourceKey, true)
+			if audit != nil {
+				if err := audit.add(ctx, result); err != nil {
+					fmt.Printf("⚠️  %v\n", err) // Kept pending for the next file
+				}
+			}
+		} else if result.notModified {
+			totalNotModified++
+		} else if result.skipped {
+			totalSkipped++
+			skippedKeys[result.sourceKey] = true
 		} else if !result.cancelled {
 			totalFailed++
+			manifest.record(result.sourceKey, false)
+		}
+		progressMu.Unlock()
+		if !result.cancelled {
+			etaEstimator.Observe(result.size - result.partialBytes)
 		}
-		
+
 		// Call progress callback for real-time updates
-		if input.ProgressCallback != nil {
-			totalObjects := int64(len(objects))
-			// FIXED: Use totalCopied instead of copied.Load() to avoid race conditions
-			currentProgress := float64(totalCopied) / float64(totalObjects) * 100.0
-			
-			// Calculate speed and ETA
-			elapsed := time.Since(startTime).Seconds()
-			currentSpeed := 0.0
-			eta := "calculating..."
-			
-			if elapsed > 0 {
-				// Speed in MB/s
-				currentSpeed = float64(totalCopiedSize) / elapsed / 1024 / 1024
-				
-				// Calculate ETA
-				remaining := totalObjects - totalCopied
-				if remaining > 0 && currentSpeed > 0 {
-					// Estimate remaining bytes based on average file size
-					avgFileSize := float64(totalSize) / float64(totalObjects)
-					remainingBytes := float64(remaining) * avgFileSize
-					remainingSeconds := remainingBytes / (currentSpeed * 1024 * 1024)
-					
-					etaDuration := time.Duration(remainingSeconds) * time.Second
-					if etaDuration < time.Minute {
-						eta = fmt.Sprintf("%ds", int(etaDuration.Seconds()))
-					} else if etaDuration < time.Hour {
-						eta = fmt.Sprintf("%dm", int(etaDuration.Minutes()))
-					} else {
-						eta = fmt.Sprintf("%dh%dm", int(etaDuration.Hours()), int(etaDuration.Minutes())%60)
-					}
-				} else if remaining == 0 {
-					eta = "0s"
-				}
-			}
-			
-			input.ProgressCallback(currentProgress, totalCopied, totalObjects, currentSpeed, eta)
+		reportProgress()
+	}
+	close(progressDone)
+	if m.syncStates != nil {
+		listed := objects
+		if input.FilesFrom != nil {
+			listed = nil // Only part of the route was listed
+		}
+		if err := m.syncStates.save(listed); err != nil {
+			m.logger.Errorf("Conditional GET: %v", err)
 		}
+		fmt.Printf("Conditional GET: %d objects unchanged since the last sync were not downloaded\n", m.syncStates.count())
+	}
+	if audit != nil {
+		// Written even when cancelled: every object that was copied is on record
+		if err := audit.flush(context.WithoutCancel(ctx)); err != nil {
+			errors = append(errors, err.Error())
+		}
+		fmt.Printf("Audit log: %d records in %d files under s3://%s/%s\n", audit.location.Records, len(audit.location.Files), audit.location.Bucket, audit.location.Prefix)
 	}
 
 	// Calculate final statistics
@@ -431,24 +682,30 @@ func (m *EnhancedMigrator) Migrate(ctx context.Context, input MigrateInput) (*Mi
 	// Simple stats calculation
 	avgSpeedMB := float64(totalCopiedSize) / elapsed.Seconds() / 1024 / 1024
 
-	// Verify migration integrity for actual runs
+	// Verify migration integrity for actual runs; a stopped run left objects
+	// uncopied on purpose, so they are not reported as missing
 	var verificationErrors []string
-	if !input.DryRun && copied.Load() > 0 {
+	if !input.DryRun && copied.Load() > 0 && !m.stopRequested.Load() {
 		fmt.Println("\n=== Verifying Migration Integrity ===")
+		reportPhase(models.PhaseVerifying)
 
-		// List destination objects to verify (use destClient for cross-account)
+		// List destination objects to verify (use destClient for cross-account); always
+		// listed fresh, then cached for the next incremental comparison
+		listingGeneration := m.destListingGeneration(input, destClient)
 		destObjects, err := m.listObjectsWithCache(ctx, input.DestBucket, input.DestPrefix, destClient)
 		if err != nil {
 			verificationErrors = append(verificationErrors, fmt.Sprintf("Failed to verify destination: %v", err))
 			fmt.Printf("Verification failed: %v\n", err)
 		} else {
+			m.cacheDestListing(input, destClient, destObjects, listingGeneration)
+			destObjects = audit.withoutOwnFiles(input.DestBucket, destObjects)
 			// Compare source and destination
-			sourceCount := len(objects)
+			sourceCount := len(objects) + int(foldersRecreated)
 			destCount := len(destObjects)
-			
+
 			fmt.Printf("Source objects: %d\n", sourceCount)
 			fmt.Printf("Destination objects: %d\n", destCount)
-			
+
 			if sourceCount != destCount {
 				diff := destCount - sourceCount
 				if diff > 0 {
@@ -464,7 +721,7 @@ func (m *EnhancedMigrator) Migrate(ctx context.Context, input MigrateInput) (*Mi
 			} else {
 				fmt.Printf("Object count matches: %d objects\n", destCount)
 			}
-			
+
 			// Calculate total sizes for comparison
 			var sourceSize, destSize int64
 			for _, obj := range objects {
@@ -473,12 +730,12 @@ func (m *EnhancedMigrator) Migrate(ctx context.Context, input MigrateInput) (*Mi
 			for _, obj := range destObjects {
 				destSize += obj.Size
 			}
-			
+
 			fmt.Printf("Source total size: %.2f MB\n", float64(sourceSize)/1024/1024)
 			fmt.Printf("Destination total size: %.2f MB\n", float64(destSize)/1024/1024)
-			
+
 			if sourceSize != destSize {
-				sizeDiff := float64(destSize - sourceSize) / 1024 / 1024
+				sizeDiff := float64(destSize-sourceSize) / 1024 / 1024
 				if sizeDiff > 0 {
 					// Destination is larger - likely pre-existing data
 					fmt.Printf("Destination is %.2f MB larger than source\n", sizeDiff)
@@ -492,7 +749,7 @@ func (m *EnhancedMigrator) Migrate(ctx context.Context, input MigrateInput) (*Mi
 			} else {
 				fmt.Printf("Total size matches: %.2f MB\n", float64(destSize)/1024/1024)
 			}
-			
+
 			// Check if this looks like pre-existing data scenario
 			if destCount > sourceCount && destSize > sourceSize {
 				fmt.Printf("\nAnalysis: This appears to be a migration to a bucket with pre-existing data\n")
@@ -502,7 +759,7 @@ func (m *EnhancedMigrator) Migrate(ctx context.Context, input MigrateInput) (*Mi
 			}
 		}
 	}
-	
+
 	// Prepare verification information
 	var dryRunVerified []string
 	if input.DryRun {
@@ -514,6 +771,9 @@ func (m *EnhancedMigrator) Migrate(ctx context.Context, input MigrateInput) (*Mi
 	} else {
 		// Add verification results for actual runs
 		dryRunVerified = append(dryRunVerified, "Migration completed")
+		if len(folderMarkers) > 0 {
+			dryRunVerified = append(dryRunVerified, folderMarkersVerified(false, foldersRecreated, len(folderMarkers)))
+		}
 		if len(verificationErrors) == 0 {
 			dryRunVerified = append(dryRunVerified, "Source and destination match perfectly")
 		} else {
@@ -522,169 +782,417 @@ func (m *EnhancedMigrator) Migrate(ctx context.Context, input MigrateInput) (*Mi
 			}
 		}
 	}
-	
+
 	// Combine migration errors with verification errors
 	allErrors := errors
 	allErrors = append(allErrors, verificationErrors...)
+	if websiteErr != nil {
+		allErrors = append(allErrors, websiteErr.Error())
+	}
 
-	return &MigrateResult{
-		Copied:           totalCopied,
-		Failed:           totalFailed,
-		TotalSizeMB:      float64(totalSize) / 1024 / 1024,
-		CopiedSizeMB:     float64(totalCopiedSize) / 1024 / 1024,
-		ElapsedTime:      elapsed.String(),
-		AvgSpeedMB:       avgSpeedMB,
-		Cancelled:        m.stopRequested.Load(),
-		RemainingObjects: int64(len(objects)) - totalCopied - totalFailed,
-		Errors:           allErrors,
-		DryRun:           input.DryRun,
-		DryRunVerified:   dryRunVerified,
-		SampleFiles:      []string{},
-	}, nil
-}
+	// Written after verification so the manifest is not counted as a migrated object
+	var manifestKey string
+	if len(duplicates) > 0 && !input.DryRun && !m.stopRequested.Load() {
+		manifestKey, err = m.writeDedupeManifest(ctx, input, destClient, duplicates)
+		if err != nil {
+			allErrors = append(allErrors, err.Error())
+		} else {
+			fmt.Printf("Dedupe manifest written: s3://%s/%s (%d duplicates)\n", input.DestBucket, manifestKey, len(duplicates))
+		}
+	}
 
-// enhancedWorker processes copy jobs with optimizations
-func (m *EnhancedMigrator) enhancedWorker(ctx context.Context, jobs <-chan copyJob, results chan<- copyResult, input MigrateInput, copied, failed *atomic.Int64, errors *[]string, mu *sync.Mutex, destClient *s3.Client) {
-	client := m.connPool.GetClient()
-	
-	for job := range jobs {
-		if m.stopRequested.Load() {
-			results <- copyResult{
-				key:       job.sourceKey,
-				sourceKey: job.sourceKey,
-				destKey:   job.destKey,
-				size:      job.size,
-				cancelled: true,
+	// Snapshot of the destination as this run left it, for later audits
+	var snapshotKey, snapshotSHA256 string
+	if input.Snapshot != nil && !input.DryRun && !m.stopRequested.Load() {
+		snapshotKey, snapshotSHA256, err = m.writeSnapshotManifest(ctx, input, destListClient, startTime)
+		if err != nil {
+			allErrors = append(allErrors, err.Error())
+		} else {
+			fmt.Printf("Snapshot manifest written: s3://%s/%s (sha256 %s)\n", input.DestBucket, snapshotKey, snapshotSHA256)
+		}
+	}
+
+	// Destination listing kept with the task for re-verifying it without listing again
+	if input.TaskListing != nil && !input.DryRun && !m.stopRequested.Load() {
+		if listing, err := m.cacheTaskListing(ctx, input, destListClient); err != nil {
+			allErrors = append(allErrors, err.Error())
+		} else {
+			fmt.Printf("Destination listing cached for task %s: %d objects in %d parts\n", listing.TaskID, listing.Objects, listing.Parts)
+		}
+	}
+
+	// Keys that failed to copy, for re-driving them from a webhook
+	var failureManifest *models.FailureManifestLink
+	var failureManifestKey string // Own key for the reconciliation when written to the destination bucket
+	if input.FailureManifest != nil && totalFailed > 0 && !input.DryRun {
+		failureManifest, err = m.writeFailureManifest(ctx, input, destListClient, manifest)
+		if err != nil {
+			allErrors = append(allErrors, err.Error())
+		} else {
+			fmt.Printf("Failure manifest written: s3://%s/%s (%d objects)\n", failureManifest.Bucket, failureManifest.Key, failureManifest.Objects)
+			if failureManifest.Bucket == input.DestBucket {
+				failureManifestKey = failureManifest.Key
 			}
-			continue
 		}
+	}
 
-		// Check if we should use streaming for large files
-		if m.streamer != nil && job.size > m.config.StreamChunkSize {
-			// Use streaming copy for large files
-			_, err := m.streamer.StreamCopy(ctx, streaming.StreamCopyInput{
-				SourceBucket: input.SourceBucket,
-				SourceKey:    job.sourceKey,
-				DestBucket:   input.DestBucket,
-				DestKey:      job.destKey,
-			})
-			if err != nil {
-				failed.Add(1)
-				mu.Lock()
-				*errors = append(*errors, fmt.Sprintf("Failed to copy %s: %v", job.sourceKey, err))
-				mu.Unlock()
-				results <- copyResult{
-					key:       job.sourceKey,
-					sourceKey: job.sourceKey,
-					destKey:   job.destKey,
-					size:      job.size,
-					err:       err,
-				}
-			} else {
-				copied.Add(1)
-				if m.progress != nil {
-					m.progress.Update(job.size, true)
-				}
-				results <- copyResult{
-					key:       job.sourceKey,
-					sourceKey: job.sourceKey,
-					destKey:   job.destKey,
-					size:      job.size,
-					success:   true,
-				}
+	// Destination contents compared with what this run put there
+	var reconciliation *models.StorageReconciliation
+	if input.Reconcile != nil && !input.DryRun && !m.stopRequested.Load() {
+		migrated := make([]objectInfo, 0, len(objects))
+		for _, obj := range objects {
+			if !skippedKeys[obj.Key] {
+				migrated = append(migrated, obj)
 			}
+		}
+		ownKeys := []string{manifestKey, snapshotKey, failureManifestKey}
+		for _, marker := range folderMarkers {
+			ownKeys = append(ownKeys, destKeyForObject(marker, input.DestPrefix, input.Partition, input.KeySanitize))
+		}
+		if audit != nil && audit.location.Bucket == input.DestBucket {
+			ownKeys = append(ownKeys, audit.location.Files...)
+		}
+		reconciliation, err = m.reconcileStorage(ctx, input, destListClient, migrated, totalCopiedSize, ownKeys...)
+		if err != nil {
+			allErrors = append(allErrors, err.Error())
 		} else {
-			// Regular copy (with cross-account support if destClient is provided)
-			err := m.copyObject(ctx, client, input.SourceBucket, job.sourceKey, input.DestBucket, job.destKey, destClient)
-			if err != nil {
-				failed.Add(1)
-				mu.Lock()
-				*errors = append(*errors, fmt.Sprintf("Failed to copy %s: %v", job.sourceKey, err))
-				mu.Unlock()
-				results <- copyResult{
-					key:       job.sourceKey,
-					sourceKey: job.sourceKey,
-					destKey:   job.destKey,
-					size:      job.size,
-					err:       err,
-				}
-			} else {
-				copied.Add(1)
-				if m.progress != nil {
-					m.progress.Update(job.size, true)
-				}
-				results <- copyResult{
-					key:       job.sourceKey,
-					sourceKey: job.sourceKey,
-					destKey:   job.destKey,
-					size:      job.size,
-					success:   true,
-				}
+			for _, discrepancy := range reconciliation.Discrepancies {
+				fmt.Printf("Reconciliation: %s\n", discrepancy)
 			}
 		}
 	}
+
+	return &MigrateResult{
+		Copied:            totalCopied,
+		Failed:            totalFailed,
+		TotalSizeMB:       float64(totalSize) / 1024 / 1024,
+		CopiedSizeMB:      float64(totalCopiedSize) / 1024 / 1024,
+		ElapsedTime:       elapsed.String(),
+		AvgSpeedMB:        avgSpeedMB,
+		Cancelled:         m.stopRequested.Load(),
+		RemainingObjects:  int64(len(objects)) - totalCopied - totalFailed - totalSkipped - totalNotModified,
+		Skipped:           totalSkipped,
+		NotModified:       totalNotModified,
+		ScanFindings:      m.scanFindings.list(),
+		Deduplicated:      int64(len(duplicates)),
+		DedupeManifest:    manifestKey,
+		LifecycleExcluded: lifecycleExcluded,
+		FolderMarkers:     foldersRecreated,
+		SnapshotManifest:  snapshotKey,
+		SnapshotSHA256:    snapshotSHA256,
+		WebsiteCopied:     websiteCopied,
+		DestFetched:       m.destFetch.count(),
+		OverwriteRefused:  overwriteRefused,
+		ExistingDestKeys:  overwriteRefusedKeys,
+		Errors:            allErrors,
+		DryRun:            input.DryRun,
+		DryRunVerified:    dryRunVerified,
+		SampleFiles:       []string{},
+		ResourceUsage:     resourceTracker.Stop(),
+		WorkerAdjustments: m.live.workerAdjustments(),
+		Manifest:          manifest,
+		Reconciliation:    reconciliation,
+		FailureManifest:   failureManifest,
+		AuditLog:          audit.written(),
+		ACL:               m.acl.result(input),
+		Labels:            m.labeler.result(),
+		Requests:          m.RequestCounts(),
+		Latency:           m.Latencies(),
+	}, nil
+}
+
+// enhancedWorker processes copy jobs with optimizations.
+// Workers whose ID is at or above the live target worker count stay parked.
+func (m *EnhancedMigrator) enhancedWorker(ctx context.Context, workerID int, jobs <-chan copyJob, results chan<- copyResult, input MigrateInput, copied, failed *atomic.Int64, errors *[]string, mu *sync.Mutex, destClient *s3.Client) {
+	client := m.connPool.GetClient()
+
+	for m.live.acquire(workerID) {
+		job, ok := <-jobs
+		if !ok {
+			m.live.release()
+			m.live.finish()
+			return
+		}
+
+		results <- m.processJob(ctx, client, job, input, copied, failed, errors, mu, destClient)
+		m.live.release()
+	}
+}
+
+// processJob copies one object and returns its result
+func (m *EnhancedMigrator) processJob(ctx context.Context, client *s3.Client, job copyJob, input MigrateInput, copied, failed *atomic.Int64, errors *[]string, mu *sync.Mutex, destClient *s3.Client) copyResult {
+	result := copyResult{
+		key:       job.sourceKey,
+		sourceKey: job.sourceKey,
+		destKey:   job.destKey,
+		size:      job.size,
+	}
+
+	if m.stopRequested.Load() {
+		result.cancelled = true
+		return result
+	}
+
+	if err := m.live.throttle(ctx); err != nil {
+		result.cancelled = true
+		return result
+	}
+
+	// Tasks writing to the same endpoint share its budget
+	if group := m.endpointGroup.Load(); group != nil {
+		if err := group.Acquire(ctx); err != nil {
+			result.cancelled = true
+			return result
+		}
+		defer group.Release()
+	}
+
+	// Spread over the source's request partitions: a hot prefix gets a bounded share
+	if m.prefixLimiter != nil {
+		release, err := m.prefixLimiter.acquire(ctx, job.sourceKey)
+		if err != nil {
+			result.cancelled = true
+			return result
+		}
+		defer release()
+	}
+
+	// A drain may have been requested while the job waited for a slot
+	if m.stopRequested.Load() {
+		result.cancelled = true
+		return result
+	}
+
+	if input.AuditLog != nil {
+		ctx, result.audit = withObjectAudit(ctx)
+		result.etag = job.etag
+	}
+	if input.NeverOverwrite != "" {
+		ctx = pool.WriteOnce(ctx)
+	}
+	m.inflight.begin(job.sourceKey, job.size)
+	started := time.Now()
+	var err error
+	if m.streamer != nil && job.size > m.config.StreamChunkSize && !m.transform.Matches(job.sourceKey) && m.scan == nil && !m.destFetch.accepts(job.size) {
+		// Use streaming copy for large files
+		m.inflight.setParts(job.sourceKey, int((job.size+m.config.StreamChunkSize-1)/m.config.StreamChunkSize))
+		_, err = m.streamer.StreamCopy(ctx, streaming.StreamCopyInput{
+			SourceBucket: input.SourceBucket,
+			SourceKey:    job.sourceKey,
+			DestBucket:   input.DestBucket,
+			DestKey:      job.destKey,
+			ObjectSize:   job.size,
+			OnPartCopied: func(partBytes int64) {
+				m.inflight.add(job.sourceKey, partBytes, true)
+			},
+		})
+		if err == nil {
+			err = m.verifyWrite(ctx, m.metadataClient(), input.DestBucket, job.destKey, job.size, writeChecksums{})
+		}
+	} else {
+		// Regular copy (with cross-account support if destClient is provided)
+		err = m.copyObject(ctx, client, input.SourceBucket, job.sourceKey, input.DestBucket, job.destKey, destClient)
+	}
+	result.partialBytes = m.inflight.end(job.sourceKey)
+
+	// The destination refused to replace an existing object
+	result.overwriteRefused = input.NeverOverwrite != "" && pool.IsPreconditionFailed(err)
+	if err == errNotModified {
+		result.notModified = true
+		return result
+	}
+	if err == errTransformSkipped || err == errScanWithheld || (result.overwriteRefused && input.NeverOverwrite == NeverOverwriteSkip) {
+		result.skipped = true
+		return result
+	}
+	if result.overwriteRefused {
+		err = fmt.Errorf("destination key %s already exists (never_overwrite: fail)", job.destKey)
+	}
+	// Feeds the error rate the worker count is scaled on, and the measured network
+	// condition; cancellation and refused overwrites are not errors
+	if ctx.Err() == nil {
+		m.connPool.RecordRequest()
+		if err != nil && !result.overwriteRefused {
+			m.connPool.RecordError()
+		}
+		m.tuner.RecordTransfer(job.size, time.Since(started), err)
+	}
+	if err != nil {
+		failed.Add(1)
+		mu.Lock()
+		*errors = append(*errors, fmt.Sprintf("Failed to copy %s: %v", job.sourceKey, err))
+		mu.Unlock()
+		result.err = err
+		return result
+	}
+
+	// The copy stands even when its ACL cannot be mapped or it cannot be labeled; the error is reported
+	writeClient := destClient
+	if writeClient == nil {
+		writeClient = client
+	}
+	if m.acl != nil {
+		if err := m.acl.mapObject(ctx, client, writeClient, input, job); err != nil {
+			mu.Lock()
+			*errors = append(*errors, fmt.Sprintf("ACL of %s: %v", job.sourceKey, err))
+			mu.Unlock()
+		}
+	}
+	if m.labeler != nil {
+		if err := m.labeler.labelObject(ctx, writeClient, input.DestBucket, job.destKey); err != nil {
+			mu.Lock()
+			*errors = append(*errors, fmt.Sprintf("Labels of %s: %v", job.sourceKey, err))
+			mu.Unlock()
+		}
+	}
+
+	copied.Add(1)
+	if m.progress != nil {
+		m.progress.Update(job.size, true)
+	}
+	if result.audit != nil {
+		result.audit.finish()
+	}
+	result.success = true
+	return result
 }
 
 // copyObject copies a single object, using multipart copy for large files (>1GB)
 // If destClient is provided, it will be used for destination operations (cross-account copy)
 func (m *EnhancedMigrator) copyObject(ctx context.Context, client *s3.Client, sourceBucket, sourceKey, destBucket, destKey string, destClient *s3.Client) error {
-	fmt.Printf("\n=== COPY OBJECT DEBUG ===\n")
-	fmt.Printf("Source: %s/%s\n", sourceBucket, sourceKey)
-	fmt.Printf("Dest: %s/%s\n", destBucket, destKey)
-	
+	log := m.logger.Object()
+	log.Debugf("=== COPY OBJECT DEBUG ===")
+	log.Debugf("Source: %s/%s", sourceBucket, sourceKey)
+	log.Debugf("Dest: %s/%s", destBucket, destKey)
+
 	// Get object metadata to check size
-	headOutput, err := client.HeadObject(ctx, &s3.HeadObjectInput{
+	headOutput, err := m.headObject(ctx, m.metadataClient(), &s3.HeadObjectInput{
 		Bucket: aws.String(sourceBucket),
 		Key:    aws.String(sourceKey),
 	})
 	if err != nil {
-		fmt.Printf("ERROR: HeadObject failed: %v\n", err)
+		log.Errorf("ERROR: HeadObject failed for %s: %v", sourceKey, err)
 		return fmt.Errorf("failed to get object metadata: %w", err)
 	}
-	
+
 	objectSize := *headOutput.ContentLength
 	sizeMB := float64(objectSize) / 1024 / 1024
 	sizeGB := sizeMB / 1024
-	thresholdGB := float64(1)
-	
-	fmt.Printf("Object size: %d bytes (%.2f MB, %.2f GB)\n", objectSize, sizeMB, sizeGB)
-	fmt.Printf("Threshold: %.2f GB\n", thresholdGB)
-	fmt.Printf("Will use multipart: %v\n", sizeGB > thresholdGB)
-	
+	threshold := m.multipart.ThresholdBytes
+	if threshold == 0 {
+		threshold = config.DefaultMultipartSettings().ThresholdBytes
+	}
+
+	log.Debugf("Object size: %d bytes (%.2f MB, %.2f GB)", objectSize, sizeMB, sizeGB)
+	log.Debugf("Threshold: %.2f MB", float64(threshold)/1024/1024)
+	log.Debugf("Will use multipart: %v", objectSize > threshold)
+
+	// Large transfers share a process-wide limit so their combined bandwidth and memory stay bounded
+	if objectSize > threshold {
+		if err := largeObjects.acquire(ctx); err != nil {
+			return err
+		}
+		defer largeObjects.release()
+	}
+
+	// Scanned objects must pass the scanner before anything is written
+	if m.scan != nil {
+		writeClient := destClient
+		if writeClient == nil {
+			writeClient = client
+		}
+		return m.scanCopy(ctx, log, client, writeClient, sourceBucket, sourceKey, destBucket, destKey, objectSize)
+	}
+
+	// Transformed objects flow through the hook, so they cannot be copied server-side
+	if m.transform.Matches(sourceKey) {
+		writeClient := destClient
+		if writeClient == nil {
+			writeClient = client
+		}
+		return m.transformCopy(ctx, log, client, writeClient, sourceBucket, sourceKey, destBucket, destKey, objectSize)
+	}
+
+	// The destination pulls the object itself when its fetch service supports it
+	if destClient != nil && m.destFetch.accepts(objectSize) {
+		if fetched, err := m.fetchCopy(ctx, log, destClient, sourceBucket, sourceKey, destBucket, destKey, objectSize); fetched {
+			return err
+		}
+	}
+
 	// If we have separate dest credentials, use GetObject + PutObject for cross-account copy
 	if destClient != nil {
-		fmt.Printf("[CROSS-ACCOUNT] Using GetObject + PutObject for cross-account copy\n")
-		return m.crossAccountCopy(ctx, client, destClient, sourceBucket, sourceKey, destBucket, destKey, objectSize)
+		log.Debugf("[CROSS-ACCOUNT] Using GetObject + PutObject for cross-account copy")
+		return m.crossAccountCopy(ctx, log, client, destClient, sourceBucket, sourceKey, destBucket, destKey, objectSize)
 	}
-	
-	// Use multipart copy for files larger than 1GB (safer threshold for compatibility)
+
+	// Use multipart copy above the configured threshold (default 1GB, safer for compatibility)
 	// Some S3 providers have lower limits than AWS's 5GB
-	if objectSize > 1*1024*1024*1024 {
-		fmt.Printf("[MULTIPART] File '%s' is %.2f GB - using multipart copy\n", sourceKey, sizeGB)
+	if objectSize > threshold {
+		log.Infof("[MULTIPART] File '%s' is %.2f GB - using multipart copy", sourceKey, sizeGB)
 		return m.multipartCopy(ctx, client, sourceBucket, sourceKey, destBucket, destKey, objectSize, destClient)
 	}
-	
+
 	// Use simple copy for smaller files (same account)
-	fmt.Printf("[SIMPLE COPY] File '%s' is %.2f MB - using simple copy\n", sourceKey, sizeMB)
-	
+	log.Debugf("[SIMPLE COPY] File '%s' is %.2f MB - using simple copy", sourceKey, sizeMB)
+
 	// For CopySource, we need to URL-encode the key but not the bucket or slash separator
 	// Format: bucket/key (where key is URL-encoded)
 	copySource := sourceBucket + "/" + url.PathEscape(sourceKey)
-	fmt.Printf("CopySource: %s\n", copySource)
-	
-	_, err = client.CopyObject(ctx, &s3.CopyObjectInput{
-		Bucket:     aws.String(destBucket),
-		CopySource: aws.String(copySource),
-		Key:        aws.String(destKey),
+	log.Debugf("CopySource: %s", copySource)
+
+	redirect, err := m.websiteRedirect(ctx, sourceBucket, sourceKey)
+	if err != nil {
+		return err
+	}
+	copyResp, err := client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:                  aws.String(destBucket),
+		CopySource:              aws.String(copySource),
+		Key:                     aws.String(destKey),
+		WebsiteRedirectLocation: redirect,
 	})
 	if err != nil {
-		fmt.Printf("ERROR: CopyObject failed: %v\n", err)
+		log.Errorf("ERROR: CopyObject failed for %s: %v", sourceKey, err)
+		return err
+	}
+	written := writeChecksums{}
+	if result := copyResp.CopyObjectResult; result != nil {
+		written = writeChecksums{
+			ETag:   aws.ToString(result.ETag),
+			CRC32:  aws.ToString(result.ChecksumCRC32),
+			CRC32C: aws.ToString(result.ChecksumCRC32C),
+			SHA1:   aws.ToString(result.ChecksumSHA1),
+			SHA256: aws.ToString(result.ChecksumSHA256),
+		}
 	}
-	return err
+	return m.verifyWrite(ctx, m.metadataClient(), destBucket, destKey, objectSize, written)
+}
+
+// destinationPool creates the connection pool of a destination with its own
+// credentials (nil when the destination is reached with the source's)
+func (m *EnhancedMigrator) destinationPool(ctx context.Context, input MigrateInput) (*pool.ConnectionPool, error) {
+	if input.DestCredentialsProvider == nil && (input.DestAccessKey == "" || input.DestSecretKey == "") {
+		return nil, nil
+	}
+	fmt.Println("Creating separate S3 client for destination (cross-account copy)")
+	destConnPool, err := pool.NewConnectionPool(ctx, pool.ConnectionPoolConfig{
+		Size:                m.config.ConnectionPoolSize * 2, // OPTIMIZATION: Double pool size for destination
+		Region:              input.DestRegion,
+		EndpointURL:         input.DestEndpointURL,
+		MaxRetries:          5,                // OPTIMIZATION: Increase retries for reliability
+		Timeout:             15 * time.Second, // OPTIMIZATION: Reduce timeout for faster failure detection
+		AccessKey:           input.DestAccessKey,
+		SecretKey:           input.DestSecretKey,
+		CredentialsProvider: input.DestCredentialsProvider,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create destination connection pool: %w", err)
+	}
+	return destConnPool, nil
 }
 
 // crossAccountCopy performs cross-account copy using GetObject + PutObject with streaming integrity verification
-func (m *EnhancedMigrator) crossAccountCopy(ctx context.Context, sourceClient, destClient *s3.Client, sourceBucket, sourceKey, destBucket, destKey string, objectSize int64) error {
+func (m *EnhancedMigrator) crossAccountCopy(ctx context.Context, log logging.ObjectLogger, sourceClient, destClient *s3.Client, sourceBucket, sourceKey, destBucket, destKey string, objectSize int64) error {
 	// OPTIMIZATION: Skip HeadObject for small objects to reduce API calls
 	// For 100KB objects, we can get ETag from GetObject response
 	var sourceETag string
@@ -692,7 +1200,7 @@ func (m *EnhancedMigrator) crossAccountCopy(ctx context.Context, sourceClient, d
 		// Get ETag from GetObject response instead of separate HeadObject call
 	} else {
 		// Only use HeadObject for larger objects where we need metadata
-		sourceHead, err := sourceClient.HeadObject(ctx, &s3.HeadObjectInput{
+		sourceHead, err := m.headObject(ctx, m.metadataClient(), &s3.HeadObjectInput{
 			Bucket: aws.String(sourceBucket),
 			Key:    aws.String(sourceKey),
 		})
@@ -701,80 +1209,106 @@ func (m *EnhancedMigrator) crossAccountCopy(ctx context.Context, sourceClient, d
 		}
 		sourceETag = aws.ToString(sourceHead.ETag)
 	}
-	
+
 	// Get object from source with optimized settings
-	getResp, err := sourceClient.GetObject(ctx, &s3.GetObjectInput{
+	getInput := &s3.GetObjectInput{
 		Bucket: aws.String(sourceBucket),
 		Key:    aws.String(sourceKey),
 		// OPTIMIZATION: Add range request optimization for small objects
 		// Range: aws.String("bytes=0-"), // Could be used for partial downloads if needed
 		// OPTIMIZATION: Add connection reuse hints
 		// RequestPayer: aws.String("requester"), // Uncomment if using requester pays
-	})
+	}
+	// The copy from an earlier sync is intact: only download a changed source
+	if previous, ok := m.syncStates.unchangedCopy(ctx, destClient, sourceKey, destBucket, destKey); ok {
+		getInput.IfNoneMatch = aws.String(previous.SourceETag)
+		getInput.IfModifiedSince = aws.Time(previous.SourceLastModified)
+	}
+	getResp, err := sourceClient.GetObject(ctx, getInput)
+	if isNotModified(err) {
+		log.Debugf("[CROSS-ACCOUNT] %s not modified since the last sync", sourceKey)
+		m.syncStates.notModified.Add(1)
+		return errNotModified
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get object from source: %w", err)
 	}
 	defer getResp.Body.Close()
-	
+
 	// OPTIMIZATION: Get ETag from GetObject response for small objects
 	if sourceETag == "" && getResp.ETag != nil {
 		sourceETag = aws.ToString(getResp.ETag)
 	}
-	
+
 	// CRITICAL: Use streaming with integrity verification
 	// Calculate hashes as data flows through (no buffering!)
 	var bodyReader io.Reader = getResp.Body
 	var hasher *integrity.StreamingHasher
 	var hashes *integrity.StreamingHashes
-	
+
 	if m.config.EnableIntegrity && m.integrityManager != nil {
-		// OPTIMIZATION: Reduce logging overhead for small objects
-		if objectSize > 1024*1024 { // Only log for objects > 1MB
-			fmt.Printf("[INTEGRITY] Enabling streaming integrity verification\n")
-		}
+		log.Debugf("[INTEGRITY] Enabling streaming integrity verification")
 		hasher = integrity.NewStreamingHasher()
 		// TeeReader: data flows to BOTH hasher AND destination
 		bodyReader = io.TeeReader(getResp.Body, hasher)
 	}
-	
-	// OPTIMIZATION: Reduce logging for small objects to improve performance
-	if objectSize > 1024*1024 { // Only log for objects > 1MB
-		fmt.Printf("[CROSS-ACCOUNT] Streaming to destination (no buffering): %s/%s\n", destBucket, destKey)
+
+	// Report bytes as they stream for large objects
+	if objectSize >= inFlightReportMinSize {
+		bodyReader = &progressReader{reader: bodyReader, tracker: m.inflight, key: sourceKey}
 	}
-	
+
+	log.Debugf("[CROSS-ACCOUNT] Streaming to destination (no buffering): %s/%s", destBucket, destKey)
+
 	// Put object to destination with optimized settings
 	putInput := &s3.PutObjectInput{
 		Bucket:        aws.String(destBucket),
 		Key:           aws.String(destKey),
 		Body:          bodyReader, // Stream with hash calculation!
 		ContentLength: aws.Int64(objectSize),
+		// S3 does not carry the website redirect over on its own
+		WebsiteRedirectLocation: getResp.WebsiteRedirectLocation,
 		// OPTIMIZATION: Add performance optimizations
 		// ServerSideEncryption: aws.String("AES256"), // Uncomment if encryption needed
 		// StorageClass: aws.String("STANDARD"), // Optimize storage class
 	}
-	
-	// OPTIMIZATION: Reduce logging overhead
-	if objectSize > 1024*1024 { // Only log for objects > 1MB
-		fmt.Printf("[CROSS-ACCOUNT] PutObject request: Bucket=%s, Key=%s, Size=%d\n", destBucket, destKey, objectSize)
-	}
-	
-	putResp, err := destClient.PutObject(ctx, putInput)
+
+	log.Debugf("[CROSS-ACCOUNT] PutObject request: Bucket=%s, Key=%s, Size=%d", destBucket, destKey, objectSize)
+
+	// The body streams from the source and cannot be rewound to hash the payload,
+	// which signing requests to plain-HTTP endpoints would otherwise need
+	putResp, err := destClient.PutObject(ctx, putInput, s3.WithAPIOptions(v4.SwapComputePayloadSHA256ForUnsignedPayloadMiddleware))
 	if err != nil {
-		// OPTIMIZATION: Only log errors for large objects or always log errors
-		fmt.Printf("[CROSS-ACCOUNT] ❌ PutObject FAILED: %v\n", err)
+		log.Errorf("[CROSS-ACCOUNT] ❌ PutObject FAILED for %s: %v", destKey, err)
 		return fmt.Errorf("failed to put object to destination: %w", err)
 	}
-	
+
 	destETag := aws.ToString(putResp.ETag)
-	
+
+	if err := m.verifyWrite(ctx, destClient, destBucket, destKey, objectSize, writeChecksums{
+		ETag:   destETag,
+		CRC32:  aws.ToString(putResp.ChecksumCRC32),
+		CRC32C: aws.ToString(putResp.ChecksumCRC32C),
+		SHA1:   aws.ToString(putResp.ChecksumSHA1),
+		SHA256: aws.ToString(putResp.ChecksumSHA256),
+	}); err != nil {
+		log.Errorf("[CROSS-ACCOUNT] ❌ %v", err)
+		return err
+	}
+	m.syncStates.record(sourceKey, pkgSync.ObjectSyncState{
+		SourceETag:         aws.ToString(getResp.ETag),
+		SourceLastModified: aws.ToTime(getResp.LastModified),
+		DestETag:           destETag,
+	})
+
 	// OPTIMIZATION: Batch integrity verification for small objects
 	if m.config.EnableIntegrity && m.integrityManager != nil && hasher != nil {
 		hashes = hasher.GetHashes()
-		
-		// Detect providers (cache this to avoid repeated calls)
+
+		// The comparison follows the provider pair's ETag compatibility
 		sourceProvider := integrity.DetectProvider(m.config.EndpointURL)
-		destProvider := integrity.DetectProvider(m.config.EndpointURL) // Same for cross-account
-		
+		destProvider := m.destProvider
+
 		// Create integrity result
 		result := integrity.CreateIntegrityResult(
 			sourceETag, destETag,
@@ -782,81 +1316,98 @@ func (m *EnhancedMigrator) crossAccountCopy(ctx context.Context, sourceClient, d
 			objectSize,
 			sourceProvider, destProvider,
 		)
-		
-		// OPTIMIZATION: Async database storage for small objects to reduce blocking
-		go func() {
-			err := m.integrityManager.StoreIntegrityResult(
-				m.config.TaskID, sourceKey,
-				result,
-				string(sourceProvider), string(destProvider),
-			)
-			if err != nil {
-				// Only log errors, not success for small objects
-				if objectSize > 1024*1024 { // Only log for objects > 1MB
-					fmt.Printf("[INTEGRITY] ⚠️ Failed to store integrity result: %v\n", err)
-				}
-			}
-		}()
-		
-		// OPTIMIZATION: Reduce logging for small objects
-		if objectSize > 1024*1024 { // Only log for objects > 1MB
-			if result.IsValid {
-				fmt.Printf("[INTEGRITY] ✅ Verified: %s (MD5: %s, Size: %d bytes)\n", sourceKey, hashes.MD5, hashes.Size)
-			} else {
-				fmt.Printf("[INTEGRITY] ❌ FAILED: %s - %s\n", sourceKey, result.ErrorMessage)
+
+		// Buffered for a batched write; blocks while the database falls behind
+		if m.journal != nil {
+			record := state.NewIntegrityRecord(m.config.TaskID, sourceKey, result, string(sourceProvider), string(destProvider))
+			if err := m.journal.Record(ctx, record); err != nil {
+				log.Errorf("[INTEGRITY] ⚠️ Failed to store integrity result for %s: %v", sourceKey, err)
 			}
 		}
+
+		if result.IsValid {
+			log.Debugf("[INTEGRITY] ✅ Verified: %s (MD5: %s, Size: %d bytes)", sourceKey, hashes.MD5, hashes.Size)
+		} else {
+			log.Errorf("[INTEGRITY] ❌ FAILED: %s - %s", sourceKey, result.ErrorMessage)
+		}
 	}
-	
-	fmt.Printf("[CROSS-ACCOUNT] Successfully copied to destination\n")
+
+	log.Debugf("[CROSS-ACCOUNT] Successfully copied to destination")
 	return nil
 }
 
+// closeJournal stores the integrity records still buffered at the end of a Migrate call
+func (m *EnhancedMigrator) closeJournal() {
+	if err := m.journal.Close(); err != nil {
+		fmt.Printf("⚠️  %d of %d integrity records were not stored: %v\n", m.journal.Failed(), m.journal.Failed()+m.journal.Written(), err)
+	}
+}
+
 // multipartCopy performs a multipart copy for large objects
 func (m *EnhancedMigrator) multipartCopy(ctx context.Context, client *s3.Client, sourceBucket, sourceKey, destBucket, destKey string, objectSize int64, destClient *s3.Client) error {
+	// Size parts before starting so an object that cannot fit the part limit fails cleanly
+	settings := m.multipart
+	if settings.PartSizeBytes == 0 {
+		settings = config.DefaultMultipartSettings()
+	}
+	partSize, numParts, err := settings.PartSize(objectSize)
+	if err != nil {
+		return err
+	}
+	if partSize != settings.PartSizeBytes {
+		m.logger.Infof("Raised part size for %s to %.0f MB to stay within %d parts",
+			sourceKey, float64(partSize)/1024/1024, settings.MaxParts)
+	}
+
 	// Initiate multipart upload
+	redirect, err := m.websiteRedirect(ctx, sourceBucket, sourceKey)
+	if err != nil {
+		return err
+	}
 	createResp, err := client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
-		Bucket: aws.String(destBucket),
-		Key:    aws.String(destKey),
+		Bucket:                  aws.String(destBucket),
+		Key:                     aws.String(destKey),
+		WebsiteRedirectLocation: redirect,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to initiate multipart upload: %w", err)
 	}
-	
+
 	uploadID := createResp.UploadId
-	
-	// Calculate part size (100MB per part, minimum 5MB for S3)
-	partSize := int64(100 * 1024 * 1024) // 100MB
-	numParts := (objectSize + partSize - 1) / partSize
-	
-	fmt.Printf("Starting multipart copy for %s (%d parts, %.2f MB each)\n", 
+
+	m.logger.Infof("Starting multipart copy for %s (%d parts, %.2f MB each)",
 		sourceKey, numParts, float64(partSize)/1024/1024)
-	
+	m.inflight.setParts(sourceKey, int(numParts))
+
 	var completedParts []types.CompletedPart
 	var mu sync.Mutex
 	var copyErr error
-	
-	// Copy parts concurrently (limit to 5 concurrent parts)
-	semaphore := make(chan struct{}, 5)
+
+	// Copy parts concurrently (limit per object, default 5 concurrent parts)
+	partConcurrency := settings.PartConcurrency
+	if partConcurrency <= 0 {
+		partConcurrency = config.DefaultPartConcurrency
+	}
+	semaphore := make(chan struct{}, partConcurrency)
 	var wg sync.WaitGroup
-	
+
 	for partNum := int32(1); partNum <= int32(numParts); partNum++ {
 		wg.Add(1)
 		go func(partNumber int32) {
 			defer wg.Done()
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
-			
+
 			// Calculate byte range for this part
 			startByte := int64(partNumber-1) * partSize
 			endByte := startByte + partSize - 1
 			if endByte >= objectSize {
 				endByte = objectSize - 1
 			}
-			
+
 			// URL-encode the source key for the copy source
 			copySource := sourceBucket + "/" + url.PathEscape(sourceKey)
-			
+
 			copyPartResp, err := client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
 				Bucket:          aws.String(destBucket),
 				Key:             aws.String(destKey),
@@ -865,7 +1416,7 @@ func (m *EnhancedMigrator) multipartCopy(ctx context.Context, client *s3.Client,
 				UploadId:        uploadID,
 				CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", startByte, endByte)),
 			})
-			
+
 			if err != nil {
 				mu.Lock()
 				if copyErr == nil {
@@ -874,18 +1425,19 @@ func (m *EnhancedMigrator) multipartCopy(ctx context.Context, client *s3.Client,
 				mu.Unlock()
 				return
 			}
-			
+
 			mu.Lock()
 			completedParts = append(completedParts, types.CompletedPart{
 				ETag:       copyPartResp.CopyPartResult.ETag,
 				PartNumber: aws.Int32(partNumber),
 			})
 			mu.Unlock()
+			m.inflight.add(sourceKey, endByte-startByte+1, true)
 		}(partNum)
 	}
-	
+
 	wg.Wait()
-	
+
 	// If any part failed, abort the multipart upload
 	if copyErr != nil {
 		_, _ = client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
@@ -895,14 +1447,14 @@ func (m *EnhancedMigrator) multipartCopy(ctx context.Context, client *s3.Client,
 		})
 		return copyErr
 	}
-	
+
 	// Sort completed parts by part number
 	sort.Slice(completedParts, func(i, j int) bool {
 		return *completedParts[i].PartNumber < *completedParts[j].PartNumber
 	})
-	
+
 	// Complete the multipart upload
-	_, err = client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+	completeResp, err := client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
 		Bucket:   aws.String(destBucket),
 		Key:      aws.String(destKey),
 		UploadId: uploadID,
@@ -910,12 +1462,28 @@ func (m *EnhancedMigrator) multipartCopy(ctx context.Context, client *s3.Client,
 			Parts: completedParts,
 		},
 	})
-	
+
 	if err != nil {
+		// Parts of an upload that cannot complete (e.g. a refused overwrite) would stay billed
+		_, _ = client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(destBucket),
+			Key:      aws.String(destKey),
+			UploadId: uploadID,
+		})
 		return fmt.Errorf("failed to complete multipart upload: %w", err)
 	}
-	
-	fmt.Printf("Successfully completed multipart copy for %s\n", sourceKey)
+
+	if err := m.verifyWrite(ctx, m.metadataClient(), destBucket, destKey, objectSize, writeChecksums{
+		ETag:   aws.ToString(completeResp.ETag),
+		CRC32:  aws.ToString(completeResp.ChecksumCRC32),
+		CRC32C: aws.ToString(completeResp.ChecksumCRC32C),
+		SHA1:   aws.ToString(completeResp.ChecksumSHA1),
+		SHA256: aws.ToString(completeResp.ChecksumSHA256),
+	}); err != nil {
+		return err
+	}
+
+	m.logger.Infof("Successfully completed multipart copy for %s", sourceKey)
 	return nil
 }
 
@@ -924,16 +1492,16 @@ func (m *EnhancedMigrator) listObjectsWithCache(ctx context.Context, bucket, pre
 	fmt.Printf("\n=== LISTING OBJECTS ===\n")
 	fmt.Printf("Bucket: %s\n", bucket)
 	fmt.Printf("Prefix: '%s'\n", prefix)
-	
+
 	// Use provided client or default to source client
 	var s3Client *s3.Client
 	if len(client) > 0 && client[0] != nil {
 		s3Client = client[0]
 		fmt.Println("Using provided custom client (likely for destination)")
 	} else {
-		s3Client = m.connPool.GetClient()
+		s3Client = m.metadataClient()
 	}
-	
+
 	// For S3-compatible storage (CMC), use ListObjects v1 API which has better pagination support
 	// ListObjectsV2 on CMC has issues with ContinuationToken
 	fmt.Println("Using ListObjects v1 API for better S3-compatible storage support")
@@ -943,103 +1511,148 @@ func (m *EnhancedMigrator) listObjectsWithCache(ctx context.Context, bucket, pre
 // listObjectsV1 uses the older ListObjects API which works better with S3-compatible storage
 func (m *EnhancedMigrator) listObjectsV1(ctx context.Context, s3Client *s3.Client, bucket, prefix string) ([]objectInfo, error) {
 	var objects []objectInfo
-	var marker *string
-	pageCount := 0
+	pages := m.newObjectPager(s3Client, bucket, prefix)
 	maxPages := 1000 // Safety limit
 
 	for {
-		pageCount++
-		
-		if pageCount > maxPages {
+		if pages.count >= maxPages {
 			fmt.Printf("WARNING: Reached maximum page limit (%d).\n", maxPages)
 			break
 		}
-		
+
+		page, err := pages.next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			break
+		}
+		objects = append(objects, page...)
+	}
+
+	fmt.Printf("Total objects found: %d (across %d pages)\n", len(objects), pages.count)
+	fmt.Printf("======================\n\n")
+	return objects, nil
+}
+
+// objectPager lists a bucket one ListObjects page at a time, in key order
+type objectPager struct {
+	m      *EnhancedMigrator
+	client *s3.Client
+	bucket string
+	prefix string
+	marker *string
+	count  int // Pages listed so far
+	done   bool
+}
+
+func (m *EnhancedMigrator) newObjectPager(client *s3.Client, bucket, prefix string) *objectPager {
+	return &objectPager{m: m, client: client, bucket: bucket, prefix: prefix}
+}
+
+// next returns the next page of objects, or nil once the listing is done
+func (p *objectPager) next(ctx context.Context) ([]objectInfo, error) {
+	for !p.done {
+		// Operators can pause listing on a live task via the admin tuning endpoint
+		if err := p.m.live.waitListing(ctx); err != nil {
+			return nil, err
+		}
+
 		input := &s3.ListObjectsInput{
-			Bucket:  aws.String(bucket),
+			Bucket:  aws.String(p.bucket),
 			MaxKeys: aws.Int32(1000),
 		}
-		
-		if prefix != "" {
-			input.Prefix = aws.String(prefix)
+
+		if p.prefix != "" {
+			input.Prefix = aws.String(p.prefix)
 		}
-		
-		if marker != nil {
-			input.Marker = marker
-			if pageCount <= 3 {
-				fmt.Printf("Page %d: Using Marker: %s\n", pageCount, *marker)
+
+		p.count++
+		if p.marker != nil {
+			input.Marker = p.marker
+			if p.count <= 3 {
+				p.m.logger.Debugf("Page %d: Using Marker: %s", p.count, *p.marker)
 			}
 		}
 
-		result, err := s3Client.ListObjects(ctx, input)
+		result, err := p.client.ListObjects(ctx, input)
 		if err != nil {
 			fmt.Printf("ERROR listing objects: %v\n", err)
 			return nil, err
 		}
 
 		objectsInPage := len(result.Contents)
-		fmt.Printf("Page %d: Found %d objects (IsTruncated: %v)\n", pageCount, objectsInPage, aws.ToBool(result.IsTruncated))
+		p.m.logger.Debugf("Page %d: Found %d objects (IsTruncated: %v)", p.count, objectsInPage, aws.ToBool(result.IsTruncated))
+		p.m.live.addListed(objectsInPage)
 
-	for _, obj := range result.Contents {
-		lastModified := time.Time{}
-		if obj.LastModified != nil {
-			lastModified = *obj.LastModified
+		p.marker = nextMarker(result)
+		p.done = p.marker == nil
+		if objectsInPage == 0 {
+			continue
 		}
-		objects = append(objects, objectInfo{
-			Key:          *obj.Key,
-			Size:         *obj.Size,
-			LastModified: lastModified,
-		})
+
+		page := make([]objectInfo, 0, objectsInPage)
+		for _, obj := range result.Contents {
+			lastModified := time.Time{}
+			if obj.LastModified != nil {
+				lastModified = *obj.LastModified
+			}
+			page = append(page, objectInfo{
+				Key:          *obj.Key,
+				Size:         *obj.Size,
+				LastModified: lastModified,
+				ETag:         aws.ToString(obj.ETag),
+			})
+		}
+		return page, nil
 	}
+	return nil, nil
+}
 
+// nextMarker returns the marker for the page after result, or nil when the listing is done.
+// Falls back to the last key when NextMarker is not provided, as on CMC and other S3-compatible storage.
+func nextMarker(result *s3.ListObjectsOutput) *string {
 	if !aws.ToBool(result.IsTruncated) {
-		break
+		return nil
 	}
-	
 	if result.NextMarker != nil {
-		marker = result.NextMarker
-	} else if len(result.Contents) > 0 {
-		// Use last key as marker if NextMarker not provided
-		marker = result.Contents[len(result.Contents)-1].Key
-	} else {
-		break
+		return result.NextMarker
 	}
+	if len(result.Contents) > 0 {
+		return result.Contents[len(result.Contents)-1].Key
 	}
-
-	fmt.Printf("Total objects found: %d (across %d pages)\n", len(objects), pageCount)
-	fmt.Printf("======================\n\n")
-	return objects, nil
+	return nil
 }
 
 // listObjectsV2Old is the old ListObjectsV2 implementation (kept for reference)
 func (m *EnhancedMigrator) listObjectsV2Old(ctx context.Context, s3Client *s3.Client, bucket, prefix string) ([]objectInfo, error) {
-	
+
 	var objects []objectInfo
 	var continuationToken *string
-	var lastKey *string // Track last key for StartAfter fallback
+	var lastKey *string         // Track last key for StartAfter fallback
 	var previousLastKey *string // Track previous last key to detect loops
 	pageCount := 0
 	maxPages := 1000 // Safety limit to prevent infinite loops
 
 	for {
 		pageCount++
-		
+
 		// Safety check: prevent infinite loops
 		if pageCount > maxPages {
 			fmt.Printf("WARNING: Reached maximum page limit (%d). Breaking to prevent infinite loop.\n", maxPages)
 			break
 		}
-		
+
 		input := &s3.ListObjectsV2Input{
 			Bucket:  aws.String(bucket),
 			MaxKeys: aws.Int32(1000),
 		}
-		
+
 		// Only set prefix if it's not empty
 		if prefix != "" {
 			input.Prefix = aws.String(prefix)
 		}
-		
+
 		// Debug: Show request parameters for first page
 		if pageCount == 1 {
 			fmt.Printf("  === S3 REQUEST DEBUG ===\n")
@@ -1054,7 +1667,7 @@ func (m *EnhancedMigrator) listObjectsV2Old(ctx context.Context, s3Client *s3.Cl
 			}
 			fmt.Printf("  === END S3 REQUEST DEBUG ===\n")
 		}
-		
+
 		// Use ContinuationToken if available
 		if continuationToken != nil {
 			input.ContinuationToken = continuationToken
@@ -1071,8 +1684,8 @@ func (m *EnhancedMigrator) listObjectsV2Old(ctx context.Context, s3Client *s3.Cl
 		}
 
 		objectsInPage := len(result.Contents)
-		fmt.Printf("Page %d: Found %d objects (IsTruncated: %v)\n", pageCount, objectsInPage, aws.ToBool(result.IsTruncated))
-		
+		m.logger.Debugf("Page %d: Found %d objects (IsTruncated: %v)", pageCount, objectsInPage, aws.ToBool(result.IsTruncated))
+
 		// Debug: Show detailed information about what we're getting
 		if pageCount <= 3 {
 			fmt.Printf("  === DEBUG PAGE %d ===\n", pageCount)
@@ -1081,7 +1694,7 @@ func (m *EnhancedMigrator) listObjectsV2Old(ctx context.Context, s3Client *s3.Cl
 			if result.NextContinuationToken != nil {
 				fmt.Printf("  NextContinuationToken: %s\n", *result.NextContinuationToken)
 			}
-			
+
 			fmt.Printf("  Sample objects from page %d:\n", pageCount)
 			for i, obj := range result.Contents {
 				if i < 5 { // Show first 5 keys
@@ -1095,20 +1708,20 @@ func (m *EnhancedMigrator) listObjectsV2Old(ctx context.Context, s3Client *s3.Cl
 			fmt.Printf("  === END DEBUG PAGE %d ===\n", pageCount)
 		}
 
-	for _, obj := range result.Contents {
-		lastModified := time.Time{}
-		if obj.LastModified != nil {
-			lastModified = *obj.LastModified
+		for _, obj := range result.Contents {
+			lastModified := time.Time{}
+			if obj.LastModified != nil {
+				lastModified = *obj.LastModified
+			}
+			objects = append(objects, objectInfo{
+				Key:          *obj.Key,
+				Size:         *obj.Size,
+				LastModified: lastModified,
+			})
+			// Track the last key for StartAfter fallback
+			lastKey = obj.Key
 		}
-		objects = append(objects, objectInfo{
-			Key:          *obj.Key,
-			Size:         *obj.Size,
-			LastModified: lastModified,
-		})
-		// Track the last key for StartAfter fallback
-		lastKey = obj.Key
-	}
-		
+
 		// Safety check: detect if we're getting the same last key repeatedly (infinite loop)
 		if previousLastKey != nil && lastKey != nil && *previousLastKey == *lastKey {
 			fmt.Printf("\n")
@@ -1134,34 +1747,34 @@ func (m *EnhancedMigrator) listObjectsV2Old(ctx context.Context, s3Client *s3.Cl
 		hasNextToken := result.NextContinuationToken != nil
 		gotFullPage := len(result.Contents) == 1000
 		hasMore := aws.ToBool(result.IsTruncated) || (hasNextToken && gotFullPage) || (!hasNextToken && gotFullPage)
-		
+
 		if !hasMore {
-			fmt.Printf("No more pages: IsTruncated=%v, NextToken=%v, ObjectsInPage=%d\n", 
-				aws.ToBool(result.IsTruncated), 
+			fmt.Printf("No more pages: IsTruncated=%v, NextToken=%v, ObjectsInPage=%d\n",
+				aws.ToBool(result.IsTruncated),
 				hasNextToken,
 				len(result.Contents))
 			break
 		}
-		
+
 		// Use NextContinuationToken if available, otherwise we'll use StartAfter in next iteration
 		if result.NextContinuationToken != nil {
 			continuationToken = result.NextContinuationToken
 		} else if gotFullPage {
 			// CMC doesn't provide NextContinuationToken, but we got a full page
 			// Use StartAfter with the last key
-			fmt.Printf("No NextContinuationToken but got full page (%d objects). Will use StartAfter with last key.\n", gotFullPage)
+			fmt.Printf("No NextContinuationToken but got full page (%d objects). Will use StartAfter with last key.\n", len(result.Contents))
 			continuationToken = nil // Clear it so StartAfter will be used
 		} else {
 			// Got less than full page and no token, we're done
 			break
 		}
-		
+
 		// Safety check: prevent same token being used repeatedly
 		if continuationToken != nil && result.NextContinuationToken != nil && *continuationToken == *result.NextContinuationToken {
 			fmt.Printf("WARNING: NextContinuationToken is same as previous token. Breaking to prevent infinite loop.\n")
 			break
 		}
-		
+
 		continuationToken = result.NextContinuationToken
 	}
 
@@ -1173,32 +1786,18 @@ func (m *EnhancedMigrator) listObjectsV2Old(ctx context.Context, s3Client *s3.Cl
 // ensureDestinationBucketExists creates the destination bucket if it doesn't exist
 func (m *EnhancedMigrator) ensureDestinationBucketExists(ctx context.Context, bucketName, region string, destClient *s3.Client) error {
 	// Use destClient if provided (cross-account), otherwise use source client
-	client := m.connPool.GetClient()
+	client := m.metadataClient()
 	if destClient != nil {
 		client = destClient
 		fmt.Println("Using destination credentials to check/create bucket")
 	}
-	
-	// Check if bucket exists
-	_, err := client.HeadBucket(ctx, &s3.HeadBucketInput{
-		Bucket: aws.String(bucketName),
-	})
-	
-	if err == nil {
-		// Bucket already exists
-		fmt.Printf("Destination bucket '%s' already exists\n", bucketName)
-		return nil
-	}
-	
-	// Bucket doesn't exist, create it
-	fmt.Printf("Creating destination bucket: %s\n", bucketName)
-	
+
 	// For custom S3 providers (MinIO, etc.), don't use LocationConstraint
 	// Only use it for AWS S3
 	createBucketInput := &s3.CreateBucketInput{
 		Bucket: aws.String(bucketName),
 	}
-	
+
 	// Only add LocationConstraint for AWS S3 (when region is provided and endpoint is not custom)
 	if region != "" && m.config.EndpointURL == "" {
 		// For AWS, us-east-1 doesn't need LocationConstraint
@@ -1211,21 +1810,17 @@ func (m *EnhancedMigrator) ensureDestinationBucketExists(ctx context.Context, bu
 	} else if m.config.EndpointURL != "" {
 		fmt.Printf("  Using custom S3 endpoint: %s\n", m.config.EndpointURL)
 	}
-	
-	_, err = client.CreateBucket(ctx, createBucketInput)
-	
+
+	// Concurrent tasks creating the same bucket wait for each other
+	created, err := buckets.Ensure(ctx, client, createBucketInput)
 	if err != nil {
-		// Check if bucket already exists - this is not an error
-		var bucketAlreadyExists *types.BucketAlreadyExists
-		var bucketAlreadyOwnedByYou *types.BucketAlreadyOwnedByYou
-		if errors.As(err, &bucketAlreadyExists) || errors.As(err, &bucketAlreadyOwnedByYou) {
-			fmt.Printf("Destination bucket '%s' already exists - continuing with migration\n", bucketName)
-			return nil
-		}
-		return fmt.Errorf("failed to create bucket '%s': %w", bucketName, err)
+		return err
+	}
+	if created {
+		fmt.Printf("Successfully created destination bucket: %s\n", bucketName)
+	} else {
+		fmt.Printf("Destination bucket '%s' already exists\n", bucketName)
 	}
-	
-	fmt.Printf("Successfully created destination bucket: %s\n", bucketName)
 	return nil
 }
 
@@ -1241,6 +1836,8 @@ func (m *EnhancedMigrator) GetClient() *s3.Client {
 
 // Close closes all resources
 func (m *EnhancedMigrator) Close() error {
+	if m.metadataPool != m.connPool {
+		m.metadataPool.Close()
+	}
 	return m.connPool.Close()
 }
-
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/pool"
+)
+
+func TestMigrateAgainstFakeEndpoint(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	endpoint.Put("source", "docs/a.txt", []byte("alpha"))
+	endpoint.Put("source", "docs/b/c.txt", []byte("charlie"))
+	endpoint.Put("source", "other.txt", []byte("not migrated"))
+
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		EnablePrefetch: true,
+		CacheTTL:       time.Minute,
+		CacheSize:      100,
+		ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := migrator.Migrate(context.Background(), MigrateInput{
+		SourceBucket:  "source",
+		SourcePrefix:  "docs/",
+		DestBucket:    "dest",
+		DestPrefix:    "backup",
+		MigrationMode: ModeFullRewrite,
+		Timeout:       time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Copied != 2 || result.Failed != 0 {
+		t.Fatalf("copied %d, failed %d: %v", result.Copied, result.Failed, result.Errors)
+	}
+	keys := endpoint.Keys("dest")
+	if len(keys) != 2 || keys[0] != "backup/docs/a.txt" || keys[1] != "backup/docs/b/c.txt" {
+		t.Fatalf("dest keys = %v", keys)
+	}
+	if got := string(endpoint.Get("dest", "backup/docs/b/c.txt").Data); got != "charlie" {
+		t.Fatalf("backup/docs/b/c.txt = %q", got)
+	}
+}
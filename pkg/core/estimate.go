@@ -0,0 +1,155 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/models"
+)
+
+// CostRates price the copy of a migration
+type CostRates struct {
+	TransferPerGB   float64 // Data transfer out of the source, per GB
+	RequestsPer1000 float64 // Write requests on the destination, per 1000 objects
+	Currency        string
+}
+
+// Default estimate link: copying within one AWS region
+const (
+	DefaultEstimateBandwidthMbps = 1000
+	DefaultEstimateLatencyMs     = 10
+	DefaultEstimateConcurrency   = 16
+)
+
+// Sources of an estimate's link
+const (
+	LinkDefault  = "default"
+	LinkAssumed  = "assumed"
+	LinkMeasured = "measured"
+)
+
+// estimateRoundTrips are the requests per object that wait on the link latency:
+// a read from the source and a write to the destination
+const estimateRoundTrips = 2
+
+// measureObjectSizes and measureConcurrency keep the benchmark of a measured link
+// short: 64 KiB objects one at a time for the latency, 8 MiB objects in parallel
+// for the bandwidth (about 66 MiB uploaded and downloaded)
+var (
+	measureObjectSizes = []int64{64 * 1024, 8 * 1024 * 1024}
+	measureConcurrency = []int{1, 4}
+)
+
+// measureObjectsPerSize is the objects a measured link uploads per size and concurrency level
+const measureObjectsPerSize = 4
+
+// NetworkLinkFor applies the defaults to a request's network profile
+func NetworkLinkFor(opts *models.NetworkProfile) (models.NetworkLink, error) {
+	link := models.NetworkLink{
+		BandwidthMbps: DefaultEstimateBandwidthMbps,
+		LatencyMs:     DefaultEstimateLatencyMs,
+		Concurrency:   DefaultEstimateConcurrency,
+		Source:        LinkDefault,
+	}
+	if opts == nil {
+		return link, nil
+	}
+	if opts.BandwidthMbps < 0 || opts.LatencyMs < 0 || opts.Concurrency < 0 {
+		return link, fmt.Errorf("bandwidth, latency and concurrency cannot be negative")
+	}
+	if opts.BandwidthMbps > 0 {
+		link.BandwidthMbps = opts.BandwidthMbps
+		link.Source = LinkAssumed
+	}
+	if opts.LatencyMs > 0 {
+		link.LatencyMs = opts.LatencyMs
+		link.Source = LinkAssumed
+	}
+	if opts.Concurrency > 0 {
+		link.Concurrency = opts.Concurrency
+	}
+	return link, nil
+}
+
+// MeasureNetworkLink benchmarks bucket with a few synthetic objects and sets the
+// bandwidth and latency of link that opts leaves unset: the fastest upload, and
+// the median upload time of the small objects sent one at a time
+func MeasureNetworkLink(ctx context.Context, client *s3.Client, bucket string, opts *models.NetworkProfile, link models.NetworkLink) (models.NetworkLink, error) {
+	result, err := RunBenchmark(ctx, client, BenchmarkConfig{
+		Bucket:         bucket,
+		ObjectSizes:    measureObjectSizes,
+		ObjectsPerSize: measureObjectsPerSize,
+		Concurrency:    measureConcurrency,
+	})
+	if err != nil {
+		return link, fmt.Errorf("failed to measure the link to %s: %w", bucket, err)
+	}
+	var bandwidthMBps, latencyMs float64
+	for _, run := range result.Runs {
+		if run.Errors > 0 {
+			continue
+		}
+		bandwidthMBps = max(bandwidthMBps, run.UploadMBps)
+		if run.ObjectSize == measureObjectSizes[0] && run.Concurrency == 1 {
+			latencyMs = run.UploadLatency.P50Ms
+		}
+	}
+	if bandwidthMBps == 0 || latencyMs == 0 {
+		return link, fmt.Errorf("failed to measure the link to %s: benchmark requests failed", bucket)
+	}
+	if opts.BandwidthMbps == 0 {
+		link.BandwidthMbps = math.Round(bandwidthMBps * 8 * 1.048576) // MiB/s to megabits/s
+	}
+	if opts.LatencyMs == 0 {
+		link.LatencyMs = max(math.Round(latencyMs*10)/10, 0.1)
+	}
+	link.Source = LinkMeasured
+	return link, nil
+}
+
+// EstimateDuration sets the time estimate takes to copy over link
+func EstimateDuration(estimate *models.MigrationEstimate, link models.NetworkLink) {
+	transfer := float64(estimate.TotalBytes) * 8 / (link.BandwidthMbps * 1e6)
+	requests := float64(estimate.Objects) * estimateRoundTrips * link.LatencyMs / 1000 / float64(link.Concurrency)
+	seconds := math.Ceil(transfer + requests)
+	estimate.EstimatedSeconds = seconds
+	estimate.EstimatedDuration = (time.Duration(seconds) * time.Second).String()
+	estimate.Network = &link
+}
+
+// EstimateMigration lists bucket/prefix and prices copying every object under it
+// with rates. Objects a run would skip (incremental mode, files_from) are
+// counted too, so the estimate is an upper bound.
+func EstimateMigration(ctx context.Context, client *s3.Client, bucket, prefix string, rates CostRates) (*models.MigrationEstimate, error) {
+	stats, err := CollectBucketStats(ctx, client, bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return PriceMigration(bucket, prefix, stats.ObjectCount, stats.TotalSize, rates), nil
+}
+
+// PriceMigration prices copying objects totalling size bytes, rounded to cents
+func PriceMigration(bucket, prefix string, objects, size int64, rates CostRates) *models.MigrationEstimate {
+	sizeGB := float64(size) / (1 << 30)
+	estimate := &models.MigrationEstimate{
+		SourceBucket: bucket,
+		SourcePrefix: prefix,
+		Objects:      objects,
+		TotalBytes:   size,
+		TotalSizeGB:  math.Round(sizeGB*100) / 100,
+		TransferCost: roundCents(sizeGB * rates.TransferPerGB),
+		RequestCost:  roundCents(float64(objects) / 1000 * rates.RequestsPer1000),
+		Currency:     rates.Currency,
+	}
+	estimate.EstimatedCost = roundCents(estimate.TransferCost + estimate.RequestCost)
+	return estimate
+}
+
+// roundCents rounds an amount to two decimals
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+)
+
+func TestEstimateDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile *models.NetworkProfile
+		objects int64
+		bytes   int64
+		want    string
+		source  string
+	}{
+		// 10 GB at 1000 Mbps is 80s; 1000 objects, 2 round trips of 10ms over 16 copies add 1.25s
+		{name: "default link", objects: 1000, bytes: 10e9, want: "1m22s", source: LinkDefault},
+		{name: "WAN bandwidth", profile: &models.NetworkProfile{BandwidthMbps: 100}, objects: 1000, bytes: 10e9, want: "13m22s", source: LinkAssumed},
+		// Many small objects over a high-latency link: 100000 * 2 * 80ms / 8
+		{name: "latency bound", profile: &models.NetworkProfile{LatencyMs: 80, Concurrency: 8}, objects: 100000, bytes: 1e6, want: "33m21s", source: LinkAssumed},
+		{name: "concurrency only", profile: &models.NetworkProfile{Concurrency: 32}, objects: 0, bytes: 0, want: "0s", source: LinkDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := NetworkLinkFor(tt.profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			estimate := PriceMigration("source", "", tt.objects, tt.bytes, CostRates{})
+			EstimateDuration(estimate, link)
+			if estimate.EstimatedDuration != tt.want || estimate.Network.Source != tt.source {
+				t.Errorf("got %s (%s), want %s (%s)", estimate.EstimatedDuration, estimate.Network.Source, tt.want, tt.source)
+			}
+		})
+	}
+
+	if _, err := NetworkLinkFor(&models.NetworkProfile{LatencyMs: -1}); err == nil {
+		t.Error("negative latency accepted")
+	}
+}
+
+func TestMeasureNetworkLink(t *testing.T) {
+	endpoint := fakes3.New("dest")
+	defer endpoint.Close()
+
+	profile := &models.NetworkProfile{BandwidthMbps: 50, Measure: true}
+	link, _ := NetworkLinkFor(profile)
+	link, err := MeasureNetworkLink(context.Background(), endpoint.Client(), "dest", profile, link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Source != LinkMeasured || link.BandwidthMbps != 50 || link.LatencyMs <= 0 || link.LatencyMs == DefaultEstimateLatencyMs {
+		t.Errorf("got %+v", link)
+	}
+	if keys := endpoint.Keys("dest"); len(keys) != 0 {
+		t.Errorf("benchmark objects left behind: %v", keys)
+	}
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/models"
+)
+
+// DefaultFailureManifestPrefix is where failure manifests go without a configured prefix
+const DefaultFailureManifestPrefix = "failure-manifests"
+
+// DefaultFailureManifestExpiry is how long the pre-signed link to a failure manifest stays valid
+const DefaultFailureManifestExpiry = 24 * time.Hour
+
+// maxPresignExpiry is the longest validity SigV4 allows a pre-signed URL
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// FailureManifestPolicy uploads the keys a run failed to copy
+type FailureManifestPolicy struct {
+	Bucket string // Empty: the destination bucket
+	Prefix string
+	Name   string // Manifest file name without extension, e.g. the task ID
+	Expiry time.Duration
+}
+
+// FailureManifestPolicyFor builds the failure manifest policy of a webhook (nil without one)
+func FailureManifestPolicyFor(opts *models.WebhookOptions, name string) (*FailureManifestPolicy, error) {
+	if opts == nil || opts.URL == "" {
+		return nil, nil
+	}
+	prefix := strings.Trim(opts.ManifestPrefix, "/")
+	if prefix == "" {
+		prefix = DefaultFailureManifestPrefix
+	}
+	for _, segment := range strings.Split(prefix, "/") {
+		if segment == ".." {
+			return nil, fmt.Errorf("manifest prefix must not contain '..' segments")
+		}
+	}
+	expiry := time.Duration(opts.URLExpiry) * time.Second
+	if opts.URLExpiry == 0 {
+		expiry = DefaultFailureManifestExpiry
+	}
+	if expiry < 0 || expiry > maxPresignExpiry {
+		return nil, fmt.Errorf("url_expiry must be between 1 and %d seconds", int(maxPresignExpiry.Seconds()))
+	}
+	return &FailureManifestPolicy{Bucket: opts.ManifestBucket, Prefix: prefix, Name: name, Expiry: expiry}, nil
+}
+
+// FailureManifest is the body of a failure manifest. FilesFrom is relative to the
+// source prefix, so posting it as files_from with the same buckets and prefixes
+// copies just the failed objects again.
+type FailureManifest struct {
+	SourceBucket string    `json:"source_bucket"`
+	SourcePrefix string    `json:"source_prefix,omitempty"`
+	DestBucket   string    `json:"dest_bucket"`
+	DestPrefix   string    `json:"dest_prefix,omitempty"`
+	GeneratedAt  time.Time `json:"generated_at"`
+	Truncated    bool      `json:"truncated"` // More objects failed than were recorded
+	FilesFrom    []string  `json:"files_from"`
+}
+
+// writeFailureManifest uploads the failed keys of a run and pre-signs a GET URL for them
+func (m *EnhancedMigrator) writeFailureManifest(ctx context.Context, input MigrateInput, destClient *s3.Client, transfers *TransferManifest) (*models.FailureManifestLink, error) {
+	policy := input.FailureManifest
+	manifest := FailureManifest{
+		SourceBucket: input.SourceBucket,
+		SourcePrefix: input.SourcePrefix,
+		DestBucket:   input.DestBucket,
+		DestPrefix:   input.DestPrefix,
+		GeneratedAt:  time.Now().UTC(),
+		Truncated:    transfers.Truncated,
+		FilesFrom:    make([]string, 0, len(transfers.Failed)),
+	}
+	for _, key := range transfers.Failed {
+		if file := relativeKey(key, input.SourcePrefix); file != "" {
+			manifest.FilesFrom = append(manifest.FilesFrom, file)
+		}
+	}
+	body, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	client := destClient
+	if client == nil {
+		client = m.metadataClient()
+	}
+	bucket := policy.Bucket
+	if bucket == "" {
+		bucket = input.DestBucket
+	}
+	key := path.Join(policy.Prefix, policy.Name+".json")
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write failure manifest %s: %w", key, err)
+	}
+
+	signed, err := s3.NewPresignClient(client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(policy.Expiry))
+	if err != nil {
+		return nil, fmt.Errorf("failed to pre-sign failure manifest %s: %w", key, err)
+	}
+	return &models.FailureManifestLink{
+		Bucket:    bucket,
+		Key:       key,
+		URL:       signed.URL,
+		ExpiresAt: time.Now().Add(policy.Expiry).UTC(),
+		Objects:   len(manifest.FilesFrom),
+		Truncated: transfers.Truncated,
+	}, nil
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/pool"
+)
+
+// FolderMarkerMode decides what happens to zero-byte "folder" placeholder keys (ending in "/")
+type FolderMarkerMode string
+
+const (
+	// FolderMarkersCopy copies markers like any other object (the default)
+	FolderMarkersCopy FolderMarkerMode = "copy"
+	// FolderMarkersRecreate writes each marker on the destination as an empty
+	// object, so empty folders survive even on providers that refuse to copy them
+	FolderMarkersRecreate FolderMarkerMode = "recreate"
+	// FolderMarkersSkip leaves markers out
+	FolderMarkersSkip FolderMarkerMode = "skip"
+)
+
+// folderMarkerContentType is what S3 consoles and s3fs-style tools write for folders
+const folderMarkerContentType = "application/x-directory"
+
+// folderMarkerWorkers is how many markers are written at once
+const folderMarkerWorkers = 16
+
+// ValidateFolderMarkerMode checks a folder marker mode is known (empty is copy)
+func ValidateFolderMarkerMode(mode FolderMarkerMode) error {
+	switch mode {
+	case "", FolderMarkersCopy, FolderMarkersRecreate, FolderMarkersSkip:
+		return nil
+	default:
+		return fmt.Errorf("unknown folder_markers %q (expected copy, recreate or skip)", mode)
+	}
+}
+
+// isFolderMarker reports whether obj is an empty placeholder for a folder
+func isFolderMarker(obj objectInfo) bool {
+	return obj.Size == 0 && strings.HasSuffix(obj.Key, "/")
+}
+
+// splitFolderMarkers separates the folder markers from the other objects
+func splitFolderMarkers(objects []objectInfo) ([]objectInfo, []objectInfo) {
+	kept := make([]objectInfo, 0, len(objects))
+	var markers []objectInfo
+	for _, obj := range objects {
+		if isFolderMarker(obj) {
+			markers = append(markers, obj)
+		} else {
+			kept = append(kept, obj)
+		}
+	}
+	return kept, markers
+}
+
+// recreateFolderMarkers writes an empty object for each marker at its destination
+// key; writing a marker again is harmless, so existing ones are not checked first.
+// With NeverOverwrite, existing keys are left as they are and not counted.
+// Returns the markers written and an error per marker that could not be.
+func (m *EnhancedMigrator) recreateFolderMarkers(ctx context.Context, input MigrateInput, destClient *s3.Client, markers []objectInfo) (int64, []string) {
+	client := destClient
+	if client == nil {
+		client = m.connPool.GetClient()
+	}
+
+	if input.NeverOverwrite != "" {
+		ctx = pool.WriteOnce(ctx)
+	}
+
+	var mu sync.Mutex
+	var created int64
+	var errs []string
+	queue := make(chan objectInfo)
+	var wg sync.WaitGroup
+	for i := 0; i < min(folderMarkerWorkers, len(markers)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for marker := range queue {
+				key := destKeyForObject(marker, input.DestPrefix, input.Partition, input.KeySanitize)
+				_, err := client.PutObject(ctx, &s3.PutObjectInput{
+					Bucket:      aws.String(input.DestBucket),
+					Key:         aws.String(key),
+					Body:        bytes.NewReader(nil),
+					ContentType: aws.String(folderMarkerContentType),
+				})
+				mu.Lock()
+				if pool.IsPreconditionFailed(err) && input.NeverOverwrite != "" {
+					// Already there
+				} else if err != nil {
+					errs = append(errs, fmt.Sprintf("Failed to recreate folder marker %s: %v", marker.Key, err))
+				} else {
+					created++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, marker := range markers {
+		if m.stopRequested.Load() {
+			break
+		}
+		queue <- marker
+	}
+	close(queue)
+	wg.Wait()
+	return created, errs
+}
+
+// folderMarkersVerified describes the folder markers of a run for its verification list
+func folderMarkersVerified(dryRun bool, created int64, found int) string {
+	if dryRun {
+		return fmt.Sprintf("Would recreate %d folder markers", found)
+	}
+	return fmt.Sprintf("Recreated %d of %d folder markers", created, found)
+}
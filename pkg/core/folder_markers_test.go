@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/pool"
+)
+
+func TestMigrateFolderMarkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    FolderMarkerMode
+		prefix  string
+		want    []string
+		created int64
+	}{
+		{name: "copied by default", want: []string{"docs/", "docs/a.txt", "empty/"}},
+		{name: "recreated", mode: FolderMarkersRecreate, want: []string{"docs/", "docs/a.txt", "empty/"}, created: 2},
+		{name: "recreated without objects", mode: FolderMarkersRecreate, prefix: "empty/", want: []string{"empty/"}, created: 1},
+		{name: "skipped", mode: FolderMarkersSkip, want: []string{"docs/a.txt"}},
+	}
+	for _, tt := range tests {
+		endpoint := fakes3.New("source")
+		endpoint.Put("source", "docs/", nil)
+		endpoint.Put("source", "docs/a.txt", []byte("alpha"))
+		endpoint.Put("source", "empty/", nil)
+
+		migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+			ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := migrator.Migrate(context.Background(), MigrateInput{
+			SourceBucket:  "source",
+			SourcePrefix:  tt.prefix,
+			DestBucket:    "dest",
+			MigrationMode: ModeFullRewrite,
+			FolderMarkers: tt.mode,
+			Timeout:       time.Minute,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if keys := endpoint.Keys("dest"); strings.Join(keys, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: dest keys = %v, want %v", tt.name, keys, tt.want)
+		}
+		if result.FolderMarkers != tt.created || len(result.Errors) != 0 {
+			t.Errorf("%s: %d folder markers recreated (want %d), errors %v", tt.name, result.FolderMarkers, tt.created, result.Errors)
+		}
+		if tt.mode == FolderMarkersRecreate {
+			if marker := endpoint.Get("dest", "empty/"); marker == nil || marker.ContentType != folderMarkerContentType || len(marker.Data) != 0 {
+				t.Errorf("%s: empty/ = %+v", tt.name, marker)
+			}
+		}
+		endpoint.Close()
+	}
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/pool"
+)
+
+func TestFreezePolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     string
+		statements int
+	}{
+		{name: "no policy", statements: 1},
+		{name: "statement array", policy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::src/*"}]}`, statements: 2},
+		{name: "single statement", policy: `{"Version":"2012-10-17","Statement":{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::src/*"}}`, statements: 2},
+	}
+	for _, tt := range tests {
+		frozen, err := freezePolicy(tt.policy, "src")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var document struct {
+			Version   string
+			Statement []map[string]interface{}
+		}
+		if err := json.Unmarshal([]byte(frozen), &document); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		last := document.Statement[len(document.Statement)-1]
+		if document.Version != "2012-10-17" || len(document.Statement) != tt.statements ||
+			last["Sid"] != FreezeStatementID || last["Effect"] != "Deny" || last["Resource"] != "arn:aws:s3:::src/*" {
+			t.Errorf("%s: frozen policy %s", tt.name, frozen)
+		}
+	}
+
+	if _, err := freezePolicy("not json", "src"); err == nil {
+		t.Fatal("unparsable policy accepted")
+	}
+}
+
+func TestFreezeSourceRelease(t *testing.T) {
+	endpoint := fakes3.New("plain", "policed")
+	defer endpoint.Close()
+	policy := `{"Version":"2012-10-17","Statement":[]}`
+	endpoint.SetBucketConfig("policed", "policy", []byte(policy))
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, bucket := range []string{"plain", "policed"} {
+		previous := string(endpoint.BucketConfig(bucket, "policy"))
+		freeze, err := migrator.FreezeSource(context.Background(), bucket)
+		if err != nil {
+			t.Fatalf("%s: %v", bucket, err)
+		}
+		if frozen := string(endpoint.BucketConfig(bucket, "policy")); frozen == previous {
+			t.Fatalf("%s: policy unchanged by the freeze", bucket)
+		}
+		if err := freeze.Release(context.Background()); err != nil {
+			t.Fatalf("%s: %v", bucket, err)
+		}
+		if restored := string(endpoint.BucketConfig(bucket, "policy")); restored != previous {
+			t.Fatalf("%s: policy %q after release, want %q", bucket, restored, previous)
+		}
+	}
+}
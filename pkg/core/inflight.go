@@ -0,0 +1,143 @@
+package core
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// inFlightReportInterval is how often part-level progress is reported between completions
+	inFlightReportInterval = 5 * time.Second
+	// inFlightReportMinSize is the smallest streamed object whose bytes are counted as they flow
+	inFlightReportMinSize = 64 * 1024 * 1024
+)
+
+// InFlightObject is an object whose copy is in progress
+type InFlightObject struct {
+	Key        string    `json:"key"`
+	Size       int64     `json:"size"`
+	BytesDone  int64     `json:"bytes_done"`
+	PartsDone  int       `json:"parts_done,omitempty"`
+	PartsTotal int       `json:"parts_total,omitempty"` // Set for multipart copies
+	StartedAt  time.Time `json:"started_at"`
+}
+
+// inFlightTracker records partial progress of objects being copied, so large
+// multipart and streamed transfers move task progress before they complete
+type inFlightTracker struct {
+	mu      sync.Mutex
+	objects map[string]*InFlightObject
+	onBytes func(n int64) // Called with each partial increment (ETA estimator)
+}
+
+func newInFlightTracker() *inFlightTracker {
+	return &inFlightTracker{objects: make(map[string]*InFlightObject)}
+}
+
+// reset clears the tracker for a new copy phase
+func (t *inFlightTracker) reset(onBytes func(n int64)) {
+	t.mu.Lock()
+	t.objects = make(map[string]*InFlightObject)
+	t.onBytes = onBytes
+	t.mu.Unlock()
+}
+
+// begin starts tracking an object
+func (t *inFlightTracker) begin(key string, size int64) {
+	t.mu.Lock()
+	t.objects[key] = &InFlightObject{Key: key, Size: size, StartedAt: time.Now()}
+	t.mu.Unlock()
+}
+
+// setParts records the number of parts of a multipart copy
+func (t *inFlightTracker) setParts(key string, parts int) {
+	t.mu.Lock()
+	if obj, ok := t.objects[key]; ok {
+		obj.PartsTotal = parts
+	}
+	t.mu.Unlock()
+}
+
+// add records n more bytes of key transferred; part marks a completed multipart part
+func (t *inFlightTracker) add(key string, n int64, part bool) {
+	t.mu.Lock()
+	obj, ok := t.objects[key]
+	if ok {
+		// Retried requests re-send bytes; never report more than the object size
+		if remaining := obj.Size - obj.BytesDone; n > remaining {
+			n = remaining
+		}
+		obj.BytesDone += n
+		if part {
+			obj.PartsDone++
+		}
+	}
+	onBytes := t.onBytes
+	t.mu.Unlock()
+
+	if ok && n > 0 && onBytes != nil {
+		onBytes(n)
+	}
+}
+
+// end stops tracking key and returns how many bytes were reported as partial progress
+func (t *inFlightTracker) end(key string) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	obj, ok := t.objects[key]
+	if !ok {
+		return 0
+	}
+	delete(t.objects, key)
+	return obj.BytesDone
+}
+
+// partial returns the bytes transferred by unfinished objects and their
+// completion as a fraction of whole objects
+func (t *inFlightTracker) partial() (int64, float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var bytes int64
+	var objects float64
+	for _, obj := range t.objects {
+		bytes += obj.BytesDone
+		if obj.Size > 0 {
+			objects += float64(obj.BytesDone) / float64(obj.Size)
+		}
+	}
+	return bytes, objects
+}
+
+// snapshot lists in-flight objects, oldest first
+func (t *inFlightTracker) snapshot() []InFlightObject {
+	t.mu.Lock()
+	objects := make([]InFlightObject, 0, len(t.objects))
+	for _, obj := range t.objects {
+		objects = append(objects, *obj)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].StartedAt.Before(objects[j].StartedAt)
+	})
+	return objects
+}
+
+// progressReader reports bytes read through it to the in-flight tracker
+type progressReader struct {
+	reader  io.Reader
+	tracker *inFlightTracker
+	key     string
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.tracker.add(r.key, int64(n), false)
+	}
+	return n, err
+}
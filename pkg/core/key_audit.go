@@ -0,0 +1,256 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// maxKeyBytes is the longest key S3 and most S3-compatible stores accept
+const maxKeyBytes = 1024
+
+// maxKeyAuditSamples caps the sample keys of each issue in a key audit
+const maxKeyAuditSamples = 10
+
+// Key sanitization rules, applied in this order
+const (
+	SanitizeInvalidUTF8        = "replace_invalid_utf8" // Invalid UTF-8 bytes become "_"
+	SanitizeBackslashes        = "replace_backslashes"  // "\" becomes "/"
+	SanitizeTrailingSpaces     = "trim_trailing_spaces" // Spaces ending a path segment are removed
+	SanitizeLongKeys           = "shorten_long_keys"    // Destination keys over 1024 bytes are cut and given a hash of the full key
+	sanitizeLongKeysHashSuffix = 17                     // "~" and 16 hex digits
+)
+
+var sanitizeRules = []string{SanitizeInvalidUTF8, SanitizeBackslashes, SanitizeTrailingSpaces, SanitizeLongKeys}
+
+// KeySanitizePolicy rewrites source keys that would break on the destination
+type KeySanitizePolicy struct {
+	InvalidUTF8    bool
+	Backslashes    bool
+	TrailingSpaces bool
+	LongKeys       bool
+}
+
+// KeySanitizePolicyFor builds the key sanitization of a request; no rules mean keys are kept as-is
+func KeySanitizePolicyFor(rules []string) (*KeySanitizePolicy, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	policy := &KeySanitizePolicy{}
+	for _, rule := range rules {
+		switch rule {
+		case SanitizeInvalidUTF8:
+			policy.InvalidUTF8 = true
+		case SanitizeBackslashes:
+			policy.Backslashes = true
+		case SanitizeTrailingSpaces:
+			policy.TrailingSpaces = true
+		case SanitizeLongKeys:
+			policy.LongKeys = true
+		default:
+			return nil, fmt.Errorf("unknown key sanitization rule %q (expected %s)", rule, strings.Join(sanitizeRules, ", "))
+		}
+	}
+	return policy, nil
+}
+
+// Key returns the sanitized form of a source key, or key itself when p is nil.
+// Long keys are shortened in DestKey, once the destination prefix is known.
+func (p *KeySanitizePolicy) Key(key string) string {
+	if p == nil {
+		return key
+	}
+	if p.InvalidUTF8 {
+		key = strings.ToValidUTF8(key, "_")
+	}
+	if p.Backslashes {
+		key = strings.ReplaceAll(key, `\`, "/")
+	}
+	if p.TrailingSpaces {
+		segments := strings.Split(key, "/")
+		for i, segment := range segments {
+			segments[i] = strings.TrimRight(segment, " ")
+		}
+		key = strings.Join(segments, "/")
+	}
+	return key
+}
+
+// DestKey shortens a destination key over 1024 bytes when p shortens long keys:
+// its start is kept, cut at a character boundary, followed by "~" and a hash of
+// the full key, so distinct long keys stay distinct
+func (p *KeySanitizePolicy) DestKey(key string) string {
+	if p == nil || !p.LongKeys || len(key) <= maxKeyBytes {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key))
+	head := key[:maxKeyBytes-sanitizeLongKeysHashSuffix]
+	for len(head) > 0 && !utf8.ValidString(head) {
+		head = head[:len(head)-1]
+	}
+	return head + "~" + hex.EncodeToString(sum[:8])
+}
+
+// Key issues a key audit reports
+const (
+	KeyIssueTrailingSpace = "trailing_space"
+	KeyIssueBackslash     = "backslash"
+	KeyIssueTooLong       = "too_long"
+	KeyIssueInvalidUTF8   = "invalid_utf8"
+	KeyIssueCaseCollision = "case_collision"
+)
+
+// keyIssueInfo says where each issue breaks and which rule fixes it ("" when none does)
+var keyIssueInfo = map[string]struct{ breaks, rule string }{
+	KeyIssueTrailingSpace: {"Windows clients and SMB/NFS file gateways strip or reject path segments ending in a space", SanitizeTrailingSpaces},
+	KeyIssueBackslash:     {"Azure Blob Storage and Windows-hosted gateways treat \\ as a path separator", SanitizeBackslashes},
+	KeyIssueTooLong:       {"S3, GCS and most S3-compatible stores reject keys over 1024 bytes (with the destination prefix)", SanitizeLongKeys},
+	KeyIssueInvalidUTF8:   {"S3 requires keys to be valid UTF-8; other stores reject or mangle such keys", SanitizeInvalidUTF8},
+	KeyIssueCaseCollision: {"Case-insensitive destinations (Windows and macOS file gateways) keep only one of the keys; rename them before migrating", ""},
+}
+
+// KeyIssueSummary counts the source keys with one issue
+type KeyIssueSummary struct {
+	Issue   string   `json:"issue"`
+	Count   int64    `json:"count"`
+	Breaks  string   `json:"breaks"`         // Where keys with the issue fail
+	Rule    string   `json:"rule,omitempty"` // key_sanitize rule that fixes it
+	Samples []string `json:"samples"`        // Go-quoted, so spaces and invalid bytes show
+}
+
+// KeyAuditReport lists the source keys that are known to break on some
+// destinations, with the key_sanitize rules a migration can apply to them
+type KeyAuditReport struct {
+	Bucket     string            `json:"bucket"`
+	Prefix     string            `json:"prefix,omitempty"`
+	DestPrefix string            `json:"dest_prefix,omitempty"`
+	Objects    int64             `json:"objects"`
+	Issues     []KeyIssueSummary `json:"issues"`
+	// Rules fixing the issues found, for the key_sanitize of the migration request
+	SuggestedRules []string `json:"suggested_rules"`
+	// Source keys the suggested rules map onto one destination key; the later
+	// copy would overwrite the earlier, so rename them before migrating
+	Collisions       int64    `json:"collisions"`
+	CollisionSamples []string `json:"collision_samples,omitempty"`
+}
+
+// AuditKeys lists bucket under prefix and reports the keys that break specific
+// destinations when copied under destPrefix. Like a migration, it holds the
+// listed keys in memory to find keys colliding by case or after sanitization.
+func AuditKeys(ctx context.Context, client *s3.Client, bucket, prefix, destPrefix string) (*KeyAuditReport, error) {
+	// ListObjects v1 with the same marker fallback as migrations
+	input := &s3.ListObjectsInput{Bucket: aws.String(bucket), MaxKeys: aws.Int32(1000)}
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+	var keys []string
+	for {
+		page, err := client.ListObjects(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		for _, obj := range page.Contents {
+			keys = append(keys, aws.ToString(obj.Key))
+		}
+		if input.Marker = nextMarker(page); input.Marker == nil {
+			break
+		}
+	}
+	return auditKeys(keys, bucket, prefix, destPrefix), nil
+}
+
+// auditKeys checks listed source keys
+func auditKeys(keys []string, bucket, prefix, destPrefix string) *KeyAuditReport {
+	report := &KeyAuditReport{Bucket: bucket, Prefix: prefix, DestPrefix: destPrefix, Objects: int64(len(keys)), Issues: []KeyIssueSummary{}, SuggestedRules: []string{}}
+	summaries := make(map[string]*KeyIssueSummary)
+	found := func(issue, sample string) {
+		summary := summaries[issue]
+		if summary == nil {
+			info := keyIssueInfo[issue]
+			summary = &KeyIssueSummary{Issue: issue, Breaks: info.breaks, Rule: info.rule, Samples: []string{}}
+			summaries[issue] = summary
+		}
+		summary.Count++
+		if len(summary.Samples) < maxKeyAuditSamples {
+			summary.Samples = append(summary.Samples, sample)
+		}
+	}
+
+	byLowerCase := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if !utf8.ValidString(key) {
+			found(KeyIssueInvalidUTF8, strconv.Quote(key))
+		}
+		if strings.Contains(key, `\`) {
+			found(KeyIssueBackslash, strconv.Quote(key))
+		}
+		for _, segment := range strings.Split(key, "/") {
+			if strings.HasSuffix(segment, " ") {
+				found(KeyIssueTrailingSpace, strconv.Quote(key))
+				break
+			}
+		}
+		if len(destKeyFor(key, destPrefix)) > maxKeyBytes {
+			found(KeyIssueTooLong, strconv.Quote(key))
+		}
+		lower := strings.ToLower(key)
+		if other, ok := byLowerCase[lower]; ok {
+			found(KeyIssueCaseCollision, strconv.Quote(other)+" / "+strconv.Quote(key))
+		} else {
+			byLowerCase[lower] = key
+		}
+	}
+
+	for _, issue := range []string{KeyIssueInvalidUTF8, KeyIssueBackslash, KeyIssueTrailingSpace, KeyIssueTooLong, KeyIssueCaseCollision} {
+		if summary := summaries[issue]; summary != nil {
+			report.Issues = append(report.Issues, *summary)
+		}
+	}
+	for _, rule := range sanitizeRules {
+		for _, summary := range report.Issues {
+			if summary.Rule == rule {
+				report.SuggestedRules = append(report.SuggestedRules, rule)
+			}
+		}
+	}
+
+	// Keys the suggested rules change may land on another source key
+	policy, _ := KeySanitizePolicyFor(report.SuggestedRules)
+	if policy == nil {
+		return report
+	}
+	destKeys := make([]string, len(keys))
+	byDestKey := make(map[string]string, len(keys))
+	for i, key := range keys {
+		destKeys[i] = policy.DestKey(destKeyFor(policy.Key(key), destPrefix))
+		if destKeys[i] == destKeyFor(key, destPrefix) {
+			byDestKey[destKeys[i]] = key // Kept as-is
+		}
+	}
+	var collisions []string
+	for i, key := range keys {
+		if destKeys[i] == destKeyFor(key, destPrefix) {
+			continue
+		}
+		if other, ok := byDestKey[destKeys[i]]; ok {
+			report.Collisions++
+			collisions = append(collisions, strconv.Quote(other)+" / "+strconv.Quote(key))
+			continue
+		}
+		byDestKey[destKeys[i]] = key
+	}
+	sort.Strings(collisions)
+	if len(collisions) > maxKeyAuditSamples {
+		collisions = collisions[:maxKeyAuditSamples]
+	}
+	report.CollisionSamples = collisions
+	return report
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/pool"
+)
+
+func TestAuditKeys(t *testing.T) {
+	long := strings.Repeat("a", 1020)
+	keys := []string{
+		"docs/Report.pdf",
+		"docs/report.pdf",
+		"docs/notes /a.txt",
+		"docs/notes/a.txt", // Where the trimmed key above lands
+		`win\path.txt`,
+		"bad\xffname",
+		long,
+	}
+	report := auditKeys(keys, "source", "", "backup")
+
+	got := map[string]int64{}
+	for _, issue := range report.Issues {
+		got[issue.Issue] = issue.Count
+	}
+	want := map[string]int64{
+		KeyIssueCaseCollision: 1,
+		KeyIssueTrailingSpace: 1,
+		KeyIssueBackslash:     1,
+		KeyIssueInvalidUTF8:   1,
+		KeyIssueTooLong:       1, // Only with the destination prefix
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("issues = %v, want %v", got, want)
+	}
+	if rules := []string{SanitizeInvalidUTF8, SanitizeBackslashes, SanitizeTrailingSpaces, SanitizeLongKeys}; !reflect.DeepEqual(report.SuggestedRules, rules) {
+		t.Errorf("suggested rules = %v", report.SuggestedRules)
+	}
+	if report.Collisions != 1 || len(report.CollisionSamples) != 1 || !strings.Contains(report.CollisionSamples[0], `"docs/notes /a.txt"`) {
+		t.Errorf("collisions = %d %v", report.Collisions, report.CollisionSamples)
+	}
+	if clean := auditKeys([]string{"a.txt", "b/c.txt"}, "source", "", ""); len(clean.Issues) != 0 || len(clean.SuggestedRules) != 0 {
+		t.Errorf("clean keys reported: %+v", clean)
+	}
+}
+
+func TestKeySanitizePolicy(t *testing.T) {
+	if _, err := KeySanitizePolicyFor([]string{"lowercase"}); err == nil {
+		t.Error("unknown rule accepted")
+	}
+	policy, err := KeySanitizePolicyFor([]string{SanitizeInvalidUTF8, SanitizeBackslashes, SanitizeTrailingSpaces, SanitizeLongKeys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key, want string
+	}{
+		{key: "plain/key.txt", want: "backup/plain/key.txt"},
+		{key: `dir \sub\file `, want: "backup/dir/sub/file"},
+		{key: "bad\xffname", want: "backup/bad_name"},
+	}
+	for _, tt := range tests {
+		if got := destKeyForObject(objectInfo{Key: tt.key}, "backup", nil, policy); got != tt.want {
+			t.Errorf("%q -> %q, want %q", tt.key, got, tt.want)
+		}
+	}
+
+	// Long keys are cut at a character boundary and stay distinct
+	first := destKeyForObject(objectInfo{Key: strings.Repeat("é", 600) + "1"}, "", nil, policy)
+	second := destKeyForObject(objectInfo{Key: strings.Repeat("é", 600) + "2"}, "", nil, policy)
+	if len(first) > maxKeyBytes || first == second || !strings.Contains(first, "~") || !strings.HasPrefix(first, "éé") {
+		t.Errorf("shortened keys %q (%d bytes) and %q", first, len(first), second)
+	}
+}
+
+func TestMigrateSanitizesKeys(t *testing.T) {
+	endpoint := fakes3.New("source", "dest")
+	defer endpoint.Close()
+	endpoint.Put("source", `reports\2026 /q1.csv`, []byte("q1"))
+	endpoint.Put("source", "ok.txt", []byte("ok"))
+
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, _ := KeySanitizePolicyFor([]string{SanitizeBackslashes, SanitizeTrailingSpaces})
+	result, err := migrator.Migrate(context.Background(), MigrateInput{
+		SourceBucket:  "source",
+		DestBucket:    "dest",
+		MigrationMode: ModeIncremental,
+		KeySanitize:   policy,
+		Timeout:       time.Minute,
+	})
+	if err != nil || len(result.Errors) != 0 {
+		t.Fatalf("migrate: %v %v", err, result.Errors)
+	}
+	if keys := endpoint.Keys("dest"); !reflect.DeepEqual(keys, []string{"ok.txt", "reports/2026/q1.csv"}) {
+		t.Fatalf("dest keys = %q", keys)
+	}
+
+	// An incremental rerun finds the sanitized keys and copies nothing
+	result, err = migrator.Migrate(context.Background(), MigrateInput{
+		SourceBucket:  "source",
+		DestBucket:    "dest",
+		MigrationMode: ModeIncremental,
+		KeySanitize:   policy,
+		Timeout:       time.Minute,
+	})
+	if err != nil || result.Copied != 0 {
+		t.Fatalf("rerun copied %d objects (%v)", result.Copied, err)
+	}
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"s3migration/pkg/models"
+)
+
+// Limits S3 puts on object tags
+const (
+	maxObjectTags     = 10
+	maxTagKeyLength   = 128
+	maxTagValueLength = 256
+)
+
+// defaultLabels are set on each copy when labels.tags is empty
+var defaultLabels = map[string]string{
+	"migrated-by": "{task_id}",
+	"migrated-at": "{timestamp}",
+}
+
+// tagPattern holds the characters S3 accepts in tag keys and values
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+
+// LabelPolicy is the set of tags written on each copy
+type LabelPolicy struct {
+	Tags    map[string]string // {task_id} is resolved, {timestamp} is set per object
+	Replace bool              // Drop the tags a copy already has
+}
+
+// LabelPolicyFor builds the labels of a migration request (nil without any);
+// taskID resolves {task_id}
+func LabelPolicyFor(opts *models.LabelOptions, taskID string) (*LabelPolicy, error) {
+	if opts == nil || !opts.Enabled {
+		return nil, nil
+	}
+	labels := opts.Tags
+	if len(labels) == 0 {
+		labels = defaultLabels
+	}
+	if len(labels) > maxObjectTags {
+		return nil, fmt.Errorf("%d tags given; S3 allows %d per object", len(labels), maxObjectTags)
+	}
+	tags := make(map[string]string, len(labels))
+	for key, value := range labels {
+		value = strings.ReplaceAll(value, "{task_id}", taskID)
+		resolved := strings.ReplaceAll(value, "{timestamp}", time.Time{}.Format(time.RFC3339))
+		switch {
+		case key == "" || len(key) > maxTagKeyLength:
+			return nil, fmt.Errorf("tag key %q must be 1 to %d characters", key, maxTagKeyLength)
+		case strings.HasPrefix(strings.ToLower(key), "aws:"):
+			return nil, fmt.Errorf("tag key %q: the aws: prefix is reserved", key)
+		case !tagPattern.MatchString(key):
+			return nil, fmt.Errorf("tag key %q holds characters S3 does not accept in tags", key)
+		case strings.ContainsAny(resolved, "{}"):
+			return nil, fmt.Errorf("tag %s: unknown placeholder in %q (expected {task_id} or {timestamp})", key, value)
+		case len(resolved) > maxTagValueLength:
+			return nil, fmt.Errorf("tag %s: value longer than %d characters", key, maxTagValueLength)
+		case !tagPattern.MatchString(resolved):
+			return nil, fmt.Errorf("tag %s: value %q holds characters S3 does not accept in tags", key, value)
+		}
+		tags[key] = value
+	}
+	return &LabelPolicy{Tags: tags, Replace: opts.Replace}, nil
+}
+
+// labeler tags the copies of one Migrate call and counts them
+type labeler struct {
+	policy  *LabelPolicy
+	labeled atomic.Int64
+	errors  atomic.Int64
+}
+
+// newLabeler returns the labeler of a policy (nil without one)
+func newLabeler(policy *LabelPolicy) *labeler {
+	if policy == nil {
+		return nil
+	}
+	return &labeler{policy: policy}
+}
+
+// labelObject adds the labels to the tags of a copy, or replaces its tags with them
+func (l *labeler) labelObject(ctx context.Context, client *s3.Client, bucket, key string) error {
+	tags := map[string]string{}
+	if !l.policy.Replace {
+		output, err := client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+		if err != nil {
+			l.errors.Add(1)
+			return fmt.Errorf("failed to read tags: %w", err)
+		}
+		for _, tag := range output.TagSet {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	for name, value := range l.policy.Tags {
+		tags[name] = strings.ReplaceAll(value, "{timestamp}", timestamp)
+	}
+	if len(tags) > maxObjectTags {
+		l.errors.Add(1)
+		return fmt.Errorf("the copy would have %d tags with the labels; S3 allows %d (set labels.replace to drop its own)", len(tags), maxObjectTags)
+	}
+
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tagSet := make([]types.Tag, len(names))
+	for i, name := range names {
+		tagSet[i] = types.Tag{Key: aws.String(name), Value: aws.String(tags[name])}
+	}
+	if _, err := client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		Tagging: &types.Tagging{TagSet: tagSet},
+	}); err != nil {
+		l.errors.Add(1)
+		return fmt.Errorf("failed to set tags: %w", err)
+	}
+	l.labeled.Add(1)
+	return nil
+}
+
+// result returns the counts of the run (nil without a labeler)
+func (l *labeler) result() *models.LabelReport {
+	if l == nil {
+		return nil
+	}
+	return &models.LabelReport{Labeled: l.labeled.Load(), Errors: l.errors.Load()}
+}
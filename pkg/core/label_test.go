@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+)
+
+func TestLabelPolicyFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    map[string]string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "default", want: map[string]string{"migrated-by": "task-1", "migrated-at": "{timestamp}"}},
+		{name: "custom", tags: map[string]string{"origin": "minio:{task_id}", "team": "data platform"},
+			want: map[string]string{"origin": "minio:task-1", "team": "data platform"}},
+		{name: "unknown placeholder", tags: map[string]string{"origin": "{source_bucket}"}, wantErr: true},
+		{name: "reserved prefix", tags: map[string]string{"aws:origin": "x"}, wantErr: true},
+		{name: "invalid characters", tags: map[string]string{"origin": "a,b"}, wantErr: true},
+		{name: "empty key", tags: map[string]string{"": "x"}, wantErr: true},
+		{name: "long value", tags: map[string]string{"origin": strings.Repeat("x", 257)}, wantErr: true},
+		{name: "too many tags", tags: map[string]string{"1": "", "2": "", "3": "", "4": "", "5": "", "6": "", "7": "", "8": "", "9": "", "10": "", "11": ""}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := LabelPolicyFor(&models.LabelOptions{Enabled: true, Tags: tt.tags}, "task-1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LabelPolicyFor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if policy != nil && fmt.Sprint(policy.Tags) != fmt.Sprint(tt.want) {
+				t.Errorf("tags = %v, want %v", policy.Tags, tt.want)
+			}
+		})
+	}
+	if policy, err := LabelPolicyFor(&models.LabelOptions{}, "task-1"); policy != nil || err != nil {
+		t.Errorf("disabled: %v, %v", policy, err)
+	}
+}
+
+func TestMigrateLabelsCopies(t *testing.T) {
+	tests := []struct {
+		name     string
+		replace  bool
+		wantTags string
+	}{
+		{name: "merged with the source tags", wantTags: "map[migrated-by:task-1 owner:analytics]"},
+		{name: "replacing the source tags", replace: true, wantTags: "map[migrated-by:task-1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := fakes3.New("source")
+			defer endpoint.Close()
+			endpoint.Put("source", "a.txt", []byte("alpha"))
+			endpoint.Get("source", "a.txt").Tags = map[string]string{"owner": "analytics"}
+			endpoint.Put("source", "b.txt", []byte("bravo"))
+
+			migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+				ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			policy, err := LabelPolicyFor(&models.LabelOptions{Enabled: true, Replace: tt.replace}, "task-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := migrator.Migrate(context.Background(), MigrateInput{
+				SourceBucket:  "source",
+				DestBucket:    "dest",
+				MigrationMode: ModeFullRewrite,
+				Labels:        policy,
+				Timeout:       time.Minute,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Errors) != 0 || result.Labels == nil || result.Labels.Labeled != 2 || result.Labels.Errors != 0 {
+				t.Fatalf("labels = %+v, errors = %v", result.Labels, result.Errors)
+			}
+
+			tags := endpoint.Get("dest", "a.txt").Tags
+			migratedAt, err := time.Parse(time.RFC3339, tags["migrated-at"])
+			if err != nil || time.Since(migratedAt) > time.Minute {
+				t.Errorf("migrated-at = %q", tags["migrated-at"])
+			}
+			delete(tags, "migrated-at")
+			if fmt.Sprint(tags) != tt.wantTags {
+				t.Errorf("tags of the copy = %v, want %s", tags, tt.wantTags)
+			}
+			if tags := endpoint.Get("source", "a.txt").Tags; fmt.Sprint(tags) != "map[owner:analytics]" {
+				t.Errorf("source tags changed: %v", tags)
+			}
+		})
+	}
+}
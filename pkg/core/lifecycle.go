@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// With ExcludeLifecycleExpired, objects a destination lifecycle rule would expire
+// as soon as they are written are not copied. A copy's age counts from its write,
+// so only rules with an expiration date already past qualify. Rules filtered on
+// tags are ignored: the tags of the copies are not known before they are written.
+
+// expiryRule is an enabled lifecycle rule whose expiration date has passed
+type expiryRule struct {
+	id          string
+	prefix      string
+	greaterThan int64 // ObjectSizeGreaterThan (0 = any size)
+	lessThan    int64 // ObjectSizeLessThan (0 = any size)
+}
+
+// matches reports whether an object of size written to key falls under the rule
+func (r expiryRule) matches(key string, size int64) bool {
+	if !strings.HasPrefix(key, r.prefix) {
+		return false
+	}
+	if r.greaterThan > 0 && size <= r.greaterThan {
+		return false
+	}
+	return r.lessThan == 0 || size < r.lessThan
+}
+
+// expiryRules returns the rules of bucket's lifecycle configuration that expire
+// objects written at now; none when the bucket has no configuration
+func expiryRules(ctx context.Context, client *s3.Client, bucket string, now time.Time) ([]expiryRule, error) {
+	output, err := client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: aws.String(bucket)})
+	switch status := configStatus(err, "NoSuchLifecycleConfiguration"); status.Status {
+	case ConfigAbsent, ConfigUnsupported:
+		return nil, nil
+	case ConfigError:
+		return nil, fmt.Errorf("failed to read destination lifecycle configuration: %s", status.Error)
+	}
+
+	var rules []expiryRule
+	for _, rule := range output.Rules {
+		if rule.Status != types.ExpirationStatusEnabled || rule.Expiration == nil ||
+			rule.Expiration.Date == nil || rule.Expiration.Date.After(now) {
+			continue
+		}
+		r := expiryRule{id: aws.ToString(rule.ID), prefix: aws.ToString(rule.Prefix)}
+		switch filter := rule.Filter.(type) {
+		case *types.LifecycleRuleFilterMemberPrefix:
+			r.prefix = filter.Value
+		case *types.LifecycleRuleFilterMemberObjectSizeGreaterThan:
+			r.greaterThan = filter.Value
+		case *types.LifecycleRuleFilterMemberObjectSizeLessThan:
+			r.lessThan = filter.Value
+		case *types.LifecycleRuleFilterMemberTag:
+			continue
+		case *types.LifecycleRuleFilterMemberAnd:
+			if len(filter.Value.Tags) > 0 {
+				continue
+			}
+			r.prefix = aws.ToString(filter.Value.Prefix)
+			r.greaterThan = aws.ToInt64(filter.Value.ObjectSizeGreaterThan)
+			r.lessThan = aws.ToInt64(filter.Value.ObjectSizeLessThan)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// excludeExpiring returns the objects no rule expires at their destination key,
+// and the number left out
+func excludeExpiring(objects []objectInfo, rules []expiryRule, input MigrateInput) ([]objectInfo, int64) {
+	if len(rules) == 0 {
+		return objects, 0
+	}
+	kept := make([]objectInfo, 0, len(objects))
+	for _, obj := range objects {
+		destKey := destKeyForObject(obj, input.DestPrefix, input.Partition, input.KeySanitize)
+		expired := false
+		for _, rule := range rules {
+			if rule.matches(destKey, obj.Size) {
+				expired = true
+				break
+			}
+		}
+		if !expired {
+			kept = append(kept, obj)
+		}
+	}
+	return kept, int64(len(objects) - len(kept))
+}
+
+// excludeLifecycleExpired leaves out the objects the destination's lifecycle
+// configuration would expire on arrival. When the configuration cannot be read,
+// every object is kept.
+func (m *EnhancedMigrator) excludeLifecycleExpired(ctx context.Context, input MigrateInput, objects []objectInfo, destClient *s3.Client) ([]objectInfo, int64) {
+	client := destClient
+	if client == nil {
+		client = m.metadataClient()
+	}
+	rules, err := expiryRules(ctx, client, input.DestBucket, time.Now())
+	if err != nil {
+		fmt.Printf("Warning: %v; copying objects regardless of lifecycle rules\n", err)
+		return objects, 0
+	}
+	kept, excluded := excludeExpiring(objects, rules, input)
+	if excluded > 0 {
+		fmt.Printf("Lifecycle: %d objects left out, the destination's lifecycle rules would expire them on arrival\n", excluded)
+	}
+	return kept, excluded
+}
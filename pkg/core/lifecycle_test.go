@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/pool"
+)
+
+const testLifecycle = `<LifecycleConfiguration>
+  <Rule><ID>expired-tmp</ID><Status>Enabled</Status><Filter><Prefix>backup/tmp/</Prefix></Filter><Expiration><Date>2020-01-01T00:00:00Z</Date></Expiration></Rule>
+  <Rule><ID>expired-large</ID><Status>Enabled</Status><Filter><And><Prefix>backup/media/</Prefix><ObjectSizeGreaterThan>10</ObjectSizeGreaterThan></And></Filter><Expiration><Date>2020-01-01T00:00:00Z</Date></Expiration></Rule>
+  <Rule><ID>future</ID><Status>Enabled</Status><Filter><Prefix>backup/logs/</Prefix></Filter><Expiration><Date>2999-01-01T00:00:00Z</Date></Expiration></Rule>
+  <Rule><ID>by-age</ID><Status>Enabled</Status><Filter><Prefix>backup/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+  <Rule><ID>tagged</ID><Status>Enabled</Status><Filter><Tag><Key>k</Key><Value>v</Value></Tag></Filter><Expiration><Date>2020-01-01T00:00:00Z</Date></Expiration></Rule>
+  <Rule><ID>disabled</ID><Status>Disabled</Status><Filter><Prefix>backup/logs/</Prefix></Filter><Expiration><Date>2020-01-01T00:00:00Z</Date></Expiration></Rule>
+</LifecycleConfiguration>`
+
+func TestExcludeLifecycleExpired(t *testing.T) {
+	endpoint := fakes3.New("src", "dest")
+	defer endpoint.Close()
+	endpoint.SetBucketConfig("dest", "lifecycle", []byte(testLifecycle))
+	objects := map[string]string{
+		"tmp/a":         "scratch",
+		"media/small":   "tiny",
+		"media/large":   "larger than ten bytes",
+		"logs/app.log":  "log line",
+		"docs/read.txt": "docs",
+	}
+	for key, content := range objects {
+		endpoint.Put("src", key, []byte(content))
+	}
+
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := migrator.Migrate(context.Background(), MigrateInput{
+		SourceBucket:            "src",
+		DestBucket:              "dest",
+		DestPrefix:              "backup",
+		MigrationMode:           ModeFullRewrite,
+		ExcludeLifecycleExpired: true,
+		Timeout:                 time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.LifecycleExcluded != 2 || result.Copied != 3 {
+		t.Fatalf("excluded %d, copied %d; want 2 and 3", result.LifecycleExcluded, result.Copied)
+	}
+	if got := strings.Join(endpoint.Keys("dest"), " "); got != "backup/docs/read.txt backup/logs/app.log backup/media/small" {
+		t.Fatalf("destination keys: %s", got)
+	}
+}
+
+func TestExpiryRuleMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		rule expiryRule
+		key  string
+		size int64
+		want bool
+	}{
+		{name: "prefix", rule: expiryRule{prefix: "tmp/"}, key: "tmp/a", want: true},
+		{name: "other prefix", rule: expiryRule{prefix: "tmp/"}, key: "data/a"},
+		{name: "whole bucket", rule: expiryRule{}, key: "any", want: true},
+		{name: "above minimum", rule: expiryRule{greaterThan: 100}, key: "a", size: 101, want: true},
+		{name: "at minimum", rule: expiryRule{greaterThan: 100}, key: "a", size: 100},
+		{name: "below maximum", rule: expiryRule{lessThan: 100}, key: "a", size: 99, want: true},
+		{name: "at maximum", rule: expiryRule{lessThan: 100}, key: "a", size: 100},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.matches(tt.key, tt.size); got != tt.want {
+			t.Errorf("%s: matches(%q, %d) = %v, want %v", tt.name, tt.key, tt.size, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/prefetch"
+)
+
+// destListingScope returns the listing cache key of the destination bucket/prefix
+func (m *EnhancedMigrator) destListingScope(input MigrateInput, destClient *s3.Client) string {
+	return prefetch.ListingScope(m.destEndpoint(input, destClient), input.DestBucket, input.DestPrefix)
+}
+
+// listDestination lists the destination for planning. A cached listing from an
+// earlier comparison or run is reused only when the request opts in with
+// input.ReuseDestListing and does not verify its writes.
+func (m *EnhancedMigrator) listDestination(ctx context.Context, input MigrateInput, destClient *s3.Client) ([]objectInfo, error) {
+	if m.prefetcher == nil {
+		return m.listObjectsWithCache(ctx, input.DestBucket, input.DestPrefix, destClient)
+	}
+
+	scope := m.destListingScope(input, destClient)
+	if input.ReuseDestListing && !input.VerifyWrite {
+		if listing, ok := m.prefetcher.GetListing(scope); ok {
+			fmt.Printf("Using cached destination listing: %d objects (cached %s)\n",
+				len(listing.Objects), listing.CachedAt.Format("2006-01-02 15:04:05"))
+			return objectsFromListing(listing), nil
+		}
+	}
+
+	generation := m.destListingGeneration(input, destClient)
+	objects, err := m.listObjectsWithCache(ctx, input.DestBucket, input.DestPrefix, destClient)
+	if err != nil {
+		return nil, err
+	}
+	m.cacheDestListing(input, destClient, objects, generation)
+	return objects, nil
+}
+
+// destListingCacheMax is the largest destination listing a streamed plan keeps
+// in memory to cache for later comparisons; larger listings are not cached
+const destListingCacheMax = 1000000
+
+// planAgainstDestination plans a run against the destination. The destination is
+// streamed page by page through a sorted merge with the source objects, so memory
+// does not grow with the destination's size. A reusable cached listing is merged
+// from memory instead. Returns the plan and the number of destination objects.
+func (m *EnhancedMigrator) planAgainstDestination(ctx context.Context, input MigrateInput, destClient *s3.Client, objects []objectInfo, mode MigrationMode, withDiff bool) ([]objectInfo, *DiffSummary, int, error) {
+	if m.prefetcher != nil && input.ReuseDestListing && !input.VerifyWrite {
+		if listing, ok := m.prefetcher.GetListing(m.destListingScope(input, destClient)); ok {
+			fmt.Printf("Using cached destination listing: %d objects (cached %s)\n",
+				len(listing.Objects), listing.CachedAt.Format("2006-01-02 15:04:05"))
+			destObjects := objectsFromListing(listing)
+			toCopy, plan := planMigration(objects, destObjects, input.DestPrefix, input.Partition, input.KeySanitize, mode, input.ConflictStrategy, withDiff)
+			return toCopy, plan, len(destObjects), nil
+		}
+	}
+
+	client := destClient
+	if client == nil {
+		client = m.metadataClient()
+	}
+	fmt.Printf("Streaming destination listing of %s/%s for comparison\n", input.DestBucket, input.DestPrefix)
+	generation := m.destListingGeneration(input, destClient)
+	pages := m.newObjectPager(client, input.DestBucket, input.DestPrefix)
+	var listed []objectInfo // Kept for the listing cache while small enough
+	count := 0
+	nextDest := func() ([]objectInfo, error) {
+		page, err := pages.next(ctx)
+		count += len(page)
+		if generation >= 0 && count <= destListingCacheMax {
+			listed = append(listed, page...)
+		}
+		return page, err
+	}
+	toCopy, plan, err := planMigrationMerged(objects, nextDest, input.DestPrefix, input.Partition, input.KeySanitize, mode, input.ConflictStrategy, withDiff)
+	if errors.Is(err, errUnsortedListing) {
+		// Some S3-compatible providers do not list in key order; compare from memory
+		fmt.Printf("Warning: %v, comparing against the full listing\n", err)
+		destObjects, err := m.listDestination(ctx, input, destClient)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		toCopy, plan := planMigration(objects, destObjects, input.DestPrefix, input.Partition, input.KeySanitize, mode, input.ConflictStrategy, withDiff)
+		return toCopy, plan, len(destObjects), nil
+	}
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	// The merge stops at the last source key; list the rest for an exact count and a complete cache
+	for {
+		page, err := nextDest()
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if page == nil {
+			break
+		}
+	}
+	if count <= destListingCacheMax {
+		m.cacheDestListing(input, destClient, listed, generation)
+	}
+	fmt.Printf("Destination objects compared: %d (across %d pages)\n", count, pages.count)
+	return toCopy, plan, count, nil
+}
+
+// planIncrementalRun plans a real incremental run: new and changed objects are
+// copied and the conflict strategy does not apply (only dry-run diffs use it).
+// Each source key is matched relative to the source prefix, as runs always
+// have; the dry-run diff matches the exact key an object is written to.
+func (m *EnhancedMigrator) planIncrementalRun(ctx context.Context, input MigrateInput, destClient *s3.Client, objects []objectInfo) ([]objectInfo, *DiffSummary, error) {
+	input.ConflictStrategy = ""
+	relative := make([]objectInfo, len(objects))
+	originals := make(map[string]objectInfo, len(objects))
+	for i, obj := range objects {
+		relative[i] = obj
+		relative[i].Key = relativeSourceKey(obj.Key, input.SourcePrefix)
+		originals[relative[i].Key] = obj
+	}
+	toCopy, plan, _, err := m.planAgainstDestination(ctx, input, destClient, relative, ModeIncremental, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, obj := range toCopy {
+		toCopy[i] = originals[obj.Key]
+	}
+	return toCopy, plan, nil
+}
+
+// relativeSourceKey strips the source prefix, and a "/" after it, from a key
+func relativeSourceKey(key, sourcePrefix string) string {
+	if sourcePrefix == "" || len(key) <= len(sourcePrefix) || !strings.HasPrefix(key, sourcePrefix) {
+		return key
+	}
+	return strings.TrimPrefix(key[len(sourcePrefix):], "/")
+}
+
+// destListingGeneration returns the destination scope's generation, read before listing
+// (-1 when it cannot be read, so the listing is not cached)
+func (m *EnhancedMigrator) destListingGeneration(input MigrateInput, destClient *s3.Client) int64 {
+	if m.prefetcher == nil {
+		return -1
+	}
+	generation, err := m.prefetcher.ListingGeneration(m.destListingScope(input, destClient))
+	if err != nil {
+		m.logger.Infof("Failed to read destination listing generation: %v", err)
+		return -1
+	}
+	return generation
+}
+
+// cacheDestListing stores a complete destination listing taken at generation
+// for later comparisons
+func (m *EnhancedMigrator) cacheDestListing(input MigrateInput, destClient *s3.Client, objects []objectInfo, generation int64) {
+	if m.prefetcher == nil || generation < 0 {
+		return
+	}
+	listing := &prefetch.Listing{
+		Scope:      m.destListingScope(input, destClient),
+		Bucket:     input.DestBucket,
+		Prefix:     input.DestPrefix,
+		Generation: generation,
+		Objects:    make([]prefetch.ObjectMetadata, len(objects)),
+	}
+	for i, obj := range objects {
+		listing.Objects[i] = prefetch.ObjectMetadata{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified}
+	}
+	if err := m.prefetcher.PutListing(listing); err != nil {
+		m.logger.Infof("Failed to cache destination listing: %v", err)
+	}
+}
+
+// invalidateDestListing forgets the destination listing before objects are written to it
+func (m *EnhancedMigrator) invalidateDestListing(input MigrateInput, destClient *s3.Client) {
+	if m.prefetcher == nil {
+		return
+	}
+	if err := m.prefetcher.InvalidateListing(m.destListingScope(input, destClient)); err != nil {
+		m.logger.Infof("Failed to invalidate destination listing: %v", err)
+	}
+}
+
+func objectsFromListing(listing *prefetch.Listing) []objectInfo {
+	objects := make([]objectInfo, len(listing.Objects))
+	for i, obj := range listing.Objects {
+		objects[i] = objectInfo{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified}
+	}
+	return objects
+}
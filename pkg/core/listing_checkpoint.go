@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"s3migration/pkg/prefetch"
+)
+
+// listingCheckpointObjects is how many newly listed objects are stored before
+// the listing marker is checkpointed again
+var listingCheckpointObjects = prefetch.TaskListingPartSize
+
+// ListingCheckpointPolicy checkpoints the source listing of a task, so a task
+// interrupted while listing resumes from the last marker stored instead of
+// listing the bucket again from the start
+type ListingCheckpointPolicy struct {
+	TaskID string
+	Store  prefetch.ListingCheckpointStore
+}
+
+// listSource lists the source objects of a Migrate call, resuming and
+// checkpointing the listing when the input has a checkpoint policy. With a
+// source inventory the objects are read from it and the bucket is not listed.
+func (m *EnhancedMigrator) listSource(ctx context.Context, input MigrateInput) ([]objectInfo, error) {
+	if input.SourceInventory != nil {
+		return m.readInventory(ctx, input)
+	}
+	if input.ListingCheckpoint == nil {
+		return m.listObjectsWithCache(ctx, input.SourceBucket, input.SourcePrefix)
+	}
+	return m.listWithCheckpoint(ctx, input)
+}
+
+// listWithCheckpoint lists the source page by page. Every listingCheckpointObjects
+// objects, those listed since the last checkpoint are stored as one part and the
+// marker of the next page is recorded after them. A checkpoint of the same
+// listing left by an interrupted run is loaded first and the listing continues
+// at its marker; the checkpoint is dropped once the listing completes. Unlike
+// listObjectsV1 the listing has no page limit, as checkpoints are what make
+// listing very large buckets affordable.
+func (m *EnhancedMigrator) listWithCheckpoint(ctx context.Context, input MigrateInput) ([]objectInfo, error) {
+	policy := input.ListingCheckpoint
+	checkpoint := prefetch.ListingCheckpoint{
+		TaskID:   policy.TaskID,
+		Endpoint: m.config.EndpointURL,
+		Bucket:   input.SourceBucket,
+		Prefix:   input.SourcePrefix,
+	}
+	pages := m.newObjectPager(m.metadataClient(), input.SourceBucket, input.SourcePrefix)
+
+	var objects []objectInfo
+	stored, err := policy.Store.LoadListingCheckpoint(policy.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	if stored != nil && stored.Endpoint == checkpoint.Endpoint && stored.Bucket == checkpoint.Bucket && stored.Prefix == checkpoint.Prefix {
+		for part := 0; part < stored.Parts; part++ {
+			metadata, err := policy.Store.LoadListingCheckpointPart(policy.TaskID, part)
+			if err != nil {
+				return nil, err
+			}
+			for _, obj := range metadata {
+				objects = append(objects, objectInfo{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified, ETag: obj.ETag})
+			}
+		}
+		checkpoint.Parts = stored.Parts
+		pages.marker = aws.String(stored.Marker)
+		m.live.addListed(len(objects))
+		fmt.Printf("Resuming listing of %s after %q: %d objects were listed before the interruption\n", input.SourceBucket, stored.Marker, len(objects))
+	}
+
+	// A checkpoint that cannot be stored leaves the listing running without further checkpoints
+	saved := len(objects)
+	save := func() error {
+		part := make([]prefetch.ObjectMetadata, 0, len(objects)-saved)
+		for _, obj := range objects[saved:] {
+			part = append(part, prefetch.ObjectMetadata{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified, ETag: obj.ETag})
+		}
+		if err := policy.Store.SaveListingCheckpointPart(policy.TaskID, checkpoint.Parts, part); err != nil {
+			return err
+		}
+		checkpoint.Parts++
+		checkpoint.Marker = aws.ToString(pages.marker)
+		checkpoint.Objects = int64(len(objects))
+		checkpoint.UpdatedAt = time.Now().UTC()
+		saved = len(objects)
+		return policy.Store.SaveListingCheckpoint(checkpoint)
+	}
+	checkpointing := true
+
+	for {
+		previous := aws.ToString(pages.marker)
+		page, err := pages.next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			break
+		}
+		if pages.marker != nil && *pages.marker == previous {
+			return nil, fmt.Errorf("listing of %s does not advance past %q", input.SourceBucket, previous)
+		}
+		objects = append(objects, page...)
+		if checkpointing && pages.marker != nil && len(objects)-saved >= listingCheckpointObjects {
+			if err := save(); err != nil {
+				m.logger.Errorf("Listing checkpoints stopped: %v", err)
+				checkpointing = false
+			}
+		}
+	}
+
+	if err := policy.Store.DeleteListingCheckpoint(policy.TaskID); err != nil {
+		m.logger.Errorf("Failed to drop the listing checkpoint of task %s: %v", policy.TaskID, err)
+	}
+	fmt.Printf("Total objects found: %d (%d pages listed by this run)\n", len(objects), pages.count)
+	return objects, nil
+}
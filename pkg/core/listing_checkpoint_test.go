@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/pool"
+	"s3migration/pkg/prefetch"
+	"s3migration/pkg/state"
+)
+
+// interruptingCheckpointStore cancels the listing once its first checkpoint is stored
+type interruptingCheckpointStore struct {
+	*state.MemoryListingCheckpointStore
+	cancel context.CancelFunc
+}
+
+func (s *interruptingCheckpointStore) SaveListingCheckpoint(checkpoint prefetch.ListingCheckpoint) error {
+	err := s.MemoryListingCheckpointStore.SaveListingCheckpoint(checkpoint)
+	s.cancel()
+	return err
+}
+
+func TestListingResumesFromCheckpoint(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	for i := 0; i < 2500; i++ {
+		endpoint.Put("source", fmt.Sprintf("data/%04d", i), []byte("x"))
+	}
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(objects int) { listingCheckpointObjects = objects }(listingCheckpointObjects)
+	listingCheckpointObjects = 1000
+
+	store := state.NewMemoryListingCheckpointStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	input := MigrateInput{
+		SourceBucket:      "source",
+		SourcePrefix:      "data/",
+		ListingCheckpoint: &ListingCheckpointPolicy{TaskID: "task-1", Store: &interruptingCheckpointStore{MemoryListingCheckpointStore: store, cancel: cancel}},
+	}
+	if _, err := migrator.listSource(ctx, input); err == nil {
+		t.Fatal("interrupted listing completed")
+	}
+	checkpoint, _ := store.LoadListingCheckpoint("task-1")
+	if checkpoint == nil || checkpoint.Marker != "data/0999" || checkpoint.Parts != 1 || checkpoint.Objects != 1000 || checkpoint.Bucket != "source" {
+		t.Fatalf("checkpoint = %+v", checkpoint)
+	}
+
+	// Changed before the marker: the resumed listing keeps what was listed then
+	endpoint.Put("source", "data/0000", []byte("changed"))
+	input.ListingCheckpoint.Store = store
+	objects, err := migrator.listSource(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2500 || objects[0].Size != 1 || objects[1000].Key != "data/1000" || objects[2499].Key != "data/2499" {
+		t.Fatalf("resumed listing: %d objects, first %+v", len(objects), objects[0])
+	}
+	if checkpoint, _ := store.LoadListingCheckpoint("task-1"); checkpoint != nil {
+		t.Errorf("checkpoint kept after the listing completed: %+v", checkpoint)
+	}
+
+	// A checkpoint of another listing is not resumed
+	if err := store.SaveListingCheckpointPart("task-2", 0, []prefetch.ObjectMetadata{{Key: "other/a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveListingCheckpoint(prefetch.ListingCheckpoint{TaskID: "task-2", Bucket: "source", Prefix: "other/", Marker: "other/a", Parts: 1}); err != nil {
+		t.Fatal(err)
+	}
+	input.ListingCheckpoint.TaskID = "task-2"
+	objects, err = migrator.listSource(context.Background(), input)
+	if err != nil || len(objects) != 2500 || objects[0].Size != int64(len("changed")) {
+		t.Fatalf("listing with another checkpoint: %d objects, %v", len(objects), err)
+	}
+}
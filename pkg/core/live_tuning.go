@@ -0,0 +1,292 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"s3migration/pkg/adaptive"
+	"s3migration/pkg/models"
+)
+
+// maxTunableWorkers is the most worker goroutines one migration can be raised to
+const maxTunableWorkers = 500
+
+// TuningState is a snapshot of a running migration's internals
+type TuningState struct {
+	Phase         string                 `json:"phase"` // idle, listing, copying, done
+	TargetWorkers int                    `json:"target_workers"`
+	ActiveWorkers int                    `json:"active_workers"`
+	MaxWorkers    int                    `json:"max_workers"` // Upper bound for target_workers
+	QueueDepth    int                    `json:"queue_depth"`
+	ListingPaused bool                   `json:"listing_paused"`
+	ObjectsListed int64                  `json:"objects_listed"`
+	RateLimitRPS  float64                `json:"rate_limit_rps"` // 0 = unlimited
+	MemoryProfile adaptive.MemoryProfile `json:"memory_profile"`
+	Network       adaptive.NetworkStats  `json:"network"` // Measured from the transfers of the last minute
+	StopRequested bool                   `json:"stop_requested"`
+	InFlight      []InFlightObject       `json:"in_flight"` // Objects being copied, with part-level progress
+	// Process-wide large-object transfers (all tasks) and the LARGE_OBJECT_CONCURRENCY limit
+	LargeObjectsActive int `json:"large_objects_active"`
+	LargeObjectSlots   int `json:"large_object_slots"`
+	// Budget shared by all tasks writing to the destination endpoint
+	EndpointGroup *EndpointGroupStats `json:"endpoint_group,omitempty"`
+	// Automatic worker count changes of the copy phase, oldest first
+	WorkerAdjustments []models.WorkerAdjustment `json:"worker_adjustments,omitempty"`
+	// S3 requests made so far, by operation class
+	Requests models.APIRequests `json:"requests"`
+	// Latency percentiles of those requests, by endpoint and operation
+	Latency []models.OperationLatency `json:"latency,omitempty"`
+}
+
+// TuningUpdate holds operator changes; nil fields are left unchanged
+type TuningUpdate struct {
+	TargetWorkers *int     `json:"target_workers,omitempty"`
+	ListingPaused *bool    `json:"listing_paused,omitempty"`
+	RateLimitRPS  *float64 `json:"rate_limit_rps,omitempty"`
+}
+
+// liveControl lets an operator adjust a running migration without cancelling it
+type liveControl struct {
+	mu            sync.Mutex
+	cond          *sync.Cond
+	phase         string
+	target        int
+	limit         int // Most workers this copy phase may run
+	spawned       int // Workers started so far; started lazily as the target rises
+	spawn         func(id int)
+	active        int
+	finished      bool
+	listingPaused bool
+	objectsListed int64
+	queue         func() int
+	// Target set by the migration or an operator; autoscaling stays at or below it
+	ceiling     int
+	adjustments []models.WorkerAdjustment
+
+	// Request spacing for the optional rate limit
+	interval time.Duration
+	next     time.Time
+}
+
+func newLiveControl() *liveControl {
+	lc := &liveControl{phase: "idle"}
+	lc.cond = sync.NewCond(&lc.mu)
+	return lc
+}
+
+// start prepares the control for a copy phase of up to limit workers and starts
+// target of them with spawn; more are started when an operator raises the target
+func (lc *liveControl) start(target, limit int, queue func() int, spawn func(id int)) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if target > limit {
+		target = limit
+	}
+	lc.phase = "copying"
+	lc.target = target
+	lc.ceiling = target
+	lc.adjustments = nil
+	lc.limit = limit
+	lc.spawned = 0
+	lc.spawn = spawn
+	lc.active = 0
+	lc.finished = false
+	lc.queue = queue
+	lc.spawnLocked()
+}
+
+// spawnLocked starts workers up to the target. Workers only exit after the phase
+// has finished, so while it has not, the pool is still alive to grow.
+func (lc *liveControl) spawnLocked() {
+	if lc.finished || lc.spawn == nil {
+		return
+	}
+	for lc.spawned < lc.target {
+		lc.spawn(lc.spawned)
+		lc.spawned++
+	}
+}
+
+// setPhase records the current phase
+func (lc *liveControl) setPhase(phase string) {
+	lc.mu.Lock()
+	lc.phase = phase
+	lc.mu.Unlock()
+}
+
+// acquire blocks worker id until it is within the target worker count.
+// Returns false once the copy phase has finished.
+func (lc *liveControl) acquire(id int) bool {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	for id >= lc.target && !lc.finished {
+		lc.cond.Wait()
+	}
+	if lc.finished {
+		return false
+	}
+	lc.active++
+	return true
+}
+
+// release marks a worker as idle after processing a job
+func (lc *liveControl) release() {
+	lc.mu.Lock()
+	lc.active--
+	lc.mu.Unlock()
+}
+
+// finish wakes all parked workers so they can exit
+func (lc *liveControl) finish() {
+	lc.mu.Lock()
+	lc.finished = true
+	lc.phase = "done"
+	lc.spawn = nil
+	lc.mu.Unlock()
+	lc.cond.Broadcast()
+}
+
+// waitListing blocks while listing is paused
+func (lc *liveControl) waitListing(ctx context.Context) error {
+	for {
+		lc.mu.Lock()
+		paused := lc.listingPaused
+		lc.mu.Unlock()
+		if !paused {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// addListed counts objects found by listing
+func (lc *liveControl) addListed(n int) {
+	lc.mu.Lock()
+	lc.objectsListed += int64(n)
+	lc.mu.Unlock()
+}
+
+// throttle spaces requests out when a rate limit is set
+func (lc *liveControl) throttle(ctx context.Context) error {
+	lc.mu.Lock()
+	if lc.interval <= 0 {
+		lc.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	if lc.next.Before(now) {
+		lc.next = now
+	}
+	wait := lc.next.Sub(now)
+	lc.next = lc.next.Add(lc.interval)
+	lc.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// apply changes the live settings
+func (lc *liveControl) apply(update TuningUpdate) {
+	lc.mu.Lock()
+	if update.TargetWorkers != nil {
+		target := *update.TargetWorkers
+		if target < 1 {
+			target = 1
+		}
+		limit := lc.limit
+		if lc.spawn == nil && limit == 0 {
+			limit = maxTunableWorkers // No copy phase has started yet
+		}
+		if target > limit {
+			target = limit
+		}
+		lc.target = target
+		lc.ceiling = target
+		lc.spawnLocked()
+	}
+	if update.ListingPaused != nil {
+		lc.listingPaused = *update.ListingPaused
+	}
+	if update.RateLimitRPS != nil {
+		if rps := *update.RateLimitRPS; rps > 0 {
+			lc.interval = time.Duration(float64(time.Second) / rps)
+		} else {
+			lc.interval = 0
+		}
+	}
+	lc.mu.Unlock()
+
+	// Parked workers re-check their slot against the new target
+	lc.cond.Broadcast()
+}
+
+// snapshot returns the control's part of the tuning state
+func (lc *liveControl) snapshot() TuningState {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	state := TuningState{
+		Phase:         lc.phase,
+		TargetWorkers: lc.target,
+		ActiveWorkers: lc.active,
+		MaxWorkers:    lc.limit,
+		ListingPaused: lc.listingPaused,
+		ObjectsListed: lc.objectsListed,
+	}
+	state.WorkerAdjustments = append(state.WorkerAdjustments, lc.adjustments...)
+	if state.MaxWorkers == 0 {
+		state.MaxWorkers = maxTunableWorkers
+	}
+	if lc.queue != nil {
+		state.QueueDepth = lc.queue()
+	}
+	if lc.interval > 0 {
+		state.RateLimitRPS = float64(time.Second) / float64(lc.interval)
+	}
+	return state
+}
+
+// MemoryProfile returns the state of the migration's memory estimator
+func (m *EnhancedMigrator) MemoryProfile() adaptive.MemoryProfile {
+	return m.tuner.GetMemoryManager().Profile()
+}
+
+// GetTuningState returns a snapshot of the migrator's live internals
+func (m *EnhancedMigrator) GetTuningState() TuningState {
+	state := m.live.snapshot()
+	state.MemoryProfile = m.MemoryProfile()
+	state.Network = m.tuner.GetNetworkMonitor().Stats()
+	state.StopRequested = m.stopRequested.Load()
+	state.InFlight = m.inflight.snapshot()
+	state.LargeObjectsActive, state.LargeObjectSlots = largeObjects.usage()
+	state.Requests = m.RequestCounts()
+	state.Latency = m.Latencies()
+	if group := m.endpointGroup.Load(); group != nil {
+		stats := group.Stats()
+		state.EndpointGroup = &stats
+	}
+	return state
+}
+
+// ApplyTuning adjusts worker count, listing pause or rate limit on a running migration
+func (m *EnhancedMigrator) ApplyTuning(update TuningUpdate) TuningState {
+	m.live.apply(update)
+	return m.GetTuningState()
+}
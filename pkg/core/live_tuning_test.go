@@ -0,0 +1,54 @@
+package core
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestLiveControlSpawnsWorkersLazily(t *testing.T) {
+	lc := newLiveControl()
+	var mu sync.Mutex
+	var started []int
+	spawn := func(id int) {
+		mu.Lock()
+		started = append(started, id)
+		mu.Unlock()
+	}
+
+	lc.start(4, 10, nil, spawn)
+	if len(started) != 4 {
+		t.Fatalf("started %d workers, want the target of 4", len(started))
+	}
+
+	lower, higher, tooHigh := 2, 6, 50
+	lc.apply(TuningUpdate{TargetWorkers: &lower})
+	if len(started) != 4 {
+		t.Fatalf("lowering the target started workers: %v", started)
+	}
+	lc.apply(TuningUpdate{TargetWorkers: &higher})
+	if len(started) != 6 || started[5] != 5 {
+		t.Fatalf("raising the target to 6 started %v", started)
+	}
+	lc.apply(TuningUpdate{TargetWorkers: &tooHigh})
+	if len(started) != 10 {
+		t.Fatalf("target above the limit started %d workers, want 10", len(started))
+	}
+
+	lc.finish()
+	lc.apply(TuningUpdate{TargetWorkers: &tooHigh})
+	if len(started) != 10 {
+		t.Fatal("workers started after the copy phase finished")
+	}
+}
+
+func TestLiveControlEmptyPhaseNeverSpawns(t *testing.T) {
+	lc := newLiveControl()
+	spawned := 0
+	lc.start(8, 0, nil, func(int) { spawned++ })
+
+	target := 20
+	lc.apply(TuningUpdate{TargetWorkers: &target})
+	if spawned != 0 {
+		t.Fatalf("started %d workers for a phase without objects", spawned)
+	}
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Listing and HEAD requests go through their own source clients (and so their own
+// connections) and at most MetadataConcurrency HEADs are in flight at once, so a
+// list-heavy phase does not hold the connections copies need, and a burst of
+// HEADs from the copy workers does not queue behind transfers or swamp the source.
+const (
+	defaultMetadataConcurrency = 32
+	minMetadataPoolSize        = 2
+)
+
+// metadataPoolSize returns the number of metadata clients for a config
+func metadataPoolSize(config EnhancedMigratorConfig) int {
+	if config.MetadataPoolSize > 0 {
+		return config.MetadataPoolSize
+	}
+	return max(minMetadataPoolSize, config.ConnectionPoolSize/4)
+}
+
+// metadataClient returns a source client for listing and HEAD requests
+func (m *EnhancedMigrator) metadataClient() *s3.Client {
+	return m.metadataPool.GetClient()
+}
+
+// headObject issues a HEAD request within the metadata concurrency limit
+func (m *EnhancedMigrator) headObject(ctx context.Context, client *s3.Client, input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	select {
+	case m.metadataSlots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-m.metadataSlots }()
+	return client.HeadObject(ctx, input)
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/pool"
+)
+
+// requestLog records the requests sent through a client and the most HEADs in flight at once
+type requestLog struct {
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	requests []string
+	heads    int
+	maxHeads int
+}
+
+func (l *requestLog) Do(req *http.Request) (*http.Response, error) {
+	kind := req.Method
+	if req.Method == http.MethodGet && !strings.Contains(strings.Trim(req.URL.Path, "/"), "/") {
+		kind = "LIST" // Bucket-level GET: listings and bucket configuration
+	}
+	l.mu.Lock()
+	l.requests = append(l.requests, kind)
+	if kind == http.MethodHead {
+		l.heads++
+		l.maxHeads = max(l.maxHeads, l.heads)
+	}
+	l.mu.Unlock()
+
+	if kind == http.MethodHead {
+		time.Sleep(5 * time.Millisecond) // Long enough for concurrent HEADs to overlap
+		defer func() {
+			l.mu.Lock()
+			l.heads--
+			l.mu.Unlock()
+		}()
+	}
+	return l.next.RoundTrip(req)
+}
+
+func (l *requestLog) count(kind string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, request := range l.requests {
+		if request == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// loggedClient returns a client of the fake endpoint whose requests are recorded
+func loggedClient(endpoint *fakes3.Server) (*s3.Client, *requestLog) {
+	log := &requestLog{next: http.DefaultTransport}
+	client := s3.New(endpoint.Client().Options(), func(o *s3.Options) { o.HTTPClient = log })
+	return client, log
+}
+
+func TestMetadataRequestsUseTheirOwnClients(t *testing.T) {
+	endpoint := fakes3.New("src", "dest")
+	defer endpoint.Close()
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		endpoint.Put("src", key, []byte("content of "+key))
+	}
+
+	transferClient, transfers := loggedClient(endpoint)
+	metadataClient, metadata := loggedClient(endpoint)
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool:         pool.NewStaticConnectionPool(transferClient),
+		MetadataConnectionPool: pool.NewStaticConnectionPool(metadataClient),
+		MetadataConcurrency:    2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := migrator.Migrate(context.Background(), MigrateInput{
+		SourceBucket:  "src",
+		DestBucket:    "dest",
+		MigrationMode: ModeFullRewrite,
+		VerifyWrite:   true,
+		Timeout:       time.Minute,
+	})
+	if err != nil || result.Copied != 8 {
+		t.Fatalf("copied %d: %v %v", result.Copied, err, result.Errors)
+	}
+
+	if n := transfers.count(http.MethodHead) + transfers.count("LIST"); n != 0 {
+		t.Errorf("%d listing or HEAD requests on the transfer clients", n)
+	}
+	if transfers.count(http.MethodPut) < 8 {
+		t.Errorf("copies did not use the transfer clients: %v", transfers.requests)
+	}
+	if metadata.count("LIST") == 0 || metadata.count(http.MethodHead) < 16 {
+		t.Errorf("listing and HEADs (source and verification) not on the metadata clients: %v", metadata.requests)
+	}
+	if metadata.maxHeads > 2 {
+		t.Errorf("%d HEAD requests in flight, limit is 2", metadata.maxHeads)
+	}
+}
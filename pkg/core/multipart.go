@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync/atomic"
+
+	"s3migration/pkg/config"
+	"s3migration/pkg/models"
+)
+
+// MultipartSettingsFor resolves the multipart settings of a migration: the provider
+// preset (named in opts or detected from the destination endpoint) with the
+// request's overrides applied
+func MultipartSettingsFor(opts *models.MultipartOptions, destEndpointURL string) (config.MultipartSettings, error) {
+	provider := config.DetectProvider(destEndpointURL)
+	if opts != nil && opts.Provider != "" {
+		provider = config.S3Provider(opts.Provider)
+		if _, ok := config.ProviderPresets()[provider]; !ok {
+			return config.MultipartSettings{}, fmt.Errorf("unknown provider %q", opts.Provider)
+		}
+	}
+
+	settings := config.MultipartPreset(provider)
+	if opts != nil {
+		const mib = 1024 * 1024
+		settings = settings.Override(config.MultipartSettings{
+			ThresholdBytes:  opts.ThresholdMB * mib,
+			PartSizeBytes:   opts.PartSizeMB * mib,
+			MaxParts:        opts.MaxParts,
+			PartConcurrency: opts.PartConcurrency,
+		})
+	}
+	if err := settings.Validate(); err != nil {
+		return config.MultipartSettings{}, err
+	}
+	return settings, nil
+}
+
+// defaultLargeObjectConcurrency is how many large objects may transfer at once across all tasks
+const defaultLargeObjectConcurrency = 4
+
+// largeObjectSemaphore caps concurrent large-object transfers process-wide, so
+// per-object part concurrency times running large objects stays bounded
+type largeObjectSemaphore struct {
+	slots  chan struct{}
+	active atomic.Int32
+}
+
+// largeObjects is shared by every migrator; sized by LARGE_OBJECT_CONCURRENCY
+var largeObjects = newLargeObjectSemaphore(envInt("LARGE_OBJECT_CONCURRENCY", defaultLargeObjectConcurrency))
+
+func newLargeObjectSemaphore(n int) *largeObjectSemaphore {
+	if n < 1 {
+		n = 1
+	}
+	return &largeObjectSemaphore{slots: make(chan struct{}, n)}
+}
+
+// acquire waits for a slot; fails only when ctx is done
+func (s *largeObjectSemaphore) acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		s.active.Add(1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by acquire
+func (s *largeObjectSemaphore) release() {
+	s.active.Add(-1)
+	<-s.slots
+}
+
+// usage returns the transfers holding a slot and the number of slots
+func (s *largeObjectSemaphore) usage() (int, int) {
+	return int(s.active.Load()), cap(s.slots)
+}
+
+// envInt reads a positive integer environment variable, returning fallback if unset or invalid
+func envInt(name string, fallback int) int {
+	if value := os.Getenv(name); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			return parsed
+		}
+		fmt.Printf("⚠️  Invalid %s=%q, using %d\n", name, value, fallback)
+	}
+	return fallback
+}
@@ -0,0 +1,185 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ObjectState is what HeadObject and GetObjectTagging return for one side of an object diff
+type ObjectState struct {
+	Bucket             string            `json:"bucket"`
+	Key                string            `json:"key"`
+	Exists             bool              `json:"exists"`
+	Size               int64             `json:"size"`
+	ETag               string            `json:"etag,omitempty"`
+	LastModified       *time.Time        `json:"last_modified,omitempty"`
+	StorageClass       string            `json:"storage_class,omitempty"`
+	VersionID          string            `json:"version_id,omitempty"`
+	ContentType        string            `json:"content_type,omitempty"`
+	ContentEncoding    string            `json:"content_encoding,omitempty"`
+	ContentDisposition string            `json:"content_disposition,omitempty"`
+	CacheControl       string            `json:"cache_control,omitempty"`
+	WebsiteRedirect    string            `json:"website_redirect,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`  // x-amz-meta-* values
+	Checksums          map[string]string `json:"checksums,omitempty"` // CRC32, CRC32C, SHA1 and SHA256 the endpoint stored
+	Tags               map[string]string `json:"tags,omitempty"`
+	TagsError          string            `json:"tags_error,omitempty"` // Tags could not be read, e.g. on providers without tagging
+	Error              string            `json:"error,omitempty"`      // HeadObject failed for another reason than a missing object
+}
+
+// ObjectDiff puts the source and destination states of one object side by side.
+// Differences names the fields that differ: "exists", "size", "etag",
+// "content_type", "content_encoding", "content_disposition", "cache_control",
+// "website_redirect", "metadata", "checksum_<algorithm>" (only when both sides
+// stored that checksum) and "tags" (only when both sides could be read).
+// An ETag may differ without the content differing when the sides were
+// uploaded in different multipart part sizes.
+type ObjectDiff struct {
+	Source      ObjectState `json:"source"`
+	Dest        ObjectState `json:"dest"`
+	Differences []string    `json:"differences"`
+	Match       bool        `json:"match"`
+}
+
+// DiffObjects diffs source keys with the destination keys a migration to
+// destPrefix writes them to, one at a time
+func DiffObjects(ctx context.Context, sourceClient, destClient *s3.Client, sourceBucket, destBucket, destPrefix string, keys []string) []ObjectDiff {
+	diffs := make([]ObjectDiff, 0, len(keys))
+	for _, key := range keys {
+		diffs = append(diffs, DiffObject(ctx, sourceClient, destClient, sourceBucket, key, destBucket, destKeyFor(key, destPrefix)))
+	}
+	return diffs
+}
+
+// DiffObject describes sourceKey and destKey and lists where they differ
+func DiffObject(ctx context.Context, sourceClient, destClient *s3.Client, sourceBucket, sourceKey, destBucket, destKey string) ObjectDiff {
+	diff := ObjectDiff{
+		Source:      describeObject(ctx, sourceClient, sourceBucket, sourceKey),
+		Dest:        describeObject(ctx, destClient, destBucket, destKey),
+		Differences: []string{},
+	}
+	source, dest := diff.Source, diff.Dest
+	if source.Error != "" || dest.Error != "" {
+		return diff
+	}
+	if source.Exists != dest.Exists {
+		diff.Differences = append(diff.Differences, "exists")
+		return diff
+	}
+	if !source.Exists {
+		return diff
+	}
+
+	fields := []struct {
+		name         string
+		source, dest string
+	}{
+		{"etag", strings.Trim(source.ETag, `"`), strings.Trim(dest.ETag, `"`)},
+		{"content_type", source.ContentType, dest.ContentType},
+		{"content_encoding", source.ContentEncoding, dest.ContentEncoding},
+		{"content_disposition", source.ContentDisposition, dest.ContentDisposition},
+		{"cache_control", source.CacheControl, dest.CacheControl},
+		{"website_redirect", source.WebsiteRedirect, dest.WebsiteRedirect},
+	}
+	if source.Size != dest.Size {
+		diff.Differences = append(diff.Differences, "size")
+	}
+	for _, field := range fields {
+		if field.source != field.dest {
+			diff.Differences = append(diff.Differences, field.name)
+		}
+	}
+	if !sameStrings(source.Metadata, dest.Metadata) {
+		diff.Differences = append(diff.Differences, "metadata")
+	}
+	for _, algorithm := range []string{"crc32", "crc32c", "sha1", "sha256"} {
+		s, d := source.Checksums[algorithm], dest.Checksums[algorithm]
+		if s != "" && d != "" && s != d {
+			diff.Differences = append(diff.Differences, "checksum_"+algorithm)
+		}
+	}
+	if source.TagsError == "" && dest.TagsError == "" && !sameStrings(source.Tags, dest.Tags) {
+		diff.Differences = append(diff.Differences, "tags")
+	}
+	diff.Match = len(diff.Differences) == 0
+	return diff
+}
+
+// describeObject reads the head and tags of one object
+func describeObject(ctx context.Context, client *s3.Client, bucket, key string) ObjectState {
+	state := ObjectState{Bucket: bucket, Key: key}
+	head, err := client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(key),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return state
+	}
+	if err != nil {
+		state.Error = err.Error()
+		return state
+	}
+
+	state.Exists = true
+	state.Size = aws.ToInt64(head.ContentLength)
+	state.ETag = aws.ToString(head.ETag)
+	state.LastModified = head.LastModified
+	state.StorageClass = string(head.StorageClass)
+	state.VersionID = aws.ToString(head.VersionId)
+	state.ContentType = aws.ToString(head.ContentType)
+	state.ContentEncoding = aws.ToString(head.ContentEncoding)
+	state.ContentDisposition = aws.ToString(head.ContentDisposition)
+	state.CacheControl = aws.ToString(head.CacheControl)
+	state.WebsiteRedirect = aws.ToString(head.WebsiteRedirectLocation)
+	if len(head.Metadata) > 0 {
+		state.Metadata = head.Metadata
+	}
+	checksums := map[string]string{
+		"crc32":  aws.ToString(head.ChecksumCRC32),
+		"crc32c": aws.ToString(head.ChecksumCRC32C),
+		"sha1":   aws.ToString(head.ChecksumSHA1),
+		"sha256": aws.ToString(head.ChecksumSHA256),
+	}
+	for algorithm, value := range checksums {
+		if value != "" {
+			if state.Checksums == nil {
+				state.Checksums = make(map[string]string)
+			}
+			state.Checksums[algorithm] = value
+		}
+	}
+
+	tagging, err := client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		state.TagsError = err.Error()
+		return state
+	}
+	for _, tag := range tagging.TagSet {
+		if state.Tags == nil {
+			state.Tags = make(map[string]string)
+		}
+		state.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return state
+}
+
+// sameStrings reports whether two maps hold the same entries (nil and empty are the same)
+func sameStrings(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
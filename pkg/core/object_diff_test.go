@@ -0,0 +1,71 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"s3migration/pkg/fakes3"
+)
+
+func TestDiffObjects(t *testing.T) {
+	endpoint := fakes3.New("source", "dest")
+	defer endpoint.Close()
+	client := endpoint.Client()
+	put := func(bucket, key, body, contentType string, metadata, tags map[string]string) {
+		t.Helper()
+		if _, err := client.PutObject(context.Background(), &s3.PutObjectInput{
+			Bucket: aws.String(bucket), Key: aws.String(key), Body: strings.NewReader(body),
+			ContentType: aws.String(contentType), Metadata: metadata,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		tagSet := []types.Tag{}
+		for key, value := range tags {
+			tagSet = append(tagSet, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+		}
+		if _, err := client.PutObjectTagging(context.Background(), &s3.PutObjectTaggingInput{
+			Bucket: aws.String(bucket), Key: aws.String(key), Tagging: &types.Tagging{TagSet: tagSet},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	put("source", "same.txt", "alpha", "text/plain", map[string]string{"owner": "ops"}, map[string]string{"team": "a"})
+	put("dest", "backup/same.txt", "alpha", "text/plain", map[string]string{"owner": "ops"}, map[string]string{"team": "a"})
+	put("source", "changed.txt", "bravo", "text/plain", map[string]string{"owner": "ops"}, map[string]string{"team": "a"})
+	put("dest", "backup/changed.txt", "bravo!", "application/octet-stream", nil, nil)
+	put("source", "missing.txt", "charlie", "text/plain", nil, nil)
+
+	diffs := DiffObjects(context.Background(), client, client, "source", "dest", "backup", []string{"same.txt", "changed.txt", "missing.txt", "nowhere.txt"})
+	tests := []struct {
+		key         string
+		destExists  bool
+		differences []string
+		match       bool
+	}{
+		{key: "same.txt", destExists: true, differences: []string{}, match: true},
+		{key: "changed.txt", destExists: true, differences: []string{"size", "etag", "content_type", "metadata", "tags"}},
+		{key: "missing.txt", differences: []string{"exists"}},
+		{key: "nowhere.txt", differences: []string{}},
+	}
+	for i, tt := range tests {
+		diff := diffs[i]
+		if diff.Source.Key != tt.key || diff.Dest.Key != "backup/"+tt.key {
+			t.Errorf("%s: compared %s with %s", tt.key, diff.Source.Key, diff.Dest.Key)
+		}
+		if diff.Dest.Exists != tt.destExists || diff.Match != tt.match || !reflect.DeepEqual(diff.Differences, tt.differences) {
+			t.Errorf("%s: dest exists %v, match %v, differences %v", tt.key, diff.Dest.Exists, diff.Match, diff.Differences)
+		}
+		if diff.Source.Error != "" || diff.Dest.Error != "" {
+			t.Errorf("%s: errors %q, %q", tt.key, diff.Source.Error, diff.Dest.Error)
+		}
+	}
+	if source := diffs[0].Source; source.Size != 5 || source.Metadata["owner"] != "ops" || source.Tags["team"] != "a" || source.LastModified == nil {
+		t.Errorf("source state = %+v", source)
+	}
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// ObjectOrder is the order in which objects are queued for copying
+type ObjectOrder string
+
+const (
+	// OrderListing queues objects as listed (by key)
+	OrderListing ObjectOrder = "listing"
+	// OrderLargestFirst starts long transfers early instead of leaving them as a tail
+	OrderLargestFirst ObjectOrder = "largest_first"
+	// OrderSmallestFirst completes many objects quickly for visible progress
+	OrderSmallestFirst ObjectOrder = "smallest_first"
+	// OrderRandom shuffles keys so concurrent requests spread over key prefixes
+	// (and so the provider's request partitions) instead of hitting one at a time
+	OrderRandom ObjectOrder = "random"
+)
+
+// ValidateObjectOrder checks that an object order is supported; empty means OrderListing
+func ValidateObjectOrder(order ObjectOrder) error {
+	switch order {
+	case "", OrderListing, OrderLargestFirst, OrderSmallestFirst, OrderRandom:
+		return nil
+	default:
+		return fmt.Errorf("unsupported object_order %q (expected listing, largest_first, smallest_first or random)", order)
+	}
+}
+
+// orderObjects returns the objects in the copy order; objects itself is not
+// reordered. Equal sizes keep key order, so the order is deterministic.
+func orderObjects(objects []objectInfo, order ObjectOrder, rng *rand.Rand) []objectInfo {
+	if order == "" || order == OrderListing || len(objects) < 2 {
+		return objects
+	}
+	ordered := append([]objectInfo(nil), objects...)
+	switch order {
+	case OrderLargestFirst:
+		sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Size > ordered[j].Size })
+	case OrderSmallestFirst:
+		sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Size < ordered[j].Size })
+	case OrderRandom:
+		rng.Shuffle(len(ordered), func(i, j int) { ordered[i], ordered[j] = ordered[j], ordered[i] })
+	}
+	return ordered
+}
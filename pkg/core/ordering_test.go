@@ -0,0 +1,47 @@
+package core
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestOrderObjects(t *testing.T) {
+	listed := []objectInfo{{Key: "a", Size: 5}, {Key: "b", Size: 900}, {Key: "c", Size: 5}, {Key: "d", Size: 40}, {Key: "e", Size: 1}}
+	keys := func(objects []objectInfo) string {
+		var b strings.Builder
+		for _, obj := range objects {
+			b.WriteString(obj.Key)
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		order ObjectOrder
+		want  string
+	}{
+		{order: "", want: "abcde"},
+		{order: OrderListing, want: "abcde"},
+		{order: OrderLargestFirst, want: "bdace"},
+		{order: OrderSmallestFirst, want: "eacdb"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			if got := keys(orderObjects(listed, tt.order, nil)); got != tt.want {
+				t.Fatalf("order %q = %s, want %s", tt.order, got, tt.want)
+			}
+		})
+	}
+
+	shuffled := orderObjects(listed, OrderRandom, rand.New(rand.NewSource(1)))
+	if len(shuffled) != len(listed) || keys(shuffled) == "abcde" {
+		t.Fatalf("random order = %s", keys(shuffled))
+	}
+	if keys(listed) != "abcde" {
+		t.Fatalf("listing reordered in place: %s", keys(listed))
+	}
+
+	if err := ValidateObjectOrder("biggest"); err == nil {
+		t.Fatal("unknown order accepted")
+	}
+}
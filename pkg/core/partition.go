@@ -0,0 +1,76 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"s3migration/pkg/models"
+)
+
+// DefaultPartitionLayout puts objects under YYYY/MM/DD of their last modification
+const DefaultPartitionLayout = "{YYYY}/{MM}/{DD}"
+
+// partitionPlaceholders maps layout placeholders to time formats
+var partitionPlaceholders = map[string]string{
+	"{YYYY}": "2006",
+	"{MM}":   "01",
+	"{DD}":   "02",
+	"{HH}":   "15",
+}
+
+var partitionPlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// PartitionPolicy rewrites destination keys into a date-partitioned layout
+// derived from each object's LastModified
+type PartitionPolicy struct {
+	Layout   string         // Path with {YYYY}, {MM}, {DD} and {HH} placeholders
+	Location *time.Location // Time zone the dates are taken in
+}
+
+// PartitionPolicyFor builds the partitioning of a request; nil options mean keys are kept as-is
+func PartitionPolicyFor(opts *models.PartitionOptions) (*PartitionPolicy, error) {
+	if opts == nil || !opts.Enabled {
+		return nil, nil
+	}
+	layout := strings.Trim(opts.Layout, "/")
+	if layout == "" {
+		layout = DefaultPartitionLayout
+	}
+	placeholders := partitionPlaceholder.FindAllString(layout, -1)
+	if len(placeholders) == 0 {
+		return nil, fmt.Errorf("partition layout %q has no date placeholder (expected {YYYY}, {MM}, {DD} or {HH})", layout)
+	}
+	for _, placeholder := range placeholders {
+		if _, ok := partitionPlaceholders[placeholder]; !ok {
+			return nil, fmt.Errorf("unsupported placeholder %s in partition layout (expected {YYYY}, {MM}, {DD} or {HH})", placeholder)
+		}
+	}
+
+	location := time.UTC
+	if opts.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(opts.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid partition timezone %q: %w", opts.Timezone, err)
+		}
+	}
+	return &PartitionPolicy{Layout: layout, Location: location}, nil
+}
+
+// Key returns the partitioned form of key, or key itself when p is nil
+func (p *PartitionPolicy) Key(key string, lastModified time.Time) string {
+	if p == nil {
+		return key
+	}
+	t := lastModified.In(p.Location)
+	partition := partitionPlaceholder.ReplaceAllStringFunc(p.Layout, func(placeholder string) string {
+		return t.Format(partitionPlaceholders[placeholder])
+	})
+	return partition + "/" + key
+}
+
+// destKeyForObject returns the destination key a listed source object is copied to
+func destKeyForObject(obj objectInfo, destPrefix string, partition *PartitionPolicy, sanitize *KeySanitizePolicy) string {
+	return sanitize.DestKey(destKeyFor(partition.Key(sanitize.Key(obj.Key), obj.LastModified), destPrefix))
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+)
+
+func TestPartitionPolicy(t *testing.T) {
+	modified := time.Date(2024, 3, 17, 23, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		opts    *models.PartitionOptions
+		want    string
+		wantErr bool
+	}{
+		{name: "disabled", opts: &models.PartitionOptions{Layout: "{YYYY}"}, want: "app.log"},
+		{name: "default layout", opts: &models.PartitionOptions{Enabled: true}, want: "2024/03/17/app.log"},
+		{name: "hive layout", opts: &models.PartitionOptions{Enabled: true, Layout: "/year={YYYY}/month={MM}/day={DD}/hour={HH}/"}, want: "year=2024/month=03/day=17/hour=23/app.log"},
+		{name: "timezone", opts: &models.PartitionOptions{Enabled: true, Timezone: "Asia/Ho_Chi_Minh"}, want: "2024/03/18/app.log"},
+		{name: "no placeholder", opts: &models.PartitionOptions{Enabled: true, Layout: "logs"}, wantErr: true},
+		{name: "unknown placeholder", opts: &models.PartitionOptions{Enabled: true, Layout: "{YYYY}/{WW}"}, wantErr: true},
+		{name: "bad timezone", opts: &models.PartitionOptions{Enabled: true, Timezone: "Mars/Olympus"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := PartitionPolicyFor(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PartitionPolicyFor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := policy.Key("app.log", modified); got != tt.want {
+				t.Fatalf("Key() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateIntoPartitions(t *testing.T) {
+	endpoint := fakes3.New("logs", "lake")
+	defer endpoint.Close()
+	endpoint.Put("logs", "app.log", []byte("line"))
+	partition, _ := PartitionPolicyFor(&models.PartitionOptions{Enabled: true})
+	wantKey := "raw/" + partition.Key("app.log", endpoint.Get("logs", "app.log").LastModified)
+
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := MigrateInput{
+		SourceBucket:  "logs",
+		DestBucket:    "lake",
+		DestPrefix:    "raw",
+		MigrationMode: ModeIncremental,
+		Partition:     partition,
+		Timeout:       time.Minute,
+	}
+	if _, err := migrator.Migrate(context.Background(), input); err != nil {
+		t.Fatal(err)
+	}
+	if keys := endpoint.Keys("lake"); len(keys) != 1 || keys[0] != wantKey {
+		t.Fatalf("lake keys = %v, want [%s]", keys, wantKey)
+	}
+
+	// The partitioned copy is recognized as up to date on the next incremental run
+	result, err := migrator.Migrate(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Copied != 0 {
+		t.Fatalf("second run copied %d objects, want 0", result.Copied)
+	}
+}
@@ -0,0 +1,266 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	pkgSync "s3migration/pkg/sync"
+)
+
+// DiffAction is what a migration would do with one source object
+type DiffAction string
+
+const (
+	DiffCreate    DiffAction = "create"    // Key does not exist in destination
+	DiffOverwrite DiffAction = "overwrite" // Key exists and would be replaced
+	DiffSkip      DiffAction = "skip"      // Key exists and would be left alone
+)
+
+// maxDiffEntries caps the per-key details kept for a dry-run diff; counts are always exact
+const maxDiffEntries = 100000
+
+// DiffEntry describes the planned action for one key
+type DiffEntry struct {
+	Key            string     `json:"key"`
+	DestKey        string     `json:"dest_key"`
+	Action         DiffAction `json:"action"`
+	Reason         string     `json:"reason,omitempty"`
+	SourceSize     int64      `json:"source_size"`
+	DestSize       int64      `json:"dest_size,omitempty"`
+	SourceModified string     `json:"source_modified,omitempty"`
+	DestModified   string     `json:"dest_modified,omitempty"`
+}
+
+// DiffSummary reports what a migration would change in the destination
+type DiffSummary struct {
+	MigrationMode    MigrationMode            `json:"migration_mode"`
+	ConflictStrategy pkgSync.ConflictStrategy `json:"conflict_strategy,omitempty"`
+	Creates          int64                    `json:"creates"`
+	Overwrites       int64                    `json:"overwrites"`
+	Skips            int64                    `json:"skips"`
+	CreateBytes      int64                    `json:"create_bytes"`
+	OverwriteBytes   int64                    `json:"overwrite_bytes"`
+	Entries          []DiffEntry              `json:"-"`
+	Truncated        bool                     `json:"truncated"` // Entries capped at maxDiffEntries
+}
+
+// ValidateConflictStrategy checks that a conflict strategy is supported by S3 migrations
+func ValidateConflictStrategy(strategy pkgSync.ConflictStrategy) error {
+	switch strategy {
+	case "", pkgSync.ConflictSource, pkgSync.ConflictDest, pkgSync.ConflictNewest, pkgSync.ConflictSkip:
+		return nil
+	default:
+		return fmt.Errorf("unsupported conflict_strategy %q (expected source, dest, newest or skip)", strategy)
+	}
+}
+
+// destKeyFor returns the destination key a source key is copied to
+func destKeyFor(sourceKey, destPrefix string) string {
+	if destPrefix != "" {
+		return destPrefix + "/" + sourceKey
+	}
+	return sourceKey
+}
+
+// classifyObject decides what happens to one source object given its destination counterpart.
+// In incremental mode unchanged objects are skipped first; the conflict strategy then
+// decides between overwrite and skip for every remaining key that already exists.
+func classifyObject(source objectInfo, dest *objectInfo, mode MigrationMode, strategy pkgSync.ConflictStrategy) (DiffAction, string) {
+	if dest == nil {
+		return DiffCreate, "new"
+	}
+
+	if mode == ModeIncremental {
+		sizeChanged := source.Size != dest.Size
+		timeChanged := source.LastModified.After(dest.LastModified)
+		if !sizeChanged && !timeChanged {
+			return DiffSkip, "unchanged"
+		}
+	}
+
+	switch strategy {
+	case pkgSync.ConflictDest, pkgSync.ConflictSkip:
+		return DiffSkip, fmt.Sprintf("exists in destination (conflict strategy: %s)", strategy)
+	case pkgSync.ConflictNewest:
+		if source.LastModified.After(dest.LastModified) {
+			return DiffOverwrite, "source is newer"
+		}
+		return DiffSkip, "destination is newer or same age"
+	}
+
+	if mode == ModeIncremental {
+		return DiffOverwrite, "changed"
+	}
+	return DiffOverwrite, "exists in destination (full rewrite)"
+}
+
+// planMigration classifies every source object against the destination listing.
+// Returns the objects to copy and a summary of the counts; per-key details are
+// only collected when withDiff is set.
+func planMigration(objects, destObjects []objectInfo, destPrefix string, partition *PartitionPolicy, sanitize *KeySanitizePolicy, mode MigrationMode, strategy pkgSync.ConflictStrategy, withDiff bool) ([]objectInfo, *DiffSummary) {
+	destMap := make(map[string]objectInfo, len(destObjects))
+	for _, obj := range destObjects {
+		destMap[obj.Key] = obj
+	}
+
+	plan := newPlanner(mode, strategy, withDiff)
+	var toCopy []objectInfo
+	for _, obj := range objects {
+		destKey := destKeyForObject(obj, destPrefix, partition, sanitize)
+
+		var dest *objectInfo
+		if existing, ok := destMap[destKey]; ok {
+			dest = &existing
+		}
+
+		if plan.classify(obj, destKey, dest) {
+			toCopy = append(toCopy, obj)
+		}
+	}
+
+	return toCopy, plan.summary
+}
+
+// errUnsortedListing means a provider listed keys out of order, so a merge cannot be trusted
+var errUnsortedListing = errors.New("destination listing is not in key order")
+
+// planMigrationMerged plans like planMigration, but walks the destination listing
+// page by page (nextDest returns nil at the end) alongside the source objects in
+// destination key order. S3 lists keys in byte order, so each destination object
+// is looked at once and only the current page is held. Returns errUnsortedListing
+// when the destination pages are not in key order.
+func planMigrationMerged(objects []objectInfo, nextDest func() ([]objectInfo, error), destPrefix string, partition *PartitionPolicy, sanitize *KeySanitizePolicy, mode MigrationMode, strategy pkgSync.ConflictStrategy, withDiff bool) ([]objectInfo, *DiffSummary, error) {
+	// Source listings are in key order and a destination prefix keeps that order;
+	// date partitions do not, so those objects are visited sorted by destination key
+	destKeyOf := func(i int) string { return destKeyForObject(objects[i], destPrefix, partition, sanitize) }
+	var order []int // nil: objects are already in destination key order
+	if !sort.SliceIsSorted(objects, func(a, b int) bool { return destKeyOf(a) < destKeyOf(b) }) {
+		destKeys := make([]string, len(objects))
+		order = make([]int, len(objects))
+		for i := range objects {
+			destKeys[i] = destKeyOf(i)
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool { return destKeys[order[a]] < destKeys[order[b]] })
+		destKeyOf = func(i int) string { return destKeys[i] }
+	}
+
+	plan := newPlanner(mode, strategy, withDiff)
+	var toCopy []objectInfo
+	var copying []bool // Marks objects visited out of order, so toCopy keeps the source order
+	if order != nil {
+		copying = make([]bool, len(objects))
+	}
+	var page []objectInfo
+	var last string
+	done := false
+	for n := range objects {
+		i := n
+		if order != nil {
+			i = order[n]
+		}
+		destKey := destKeyOf(i)
+
+		// Skip destination keys before this one; they have no source object
+		var dest *objectInfo
+		for !done {
+			if len(page) == 0 {
+				next, err := nextDest()
+				if err != nil {
+					return nil, nil, err
+				}
+				if next == nil {
+					done = true
+					break
+				}
+				page = next
+			}
+			if page[0].Key < last {
+				return nil, nil, errUnsortedListing
+			}
+			last = page[0].Key
+			if last > destKey {
+				break
+			}
+			if last == destKey {
+				dest = &page[0]
+				break
+			}
+			page = page[1:]
+		}
+
+		switch copied := plan.classify(objects[i], destKey, dest); {
+		case order != nil:
+			copying[i] = copied
+		case copied:
+			toCopy = append(toCopy, objects[i])
+		}
+	}
+
+	for i, copied := range copying {
+		if copied {
+			toCopy = append(toCopy, objects[i])
+		}
+	}
+	return toCopy, plan.summary, nil
+}
+
+// planner counts the actions of a plan and collects its per-key details
+type planner struct {
+	mode     MigrationMode
+	strategy pkgSync.ConflictStrategy
+	withDiff bool
+	summary  *DiffSummary
+}
+
+func newPlanner(mode MigrationMode, strategy pkgSync.ConflictStrategy, withDiff bool) *planner {
+	return &planner{
+		mode:     mode,
+		strategy: strategy,
+		withDiff: withDiff,
+		summary: &DiffSummary{
+			MigrationMode:    mode,
+			ConflictStrategy: strategy,
+		},
+	}
+}
+
+// classify records what happens to one source object and reports whether it is copied
+func (p *planner) classify(obj objectInfo, destKey string, dest *objectInfo) bool {
+	summary := p.summary
+	action, reason := classifyObject(obj, dest, p.mode, p.strategy)
+
+	switch action {
+	case DiffCreate:
+		summary.Creates++
+		summary.CreateBytes += obj.Size
+	case DiffOverwrite:
+		summary.Overwrites++
+		summary.OverwriteBytes += obj.Size
+	case DiffSkip:
+		summary.Skips++
+	}
+
+	if !p.withDiff {
+		return action != DiffSkip
+	}
+	if len(summary.Entries) >= maxDiffEntries {
+		summary.Truncated = true
+		return action != DiffSkip
+	}
+	entry := DiffEntry{
+		Key:            obj.Key,
+		DestKey:        destKey,
+		Action:         action,
+		Reason:         reason,
+		SourceSize:     obj.Size,
+		SourceModified: formatTime(obj.LastModified),
+	}
+	if dest != nil {
+		entry.DestSize = dest.Size
+		entry.DestModified = formatTime(dest.LastModified)
+	}
+	summary.Entries = append(summary.Entries, entry)
+	return action != DiffSkip
+}
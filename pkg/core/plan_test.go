@@ -0,0 +1,198 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+	pkgSync "s3migration/pkg/sync"
+)
+
+func TestPlanMigration(t *testing.T) {
+	older := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	source := []objectInfo{
+		{Key: "data/new.txt", Size: 1, LastModified: newer},
+		{Key: "data/same.txt", Size: 2, LastModified: older},
+		{Key: "data/changed.txt", Size: 3, LastModified: newer},
+	}
+	dest := []objectInfo{
+		{Key: "backup/data/same.txt", Size: 2, LastModified: older},
+		{Key: "backup/data/changed.txt", Size: 3, LastModified: older},
+	}
+
+	tests := []struct {
+		name     string
+		mode     MigrationMode
+		strategy pkgSync.ConflictStrategy
+		want     [3]int64 // creates, overwrites, skips
+	}{
+		{"incremental", ModeIncremental, "", [3]int64{1, 1, 1}},
+		{"incremental keeps destination", ModeIncremental, pkgSync.ConflictDest, [3]int64{1, 0, 2}},
+		{"full rewrite", ModeFullRewrite, "", [3]int64{1, 2, 0}},
+		{"full rewrite skips existing", ModeFullRewrite, pkgSync.ConflictSkip, [3]int64{1, 0, 2}},
+		{"full rewrite newest", ModeFullRewrite, pkgSync.ConflictNewest, [3]int64{1, 1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toCopy, plan := planMigration(source, dest, "backup", nil, nil, tt.mode, tt.strategy, false)
+			got := [3]int64{plan.Creates, plan.Overwrites, plan.Skips}
+			if got != tt.want {
+				t.Fatalf("creates/overwrites/skips = %v, want %v", got, tt.want)
+			}
+			if int64(len(toCopy)) != plan.Creates+plan.Overwrites {
+				t.Fatalf("copying %d objects, plan counts %d", len(toCopy), plan.Creates+plan.Overwrites)
+			}
+			if len(plan.Entries) != 0 {
+				t.Fatal("per-key entries collected without withDiff")
+			}
+		})
+	}
+}
+
+func TestPlanMigrationWithDiff(t *testing.T) {
+	source := []objectInfo{{Key: "a"}, {Key: "b"}}
+	_, plan := planMigration(source, nil, "", nil, nil, ModeFullRewrite, "", true)
+	if len(plan.Entries) != 2 || plan.Entries[0].DestKey != "a" || plan.Entries[0].Action != DiffCreate {
+		t.Fatalf("unexpected entries: %+v", plan.Entries)
+	}
+}
+
+// pagesOf returns a destination pager over objects, size keys per page
+func pagesOf(objects []objectInfo, size int) func() ([]objectInfo, error) {
+	return func() ([]objectInfo, error) {
+		if len(objects) == 0 {
+			return nil, nil
+		}
+		n := min(size, len(objects))
+		page := objects[:n]
+		objects = objects[n:]
+		return page, nil
+	}
+}
+
+func TestPlanMigrationMergedMatchesPlanMigration(t *testing.T) {
+	older := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(48 * time.Hour)
+	source := []objectInfo{
+		{Key: "a/1.txt", Size: 1, LastModified: newer},
+		{Key: "a/2.txt", Size: 2, LastModified: older},
+		{Key: "b/3.txt", Size: 3, LastModified: newer},
+		{Key: "c/4.txt", Size: 4, LastModified: older},
+		{Key: "d/5.txt", Size: 5, LastModified: older},
+	}
+	partition, err := PartitionPolicyFor(&models.PartitionOptions{Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		prefix    string
+		partition *PartitionPolicy
+	}{
+		{name: "no prefix"},
+		{name: "prefix", prefix: "backup"},
+		{name: "date partitions reorder keys", prefix: "backup", partition: partition},
+	}
+	for _, tt := range tests {
+		// Destination: the unchanged and changed objects, plus keys without a source
+		var dest []objectInfo
+		for i, obj := range source[1:4] {
+			obj.Key = destKeyForObject(obj, tt.prefix, tt.partition, nil)
+			obj.LastModified = older
+			obj.Size += int64(i % 2) // b/3.txt keeps its size but is newer at the source
+			dest = append(dest, obj)
+		}
+		dest = append(dest, objectInfo{Key: "0-orphan"}, objectInfo{Key: "zz-orphan"})
+		sort.Slice(dest, func(a, b int) bool { return dest[a].Key < dest[b].Key })
+
+		for _, mode := range []MigrationMode{ModeIncremental, ModeFullRewrite} {
+			wantCopy, want := planMigration(source, dest, tt.prefix, tt.partition, nil, mode, "", true)
+			gotCopy, got, err := planMigrationMerged(source, pagesOf(dest, 2), tt.prefix, tt.partition, nil, mode, "", true)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", tt.name, mode, err)
+			}
+			if !reflect.DeepEqual(gotCopy, wantCopy) {
+				t.Errorf("%s/%s: copying %v, want %v", tt.name, mode, gotCopy, wantCopy)
+			}
+			if got.Creates != want.Creates || got.Overwrites != want.Overwrites || got.Skips != want.Skips || len(got.Entries) != len(source) {
+				t.Errorf("%s/%s: plan %+v, want %+v", tt.name, mode, got, want)
+			}
+		}
+	}
+}
+
+func TestPlanMigrationMergedRejectsUnsortedListing(t *testing.T) {
+	source := []objectInfo{{Key: "a"}, {Key: "m"}, {Key: "z"}}
+	dest := []objectInfo{{Key: "b"}, {Key: "a"}, {Key: "m"}}
+	if _, _, err := planMigrationMerged(source, pagesOf(dest, 1), "", nil, nil, ModeIncremental, "", false); !errors.Is(err, errUnsortedListing) {
+		t.Fatalf("err = %v, want errUnsortedListing", err)
+	}
+}
+
+// Real runs plan as they always have: the conflict strategy only shapes dry-run
+// diffs, and incremental runs match keys relative to the source prefix
+func TestRealRunPlanning(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       MigrationMode
+		strategy   pkgSync.ConflictStrategy
+		wantCopied int64
+	}{
+		{"full rewrite ignores conflict strategy", ModeFullRewrite, pkgSync.ConflictSkip, 2},
+		{"incremental skips unchanged objects", ModeIncremental, "", 1},
+		{"incremental ignores conflict strategy", ModeIncremental, pkgSync.ConflictSkip, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := fakes3.New("source", "dest")
+			defer endpoint.Close()
+			endpoint.Put("source", "data/same.txt", []byte("same"))
+			endpoint.Put("source", "data/new.txt", []byte("new"))
+			endpoint.Put("dest", "backup/same.txt", []byte("same"))
+
+			migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+				ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := migrator.Migrate(context.Background(), MigrateInput{
+				SourceBucket:     "source",
+				SourcePrefix:     "data/",
+				DestBucket:       "dest",
+				DestPrefix:       "backup",
+				MigrationMode:    tt.mode,
+				ConflictStrategy: tt.strategy,
+				Timeout:          time.Minute,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Copied != tt.wantCopied {
+				t.Errorf("copied %d objects, want %d", result.Copied, tt.wantCopied)
+			}
+		})
+	}
+}
+
+func TestRelativeSourceKey(t *testing.T) {
+	tests := []struct{ key, prefix, want string }{
+		{"data/a.txt", "data/", "a.txt"},
+		{"data/a.txt", "data", "a.txt"},
+		{"data/a.txt", "", "data/a.txt"},
+		{"data/", "data/", "data/"},
+	}
+	for _, tt := range tests {
+		if got := relativeSourceKey(tt.key, tt.prefix); got != tt.want {
+			t.Errorf("relativeSourceKey(%q, %q) = %q, want %q", tt.key, tt.prefix, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"s3migration/pkg/models"
+)
+
+// S3 partitions a bucket's request rate by key prefix, so a run whose keys share
+// one hot prefix sees 503 SlowDown long before the bucket's overall limit. With
+// prefix shards, the copy queue alternates between prefixes and at most
+// MaxConcurrent copies of one prefix are in flight at once.
+const (
+	defaultPrefixShardDepth         = 1
+	defaultPrefixShardMaxConcurrent = 32
+	maxPrefixShardDepth             = 10
+	flatKeyShardLength              = 2 // Keys without a "/" are sharded by their first characters
+)
+
+// PrefixShardPolicy limits the concurrent copies per key prefix
+type PrefixShardPolicy struct {
+	Depth         int // "/"-separated segments of the source key that make up its shard
+	MaxConcurrent int // Copies in flight per shard
+}
+
+// PrefixShardPolicyFor builds the prefix sharding of a request; nil options mean no per-prefix limit
+func PrefixShardPolicyFor(opts *models.PrefixShardOptions) (*PrefixShardPolicy, error) {
+	if opts == nil || !opts.Enabled {
+		return nil, nil
+	}
+	policy := &PrefixShardPolicy{Depth: opts.Depth, MaxConcurrent: opts.MaxConcurrent}
+	if policy.Depth == 0 {
+		policy.Depth = defaultPrefixShardDepth
+	}
+	if policy.MaxConcurrent == 0 {
+		policy.MaxConcurrent = defaultPrefixShardMaxConcurrent
+	}
+	if policy.Depth < 1 || policy.Depth > maxPrefixShardDepth {
+		return nil, fmt.Errorf("prefix shard depth %d out of range (1-%d)", opts.Depth, maxPrefixShardDepth)
+	}
+	if policy.MaxConcurrent < 1 {
+		return nil, fmt.Errorf("prefix shard max_concurrent %d must be positive", opts.MaxConcurrent)
+	}
+	return policy, nil
+}
+
+// Shard returns the prefix of key up to its Depth-th "/" (fewer for shallower
+// keys), or its first characters when it has no "/" at all
+func (p *PrefixShardPolicy) Shard(key string) string {
+	end := 0
+	for i := 0; i < p.Depth; i++ {
+		next := strings.IndexByte(key[end:], '/')
+		if next < 0 {
+			break
+		}
+		end += next + 1
+	}
+	if end == 0 {
+		return key[:min(len(key), flatKeyShardLength)]
+	}
+	return key[:end]
+}
+
+// interleaveByPrefix returns the objects reordered so consecutive jobs come from
+// different shards: one object of each shard in turn, keeping each shard's order
+func interleaveByPrefix(objects []objectInfo, policy *PrefixShardPolicy) []objectInfo {
+	if policy == nil || len(objects) < 2 {
+		return objects
+	}
+	var shards []string
+	byShard := make(map[string][]objectInfo)
+	for _, obj := range objects {
+		shard := policy.Shard(obj.Key)
+		if _, ok := byShard[shard]; !ok {
+			shards = append(shards, shard)
+		}
+		byShard[shard] = append(byShard[shard], obj)
+	}
+	if len(shards) == 1 {
+		return objects
+	}
+
+	interleaved := make([]objectInfo, 0, len(objects))
+	for len(interleaved) < len(objects) {
+		for _, shard := range shards {
+			if queue := byShard[shard]; len(queue) > 0 {
+				interleaved = append(interleaved, queue[0])
+				byShard[shard] = queue[1:]
+			}
+		}
+	}
+	return interleaved
+}
+
+// prefixLimiter bounds the copies in flight per shard. Shards are created on
+// first use and dropped once idle, so memory follows the active prefixes.
+type prefixLimiter struct {
+	policy *PrefixShardPolicy
+	mu     sync.Mutex
+	shards map[string]*prefixShard
+}
+
+type prefixShard struct {
+	slots chan struct{}
+	users int // Holders and waiters; the shard is dropped at 0
+}
+
+// newPrefixLimiter returns the limiter of a policy, nil when there is none
+func newPrefixLimiter(policy *PrefixShardPolicy) *prefixLimiter {
+	if policy == nil {
+		return nil
+	}
+	return &prefixLimiter{policy: policy, shards: make(map[string]*prefixShard)}
+}
+
+// acquire waits for a slot in key's shard; the returned function releases it
+func (l *prefixLimiter) acquire(ctx context.Context, key string) (func(), error) {
+	name := l.policy.Shard(key)
+	l.mu.Lock()
+	shard, ok := l.shards[name]
+	if !ok {
+		shard = &prefixShard{slots: make(chan struct{}, l.policy.MaxConcurrent)}
+		l.shards[name] = shard
+	}
+	shard.users++
+	l.mu.Unlock()
+
+	select {
+	case shard.slots <- struct{}{}:
+		return func() {
+			<-shard.slots
+			l.leave(name, shard)
+		}, nil
+	case <-ctx.Done():
+		l.leave(name, shard)
+		return nil, ctx.Err()
+	}
+}
+
+func (l *prefixLimiter) leave(name string, shard *prefixShard) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if shard.users--; shard.users == 0 {
+		delete(l.shards, name)
+	}
+}
+
+// active returns the number of shards with copies in flight or waiting
+func (l *prefixLimiter) active() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.shards)
+}
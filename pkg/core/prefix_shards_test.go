@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"s3migration/pkg/models"
+)
+
+func TestPrefixShardPolicyFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *models.PrefixShardOptions
+		want    *PrefixShardPolicy
+		wantErr bool
+	}{
+		{name: "not requested", opts: nil},
+		{name: "disabled", opts: &models.PrefixShardOptions{Depth: 3}},
+		{name: "defaults", opts: &models.PrefixShardOptions{Enabled: true}, want: &PrefixShardPolicy{Depth: 1, MaxConcurrent: 32}},
+		{name: "custom", opts: &models.PrefixShardOptions{Enabled: true, Depth: 2, MaxConcurrent: 8}, want: &PrefixShardPolicy{Depth: 2, MaxConcurrent: 8}},
+		{name: "depth too deep", opts: &models.PrefixShardOptions{Enabled: true, Depth: 11}, wantErr: true},
+		{name: "negative limit", opts: &models.PrefixShardOptions{Enabled: true, MaxConcurrent: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PrefixShardPolicyFor(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Fatalf("policy = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrefixShard(t *testing.T) {
+	tests := []struct {
+		key   string
+		depth int
+		want  string
+	}{
+		{key: "logs/2024/01/a.gz", depth: 1, want: "logs/"},
+		{key: "logs/2024/01/a.gz", depth: 2, want: "logs/2024/"},
+		{key: "logs/a.gz", depth: 3, want: "logs/"}, // Shallower than the depth
+		{key: "a1b2c3.jpg", depth: 1, want: "a1"},   // No "/": first characters
+		{key: "x", depth: 1, want: "x"},
+	}
+	for _, tt := range tests {
+		policy := &PrefixShardPolicy{Depth: tt.depth, MaxConcurrent: 1}
+		if got := policy.Shard(tt.key); got != tt.want {
+			t.Errorf("Shard(%q) at depth %d = %q, want %q", tt.key, tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestInterleaveByPrefix(t *testing.T) {
+	var objects []objectInfo
+	for _, key := range []string{"a/1", "a/2", "a/3", "a/4", "b/1", "b/2", "c/1"} {
+		objects = append(objects, objectInfo{Key: key})
+	}
+	got := interleaveByPrefix(objects, &PrefixShardPolicy{Depth: 1, MaxConcurrent: 1})
+
+	var keys []string
+	for _, obj := range got {
+		keys = append(keys, obj.Key)
+	}
+	if want := "a/1 b/1 c/1 a/2 b/2 a/3 a/4"; strings.Join(keys, " ") != want {
+		t.Fatalf("order = %s, want %s", strings.Join(keys, " "), want)
+	}
+	if objects[1].Key != "a/2" {
+		t.Fatal("input reordered")
+	}
+}
+
+func TestPrefixLimiterBoundsEachShard(t *testing.T) {
+	limiter := newPrefixLimiter(&PrefixShardPolicy{Depth: 1, MaxConcurrent: 2})
+
+	var mu sync.Mutex
+	inFlight := map[string]int{}
+	peak := map[string]int{}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		key := "hot/" + string(rune('a'+i))
+		if i%4 == 0 {
+			key = "cold/" + string(rune('a'+i))
+		}
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			release, err := limiter.acquire(context.Background(), key)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			shard := limiter.policy.Shard(key)
+			mu.Lock()
+			inFlight[shard]++
+			peak[shard] = max(peak[shard], inFlight[shard])
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			inFlight[shard]--
+			mu.Unlock()
+			release()
+		}(key)
+	}
+	wg.Wait()
+
+	if peak["hot/"] != 2 || peak["cold/"] > 2 {
+		t.Fatalf("peak copies in flight per shard = %v, want at most 2", peak)
+	}
+	if n := limiter.active(); n != 0 {
+		t.Fatalf("%d idle shards kept", n)
+	}
+
+	// A waiter gives up with its context and leaves no shard behind
+	release, _ := limiter.acquire(context.Background(), "hot/1")
+	release2, _ := limiter.acquire(context.Background(), "hot/2")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx, "hot/3"); err == nil {
+		t.Fatal("acquired a slot beyond the shard's limit")
+	}
+	release()
+	release2()
+	if n := limiter.active(); n != 0 {
+		t.Fatalf("%d shards kept after the waiter gave up", n)
+	}
+}
@@ -0,0 +1,245 @@
+package core
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/models"
+)
+
+// maxReconcileSample caps the extra keys listed in a reconciliation report
+const maxReconcileSample = 20
+
+// metricTolerance is the share by which the CloudWatch bucket size may exceed the
+// listed bytes before it is reported; the metric lags up to a day behind
+const metricTolerance = 0.01
+
+// cloudWatchEndpoint returns the CloudWatch query API endpoint of a region
+var cloudWatchEndpoint = func(region string) string {
+	return "https://monitoring." + region + ".amazonaws.com/"
+}
+
+// ReconcilePolicy compares the destination with what a completed migration wrote
+type ReconcilePolicy struct {
+	CloudWatch bool // Also read the BucketSizeBytes metric of the destination bucket
+}
+
+// ReconcilePolicyFor builds the reconciliation policy of a migration request (nil without one)
+func ReconcilePolicyFor(opts *models.ReconcileOptions) *ReconcilePolicy {
+	if opts == nil || !opts.Enabled {
+		return nil
+	}
+	return &ReconcilePolicy{CloudWatch: opts.CloudWatch}
+}
+
+// reconcileStorage lists the destination prefix and compares it, key by key, with
+// the objects the migration put there. ownKeys are files the run wrote itself
+// (dedupe and snapshot manifests), not counted as extra.
+func (m *EnhancedMigrator) reconcileStorage(ctx context.Context, input MigrateInput, destClient *s3.Client, objects []objectInfo, writtenBytes int64, ownKeys ...string) (*models.StorageReconciliation, error) {
+	client := destClient
+	endpoint := input.DestEndpointURL
+	if client == nil {
+		client = m.metadataClient()
+		endpoint = m.config.EndpointURL
+	}
+
+	expected := make(map[string]int64, len(objects))
+	report := &models.StorageReconciliation{WrittenBytes: writtenBytes, Discrepancies: []string{}}
+	for _, obj := range objects {
+		expected[destKeyForObject(obj, input.DestPrefix, input.Partition, input.KeySanitize)] = obj.Size
+		report.ExpectedObjects++
+		report.ExpectedBytes += obj.Size
+	}
+	for _, key := range ownKeys {
+		if key != "" {
+			expected[key] = -1 // Any size
+		}
+	}
+
+	seen := make(map[string]bool, len(expected))
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(input.DestBucket),
+		Prefix: aws.String(input.DestPrefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list destination for reconciliation: %w", err)
+		}
+		for _, obj := range page.Contents {
+			key, size := aws.ToString(obj.Key), aws.ToInt64(obj.Size)
+			report.ListedObjects++
+			report.ListedBytes += size
+			want, ok := expected[key]
+			switch {
+			case !ok:
+				report.ExtraObjects++
+				report.ExtraBytes += size
+				if len(report.ExtraSample) < maxReconcileSample {
+					report.ExtraSample = append(report.ExtraSample, key)
+				}
+			case want >= 0 && want != size:
+				report.SizeMismatches++
+			}
+			seen[key] = true
+		}
+	}
+	for key, size := range expected {
+		if size >= 0 && !seen[key] {
+			report.MissingObjects++
+		}
+	}
+	sort.Strings(report.ExtraSample)
+
+	uploads, err := incompleteUploads(ctx, client, input.DestBucket, input.DestPrefix)
+	if err != nil {
+		fmt.Printf("Reconciliation: could not list multipart uploads: %v\n", err)
+	}
+	report.IncompleteUploads = uploads
+
+	if input.Reconcile.CloudWatch {
+		if endpoint != "" && !strings.Contains(endpoint, "amazonaws.com") {
+			report.MetricError = "CloudWatch metrics are only available for AWS destinations"
+		} else if size, at, err := bucketSizeMetric(ctx, client, input.DestBucket); err != nil {
+			report.MetricError = err.Error()
+		} else {
+			report.MetricBytes, report.MetricTimestamp = &size, at
+		}
+	}
+
+	report.Discrepancies = storageDiscrepancies(report, input.DestPrefix == "")
+	return report, nil
+}
+
+// storageDiscrepancies explains the differences in a report; the bucket size
+// metric is only comparable when the whole bucket was listed
+func storageDiscrepancies(report *models.StorageReconciliation, wholeBucket bool) []string {
+	discrepancies := []string{}
+	if report.MissingObjects > 0 {
+		discrepancies = append(discrepancies, fmt.Sprintf(
+			"%d migrated objects are not on the destination: failed copies, or deleted since", report.MissingObjects))
+	}
+	if report.SizeMismatches > 0 {
+		discrepancies = append(discrepancies, fmt.Sprintf(
+			"%d objects differ in size from their source: truncated writes, or overwritten since", report.SizeMismatches))
+	}
+	if report.ExtraObjects > 0 {
+		discrepancies = append(discrepancies, fmt.Sprintf(
+			"%d objects (%.1f MB) were not written by this migration: earlier data, duplicates under other keys, or deletes that failed",
+			report.ExtraObjects, float64(report.ExtraBytes)/1024/1024))
+	}
+	if report.IncompleteUploads > 0 {
+		discrepancies = append(discrepancies, fmt.Sprintf(
+			"%d incomplete multipart uploads keep billed parts no listing shows: abort them or add an AbortIncompleteMultipartUpload lifecycle rule",
+			report.IncompleteUploads))
+	}
+	if report.MetricBytes != nil && wholeBucket && float64(*report.MetricBytes) > float64(report.ListedBytes)*(1+metricTolerance) {
+		discrepancies = append(discrepancies, fmt.Sprintf(
+			"CloudWatch reports %.1f MB stored, %.1f MB more than listed: noncurrent versions or multipart parts (the metric is updated daily)",
+			float64(*report.MetricBytes)/1024/1024, float64(*report.MetricBytes-report.ListedBytes)/1024/1024))
+	}
+	return discrepancies
+}
+
+// incompleteUploads counts the multipart uploads under prefix that were neither completed nor aborted
+func incompleteUploads(ctx context.Context, client *s3.Client, bucket, prefix string) (int64, error) {
+	var count int64
+	input := &s3.ListMultipartUploadsInput{Bucket: aws.String(bucket), Prefix: aws.String(prefix)}
+	for {
+		page, err := client.ListMultipartUploads(ctx, input)
+		if err != nil {
+			return count, err
+		}
+		count += int64(len(page.Uploads))
+		if !aws.ToBool(page.IsTruncated) {
+			return count, nil
+		}
+		input.KeyMarker, input.UploadIdMarker = page.NextKeyMarker, page.NextUploadIdMarker
+	}
+}
+
+// metricStatistics is the part of a GetMetricStatistics response read here
+type metricStatistics struct {
+	Datapoints []struct {
+		Timestamp time.Time `xml:"Timestamp"`
+		Average   float64   `xml:"Average"`
+	} `xml:"GetMetricStatisticsResult>Datapoints>member"`
+}
+
+// bucketSizeMetric returns the latest daily BucketSizeBytes (Standard storage) of
+// a bucket from the CloudWatch query API, signed with the S3 client's credentials
+func bucketSizeMetric(ctx context.Context, client *s3.Client, bucket string) (int64, time.Time, error) {
+	options := client.Options()
+	region := options.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	now := time.Now().UTC()
+	query := url.Values{
+		"Action":                    {"GetMetricStatistics"},
+		"Version":                   {"2010-08-01"},
+		"Namespace":                 {"AWS/S3"},
+		"MetricName":                {"BucketSizeBytes"},
+		"Dimensions.member.1.Name":  {"BucketName"},
+		"Dimensions.member.1.Value": {bucket},
+		"Dimensions.member.2.Name":  {"StorageType"},
+		"Dimensions.member.2.Value": {"StandardStorage"},
+		"StartTime":                 {now.Add(-3 * 24 * time.Hour).Format(time.RFC3339)},
+		"EndTime":                   {now.Format(time.RFC3339)},
+		"Period":                    {"86400"},
+		"Statistics.member.1":       {"Average"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cloudWatchEndpoint(region)+"?"+query.Encode(), nil)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if options.Credentials == nil {
+		return 0, time.Time{}, fmt.Errorf("no credentials to query CloudWatch with")
+	}
+	creds, err := options.Credentials.Retrieve(ctx)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to get credentials for CloudWatch: %w", err)
+	}
+	// SHA-256 of the empty body
+	const emptyPayload = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, emptyPayload, "monitoring", region, time.Now()); err != nil {
+		return 0, time.Time{}, err
+	}
+
+	var httpClient s3.HTTPClient = http.DefaultClient
+	if options.HTTPClient != nil {
+		httpClient = options.HTTPClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("CloudWatch request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, time.Time{}, fmt.Errorf("CloudWatch returned %s (cloudwatch:GetMetricStatistics permission needed)", resp.Status)
+	}
+	var stats metricStatistics
+	if err := xml.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to parse the CloudWatch response: %w", err)
+	}
+	if len(stats.Datapoints) == 0 {
+		return 0, time.Time{}, fmt.Errorf("no BucketSizeBytes datapoint for %s in the last 3 days (new buckets report after about a day)", bucket)
+	}
+	latest := stats.Datapoints[0]
+	for _, point := range stats.Datapoints[1:] {
+		if point.Timestamp.After(latest.Timestamp) {
+			latest = point
+		}
+	}
+	return int64(latest.Average), latest.Timestamp, nil
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+)
+
+func TestMigrateReconcilesDestinationStorage(t *testing.T) {
+	endpoint := fakes3.New("src", "dest")
+	defer endpoint.Close()
+	for _, key := range []string{"a", "b", "c"} {
+		endpoint.Put("src", key, []byte("content of "+key))
+	}
+	endpoint.Put("dest", "old.txt", []byte("from an earlier run"))
+	endpoint.StartUpload("dest", "big.bin")
+
+	var authorization string
+	metrics := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Write([]byte(`<GetMetricStatisticsResponse><GetMetricStatisticsResult><Datapoints>` +
+			`<member><Timestamp>2026-01-01T00:00:00Z</Timestamp><Average>10.0</Average></member>` +
+			`<member><Timestamp>2026-01-02T00:00:00Z</Timestamp><Average>52428800.0</Average></member>` +
+			`</Datapoints></GetMetricStatisticsResult></GetMetricStatisticsResponse>`))
+	}))
+	defer metrics.Close()
+	previous := cloudWatchEndpoint
+	cloudWatchEndpoint = func(string) string { return metrics.URL + "/" }
+	defer func() { cloudWatchEndpoint = previous }()
+
+	client := s3.New(endpoint.Client().Options(), func(o *s3.Options) {
+		o.Credentials = credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", "")
+	})
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool: pool.NewStaticConnectionPool(client),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := migrator.Migrate(context.Background(), MigrateInput{
+		SourceBucket:  "src",
+		DestBucket:    "dest",
+		MigrationMode: ModeFullRewrite,
+		Reconcile:     &ReconcilePolicy{CloudWatch: true},
+		Timeout:       time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := result.Reconciliation
+	if report == nil {
+		t.Fatal("no reconciliation report")
+	}
+	if report.ExpectedObjects != 3 || report.ListedObjects != 4 || report.MissingObjects != 0 || report.SizeMismatches != 0 ||
+		report.ExtraObjects != 1 || report.ExtraSample[0] != "old.txt" || report.IncompleteUploads != 1 || report.WrittenBytes != report.ExpectedBytes {
+		t.Fatalf("report = %+v", report)
+	}
+	if report.MetricBytes == nil || *report.MetricBytes != 50<<20 || !report.MetricTimestamp.Equal(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("metric = %v at %v (%s)", report.MetricBytes, report.MetricTimestamp, report.MetricError)
+	}
+	if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(authorization, "/monitoring/aws4_request") {
+		t.Fatalf("CloudWatch request signed with %q", authorization)
+	}
+	if len(report.Discrepancies) != 3 {
+		t.Fatalf("discrepancies = %q, want extra objects, incomplete uploads and the metric", report.Discrepancies)
+	}
+}
+
+func TestStorageDiscrepancies(t *testing.T) {
+	metric := int64(1000)
+	tests := []struct {
+		name        string
+		report      models.StorageReconciliation
+		wholeBucket bool
+		want        []string
+	}{
+		{name: "clean", report: models.StorageReconciliation{ListedBytes: 1000, MetricBytes: &metric}, wholeBucket: true},
+		{name: "missing and truncated", report: models.StorageReconciliation{MissingObjects: 2, SizeMismatches: 1}, want: []string{"2 migrated objects", "1 objects differ"}},
+		{name: "metric within tolerance", report: models.StorageReconciliation{ListedBytes: 995, MetricBytes: &metric}, wholeBucket: true},
+		{name: "metric above listing", report: models.StorageReconciliation{ListedBytes: 500, MetricBytes: &metric}, wholeBucket: true, want: []string{"CloudWatch reports"}},
+		{name: "metric of a prefix", report: models.StorageReconciliation{ListedBytes: 500, MetricBytes: &metric}},
+	}
+	for _, tt := range tests {
+		got := storageDiscrepancies(&tt.report, tt.wholeBucket)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: discrepancies = %q", tt.name, got)
+			continue
+		}
+		for i, prefix := range tt.want {
+			if !strings.HasPrefix(got[i], prefix) {
+				t.Errorf("%s: discrepancy %q, want %q...", tt.name, got[i], prefix)
+			}
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+	"s3migration/pkg/progress"
+)
+
+// reorganizeWorkers is how many server-side copies a reorganization runs at once
+const reorganizeWorkers = 32
+
+// deleteObjectsBatch is the most keys one DeleteObjects request takes
+const deleteObjectsBatch = 1000
+
+// maxReorganizeSamples caps the renames and failures a reorganization report lists; counts are always exact
+const maxReorganizeSamples = 100
+
+// ReorganizeKey returns the key an object under sourcePrefix moves to: its part
+// after sourcePrefix, under destPrefix
+func ReorganizeKey(key, sourcePrefix, destPrefix string) string {
+	return destKeyFor(strings.TrimPrefix(key, sourcePrefix), destPrefix)
+}
+
+// ValidateReorganizePrefixes checks that objects can move from sourcePrefix to
+// destPrefix (as normalized by validation: sourcePrefix may end with "/",
+// destPrefix does not). Neither may contain the other, or a copy could land on
+// a key that is itself moved, and deleted, later.
+func ValidateReorganizePrefixes(sourcePrefix, destPrefix string) error {
+	if destPrefix == "" {
+		return fmt.Errorf("reorganize requires dest_prefix")
+	}
+	destDir := destPrefix + "/"
+	if strings.HasPrefix(sourcePrefix, destDir) || strings.HasPrefix(destDir, sourcePrefix) {
+		return fmt.Errorf("source_prefix %q and dest_prefix %q overlap; reorganize needs prefixes that do not contain each other", sourcePrefix, destPrefix)
+	}
+	return nil
+}
+
+// Reorganize moves the objects under the input's source prefix to its
+// destination prefix within the source bucket: each object is copied
+// server-side to ReorganizeKey, and with deleteOriginals the originals whose
+// copy succeeded are then deleted in DeleteObjects batches. A dry run only
+// lists both prefixes and reports what would move and overwrite. Other copy
+// options of the input do not apply.
+func (m *EnhancedMigrator) Reorganize(ctx context.Context, input MigrateInput, deleteOriginals bool) (*models.ReorganizeReport, error) {
+	ctx = pool.CountRequests(ctx, &m.requests)
+	client := m.metadataClient()
+	bucket := input.SourceBucket
+	report := &models.ReorganizeReport{DryRun: input.DryRun}
+	reportPhase := func(phase string) {
+		if input.Reporter != nil {
+			input.Reporter.Phase(phase)
+		}
+	}
+
+	m.live.setPhase("listing")
+	reportPhase(models.PhaseDiscovering)
+	objects, err := m.listAll(ctx, client, bucket, input.SourcePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source: %w", err)
+	}
+	existing, err := m.listAll(ctx, client, bucket, input.DestPrefix+"/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list destination: %w", err)
+	}
+	destKeys := make(map[string]bool, len(existing))
+	for _, obj := range existing {
+		destKeys[obj.Key] = true
+	}
+	for _, obj := range objects {
+		report.Objects++
+		report.Bytes += obj.Size
+		if destKeys[ReorganizeKey(obj.Key, input.SourcePrefix, input.DestPrefix)] {
+			report.Overwrites++
+		}
+		if input.DryRun && len(report.Samples) < maxReorganizeSamples {
+			report.Samples = append(report.Samples, models.ReorganizeEntry{Key: obj.Key, DestKey: ReorganizeKey(obj.Key, input.SourcePrefix, input.DestPrefix)})
+		}
+	}
+	fmt.Printf("Reorganize s3://%s/%s -> %s/: %d objects (%.1f MB), %d would overwrite existing keys\n",
+		bucket, input.SourcePrefix, input.DestPrefix, report.Objects, float64(report.Bytes)/1024/1024, report.Overwrites)
+	if input.DryRun {
+		return report, nil
+	}
+
+	m.live.setPhase("copying")
+	reportPhase(models.PhaseUploading)
+	copied := m.reorganizeCopy(ctx, client, input, objects, report)
+	if err := ctx.Err(); err != nil {
+		return report, err // Originals are kept; a new run copies what is left again
+	}
+	if deleteOriginals {
+		m.deleteObjects(ctx, client, bucket, copied, report)
+	}
+	return report, ctx.Err()
+}
+
+// reorganizeCopy copies objects server-side to their reorganized keys and
+// returns the keys whose copy succeeded
+func (m *EnhancedMigrator) reorganizeCopy(ctx context.Context, client *s3.Client, input MigrateInput, objects []objectInfo, report *models.ReorganizeReport) []string {
+	type result struct {
+		obj objectInfo
+		err error
+	}
+	jobs := make(chan objectInfo)
+	results := make(chan result)
+	var workers sync.WaitGroup
+	for i := 0; i < reorganizeWorkers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for obj := range jobs {
+				destKey := ReorganizeKey(obj.Key, input.SourcePrefix, input.DestPrefix)
+				results <- result{obj: obj, err: m.copyObject(ctx, client, input.SourceBucket, obj.Key, input.SourceBucket, destKey, nil)}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, obj := range objects {
+			select {
+			case jobs <- obj:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	start := time.Now()
+	var copiedBytes int64
+	var copied []string
+	for r := range results {
+		if r.err != nil {
+			report.CopyFailed++
+			if len(report.Samples) < maxReorganizeSamples {
+				report.Samples = append(report.Samples, models.ReorganizeEntry{Key: r.obj.Key, DestKey: ReorganizeKey(r.obj.Key, input.SourcePrefix, input.DestPrefix), Error: r.err.Error()})
+			}
+			continue
+		}
+		report.Copied++
+		copiedBytes += r.obj.Size
+		copied = append(copied, r.obj.Key)
+		if input.Reporter != nil {
+			input.Reporter.Progress(progress.Update{
+				Time:          time.Now(),
+				Phase:         models.PhaseUploading,
+				Progress:      float64(report.Copied+report.CopyFailed) / float64(report.Objects) * 100,
+				CopiedObjects: report.Copied,
+				TotalObjects:  report.Objects,
+				CopiedBytes:   copiedBytes,
+				TotalBytes:    report.Bytes,
+				SpeedMBps:     float64(copiedBytes) / 1024 / 1024 / time.Since(start).Seconds(),
+			})
+		}
+	}
+	return copied
+}
+
+// deleteObjects deletes keys in DeleteObjects batches, counting them in report
+func (m *EnhancedMigrator) deleteObjects(ctx context.Context, client *s3.Client, bucket string, keys []string, report *models.ReorganizeReport) {
+	fail := func(key, reason string) {
+		report.DeleteFailed++
+		if len(report.Samples) < maxReorganizeSamples {
+			report.Samples = append(report.Samples, models.ReorganizeEntry{Key: key, Error: "delete: " + reason})
+		}
+	}
+	for start := 0; start < len(keys) && ctx.Err() == nil; start += deleteObjectsBatch {
+		batch := keys[start:min(start+deleteObjectsBatch, len(keys))]
+		identifiers := make([]types.ObjectIdentifier, len(batch))
+		for i, key := range batch {
+			identifiers[i] = types.ObjectIdentifier{Key: aws.String(key)}
+		}
+		output, err := client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &types.Delete{Objects: identifiers, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			for _, key := range batch {
+				fail(key, err.Error())
+			}
+			continue
+		}
+		// Quiet mode lists only the keys that failed
+		for _, failed := range output.Errors {
+			fail(aws.ToString(failed.Key), fmt.Sprintf("%s: %s", aws.ToString(failed.Code), aws.ToString(failed.Message)))
+		}
+		report.Deleted += int64(len(batch) - len(output.Errors))
+	}
+}
+
+// listAll lists every object under prefix, without the listing cache
+func (m *EnhancedMigrator) listAll(ctx context.Context, client *s3.Client, bucket, prefix string) ([]objectInfo, error) {
+	pages := m.newObjectPager(client, bucket, prefix)
+	var objects []objectInfo
+	for {
+		page, err := pages.next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			return objects, nil
+		}
+		objects = append(objects, page...)
+	}
+}
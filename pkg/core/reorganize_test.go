@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/pool"
+)
+
+func TestReorganize(t *testing.T) {
+	tests := []struct {
+		name            string
+		dryRun          bool
+		deleteOriginals bool
+		denyDelete      string
+		wantKeys        []string
+		wantCopied      int64
+		wantDeleted     int64
+		wantFailed      int64
+	}{
+		{
+			name:     "dry run",
+			dryRun:   true,
+			wantKeys: []string{"archive/b.txt", "logs/a.txt", "logs/b.txt", "logs/sub/c.txt", "other.txt"},
+		},
+		{
+			name:       "copy",
+			wantKeys:   []string{"archive/a.txt", "archive/b.txt", "archive/sub/c.txt", "logs/a.txt", "logs/b.txt", "logs/sub/c.txt", "other.txt"},
+			wantCopied: 3,
+		},
+		{
+			name:            "move",
+			deleteOriginals: true,
+			wantKeys:        []string{"archive/a.txt", "archive/b.txt", "archive/sub/c.txt", "other.txt"},
+			wantCopied:      3,
+			wantDeleted:     3,
+		},
+		{
+			name:            "move with a denied delete",
+			deleteOriginals: true,
+			denyDelete:      "logs/b.txt",
+			wantKeys:        []string{"archive/a.txt", "archive/b.txt", "archive/sub/c.txt", "logs/b.txt", "other.txt"},
+			wantCopied:      3,
+			wantDeleted:     2,
+			wantFailed:      1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := fakes3.New("data")
+			defer endpoint.Close()
+			endpoint.Put("data", "logs/a.txt", []byte("alpha"))
+			endpoint.Put("data", "logs/b.txt", []byte("bravo"))
+			endpoint.Put("data", "logs/sub/c.txt", []byte("charlie"))
+			endpoint.Put("data", "archive/b.txt", []byte("old"))
+			endpoint.Put("data", "other.txt", []byte("untouched"))
+			endpoint.Deny = func(method, bucket, key string) bool {
+				return method == http.MethodDelete && key == tt.denyDelete
+			}
+
+			migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+				ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			report, err := migrator.Reorganize(context.Background(), MigrateInput{
+				SourceBucket: "data",
+				SourcePrefix: "logs/",
+				DestBucket:   "data",
+				DestPrefix:   "archive",
+				DryRun:       tt.dryRun,
+			}, tt.deleteOriginals)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Objects != 3 || report.Bytes != 17 || report.Overwrites != 1 {
+				t.Errorf("report = %d objects, %d bytes, %d overwrites", report.Objects, report.Bytes, report.Overwrites)
+			}
+			if report.Copied != tt.wantCopied || report.Deleted != tt.wantDeleted || report.DeleteFailed != tt.wantFailed || report.CopyFailed != 0 {
+				t.Errorf("report = %d copied, %d deleted, %d deletes failed, %d copies failed", report.Copied, report.Deleted, report.DeleteFailed, report.CopyFailed)
+			}
+			if tt.dryRun && (len(report.Samples) != 3 || report.Samples[2].DestKey != "archive/sub/c.txt") {
+				t.Errorf("dry run samples = %+v", report.Samples)
+			}
+			if keys := endpoint.Keys("data"); fmt.Sprint(keys) != fmt.Sprint(tt.wantKeys) {
+				t.Errorf("keys = %v, want %v", keys, tt.wantKeys)
+			}
+			if !tt.dryRun {
+				if obj := endpoint.Get("data", "archive/b.txt"); string(obj.Data) != "bravo" {
+					t.Errorf("archive/b.txt = %q, want the moved object", obj.Data)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateReorganizePrefixes(t *testing.T) {
+	tests := []struct {
+		source, dest string
+		wantErr      bool
+	}{
+		{"logs/", "archive/logs", false},
+		{"", "archive", true},
+		{"logs/", "", true},
+		{"logs/", "logs/2024", true},
+		{"archive/logs/", "archive", true},
+		{"log", "logs", true},
+		{"logs/", "logs-archive", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source+"->"+tt.dest, func(t *testing.T) {
+			if err := ValidateReorganizePrefixes(tt.source, tt.dest); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateReorganizePrefixes(%q, %q) error = %v, wantErr %v", tt.source, tt.dest, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"strings"
+
+	"s3migration/pkg/models"
+)
+
+// RequestCounts returns the S3 requests the migrator has made so far, retries
+// included, by operation class
+func (m *EnhancedMigrator) RequestCounts() models.APIRequests {
+	return apiRequests(m.requests.Counts())
+}
+
+// Latencies returns the latency percentiles of the S3 requests the migrator has
+// made so far, by endpoint and operation
+func (m *EnhancedMigrator) Latencies() []models.OperationLatency {
+	return m.requests.Latencies()
+}
+
+// apiRequests groups request counts by S3 operation name into billing classes
+func apiRequests(byOperation map[string]int64) models.APIRequests {
+	requests := models.APIRequests{ByOperation: byOperation}
+	for operation, count := range byOperation {
+		requests.Total += count
+		switch {
+		case strings.HasPrefix(operation, "List"):
+			requests.List += count
+		case strings.HasPrefix(operation, "Head"):
+			requests.Head += count
+		case strings.HasPrefix(operation, "Get"):
+			requests.Get += count
+		case strings.HasPrefix(operation, "UploadPart"):
+			requests.UploadPart += count
+		case strings.HasPrefix(operation, "Put"), strings.HasPrefix(operation, "Create"),
+			strings.HasPrefix(operation, "Complete"), operation == "CopyObject":
+			requests.Put += count
+		case strings.HasPrefix(operation, "Delete"), operation == "AbortMultipartUpload":
+			requests.Delete += count
+		default:
+			requests.Other += count
+		}
+	}
+	return requests
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+)
+
+func TestAPIRequestsGroupsOperations(t *testing.T) {
+	requests := apiRequests(map[string]int64{
+		"ListObjectsV2":           3,
+		"ListMultipartUploads":    1,
+		"HeadObject":              4,
+		"GetObject":               2,
+		"PutObject":               1,
+		"CopyObject":              2,
+		"CreateMultipartUpload":   1,
+		"UploadPart":              5,
+		"UploadPartCopy":          2,
+		"CompleteMultipartUpload": 1,
+		"AbortMultipartUpload":    1,
+		"DeleteObject":            1,
+		"GetBucketPolicy":         1,
+		"SelectObjectContent":     1,
+	})
+	want := models.APIRequests{Total: 26, List: 4, Head: 4, Get: 3, Put: 5, UploadPart: 7, Delete: 2, Other: 1}
+	requests.ByOperation = nil
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %+v, want %+v", requests, want)
+	}
+}
+
+func TestMigrateCountsRequests(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	endpoint.Put("source", "a.txt", []byte("alpha"))
+	endpoint.Put("source", "b.txt", []byte("bravo"))
+
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := MigrateInput{SourceBucket: "source", DestBucket: "dest", MigrationMode: ModeFullRewrite, Timeout: time.Minute}
+	result, err := migrator.Migrate(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := result.Requests
+	if first.ByOperation["CopyObject"] != 2 || first.List == 0 || first.Total != first.List+first.Head+first.Get+first.Put+first.UploadPart+first.Delete+first.Other {
+		t.Errorf("first run: %+v", first)
+	}
+
+	// Counts are per migrator, so a second run adds to them
+	result, err = migrator.Migrate(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second := result.Requests; second.ByOperation["CopyObject"] != 4 || second.List <= first.List {
+		t.Errorf("second run: %+v", second)
+	}
+	if state := migrator.GetTuningState(); state.Requests.Total != result.Requests.Total {
+		t.Errorf("tuning state requests = %+v", state.Requests)
+	}
+
+	var copies *models.OperationLatency
+	for i, latency := range result.Latency {
+		if latency.Operation == "CopyObject" {
+			copies = &result.Latency[i]
+		}
+	}
+	if copies == nil || copies.Endpoint != endpoint.URL || copies.Count != 4 || copies.P50Ms > copies.P99Ms || copies.P99Ms > copies.MaxMs {
+		t.Errorf("latency = %+v", result.Latency)
+	}
+}
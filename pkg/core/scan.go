@@ -0,0 +1,189 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/config"
+	"s3migration/pkg/logging"
+	"s3migration/pkg/models"
+	"s3migration/pkg/scan"
+)
+
+// errScanWithheld marks an object not written to its key: infected, or unscannable with on_error: skip
+var errScanWithheld = errors.New("object withheld by content scan")
+
+// ScanPolicyFor builds the content scanning policy of a migration request (nil without one)
+func ScanPolicyFor(opts *models.ScanOptions) (*scan.Policy, error) {
+	if opts == nil {
+		return nil, nil
+	}
+	if opts.TimeoutSeconds < 0 || opts.MaxSizeMB < 0 {
+		return nil, fmt.Errorf("scan timeout_seconds and max_size_mb must not be negative")
+	}
+	return scan.New(scan.Config{
+		Type:             opts.Type,
+		Address:          opts.Address,
+		Timeout:          time.Duration(opts.TimeoutSeconds) * time.Second,
+		MaxSize:          opts.MaxSizeMB * 1024 * 1024,
+		Quarantine:       opts.Quarantine,
+		QuarantinePrefix: opts.QuarantinePrefix,
+		OnError:          opts.OnError,
+	})
+}
+
+// scanFindings collects the objects a scan withheld during one Migrate call
+type scanFindings struct {
+	mu       sync.Mutex
+	findings []models.ScanFinding
+}
+
+func (f *scanFindings) reset() {
+	f.mu.Lock()
+	f.findings = nil
+	f.mu.Unlock()
+}
+
+func (f *scanFindings) add(finding models.ScanFinding) {
+	f.mu.Lock()
+	f.findings = append(f.findings, finding)
+	f.mu.Unlock()
+}
+
+func (f *scanFindings) list() []models.ScanFinding {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]models.ScanFinding(nil), f.findings...)
+}
+
+// scanCopy reads an object once, spooling it to a temporary file while the
+// scanner reads the same bytes, and writes the spool only after a clean
+// verdict. What is scanned is exactly what is written; objects go through one
+// PutObject, so scanned objects are limited to 5 GiB.
+func (m *EnhancedMigrator) scanCopy(ctx context.Context, log logging.ObjectLogger, sourceClient, destClient *s3.Client, sourceBucket, sourceKey, destBucket, destKey string, objectSize int64) error {
+	policy := m.scan
+	if objectSize > policy.MaxSize || objectSize > config.MaxPartSize {
+		return m.scanFailed(ctx, log, fmt.Errorf("object of %d bytes exceeds the scan size limit of %d bytes", objectSize, policy.MaxSize),
+			sourceClient, destClient, sourceBucket, sourceKey, destBucket, destKey, objectSize)
+	}
+
+	getResp, err := sourceClient.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(sourceBucket),
+		Key:    aws.String(sourceKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get object from source: %w", err)
+	}
+	defer getResp.Body.Close()
+
+	spool, err := os.CreateTemp("", "s3migration-scan-*")
+	if err != nil {
+		return fmt.Errorf("failed to create spool file: %w", err)
+	}
+	defer removeSpool(spool)
+
+	var body io.Reader = getResp.Body
+	if objectSize >= inFlightReportMinSize {
+		body = &progressReader{reader: body, tracker: m.inflight, key: sourceKey}
+	}
+	verdict, scanErr := policy.Scanner.Scan(ctx, io.TeeReader(body, spool))
+	if scanErr == nil {
+		// The scanner stops at EOF of what it read; make sure the spool has everything
+		_, scanErr = io.Copy(spool, body)
+	}
+	if scanErr != nil {
+		return m.scanFailed(ctx, log, scanErr, sourceClient, destClient, sourceBucket, sourceKey, destBucket, destKey, objectSize)
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind spool file: %w", err)
+	}
+
+	source := sourceObject{
+		bucket:      sourceBucket,
+		key:         sourceKey,
+		size:        objectSize,
+		contentType: aws.ToString(getResp.ContentType),
+		metadata:    getResp.Metadata,
+		redirect:    getResp.WebsiteRedirectLocation,
+	}
+
+	if !verdict.Clean {
+		finding := models.ScanFinding{Key: sourceKey, Threat: verdict.Threat, Action: "skipped"}
+		if policy.Quarantine == scan.QuarantinePrefix {
+			finding.Action = "quarantined"
+			finding.QuarantineKey = policy.QuarantinePrefix + "/" + destKey
+			if err := m.putSpooled(ctx, destClient, source, spool, destBucket, finding.QuarantineKey); err != nil {
+				return fmt.Errorf("failed to quarantine infected object (%s): %w", verdict.Threat, err)
+			}
+		}
+		log.Errorf("[SCAN] ❌ %s: %s (%s)", sourceKey, verdict.Threat, finding.Action)
+		m.scanFindings.add(finding)
+		return errScanWithheld
+	}
+
+	log.Debugf("[SCAN] Clean: %s (%s)", sourceKey, policy.Scanner.Name())
+	if m.transform.Matches(sourceKey) {
+		return m.transformBody(ctx, log, destClient, source, spool, destBucket, destKey, func() error {
+			if _, err := spool.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			return m.putSpooled(ctx, destClient, source, spool, destBucket, destKey)
+		})
+	}
+	return m.putSpooled(ctx, destClient, source, spool, destBucket, destKey)
+}
+
+// putSpooled writes a spooled source object to destKey
+func (m *EnhancedMigrator) putSpooled(ctx context.Context, destClient *s3.Client, source sourceObject, spool *os.File, destBucket, destKey string) error {
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind spool file: %w", err)
+	}
+	putInput := &s3.PutObjectInput{
+		Bucket:                  aws.String(destBucket),
+		Key:                     aws.String(destKey),
+		Body:                    spool,
+		ContentLength:           aws.Int64(source.size),
+		Metadata:                source.metadata,
+		WebsiteRedirectLocation: source.redirect,
+	}
+	if source.contentType != "" {
+		putInput.ContentType = aws.String(source.contentType)
+	}
+	putResp, err := destClient.PutObject(ctx, putInput)
+	if err != nil {
+		return fmt.Errorf("failed to put object to destination: %w", err)
+	}
+	return m.verifyWrite(ctx, destClient, destBucket, destKey, source.size, writeChecksums{
+		ETag:   aws.ToString(putResp.ETag),
+		CRC32:  aws.ToString(putResp.ChecksumCRC32),
+		CRC32C: aws.ToString(putResp.ChecksumCRC32C),
+		SHA1:   aws.ToString(putResp.ChecksumSHA1),
+		SHA256: aws.ToString(putResp.ChecksumSHA256),
+	})
+}
+
+// scanFailed applies the scan error policy to an object that could not be scanned
+func (m *EnhancedMigrator) scanFailed(ctx context.Context, log logging.ObjectLogger, cause error, sourceClient, destClient *s3.Client, sourceBucket, sourceKey, destBucket, destKey string, objectSize int64) error {
+	switch m.scan.OnError {
+	case scan.OnErrorSkip:
+		log.Infof("[SCAN] Skipping unscanned %s: %v", sourceKey, cause)
+		m.scanFindings.add(models.ScanFinding{Key: sourceKey, Action: "skipped", Error: cause.Error()})
+		return errScanWithheld
+	case scan.OnErrorCopy:
+		log.Infof("[SCAN] Copying unscanned %s: %v", sourceKey, cause)
+		if m.transform.Matches(sourceKey) {
+			return m.transformCopy(ctx, log, sourceClient, destClient, sourceBucket, sourceKey, destBucket, destKey, objectSize)
+		}
+		return m.crossAccountCopy(ctx, log, sourceClient, destClient, sourceBucket, sourceKey, destBucket, destKey, objectSize)
+	default:
+		return fmt.Errorf("content scan failed: %w", cause)
+	}
+}
@@ -0,0 +1,175 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/models"
+)
+
+// DefaultSnapshotPrefix is where snapshot manifests are written when no prefix is set
+const DefaultSnapshotPrefix = "manifests"
+
+// SnapshotPolicy writes a manifest of the destination state after a migration
+type SnapshotPolicy struct {
+	Prefix string // Key prefix in the destination bucket
+	Name   string // Manifest file name without extension, usually the task ID
+}
+
+// SnapshotEntry is one destination object as it was when the migration completed
+type SnapshotEntry struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag"`
+	VersionID    string    `json:"version_id,omitempty"` // Empty when the bucket is not versioned
+	LastModified time.Time `json:"last_modified"`
+}
+
+// SnapshotManifest records exactly what the destination held after a migration
+type SnapshotManifest struct {
+	SourceBucket string          `json:"source_bucket"`
+	SourcePrefix string          `json:"source_prefix,omitempty"`
+	DestBucket   string          `json:"dest_bucket"`
+	DestPrefix   string          `json:"dest_prefix,omitempty"`
+	StartedAt    time.Time       `json:"started_at"`
+	CompletedAt  time.Time       `json:"completed_at"`
+	Versioned    bool            `json:"versioned"` // Version IDs were listed
+	Objects      int64           `json:"objects"`
+	TotalSize    int64           `json:"total_size"`
+	Entries      []SnapshotEntry `json:"entries"` // Sorted by key
+}
+
+// SnapshotPolicyFor builds the snapshot manifest policy of a migration request (nil without one)
+func SnapshotPolicyFor(opts *models.SnapshotOptions, name string) (*SnapshotPolicy, error) {
+	if opts == nil || !opts.Enabled {
+		return nil, nil
+	}
+	prefix := strings.Trim(opts.Prefix, "/")
+	if prefix == "" {
+		prefix = DefaultSnapshotPrefix
+	}
+	for _, segment := range strings.Split(prefix, "/") {
+		if segment == ".." {
+			return nil, fmt.Errorf("snapshot prefix must not contain '..' segments")
+		}
+	}
+	return &SnapshotPolicy{Prefix: prefix, Name: name}, nil
+}
+
+// snapshotEntries lists the current destination objects with their version IDs.
+// Providers without ListObjectVersions (or without versioning) fall back to a plain listing.
+func (m *EnhancedMigrator) snapshotEntries(ctx context.Context, client *s3.Client, bucket, prefix string) ([]SnapshotEntry, bool, error) {
+	entries, err := listLatestVersions(ctx, client, bucket, prefix)
+	if err == nil {
+		return entries, true, nil
+	}
+	fmt.Printf("Snapshot: listing versions failed (%v), listing objects instead\n", err)
+
+	objects, err := m.listObjectsWithCache(ctx, bucket, prefix, client)
+	if err != nil {
+		return nil, false, err
+	}
+	entries = make([]SnapshotEntry, len(objects))
+	for i, obj := range objects {
+		entries[i] = SnapshotEntry{Key: obj.Key, Size: obj.Size, ETag: strings.Trim(obj.ETag, `"`), LastModified: obj.LastModified}
+	}
+	return entries, false, nil
+}
+
+// listLatestVersions returns the current version of every object under prefix
+func listLatestVersions(ctx context.Context, client *s3.Client, bucket, prefix string) ([]SnapshotEntry, error) {
+	input := &s3.ListObjectVersionsInput{Bucket: aws.String(bucket)}
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+
+	var entries []SnapshotEntry
+	for {
+		page, err := client.ListObjectVersions(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range page.Versions {
+			if !aws.ToBool(v.IsLatest) {
+				continue
+			}
+			versionID := aws.ToString(v.VersionId)
+			if versionID == "null" {
+				versionID = "" // Written while versioning was off
+			}
+			entries = append(entries, SnapshotEntry{
+				Key:          aws.ToString(v.Key),
+				Size:         aws.ToInt64(v.Size),
+				ETag:         strings.Trim(aws.ToString(v.ETag), `"`),
+				VersionID:    versionID,
+				LastModified: aws.ToTime(v.LastModified),
+			})
+		}
+		if !aws.ToBool(page.IsTruncated) || page.NextKeyMarker == nil {
+			break
+		}
+		input.KeyMarker = page.NextKeyMarker
+		input.VersionIdMarker = page.NextVersionIdMarker
+	}
+	return entries, nil
+}
+
+// writeSnapshotManifest lists the destination and stores its manifest; returns the
+// manifest key and the SHA-256 of its body, also stored as object metadata
+func (m *EnhancedMigrator) writeSnapshotManifest(ctx context.Context, input MigrateInput, destClient *s3.Client, startedAt time.Time) (string, string, error) {
+	client := destClient
+	if client == nil {
+		client = m.metadataClient()
+	}
+
+	entries, versioned, err := m.snapshotEntries(ctx, client, input.DestBucket, input.DestPrefix)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list destination for snapshot manifest: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+
+	manifest := SnapshotManifest{
+		SourceBucket: input.SourceBucket,
+		SourcePrefix: input.SourcePrefix,
+		DestBucket:   input.DestBucket,
+		DestPrefix:   input.DestPrefix,
+		StartedAt:    startedAt.UTC(),
+		CompletedAt:  time.Now().UTC(),
+		Versioned:    versioned,
+		Objects:      int64(len(entries)),
+		Entries:      entries,
+	}
+	for _, e := range entries {
+		manifest.TotalSize += e.Size
+	}
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(body)
+	digest := hex.EncodeToString(sum[:])
+
+	key := path.Join(input.Snapshot.Prefix, input.Snapshot.Name+".json")
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(input.DestBucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+		Metadata:    map[string]string{"manifest-sha256": digest},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to write snapshot manifest %s: %w", key, err)
+	}
+	return key, digest, nil
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/models"
+)
+
+// fakeVersionedBucket serves two ListObjectVersions pages and records the uploaded manifest
+type fakeVersionedBucket struct {
+	putKey      string
+	putBody     []byte
+	putMetadata string
+}
+
+func (f *fakeVersionedBucket) client(t *testing.T) *s3.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut:
+			f.putKey = r.URL.Path
+			f.putBody, _ = io.ReadAll(r.Body)
+			f.putMetadata = r.Header.Get("X-Amz-Meta-Manifest-Sha256")
+		case r.URL.Query().Has("versions") && r.URL.Query().Get("key-marker") == "":
+			fmt.Fprint(w, `<ListVersionsResult><IsTruncated>true</IsTruncated><NextKeyMarker>b</NextKeyMarker><NextVersionIdMarker>v1</NextVersionIdMarker>`+
+				`<Version><Key>b</Key><VersionId>v2</VersionId><IsLatest>true</IsLatest><Size>2</Size><ETag>"bb"</ETag><LastModified>2026-01-02T00:00:00.000Z</LastModified></Version>`+
+				`<Version><Key>b</Key><VersionId>v1</VersionId><IsLatest>false</IsLatest><Size>9</Size><ETag>"old"</ETag><LastModified>2026-01-01T00:00:00.000Z</LastModified></Version>`+
+				`</ListVersionsResult>`)
+		case r.URL.Query().Has("versions"):
+			fmt.Fprint(w, `<ListVersionsResult><IsTruncated>false</IsTruncated>`+
+				`<Version><Key>a</Key><VersionId>null</VersionId><IsLatest>true</IsLatest><Size>1</Size><ETag>"aa"</ETag><LastModified>2026-01-01T00:00:00.000Z</LastModified></Version>`+
+				`</ListVersionsResult>`)
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+	})
+}
+
+func TestWriteSnapshotManifest(t *testing.T) {
+	bucket := &fakeVersionedBucket{}
+	client := bucket.client(t)
+	m := &EnhancedMigrator{}
+	input := MigrateInput{
+		SourceBucket: "src",
+		DestBucket:   "dest",
+		Snapshot:     &SnapshotPolicy{Prefix: "manifests", Name: "task-1"},
+	}
+
+	key, digest, err := m.writeSnapshotManifest(context.Background(), input, client, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "manifests/task-1.json" || bucket.putKey != "/dest/manifests/task-1.json" {
+		t.Fatalf("manifest written to %q (%s)", key, bucket.putKey)
+	}
+	sum := sha256.Sum256(bucket.putBody)
+	if want := hex.EncodeToString(sum[:]); digest != want || bucket.putMetadata != want {
+		t.Fatalf("digest %q, metadata %q, body hashes to %q", digest, bucket.putMetadata, want)
+	}
+
+	var manifest SnapshotManifest
+	if err := json.Unmarshal(bucket.putBody, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if !manifest.Versioned || manifest.Objects != 2 || manifest.TotalSize != 3 {
+		t.Fatalf("unexpected manifest summary: %+v", manifest)
+	}
+	want := []SnapshotEntry{
+		{Key: "a", Size: 1, ETag: "aa", LastModified: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Key: "b", Size: 2, ETag: "bb", VersionID: "v2", LastModified: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for i, e := range manifest.Entries {
+		if e.Key != want[i].Key || e.Size != want[i].Size || e.ETag != want[i].ETag || e.VersionID != want[i].VersionID || !e.LastModified.Equal(want[i].LastModified) {
+			t.Errorf("entry %d = %+v, want %+v", i, e, want[i])
+		}
+	}
+}
+
+func TestSnapshotPolicyFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *models.SnapshotOptions
+		want    string
+		wantErr bool
+	}{
+		{name: "disabled", opts: &models.SnapshotOptions{Prefix: "x"}},
+		{name: "default prefix", opts: &models.SnapshotOptions{Enabled: true}, want: DefaultSnapshotPrefix},
+		{name: "trimmed prefix", opts: &models.SnapshotOptions{Enabled: true, Prefix: "/audit/manifests/"}, want: "audit/manifests"},
+		{name: "escaping prefix", opts: &models.SnapshotOptions{Enabled: true, Prefix: "a/../.."}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := SnapshotPolicyFor(tt.opts, "task")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SnapshotPolicyFor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := ""
+			if policy != nil {
+				got = policy.Prefix
+			}
+			if got != tt.want {
+				t.Fatalf("prefix = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
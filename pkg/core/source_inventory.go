@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+
+	"s3migration/pkg/inventory"
+	"s3migration/pkg/models"
+)
+
+// SourceInventoryFor builds the source inventory of a migration request (nil
+// without one). Secret references of its credentials must already be resolved.
+func SourceInventoryFor(opts *models.SourceInventory) (inventory.Source, error) {
+	if opts == nil {
+		return nil, nil
+	}
+	cfg := inventory.Config{
+		Type:              opts.Type,
+		Table:             opts.Table,
+		KeyAttribute:      opts.KeyAttribute,
+		SizeAttribute:     opts.SizeAttribute,
+		ModifiedAttribute: opts.ModifiedAttribute,
+		ETagAttribute:     opts.ETagAttribute,
+		Driver:            opts.Driver,
+		DSN:               opts.DSN,
+		Query:             opts.Query,
+	}
+	if creds := opts.Credentials; creds != nil {
+		cfg.Region, cfg.Endpoint = creds.Region, creds.EndpointURL
+		if creds.AccessKey != "" {
+			cfg.Credentials = aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(creds.AccessKey, creds.SecretKey, creds.SessionToken))
+		}
+	}
+	return inventory.New(cfg)
+}
+
+// readInventory reads the source objects of a Migrate call from its inventory
+func (m *EnhancedMigrator) readInventory(ctx context.Context, input MigrateInput) ([]objectInfo, error) {
+	entries, err := input.SourceInventory.Entries(ctx, input.SourcePrefix)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]objectInfo, 0, len(entries))
+	for _, entry := range entries {
+		objects = append(objects, objectInfo{Key: entry.Key, Size: entry.Size, LastModified: entry.LastModified, ETag: entry.ETag})
+	}
+	m.live.addListed(len(objects))
+	fmt.Printf("Source inventory %s: %d objects under %q, the source bucket is not listed\n", input.SourceInventory.Name(), len(objects), input.SourcePrefix)
+	return objects, nil
+}
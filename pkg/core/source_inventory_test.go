@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/inventory"
+	"s3migration/pkg/models"
+	"s3migration/pkg/pool"
+)
+
+// staticInventory is an inventory of fixed entries
+type staticInventory []inventory.Entry
+
+func (s staticInventory) Name() string { return "static" }
+
+func (s staticInventory) Entries(ctx context.Context, prefix string) ([]inventory.Entry, error) {
+	return s, nil
+}
+
+func TestMigrateFromSourceInventory(t *testing.T) {
+	endpoint := fakes3.New("source")
+	defer endpoint.Close()
+	endpoint.Put("source", "docs/a.txt", []byte("alpha"))
+	endpoint.Put("source", "docs/b.txt", []byte("bravo"))
+	endpoint.Put("source", "docs/not-in-inventory.txt", []byte("left out"))
+	// Listing the source fails, so the run only succeeds if it never lists it
+	endpoint.Deny = func(method, bucket, key string) bool {
+		return method == http.MethodGet && bucket == "source" && key == ""
+	}
+
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := migrator.Migrate(context.Background(), MigrateInput{
+		SourceBucket:  "source",
+		SourcePrefix:  "docs/",
+		DestBucket:    "dest",
+		MigrationMode: ModeFullRewrite,
+		Timeout:       time.Minute,
+		SourceInventory: staticInventory{
+			{Key: "docs/a.txt", Size: 5},
+			{Key: "docs/b.txt", Size: 5},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Copied != 2 || result.Failed != 0 {
+		t.Fatalf("copied %d, failed %d: %v", result.Copied, result.Failed, result.Errors)
+	}
+	if keys := endpoint.Keys("dest"); len(keys) != 2 || keys[0] != "docs/a.txt" || keys[1] != "docs/b.txt" {
+		t.Fatalf("dest keys = %v", keys)
+	}
+}
+
+func TestSourceInventoryFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *models.SourceInventory
+		want    string
+		wantErr bool
+	}{
+		{name: "none", opts: nil},
+		{name: "dynamodb", opts: &models.SourceInventory{Type: "dynamodb", Table: "objects", Credentials: &models.Credentials{AccessKey: "AK", SecretKey: "SK", Region: "eu-west-1"}}, want: "dynamodb:objects"},
+		{name: "dynamodb without table", opts: &models.SourceInventory{Type: "dynamodb"}, wantErr: true},
+		{name: "sql", opts: &models.SourceInventory{Type: "sql", DSN: "postgres://inventory", Query: "SELECT key, size FROM objects"}, want: "sql:postgres"},
+		{name: "unknown type", opts: &models.SourceInventory{Type: "firestore"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := SourceInventoryFor(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SourceInventoryFor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if source != nil && source.Name() != tt.want || source == nil && tt.want != "" {
+				t.Errorf("SourceInventoryFor() = %v, want %s", source, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/prefetch"
+)
+
+// TaskListingPolicy caches the destination listing a completed run leaves, tied
+// to its task, so later verifications compare against it instead of listing again
+type TaskListingPolicy struct {
+	TaskID string
+	Store  prefetch.TaskListingStore
+}
+
+// TaskListingSource is the cached listing of a task that a verification compares
+// the source with, in place of the destination
+type TaskListingSource struct {
+	TaskID string
+	Store  prefetch.TaskListingStore
+}
+
+// destEndpoint returns the endpoint of the destination: its own, or the source's
+// when it is written with the source client
+func (m *EnhancedMigrator) destEndpoint(input MigrateInput, destClient *s3.Client) string {
+	if destClient != nil {
+		return input.DestEndpointURL
+	}
+	return m.config.EndpointURL
+}
+
+// cacheTaskListing lists the destination page by page and stores the listing in
+// parts of prefetch.TaskListingPartSize objects
+func (m *EnhancedMigrator) cacheTaskListing(ctx context.Context, input MigrateInput, destClient *s3.Client) (*prefetch.TaskListing, error) {
+	client := destClient
+	if client == nil {
+		client = m.metadataClient()
+	}
+	policy := input.TaskListing
+	listing := prefetch.TaskListing{
+		TaskID:   policy.TaskID,
+		Endpoint: m.destEndpoint(input, destClient),
+		Bucket:   input.DestBucket,
+		Prefix:   input.DestPrefix,
+		ListedAt: time.Now().UTC(),
+	}
+
+	pages := m.newObjectPager(client, input.DestBucket, input.DestPrefix)
+	part := make([]prefetch.ObjectMetadata, 0, prefetch.TaskListingPartSize)
+	save := func() error {
+		if err := policy.Store.SaveTaskListingPart(policy.TaskID, listing.Parts, part); err != nil {
+			return err
+		}
+		listing.Parts++
+		part = part[:0]
+		return nil
+	}
+	for {
+		page, err := pages.next(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list destination for the task listing: %w", err)
+		}
+		if page == nil {
+			break
+		}
+		for _, obj := range page {
+			part = append(part, prefetch.ObjectMetadata{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified, ETag: obj.ETag})
+			listing.Objects++
+			listing.Bytes += obj.Size
+			if len(part) == prefetch.TaskListingPartSize {
+				if err := save(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if len(part) > 0 || listing.Parts == 0 {
+		if err := save(); err != nil {
+			return nil, err
+		}
+	}
+	if err := policy.Store.CompleteTaskListing(listing); err != nil {
+		return nil, err
+	}
+	return &listing, nil
+}
+
+// taskListingPages returns a pager over the objects of a task's cached listing
+// under prefix, one stored part at a time; it returns nil once the listing is done
+func taskListingPages(source *TaskListingSource, endpoint, bucket, prefix string) (func(ctx context.Context) ([]objectInfo, error), *prefetch.TaskListing, error) {
+	listing, err := source.Store.LoadTaskListing(source.TaskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if listing == nil {
+		return nil, nil, fmt.Errorf("task %s has no cached destination listing", source.TaskID)
+	}
+	if listing.Bucket != bucket || strings.TrimRight(listing.Endpoint, "/") != strings.TrimRight(endpoint, "/") {
+		return nil, nil, fmt.Errorf("the listing of task %s is of bucket %s at %q, not %s at %q", source.TaskID, listing.Bucket, listing.Endpoint, bucket, endpoint)
+	}
+	if !strings.HasPrefix(prefix, listing.Prefix) {
+		return nil, nil, fmt.Errorf("the listing of task %s covers prefix %q, which does not include %q", source.TaskID, listing.Prefix, prefix)
+	}
+
+	part := 0
+	next := func(ctx context.Context) ([]objectInfo, error) {
+		for ; part < listing.Parts; part++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			objects, err := source.Store.LoadTaskListingPart(source.TaskID, part)
+			if err != nil {
+				return nil, err
+			}
+			page := make([]objectInfo, 0, len(objects))
+			for _, obj := range objects {
+				if strings.HasPrefix(obj.Key, prefix) {
+					page = append(page, objectInfo{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified, ETag: obj.ETag})
+				}
+			}
+			if len(page) > 0 {
+				part++
+				return page, nil
+			}
+		}
+		return nil, nil
+	}
+	return next, listing, nil
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/pool"
+	"s3migration/pkg/state"
+)
+
+func TestVerifyAgainstTaskListing(t *testing.T) {
+	endpoint := fakes3.New("source", "dest")
+	defer endpoint.Close()
+	endpoint.Put("source", "logs/a.txt", []byte("alpha"))
+	endpoint.Put("source", "logs/b.txt", []byte("bravo"))
+	endpoint.Put("source", "other/c.txt", []byte("charlie"))
+
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := state.NewMemoryTaskListingStore()
+	result, err := migrator.Migrate(context.Background(), MigrateInput{
+		SourceBucket:  "source",
+		DestBucket:    "dest",
+		DestPrefix:    "backup",
+		MigrationMode: ModeFullRewrite,
+		TaskListing:   &TaskListingPolicy{TaskID: "task-1", Store: store},
+		Timeout:       time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errors) != 0 {
+		t.Fatalf("errors = %v", result.Errors)
+	}
+	listing, _ := store.LoadTaskListing("task-1")
+	if listing == nil || listing.Bucket != "dest" || listing.Prefix != "backup" || listing.Objects != 3 || listing.Bytes != 17 || listing.Parts != 1 {
+		t.Fatalf("listing = %+v", listing)
+	}
+
+	// Written after the run: seen by a fresh listing, not by the cached one
+	endpoint.Put("dest", "backup/logs/z.txt", []byte("zulu"))
+	input := MigrateInput{SourceBucket: "source", SourcePrefix: "logs/", DestBucket: "dest", DestPrefix: "backup"}
+	live, err := migrator.Verify(context.Background(), input, VerifySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if live.Extra != 1 || live.DestListedAt != nil {
+		t.Errorf("live report = %+v", live)
+	}
+	input.VerifyListing = &TaskListingSource{TaskID: "task-1", Store: store}
+	cached, err := migrator.Verify(context.Background(), input, VerifySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.Drifted || cached.SourceObjects != 2 || cached.DestObjects != 2 || cached.DestListedAt == nil || !cached.DestListedAt.Equal(listing.ListedAt) {
+		t.Errorf("cached report = %+v", cached)
+	}
+
+	tests := []struct {
+		name  string
+		input MigrateInput
+	}{
+		{name: "no listing", input: MigrateInput{SourceBucket: "source", DestBucket: "dest", VerifyListing: &TaskListingSource{TaskID: "task-2", Store: store}}},
+		{name: "other bucket", input: MigrateInput{SourceBucket: "source", DestBucket: "source", VerifyListing: input.VerifyListing}},
+		{name: "outside the prefix", input: MigrateInput{SourceBucket: "source", DestBucket: "dest", DestPrefix: "restore", VerifyListing: input.VerifyListing}},
+	}
+	for _, tt := range tests {
+		if _, err := migrator.Verify(context.Background(), tt.input, VerifySize); err == nil {
+			t.Errorf("%s: verification ran", tt.name)
+		}
+	}
+}
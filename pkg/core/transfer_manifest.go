@@ -0,0 +1,176 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxTransferManifestEntries caps the keys recorded per list of a transfer manifest
+const maxTransferManifestEntries = 100000
+
+// TransferManifest lists the source keys a migration copied and failed to copy,
+// for handing the rest of a cutover to rclone or the AWS CLI
+type TransferManifest struct {
+	SourcePrefix string   `json:"source_prefix,omitempty"`
+	Copied       []string `json:"copied"`
+	Failed       []string `json:"failed"`
+	Truncated    bool     `json:"truncated"` // A list reached maxTransferManifestEntries
+}
+
+// record adds a copy's outcome to the manifest
+func (t *TransferManifest) record(key string, copied bool) {
+	list := &t.Failed
+	if copied {
+		list = &t.Copied
+	}
+	if len(*list) >= maxTransferManifestEntries {
+		t.Truncated = true
+		return
+	}
+	*list = append(*list, key)
+}
+
+// ManifestFormat is a file list format of another transfer tool
+type ManifestFormat string
+
+const (
+	// ManifestRclone is one path per line, relative to the source prefix, for
+	// rclone copy --files-from-raw (or --files-from when no path starts with # or ;)
+	ManifestRclone ManifestFormat = "rclone"
+	// ManifestAWS is one --include argument per line, relative to the source prefix,
+	// for xargs aws s3 cp (or sync) ... --recursive --exclude "*"
+	ManifestAWS ManifestFormat = "aws"
+)
+
+// relativeKey returns key relative to prefix, the root both tools list from
+func relativeKey(key, prefix string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(key, prefix), "/")
+}
+
+// WriteManifest writes keys in format; keys a line cannot hold (with a newline)
+// are left out and their number returned
+func WriteManifest(w io.Writer, format ManifestFormat, keys []string, prefix string) (int, error) {
+	bw := bufio.NewWriter(w)
+	omitted := 0
+	for _, key := range keys {
+		path := relativeKey(key, prefix)
+		if path == "" || strings.ContainsAny(path, "\r\n") {
+			omitted++
+			continue
+		}
+		switch format {
+		case ManifestRclone:
+			bw.WriteString(path)
+		case ManifestAWS:
+			bw.WriteString("--include ")
+			bw.WriteString(xargsQuote(awsGlobEscape(path)))
+		default:
+			return 0, fmt.Errorf("unsupported manifest format %q (expected rclone or aws)", format)
+		}
+		if err := bw.WriteByte('\n'); err != nil {
+			return 0, err
+		}
+	}
+	return omitted, bw.Flush()
+}
+
+// awsGlobEscape makes a path match itself only in an AWS CLI --include pattern
+func awsGlobEscape(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		switch r {
+		case '*', '?', '[':
+			b.WriteByte('[')
+			b.WriteRune(r)
+			b.WriteByte(']')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// xargsQuote backslash-escapes the characters xargs splits or unquotes on
+func xargsQuote(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case ' ', '\t', '\'', '"', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// RcloneCheck is the outcome of rclone check --combined, by path
+type RcloneCheck struct {
+	Identical       []string `json:"identical"`
+	MissingOnDest   []string `json:"missing_on_dest"`   // "+": only in the source
+	MissingOnSource []string `json:"missing_on_source"` // "-": only in the destination
+	Differ          []string `json:"differ"`            // "*": in both, with different content
+	Errors          []string `json:"errors"`            // "!": could not be read or hashed
+}
+
+// FilesFrom returns the paths still to copy: missing on the destination, different, or unreadable
+func (r *RcloneCheck) FilesFrom() []string {
+	files := make([]string, 0, len(r.MissingOnDest)+len(r.Differ)+len(r.Errors))
+	files = append(files, r.MissingOnDest...)
+	files = append(files, r.Differ...)
+	return append(files, r.Errors...)
+}
+
+// ParseRcloneCheck reads the output of rclone check --combined. Plain lists
+// (--missing-on-dst, --differ) have no marker and count as missing on the destination.
+func ParseRcloneCheck(r io.Reader) (*RcloneCheck, error) {
+	check := &RcloneCheck{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		marker, path, combined := strings.Cut(text, " ")
+		if !combined || len(marker) != 1 || !strings.Contains("=+-*!", marker) {
+			check.MissingOnDest = append(check.MissingOnDest, text)
+			continue
+		}
+		if path == "" {
+			return nil, fmt.Errorf("line %d: %q has no path", line, text)
+		}
+		switch marker {
+		case "=":
+			check.Identical = append(check.Identical, path)
+		case "+":
+			check.MissingOnDest = append(check.MissingOnDest, path)
+		case "-":
+			check.MissingOnSource = append(check.MissingOnSource, path)
+		case "*":
+			check.Differ = append(check.Differ, path)
+		case "!":
+			check.Errors = append(check.Errors, path)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rclone check output: %w", err)
+	}
+	return check, nil
+}
+
+// filterFilesFrom keeps the objects whose key, relative to prefix, is in files
+func filterFilesFrom(objects []objectInfo, files []string, prefix string) []objectInfo {
+	wanted := make(map[string]bool, len(files))
+	for _, file := range files {
+		wanted[strings.TrimPrefix(file, "/")] = true
+	}
+	kept := make([]objectInfo, 0, len(files))
+	for _, obj := range objects {
+		if wanted[relativeKey(obj.Key, prefix)] {
+			kept = append(kept, obj)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteManifest(t *testing.T) {
+	keys := []string{"data/a.txt", "data/dir/b c.jpg", "data/*odd?[1].txt", "data/it's \"q\".txt", "data/line\nbreak"}
+	tests := []struct {
+		format ManifestFormat
+		want   string
+	}{
+		{format: ManifestRclone, want: "a.txt\ndir/b c.jpg\n*odd?[1].txt\nit's \"q\".txt\n"},
+		{format: ManifestAWS, want: "--include a.txt\n--include dir/b\\ c.jpg\n--include [*]odd[?][[]1].txt\n--include it\\'s\\ \\\"q\\\".txt\n"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		omitted, err := WriteManifest(&b, tt.format, keys, "data/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != tt.want || omitted != 1 {
+			t.Errorf("%s: got %q (%d omitted), want %q (1 omitted)", tt.format, b.String(), omitted, tt.want)
+		}
+	}
+
+	if _, err := WriteManifest(&strings.Builder{}, "csv", keys, ""); err == nil {
+		t.Fatal("unsupported format accepted")
+	}
+}
+
+func TestParseRcloneCheck(t *testing.T) {
+	output := "= same.txt\n+ new file.txt\n- only-dest.txt\n* changed.txt\r\n! unreadable.txt\n\nplain/missing.txt\n"
+	check, err := ParseRcloneCheck(strings.NewReader(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &RcloneCheck{
+		Identical:       []string{"same.txt"},
+		MissingOnDest:   []string{"new file.txt", "plain/missing.txt"},
+		MissingOnSource: []string{"only-dest.txt"},
+		Differ:          []string{"changed.txt"},
+		Errors:          []string{"unreadable.txt"},
+	}
+	if !reflect.DeepEqual(check, want) {
+		t.Fatalf("check = %+v, want %+v", check, want)
+	}
+	if got := check.FilesFrom(); !reflect.DeepEqual(got, []string{"new file.txt", "plain/missing.txt", "changed.txt", "unreadable.txt"}) {
+		t.Fatalf("files from = %v", got)
+	}
+
+	if _, err := ParseRcloneCheck(strings.NewReader("+ \n")); err == nil {
+		t.Fatal("marker without a path accepted")
+	}
+}
+
+func TestTransferManifestRecord(t *testing.T) {
+	manifest := &TransferManifest{Copied: make([]string, maxTransferManifestEntries-1)}
+	manifest.record("a", true)
+	manifest.record("b", false)
+	if manifest.Truncated || len(manifest.Copied) != maxTransferManifestEntries || len(manifest.Failed) != 1 {
+		t.Fatalf("truncated %v with %d copied, %d failed", manifest.Truncated, len(manifest.Copied), len(manifest.Failed))
+	}
+	manifest.record("c", true)
+	if !manifest.Truncated || len(manifest.Copied) != maxTransferManifestEntries {
+		t.Fatalf("full list: truncated %v with %d copied", manifest.Truncated, len(manifest.Copied))
+	}
+}
+
+func TestFilterFilesFrom(t *testing.T) {
+	objects := []objectInfo{{Key: "data/a"}, {Key: "data/b"}, {Key: "data/dir/c"}}
+	kept := filterFilesFrom(objects, []string{"a", "/dir/c", "missing"}, "data/")
+	if len(kept) != 2 || kept[0].Key != "data/a" || kept[1].Key != "data/dir/c" {
+		t.Fatalf("kept %+v", kept)
+	}
+}
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/config"
+	"s3migration/pkg/logging"
+	"s3migration/pkg/models"
+	"s3migration/pkg/transform"
+)
+
+// TransformPolicyFor builds the transformation policy of a migration request (nil without one)
+func TransformPolicyFor(opts *models.TransformOptions) (*transform.Policy, error) {
+	if opts == nil {
+		return nil, nil
+	}
+	if opts.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("transform timeout_seconds must not be negative")
+	}
+	return transform.New(transform.Config{
+		Type:    opts.Type,
+		URL:     opts.URL,
+		Timeout: time.Duration(opts.TimeoutSeconds) * time.Second,
+		Headers: opts.Headers,
+		OnError: transform.ErrorPolicy(opts.OnError),
+		Match:   opts.Match,
+	})
+}
+
+// errTransformSkipped marks an object left out by the skip error policy
+var errTransformSkipped = errors.New("object skipped after transformation error")
+
+// transformCopy streams an object through the task's transformation hook and
+// writes the result with PutObject. The result goes through one PutObject, so
+// transformed objects are limited to 5 GiB; bodies of unknown length are spooled
+// to a temporary file first. Integrity checks compare source and destination,
+// which differ by design here, so they are not recorded for transformed objects.
+func (m *EnhancedMigrator) transformCopy(ctx context.Context, log logging.ObjectLogger, sourceClient, destClient *s3.Client, sourceBucket, sourceKey, destBucket, destKey string, objectSize int64) error {
+	if objectSize > config.MaxPartSize {
+		return fmt.Errorf("object of %d bytes is too large to transform (limit 5 GiB)", objectSize)
+	}
+
+	getResp, err := sourceClient.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(sourceBucket),
+		Key:    aws.String(sourceKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get object from source: %w", err)
+	}
+	defer getResp.Body.Close()
+
+	var body io.Reader = getResp.Body
+	if objectSize >= inFlightReportMinSize {
+		body = &progressReader{reader: body, tracker: m.inflight, key: sourceKey}
+	}
+
+	source := sourceObject{
+		bucket:      sourceBucket,
+		key:         sourceKey,
+		size:        objectSize,
+		contentType: aws.ToString(getResp.ContentType),
+		metadata:    getResp.Metadata,
+		redirect:    getResp.WebsiteRedirectLocation,
+	}
+	return m.transformBody(ctx, log, destClient, source, body, destBucket, destKey, func() error {
+		return m.crossAccountCopy(ctx, log, sourceClient, destClient, sourceBucket, sourceKey, destBucket, destKey, objectSize)
+	})
+}
+
+// sourceObject is the source side of an object read through the migrator
+type sourceObject struct {
+	bucket      string
+	key         string
+	size        int64
+	contentType string
+	metadata    map[string]string
+	redirect    *string // x-amz-website-redirect-location
+}
+
+// transformBody passes body through the hook and writes the result to destKey;
+// untransformed runs the passthrough error policy with the original object
+func (m *EnhancedMigrator) transformBody(ctx context.Context, log logging.ObjectLogger, destClient *s3.Client, source sourceObject, body io.Reader, destBucket, destKey string, untransformed func() error) error {
+	policy := m.transform
+	result, err := policy.Hook.Transform(ctx, transform.Object{
+		Bucket:      source.bucket,
+		Key:         source.key,
+		Size:        source.size,
+		ContentType: source.contentType,
+	}, body)
+	if err != nil {
+		return m.transformFailed(log, source.key, err, untransformed)
+	}
+	defer result.Body.Close()
+
+	var output io.Reader = result.Body
+	size := result.Size
+	if size < 0 {
+		spool, spooledSize, err := spoolBody(result.Body)
+		if err != nil {
+			return m.transformFailed(log, source.key, err, untransformed)
+		}
+		defer removeSpool(spool)
+		output, size = spool, spooledSize
+	}
+	if size > config.MaxPartSize {
+		return fmt.Errorf("transformed object of %d bytes exceeds the 5 GiB PutObject limit", size)
+	}
+
+	contentType := result.ContentType
+	if contentType == "" {
+		contentType = source.contentType
+	}
+	putInput := &s3.PutObjectInput{
+		Bucket:        aws.String(destBucket),
+		Key:           aws.String(destKey),
+		Body:          output,
+		ContentLength: aws.Int64(size),
+		Metadata:      source.metadata,
+		// Website redirects are object metadata S3 keeps outside x-amz-meta-*
+		WebsiteRedirectLocation: source.redirect,
+	}
+	if contentType != "" {
+		putInput.ContentType = aws.String(contentType)
+	}
+	putResp, err := destClient.PutObject(ctx, putInput)
+	if err != nil {
+		return fmt.Errorf("failed to put transformed object: %w", err)
+	}
+
+	log.Debugf("[TRANSFORM] %s via %s: %d -> %d bytes", source.key, policy.Hook.Name(), source.size, size)
+	return m.verifyWrite(ctx, destClient, destBucket, destKey, size, writeChecksums{ETag: aws.ToString(putResp.ETag)})
+}
+
+// transformFailed applies the hook's error policy to a failed transformation
+func (m *EnhancedMigrator) transformFailed(log logging.ObjectLogger, sourceKey string, cause error, untransformed func() error) error {
+	switch m.transform.OnError {
+	case transform.OnErrorSkip:
+		log.Infof("[TRANSFORM] Skipping %s: %v", sourceKey, cause)
+		return errTransformSkipped
+	case transform.OnErrorPassThrough:
+		log.Infof("[TRANSFORM] Copying %s untransformed: %v", sourceKey, cause)
+		return untransformed()
+	default:
+		return fmt.Errorf("transformation failed: %w", cause)
+	}
+}
+
+// spoolBody copies body to a temporary file and rewinds it
+func spoolBody(body io.Reader) (*os.File, int64, error) {
+	spool, err := os.CreateTemp("", "s3migration-transform-*")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create spool file: %w", err)
+	}
+	size, err := io.Copy(spool, body)
+	if err == nil {
+		_, err = spool.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		removeSpool(spool)
+		return nil, 0, fmt.Errorf("failed to spool transformed object: %w", err)
+	}
+	return spool, size, nil
+}
+
+// removeSpool closes and deletes a spool file
+func removeSpool(spool *os.File) {
+	spool.Close()
+	os.Remove(spool.Name())
+}
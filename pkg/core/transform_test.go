@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/pool"
+	"s3migration/pkg/transform"
+)
+
+// upperHook upper-cases objects and fails those whose key ends in .bad
+type upperHook struct{}
+
+func (upperHook) Name() string { return "upper" }
+
+func (upperHook) Transform(_ context.Context, obj transform.Object, body io.Reader) (*transform.Result, error) {
+	if strings.HasSuffix(obj.Key, ".bad") {
+		return nil, errors.New("unsupported")
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	return &transform.Result{Body: io.NopCloser(strings.NewReader(strings.ToUpper(string(data)))), Size: -1}, nil
+}
+
+func TestTransformErrorPolicies(t *testing.T) {
+	tests := []struct {
+		onError  transform.ErrorPolicy
+		copied   int
+		failed   int
+		skipped  int
+		wantDest string // Content of b.bad at the destination; empty when not copied
+	}{
+		{onError: transform.OnErrorFail, copied: 1, failed: 1},
+		{onError: transform.OnErrorSkip, copied: 1, skipped: 1},
+		{onError: transform.OnErrorPassThrough, copied: 2, wantDest: "bravo"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.onError), func(t *testing.T) {
+			endpoint := fakes3.New("src", "dest")
+			defer endpoint.Close()
+			endpoint.Put("src", "a.txt", []byte("alpha"))
+			endpoint.Put("src", "b.bad", []byte("bravo"))
+
+			migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+				ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := migrator.Migrate(context.Background(), MigrateInput{
+				SourceBucket:  "src",
+				DestBucket:    "dest",
+				MigrationMode: ModeFullRewrite,
+				Transform:     &transform.Policy{Hook: upperHook{}, OnError: tt.onError},
+				Timeout:       time.Minute,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Copied != int64(tt.copied) || result.Failed != int64(tt.failed) || result.Skipped != int64(tt.skipped) {
+				t.Fatalf("copied %d, failed %d, skipped %d; want %d, %d and %d", result.Copied, result.Failed, result.Skipped, tt.copied, tt.failed, tt.skipped)
+			}
+			if obj := endpoint.Get("dest", "a.txt"); obj == nil || string(obj.Data) != "ALPHA" {
+				t.Fatalf("a.txt was not transformed: %+v", obj)
+			}
+			obj := endpoint.Get("dest", "b.bad")
+			if (obj == nil) != (tt.wantDest == "") || (obj != nil && string(obj.Data) != tt.wantDest) {
+				t.Fatalf("b.bad at the destination = %+v, want %q", obj, tt.wantDest)
+			}
+		})
+	}
+}
@@ -2,6 +2,16 @@ package core
 
 import (
 	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"s3migration/pkg/config"
+	"s3migration/pkg/inventory"
+	"s3migration/pkg/models"
+	"s3migration/pkg/progress"
+	"s3migration/pkg/scan"
+	pkgSync "s3migration/pkg/sync"
+	"s3migration/pkg/transform"
 )
 
 // MigrationMode defines the migration behavior
@@ -16,38 +26,135 @@ const (
 
 // MigrateInput contains parameters for a migration operation
 type MigrateInput struct {
-	SourceBucket      string
-	DestBucket        string
-	SourcePrefix      string
-	DestPrefix        string
-	DestRegion        string
-	DryRun            bool
-	SyncMode          bool          // Deprecated: use MigrationMode instead
-	MigrationMode     MigrationMode // Migration mode: full_rewrite or incremental
-	Timeout           time.Duration
+	SourceBucket  string
+	DestBucket    string
+	SourcePrefix  string
+	DestPrefix    string
+	DestRegion    string
+	DryRun        bool
+	SyncMode      bool          // Deprecated: use MigrationMode instead
+	MigrationMode MigrationMode // Migration mode: full_rewrite or incremental
+	Timeout       time.Duration
 	// Destination credentials (optional, if different from source)
-	DestAccessKey     string
-	DestSecretKey     string
-	DestEndpointURL   string
-	// Progress callback for real-time updates
-	ProgressCallback  func(progress float64, copied, total int64, speed float64, eta string)
+	DestAccessKey   string
+	DestSecretKey   string
+	DestEndpointURL string
+	// Dynamic destination credentials (secret reference); used instead of DestAccessKey/DestSecretKey
+	DestCredentialsProvider aws.CredentialsProvider
+	// Conflict handling for keys that already exist in the destination
+	ConflictStrategy pkgSync.ConflictStrategy
+	// Only copy these keys, relative to SourcePrefix, e.g. from rclone check (nil = every listed object)
+	FilesFrom []string
+	// Order in which objects are queued for copying (empty = as listed)
+	Order ObjectOrder
+	// Dry run: also list the destination and report per-key create/overwrite/skip
+	DryRunDiff bool
+	// Compare against a cached destination listing instead of listing again
+	// (objects changed outside the tool since then are missed); ignored with VerifyWrite
+	ReuseDestListing bool
+	// HEAD each destination object after writing it and check length and checksums
+	VerifyWrite bool
+	// Request budget shared with other tasks writing to the same destination endpoint (nil = unbounded)
+	EndpointGroup *EndpointGroup
+	// Transformation applied to matching objects on their way to the destination (nil = copy as-is)
+	Transform *transform.Policy
+	// Content scan each object must pass before it is written (nil = no scanning)
+	Scan *scan.Policy
+	// Copy each distinct content once and record the duplicates in a manifest (nil = copy every key)
+	Dedupe *DedupePolicy
+	// Manifest of the destination state written after a completed run (nil = none)
+	Snapshot *SnapshotPolicy
+	// Store the destination listing with the task once the run completes (nil = not kept)
+	TaskListing *TaskListingPolicy
+	// Verify against a task's cached destination listing instead of listing the destination (nil = list it)
+	VerifyListing *TaskListingSource
+	// Checkpoint the source listing, so an interrupted task resumes it from the last marker (nil = list from the start)
+	ListingCheckpoint *ListingCheckpointPolicy
+	// Read the source objects from an inventory database instead of listing the source bucket (nil = list it)
+	SourceInventory inventory.Source
+	// Leave out objects a destination lifecycle rule would expire as soon as they are written
+	ExcludeLifecycleExpired bool
+	// Date-partitioned destination layout derived from LastModified (nil = keys kept as-is)
+	Partition *PartitionPolicy
+	// Rewrite source keys that would break on the destination (nil = keys kept as-is)
+	KeySanitize *KeySanitizePolicy
+	// Concurrent copies per source key prefix, with the queue alternating between prefixes (nil = unlimited)
+	PrefixShards *PrefixShardPolicy
+	// Let the destination pull objects from pre-signed source URLs, when its fetch
+	// service supports the destination (nil = stream them through the migration)
+	DestFetch *DestFetchPolicy
+	// Compare the destination with what the run wrote once it completes (nil = no report)
+	Reconcile *ReconcilePolicy
+	// Zero-byte folder markers (keys ending in "/"): copied (default), recreated or left out
+	FolderMarkers FolderMarkerMode
+	// Record cross-account copies and send conditional GETs for them on later syncs of the route (nil = always download)
+	ConditionalGet *ConditionalGetPolicy
+	// Write objects only where their destination key does not exist, skipping or failing the others (empty = overwrite)
+	NeverOverwrite NeverOverwriteMode
+	// Upload the keys that failed to copy and pre-sign a link to them (nil = no manifest)
+	FailureManifest *FailureManifestPolicy
+	// Write a record of every copied object to an append-only log (nil = no log)
+	AuditLog *AuditLogPolicy
+	// Map each copied object's source ACL onto the copy (nil = copies get the destination default)
+	ACL *ACLPolicy
+	// Tag every copy on the destination (nil = copies are not labeled)
+	Labels *LabelPolicy
+	// Multipart threshold and part sizing (zero value: default settings)
+	Multipart config.MultipartSettings
+	// Receives the phases and real-time progress of the migration (nil = not reported)
+	Reporter progress.Reporter
 }
 
 // MigrateResult contains the result of a migration operation
 type MigrateResult struct {
-	Copied           int64
-	Failed           int64
-	TotalSizeMB      float64
-	CopiedSizeMB     float64
-	ElapsedTime      string
-	AvgSpeedMB       float64
-	Cancelled        bool
-	RemainingObjects int64
-	Errors           []string
+	Copied            int64
+	Failed            int64
+	TotalSizeMB       float64
+	CopiedSizeMB      float64
+	ElapsedTime       string
+	AvgSpeedMB        float64
+	Cancelled         bool
+	RemainingObjects  int64
+	Skipped           int64                // Objects left out after a transformation error, by the content scan or as their key existed (NeverOverwriteSkip)
+	NotModified       int64                // Objects not downloaded as the source reported them unchanged since the last sync (ConditionalGet)
+	ScanFindings      []models.ScanFinding // Objects the content scan withheld
+	Deduplicated      int64                // Duplicate objects not copied, listed in DedupeManifest
+	DedupeManifest    string               // Destination key of the duplicate-to-canonical manifest
+	LifecycleExcluded int64                // Objects not copied as the destination's lifecycle rules would expire them
+	SnapshotManifest  string               // Destination key of the snapshot manifest
+	SnapshotSHA256    string               // SHA-256 of the snapshot manifest body
+	WebsiteCopied     bool                 // Static website configuration copied to the destination bucket
+	DestFetched       int64                // Objects the destination pulled from pre-signed source URLs
+	OverwriteRefused  int64                // Objects not written as their destination key existed (NeverOverwrite)
+	ExistingDestKeys  []string             // Destination keys of the first refused writes
+	Errors            []string
 	// Dry run specific information
-	DryRun           bool
-	DryRunVerified   []string
-	SampleFiles      []string
+	DryRun         bool
+	DryRunVerified []string
+	SampleFiles    []string
+	DryRunDiff     *DiffSummary
+	// Resource consumption sampled during the task window
+	ResourceUsage *models.ResourceUsage
+	// Worker count changes made on the source error rate
+	WorkerAdjustments []models.WorkerAdjustment
+	// Source keys copied and failed, for exporting to other transfer tools
+	Manifest *TransferManifest
+	// Destination objects and bytes compared with those migrated
+	Reconciliation *models.StorageReconciliation
+	// Folder markers written on the destination (FolderMarkersRecreate)
+	FolderMarkers int64
+	// Uploaded list of the keys that failed to copy
+	FailureManifest *models.FailureManifestLink
+	// Files of the per-object audit log written by the run
+	AuditLog *models.AuditLogLocation
+	// How the source ACLs were mapped onto the copies
+	ACL *models.ACLReport
+	// How many copies were labeled
+	Labels *models.LabelReport
+	// S3 requests the migrator made so far, this run and earlier ones included
+	Requests models.APIRequests
+	// Latency percentiles of those requests, by endpoint and operation
+	Latency []models.OperationLatency
 }
 
 // objectInfo represents basic object information
@@ -55,6 +162,7 @@ type objectInfo struct {
 	Key          string
 	Size         int64
 	LastModified time.Time
+	ETag         string // As listed; empty for cached listings
 }
 
 // copyJob represents a copy job for the worker pool
@@ -62,6 +170,7 @@ type copyJob struct {
 	sourceKey string
 	destKey   string
 	size      int64
+	etag      string // As listed, for the audit log
 }
 
 // copyResult represents the result of a copy operation
@@ -73,5 +182,21 @@ type copyResult struct {
 	err       error
 	success   bool
 	cancelled bool
+	skipped   bool // Left out by the transformation hook's skip policy, the content scan or NeverOverwriteSkip
+	// Unchanged since the last sync, with its copy in place (ConditionalGet)
+	notModified bool
+	// The destination refused the write as the key existed (NeverOverwrite)
+	overwriteRefused bool
+	// Bytes already reported as in-flight progress before the copy finished
+	partialBytes int64
+	etag         string
+	audit        *objectAudit // Details for the audit log (nil when the run is not audited)
 }
 
+// formatTime formats a timestamp as RFC3339, or empty for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// writeChecksums are the checksums a write response may carry; empty fields are unknown
+type writeChecksums struct {
+	ETag   string
+	CRC32  string
+	CRC32C string
+	SHA1   string
+	SHA256 string
+}
+
+// verifyWrite reads back a freshly written destination object with HeadObject and
+// checks its length, ETag and any checksum the write returned. Some S3-compatible
+// backends acknowledge writes they truncated; this catches them at the cost of
+// one request per object. It is a no-op unless verify_write is set, apart from
+// handing the checksums to the audit log.
+func (m *EnhancedMigrator) verifyWrite(ctx context.Context, client *s3.Client, bucket, key string, size int64, written writeChecksums) error {
+	recordWrite(ctx, written)
+	if !m.verifyWrites {
+		return nil
+	}
+
+	head, err := m.headObject(ctx, client, &s3.HeadObjectInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(key),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+	if err != nil {
+		return fmt.Errorf("write verification failed: HeadObject on %s: %w", key, err)
+	}
+
+	if got := aws.ToInt64(head.ContentLength); got != size {
+		return fmt.Errorf("write verification failed: %s has %d bytes, expected %d", key, got, size)
+	}
+
+	checks := []struct {
+		name, written, stored string
+	}{
+		{"ETag", written.ETag, aws.ToString(head.ETag)},
+		{"CRC32", written.CRC32, aws.ToString(head.ChecksumCRC32)},
+		{"CRC32C", written.CRC32C, aws.ToString(head.ChecksumCRC32C)},
+		{"SHA1", written.SHA1, aws.ToString(head.ChecksumSHA1)},
+		{"SHA256", written.SHA256, aws.ToString(head.ChecksumSHA256)},
+	}
+	for _, check := range checks {
+		w, s := strings.Trim(check.written, `"`), strings.Trim(check.stored, `"`)
+		if w != "" && s != "" && w != s {
+			return fmt.Errorf("write verification failed: %s %s is %s, write returned %s", key, check.name, s, w)
+		}
+	}
+
+	m.logger.Object().Debugf("[VERIFY] Read-after-write OK: %s (%d bytes)", key, size)
+	return nil
+}
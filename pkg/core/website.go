@@ -0,0 +1,73 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// prepareWebsite checks whether the source bucket hosts a static website. If it
+// does, per-object redirect locations are carried over on server-side copies and
+// the website configuration (index and error documents, redirects, routing rules)
+// is copied to the destination, so the migrated bucket serves the same site.
+// The configuration is only copied when keys keep their paths, and never over a
+// configuration the destination already has. Returns whether it was copied.
+func (m *EnhancedMigrator) prepareWebsite(ctx context.Context, input MigrateInput, destClient *s3.Client) (bool, error) {
+	sourceClient := m.metadataClient()
+	if destClient == nil {
+		destClient = sourceClient
+	}
+
+	website, err := sourceClient.GetBucketWebsite(ctx, &s3.GetBucketWebsiteInput{Bucket: aws.String(input.SourceBucket)})
+	switch status := configStatus(err, "NoSuchWebsiteConfiguration"); status.Status {
+	case ConfigAbsent, ConfigUnsupported:
+		return false, nil
+	case ConfigError:
+		return false, fmt.Errorf("failed to read source website configuration: %s", status.Error)
+	}
+	m.sourceWebsite = true
+
+	if input.DestPrefix != "" || input.Partition != nil {
+		fmt.Printf("Source bucket '%s' hosts a website, but keys are moved - not copying its website configuration\n", input.SourceBucket)
+		return false, nil // Index documents and routing rules would point at the old paths
+	}
+
+	_, err = destClient.GetBucketWebsite(ctx, &s3.GetBucketWebsiteInput{Bucket: aws.String(input.DestBucket)})
+	if configStatus(err, "NoSuchWebsiteConfiguration").Status != ConfigAbsent {
+		fmt.Printf("Destination bucket '%s' already has a website configuration (or it cannot be read) - leaving it as is\n", input.DestBucket)
+		return false, nil
+	}
+
+	_, err = destClient.PutBucketWebsite(ctx, &s3.PutBucketWebsiteInput{
+		Bucket: aws.String(input.DestBucket),
+		WebsiteConfiguration: &types.WebsiteConfiguration{
+			IndexDocument:         website.IndexDocument,
+			ErrorDocument:         website.ErrorDocument,
+			RedirectAllRequestsTo: website.RedirectAllRequestsTo,
+			RoutingRules:          website.RoutingRules,
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to copy website configuration: %w", err)
+	}
+	return true, nil
+}
+
+// websiteRedirect returns the redirect location of a source object, which S3
+// does not carry over on CopyObject. Only looked up when the source hosts a website.
+func (m *EnhancedMigrator) websiteRedirect(ctx context.Context, bucket, key string) (*string, error) {
+	if !m.sourceWebsite {
+		return nil, nil
+	}
+	head, err := m.headObject(ctx, m.metadataClient(), &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source metadata: %w", err)
+	}
+	return head.WebsiteRedirectLocation, nil
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/pool"
+)
+
+const testWebsiteConfig = `<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument><ErrorDocument><Key>404.html</Key></ErrorDocument><RoutingRules><RoutingRule><Condition><KeyPrefixEquals>docs/</KeyPrefixEquals></Condition><Redirect><ReplaceKeyPrefixWith>documents/</ReplaceKeyPrefixWith></Redirect></RoutingRule></RoutingRules></WebsiteConfiguration>`
+
+func TestMigratePreservesWebsite(t *testing.T) {
+	tests := []struct {
+		name        string
+		destPrefix  string
+		destWebsite string // Website configuration the destination already has
+		wantCopied  bool
+		wantKey     string
+	}{
+		{name: "copied", wantCopied: true, wantKey: "old.html"},
+		{name: "moved keys", destPrefix: "site", wantKey: "site/old.html"},
+		{name: "destination already configured", destWebsite: `<WebsiteConfiguration><IndexDocument><Suffix>home.html</Suffix></IndexDocument></WebsiteConfiguration>`, wantKey: "old.html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := fakes3.New("site", "dest")
+			defer endpoint.Close()
+			client := endpoint.Client()
+			endpoint.SetBucketConfig("site", "website", []byte(testWebsiteConfig))
+			if tt.destWebsite != "" {
+				endpoint.SetBucketConfig("dest", "website", []byte(tt.destWebsite))
+			}
+			endpoint.Put("site", "index.html", []byte("<h1>home</h1>"))
+			_, err := client.PutObject(context.Background(), &s3.PutObjectInput{
+				Bucket:                  aws.String("site"),
+				Key:                     aws.String("old.html"),
+				Body:                    strings.NewReader(""),
+				WebsiteRedirectLocation: aws.String("/index.html"),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+				ConnectionPool: pool.NewStaticConnectionPool(client),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := migrator.Migrate(context.Background(), MigrateInput{
+				SourceBucket:  "site",
+				DestBucket:    "dest",
+				DestPrefix:    tt.destPrefix,
+				MigrationMode: ModeFullRewrite,
+				Timeout:       time.Minute,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Copied != 2 || result.WebsiteCopied != tt.wantCopied {
+				t.Fatalf("copied %d, website copied %v: %v", result.Copied, result.WebsiteCopied, result.Errors)
+			}
+
+			website := string(endpoint.BucketConfig("dest", "website"))
+			switch {
+			case tt.wantCopied:
+				for _, want := range []string{"index.html", "404.html", "documents/"} {
+					if !strings.Contains(website, want) {
+						t.Errorf("destination website configuration does not contain %q: %s", want, website)
+					}
+				}
+			case website != tt.destWebsite:
+				t.Errorf("destination website configuration = %q, want %q", website, tt.destWebsite)
+			}
+			if object := endpoint.Get("dest", tt.wantKey); object == nil || object.Redirect != "/index.html" {
+				t.Fatalf("redirect location of %s not preserved: %+v", tt.wantKey, object)
+			}
+		})
+	}
+}
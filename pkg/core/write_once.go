@@ -0,0 +1,98 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/pool"
+)
+
+// NeverOverwriteMode makes a migration leave every existing destination object
+// untouched. Objects are written with If-None-Match: *, so the destination
+// itself refuses a write onto an existing key in the request that would have
+// replaced it, instead of a HEAD and a PUT racing other writers.
+type NeverOverwriteMode string
+
+const (
+	// NeverOverwriteSkip counts objects whose key exists as skipped
+	NeverOverwriteSkip NeverOverwriteMode = "skip"
+	// NeverOverwriteFail counts them as failed copies
+	NeverOverwriteFail NeverOverwriteMode = "fail"
+)
+
+// maxRefusedOverwriteKeys caps the destination keys a result lists as refused
+const maxRefusedOverwriteKeys = 100
+
+// ValidateNeverOverwriteMode checks a never-overwrite mode is known (empty is off)
+func ValidateNeverOverwriteMode(mode NeverOverwriteMode) error {
+	switch mode {
+	case "", NeverOverwriteSkip, NeverOverwriteFail:
+		return nil
+	default:
+		return fmt.Errorf("unknown never_overwrite %q (expected skip or fail)", mode)
+	}
+}
+
+// probeWriteOnce checks the destination honours If-None-Match: * before the
+// migration relies on it: a probe object is written under the destination
+// prefix, then written again and, when copies are server-side, copied onto a
+// second probe object; both must be refused. Providers that ignore the header
+// would overwrite silently, so the migration does not start on them.
+func (m *EnhancedMigrator) probeWriteOnce(ctx context.Context, input MigrateInput, destClient *s3.Client) error {
+	client := destClient
+	if client == nil {
+		client = m.connPool.GetClient()
+	}
+	ctx = pool.WriteOnce(ctx)
+	key := destKeyFor(fmt.Sprintf(".s3migration-write-once-probe-%d", time.Now().UnixNano()), input.DestPrefix)
+	put := func(key string) error {
+		_, err := client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(input.DestBucket),
+			Key:    aws.String(key),
+			Body:   bytes.NewReader(nil),
+		})
+		return err
+	}
+
+	// A copy onto its own source is refused for other reasons, so copies get a second object
+	for _, probe := range []string{key, key + "-copy"} {
+		if err := put(probe); err != nil {
+			return fmt.Errorf("never_overwrite: failed to write probe object %s: %w", probe, err)
+		}
+		defer func(probe string) {
+			if _, err := client.DeleteObject(context.WithoutCancel(ctx), &s3.DeleteObjectInput{Bucket: aws.String(input.DestBucket), Key: aws.String(probe)}); err != nil {
+				m.logger.Errorf("Failed to delete never_overwrite probe object %s: %v", probe, err)
+			}
+		}(probe)
+	}
+
+	if err := put(key); !pool.IsPreconditionFailed(err) {
+		return writeOnceUnsupported("PutObject", err)
+	}
+	if destClient == nil {
+		_, err := client.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:     aws.String(input.DestBucket),
+			CopySource: aws.String(input.DestBucket + "/" + url.PathEscape(key)),
+			Key:        aws.String(key + "-copy"),
+		})
+		if !pool.IsPreconditionFailed(err) {
+			return writeOnceUnsupported("CopyObject", err)
+		}
+	}
+	fmt.Printf("Never overwrite (%s): destination refuses writes onto existing keys\n", input.NeverOverwrite)
+	return nil
+}
+
+// writeOnceUnsupported describes a probe write that was not refused
+func writeOnceUnsupported(operation string, err error) error {
+	if err == nil {
+		return fmt.Errorf("never_overwrite: the destination replaced an existing object on a conditional %s; it does not support conditional writes (If-None-Match), so existing objects cannot be protected", operation)
+	}
+	return fmt.Errorf("never_overwrite: conditional %s probe failed: %w", operation, err)
+}
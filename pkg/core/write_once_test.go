@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"s3migration/pkg/fakes3"
+	"s3migration/pkg/pool"
+)
+
+func TestMigrateNeverOverwrite(t *testing.T) {
+	tests := []struct {
+		mode            NeverOverwriteMode
+		skipped, failed int64
+	}{
+		{NeverOverwriteSkip, 1, 0},
+		{NeverOverwriteFail, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			endpoint := fakes3.New("source", "dest")
+			defer endpoint.Close()
+			endpoint.Put("source", "new.txt", []byte("new"))
+			endpoint.Put("source", "kept.txt", []byte("source version"))
+			endpoint.Put("dest", "kept.txt", []byte("destination version"))
+
+			migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+				ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := migrator.Migrate(context.Background(), MigrateInput{
+				SourceBucket:   "source",
+				DestBucket:     "dest",
+				MigrationMode:  ModeFullRewrite,
+				NeverOverwrite: tt.mode,
+				Timeout:        time.Minute,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Copied != 1 || result.Skipped != tt.skipped || result.Failed != tt.failed {
+				t.Errorf("copied %d, skipped %d, failed %d; want 1, %d, %d", result.Copied, result.Skipped, result.Failed, tt.skipped, tt.failed)
+			}
+			if result.OverwriteRefused != 1 || !reflect.DeepEqual(result.ExistingDestKeys, []string{"kept.txt"}) {
+				t.Errorf("refused %d overwrites of %q", result.OverwriteRefused, result.ExistingDestKeys)
+			}
+			if data := string(endpoint.Get("dest", "kept.txt").Data); data != "destination version" {
+				t.Errorf("existing object was overwritten with %q", data)
+			}
+			// The probe objects are gone
+			if keys := endpoint.Keys("dest"); !reflect.DeepEqual(keys, []string{"kept.txt", "new.txt"}) {
+				t.Errorf("dest keys = %q", keys)
+			}
+		})
+	}
+}
+
+func TestMigrateNeverOverwriteUnsupported(t *testing.T) {
+	endpoint := fakes3.New("source", "dest")
+	defer endpoint.Close()
+	endpoint.IgnoreIfNoneMatch = true
+	endpoint.Put("source", "kept.txt", []byte("source version"))
+	endpoint.Put("dest", "kept.txt", []byte("destination version"))
+
+	migrator, err := NewEnhancedMigrator(context.Background(), EnhancedMigratorConfig{
+		ConnectionPool: pool.NewStaticConnectionPool(endpoint.Client()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Migrate(context.Background(), MigrateInput{
+		SourceBucket:   "source",
+		DestBucket:     "dest",
+		MigrationMode:  ModeFullRewrite,
+		NeverOverwrite: NeverOverwriteSkip,
+		Timeout:        time.Minute,
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not support conditional writes") {
+		t.Fatalf("err = %v, want the destination refused", err)
+	}
+	if data := string(endpoint.Get("dest", "kept.txt").Data); data != "destination version" {
+		t.Errorf("existing object was overwritten with %q", data)
+	}
+	if keys := endpoint.Keys("dest"); !reflect.DeepEqual(keys, []string{"kept.txt"}) {
+		t.Errorf("dest keys = %q", keys)
+	}
+}
@@ -0,0 +1,615 @@
+// Package fakes3 is an in-memory S3 endpoint for tests. It speaks enough of the
+// S3 REST API (buckets, objects, copies, conditional writes, listings, starting
+// and aborting multipart uploads, canned object ACLs, object tags and bucket
+// policy, CORS and website configuration)
+// for the migrator and the API handlers to run against
+// it instead of a live provider.
+package fakes3
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Object is a stored object
+type Object struct {
+	Data         []byte
+	ContentType  string
+	Metadata     map[string]string // x-amz-meta-* headers, without the prefix
+	Redirect     string            // x-amz-website-redirect-location
+	ACL          string            // Canned ACL (x-amz-acl); empty is private
+	Tags         map[string]string // Object tags (?tagging)
+	ETag         string
+	LastModified time.Time
+}
+
+// Server is an in-memory S3 endpoint served over HTTP
+type Server struct {
+	URL string
+	// BeforeWrite, when set, is called before an object upload or copy is
+	// applied, outside the server lock; tests block in it to hold a transfer in flight
+	BeforeWrite func(bucket, key string)
+	// IgnoreIfNoneMatch makes uploads and copies ignore If-None-Match: *, like
+	// providers without conditional writes
+	IgnoreIfNoneMatch bool
+	// Deny, when set, answers AccessDenied to the requests it returns true for
+	// (key is empty for bucket requests); tests use it to model restricted credentials
+	Deny func(method, bucket, key string) bool
+
+	mu      sync.Mutex
+	server  *httptest.Server
+	buckets map[string]map[string]*Object
+	configs map[string]map[string][]byte // Bucket subresources (policy, cors, website) as sent
+	uploads map[string][]string          // Keys of multipart uploads started and never completed, by bucket
+}
+
+// bucketConfigErrors are the error codes of unset bucket subresources
+var bucketConfigErrors = map[string]string{
+	"policy":     "NoSuchBucketPolicy",
+	"cors":       "NoSuchCORSConfiguration",
+	"website":    "NoSuchWebsiteConfiguration",
+	"lifecycle":  "NoSuchLifecycleConfiguration",
+	"encryption": "ServerSideEncryptionConfigurationNotFoundError",
+}
+
+// New starts a fake endpoint with the given (empty) buckets. Close it when done.
+func New(buckets ...string) *Server {
+	s := &Server{buckets: make(map[string]map[string]*Object), configs: make(map[string]map[string][]byte), uploads: make(map[string][]string)}
+	for _, bucket := range buckets {
+		s.buckets[bucket] = make(map[string]*Object)
+	}
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL
+	return s
+}
+
+// Close shuts the endpoint down
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns an S3 client for the endpoint that does not retry
+func (s *Server) Client() *s3.Client {
+	return s3.New(s3.Options{
+		Region:           "us-east-1",
+		BaseEndpoint:     aws.String(s.URL),
+		UsePathStyle:     true,
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+	})
+}
+
+// Put stores an object, creating the bucket if needed
+func (s *Server) Put(bucket, key string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = make(map[string]*Object)
+	}
+	s.buckets[bucket][key] = newObject(data, "", nil)
+}
+
+// Get returns a stored object, or nil
+func (s *Server) Get(bucket, key string) *Object {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buckets[bucket][key]
+}
+
+// SetBucketConfig stores a bucket subresource ("policy", "cors", "website", "lifecycle" or "encryption") body,
+// a JSON policy or an XML configuration
+func (s *Server) SetBucketConfig(bucket, subresource string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.configs[bucket] == nil {
+		s.configs[bucket] = make(map[string][]byte)
+	}
+	s.configs[bucket][subresource] = body
+}
+
+// BucketConfig returns a bucket subresource body, or nil when it is not set
+func (s *Server) BucketConfig(bucket, subresource string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.configs[bucket][subresource]
+}
+
+// StartUpload records a multipart upload of key that is never completed, as
+// listed by ListMultipartUploads
+func (s *Server) StartUpload(bucket, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.uploads[bucket] = append(s.uploads[bucket], key)
+}
+
+// Keys returns the sorted keys of a bucket
+func (s *Server) Keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for key := range s.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func newObject(data []byte, contentType string, metadata map[string]string) *Object {
+	sum := md5.Sum(data)
+	if contentType == "" {
+		contentType = "binary/octet-stream"
+	}
+	return &Object{
+		Data:         data,
+		ContentType:  contentType,
+		Metadata:     metadata,
+		ETag:         `"` + hex.EncodeToString(sum[:]) + `"`,
+		LastModified: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// ServeHTTP implements the path-style S3 REST API
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+	if s.BeforeWrite != nil && r.Method == http.MethodPut && key != "" {
+		s.BeforeWrite(bucket, key)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Deny != nil && s.Deny(r.Method, bucket, key) {
+		writeError(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+	if bucket == "" {
+		if r.Method == http.MethodGet {
+			s.listBuckets(w)
+			return
+		}
+		writeError(w, http.StatusNotImplemented, "NotImplemented")
+		return
+	}
+	objects, exists := s.buckets[bucket]
+
+	if key == "" {
+		for subresource := range bucketConfigErrors {
+			if exists && query.Has(subresource) {
+				s.bucketConfig(w, r, bucket, subresource)
+				return
+			}
+		}
+		switch {
+		case r.Method == http.MethodPut:
+			if exists {
+				writeError(w, http.StatusConflict, "BucketAlreadyOwnedByYou")
+				return
+			}
+			s.buckets[bucket] = make(map[string]*Object)
+		case !exists:
+			writeError(w, http.StatusNotFound, "NoSuchBucket")
+		case r.Method == http.MethodHead:
+		case r.Method == http.MethodGet && query.Has("location"):
+			writeXML(w, struct {
+				XMLName xml.Name `xml:"LocationConstraint"`
+			}{})
+		case r.Method == http.MethodGet && query.Has("uploads"):
+			s.listUploads(w, bucket, query.Get("prefix"))
+		case r.Method == http.MethodPost && query.Has("delete"):
+			s.deleteObjects(w, r, bucket, objects)
+		case r.Method == http.MethodGet:
+			listObjects(w, bucket, objects, query)
+		default:
+			writeError(w, http.StatusNotImplemented, "NotImplemented")
+		}
+		return
+	}
+	if !exists {
+		writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	if query.Has("acl") {
+		objectACL(w, r, objects[key])
+		return
+	}
+	if query.Has("tagging") {
+		objectTagging(w, r, objects[key])
+		return
+	}
+	if r.Method == http.MethodPost && query.Has("uploads") {
+		s.uploads[bucket] = append(s.uploads[bucket], key)
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Bucket   string   `xml:"Bucket"`
+			Key      string   `xml:"Key"`
+			UploadID string   `xml:"UploadId"`
+		}{Bucket: bucket, Key: key, UploadID: fmt.Sprintf("upload-%d", len(s.uploads[bucket]))})
+		return
+	}
+	if r.Method == http.MethodDelete && query.Has("uploadId") {
+		s.abortUpload(w, bucket, key, query.Get("uploadId"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		if _, ok := objects[key]; ok && r.Header.Get("If-None-Match") == "*" && !s.IgnoreIfNoneMatch {
+			writeError(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		if source := r.Header.Get("x-amz-copy-source"); source != "" {
+			s.copyObject(w, r, objects, key, source)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		object := newObject(data, r.Header.Get("Content-Type"), requestMetadata(r.Header))
+		object.Redirect = r.Header.Get("x-amz-website-redirect-location")
+		object.ACL = r.Header.Get("x-amz-acl")
+		objects[key] = object
+		w.Header().Set("ETag", object.ETag)
+	case http.MethodGet, http.MethodHead:
+		object, ok := objects[key]
+		if !ok {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", object.ETag)
+		w.Header().Set("Content-Type", object.ContentType)
+		for name, value := range object.Metadata {
+			w.Header().Set("x-amz-meta-"+name, value)
+		}
+		if object.Redirect != "" {
+			w.Header().Set("x-amz-website-redirect-location", object.Redirect)
+		}
+		http.ServeContent(w, r, "", object.LastModified, bytes.NewReader(object.Data))
+	case http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// bucketConfig gets, puts or deletes a bucket subresource
+func (s *Server) bucketConfig(w http.ResponseWriter, r *http.Request, bucket, subresource string) {
+	switch r.Method {
+	case http.MethodGet:
+		body, ok := s.configs[bucket][subresource]
+		if !ok {
+			writeError(w, http.StatusNotFound, bucketConfigErrors[subresource])
+			return
+		}
+		w.Write(body)
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		if s.configs[bucket] == nil {
+			s.configs[bucket] = make(map[string][]byte)
+		}
+		s.configs[bucket][subresource] = body
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		delete(s.configs[bucket], subresource)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// fakeOwner is the canonical user ID owning every fake object
+const fakeOwner = "fakes3-owner"
+
+// aclGroups are the group grantees of the canned ACLs, with their permissions
+var aclGroups = map[string][][2]string{
+	"public-read":        {{"http://acs.amazonaws.com/groups/global/AllUsers", "READ"}},
+	"public-read-write":  {{"http://acs.amazonaws.com/groups/global/AllUsers", "READ"}, {"http://acs.amazonaws.com/groups/global/AllUsers", "WRITE"}},
+	"authenticated-read": {{"http://acs.amazonaws.com/groups/global/AuthenticatedUsers", "READ"}},
+}
+
+// objectACL gets or sets the canned ACL of an object
+func objectACL(w http.ResponseWriter, r *http.Request, object *Object) {
+	if object == nil {
+		writeError(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	if r.Method == http.MethodPut {
+		object.ACL = r.Header.Get("x-amz-acl")
+		return
+	}
+
+	var grants strings.Builder
+	fmt.Fprintf(&grants, `<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser"><ID>%s</ID></Grantee><Permission>FULL_CONTROL</Permission></Grant>`, fakeOwner)
+	for _, grant := range aclGroups[object.ACL] {
+		fmt.Fprintf(&grants, `<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>%s</URI></Grantee><Permission>%s</Permission></Grant>`, grant[0], grant[1])
+	}
+	fmt.Fprintf(w, `<AccessControlPolicy><Owner><ID>%s</ID></Owner><AccessControlList>%s</AccessControlList></AccessControlPolicy>`, fakeOwner, grants.String())
+}
+
+// tagSet is the body of GetObjectTagging and PutObjectTagging
+type tagSet struct {
+	XMLName xml.Name `xml:"Tagging"`
+	Tags    []tag    `xml:"TagSet>Tag"`
+}
+
+type tag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+// objectTagging gets or replaces the tags of an object
+func objectTagging(w http.ResponseWriter, r *http.Request, object *Object) {
+	if object == nil {
+		writeError(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	var tags tagSet
+	if r.Method == http.MethodPut {
+		if err := xml.NewDecoder(r.Body).Decode(&tags); err != nil {
+			writeError(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		object.Tags = make(map[string]string, len(tags.Tags))
+		for _, t := range tags.Tags {
+			object.Tags[t.Key] = t.Value
+		}
+		return
+	}
+	for key, value := range object.Tags {
+		tags.Tags = append(tags.Tags, tag{Key: key, Value: value})
+	}
+	sort.Slice(tags.Tags, func(i, j int) bool { return tags.Tags[i].Key < tags.Tags[j].Key })
+	writeXML(w, tags)
+}
+
+// copyObject copies like S3 does: metadata is kept, but the website redirect
+// location only comes from the request
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, objects map[string]*Object, key, source string) {
+	source, _ = url.PathUnescape(strings.TrimPrefix(source, "/"))
+	sourceBucket, sourceKey, _ := strings.Cut(source, "/")
+	original, ok := s.buckets[sourceBucket][sourceKey]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	object := newObject(original.Data, original.ContentType, original.Metadata)
+	object.Redirect = r.Header.Get("x-amz-website-redirect-location")
+	object.ACL = r.Header.Get("x-amz-acl")
+	if r.Header.Get("x-amz-tagging-directive") != "REPLACE" {
+		object.Tags = maps.Clone(original.Tags) // Tags are copied along by default
+	}
+	objects[key] = object
+	writeXML(w, struct {
+		XMLName      xml.Name `xml:"CopyObjectResult"`
+		ETag         string   `xml:"ETag"`
+		LastModified string   `xml:"LastModified"`
+	}{ETag: object.ETag, LastModified: object.LastModified.Format(time.RFC3339)})
+}
+
+func (s *Server) listBuckets(w http.ResponseWriter) {
+	type bucketEntry struct {
+		Name         string `xml:"Name"`
+		CreationDate string `xml:"CreationDate"`
+	}
+	names := make([]string, 0, len(s.buckets))
+	for name := range s.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	entries := make([]bucketEntry, len(names))
+	for i, name := range names {
+		entries[i] = bucketEntry{Name: name, CreationDate: time.Now().UTC().Format(time.RFC3339)}
+	}
+	writeXML(w, struct {
+		XMLName xml.Name      `xml:"ListAllMyBucketsResult"`
+		Buckets []bucketEntry `xml:"Buckets>Bucket"`
+	}{Buckets: entries})
+}
+
+// abortUpload answers AbortMultipartUpload. An upload ID is the position of
+// the upload in s.uploads, whose key is cleared so later IDs stay valid.
+func (s *Server) abortUpload(w http.ResponseWriter, bucket, key, uploadID string) {
+	n, err := strconv.Atoi(strings.TrimPrefix(uploadID, "upload-"))
+	if err != nil || n < 1 || n > len(s.uploads[bucket]) || s.uploads[bucket][n-1] != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	s.uploads[bucket][n-1] = ""
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteObjects answers DeleteObjects. Keys Deny refuses for DELETE are reported as errors.
+func (s *Server) deleteObjects(w http.ResponseWriter, r *http.Request, bucket string, objects map[string]*Object) {
+	var request struct {
+		Objects []struct {
+			Key string `xml:"Key"`
+		} `xml:"Object"`
+		Quiet bool `xml:"Quiet"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+	type deleted struct {
+		Key string `xml:"Key"`
+	}
+	type deleteError struct {
+		Key     string `xml:"Key"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	var result struct {
+		XMLName xml.Name      `xml:"DeleteResult"`
+		Deleted []deleted     `xml:"Deleted"`
+		Errors  []deleteError `xml:"Error"`
+	}
+	for _, object := range request.Objects {
+		if s.Deny != nil && s.Deny(http.MethodDelete, bucket, object.Key) {
+			result.Errors = append(result.Errors, deleteError{Key: object.Key, Code: "AccessDenied", Message: "Access Denied"})
+			continue
+		}
+		delete(objects, object.Key)
+		if !request.Quiet {
+			result.Deleted = append(result.Deleted, deleted{Key: object.Key})
+		}
+	}
+	writeXML(w, result)
+}
+
+// listUploads answers ListMultipartUploads, in one page
+func (s *Server) listUploads(w http.ResponseWriter, bucket, prefix string) {
+	type uploadEntry struct {
+		Key      string `xml:"Key"`
+		UploadID string `xml:"UploadId"`
+	}
+	var uploads []uploadEntry
+	for i, key := range s.uploads[bucket] {
+		if key != "" && strings.HasPrefix(key, prefix) {
+			uploads = append(uploads, uploadEntry{Key: key, UploadID: fmt.Sprintf("upload-%d", i+1)})
+		}
+	}
+	writeXML(w, struct {
+		XMLName     xml.Name      `xml:"ListMultipartUploadsResult"`
+		Bucket      string        `xml:"Bucket"`
+		IsTruncated bool          `xml:"IsTruncated"`
+		Uploads     []uploadEntry `xml:"Upload"`
+	}{Bucket: bucket, Uploads: uploads})
+}
+
+type listEntry struct {
+	Key          string `xml:"Key"`
+	Size         int64  `xml:"Size"`
+	ETag         string `xml:"ETag"`
+	LastModified string `xml:"LastModified"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type commonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// listObjects answers ListObjects (marker) and ListObjectsV2 (list-type=2, continuation token)
+func listObjects(w http.ResponseWriter, bucket string, objects map[string]*Object, query url.Values) {
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	maxKeys := 1000
+	if n, err := strconv.Atoi(query.Get("max-keys")); err == nil && n > 0 && n < maxKeys {
+		maxKeys = n
+	}
+	v2 := query.Get("list-type") == "2"
+	after := query.Get("marker")
+	if v2 {
+		after = query.Get("start-after")
+		if token := query.Get("continuation-token"); token != "" {
+			after = token
+		}
+	}
+
+	keys := make([]string, 0, len(objects))
+	for key := range objects {
+		if strings.HasPrefix(key, prefix) && key > after {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var contents []listEntry
+	var prefixes []commonPrefix
+	seenPrefixes := make(map[string]bool)
+	truncated, last := false, ""
+	for _, key := range keys {
+		if len(contents)+len(prefixes) == maxKeys {
+			truncated = true
+			break
+		}
+		last = key
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				p := key[:len(prefix)+i+len(delimiter)]
+				if !seenPrefixes[p] {
+					seenPrefixes[p] = true
+					prefixes = append(prefixes, commonPrefix{Prefix: p})
+				}
+				continue
+			}
+		}
+		object := objects[key]
+		contents = append(contents, listEntry{
+			Key:          key,
+			Size:         int64(len(object.Data)),
+			ETag:         object.ETag,
+			LastModified: object.LastModified.Format(time.RFC3339),
+			StorageClass: "STANDARD",
+		})
+	}
+
+	result := struct {
+		XMLName               xml.Name       `xml:"ListBucketResult"`
+		Name                  string         `xml:"Name"`
+		Prefix                string         `xml:"Prefix"`
+		KeyCount              int            `xml:"KeyCount,omitempty"`
+		MaxKeys               int            `xml:"MaxKeys"`
+		IsTruncated           bool           `xml:"IsTruncated"`
+		NextMarker            string         `xml:"NextMarker,omitempty"`
+		NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+		Contents              []listEntry    `xml:"Contents"`
+		CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+	}{Name: bucket, Prefix: prefix, MaxKeys: maxKeys, IsTruncated: truncated, Contents: contents, CommonPrefixes: prefixes}
+	if v2 {
+		result.KeyCount = len(contents) + len(prefixes)
+	}
+	if truncated {
+		if v2 {
+			result.NextContinuationToken = last
+		} else {
+			result.NextMarker = last
+		}
+	}
+	writeXML(w, result)
+}
+
+// requestMetadata collects the x-amz-meta-* headers of a request
+func requestMetadata(header http.Header) map[string]string {
+	metadata := make(map[string]string)
+	for name, values := range header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-meta-") && len(values) > 0 {
+			metadata[strings.TrimPrefix(lower, "x-amz-meta-")] = values[0]
+		}
+	}
+	return metadata
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "%s<Error><Code>%s</Code><Message>%s</Message></Error>", xml.Header, code, http.StatusText(status))
+}
@@ -0,0 +1,122 @@
+// Package i18n translates user-facing API messages for the language a client
+// asks for in Accept-Language. Messages are built in English everywhere (and
+// logged that way); the catalog maps their English format strings to
+// translations, so a rendered message is recognised and re-rendered with the
+// same arguments at the edge of the API.
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// English is the language messages are written in; it needs no catalog
+const English = "en"
+
+// catalogs maps a language to its translations, keyed by English format string.
+// A translation uses the same verbs in the same order as its English format.
+var catalogs = map[string]map[string]string{
+	"vi": vietnamese,
+}
+
+// verbPattern matches a fmt verb such as %s, %q, %d or %.1f
+var verbPattern = regexp.MustCompile(`%[-+# 0]*\d*(?:\.\d+)?[a-zA-Z]`)
+
+// pattern recognises a message rendered from one catalog format
+type pattern struct {
+	match       *regexp.Regexp
+	translation string
+	literal     int // Characters outside verbs: longer patterns are more specific and tried first
+}
+
+// patterns are the catalog formats with verbs, per language, compiled on load
+var patterns = compilePatterns()
+
+func compilePatterns() map[string][]pattern {
+	compiled := make(map[string][]pattern, len(catalogs))
+	for lang, catalog := range catalogs {
+		var list []pattern
+		for format, translation := range catalog {
+			verbs := verbPattern.FindAllStringIndex(format, -1)
+			if len(verbs) == 0 || len(verbs) == 1 && verbs[0][0] == 0 && verbs[0][1] == len(format) {
+				continue
+			}
+			var expr strings.Builder
+			expr.WriteString("^")
+			last := 0
+			for _, verb := range verbs {
+				expr.WriteString(regexp.QuoteMeta(format[last:verb[0]]))
+				expr.WriteString("(.*?)")
+				last = verb[1]
+			}
+			expr.WriteString(regexp.QuoteMeta(format[last:]))
+			expr.WriteString("$")
+			list = append(list, pattern{
+				match:       regexp.MustCompile(expr.String()),
+				translation: translation,
+				literal:     len(verbPattern.ReplaceAllString(format, "")),
+			})
+		}
+		sort.SliceStable(list, func(i, j int) bool { return list[i].literal > list[j].literal })
+		compiled[lang] = list
+	}
+	return compiled
+}
+
+// Supported reports whether lang has a catalog (English always does)
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok || lang == English
+}
+
+// FromAcceptLanguage picks the supported language a client prefers most from
+// an Accept-Language header, e.g. "vi-VN,vi;q=0.9,en;q=0.8"; English when none is
+func FromAcceptLanguage(header string) string {
+	best, bestQ := English, 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if Supported(base) && q > bestQ {
+			best, bestQ = base, q
+		}
+	}
+	return best
+}
+
+// Translate renders an English message in lang. Arguments recognised in the
+// message are translated too, so wrapped errors ("preflight failed: %v") are
+// translated through. Messages missing from the catalog are returned unchanged.
+func Translate(lang, message string) string {
+	catalog, ok := catalogs[lang]
+	if !ok || message == "" {
+		return message
+	}
+	if translation, ok := catalog[message]; ok {
+		return translation
+	}
+	for _, p := range patterns[lang] {
+		args := p.match.FindStringSubmatch(message)
+		if args == nil {
+			continue
+		}
+		i := 0
+		return verbPattern.ReplaceAllStringFunc(p.translation, func(string) string {
+			i++
+			if i >= len(args) {
+				return ""
+			}
+			return Translate(lang, args[i])
+		})
+	}
+	return message
+}
@@ -0,0 +1,64 @@
+package i18n
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFromAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: "en"},
+		{header: "vi", want: "vi"},
+		{header: "vi-VN,vi;q=0.9,en;q=0.8", want: "vi"},
+		{header: "en-US,en;q=0.9,vi;q=0.8", want: "en"},
+		{header: "fr-FR,vi;q=0.5", want: "vi"},
+		{header: "de, fr;q=0.7", want: "en"},
+		{header: "vi;q=bad", want: "en"},
+	}
+	for _, tt := range tests {
+		if got := FromAcceptLanguage(tt.header); got != tt.want {
+			t.Errorf("FromAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		lang    string
+		message string
+		want    string
+	}{
+		{lang: "en", message: "dest_bucket is required", want: "dest_bucket is required"},
+		{lang: "vi", message: "dest_bucket is required", want: "dest_bucket là bắt buộc"},
+		{lang: "vi", message: fmt.Sprintf("Found %d objects totaling %.1f MB", 12, 3.5), want: "Tìm thấy 12 đối tượng, tổng cộng 3.5 MB"},
+		{lang: "vi", message: fmt.Sprintf("bucket name %q may only contain letters, digits, '.', '_' and '-'", "a b"), want: `tên bucket "a b" chỉ được chứa chữ cái, chữ số, '.', '_' và '-'`},
+		{
+			lang:    "vi",
+			message: "destination preflight failed: no write access to bucket 'dest': AccessDenied",
+			want:    "kiểm tra trước bucket đích thất bại: không có quyền ghi vào bucket 'dest': AccessDenied",
+		},
+		{lang: "vi", message: "ERROR: Could not list destination for diff: timeout", want: "LỖI: Không liệt kê được đích để so sánh: timeout"},
+		{lang: "vi", message: "something new", want: "something new"},
+		{lang: "fr", message: "dest_bucket is required", want: "dest_bucket is required"},
+	}
+	for _, tt := range tests {
+		if got := Translate(tt.lang, tt.message); got != tt.want {
+			t.Errorf("Translate(%q, %q) = %q, want %q", tt.lang, tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestCatalogsKeepVerbs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for format, translation := range catalog {
+			want := verbPattern.FindAllString(format, -1)
+			got := verbPattern.FindAllString(translation, -1)
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("%s: %q uses verbs %v, want %v", lang, translation, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package i18n
+
+// vietnamese translates validation errors, preflight failures and dry-run summaries
+var vietnamese = map[string]string{
+	// Request body
+	"expected %s, got %s":             "cần kiểu %s, nhận được %s",
+	"malformed JSON at offset %d: %v": "JSON không hợp lệ tại vị trí %d: %v",
+	"request body exceeds %d bytes":   "nội dung yêu cầu vượt quá %d byte",
+	"request body is empty":           "nội dung yêu cầu trống",
+
+	// Buckets and prefixes
+	"bucket name %q may only contain letters, digits, '.', '_' and '-'":                                                     "tên bucket %q chỉ được chứa chữ cái, chữ số, '.', '_' và '-'",
+	"bucket name %q may only contain lowercase letters, digits, '.' and '-', and must start and end with a letter or digit": "tên bucket %q chỉ được chứa chữ thường, chữ số, '.' và '-', và phải bắt đầu và kết thúc bằng chữ cái hoặc chữ số",
+	"bucket name must be 3-63 characters long":                                                                              "tên bucket phải dài từ 3 đến 63 ký tự",
+	"bucket name must not be formatted as an IP address":                                                                    "tên bucket không được có dạng địa chỉ IP",
+	"bucket name must not contain consecutive dots":                                                                         "tên bucket không được chứa hai dấu chấm liên tiếp",
+	"prefix must be at most %d bytes":                                                                                       "prefix không được dài quá %d byte",
+	"prefix must not contain '..' segments":                                                                                 "prefix không được chứa đoạn '..'",
+	"dest_bucket is required":                                                                                               "dest_bucket là bắt buộc",
+	"dest_bucket is required when source_bucket is set":                                                                     "dest_bucket là bắt buộc khi đã đặt source_bucket",
+	"dest_bucket must be empty when source_bucket is empty (all buckets)":                                                   "dest_bucket phải để trống khi source_bucket để trống (tất cả bucket)",
+	"source_prefix requires source_bucket":                                                                                  "source_prefix cần có source_bucket",
+	"destination is the same bucket as the source; set dest_prefix or choose another bucket":                                "bucket đích trùng với bucket nguồn; hãy đặt dest_prefix hoặc chọn bucket khác",
+
+	// Credentials
+	"access_key and secret_key (or secret_ref) are required unless dest_profile is set": "access_key và secret_key (hoặc secret_ref) là bắt buộc trừ khi đã đặt dest_profile",
+	"access_key is required when secret_key is set":                                     "access_key là bắt buộc khi đã đặt secret_key",
+	"secret_key is required when access_key is set":                                     "secret_key là bắt buộc khi đã đặt access_key",
+	"session_token requires access_key and secret_key":                                  "session_token cần có access_key và secret_key",
+	"secret_ref cannot be combined with access_key/secret_key":                          "không thể dùng secret_ref cùng với access_key/secret_key",
+	"source_credentials is required":                                                    "source_credentials là bắt buộc",
+	"dest_credentials (or dest_profile) is required":                                    "dest_credentials (hoặc dest_profile) là bắt buộc",
+	"dest_profile cannot be combined with dest_credentials keys or secret_ref":          "không thể dùng dest_profile cùng với khóa trong dest_credentials hoặc secret_ref",
+	"credentials is deprecated; set only source_credentials":                            "credentials đã lỗi thời; chỉ đặt source_credentials",
+	"access_token or refresh_token is required":                                         "access_token hoặc refresh_token là bắt buộc",
+	"client_id and client_secret are required to use refresh_token":                     "client_id và client_secret là bắt buộc khi dùng refresh_token",
+	"endpoint_url must be an http(s) URL, e.g. https://s3.example.com":                  "endpoint_url phải là URL http(s), ví dụ https://s3.example.com",
+
+	// Options
+	"migration_mode must be full_rewrite or incremental":                "migration_mode phải là full_rewrite hoặc incremental",
+	"log_level must be error, info or debug":                            "log_level phải là error, info hoặc debug",
+	"timeout must be zero (default) or a positive number of seconds":    "timeout phải bằng 0 (mặc định) hoặc là số giây dương",
+	"multipart values must not be negative":                             "các giá trị multipart không được âm",
+	"debug_sample_rate must not be negative":                            "debug_sample_rate không được âm",
+	"dry_run_diff requires dry_run":                                     "dry_run_diff cần có dry_run",
+	"files_from requires source_bucket":                                 "files_from cần có source_bucket",
+	"reconcile requires source_bucket":                                  "reconcile cần có source_bucket",
+	"webhook requires source_bucket":                                    "webhook cần có source_bucket",
+	"webhook url must be an http(s) URL":                                "url của webhook phải là URL http(s)",
+	"cutover requires source_bucket":                                    "cutover cần có source_bucket",
+	"cutover cannot be a dry run":                                       "cutover không thể là lần chạy thử",
+	"cutover max_delta_passes and delta_threshold must not be negative": "max_delta_passes và delta_threshold của cutover không được âm",
+	"unknown folder_markers %q (expected copy, recreate or skip)":       "folder_markers %q không hợp lệ (cần copy, recreate hoặc skip)",
+
+	// Preflight
+	"destination preflight failed: %v":        "kiểm tra trước bucket đích thất bại: %v",
+	"failed to create destination client: %v": "không tạo được client cho đích: %v",
+	"cannot access bucket '%s': %v":           "không truy cập được bucket '%s': %v",
+	"no write access to bucket '%s': %v":      "không có quyền ghi vào bucket '%s': %v",
+
+	// Dry-run summaries
+	"Source bucket connection verified":                                                "Đã kiểm tra kết nối tới bucket nguồn",
+	"No objects found in bucket":                                                       "Không tìm thấy đối tượng nào trong bucket",
+	"Destination bucket would be created if needed":                                    "Bucket đích sẽ được tạo nếu cần",
+	"Destination bucket created/verified":                                              "Đã tạo/kiểm tra bucket đích",
+	"File permissions verified":                                                        "Đã kiểm tra quyền truy cập tệp",
+	"Migration path validated":                                                         "Đã xác nhận đường di chuyển",
+	"Migration path validated (empty bucket)":                                          "Đã xác nhận đường di chuyển (bucket trống)",
+	"Migration completed":                                                              "Di chuyển đã hoàn tất",
+	"Source and destination match perfectly":                                           "Nguồn và đích khớp hoàn toàn",
+	"Found %d objects totaling %.1f MB":                                                "Tìm thấy %d đối tượng, tổng cộng %.1f MB",
+	"Destination listed: %d objects":                                                   "Đã liệt kê đích: %d đối tượng",
+	"Would create %d, overwrite %d, skip %d objects (%s mode)":                         "Sẽ tạo %d, ghi đè %d, bỏ qua %d đối tượng (chế độ %s)",
+	"Deduplication would leave out %d duplicate objects (%.1f MB) and list them in %s": "Khử trùng lặp sẽ bỏ qua %d đối tượng trùng lặp (%.1f MB) và liệt kê chúng trong %s",
+	"Would leave out %d objects the destination's lifecycle rules expire on arrival":   "Sẽ bỏ qua %d đối tượng mà quy tắc vòng đời của đích cho hết hạn ngay khi ghi",
+	"Would recreate %d folder markers":                                                 "Sẽ tạo lại %d đánh dấu thư mục",
+	"Recreated %d of %d folder markers":                                                "Đã tạo lại %d trên %d đánh dấu thư mục",
+	"Could not list destination for diff: %v":                                          "Không liệt kê được đích để so sánh: %v",
+	"ERROR: %s": "LỖI: %s",
+}
@@ -0,0 +1,106 @@
+package integrity
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Comparison is how a copied object is checked against its source
+type Comparison string
+
+const (
+	CompareETag        Comparison = "etag"         // Source and destination ETags are equal
+	CompareContentHash Comparison = "content_hash" // Each single-part ETag matches the hash of the streamed bytes
+	CompareSize        Comparison = "size"         // Neither ETag can be checked; only the size is trusted
+)
+
+// Providers lists the provider types of the compatibility matrix
+var Providers = []ProviderType{
+	ProviderAWS, ProviderMinIO, ProviderWasabi, ProviderBackblazeB2,
+	ProviderCloudflareR2, ProviderDOSpaces, ProviderGeneric,
+}
+
+// Compatibility is what verification can rely on when copying from one provider to another
+type Compatibility struct {
+	Source ProviderType `json:"source"`
+	Dest   ProviderType `json:"dest"`
+	// Single-part ETags are the same hash on both sides, so a copy keeps them
+	ETagsSurviveCopy bool `json:"etags_survive_copy"`
+	// Both sides derive multipart ETags from the part MD5s, so they match when the parts do
+	MultipartETagsComparable bool `json:"multipart_etags_comparable"`
+	// Streamed hash a single-part destination ETag holds; empty when the ETag is opaque
+	TrustedChecksum string `json:"trusted_checksum,omitempty"`
+}
+
+// etagHash is the hash a provider puts in the ETag of a single-part object
+func etagHash(provider ProviderType) string {
+	switch provider {
+	case ProviderAWS, ProviderMinIO, ProviderWasabi, ProviderCloudflareR2, ProviderDOSpaces:
+		return "md5"
+	case ProviderBackblazeB2:
+		return "sha1"
+	default:
+		return ""
+	}
+}
+
+// multipartFromPartMD5s reports whether a provider's multipart ETag is the MD5 of
+// the part MD5s followed by the part count. R2 and B2 use their own schemes.
+func multipartFromPartMD5s(provider ProviderType) bool {
+	switch provider {
+	case ProviderAWS, ProviderMinIO, ProviderWasabi, ProviderDOSpaces:
+		return true
+	default:
+		return false
+	}
+}
+
+// CompatibilityOf returns the matrix entry of a provider pair
+func CompatibilityOf(source, dest ProviderType) Compatibility {
+	sourceHash, destHash := etagHash(source), etagHash(dest)
+	return Compatibility{
+		Source:                   source,
+		Dest:                     dest,
+		ETagsSurviveCopy:         sourceHash != "" && sourceHash == destHash,
+		MultipartETagsComparable: multipartFromPartMD5s(source) && multipartFromPartMD5s(dest),
+		TrustedChecksum:          destHash,
+	}
+}
+
+// Matrix returns the entry of every provider pair
+func Matrix() []Compatibility {
+	matrix := make([]Compatibility, 0, len(Providers)*len(Providers))
+	for _, source := range Providers {
+		for _, dest := range Providers {
+			matrix = append(matrix, CompatibilityOf(source, dest))
+		}
+	}
+	return matrix
+}
+
+// ComparisonFor picks how to check one copied object from the ETags both sides
+// report. Multipart ETags only compare when both have the same number of parts;
+// a copy written in other part sizes is checked against its streamed hash instead.
+func (c Compatibility) ComparisonFor(sourceETag, destETag string) Comparison {
+	sourceMultipart, destMultipart := IsMultipartETag(sourceETag), IsMultipartETag(destETag)
+	switch {
+	case !sourceMultipart && !destMultipart && c.ETagsSurviveCopy:
+		return CompareETag
+	case sourceMultipart && destMultipart && c.MultipartETagsComparable && partCount(sourceETag) == partCount(destETag):
+		return CompareETag
+	case !destMultipart && c.TrustedChecksum != "":
+		return CompareContentHash
+	default:
+		return CompareSize
+	}
+}
+
+// partCount returns the part count of a multipart ETag (0 when there is none)
+func partCount(etag string) int {
+	_, count, found := strings.Cut(CleanETag(etag), "-")
+	if !found {
+		return 0
+	}
+	n, _ := strconv.Atoi(count)
+	return n
+}
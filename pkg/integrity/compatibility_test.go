@@ -0,0 +1,70 @@
+package integrity
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCreateIntegrityResultUsesProviderComparison(t *testing.T) {
+	hasher := NewStreamingHasher()
+	hasher.Write([]byte("hello"))
+	hashes := hasher.GetHashes()
+	md5, sha1 := `"`+hashes.MD5+`"`, hashes.SHA1
+
+	tests := []struct {
+		name           string
+		sourceETag     string
+		destETag       string
+		source, dest   ProviderType
+		wantComparison Comparison
+		wantValid      bool
+	}{
+		{name: "same provider, same ETag", sourceETag: md5, destETag: md5, source: ProviderAWS, dest: ProviderMinIO,
+			wantComparison: CompareETag, wantValid: true},
+		{name: "same provider, corrupted copy", sourceETag: md5, destETag: `"0123"`, source: ProviderAWS, dest: ProviderAWS,
+			wantComparison: CompareETag},
+		{name: "SHA-1 ETags into MD5 ETags", sourceETag: sha1, destETag: md5, source: ProviderBackblazeB2, dest: ProviderAWS,
+			wantComparison: CompareContentHash, wantValid: true},
+		{name: "multipart source into a single-part write", sourceETag: `"abc-12"`, destETag: md5, source: ProviderAWS, dest: ProviderWasabi,
+			wantComparison: CompareContentHash, wantValid: true},
+		{name: "multipart source, corrupted write", sourceETag: `"abc-12"`, destETag: `"0123"`, source: ProviderAWS, dest: ProviderAWS,
+			wantComparison: CompareContentHash},
+		{name: "multipart ETags with equal part counts", sourceETag: `"abc-3"`, destETag: `"abc-3"`, source: ProviderAWS, dest: ProviderMinIO,
+			wantComparison: CompareETag, wantValid: true},
+		{name: "multipart ETags with other part sizes", sourceETag: `"abc-3"`, destETag: `"def-2"`, source: ProviderAWS, dest: ProviderAWS,
+			wantComparison: CompareSize, wantValid: true},
+		{name: "R2 multipart ETags", sourceETag: `"abc-3"`, destETag: `"abc-3"`, source: ProviderCloudflareR2, dest: ProviderAWS,
+			wantComparison: CompareSize, wantValid: true},
+		{name: "opaque destination ETag", sourceETag: md5, destETag: `"opaque"`, source: ProviderAWS, dest: ProviderGeneric,
+			wantComparison: CompareSize, wantValid: true},
+	}
+	for _, tt := range tests {
+		result := CreateIntegrityResult(tt.sourceETag, tt.destETag, hashes, 5, tt.source, tt.dest)
+		if result.Comparison != tt.wantComparison || result.IsValid != tt.wantValid {
+			t.Errorf("%s: comparison %s, valid %v (%s); want %s, %v",
+				tt.name, result.Comparison, result.IsValid, result.ErrorMessage, tt.wantComparison, tt.wantValid)
+		}
+		if !tt.wantValid && result.ErrorMessage == "" {
+			t.Errorf("%s: no error message", tt.name)
+		}
+	}
+
+	if result := CreateIntegrityResult(md5, md5, hashes, 6, ProviderAWS, ProviderAWS); result.IsValid || !strings.Contains(result.ErrorMessage, "Size mismatch") {
+		t.Errorf("short copy: %+v", result)
+	}
+}
+
+func TestDetectProvider(t *testing.T) {
+	tests := map[string]ProviderType{
+		"":                                       ProviderAWS,
+		"https://s3.us-west-2.amazonaws.com":     ProviderAWS,
+		"https://s3.us-west-004.backblazeb2.com": ProviderBackblazeB2,
+		"http://minio.internal:9000":             ProviderMinIO,
+		"https://storage.example.com":            ProviderGeneric,
+	}
+	for endpoint, want := range tests {
+		if got := DetectProvider(endpoint); got != want {
+			t.Errorf("DetectProvider(%q) = %s, want %s", endpoint, got, want)
+		}
+	}
+}
@@ -46,6 +46,7 @@ type IntegrityResult struct {
 	SHA1Match       bool   `json:"sha1_match"`
 	IsValid         bool   `json:"is_valid"`
 	Provider        string `json:"provider"`
+	Comparison      Comparison `json:"comparison"` // How IsValid was decided for this provider pair
 	ErrorMessage    string `json:"error_message,omitempty"`
 }
 
@@ -96,12 +97,12 @@ const (
 	ProviderGeneric     ProviderType = "generic-s3"
 )
 
-// DetectProvider detects the S3-compatible storage provider from endpoint
+// DetectProvider detects the S3-compatible storage provider from endpoint; no endpoint means AWS
 func DetectProvider(endpoint string) ProviderType {
 	endpoint = strings.ToLower(endpoint)
 	
 	switch {
-	case strings.Contains(endpoint, "amazonaws.com"):
+	case endpoint == "" || strings.Contains(endpoint, "amazonaws.com"):
 		return ProviderAWS
 	case strings.Contains(endpoint, "minio"):
 		return ProviderMinIO
@@ -204,15 +205,31 @@ func CreateIntegrityResult(
 		result.SHA1Match = sourceMatch && destMatch
 	}
 	
-	// Overall validity
-	result.IsValid = result.ETagMatch && result.SizeMatch
+	// Overall validity, by the comparison the provider pair supports: ETags that
+	// differ only because the providers hash differently are not a mismatch
+	result.Comparison = CompatibilityOf(sourceProvider, destProvider).ComparisonFor(sourceETag, destETag)
+	hashMatch := destMatch
+	if !IsMultipartETag(sourceETag) && etagHash(sourceProvider) != "" {
+		hashMatch = hashMatch && sourceMatch
+	}
+	switch result.Comparison {
+	case CompareETag:
+		result.IsValid = result.ETagMatch && result.SizeMatch
+	case CompareContentHash:
+		result.IsValid = hashMatch && result.SizeMatch
+	default:
+		result.IsValid = result.SizeMatch
+	}
 	
 	// Add error message if invalid
 	if !result.IsValid {
 		var errors []string
-		if !result.ETagMatch {
+		if result.Comparison == CompareETag && !result.ETagMatch {
 			errors = append(errors, "ETag mismatch")
 		}
+		if result.Comparison == CompareContentHash && !hashMatch {
+			errors = append(errors, "ETag does not match the hash of the copied bytes")
+		}
 		if !result.SizeMatch {
 			errors = append(errors, fmt.Sprintf("Size mismatch: source=%d, dest=%d", sourceSize, hashes.Size))
 		}
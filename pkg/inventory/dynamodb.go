@@ -0,0 +1,212 @@
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// scanRetryDelays are the waits before retrying a throttled or failed Scan page
+var scanRetryDelays = []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+
+// DynamoDB reads an inventory table with Scan, one item per object. Items hold
+// the object key and size, and optionally its last modified time (an ISO 8601
+// string or Unix seconds) and ETag.
+type DynamoDB struct {
+	Table             string
+	Region            string                  // Default: us-east-1
+	Endpoint          string                  // Default: https://dynamodb.<region>.amazonaws.com
+	Credentials       aws.CredentialsProvider // nil = the service's default credential chain
+	KeyAttribute      string
+	SizeAttribute     string
+	ModifiedAttribute string
+	ETagAttribute     string       // Empty = not read
+	Client            *http.Client // Default: a client with a 30s timeout
+}
+
+// attributeValue is the part of a DynamoDB attribute value an inventory reads
+type attributeValue struct {
+	S *string `json:"S,omitempty"`
+	N *string `json:"N,omitempty"`
+}
+
+type scanOutput struct {
+	Items            []map[string]attributeValue `json:"Items"`
+	LastEvaluatedKey map[string]json.RawMessage  `json:"LastEvaluatedKey"`
+}
+
+// Name implements Source
+func (d *DynamoDB) Name() string { return "dynamodb:" + d.Table }
+
+// Entries implements Source. The prefix is applied by the table's Scan filter,
+// so items outside it are read (and consume capacity) but not returned.
+func (d *DynamoDB) Entries(ctx context.Context, prefix string) ([]Entry, error) {
+	region := valueOr(d.Region, "us-east-1")
+	credentials := d.Credentials
+	if credentials == nil {
+		awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config for DynamoDB: %w", err)
+		}
+		credentials = awsCfg.Credentials
+	}
+
+	names := map[string]string{"#k": d.KeyAttribute, "#s": d.SizeAttribute, "#m": d.ModifiedAttribute}
+	projection := "#k, #s, #m"
+	if d.ETagAttribute != "" {
+		names["#e"] = d.ETagAttribute
+		projection += ", #e"
+	}
+	input := map[string]interface{}{
+		"TableName":                d.Table,
+		"ProjectionExpression":     projection,
+		"ExpressionAttributeNames": names,
+	}
+	if prefix != "" {
+		input["FilterExpression"] = "begins_with(#k, :prefix)"
+		input["ExpressionAttributeValues"] = map[string]attributeValue{":prefix": {S: &prefix}}
+	}
+
+	var entries []Entry
+	for {
+		page, err := d.scanPage(ctx, credentials, region, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			entry, err := d.entry(item)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+		if len(page.LastEvaluatedKey) == 0 {
+			return entries, nil
+		}
+		input["ExclusiveStartKey"] = page.LastEvaluatedKey
+	}
+}
+
+// scanPage sends one Scan request, retrying throttling and server errors
+func (d *DynamoDB) scanPage(ctx context.Context, credentials aws.CredentialsProvider, region string, input map[string]interface{}) (*scanOutput, error) {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; ; attempt++ {
+		page, retryable, err := d.send(ctx, credentials, region, body)
+		if err == nil || !retryable || attempt == len(scanRetryDelays) {
+			return page, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(scanRetryDelays[attempt]):
+		}
+	}
+}
+
+// send signs and sends a Scan request body; retryable reports whether the
+// error is throttling or a server error
+func (d *DynamoDB) send(ctx context.Context, credentials aws.CredentialsProvider, region string, body []byte) (*scanOutput, bool, error) {
+	creds, err := credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("no AWS credentials to read the inventory table: %w", err)
+	}
+	endpoint := d.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://dynamodb.%s.amazonaws.com", region)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", "DynamoDB_20120810.Scan")
+	payloadHash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(payloadHash[:]), "dynamodb", region, time.Now()); err != nil {
+		return nil, false, fmt.Errorf("failed to sign DynamoDB request: %w", err)
+	}
+
+	client := d.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to scan inventory table %s: %w", d.Table, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read inventory table %s: %w", d.Table, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &failure)
+		errType := failure.Type[strings.LastIndex(failure.Type, "#")+1:]
+		retryable := resp.StatusCode >= 500 || errType == "ProvisionedThroughputExceededException" || errType == "ThrottlingException" || errType == "RequestLimitExceeded"
+		return nil, retryable, fmt.Errorf("failed to scan inventory table %s: %s %s: %s", d.Table, resp.Status, errType, failure.Message)
+	}
+
+	var page scanOutput
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, false, fmt.Errorf("invalid Scan response from inventory table %s: %w", d.Table, err)
+	}
+	return &page, false, nil
+}
+
+// entry converts an inventory item
+func (d *DynamoDB) entry(item map[string]attributeValue) (Entry, error) {
+	key := item[d.KeyAttribute].S
+	if key == nil || *key == "" {
+		return Entry{}, fmt.Errorf("inventory item without a string %s attribute", d.KeyAttribute)
+	}
+	entry := Entry{Key: *key}
+
+	size := item[d.SizeAttribute].N
+	if size == nil {
+		size = item[d.SizeAttribute].S
+	}
+	if size == nil {
+		return Entry{}, fmt.Errorf("inventory item %q has no %s attribute", *key, d.SizeAttribute)
+	}
+	parsed, err := strconv.ParseInt(*size, 10, 64)
+	if err != nil || parsed < 0 {
+		return Entry{}, fmt.Errorf("inventory item %q has an invalid %s %q", *key, d.SizeAttribute, *size)
+	}
+	entry.Size = parsed
+
+	modified := item[d.ModifiedAttribute]
+	switch {
+	case modified.S != nil:
+		if entry.LastModified, err = time.Parse(time.RFC3339, *modified.S); err != nil {
+			return Entry{}, fmt.Errorf("inventory item %q has an invalid %s %q (expected RFC 3339)", *key, d.ModifiedAttribute, *modified.S)
+		}
+	case modified.N != nil:
+		seconds, err := strconv.ParseFloat(*modified.N, 64)
+		if err != nil {
+			return Entry{}, fmt.Errorf("inventory item %q has an invalid %s %q (expected Unix seconds)", *key, d.ModifiedAttribute, *modified.N)
+		}
+		entry.LastModified = time.Unix(0, int64(seconds*float64(time.Second))).UTC()
+	}
+	if etag := item[d.ETagAttribute].S; d.ETagAttribute != "" && etag != nil {
+		entry.ETag = *etag
+	}
+	return entry, nil
+}
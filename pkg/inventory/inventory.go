@@ -0,0 +1,132 @@
+// Package inventory reads the key list of a migration source from an object
+// inventory a team keeps in a database (a DynamoDB table or a SQL query), so
+// the migrator does not LIST the source bucket.
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Entry is one object of an inventory
+type Entry struct {
+	Key          string // Full key in the source bucket
+	Size         int64
+	LastModified time.Time // Zero when the inventory does not record it
+	ETag         string    // Empty when the inventory does not record it
+}
+
+// Source supplies the objects of a source bucket
+type Source interface {
+	Name() string
+	// Entries returns the objects whose key starts with prefix
+	Entries(ctx context.Context, prefix string) ([]Entry, error)
+}
+
+// Inventory types
+const (
+	TypeDynamoDB = "dynamodb"
+	TypeSQL      = "sql"
+)
+
+// Default attribute names of a DynamoDB inventory
+const (
+	DefaultKeyAttribute      = "key"
+	DefaultSizeAttribute     = "size"
+	DefaultModifiedAttribute = "last_modified"
+)
+
+// DefaultDriver is the database/sql driver of a SQL inventory
+const DefaultDriver = "postgres"
+
+// Config selects an inventory and how its records map to objects
+type Config struct {
+	Type string // TypeDynamoDB or TypeSQL
+
+	// DynamoDB: table scanned, with the region, endpoint and credentials of its account
+	Table             string
+	Region            string
+	Endpoint          string                  // Default: https://dynamodb.<region>.amazonaws.com
+	Credentials       aws.CredentialsProvider // nil = the service's default credential chain
+	KeyAttribute      string                  // Default: DefaultKeyAttribute
+	SizeAttribute     string                  // Default: DefaultSizeAttribute
+	ModifiedAttribute string                  // Default: DefaultModifiedAttribute; not required on items
+	ETagAttribute     string                  // Optional
+
+	// SQL: a query returning key and size columns, optionally followed by
+	// last_modified and etag
+	Driver string // Default: DefaultDriver
+	DSN    string
+	Query  string
+}
+
+// Validate checks an inventory configuration without connecting to it
+func (c Config) Validate() error {
+	switch c.Type {
+	case TypeDynamoDB:
+		if c.Table == "" {
+			return fmt.Errorf("inventory table is required with type dynamodb")
+		}
+	case TypeSQL:
+		driver := c.Driver
+		if driver == "" {
+			driver = DefaultDriver
+		}
+		if !registered(driver) {
+			return fmt.Errorf("unknown inventory driver %q (available: %s)", driver, strings.Join(sql.Drivers(), ", "))
+		}
+		if c.DSN == "" || c.Query == "" {
+			return fmt.Errorf("inventory dsn and query are required with type sql")
+		}
+		if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(c.Query)), "SELECT") {
+			return fmt.Errorf("inventory query must be a SELECT")
+		}
+	default:
+		return fmt.Errorf("unknown inventory type %q (expected dynamodb or sql)", c.Type)
+	}
+	return nil
+}
+
+// New builds the source of a configuration
+func New(c Config) (Source, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	switch c.Type {
+	case TypeDynamoDB:
+		return &DynamoDB{
+			Table:             c.Table,
+			Region:            c.Region,
+			Endpoint:          c.Endpoint,
+			Credentials:       c.Credentials,
+			KeyAttribute:      valueOr(c.KeyAttribute, DefaultKeyAttribute),
+			SizeAttribute:     valueOr(c.SizeAttribute, DefaultSizeAttribute),
+			ModifiedAttribute: valueOr(c.ModifiedAttribute, DefaultModifiedAttribute),
+			ETagAttribute:     c.ETagAttribute,
+		}, nil
+	default:
+		return &SQL{Driver: valueOr(c.Driver, DefaultDriver), DSN: c.DSN, Query: c.Query}, nil
+	}
+}
+
+// registered reports whether a database/sql driver is compiled in
+func registered(driver string) bool {
+	for _, name := range sql.Drivers() {
+		if name == driver {
+			return true
+		}
+	}
+	return false
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
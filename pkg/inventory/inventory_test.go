@@ -0,0 +1,243 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"dynamodb", Config{Type: TypeDynamoDB, Table: "objects"}, false},
+		{"dynamodb without table", Config{Type: TypeDynamoDB}, true},
+		{"sql", Config{Type: TypeSQL, DSN: "postgres://db/inventory", Query: "select key, size from objects"}, false},
+		{"sql without query", Config{Type: TypeSQL, DSN: "postgres://db/inventory"}, true},
+		{"sql not a select", Config{Type: TypeSQL, DSN: "postgres://db/inventory", Query: "DELETE FROM objects"}, true},
+		{"unknown driver", Config{Type: TypeSQL, Driver: "oracle", DSN: "x", Query: "SELECT 1"}, true},
+		{"unknown type", Config{Type: "firestore"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeDynamoDB serves Scan from items, two per page
+func fakeDynamoDB(t *testing.T, items []map[string]attributeValue) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "DynamoDB_20120810.Scan" || !strings.Contains(r.Header.Get("Authorization"), "/dynamodb/aws4_request") {
+			t.Errorf("unsigned or unexpected request: %v", r.Header)
+		}
+		var input struct {
+			TableName                 string
+			FilterExpression          string
+			ExpressionAttributeNames  map[string]string
+			ExpressionAttributeValues map[string]attributeValue
+			ExclusiveStartKey         map[string]attributeValue
+		}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &input)
+		if input.TableName != "objects" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"__type": "com.amazonaws.dynamodb.v20120810#ResourceNotFoundException", "message": "Requested resource not found"}`)
+			return
+		}
+
+		keyName := input.ExpressionAttributeNames["#k"]
+		var matching []map[string]attributeValue
+		for _, item := range items {
+			if input.FilterExpression == "" || strings.HasPrefix(*item[keyName].S, *input.ExpressionAttributeValues[":prefix"].S) {
+				matching = append(matching, item)
+			}
+		}
+		start := 0
+		if input.ExclusiveStartKey != nil {
+			fmt.Sscan(*input.ExclusiveStartKey["page"].N, &start)
+		}
+		end := min(start+2, len(matching))
+		out := map[string]interface{}{"Items": matching[start:end]}
+		if end < len(matching) {
+			next := fmt.Sprint(end)
+			out["LastEvaluatedKey"] = map[string]attributeValue{"page": {N: &next}}
+		}
+		json.NewEncoder(w).Encode(out)
+	}))
+}
+
+func str(s string) *string { return &s }
+
+func TestDynamoDBEntries(t *testing.T) {
+	items := []map[string]attributeValue{
+		{"path": {S: str("docs/a.txt")}, "bytes": {N: str("5")}, "modified": {S: str("2024-03-01T12:00:00Z")}, "md5": {S: str(`"abc"`)}},
+		{"path": {S: str("docs/b.txt")}, "bytes": {N: str("7")}, "modified": {N: str("1709294400")}},
+		{"path": {S: str("docs/c/d.txt")}, "bytes": {S: str("0")}},
+		{"path": {S: str("other.txt")}, "bytes": {N: str("9")}},
+	}
+	server := fakeDynamoDB(t, items)
+	defer server.Close()
+
+	source, err := New(Config{
+		Type:              TypeDynamoDB,
+		Table:             "objects",
+		Endpoint:          server.URL,
+		Credentials:       credentials.NewStaticCredentialsProvider("AK", "SK", ""),
+		KeyAttribute:      "path",
+		SizeAttribute:     "bytes",
+		ModifiedAttribute: "modified",
+		ETagAttribute:     "md5",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := source.Entries(context.Background(), "docs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{Key: "docs/a.txt", Size: 5, LastModified: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), ETag: `"abc"`},
+		{Key: "docs/b.txt", Size: 7, LastModified: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{Key: "docs/c/d.txt"},
+	}
+	if fmt.Sprint(entries) != fmt.Sprint(want) {
+		t.Errorf("entries = %v, want %v", entries, want)
+	}
+
+	all, err := source.Entries(context.Background(), "")
+	if err != nil || len(all) != len(items) {
+		t.Errorf("unfiltered scan = %d entries, %v", len(all), err)
+	}
+
+	missing := &DynamoDB{Table: "missing", Endpoint: server.URL, Credentials: credentials.NewStaticCredentialsProvider("AK", "SK", ""), KeyAttribute: "path"}
+	if _, err := missing.Entries(context.Background(), ""); err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException") {
+		t.Errorf("scan of a missing table = %v", err)
+	}
+}
+
+func TestDynamoDBInvalidItems(t *testing.T) {
+	tests := []struct {
+		name string
+		item map[string]attributeValue
+	}{
+		{"no key", map[string]attributeValue{"size": {N: str("1")}}},
+		{"no size", map[string]attributeValue{"key": {S: str("a")}}},
+		{"negative size", map[string]attributeValue{"key": {S: str("a")}, "size": {N: str("-1")}}},
+		{"invalid time", map[string]attributeValue{"key": {S: str("a")}, "size": {N: str("1")}, "last_modified": {S: str("yesterday")}}},
+	}
+	source := &DynamoDB{KeyAttribute: DefaultKeyAttribute, SizeAttribute: DefaultSizeAttribute, ModifiedAttribute: DefaultModifiedAttribute}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if entry, err := source.entry(tt.item); err == nil {
+				t.Errorf("entry() = %+v, want an error", entry)
+			}
+		})
+	}
+}
+
+func TestDynamoDBRetriesThrottling(t *testing.T) {
+	previous := scanRetryDelays
+	scanRetryDelays = []time.Duration{time.Millisecond}
+	t.Cleanup(func() { scanRetryDelays = previous })
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"__type": "com.amazonaws.dynamodb.v20120810#ProvisionedThroughputExceededException", "message": "slow down"}`)
+			return
+		}
+		fmt.Fprint(w, `{"Items": [{"key": {"S": "a"}, "size": {"N": "1"}}]}`)
+	}))
+	defer server.Close()
+
+	source, _ := New(Config{Type: TypeDynamoDB, Table: "objects", Endpoint: server.URL, Credentials: credentials.NewStaticCredentialsProvider("AK", "SK", "")})
+	entries, err := source.Entries(context.Background(), "")
+	if err != nil || len(entries) != 1 || attempts != 2 {
+		t.Errorf("Entries() = %v, %v after %d attempts", entries, err, attempts)
+	}
+}
+
+// fakeDriver is a database/sql driver whose queries return rows
+type fakeDriver struct{ rows [][]driver.Value }
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{d}, nil }
+
+type fakeConn struct{ driver *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{c.driver}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return nil, fmt.Errorf("not supported") }
+
+type fakeStmt struct{ driver *fakeDriver }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("not supported")
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &fakeRows{rows: s.driver.rows}, nil
+}
+
+type fakeRows struct{ rows [][]driver.Value }
+
+func (r *fakeRows) Columns() []string {
+	return []string{"key", "size", "last_modified", "etag"}[:len(r.rows[0])]
+}
+func (r *fakeRows) Close() error { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var inventoryRows = &fakeDriver{}
+
+func init() { sql.Register("fake-inventory", inventoryRows) }
+
+func TestSQLEntries(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	inventoryRows.rows = [][]driver.Value{
+		{"docs/a.txt", int64(5), modified, `"abc"`},
+		{"docs/b.txt", int64(7), nil, nil},
+		{"other.txt", int64(9), modified, nil},
+	}
+	source, err := New(Config{Type: TypeSQL, Driver: "fake-inventory", DSN: "inventory", Query: "SELECT key, size, last_modified, etag FROM objects"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := source.Entries(context.Background(), "docs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{{Key: "docs/a.txt", Size: 5, LastModified: modified, ETag: `"abc"`}, {Key: "docs/b.txt", Size: 7}}
+	if fmt.Sprint(entries) != fmt.Sprint(want) {
+		t.Errorf("entries = %v, want %v", entries, want)
+	}
+
+	inventoryRows.rows = [][]driver.Value{{"docs/a.txt"}}
+	if _, err := source.Entries(context.Background(), ""); err == nil {
+		t.Error("query without a size column accepted")
+	}
+}
@@ -0,0 +1,69 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq" // PostgreSQL driver, the DefaultDriver
+)
+
+// SQL reads an inventory with a query whose rows are objects. The columns are
+// taken by position: key and size, then optionally last_modified (a timestamp)
+// and etag; NULL last modified times and ETags are left empty.
+type SQL struct {
+	Driver string
+	DSN    string
+	Query  string
+}
+
+// Name implements Source
+func (s *SQL) Name() string { return "sql:" + s.Driver }
+
+// Entries implements Source. The query runs as given; rows outside prefix are
+// left out here, so a query that filters on the prefix itself reads less.
+func (s *SQL) Entries(ctx context.Context, prefix string) ([]Entry, error) {
+	db, err := sql.Open(s.Driver, s.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open inventory database: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, s.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query inventory: %w", err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) < 2 || len(columns) > 4 {
+		return nil, fmt.Errorf("inventory query returns %d columns, expected key, size[, last_modified[, etag]]", len(columns))
+	}
+
+	var entries []Entry
+	for rows.Next() {
+		var entry Entry
+		var modified sql.NullTime
+		var etag sql.NullString
+		dest := []interface{}{&entry.Key, &entry.Size, &modified, &etag}[:len(columns)]
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("invalid inventory row: %w", err)
+		}
+		if entry.Key == "" || entry.Size < 0 {
+			return nil, fmt.Errorf("invalid inventory row %q with size %d", entry.Key, entry.Size)
+		}
+		if !strings.HasPrefix(entry.Key, prefix) {
+			continue
+		}
+		entry.LastModified = modified.Time
+		entry.ETag = etag.String
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read inventory: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,201 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Level controls how much a Logger prints
+type Level int32
+
+const (
+	// LevelError prints only errors
+	LevelError Level = iota
+	// LevelInfo prints errors and progress/summary messages (default)
+	LevelInfo
+	// LevelDebug additionally prints per-object details, subject to sampling
+	LevelDebug
+)
+
+// String returns the level name
+func (l Level) String() string {
+	switch l {
+	case LevelError:
+		return "error"
+	case LevelDebug:
+		return "debug"
+	default:
+		return "info"
+	}
+}
+
+// ParseLevel parses "error", "info" or "debug"
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "error":
+		return LevelError, nil
+	case "info", "":
+		return LevelInfo, nil
+	case "debug":
+		return LevelDebug, nil
+	default:
+		return LevelInfo, fmt.Errorf("invalid log level %q (expected error, info or debug)", s)
+	}
+}
+
+// Logger prints leveled messages, sampling per-object debug output
+type Logger struct {
+	level      atomic.Int32
+	sampleRate atomic.Int64 // Log debug details for 1 in N objects
+	counter    atomic.Int64
+}
+
+// New creates a logger at the given level, logging debug details for 1 in sampleRate objects
+func New(level Level, sampleRate int64) *Logger {
+	l := &Logger{}
+	l.SetLevel(level)
+	l.SetSampleRate(sampleRate)
+	return l
+}
+
+var (
+	defaultLogger *Logger
+	defaultOnce   sync.Once
+)
+
+// Default returns the global logger, configured from LOG_LEVEL and LOG_DEBUG_SAMPLE_RATE
+func Default() *Logger {
+	defaultOnce.Do(func() {
+		level, err := ParseLevel(os.Getenv("LOG_LEVEL"))
+		if err != nil {
+			fmt.Printf("⚠️  %v, using info\n", err)
+		}
+
+		var sampleRate int64 = 1
+		if value := os.Getenv("LOG_DEBUG_SAMPLE_RATE"); value != "" {
+			if parsed, parseErr := strconv.ParseInt(value, 10, 64); parseErr == nil && parsed > 0 {
+				sampleRate = parsed
+			}
+		}
+
+		defaultLogger = New(level, sampleRate)
+	})
+	return defaultLogger
+}
+
+// ForTask returns a logger for one task. Empty level and zero sampleRate
+// inherit the global settings at the time the task starts.
+func ForTask(level string, sampleRate int64) (*Logger, error) {
+	global := Default()
+
+	taskLevel := global.Level()
+	if level != "" {
+		parsed, err := ParseLevel(level)
+		if err != nil {
+			return nil, err
+		}
+		taskLevel = parsed
+	}
+
+	if sampleRate <= 0 {
+		sampleRate = global.SampleRate()
+	}
+
+	return New(taskLevel, sampleRate), nil
+}
+
+// Level returns the current level
+func (l *Logger) Level() Level {
+	return Level(l.level.Load())
+}
+
+// SetLevel changes the level at runtime
+func (l *Logger) SetLevel(level Level) {
+	l.level.Store(int32(level))
+}
+
+// SampleRate returns N where debug details are logged for 1 in N objects
+func (l *Logger) SampleRate() int64 {
+	return l.sampleRate.Load()
+}
+
+// SetSampleRate changes the debug sampling rate at runtime (values < 1 mean every object)
+func (l *Logger) SetSampleRate(rate int64) {
+	if rate < 1 {
+		rate = 1
+	}
+	l.sampleRate.Store(rate)
+}
+
+// Errorf prints an error message
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	printf(format, args...)
+}
+
+// Infof prints a message at info level or above
+func (l *Logger) Infof(format string, args ...interface{}) {
+	if l.Level() >= LevelInfo {
+		printf(format, args...)
+	}
+}
+
+// Debugf prints a message at debug level (unsampled; use Object for per-object output)
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	if l.Level() >= LevelDebug {
+		printf(format, args...)
+	}
+}
+
+// DebugEnabled reports whether debug output is on
+func (l *Logger) DebugEnabled() bool {
+	return l.Level() >= LevelDebug
+}
+
+// Object returns a logger for one object; its Debugf only prints for 1 in N objects
+func (l *Logger) Object() ObjectLogger {
+	sampled := false
+	if l.DebugEnabled() {
+		sampled = (l.counter.Add(1)-1)%l.SampleRate() == 0
+	}
+	return ObjectLogger{logger: l, sampled: sampled}
+}
+
+// ObjectLogger logs for a single object with a fixed sampling decision
+type ObjectLogger struct {
+	logger  *Logger
+	sampled bool
+}
+
+// Sampled reports whether this object's debug output is printed
+func (o ObjectLogger) Sampled() bool {
+	return o.sampled
+}
+
+// Errorf prints an error message
+func (o ObjectLogger) Errorf(format string, args ...interface{}) {
+	o.logger.Errorf(format, args...)
+}
+
+// Infof prints a message at info level or above
+func (o ObjectLogger) Infof(format string, args ...interface{}) {
+	o.logger.Infof(format, args...)
+}
+
+// Debugf prints a message only if debug is on and this object was sampled
+func (o ObjectLogger) Debugf(format string, args ...interface{}) {
+	if o.sampled {
+		printf(format, args...)
+	}
+}
+
+// printf prints with a trailing newline
+func printf(format string, args ...interface{}) {
+	if !strings.HasSuffix(format, "\n") {
+		format += "\n"
+	}
+	fmt.Printf(format, args...)
+}
@@ -0,0 +1,184 @@
+// Package metricspush sends the key metrics of a server (active tasks,
+// throughput, errors) to a Prometheus push gateway or to CloudWatch as
+// embedded metric format (EMF) records, for environments where the migration
+// pods cannot be scraped.
+package metricspush
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot is the state of the tasks of a server at one moment
+type Snapshot struct {
+	Time            time.Time
+	ActiveTasks     int     // Pending and running tasks
+	ThroughputBytes float64 // Bytes per second, summed over the running tasks
+	CopiedObjects   int64   // Objects copied by the active tasks
+	CopiedBytes     int64   // Bytes copied by the active tasks
+	TaskErrors      int64   // Errors recorded by the active tasks
+	FailedTasks     int     // Failed tasks the server holds
+}
+
+// metrics are the values pushed from a snapshot, with their Prometheus
+// name, EMF name and CloudWatch unit
+var metrics = []struct {
+	name, emfName, unit, help string
+	value                     func(s Snapshot) float64
+}{
+	{"s3migration_active_tasks", "ActiveTasks", "Count", "Pending and running tasks.",
+		func(s Snapshot) float64 { return float64(s.ActiveTasks) }},
+	{"s3migration_throughput_bytes_per_second", "Throughput", "Bytes/Second", "Copy throughput summed over the running tasks.",
+		func(s Snapshot) float64 { return s.ThroughputBytes }},
+	{"s3migration_copied_objects", "CopiedObjects", "Count", "Objects copied by the active tasks.",
+		func(s Snapshot) float64 { return float64(s.CopiedObjects) }},
+	{"s3migration_copied_bytes", "CopiedBytes", "Bytes", "Bytes copied by the active tasks.",
+		func(s Snapshot) float64 { return float64(s.CopiedBytes) }},
+	{"s3migration_task_errors", "TaskErrors", "Count", "Errors recorded by the active tasks.",
+		func(s Snapshot) float64 { return float64(s.TaskErrors) }},
+	{"s3migration_failed_tasks", "FailedTasks", "Count", "Failed tasks held by the server.",
+		func(s Snapshot) float64 { return float64(s.FailedTasks) }},
+}
+
+// Pusher sends snapshots somewhere
+type Pusher interface {
+	Push(ctx context.Context, snapshot Snapshot) error
+}
+
+// Run pushes a snapshot from collect every interval until ctx ends. A push
+// may take up to interval; failed pushes are logged, and the next one sends
+// fresh values anyway.
+func Run(ctx context.Context, pusher Pusher, interval time.Duration, collect func() Snapshot) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pushCtx, cancel := context.WithTimeout(ctx, interval)
+			if err := pusher.Push(pushCtx, collect()); err != nil {
+				fmt.Printf("Warning: failed to push metrics: %v\n", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Exposition renders a snapshot in the Prometheus text format
+func Exposition(snapshot Snapshot) []byte {
+	var out bytes.Buffer
+	for _, metric := range metrics {
+		fmt.Fprintf(&out, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", metric.name, metric.help, metric.name, metric.name, metric.value(snapshot))
+	}
+	return out.Bytes()
+}
+
+// PushGateway pushes snapshots to a Prometheus push gateway. Each push
+// replaces the metrics of the job and instance.
+type PushGateway struct {
+	URL      string // Base URL of the gateway, e.g. http://pushgateway:9091
+	Job      string
+	Instance string
+	Client   *http.Client // Default: a client with a 10s timeout
+}
+
+// Push implements Pusher
+func (g *PushGateway) Push(ctx context.Context, snapshot Snapshot) error {
+	target := strings.TrimSuffix(g.URL, "/") + "/metrics/job/" + url.PathEscape(g.Job)
+	if g.Instance != "" {
+		target += "/instance/" + url.PathEscape(g.Instance)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(Exposition(snapshot)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	client := g.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("push gateway answered %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// EMF writes snapshots as CloudWatch embedded metric format records: one JSON
+// line per push, either to Output (a log stream CloudWatch ingests, such as
+// stdout under awslogs or Lambda) or to the EMF listener of the CloudWatch agent
+type EMF struct {
+	Namespace  string
+	Dimensions map[string]string // Added to each record and used as its one dimension set
+	Address    string            // Agent listener, "tcp://host:port" or "udp://host:port"; empty writes to Output
+	Output     io.Writer
+}
+
+// Record returns the EMF record of a snapshot, without the trailing newline
+func (e *EMF) Record(snapshot Snapshot) ([]byte, error) {
+	dimensions := make([]string, 0, len(e.Dimensions))
+	record := map[string]interface{}{}
+	for name, value := range e.Dimensions {
+		dimensions = append(dimensions, name)
+		record[name] = value
+	}
+	sort.Strings(dimensions)
+	definitions := make([]map[string]string, 0, len(metrics))
+	for _, metric := range metrics {
+		definitions = append(definitions, map[string]string{"Name": metric.emfName, "Unit": metric.unit})
+		record[metric.emfName] = metric.value(snapshot)
+	}
+	record["_aws"] = map[string]interface{}{
+		"Timestamp": snapshot.Time.UnixMilli(),
+		"CloudWatchMetrics": []map[string]interface{}{{
+			"Namespace":  e.Namespace,
+			"Dimensions": [][]string{dimensions},
+			"Metrics":    definitions,
+		}},
+	}
+	return json.Marshal(record)
+}
+
+// Push implements Pusher
+func (e *EMF) Push(ctx context.Context, snapshot Snapshot) error {
+	record, err := e.Record(snapshot)
+	if err != nil {
+		return err
+	}
+	record = append(record, '\n')
+	if e.Address == "" {
+		_, err := e.Output.Write(record)
+		return err
+	}
+
+	network, address, ok := strings.Cut(e.Address, "://")
+	if !ok || (network != "tcp" && network != "udp") {
+		return fmt.Errorf("EMF address %q must be tcp://host:port or udp://host:port", e.Address)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetWriteDeadline(deadline)
+	}
+	_, err = conn.Write(record)
+	return err
+}
@@ -0,0 +1,134 @@
+package metricspush
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var snapshot = Snapshot{
+	Time:            time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	ActiveTasks:     2,
+	ThroughputBytes: 1.5e6,
+	CopiedObjects:   1200,
+	CopiedBytes:     3 << 30,
+	TaskErrors:      4,
+	FailedTasks:     1,
+}
+
+func TestPushGateway(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "accepted", status: http.StatusOK},
+		{name: "rejected", status: http.StatusBadRequest, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var method, path, body string
+			gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				method, path, body = r.Method, r.URL.EscapedPath(), string(data)
+				w.WriteHeader(tt.status)
+			}))
+			defer gateway.Close()
+
+			pusher := &PushGateway{URL: gateway.URL + "/", Job: "s3-migration", Instance: "pod/1"}
+			if err := pusher.Push(context.Background(), snapshot); (err != nil) != tt.wantErr {
+				t.Fatalf("Push() = %v, want error %v", err, tt.wantErr)
+			}
+			if method != http.MethodPut || path != "/metrics/job/s3-migration/instance/pod%2F1" {
+				t.Errorf("pushed with %s %s", method, path)
+			}
+			for _, want := range []string{
+				"# TYPE s3migration_active_tasks gauge\ns3migration_active_tasks 2\n",
+				"s3migration_throughput_bytes_per_second 1.5e+06\n",
+				"s3migration_copied_bytes 3.221225472e+09\n",
+				"s3migration_failed_tasks 1\n",
+			} {
+				if !strings.Contains(body, want) {
+					t.Errorf("body does not contain %q:\n%s", want, body)
+				}
+			}
+		})
+	}
+}
+
+func TestEMFRecord(t *testing.T) {
+	var out bytes.Buffer
+	emf := &EMF{Namespace: "S3Migration", Dimensions: map[string]string{"Instance": "pod-1"}, Output: &out}
+	if err := emf.Push(context.Background(), snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(out.String(), "}\n") || strings.Count(out.String(), "\n") != 1 {
+		t.Fatalf("record is not one JSON line: %q", out.String())
+	}
+
+	var record struct {
+		AWS struct {
+			Timestamp         int64
+			CloudWatchMetrics []struct {
+				Namespace  string
+				Dimensions [][]string
+				Metrics    []struct{ Name, Unit string }
+			}
+		} `json:"_aws"`
+		Instance    string
+		ActiveTasks float64
+		Throughput  float64
+	}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	directive := record.AWS.CloudWatchMetrics[0]
+	if record.AWS.Timestamp != snapshot.Time.UnixMilli() || directive.Namespace != "S3Migration" || len(directive.Dimensions) != 1 || directive.Dimensions[0][0] != "Instance" {
+		t.Errorf("metadata = %+v", record.AWS)
+	}
+	if len(directive.Metrics) != len(metrics) || directive.Metrics[1].Name != "Throughput" || directive.Metrics[1].Unit != "Bytes/Second" {
+		t.Errorf("metric definitions = %+v", directive.Metrics)
+	}
+	if record.Instance != "pod-1" || record.ActiveTasks != 2 || record.Throughput != 1.5e6 {
+		t.Errorf("values = %+v", record)
+	}
+}
+
+func TestEMFAgentAddress(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	lines := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		lines <- line
+	}()
+
+	emf := &EMF{Namespace: "S3Migration", Address: "tcp://" + listener.Addr().String()}
+	if err := emf.Push(context.Background(), snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if line := <-lines; !strings.Contains(line, `"ActiveTasks":2`) {
+		t.Errorf("agent received %q", line)
+	}
+
+	emf.Address = "http://127.0.0.1:25888"
+	if err := emf.Push(context.Background(), snapshot); err == nil {
+		t.Error("pushed to an address that is not tcp:// or udp://")
+	}
+}
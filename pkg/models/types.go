@@ -1,19 +1,376 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // MigrationRequest represents a migration request
 type MigrationRequest struct {
-	SourceBucket      string       `json:"source_bucket"` // Empty = migrate all buckets
-	DestBucket        string       `json:"dest_bucket"`   // Empty = use source bucket names
-	SourcePrefix      string       `json:"source_prefix"`
-	DestPrefix        string       `json:"dest_prefix"`
-	SourceCredentials *Credentials `json:"source_credentials,omitempty"` // Credentials for source bucket
-	DestCredentials   *Credentials `json:"dest_credentials,omitempty"`   // Credentials for destination bucket (optional, uses source if not provided)
-	Credentials       *Credentials `json:"credentials,omitempty"`        // Deprecated: for backward compatibility, use source_credentials instead
-	DryRun            bool         `json:"dry_run"`
-	MigrationMode     string       `json:"migration_mode"` // "full_rewrite" or "incremental" (default: full_rewrite)
-	Timeout           int          `json:"timeout"`
+	SourceBucket            string              `json:"source_bucket"` // Empty = migrate all buckets
+	DestBucket              string              `json:"dest_bucket"`   // Empty = use source bucket names
+	SourcePrefix            string              `json:"source_prefix"`
+	DestPrefix              string              `json:"dest_prefix"`
+	SourceCredentials       *Credentials        `json:"source_credentials,omitempty"` // Credentials for source bucket
+	DestCredentials         *Credentials        `json:"dest_credentials,omitempty"`   // Credentials for destination bucket (optional, uses source if not provided)
+	Credentials             *Credentials        `json:"credentials,omitempty"`        // Deprecated: for backward compatibility, use source_credentials instead
+	DryRun                  bool                `json:"dry_run"`
+	MigrationMode           string              `json:"migration_mode"` // "full_rewrite" or "incremental" (default: full_rewrite)
+	Timeout                 int                 `json:"timeout"`
+	LogLevel                string              `json:"log_level,omitempty"`                 // "error", "info" or "debug" (default: global LOG_LEVEL)
+	DebugSampleRate         int64               `json:"debug_sample_rate,omitempty"`         // Log per-object debug details for 1 in N objects
+	ConflictStrategy        string              `json:"conflict_strategy,omitempty"`         // "source", "dest", "newest" or "skip" for keys that already exist
+	FilesFrom               []string            `json:"files_from,omitempty"`                // Only copy these keys, relative to source_prefix (e.g. from rclone check)
+	SourceInventory         *SourceInventory    `json:"source_inventory,omitempty"`          // Read the source keys from an inventory table or query instead of listing the source bucket
+	ObjectOrder             string              `json:"object_order,omitempty"`              // "listing" (default), "largest_first", "smallest_first" or "random"
+	DryRunDiff              bool                `json:"dry_run_diff,omitempty"`              // With dry_run, also list the destination and report per-key changes
+	Multipart               *MultipartOptions   `json:"multipart,omitempty"`                 // Override multipart copy settings for large objects
+	ReuseDestListing        bool                `json:"reuse_dest_listing,omitempty"`        // Compare against a cached destination listing (up to LISTING_CACHE_TTL old) instead of listing again; ignored with verify_write
+	VerifyWrite             bool                `json:"verify_write,omitempty"`              // HEAD each written object to catch silent truncation (one extra request per object)
+	Transform               *TransformOptions   `json:"transform,omitempty"`                 // Pass matching objects through a transformation hook
+	Scan                    *ScanOptions        `json:"scan,omitempty"`                      // Scan each object (ClamAV or ICAP) before writing it
+	Dedupe                  *DedupeOptions      `json:"dedupe,omitempty"`                    // Copy identical content once and write a manifest of the duplicates
+	Snapshot                *SnapshotOptions    `json:"snapshot,omitempty"`                  // After completion, write a manifest of the destination state
+	CacheListing            bool                `json:"cache_listing,omitempty"`             // After completion, keep the destination listing with the task for verify.from_task_listing
+	ExcludeLifecycleExpired bool                `json:"exclude_lifecycle_expired,omitempty"` // Skip objects the destination's lifecycle rules would expire on arrival
+	FolderMarkers           string              `json:"folder_markers,omitempty"`            // Zero-byte keys ending in "/": "copy" (default), "recreate" (write them as empty folders) or "skip"
+	NeverOverwrite          string              `json:"never_overwrite,omitempty"`           // Never replace an existing destination object: "skip" or "fail" it (conditional writes; the destination must support If-None-Match)
+	Partition               *PartitionOptions   `json:"partition,omitempty"`                 // Rewrite destination keys into a date-partitioned layout
+	KeySanitize             []string            `json:"key_sanitize,omitempty"`              // Rewrite keys that break on the destination: replace_invalid_utf8, replace_backslashes, trim_trailing_spaces, shorten_long_keys (see POST /api/analysis/keys)
+	PrefixShards            *PrefixShardOptions `json:"prefix_shards,omitempty"`             // Limit concurrent copies per source key prefix
+	DestFetch               *DestFetchOptions   `json:"dest_fetch,omitempty"`                // Let the destination pull objects from pre-signed source URLs when its fetch service supports it
+	ConditionalGet          bool                `json:"conditional_get,omitempty"`           // Cross-account syncs: record each copy and skip downloading objects unchanged since (If-None-Match/If-Modified-Since)
+	Cutover                 *CutoverOptions     `json:"cutover,omitempty"`                   // Bulk copy, delta syncs, then a confirmed final sync for switching over
+	Reconcile               *ReconcileOptions   `json:"reconcile,omitempty"`                 // After completion, compare the destination's stored bytes with what was written
+	Webhook                 *WebhookOptions     `json:"webhook,omitempty"`                   // POST the task result to a URL when the task ends
+	AuditLog                *AuditLogOptions    `json:"audit_log,omitempty"`                 // Write a record of every copied object to an append-only log in S3
+	ACL                     *ACLOptions         `json:"acl,omitempty"`                       // Map each object's source ACL onto its copy and report what was not carried over
+	Labels                  *LabelOptions       `json:"labels,omitempty"`                    // Tag every copy on the destination, e.g. migrated-by=<task id>
+	ConfirmLargeMigration   bool                `json:"confirm_large_migration,omitempty"`   // Start even when the estimate exceeds LARGE_MIGRATION_MAX_GB or LARGE_MIGRATION_MAX_COST
+	Force                   bool                `json:"force,omitempty"`                     // Start even when an active task copies the same source to the same destination (DUPLICATE_TASK_POLICY)
+	Verify                  *VerifyOptions      `json:"verify,omitempty"`                    // Only compare source and destination and report drift; nothing is copied
+	Reorganize              *ReorganizeOptions  `json:"reorganize,omitempty"`                // Move objects from source_prefix to dest_prefix within source_bucket (a mass rename)
+	ScheduleID              string              `json:"schedule_id,omitempty"`               // Set on tasks started by a schedule
+	Network                 *NetworkProfile     `json:"network,omitempty"`                   // Link POST /api/migrate/estimate assumes for the duration; not used by migrations
+}
+
+// NetworkProfile describes the link a migration estimate's duration assumes;
+// unset values fall back to copying within one AWS region
+type NetworkProfile struct {
+	BandwidthMbps float64 `json:"bandwidth_mbps,omitempty"` // Throughput of the link, in megabits per second
+	LatencyMs     float64 `json:"latency_ms,omitempty"`     // Round trip time of one request
+	Concurrency   int     `json:"concurrency,omitempty"`    // Objects copied at once (default: 16)
+	Measure       bool    `json:"measure,omitempty"`        // Benchmark the destination bucket for the bandwidth and latency not given
+}
+
+// MigrationEstimate is the predicted size and cost of copying a migration's source
+type MigrationEstimate struct {
+	SourceBucket      string   `json:"source_bucket"` // Empty for all-buckets migrations, which are not listed
+	SourcePrefix      string   `json:"source_prefix,omitempty"`
+	Objects           int64    `json:"objects"`
+	TotalBytes        int64    `json:"total_bytes"`
+	TotalSizeGB       float64  `json:"total_size_gb"`
+	TransferCost      float64  `json:"transfer_cost"` // total_size_gb times the per-GB transfer rate
+	RequestCost       float64  `json:"request_cost"`  // One write per object at the per-1000 request rate
+	EstimatedCost     float64  `json:"estimated_cost"`
+	Currency          string   `json:"currency"`
+	Exceeds           []string `json:"exceeds,omitempty"` // Guardrail thresholds the migration is above
+	NeedsConfirmation bool     `json:"needs_confirmation"`
+	// Time to copy the source over the link below: the bytes at its bandwidth plus
+	// two round trips per object, spread over the concurrent copies
+	EstimatedSeconds  float64      `json:"estimated_seconds"`
+	EstimatedDuration string       `json:"estimated_duration"`
+	Network           *NetworkLink `json:"network,omitempty"`
+}
+
+// NetworkLink is the link a duration estimate assumed
+type NetworkLink struct {
+	BandwidthMbps float64 `json:"bandwidth_mbps"`
+	LatencyMs     float64 `json:"latency_ms"`
+	Concurrency   int     `json:"concurrency"`
+	Source        string  `json:"source"` // "default", "assumed" (from the request) or "measured" (benchmarked, possibly with assumed values)
+}
+
+// MultipartOptions override the multipart copy settings of a migration.
+// Zero fields use the provider preset (detected from the destination endpoint).
+type MultipartOptions struct {
+	Provider        string `json:"provider,omitempty"`         // Preset to start from, e.g. "aws", "scaleway"
+	ThresholdMB     int64  `json:"threshold_mb,omitempty"`     // Objects larger than this use multipart copy
+	PartSizeMB      int64  `json:"part_size_mb,omitempty"`     // Preferred part size (raised automatically for huge objects)
+	MaxParts        int    `json:"max_parts,omitempty"`        // Most parts per upload the provider accepts
+	PartConcurrency int    `json:"part_concurrency,omitempty"` // Parts of one object copied at once (default: 5)
+}
+
+// TransformOptions configure a per-object transformation hook. Transformed
+// objects are read and re-uploaded instead of copied server-side.
+type TransformOptions struct {
+	Type           string            `json:"type"`                      // "http"
+	URL            string            `json:"url,omitempty"`             // Endpoint receiving each object as a POST body
+	TimeoutSeconds int               `json:"timeout_seconds,omitempty"` // Per object (default: 300)
+	Headers        map[string]string `json:"headers,omitempty"`         // Extra request headers, e.g. Authorization
+	OnError        string            `json:"on_error,omitempty"`        // "fail" (default), "skip" or "passthrough"
+	Match          []string          `json:"match,omitempty"`           // Key base-name globs, e.g. ["*.jpg", "*.csv"]; empty = all
+}
+
+// ScanOptions configure content scanning. Scanned objects are read, spooled
+// to a temporary file and written only after a clean verdict.
+type ScanOptions struct {
+	Type             string `json:"type"`                        // "clamav" or "icap"
+	Address          string `json:"address"`                     // clamav: host:port of clamd; icap: icap://host[:port]/service
+	TimeoutSeconds   int    `json:"timeout_seconds,omitempty"`   // Per object (default: 300)
+	MaxSizeMB        int64  `json:"max_size_mb,omitempty"`       // Larger objects are handled by on_error (default: 25)
+	Quarantine       string `json:"quarantine,omitempty"`        // "skip" (default: leave out and report) or "prefix"
+	QuarantinePrefix string `json:"quarantine_prefix,omitempty"` // Destination prefix for infected objects with quarantine: prefix
+	OnError          string `json:"on_error,omitempty"`          // Unscannable objects: "fail" (default), "skip" or "copy"
+}
+
+// SourceInventory reads the objects of the source bucket from an inventory a
+// team keeps in a database, so the source is never listed. Records hold full
+// keys; those outside source_prefix are left out. Objects missing from the
+// inventory are not copied, and its sizes are trusted for progress and
+// multipart decisions.
+type SourceInventory struct {
+	Type string `json:"type"` // "dynamodb" or "sql"
+	// dynamodb: table scanned, one item per object
+	Table             string       `json:"table,omitempty"`
+	Credentials       *Credentials `json:"credentials,omitempty"`        // Region, endpoint and keys of the table's account (default: the service's own credential chain in us-east-1)
+	KeyAttribute      string       `json:"key_attribute,omitempty"`      // String attribute holding the key (default: key)
+	SizeAttribute     string       `json:"size_attribute,omitempty"`     // Number attribute holding the size in bytes (default: size)
+	ModifiedAttribute string       `json:"modified_attribute,omitempty"` // RFC 3339 string or Unix seconds, used by incremental mode (default: last_modified)
+	ETagAttribute     string       `json:"etag_attribute,omitempty"`     // Optional, recorded in the audit log
+	// sql: query returning key and size columns, then optionally last_modified and etag
+	Driver string `json:"driver,omitempty"` // database/sql driver (default: postgres)
+	DSN    string `json:"dsn,omitempty"`    // Connection string; not kept in task exports or status
+	Query  string `json:"query,omitempty"`
+}
+
+// ScanFinding is an object a content scan kept from its destination key
+type ScanFinding struct {
+	Key           string `json:"key"`
+	Threat        string `json:"threat,omitempty"`         // Signature reported by the scanner
+	Action        string `json:"action"`                   // "skipped" or "quarantined"
+	QuarantineKey string `json:"quarantine_key,omitempty"` // Where a quarantined object was written
+	Error         string `json:"error,omitempty"`          // Why the object could not be scanned
+}
+
+// UnexportableFile is a Google Drive file Drive would not export or download
+type UnexportableFile struct {
+	FileID   string `json:"file_id"`
+	Path     string `json:"path"`
+	MimeType string `json:"mime_type"`
+	Reason   string `json:"reason"` // Drive error reason, e.g. exportSizeExceeded or fileNotDownloadable
+	Error    string `json:"error"`
+}
+
+// DedupeOptions make a migration copy each distinct content once. Duplicates are
+// left out and listed, with the key their content was copied to, in a JSON manifest.
+type DedupeOptions struct {
+	Enabled     bool   `json:"enabled"`
+	Method      string `json:"method,omitempty"`       // "etag" (default: size and ETag) or "sample" (size and a hash of the first and last 64 KiB)
+	ManifestKey string `json:"manifest_key,omitempty"` // Under dest_prefix (default: dedupe-manifest.json)
+}
+
+// PartitionOptions rewrite destination keys to dest_prefix/<partition>/<key>, with the
+// partition derived from each object's last modification, e.g. logs/2024/03/17/app.log
+type PartitionOptions struct {
+	Enabled  bool   `json:"enabled"`
+	Layout   string `json:"layout,omitempty"`   // Placeholders {YYYY}, {MM}, {DD}, {HH} (default: {YYYY}/{MM}/{DD}); e.g. year={YYYY}/month={MM}
+	Timezone string `json:"timezone,omitempty"` // IANA name the dates are taken in (default: UTC)
+}
+
+// DestFetchOptions hand objects to a fetch service next to the destination
+// (a worker bound to the bucket, or an adapter for a provider's fetch API), which
+// pulls each one from a pre-signed source URL. The object's bytes then do not pass
+// through the migration pod. The service is probed when the run starts; without
+// support for the destination, objects are streamed through the pod as usual.
+type DestFetchOptions struct {
+	URL            string            `json:"url"`                       // Fetch service endpoint
+	Headers        map[string]string `json:"headers,omitempty"`         // Extra request headers, e.g. Authorization
+	MinSizeMB      int64             `json:"min_size_mb,omitempty"`     // Smaller objects are streamed through the pod (default: 0, every object is fetched)
+	URLTTLSeconds  int               `json:"url_ttl_seconds,omitempty"` // Lifetime of the pre-signed source URLs (default: 3600)
+	TimeoutSeconds int               `json:"timeout_seconds,omitempty"` // Per object (default: 300)
+}
+
+// PrefixShardOptions limit the copies in flight per source key prefix and
+// alternate the copy queue between prefixes, so a prefix holding most of the
+// keys is not throttled by S3's per-prefix request limit (503 SlowDown).
+type PrefixShardOptions struct {
+	Enabled       bool `json:"enabled"`
+	Depth         int  `json:"depth,omitempty"`          // "/"-separated key segments that form a prefix (default: 1)
+	MaxConcurrent int  `json:"max_concurrent,omitempty"` // Copies in flight per prefix (default: 32)
+}
+
+// ReconcileOptions compare, after a migration completes, the objects and bytes
+// the destination holds under dest_prefix with those the migration wrote there
+type ReconcileOptions struct {
+	Enabled    bool `json:"enabled"`
+	CloudWatch bool `json:"cloudwatch,omitempty"` // Also read the bucket's BucketSizeBytes metric (AWS destinations only; updated daily)
+}
+
+// WebhookOptions post a task's result to a URL once the task ends. When objects
+// failed to copy, their keys are first uploaded as a failure manifest and the
+// payload links to it with a pre-signed URL, so the failures can be re-driven
+// by posting the manifest's files_from back to /api/migrate.
+type WebhookOptions struct {
+	URL            string `json:"url"`
+	ManifestBucket string `json:"manifest_bucket,omitempty"` // Bucket for the failure manifest, written with the destination credentials (default: dest_bucket)
+	ManifestPrefix string `json:"manifest_prefix,omitempty"` // Key prefix of the failure manifest (default: failure-manifests)
+	URLExpiry      int    `json:"url_expiry,omitempty"`      // Seconds the pre-signed URL stays valid (default: 86400, at most 604800)
+}
+
+// AuditLogOptions write one record per copied object (key, size, duration,
+// attempts and checksum) to JSON Lines files in S3, for customers that must show
+// chain of custody for every migrated file. Files are only ever added, never
+// rewritten, in a date and task partitioned layout that Athena can query.
+type AuditLogOptions struct {
+	Enabled bool   `json:"enabled"`
+	Bucket  string `json:"bucket,omitempty"` // Bucket for the log, written with the destination credentials (default: dest_bucket)
+	Prefix  string `json:"prefix,omitempty"` // Key prefix of the log (default: audit-log)
+}
+
+// ACLOptions map the object ACLs of the source onto the copies. Grants do not
+// carry over between providers (grantee IDs differ per provider), so each kind of
+// source ACL gets an explicit action and every object that had a non-private ACL
+// is reported, rather than all copies silently becoming private.
+type ACLOptions struct {
+	Enabled bool `json:"enabled"`
+	// Action by source ACL, for the keys "public-read", "public-read-write",
+	// "authenticated-read" and "grants" (any other grantee): "apply" sets the canned
+	// ACL on the copy, "policy" keeps it private and adds it to a suggested bucket
+	// policy statement, "drop" keeps it private. Default: policy for the public
+	// ACLs, drop for the others; "grants" cannot be applied.
+	Mapping map[string]string `json:"mapping,omitempty"`
+}
+
+// LabelOptions tag every migrated object on the destination, so lifecycle rules
+// and audits can target migrated data. Each copy costs one extra PutObjectTagging
+// request, plus a GetObjectTagging unless replace is set; off by default.
+type LabelOptions struct {
+	Enabled bool `json:"enabled"`
+	// Tags set on each copy. Values may hold {task_id} and {timestamp} (when the
+	// object was labeled, RFC 3339 in UTC). Default: migrated-by={task_id},
+	// migrated-at={timestamp}. S3 allows 10 tags per object.
+	Tags map[string]string `json:"tags,omitempty"`
+	// Write only these tags, dropping those the copy already has (server-side
+	// copies keep the source's tags); saves the GetObjectTagging request
+	Replace bool `json:"replace,omitempty"`
+}
+
+// CutoverOptions run a migration as a cutover: a bulk copy, incremental delta
+// syncs until few enough changes remain, then (once confirmed through
+// POST /api/tasks/:taskID/cutover/confirm) an optional source freeze, a final
+// sync and a verification that reports whether the destination is ready.
+type CutoverOptions struct {
+	Enabled        bool  `json:"enabled"`
+	MaxDeltaPasses int   `json:"max_delta_passes,omitempty"` // Delta syncs before waiting for confirmation anyway (default: 5)
+	DeltaThreshold int64 `json:"delta_threshold,omitempty"`  // Wait for confirmation once a delta sync copies at most this many objects (default: 0)
+	FreezeSource   bool  `json:"freeze_source,omitempty"`    // Deny writes to the source through its bucket policy for the final sync
+}
+
+// VerifyOptions make a task a verification: nothing is copied, the source and
+// destination are compared and the differences are reported as drift. Run on a
+// schedule after a cutover, it shows whether anything still writes to the old bucket.
+type VerifyOptions struct {
+	Mode string `json:"mode,omitempty"` // "count" (totals only), "size" (default: key by key, by size and last modified) or "etag" (also ETags, where the providers allow)
+	// Compare with the destination listing this task cached with cache_listing
+	// instead of listing the destination; objects written since are not seen
+	FromTaskListing string `json:"from_task_listing,omitempty"`
+}
+
+// ReorganizeOptions move the objects under source_prefix to dest_prefix within
+// one bucket: each key keeps its part after source_prefix, so old/a.txt becomes
+// new/a.txt. Objects are copied server-side and, with delete_originals, the
+// originals are then deleted in batches.
+type ReorganizeOptions struct {
+	Enabled         bool `json:"enabled"`
+	DeleteOriginals bool `json:"delete_originals,omitempty"` // Delete each original once its copy is written
+}
+
+// ReorganizeReport is what a reorganization did, or with dry_run would do
+type ReorganizeReport struct {
+	DryRun       bool              `json:"dry_run"`
+	Objects      int64             `json:"objects"` // Under source_prefix
+	Bytes        int64             `json:"bytes"`
+	Overwrites   int64             `json:"overwrites"` // Destination keys that already existed
+	Copied       int64             `json:"copied"`
+	CopyFailed   int64             `json:"copy_failed"`
+	Deleted      int64             `json:"deleted"`
+	DeleteFailed int64             `json:"delete_failed"`
+	Samples      []ReorganizeEntry `json:"samples,omitempty"` // The first renames (dry runs) or failures
+}
+
+// ReorganizeEntry is one rename of a reorganization
+type ReorganizeEntry struct {
+	Key     string `json:"key"`
+	DestKey string `json:"dest_key"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SnapshotOptions write a manifest (key, size, ETag, version ID, last modified) of
+// the destination after a migration completes, as a verifiable record for audits.
+// Manifests under dest_prefix are listed by later runs as extra destination objects.
+type SnapshotOptions struct {
+	Enabled bool   `json:"enabled"`
+	Prefix  string `json:"prefix,omitempty"` // Destination bucket key prefix (default: manifests); the file is <task_id>.json
+}
+
+// KeyAuditRequest asks which keys of a bucket prefix break on some destinations
+type KeyAuditRequest struct {
+	Profile     string       `json:"profile,omitempty"`     // Named profile from the shared AWS credentials file
+	Provider    string       `json:"provider,omitempty"`    // Provider preset (aws, minio, wasabi, ...) filling in region/endpoint defaults
+	Credentials *Credentials `json:"credentials,omitempty"` // Inline credentials (take precedence over profile)
+	Bucket      string       `json:"bucket"`
+	Prefix      string       `json:"prefix,omitempty"`
+	DestPrefix  string       `json:"dest_prefix,omitempty"` // Counted in the key length, as the migration would write it
+}
+
+// DuplicateAnalysisRequest asks for duplicate objects within and across bucket prefixes
+type DuplicateAnalysisRequest struct {
+	Profile     string         `json:"profile,omitempty"`     // Named profile from the shared AWS credentials file
+	Provider    string         `json:"provider,omitempty"`    // Provider preset (aws, minio, wasabi, ...) filling in region/endpoint defaults
+	Credentials *Credentials   `json:"credentials,omitempty"` // Inline credentials (take precedence over profile)
+	Sources     []BucketPrefix `json:"sources"`
+	Method      string         `json:"method,omitempty"` // "etag" (default) or "sample"
+}
+
+// BucketConfigRequest asks which bucket-level settings (policy, CORS, website) of a
+// source bucket must be re-created on the destination, optionally re-creating them
+type BucketConfigRequest struct {
+	SourceBucket      string            `json:"source_bucket"`
+	DestBucket        string            `json:"dest_bucket"`
+	SourceCredentials *Credentials      `json:"source_credentials,omitempty"`
+	DestCredentials   *Credentials      `json:"dest_credentials,omitempty"` // Used with apply (default: source credentials)
+	Apply             bool              `json:"apply,omitempty"`            // Put the rewritten policy, CORS and website configuration on the destination
+	Replacements      map[string]string `json:"replacements,omitempty"`     // Literal substitutions in the policy after the bucket ARNs, e.g. source -> destination account ID
+}
+
+// ObjectDiffRequest asks for the source and destination metadata of a few
+// objects side by side
+type ObjectDiffRequest struct {
+	SourceBucket      string       `json:"source_bucket"`
+	DestBucket        string       `json:"dest_bucket"`
+	DestPrefix        string       `json:"dest_prefix,omitempty"` // As in the migration request: destination keys are dest_prefix/key
+	Keys              []string     `json:"keys"`                  // Source keys
+	SourceCredentials *Credentials `json:"source_credentials,omitempty"`
+	DestCredentials   *Credentials `json:"dest_credentials,omitempty"` // Default: source credentials
+}
+
+// BenchmarkRequest asks for a throughput benchmark of a bucket with synthetic objects
+type BenchmarkRequest struct {
+	Profile        string       `json:"profile,omitempty"`     // Named profile from the shared AWS credentials file
+	Provider       string       `json:"provider,omitempty"`    // Provider preset (aws, minio, wasabi, ...) filling in region/endpoint defaults
+	Credentials    *Credentials `json:"credentials,omitempty"` // Inline credentials (take precedence over profile)
+	Bucket         string       `json:"bucket"`
+	Prefix         string       `json:"prefix,omitempty"`           // Where benchmark objects are written and deleted (default: s3migration-benchmark)
+	ObjectSizesKB  []int64      `json:"object_sizes_kb,omitempty"`  // Default: 64, 1024 and 16384
+	ObjectsPerSize int          `json:"objects_per_size,omitempty"` // Per size and concurrency level (default: 8)
+	Concurrency    []int        `json:"concurrency,omitempty"`      // Parallel request levels to compare (default: 1, 4, 16)
+}
+
+// BucketPrefix is a bucket and an optional key prefix
+type BucketPrefix struct {
+	Bucket string `json:"bucket"`
+	Prefix string `json:"prefix,omitempty"`
 }
 
 // Credentials for S3 access
@@ -23,6 +380,22 @@ type Credentials struct {
 	SessionToken string `json:"session_token,omitempty"`
 	Region       string `json:"region"`
 	EndpointURL  string `json:"endpoint_url,omitempty"`
+	// Secrets Manager ARN or vault:<path> holding the keys; resolved at task start instead of storing them
+	SecretRef string `json:"secret_ref,omitempty"`
+}
+
+// String masks secrets so credentials never reach logs through %v
+func (c Credentials) String() string {
+	return fmt.Sprintf("{AccessKey:%s SecretKey:%s SessionToken:%s Region:%s EndpointURL:%s SecretRef:%s}",
+		maskSecret(c.AccessKey), maskSecret(c.SecretKey), maskSecret(c.SessionToken), c.Region, c.EndpointURL, c.SecretRef)
+}
+
+// maskSecret shows whether a secret is set without revealing it
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "***"
 }
 
 // GoogleDriveCredentials for Google Drive access
@@ -34,53 +407,421 @@ type GoogleDriveCredentials struct {
 	RedirectURL  string `json:"redirect_url"`
 }
 
+// String masks secrets so credentials never reach logs through %v
+func (c GoogleDriveCredentials) String() string {
+	return fmt.Sprintf("{ClientID:%s ClientSecret:%s AccessToken:%s RefreshToken:%s RedirectURL:%s}",
+		c.ClientID, maskSecret(c.ClientSecret), maskSecret(c.AccessToken), maskSecret(c.RefreshToken), c.RedirectURL)
+}
+
 // GoogleDriveMigrationRequest represents a Google Drive to S3 migration request
 type GoogleDriveMigrationRequest struct {
-	SourceFolderID    string                  `json:"source_folder_id"`    // Google Drive folder ID (empty = root)
-	DestBucket        string                  `json:"dest_bucket"`         // S3 destination bucket
-	DestPrefix        string                  `json:"dest_prefix"`         // S3 destination prefix
-	SourceCredentials *GoogleDriveCredentials `json:"source_credentials"`  // Google Drive credentials
-	DestCredentials   *Credentials            `json:"dest_credentials"`    // S3 destination credentials
-	DryRun            bool                    `json:"dry_run"`
-	MigrationMode     string                  `json:"migration_mode"`      // "full_rewrite" or "incremental"
-	Timeout           int                     `json:"timeout"`
-	IncludeSharedFiles bool                   `json:"include_shared_files"` // Include files shared with me (default: false)
+	SourceFolderID     string                  `json:"source_folder_id"`       // Google Drive folder ID (empty = root)
+	DestBucket         string                  `json:"dest_bucket"`            // S3 destination bucket
+	DestPrefix         string                  `json:"dest_prefix"`            // S3 destination prefix
+	SourceCredentials  *GoogleDriveCredentials `json:"source_credentials"`     // Google Drive credentials
+	DestCredentials    *Credentials            `json:"dest_credentials"`       // S3 destination credentials (required unless dest_profile is set)
+	DestProfile        string                  `json:"dest_profile,omitempty"` // Named profile from the shared AWS credentials file; dest_credentials then only sets region/endpoint
+	DryRun             bool                    `json:"dry_run"`
+	MigrationMode      string                  `json:"migration_mode"` // "full_rewrite" or "incremental"
+	Timeout            int                     `json:"timeout"`
+	IncludeSharedFiles bool                    `json:"include_shared_files"`         // Include files shared with me (default: false)
+	IncludePaths       []string                `json:"include_paths,omitempty"`      // Only migrate these paths (relative to source folder, glob per segment)
+	ExcludePaths       []string                `json:"exclude_paths,omitempty"`      // Skip these paths and their subtrees
+	IncludeMimeTypes   []string                `json:"include_mime_types,omitempty"` // Only migrate these mime types (e.g. "image/*")
+	ExcludeMimeTypes   []string                `json:"exclude_mime_types,omitempty"` // Skip these mime types (e.g. "video/*", "application/vnd.google-apps.form")
+}
+
+// BoxCredentials for Box access
+type BoxCredentials struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// String masks secrets so credentials never reach logs through %v
+func (c BoxCredentials) String() string {
+	return fmt.Sprintf("{ClientID:%s ClientSecret:%s AccessToken:%s RefreshToken:%s}",
+		c.ClientID, maskSecret(c.ClientSecret), maskSecret(c.AccessToken), maskSecret(c.RefreshToken))
+}
+
+// BoxMigrationRequest represents a Box to S3 migration request
+type BoxMigrationRequest struct {
+	SourceFolderID    string          `json:"source_folder_id"`   // Box folder ID (empty = root)
+	DestBucket        string          `json:"dest_bucket"`        // S3 destination bucket
+	DestPrefix        string          `json:"dest_prefix"`        // S3 destination prefix
+	SourceCredentials *BoxCredentials `json:"source_credentials"` // Box credentials
+	DestCredentials   *Credentials    `json:"dest_credentials"`   // S3 destination credentials
+	DryRun            bool            `json:"dry_run"`
+	Workers           int             `json:"workers,omitempty"`       // Concurrent uploads (default: 4)
+	ChunkSizeMB       int             `json:"chunk_size_mb,omitempty"` // Ranged download chunk size (default: 32)
+	Timeout           int             `json:"timeout"`
+}
+
+// URLListMigrationRequest represents an HTTP(S) URL-list to S3 ingestion request
+type URLListMigrationRequest struct {
+	URLs            []string          `json:"urls,omitempty"`         // URLs to ingest
+	Manifest        string            `json:"manifest,omitempty"`     // Inline manifest, one "url[,key[,sha256]]" per line
+	ManifestURL     string            `json:"manifest_url,omitempty"` // Manifest to download (same format as manifest)
+	Headers         map[string]string `json:"headers,omitempty"`      // Extra request headers sent with every download
+	DestBucket      string            `json:"dest_bucket"`            // S3 destination bucket
+	DestPrefix      string            `json:"dest_prefix"`            // S3 destination prefix
+	DestCredentials *Credentials      `json:"dest_credentials"`       // S3 destination credentials
+	DryRun          bool              `json:"dry_run"`
+	Concurrency     int               `json:"concurrency,omitempty"` // Parallel downloads (default: 8)
+	MaxRetries      int               `json:"max_retries,omitempty"` // Attempts per URL (default: 3)
+	Timeout         int               `json:"timeout"`
+}
+
+// BucketListRequest asks for the buckets visible to a set of credentials
+type BucketListRequest struct {
+	Profile     string       `json:"profile,omitempty"`     // Named profile from the shared AWS credentials file
+	Provider    string       `json:"provider,omitempty"`    // Provider preset (aws, minio, wasabi, ...) filling in region/endpoint defaults
+	Credentials *Credentials `json:"credentials,omitempty"` // Inline credentials (take precedence over profile)
+	SkipRegion  bool         `json:"skip_region,omitempty"` // Don't look up each bucket's region (one request per bucket)
+}
+
+// ObjectListRequest asks for one page of a bucket's folder structure
+type ObjectListRequest struct {
+	Profile           string       `json:"profile,omitempty"`     // Named profile from the shared AWS credentials file
+	Provider          string       `json:"provider,omitempty"`    // Provider preset (aws, minio, wasabi, ...) filling in region/endpoint defaults
+	Credentials       *Credentials `json:"credentials,omitempty"` // Inline credentials (take precedence over profile)
+	Bucket            string       `json:"bucket"`
+	Prefix            string       `json:"prefix"`
+	Delimiter         *string      `json:"delimiter,omitempty"`          // Default "/"; "" lists keys recursively
+	ContinuationToken string       `json:"continuation_token,omitempty"` // From next_continuation_token of the previous page
+	MaxKeys           int          `json:"max_keys,omitempty"`           // Page size (default and max: 1000)
+}
+
+// CredentialValidationRequest asks what a set of credentials can do
+type CredentialValidationRequest struct {
+	Profile     string       `json:"profile,omitempty"`     // Named profile from the shared AWS credentials file
+	Provider    string       `json:"provider,omitempty"`    // Provider preset (aws, minio, wasabi, ...) filling in region/endpoint defaults
+	Credentials *Credentials `json:"credentials,omitempty"` // Inline credentials (take precedence over profile)
+	Bucket      string       `json:"bucket,omitempty"`      // Bucket to check read, write and multipart access on; without it only listing is checked
+	Prefix      string       `json:"prefix,omitempty"`      // Where in the bucket the write probe object goes
 }
 
 // MigrationStatus represents the current status of a migration task
 type MigrationStatus struct {
-	TaskID         string    `json:"task_id"`
-	Status         string    `json:"status"` // pending, running, completed, failed, cancelled
-	MigrationType  string    `json:"migration_type"` // "s3" or "google-drive"
-	Progress       float64   `json:"progress"`
-	CopiedObjects  int64     `json:"copied_objects"`
-	TotalObjects   int64     `json:"total_objects"`
-	CopiedSize     int64     `json:"copied_size"`
-	TotalSize      int64     `json:"total_size"`
-	CurrentSpeed   float64   `json:"current_speed"` // MB/s
-	ETA            string    `json:"eta"`
-	Errors         []string  `json:"errors"`
-	StartTime      time.Time `json:"start_time"`
-	EndTime        time.Time `json:"end_time"`
-	Duration       string    `json:"duration"` // Human-readable duration
-	LastUpdateTime time.Time `json:"last_update_time"`
+	TaskID         string       `json:"task_id"`
+	Status         string       `json:"status"`         // pending, running, completed, failed, cancelled
+	MigrationType  string       `json:"migration_type"` // "s3", "google-drive", "box" or "url-list"
+	Progress       float64      `json:"progress"`
+	CopiedObjects  int64        `json:"copied_objects"`
+	TotalObjects   int64        `json:"total_objects"`
+	CopiedSize     int64        `json:"copied_size"`
+	TotalSize      int64        `json:"total_size"`
+	CurrentSpeed   float64      `json:"current_speed"` // MB/s
+	ETA            string       `json:"eta"`
+	ETAEstimate    *ETAEstimate `json:"eta_estimate,omitempty"` // Byte-based estimate with bounds (S3 migrations)
+	Errors         []string     `json:"errors"`
+	StartTime      time.Time    `json:"start_time"`
+	EndTime        time.Time    `json:"end_time"`
+	Duration       string       `json:"duration"` // Human-readable duration
+	LastUpdateTime time.Time    `json:"last_update_time"`
+	// Current phase while running; progress counters above cover the uploading phase only
+	Phase      string             `json:"phase,omitempty"`      // "discovering", "uploading" or "verifying"
+	Discovery  *DiscoveryProgress `json:"discovery,omitempty"`  // Files found so far (Google Drive)
+	Cutover    *CutoverState      `json:"cutover,omitempty"`    // Phases of a cutover task
+	Drift      *DriftReport       `json:"drift,omitempty"`      // What a verification task found
+	Reorganize *ReorganizeReport  `json:"reorganize,omitempty"` // What a reorganization task did
+	Notes      []TaskNote         `json:"notes,omitempty"`      // Operator notes, oldest first
+	Request    *RequestSummary    `json:"request,omitempty"`    // What an S3 task was asked to do, without secrets
+	// How the task was cancelled: "hard", or "drain" (set while in-flight transfers finish)
+	CancelMode string `json:"cancel_mode,omitempty"`
+	// Active task copying the same route that a queued task waits for (DUPLICATE_TASK_POLICY=queue)
+	QueuedBehind string `json:"queued_behind,omitempty"`
 	// Dry run specific information
-	DryRun         bool      `json:"dry_run"`
-	DryRunVerified []string  `json:"dry_run_verified,omitempty"` // What was verified during dry run
-	SampleFiles    []string  `json:"sample_files,omitempty"`     // Sample files found
+	DryRun         bool     `json:"dry_run"`
+	DryRunVerified []string `json:"dry_run_verified,omitempty"` // What was verified during dry run
+	SampleFiles    []string `json:"sample_files,omitempty"`     // Sample files found
+}
+
+// RequestSummary is the request of an S3 task as shown in its status. Keys,
+// hook headers and the paths and queries of hook URLs are left out, and
+// files_from is replaced by its count.
+type RequestSummary struct {
+	Request        *MigrationRequest `json:"request"`
+	FilesFromCount int               `json:"files_from_count,omitempty"`
+	SourceProvider string            `json:"source_provider"`           // Provider detected from the source endpoint, e.g. "aws" or "minio"
+	SourceEndpoint string            `json:"source_endpoint,omitempty"` // Empty for AWS
+	DestProvider   string            `json:"dest_provider"`
+	DestEndpoint   string            `json:"dest_endpoint,omitempty"`
+}
+
+// TaskNote is a timestamped operator note on a task, e.g. "source team paused writes at 14:00"
+type TaskNote struct {
+	Time   time.Time `json:"time"`
+	Author string    `json:"author"` // From the request, or the caller's hashed API key or IP address
+	Text   string    `json:"text"`
+}
+
+// TaskNoteRequest is the body of POST /api/tasks/:taskId/notes
+type TaskNoteRequest struct {
+	Text   string `json:"text" binding:"required"`
+	Author string `json:"author,omitempty"`
+}
+
+// Task phases reported in MigrationStatus.Phase
+const (
+	PhaseDiscovering = "discovering" // Listing the source; totals are not known yet
+	PhaseUploading   = "uploading"   // Copying; CopiedObjects of TotalObjects
+	PhaseVerifying   = "verifying"   // Comparing the destination with the source
+)
+
+// Cutover phases reported in CutoverState.Phase
+const (
+	CutoverBulkCopy             = "bulk_copy"
+	CutoverDeltaSync            = "delta_sync"
+	CutoverAwaitingConfirmation = "awaiting_confirmation" // Until POST /api/tasks/:taskID/cutover/confirm
+	CutoverFreezing             = "freezing"
+	CutoverFinalSync            = "final_sync"
+	CutoverVerifying            = "verifying"
+	CutoverReady                = "ready"
+	CutoverNotReady             = "not_ready" // The final sync failed objects or verification found differences
+)
+
+// CutoverState is the progress of a cutover task
+type CutoverState struct {
+	Phase        string        `json:"phase"`
+	Passes       []CutoverPass `json:"passes"`
+	SourceFrozen bool          `json:"source_frozen"` // The freeze statement is in the source bucket policy
+	Ready        bool          `json:"ready"`         // The destination matched the frozen source
+	Remaining    int           `json:"remaining"`     // Objects verification found missing or different on the destination
+	Message      string        `json:"message,omitempty"`
+}
+
+// CutoverPass is one copy pass of a cutover
+type CutoverPass struct {
+	Phase        string    `json:"phase"` // bulk_copy, delta_sync or final_sync
+	Copied       int64     `json:"copied"`
+	Failed       int64     `json:"failed"`
+	CopiedSizeMB float64   `json:"copied_size_mb"`
+	Started      time.Time `json:"started"`
+	Duration     string    `json:"duration"`
+}
+
+// DriftReport is what a verification task found: how the destination differs
+// from the source at CheckedAt
+type DriftReport struct {
+	Mode          string       `json:"mode"`
+	CheckedAt     time.Time    `json:"checked_at"`
+	SourceObjects int64        `json:"source_objects"`
+	SourceBytes   int64        `json:"source_bytes"`
+	DestObjects   int64        `json:"dest_objects"`
+	DestBytes     int64        `json:"dest_bytes"`
+	Missing       int64        `json:"missing"` // At the source only: written since the copy, or never copied
+	Changed       int64        `json:"changed"` // Size or ETag differs, or the source was modified after the copy
+	Extra         int64        `json:"extra"`   // At the destination only: deleted from the source, or written to the destination
+	Drifted       bool         `json:"drifted"`
+	Samples       []DriftEntry `json:"samples,omitempty"`        // The first differences found
+	DestListedAt  *time.Time   `json:"dest_listed_at,omitempty"` // When the cached destination listing compared with was taken
+}
+
+// DriftEntry is one difference a verification found
+type DriftEntry struct {
+	Key    string `json:"key"`   // Source key; destination key of extra objects
+	Issue  string `json:"issue"` // "missing", "changed" or "extra"
+	Detail string `json:"detail,omitempty"`
+}
+
+// DiscoveryProgress counts what the discovery phase has found so far
+type DiscoveryProgress struct {
+	FilesDiscovered int64 `json:"files_discovered"`
+	BytesDiscovered int64 `json:"bytes_discovered"`
+	FoldersFound    int64 `json:"folders_found"`
+}
+
+// ETAEstimate is the remaining-time estimate for a running task, from the bytes still
+// pending and an exponentially weighted throughput average. The bounds widen with
+// throughput variance and with the per-object rate (many small files left).
+type ETAEstimate struct {
+	Seconds               float64 `json:"seconds"`
+	OptimisticSeconds     float64 `json:"optimistic_seconds"`
+	PessimisticSeconds    float64 `json:"pessimistic_seconds"`
+	RemainingBytes        int64   `json:"remaining_bytes"`
+	RemainingObjects      int64   `json:"remaining_objects"`
+	ThroughputBytesPerSec float64 `json:"throughput_bytes_per_sec"` // Weighted recent throughput
+	ObjectsPerSec         float64 `json:"objects_per_sec"`
+}
+
+// WorkerAdjustment is an automatic change of the worker count of a running task
+type WorkerAdjustment struct {
+	Time      time.Time `json:"time"`
+	From      int       `json:"from"`
+	To        int       `json:"to"`
+	ErrorRate float64   `json:"error_rate"` // Percent of copies that failed in the interval
+	Reason    string    `json:"reason"`
 }
 
 // MigrationResult represents the final result of a migration
 type MigrationResult struct {
-	TaskID       string   `json:"task_id"`
-	Success      bool     `json:"success"`
-	Copied       int64    `json:"copied"`
-	Failed       int64    `json:"failed"`
-	TotalSizeMB  float64  `json:"total_size_mb"`
-	CopiedSizeMB float64  `json:"copied_size_mb"`
-	ElapsedTime  string   `json:"elapsed_time"`
-	AvgSpeedMB   float64  `json:"avg_speed_mb"`
-	Errors       []string `json:"errors"`
+	TaskID              string                 `json:"task_id"`
+	Success             bool                   `json:"success"`
+	Copied              int64                  `json:"copied"`
+	Failed              int64                  `json:"failed"`
+	Skipped             int64                  `json:"skipped,omitempty"`              // Left out after transformation errors (transform.on_error: skip), by the content scan or as their key existed (never_overwrite: skip)
+	NotModified         int64                  `json:"not_modified,omitempty"`         // Not downloaded: unchanged since the last sync, with the copy in place (conditional_get)
+	ScanFindings        []ScanFinding          `json:"scan_findings,omitempty"`        // Objects withheld by the content scan
+	SkippedUnexportable []UnexportableFile     `json:"skipped_unexportable,omitempty"` // Google Drive files Drive cannot provide (counted in skipped)
+	Deduplicated        int64                  `json:"deduplicated,omitempty"`         // Duplicates not copied (dedupe.enabled)
+	DedupeManifest      string                 `json:"dedupe_manifest,omitempty"`      // Destination key of the duplicate manifest
+	LifecycleExcluded   int64                  `json:"lifecycle_excluded,omitempty"`   // Not copied: the destination's lifecycle rules would expire them (exclude_lifecycle_expired)
+	FolderMarkers       int64                  `json:"folder_markers,omitempty"`       // Empty folder markers recreated on the destination (folder_markers: recreate)
+	SnapshotManifest    string                 `json:"snapshot_manifest,omitempty"`    // Destination key of the snapshot manifest
+	SnapshotSHA256      string                 `json:"snapshot_sha256,omitempty"`      // Digest of the manifest body, also in its manifest-sha256 metadata
+	WebsiteCopied       bool                   `json:"website_copied,omitempty"`       // Static website configuration recreated on the destination bucket
+	DestFetched         int64                  `json:"dest_fetched,omitempty"`         // Objects the destination pulled itself (dest_fetch)
+	OverwriteRefused    int64                  `json:"overwrite_refused,omitempty"`    // Objects not written as their destination key existed (never_overwrite)
+	ExistingDestKeys    []string               `json:"existing_dest_keys,omitempty"`   // Destination keys of the first 100 of them
+	TotalSizeMB         float64                `json:"total_size_mb"`
+	CopiedSizeMB        float64                `json:"copied_size_mb"`
+	ElapsedTime         string                 `json:"elapsed_time"`
+	AvgSpeedMB          float64                `json:"avg_speed_mb"`
+	Errors              []string               `json:"errors"`
+	ResourceUsage       *ResourceUsage         `json:"resource_usage,omitempty"`
+	Requests            *APIRequests           `json:"requests,omitempty"`           // S3 requests made by the task, by operation class
+	Latency             []OperationLatency     `json:"latency,omitempty"`            // Request latency percentiles by endpoint and operation
+	WorkerAdjustments   []WorkerAdjustment     `json:"worker_adjustments,omitempty"` // Worker count changes made on the error rate
+	Reconciliation      *StorageReconciliation `json:"reconciliation,omitempty"`     // Stored bytes compared with the bytes written (reconcile.enabled)
+	FailureManifest     *FailureManifestLink   `json:"failure_manifest,omitempty"`   // Keys that failed to copy, uploaded for the webhook
+	AuditLog            *AuditLogLocation      `json:"audit_log,omitempty"`          // Where the per-object audit records of the run were written
+	ACL                 *ACLReport             `json:"acl,omitempty"`                // How the source ACLs were mapped (acl.enabled)
+	Labels              *LabelReport           `json:"labels,omitempty"`             // How many copies were tagged (labels.enabled)
+}
+
+// LabelReport counts the copies tagged by labels
+type LabelReport struct {
+	Labeled int64 `json:"labeled"`
+	Errors  int64 `json:"errors"` // Copies that could not be tagged; listed in the task errors
+}
+
+// ACLReport records how the source ACLs of the copied objects were mapped
+type ACLReport struct {
+	BySourceACL map[string]int64 `json:"by_source_acl"` // Objects by source ACL, "private" included
+	Applied     int64            `json:"applied"`       // Copies given the source's canned ACL
+	NotCarried  int64            `json:"not_carried"`   // Non-private source ACLs left off the copies (policy or drop)
+	Errors      int64            `json:"errors"`        // ACLs that could not be read or set; listed in the task errors
+	// Bucket policy granting public reads of the copies mapped to "policy", to review and apply by hand
+	SuggestedPolicy string     `json:"suggested_policy,omitempty"`
+	Objects         []ACLEntry `json:"objects,omitempty"` // Objects with a non-private source ACL (first 100)
+}
+
+// ACLEntry is the mapping of one object's non-private source ACL
+type ACLEntry struct {
+	Key       string   `json:"key"`
+	SourceACL string   `json:"source_acl"` // public-read, public-read-write, authenticated-read or grants
+	Action    string   `json:"action"`     // apply, policy or drop
+	Grants    []string `json:"grants,omitempty"`
+}
+
+// AuditLogLocation locates the audit records a run wrote
+type AuditLogLocation struct {
+	Bucket  string   `json:"bucket"`
+	Prefix  string   `json:"prefix"` // Partition of the run, e.g. audit-log/date=2026-10-15/task=ID
+	Files   []string `json:"files"`
+	Records int64    `json:"records"`
+}
+
+// FailureManifestLink locates an uploaded failure manifest
+type FailureManifestLink struct {
+	Bucket    string    `json:"bucket"`
+	Key       string    `json:"key"`
+	URL       string    `json:"url"` // Pre-signed GET URL
+	ExpiresAt time.Time `json:"expires_at"`
+	Objects   int       `json:"objects"`
+	Truncated bool      `json:"truncated,omitempty"` // More objects failed than a manifest records
+}
+
+// TaskWebhookPayload is posted to a task's webhook when the task ends
+type TaskWebhookPayload struct {
+	Event           string               `json:"event"` // "task.finished"
+	TaskID          string               `json:"task_id"`
+	Status          string               `json:"status"`
+	SourceBucket    string               `json:"source_bucket"`
+	SourcePrefix    string               `json:"source_prefix,omitempty"`
+	DestBucket      string               `json:"dest_bucket"`
+	DestPrefix      string               `json:"dest_prefix,omitempty"`
+	Copied          int64                `json:"copied"`
+	Failed          int64                `json:"failed"`
+	Skipped         int64                `json:"skipped,omitempty"`
+	StartTime       time.Time            `json:"start_time"`
+	EndTime         time.Time            `json:"end_time"`
+	Errors          []string             `json:"errors,omitempty"` // The first errors of the task
+	FailureManifest *FailureManifestLink `json:"failure_manifest,omitempty"`
+	Drift           *DriftReport         `json:"drift,omitempty"`      // Verification tasks: what the comparison found
+	Reorganize      *ReorganizeReport    `json:"reorganize,omitempty"` // Reorganization tasks: what was moved
+}
+
+// StorageReconciliation compares what a destination holds under the migration's
+// prefix with what the migration put there. Differences point at failed copies or
+// deletes, duplicates under other keys, or billed storage a listing does not show.
+type StorageReconciliation struct {
+	ExpectedObjects   int64    `json:"expected_objects"` // Source objects migrated (copied now or already present)
+	ExpectedBytes     int64    `json:"expected_bytes"`
+	WrittenBytes      int64    `json:"written_bytes"` // Copied by this run
+	ListedObjects     int64    `json:"listed_objects"`
+	ListedBytes       int64    `json:"listed_bytes"`
+	MissingObjects    int64    `json:"missing_objects"`
+	SizeMismatches    int64    `json:"size_mismatches"`
+	ExtraObjects      int64    `json:"extra_objects"` // Listed, but not written by the migration
+	ExtraBytes        int64    `json:"extra_bytes"`
+	ExtraSample       []string `json:"extra_sample,omitempty"`
+	IncompleteUploads int64    `json:"incomplete_uploads"` // Multipart uploads never completed or aborted; their parts are billed
+	// BucketSizeBytes of the whole bucket (Standard storage) as last reported by CloudWatch
+	MetricBytes     *int64    `json:"metric_bytes,omitempty"`
+	MetricTimestamp time.Time `json:"metric_timestamp,omitempty"`
+	MetricError     string    `json:"metric_error,omitempty"`
+	Discrepancies   []string  `json:"discrepancies"`
+}
+
+// APIRequests counts the S3 requests a task made, retries included, in the
+// classes providers bill them in
+type APIRequests struct {
+	Total       int64            `json:"total"`
+	List        int64            `json:"list"` // ListObjectsV2, ListParts, ListMultipartUploads, ...
+	Head        int64            `json:"head"`
+	Get         int64            `json:"get"`
+	Put         int64            `json:"put"`         // PutObject, CopyObject, CreateBucket and creating or completing multipart uploads
+	UploadPart  int64            `json:"upload_part"` // UploadPart and UploadPartCopy
+	Delete      int64            `json:"delete"`      // Including multipart upload aborts
+	Other       int64            `json:"other"`
+	ByOperation map[string]int64 `json:"by_operation,omitempty"` // Requests by S3 operation name
+}
+
+// OperationLatency gives the latency percentiles of one S3 operation on one
+// endpoint, over its successful attempts. GetObject is timed to the first byte of
+// the response; compare it with PutObject on the destination to tell whether a
+// slow run is bound by source reads or destination writes.
+type OperationLatency struct {
+	Endpoint  string  `json:"endpoint"` // Endpoint URL, or "aws"
+	Operation string  `json:"operation"`
+	Count     int64   `json:"count"`
+	P50Ms     float64 `json:"p50_ms"` // Upper bound of the histogram bucket the percentile falls in
+	P95Ms     float64 `json:"p95_ms"`
+	P99Ms     float64 `json:"p99_ms"`
+	MaxMs     float64 `json:"max_ms"`
+}
+
+// ResourceUsage summarizes process resource consumption sampled during a task window.
+// Values are process-wide samples, not this task's own usage: with
+// PeakConcurrentTasks above 1 they include other tasks. Use them for sizing, not billing.
+type ResourceUsage struct {
+	Scope               string  `json:"scope"`                 // Always "process"
+	PeakConcurrentTasks int     `json:"peak_concurrent_tasks"` // Most tasks running in the process during the window
+	Samples             int     `json:"samples"`
+	DurationSeconds     float64 `json:"duration_seconds"`
+	PeakHeapInUseMiB    int64   `json:"peak_heap_in_use_mib"` // Process heap in use, including buffered object data (peak)
+	AvgHeapInUseMiB     int64   `json:"avg_heap_in_use_mib"`
+	PeakSysMiB          int64   `json:"peak_sys_mib"`
+	AllocatedMiB        int64   `json:"allocated_mib"` // Cumulative allocations during the window
+	PeakGoroutines      int     `json:"peak_goroutines"`
+	AvgGoroutines       int     `json:"avg_goroutines"`
+	NumGC               uint32  `json:"num_gc"`
+	GCPauseTotalMs      float64 `json:"gc_pause_total_ms"`
+	GCPauseMaxMs        float64 `json:"gc_pause_max_ms"`
+	CPUFractionGC       float64 `json:"gc_cpu_fraction"`
 }
 
 // ObjectInfo represents information about an S3 object
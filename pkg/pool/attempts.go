@@ -0,0 +1,56 @@
+package pool
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/aws/smithy-go/middleware"
+)
+
+// AttemptCounter counts the S3 operations made under a context and the attempts
+// they took, retries included
+type AttemptCounter struct {
+	operations atomic.Int64
+	attempts   atomic.Int64
+}
+
+// Operations returns the operations counted so far
+func (c *AttemptCounter) Operations() int64 {
+	return c.operations.Load()
+}
+
+// Retries returns the attempts beyond the first of each operation
+func (c *AttemptCounter) Retries() int64 {
+	return max(c.attempts.Load()-c.operations.Load(), 0)
+}
+
+type attemptCounterKey struct{}
+
+// WithAttemptCounter returns a context whose S3 operations, made with clients of
+// a connection pool, are counted by the returned counter
+func WithAttemptCounter(ctx context.Context) (context.Context, *AttemptCounter) {
+	counter := &AttemptCounter{}
+	return context.WithValue(ctx, attemptCounterKey{}, counter), counter
+}
+
+// countAttempts counts each operation once when it starts and each attempt after
+// the retry middleware, which sends the rest of the finalize step once per attempt
+func countAttempts(stack *middleware.Stack) error {
+	err := stack.Initialize.Add(middleware.InitializeMiddlewareFunc("CountOperations",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			if counter, ok := ctx.Value(attemptCounterKey{}).(*AttemptCounter); ok {
+				counter.operations.Add(1)
+			}
+			return next.HandleInitialize(ctx, in)
+		}), middleware.Before)
+	if err != nil {
+		return err
+	}
+	return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("CountAttempts",
+		func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			if counter, ok := ctx.Value(attemptCounterKey{}).(*AttemptCounter); ok {
+				counter.attempts.Add(1)
+			}
+			return next.HandleFinalize(ctx, in)
+		}), middleware.After)
+}
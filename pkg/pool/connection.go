@@ -37,6 +37,14 @@ type ConnectionPoolConfig struct {
 	// Explicit credentials for custom S3 providers
 	AccessKey string
 	SecretKey string
+	// Named profile from the shared credentials file, used when neither explicit
+	// credentials nor a credentials provider are set
+	Profile string
+	// Dynamic credentials (e.g. a secret reference); takes precedence over AccessKey/SecretKey
+	CredentialsProvider aws.CredentialsProvider
+	// Name resolution and address family of connections (nil = from S3_HOSTS,
+	// S3_DNS_SERVER, S3_PREFER_IPV6 and S3_IPV6_ONLY)
+	Dial *DialOptions
 }
 
 // DefaultConnectionPoolConfig returns default pool configuration
@@ -44,7 +52,7 @@ func DefaultConnectionPoolConfig() ConnectionPoolConfig {
 	return ConnectionPoolConfig{
 		Size:       10,
 		Region:     "us-east-1",
-		MaxRetries: 10,  // Increased from 3 to handle rate limiting better
+		MaxRetries: 10, // Increased from 3 to handle rate limiting better
 		Timeout:    30 * time.Second,
 	}
 }
@@ -75,17 +83,65 @@ func NewConnectionPool(ctx context.Context, cfg ConnectionPoolConfig) (*Connecti
 	return pool, nil
 }
 
+// NewStaticConnectionPool wraps already configured clients, e.g. clients of a
+// fake endpoint in tests, in a pool. Their requests are counted like those of
+// pooled clients.
+func NewStaticConnectionPool(clients ...*s3.Client) *ConnectionPool {
+	counted := make([]*s3.Client, len(clients))
+	for i, client := range clients {
+		options := client.Options()
+		counted[i] = s3.New(options, func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions, countAttempts, countRequests, recordLatency(aws.ToString(options.BaseEndpoint)), conditionalWrites)
+		})
+	}
+	return &ConnectionPool{
+		clients: counted,
+		size:    len(clients),
+		created: time.Now(),
+	}
+}
+
+// loadOptions returns the SDK config options of a client. Credentials come from,
+// in order: the credentials provider, explicit keys, the named profile, and
+// otherwise the default chain (environment variables, IAM role, etc.).
+func loadOptions(cfg ConnectionPoolConfig, region string, httpClient *http.Client) []func(*config.LoadOptions) error {
+	configOptions := []func(*config.LoadOptions) error{
+		config.WithRegion(region),
+		config.WithRetryMaxAttempts(cfg.MaxRetries),
+		config.WithRetryMode(aws.RetryModeAdaptive), // Use adaptive retry mode for better rate limit handling
+	}
+
+	switch {
+	case cfg.CredentialsProvider != nil:
+		configOptions = append(configOptions, config.WithCredentialsProvider(cfg.CredentialsProvider))
+	case cfg.AccessKey != "" && cfg.SecretKey != "":
+		// Use explicit credentials for custom S3 providers
+		configOptions = append(configOptions, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			cfg.AccessKey,
+			cfg.SecretKey,
+			"", // session token (empty for static credentials)
+		)))
+	case cfg.Profile != "":
+		configOptions = append(configOptions, config.WithSharedConfigProfile(cfg.Profile))
+	}
+
+	if httpClient != nil {
+		configOptions = append(configOptions, config.WithHTTPClient(httpClient))
+	}
+	return configOptions
+}
+
 func (cp *ConnectionPool) createClient(ctx context.Context, cfg ConnectionPoolConfig) (*s3.Client, error) {
 	var awsCfg aws.Config
 	var err error
-	
+
 	// For S3-compatible storage with custom endpoint and no region, use a dummy region
 	// AWS SDK requires a region for signature calculation, but S3-compatible storage ignores it
 	region := cfg.Region
 	if region == "" && cfg.EndpointURL != "" {
 		region = "us-east-1" // Dummy region for S3-compatible storage
 	}
-	
+
 	// For S3-compatible storage, use a custom HTTP client that doesn't follow redirects
 	var httpClient *http.Client
 	if cfg.EndpointURL != "" {
@@ -98,40 +154,17 @@ func (cp *ConnectionPool) createClient(ctx context.Context, cfg ConnectionPoolCo
 			},
 		}
 	}
-	
-	if cfg.AccessKey != "" && cfg.SecretKey != "" {
-		// Use explicit credentials for custom S3 providers
-		configOptions := []func(*config.LoadOptions) error{
-			config.WithRegion(region),
-			config.WithRetryMaxAttempts(cfg.MaxRetries),
-			config.WithRetryMode(aws.RetryModeAdaptive), // Use adaptive retry mode for better rate limit handling
-			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
-				cfg.AccessKey,
-				cfg.SecretKey,
-				"", // session token (empty for static credentials)
-			)),
-		}
-		
-		if httpClient != nil {
-			configOptions = append(configOptions, config.WithHTTPClient(httpClient))
-		}
-		
-		awsCfg, err = config.LoadDefaultConfig(ctx, configOptions...)
-	} else {
-		// Use default credential chain (environment variables, IAM role, etc.)
-		configOptions := []func(*config.LoadOptions) error{
-			config.WithRegion(region),
-			config.WithRetryMaxAttempts(cfg.MaxRetries),
-			config.WithRetryMode(aws.RetryModeAdaptive), // Use adaptive retry mode for better rate limit handling
-		}
-		
-		if httpClient != nil {
-			configOptions = append(configOptions, config.WithHTTPClient(httpClient))
-		}
-		
-		awsCfg, err = config.LoadDefaultConfig(ctx, configOptions...)
+
+	dial := cfg.Dial
+	if dial == nil {
+		dial = dialOptionsFromEnv()
+	}
+	if dial.enabled() {
+		httpClient = dial.httpClient(httpClient)
 	}
-	
+
+	awsCfg, err = config.LoadDefaultConfig(ctx, loadOptions(cfg, region, httpClient)...)
+
 	if err != nil {
 		return nil, err
 	}
@@ -139,6 +172,7 @@ func (cp *ConnectionPool) createClient(ctx context.Context, cfg ConnectionPoolCo
 	clientOptions := []func(*s3.Options){
 		func(o *s3.Options) {
 			o.RetryMaxAttempts = cfg.MaxRetries
+			o.APIOptions = append(o.APIOptions, countAttempts, countRequests, recordLatency(cfg.EndpointURL), conditionalWrites)
 		},
 	}
 
@@ -151,7 +185,7 @@ func (cp *ConnectionPool) createClient(ctx context.Context, cfg ConnectionPoolCo
 				Source:            aws.EndpointSourceCustom,
 			}, nil
 		})
-		
+
 		clientOptions = append(clientOptions, func(o *s3.Options) {
 			o.BaseEndpoint = aws.String(cfg.EndpointURL)
 			o.EndpointResolver = customResolver
@@ -169,6 +203,11 @@ func (cp *ConnectionPool) createClient(ctx context.Context, cfg ConnectionPoolCo
 		fmt.Printf("S3 Client Config: AWS Default, Region=%s\n", region)
 	}
 
+	// No-op unless built with -tags chaos
+	clientOptions = append(clientOptions, func(o *s3.Options) {
+		o.HTTPClient = injectFaults(o.HTTPClient)
+	})
+
 	return s3.NewFromConfig(awsCfg, clientOptions...), nil
 }
 
@@ -199,6 +238,11 @@ func (cp *ConnectionPool) GetClientByKey(key string) *s3.Client {
 	return cp.clients[int(hash)%cp.size]
 }
 
+// RecordRequest counts a request made with a pooled client, for statistics
+func (cp *ConnectionPool) RecordRequest() {
+	cp.requests.Add(1)
+}
+
 // RecordError records an error for statistics
 func (cp *ConnectionPool) RecordError() {
 	cp.errors.Add(1)
@@ -0,0 +1,56 @@
+package pool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+func TestLoadOptionsCredentials(t *testing.T) {
+	provider := aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		return aws.Credentials{AccessKeyID: "FROM-PROVIDER"}, nil
+	})
+	tests := []struct {
+		name        string
+		cfg         ConnectionPoolConfig
+		wantProfile string
+		wantKey     string // Access key of the explicit provider, "" when none is set
+	}{
+		{name: "default chain", cfg: ConnectionPoolConfig{}},
+		{name: "profile only", cfg: ConnectionPoolConfig{Profile: "backup"}, wantProfile: "backup"},
+		{name: "explicit keys", cfg: ConnectionPoolConfig{AccessKey: "AKIA", SecretKey: "secret"}, wantKey: "AKIA"},
+		{name: "keys win over profile", cfg: ConnectionPoolConfig{AccessKey: "AKIA", SecretKey: "secret", Profile: "backup"}, wantKey: "AKIA"},
+		{name: "provider wins", cfg: ConnectionPoolConfig{CredentialsProvider: provider, AccessKey: "AKIA", SecretKey: "secret"}, wantKey: "FROM-PROVIDER"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var options config.LoadOptions
+			for _, apply := range loadOptions(tt.cfg, "us-east-1", nil) {
+				if err := apply(&options); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if options.SharedConfigProfile != tt.wantProfile {
+				t.Errorf("profile = %q, want %q", options.SharedConfigProfile, tt.wantProfile)
+			}
+			if tt.wantKey == "" {
+				if options.Credentials != nil {
+					t.Errorf("explicit credentials set, want the %s", tt.name)
+				}
+				return
+			}
+			if options.Credentials == nil {
+				t.Fatal("no explicit credentials set")
+			}
+			creds, err := options.Credentials.Retrieve(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if creds.AccessKeyID != tt.wantKey {
+				t.Errorf("access key = %q, want %q", creds.AccessKeyID, tt.wantKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+// DialOptions control how the clients of a pool resolve and connect to endpoints,
+// for networks where the system resolver does not know the S3 endpoints or only
+// IPv6 routes exist
+type DialOptions struct {
+	Hosts      map[string]string // Host name → IP address, used instead of DNS (like /etc/hosts)
+	DNSServer  string            // Resolver "host:port" (port 53 when omitted) used instead of the system's
+	PreferIPv6 bool              // Connect to IPv6 addresses first, IPv4 only when none answers
+	IPv6Only   bool              // Never connect over IPv4
+}
+
+// enabled reports whether the options change anything from the default dialer
+func (o *DialOptions) enabled() bool {
+	return o != nil && (len(o.Hosts) > 0 || o.DNSServer != "" || o.PreferIPv6 || o.IPv6Only)
+}
+
+var (
+	envDialOptions     *DialOptions
+	envDialOptionsOnce sync.Once
+)
+
+// dialOptionsFromEnv reads S3_HOSTS ("host=ip,host=ip"), S3_DNS_SERVER,
+// S3_PREFER_IPV6 and S3_IPV6_ONLY once; malformed values are reported and ignored
+func dialOptionsFromEnv() *DialOptions {
+	envDialOptionsOnce.Do(func() {
+		opts := &DialOptions{Hosts: parseHosts(os.Getenv("S3_HOSTS")), DNSServer: os.Getenv("S3_DNS_SERVER")}
+		opts.PreferIPv6, _ = strconv.ParseBool(os.Getenv("S3_PREFER_IPV6"))
+		opts.IPv6Only, _ = strconv.ParseBool(os.Getenv("S3_IPV6_ONLY"))
+		envDialOptions = opts
+	})
+	return envDialOptions
+}
+
+// parseHosts parses "host=ip,host=ip"; malformed entries are reported and skipped
+func parseHosts(value string) map[string]string {
+	var hosts map[string]string
+	for _, entry := range strings.Split(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		host, ip, ok := strings.Cut(entry, "=")
+		host, ip = strings.ToLower(strings.TrimSpace(host)), strings.TrimSpace(ip)
+		if !ok || host == "" || net.ParseIP(ip) == nil {
+			fmt.Printf("⚠️  Ignoring S3_HOSTS entry %q (expected host=ip)\n", entry)
+			continue
+		}
+		if hosts == nil {
+			hosts = make(map[string]string)
+		}
+		hosts[host] = ip
+	}
+	return hosts
+}
+
+// resolver returns the resolver of the options: the system's, or one querying DNSServer
+func (o *DialOptions) resolver(dialer *net.Dialer) *net.Resolver {
+	if o.DNSServer == "" {
+		return net.DefaultResolver
+	}
+	server := o.DNSServer
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// lookup returns the addresses to try for host, in the order to try them
+func (o *DialOptions) lookup(ctx context.Context, resolver *net.Resolver, host string) ([]net.IP, error) {
+	var ips []net.IP
+	if mapped, ok := o.Hosts[strings.ToLower(host)]; ok {
+		ips = []net.IP{net.ParseIP(mapped)}
+	} else if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+	return o.order(host, ips)
+}
+
+// order returns the addresses of host in the order to try them: IPv6 first (or
+// only) when requested, otherwise as resolved
+func (o *DialOptions) order(host string, ips []net.IP) ([]net.IP, error) {
+	if !o.PreferIPv6 && !o.IPv6Only {
+		return ips, nil
+	}
+	var v6, v4 []net.IP
+	for _, ip := range ips {
+		if ip.To4() == nil {
+			v6 = append(v6, ip)
+		} else {
+			v4 = append(v4, ip)
+		}
+	}
+	if o.IPv6Only {
+		if len(v6) == 0 {
+			return nil, fmt.Errorf("no IPv6 address for %s", host)
+		}
+		return v6, nil
+	}
+	return append(v6, v4...), nil
+}
+
+// dialContext returns a DialContext resolving with the options, trying each
+// address in turn
+func (o *DialOptions) dialContext() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	resolver := o.resolver(dialer)
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := o.lookup(ctx, resolver, host)
+		if err != nil {
+			return nil, err
+		}
+		if o.IPv6Only {
+			network = "tcp6"
+		}
+
+		var lastErr error
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
+
+// httpClient returns the HTTP client of a pool's clients with the dial options
+// applied; base is the client otherwise used (nil: the SDK default)
+func (o *DialOptions) httpClient(base *http.Client) *http.Client {
+	if base == nil {
+		transport := awshttp.NewBuildableClient().GetTransport()
+		transport.DialContext = o.dialContext()
+		return &http.Client{Transport: transport}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = o.dialContext()
+	client := *base
+	client.Transport = transport
+	return &client
+}
@@ -0,0 +1,134 @@
+package pool
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHosts(t *testing.T) {
+	got := parseHosts(" S3.Onprem.Local = 10.0.0.5 , minio=fd00::1,broken,bad=not-an-ip,")
+	want := map[string]string{"s3.onprem.local": "10.0.0.5", "minio": "fd00::1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("hosts = %v, want %v", got, want)
+	}
+	if parseHosts("") != nil {
+		t.Fatal("empty value gave hosts")
+	}
+}
+
+func TestDialOptionsOrder(t *testing.T) {
+	ips := []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("fd00::1"), net.ParseIP("10.0.0.2"), net.ParseIP("fd00::2")}
+	tests := []struct {
+		name    string
+		opts    DialOptions
+		want    string
+		wantErr bool
+	}{
+		{name: "as resolved", want: "10.0.0.1 fd00::1 10.0.0.2 fd00::2"},
+		{name: "prefer IPv6", opts: DialOptions{PreferIPv6: true}, want: "fd00::1 fd00::2 10.0.0.1 10.0.0.2"},
+		{name: "IPv6 only", opts: DialOptions{IPv6Only: true}, want: "fd00::1 fd00::2"},
+	}
+	for _, tt := range tests {
+		got, err := tt.opts.order("s3.local", ips)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var names []string
+		for _, ip := range got {
+			names = append(names, ip.String())
+		}
+		if strings.Join(names, " ") != tt.want {
+			t.Errorf("%s: order = %v, want %s", tt.name, names, tt.want)
+		}
+	}
+
+	opts := DialOptions{IPv6Only: true}
+	if _, err := opts.order("s3.local", ips[:1]); err == nil {
+		t.Fatal("IPv6-only dial accepted an IPv4-only host")
+	}
+}
+
+// get fetches url through a client with the dial options and returns the body
+func get(t *testing.T, opts *DialOptions, url string) string {
+	t.Helper()
+	resp, err := opts.httpClient(&http.Client{}).Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestDialOptionsStaticHosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("host " + r.Host))
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	opts := &DialOptions{Hosts: map[string]string{"s3.onprem.invalid": "127.0.0.1"}}
+	if got := get(t, opts, "http://S3.onprem.invalid:"+port+"/bucket"); got != "host S3.onprem.invalid:"+port {
+		t.Fatalf("response %q", got)
+	}
+}
+
+func TestDialOptionsDNSServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	dns, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dns.Close()
+	go serveDNS(dns, net.IPv4(127, 0, 0, 1))
+
+	opts := &DialOptions{DNSServer: dns.LocalAddr().String()}
+	if got := get(t, opts, "http://s3.onprem.invalid:"+port+"/"); got != "ok" {
+		t.Fatalf("response %q", got)
+	}
+}
+
+// serveDNS answers every A query with ip and every other query with no records
+func serveDNS(conn net.PacketConn, ip net.IP) {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		query := buf[:n]
+		end := 12
+		for end < n && query[end] != 0 {
+			end += int(query[end]) + 1
+		}
+		end += 5 // Root label, type and class
+		if end > n {
+			continue
+		}
+		qtype := binary.BigEndian.Uint16(query[end-4:])
+
+		resp := append([]byte(nil), query[:end]...)
+		binary.BigEndian.PutUint16(resp[2:], 0x8180) // Response, recursion available, no error
+		binary.BigEndian.PutUint16(resp[8:], 0)      // No authority records
+		binary.BigEndian.PutUint16(resp[10:], 0)     // No additional records
+		if qtype == 1 {
+			binary.BigEndian.PutUint16(resp[6:], 1)
+			resp = append(resp, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4)
+			resp = append(resp, ip.To4()...)
+		} else {
+			binary.BigEndian.PutUint16(resp[6:], 0)
+		}
+		conn.WriteTo(resp, addr)
+	}
+}
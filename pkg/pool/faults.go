@@ -0,0 +1,10 @@
+//go:build !chaos
+
+package pool
+
+import "github.com/aws/aws-sdk-go-v2/service/s3"
+
+// injectFaults returns client unchanged; fault injection exists only in builds with -tags chaos
+func injectFaults(client s3.HTTPClient) s3.HTTPClient {
+	return client
+}
@@ -0,0 +1,174 @@
+//go:build chaos
+
+package pool
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// FaultConfig sets how often each injected fault happens (rates are 0..1 per request)
+type FaultConfig struct {
+	ErrorRate     float64       // Requests answered with a 500 InternalError without reaching the endpoint
+	SlowReadRate  float64       // Response bodies read in small, delayed chunks
+	SlowReadDelay time.Duration // Delay before each chunk of a slow body
+	TruncateRate  float64       // Response bodies cut off part way with io.ErrUnexpectedEOF
+	Seed          int64         // Random seed, for reproducible runs
+}
+
+// faultConfigFromEnv reads CHAOS_ERROR_RATE, CHAOS_SLOW_READ_RATE, CHAOS_SLOW_READ_DELAY
+// (e.g. "50ms"), CHAOS_TRUNCATE_RATE and CHAOS_SEED
+func faultConfigFromEnv() FaultConfig {
+	cfg := FaultConfig{SlowReadDelay: 50 * time.Millisecond, Seed: time.Now().UnixNano()}
+	rate := func(name string) float64 {
+		v, err := strconv.ParseFloat(os.Getenv(name), 64)
+		if err != nil || v < 0 {
+			return 0
+		}
+		if v > 1 {
+			return 1
+		}
+		return v
+	}
+	cfg.ErrorRate = rate("CHAOS_ERROR_RATE")
+	cfg.SlowReadRate = rate("CHAOS_SLOW_READ_RATE")
+	cfg.TruncateRate = rate("CHAOS_TRUNCATE_RATE")
+	if d, err := time.ParseDuration(os.Getenv("CHAOS_SLOW_READ_DELAY")); err == nil && d > 0 {
+		cfg.SlowReadDelay = d
+	}
+	if seed, err := strconv.ParseInt(os.Getenv("CHAOS_SEED"), 10, 64); err == nil {
+		cfg.Seed = seed
+	}
+	return cfg
+}
+
+var (
+	faultsOnce   sync.Once
+	sharedFaults *faultInjector
+)
+
+// injectFaults wraps client with the fault injector configured from the environment.
+// All clients share one injector so the configured rates hold across the process.
+func injectFaults(client s3.HTTPClient) s3.HTTPClient {
+	faultsOnce.Do(func() {
+		cfg := faultConfigFromEnv()
+		sharedFaults = newFaultInjector(cfg)
+		fmt.Printf("⚠️  Fault injection enabled: errors=%.2f slow_reads=%.2f (%v) truncations=%.2f seed=%d\n",
+			cfg.ErrorRate, cfg.SlowReadRate, cfg.SlowReadDelay, cfg.TruncateRate, cfg.Seed)
+	})
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &faultyClient{next: client, faults: sharedFaults}
+}
+
+// faultInjector decides which requests fail
+type faultInjector struct {
+	cfg  FaultConfig
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newFaultInjector(cfg FaultConfig) *faultInjector {
+	return &faultInjector{cfg: cfg, rand: rand.New(rand.NewSource(cfg.Seed))}
+}
+
+// roll reports whether a fault with the given rate happens
+func (f *faultInjector) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rand.Float64() < rate
+}
+
+// fraction returns a random value in [0, 1)
+func (f *faultInjector) fraction() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rand.Float64()
+}
+
+// faultyClient is an S3 HTTP client that injects faults into requests and responses
+type faultyClient struct {
+	next   s3.HTTPClient
+	faults *faultInjector
+}
+
+func (c *faultyClient) Do(req *http.Request) (*http.Response, error) {
+	if c.faults.roll(c.faults.cfg.ErrorRate) {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		body := `<?xml version="1.0" encoding="UTF-8"?><Error><Code>InternalError</Code><Message>Injected fault</Message></Error>`
+		return &http.Response{
+			Status:        "500 Internal Server Error",
+			StatusCode:    http.StatusInternalServerError,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"application/xml"}},
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := c.next.Do(req)
+	if err != nil || resp.StatusCode >= 300 || resp.Body == nil {
+		return resp, err
+	}
+	if c.faults.roll(c.faults.cfg.TruncateRate) && resp.ContentLength > 0 {
+		limit := int64(c.faults.fraction() * float64(resp.ContentLength))
+		resp.Body = &truncatedBody{ReadCloser: resp.Body, remaining: limit}
+	}
+	if c.faults.roll(c.faults.cfg.SlowReadRate) {
+		resp.Body = &slowBody{ReadCloser: resp.Body, delay: c.faults.cfg.SlowReadDelay}
+	}
+	return resp, nil
+}
+
+// slowBodyChunk is the most a slow body returns per read
+const slowBodyChunk = 16 * 1024
+
+// slowBody returns small chunks after a delay each, like a congested link
+type slowBody struct {
+	io.ReadCloser
+	delay time.Duration
+}
+
+func (b *slowBody) Read(p []byte) (int, error) {
+	time.Sleep(b.delay)
+	if len(p) > slowBodyChunk {
+		p = p[:slowBodyChunk]
+	}
+	return b.ReadCloser.Read(p)
+}
+
+// truncatedBody ends the stream early, as a dropped connection would
+type truncatedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
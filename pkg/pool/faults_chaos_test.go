@@ -0,0 +1,62 @@
+//go:build chaos
+
+package pool
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFaultyClient(t *testing.T) {
+	payload := strings.Repeat("x", 64*1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+		io.WriteString(w, payload)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		cfg        FaultConfig
+		wantStatus int
+		wantErr    error
+		minElapsed time.Duration
+	}{
+		{name: "no faults", cfg: FaultConfig{}, wantStatus: http.StatusOK},
+		{name: "error", cfg: FaultConfig{ErrorRate: 1}, wantStatus: http.StatusInternalServerError},
+		{name: "truncate", cfg: FaultConfig{TruncateRate: 1}, wantStatus: http.StatusOK, wantErr: io.ErrUnexpectedEOF},
+		{name: "slow read", cfg: FaultConfig{SlowReadRate: 1, SlowReadDelay: 10 * time.Millisecond}, wantStatus: http.StatusOK, minElapsed: 40 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &faultyClient{next: server.Client(), faults: newFaultInjector(tt.cfg)}
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+			started := time.Now()
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("read error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantStatus == http.StatusOK && tt.wantErr == nil && string(body) != payload {
+				t.Fatalf("read %d bytes, want %d", len(body), len(payload))
+			}
+			if elapsed := time.Since(started); elapsed < tt.minElapsed {
+				t.Fatalf("read took %v, want at least %v", elapsed, tt.minElapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package pool
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+
+	"s3migration/pkg/models"
+)
+
+// latencyBounds are the upper bounds of the histogram buckets: from 1ms, each
+// half as wide again as the one before, up to 5 minutes
+var latencyBounds = func() []time.Duration {
+	var bounds []time.Duration
+	for bound := time.Millisecond; bound < 5*time.Minute; bound = bound * 3 / 2 {
+		bounds = append(bounds, bound)
+	}
+	return append(bounds, 5*time.Minute)
+}()
+
+// latencyHistogram counts request latencies in latencyBounds buckets
+type latencyHistogram struct {
+	buckets []int64 // One more than latencyBounds: the last counts what is above them
+	count   int64
+	max     time.Duration
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	if h.buckets == nil {
+		h.buckets = make([]int64, len(latencyBounds)+1)
+	}
+	h.buckets[sort.Search(len(latencyBounds), func(i int) bool { return d <= latencyBounds[i] })]++
+	h.count++
+	h.max = max(h.max, d)
+}
+
+// percentile returns the upper bound of the bucket holding the q quantile,
+// capped at the largest latency seen
+func (h *latencyHistogram) percentile(q float64) time.Duration {
+	rank := int64(math.Ceil(q * float64(h.count)))
+	var seen int64
+	for i, count := range h.buckets {
+		if seen += count; seen >= rank && i < len(latencyBounds) {
+			return min(latencyBounds[i], h.max)
+		}
+	}
+	return h.max
+}
+
+type latencyKey struct {
+	endpoint  string
+	operation string
+}
+
+// LatencyRecorder keeps a latency histogram per endpoint and S3 operation
+type LatencyRecorder struct {
+	mu         sync.Mutex
+	histograms map[latencyKey]*latencyHistogram
+}
+
+func (r *LatencyRecorder) observe(endpoint, operation string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.histograms == nil {
+		r.histograms = make(map[latencyKey]*latencyHistogram)
+	}
+	key := latencyKey{endpoint: endpoint, operation: operation}
+	if r.histograms[key] == nil {
+		r.histograms[key] = &latencyHistogram{}
+	}
+	r.histograms[key].observe(d)
+}
+
+// Latencies returns the percentiles recorded so far, by endpoint and operation
+func (r *LatencyRecorder) Latencies() []models.OperationLatency {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	latencies := make([]models.OperationLatency, 0, len(r.histograms))
+	for key, h := range r.histograms {
+		latencies = append(latencies, models.OperationLatency{
+			Endpoint:  key.endpoint,
+			Operation: key.operation,
+			Count:     h.count,
+			P50Ms:     milliseconds(h.percentile(0.50)),
+			P95Ms:     milliseconds(h.percentile(0.95)),
+			P99Ms:     milliseconds(h.percentile(0.99)),
+			MaxMs:     milliseconds(h.max),
+		})
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		if latencies[i].Endpoint != latencies[j].Endpoint {
+			return latencies[i].Endpoint < latencies[j].Endpoint
+		}
+		return latencies[i].Operation < latencies[j].Operation
+	})
+	return latencies
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
+
+// processLatency records the requests of every pooled client of the process
+var processLatency LatencyRecorder
+
+// ProcessLatencies returns the latency percentiles of all S3 requests made by the process
+func ProcessLatencies() []models.OperationLatency {
+	return processLatency.Latencies()
+}
+
+// latencyEndpoint names the endpoint of a client in latency reports
+func latencyEndpoint(endpointURL string) string {
+	if endpointURL == "" {
+		return "aws"
+	}
+	return endpointURL
+}
+
+// recordLatency times each successful attempt after the retry middleware, into the
+// process recorder and the request counter of the context. The SDK returns once
+// the response headers arrive, so GetObject is timed to its first byte, not
+// until its body is read; writes are timed until the endpoint acknowledges them.
+func recordLatency(endpointURL string) func(*middleware.Stack) error {
+	endpoint := latencyEndpoint(endpointURL)
+	return func(stack *middleware.Stack) error {
+		return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("RecordLatency",
+			func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+				start := time.Now()
+				out, metadata, err := next.HandleFinalize(ctx, in)
+				if err == nil {
+					operation := awsmiddleware.GetOperationName(ctx)
+					elapsed := time.Since(start)
+					processLatency.observe(endpoint, operation, elapsed)
+					if counter, ok := ctx.Value(requestCounterKey{}).(*RequestCounter); ok {
+						counter.latency.observe(endpoint, operation, elapsed)
+					}
+				}
+				return out, metadata, err
+			}), middleware.After)
+	}
+}
@@ -0,0 +1,57 @@
+package pool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogramPercentiles(t *testing.T) {
+	h := &latencyHistogram{}
+	for i := 0; i < 90; i++ {
+		h.observe(10 * time.Millisecond)
+	}
+	for i := 0; i < 9; i++ {
+		h.observe(200 * time.Millisecond)
+	}
+	h.observe(3 * time.Second)
+
+	tests := []struct {
+		q        float64
+		min, max time.Duration
+	}{
+		{q: 0.50, min: 10 * time.Millisecond, max: 15 * time.Millisecond},
+		{q: 0.95, min: 200 * time.Millisecond, max: 300 * time.Millisecond},
+		{q: 0.99, min: 200 * time.Millisecond, max: 300 * time.Millisecond},
+		{q: 1, min: 3 * time.Second, max: 3 * time.Second}, // Capped at the largest latency seen
+	}
+	for _, tt := range tests {
+		if got := h.percentile(tt.q); got < tt.min || got > tt.max {
+			t.Errorf("percentile(%v) = %v, want %v to %v", tt.q, got, tt.min, tt.max)
+		}
+	}
+
+	// Above the last bound, the largest latency seen is reported
+	h.observe(10 * time.Minute)
+	if got := h.percentile(1); got != 10*time.Minute {
+		t.Errorf("percentile(1) = %v", got)
+	}
+}
+
+func TestLatencyRecorder(t *testing.T) {
+	var recorder LatencyRecorder
+	recorder.observe("aws", "PutObject", 40*time.Millisecond)
+	recorder.observe("https://minio.internal", "GetObject", 5*time.Millisecond)
+	recorder.observe("aws", "GetObject", 20*time.Millisecond)
+	recorder.observe("aws", "GetObject", 30*time.Millisecond)
+
+	latencies := recorder.Latencies()
+	if len(latencies) != 3 || latencies[0].Operation != "GetObject" || latencies[1].Operation != "PutObject" || latencies[2].Endpoint != "https://minio.internal" {
+		t.Fatalf("latencies = %+v", latencies)
+	}
+	if get := latencies[0]; get.Count != 2 || get.MaxMs != 30 || get.P50Ms < 20 || get.P50Ms > get.P99Ms {
+		t.Errorf("aws GetObject = %+v", get)
+	}
+	if latencyEndpoint("") != "aws" {
+		t.Error("empty endpoint not named aws")
+	}
+}
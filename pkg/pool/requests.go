@@ -0,0 +1,67 @@
+package pool
+
+import (
+	"context"
+	"sync"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+
+	"s3migration/pkg/models"
+)
+
+// RequestCounter counts the S3 requests made under a context by operation name,
+// retries included, so the requests of a task can be attributed and priced. It
+// also records their latencies.
+type RequestCounter struct {
+	mu          sync.Mutex
+	byOperation map[string]int64
+	latency     LatencyRecorder
+}
+
+// Counts returns the requests counted so far by operation name (e.g. "ListObjectsV2")
+func (c *RequestCounter) Counts() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[string]int64, len(c.byOperation))
+	for operation, count := range c.byOperation {
+		counts[operation] = count
+	}
+	return counts
+}
+
+// Latencies returns the latency percentiles of the requests, by endpoint and operation
+func (c *RequestCounter) Latencies() []models.OperationLatency {
+	return c.latency.Latencies()
+}
+
+func (c *RequestCounter) add(operation string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.byOperation == nil {
+		c.byOperation = make(map[string]int64)
+	}
+	c.byOperation[operation]++
+}
+
+type requestCounterKey struct{}
+
+// CountRequests returns a context whose S3 requests, made with clients of a
+// connection pool, are added to counter
+func CountRequests(ctx context.Context, counter *RequestCounter) context.Context {
+	return context.WithValue(ctx, requestCounterKey{}, counter)
+}
+
+// countRequests counts each attempt after the retry middleware, under the name
+// of its operation
+func countRequests(stack *middleware.Stack) error {
+	return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("CountRequests",
+		func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			if counter, ok := ctx.Value(requestCounterKey{}).(*RequestCounter); ok {
+				counter.add(awsmiddleware.GetOperationName(ctx))
+			}
+			return next.HandleFinalize(ctx, in)
+		}), middleware.After)
+}
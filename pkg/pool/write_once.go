@@ -0,0 +1,49 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// writeOnceOperations are the writes that create an object under its key
+var writeOnceOperations = map[string]bool{
+	"PutObject":               true,
+	"CopyObject":              true,
+	"CompleteMultipartUpload": true,
+}
+
+type writeOnceKey struct{}
+
+// WriteOnce returns a context whose object writes (PutObject, CopyObject and
+// CompleteMultipartUpload), made with clients of a connection pool, are sent
+// with If-None-Match: *. An endpoint honouring conditional writes refuses them
+// with 412 Precondition Failed when the key already exists, in the same request
+// that would have replaced it.
+func WriteOnce(ctx context.Context) context.Context {
+	return context.WithValue(ctx, writeOnceKey{}, true)
+}
+
+// IsPreconditionFailed reports whether err is an endpoint refusing a conditional write
+func IsPreconditionFailed(err error) bool {
+	var response interface{ HTTPStatusCode() int }
+	return errors.As(err, &response) && response.HTTPStatusCode() == http.StatusPreconditionFailed
+}
+
+// conditionalWrites adds If-None-Match: * to the object writes of a WriteOnce
+// context, before the request is signed
+func conditionalWrites(stack *middleware.Stack) error {
+	return stack.Build.Add(middleware.BuildMiddlewareFunc("ConditionalWrites",
+		func(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+			if writeOnce, _ := ctx.Value(writeOnceKey{}).(bool); writeOnce && writeOnceOperations[awsmiddleware.GetOperationName(ctx)] {
+				if request, ok := in.Request.(*smithyhttp.Request); ok {
+					request.Header.Set("If-None-Match", "*")
+				}
+			}
+			return next.HandleBuild(ctx, in)
+		}), middleware.After)
+}
@@ -0,0 +1,148 @@
+package prefetch
+
+import (
+	"strings"
+	"time"
+)
+
+// Listing is a cached listing of every object under a bucket/prefix
+type Listing struct {
+	Scope      string           `json:"scope"`
+	Bucket     string           `json:"bucket"`
+	Prefix     string           `json:"prefix"`
+	Generation int64            `json:"generation"` // Bumped by invalidation; older copies are stale
+	Objects    []ObjectMetadata `json:"objects,omitempty"`
+	CachedAt   time.Time        `json:"cached_at"`
+}
+
+// ListingStore persists listings so they survive restarts and are shared between replicas
+type ListingStore interface {
+	// LoadListing returns the stored listing, or nil if there is none
+	LoadListing(scope string) (*Listing, error)
+	// SaveListing stores a listing unless the scope was invalidated since its generation
+	SaveListing(listing *Listing) error
+	// InvalidateListing drops the listing and bumps the scope's generation
+	InvalidateListing(scope string) (int64, error)
+	// ListingGeneration returns the scope's current generation (0 if never stored)
+	ListingGeneration(scope string) (int64, error)
+}
+
+// ListingScope builds the cache key for a listing of bucket/prefix at an endpoint
+// ("" for AWS)
+func ListingScope(endpointURL, bucket, prefix string) string {
+	return strings.TrimRight(endpointURL, "/") + "|" + bucket + "|" + prefix
+}
+
+// SetStore adds a persistent layer behind the in-memory listings
+func (mc *MetadataCache) SetStore(store ListingStore) {
+	mc.mu.Lock()
+	mc.store = store
+	mc.mu.Unlock()
+}
+
+// SetListingTTL sets how long a listing is trusted before it is listed again
+// (0 = listings are never reused)
+func (mc *MetadataCache) SetListingTTL(ttl time.Duration) {
+	mc.mu.Lock()
+	mc.listingTTL = ttl
+	mc.mu.Unlock()
+}
+
+// GetListing returns a fresh cached listing for scope, from memory or the store.
+// A memory copy is used only while its generation matches the store's.
+func (mc *MetadataCache) GetListing(scope string) (*Listing, bool) {
+	mc.mu.Lock()
+	listing, inMemory := mc.listings[scope]
+	store, ttl := mc.store, mc.listingTTL
+	mc.mu.Unlock()
+
+	if store != nil {
+		generation, err := store.ListingGeneration(scope)
+		if err != nil {
+			return nil, false
+		}
+		if inMemory && listing.Generation != generation {
+			inMemory = false
+		}
+		if !inMemory {
+			stored, err := store.LoadListing(scope)
+			if err != nil || stored == nil || stored.Generation != generation {
+				mc.recordListingMiss()
+				return nil, false
+			}
+			listing = stored
+			mc.mu.Lock()
+			mc.listings[scope] = listing
+			mc.mu.Unlock()
+		}
+	} else if !inMemory {
+		mc.recordListingMiss()
+		return nil, false
+	}
+
+	if ttl <= 0 || time.Since(listing.CachedAt) > ttl {
+		mc.recordListingMiss()
+		return nil, false
+	}
+
+	mc.mu.Lock()
+	mc.listingHits++
+	mc.mu.Unlock()
+	return listing, true
+}
+
+// ListingGeneration returns the scope's current generation. Read it before
+// listing and store it in Listing.Generation, so an invalidation that happens
+// while listing makes PutListing discard the result.
+func (mc *MetadataCache) ListingGeneration(scope string) (int64, error) {
+	mc.mu.Lock()
+	store, generation := mc.store, mc.generations[scope]
+	mc.mu.Unlock()
+
+	if store != nil {
+		return store.ListingGeneration(scope)
+	}
+	return generation, nil
+}
+
+// PutListing caches a complete listing taken at listing.Generation
+func (mc *MetadataCache) PutListing(listing *Listing) error {
+	if listing.CachedAt.IsZero() {
+		listing.CachedAt = time.Now()
+	}
+
+	mc.mu.Lock()
+	store := mc.store
+	if store == nil && listing.Generation != mc.generations[listing.Scope] {
+		mc.mu.Unlock()
+		return nil // Invalidated while listing
+	}
+	mc.listings[listing.Scope] = listing
+	mc.mu.Unlock()
+
+	if store != nil {
+		return store.SaveListing(listing)
+	}
+	return nil
+}
+
+// InvalidateListing forgets the listing of scope everywhere, so the next run lists again
+func (mc *MetadataCache) InvalidateListing(scope string) error {
+	mc.mu.Lock()
+	delete(mc.listings, scope)
+	mc.generations[scope]++
+	store := mc.store
+	mc.mu.Unlock()
+
+	if store != nil {
+		_, err := store.InvalidateListing(scope)
+		return err
+	}
+	return nil
+}
+
+func (mc *MetadataCache) recordListingMiss() {
+	mc.mu.Lock()
+	mc.listingMisses++
+	mc.mu.Unlock()
+}
@@ -0,0 +1,33 @@
+package prefetch
+
+import "time"
+
+// ListingCheckpoint is how far the source listing of a running task got. The
+// objects listed so far are stored in parts, like a task listing, and Marker is
+// where the listing continues, so a task interrupted while listing a large
+// bucket resumes from there instead of listing it again from the start.
+type ListingCheckpoint struct {
+	TaskID    string    `json:"task_id"`
+	Endpoint  string    `json:"endpoint,omitempty"` // Source endpoint ("" for AWS)
+	Bucket    string    `json:"bucket"`
+	Prefix    string    `json:"prefix"`
+	Marker    string    `json:"marker"`
+	Parts     int       `json:"parts"`
+	Objects   int64     `json:"objects"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListingCheckpointStore keeps the listing checkpoints of tasks
+type ListingCheckpointStore interface {
+	// SaveListingCheckpointPart stores part (from 0) of the objects listed so
+	// far; part 0 drops an earlier checkpoint of the task
+	SaveListingCheckpointPart(taskID string, part int, objects []ObjectMetadata) error
+	// SaveListingCheckpoint records the marker reached once its parts are saved
+	SaveListingCheckpoint(checkpoint ListingCheckpoint) error
+	// LoadListingCheckpoint returns the checkpoint of a task, or nil if there is none
+	LoadListingCheckpoint(taskID string) (*ListingCheckpoint, error)
+	// LoadListingCheckpointPart returns one part of a checkpoint
+	LoadListingCheckpointPart(taskID string, part int) ([]ObjectMetadata, error)
+	// DeleteListingCheckpoint drops the checkpoint of a task whose listing completed
+	DeleteListingCheckpoint(taskID string) error
+}
@@ -0,0 +1,114 @@
+package prefetch
+
+import (
+	"testing"
+	"time"
+)
+
+// memoryStore is a ListingStore kept in a map, standing in for the database
+type memoryStore struct {
+	listings    map[string]*Listing
+	generations map[string]int64
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{listings: map[string]*Listing{}, generations: map[string]int64{}}
+}
+
+func (s *memoryStore) LoadListing(scope string) (*Listing, error) { return s.listings[scope], nil }
+
+func (s *memoryStore) SaveListing(listing *Listing) error {
+	if listing.Generation == s.generations[listing.Scope] {
+		s.listings[listing.Scope] = listing
+	}
+	return nil
+}
+
+func (s *memoryStore) InvalidateListing(scope string) (int64, error) {
+	delete(s.listings, scope)
+	s.generations[scope]++
+	return s.generations[scope], nil
+}
+
+func (s *memoryStore) ListingGeneration(scope string) (int64, error) {
+	return s.generations[scope], nil
+}
+
+func TestGetListing(t *testing.T) {
+	const scope = "|dest|data/"
+	tests := []struct {
+		name   string
+		store  bool
+		ttl    time.Duration
+		setup  func(mc *MetadataCache)
+		wantOK bool
+	}{
+		{name: "fresh", ttl: time.Hour, wantOK: true},
+		{name: "fresh in store", store: true, ttl: time.Hour, wantOK: true},
+		{name: "ttl zero never reuses", ttl: 0},
+		{
+			name: "expired",
+			ttl:  time.Minute,
+			setup: func(mc *MetadataCache) {
+				mc.listings[scope].CachedAt = time.Now().Add(-time.Hour)
+			},
+		},
+		{
+			name: "invalidated",
+			ttl:  time.Hour,
+			setup: func(mc *MetadataCache) {
+				mc.InvalidateListing(scope)
+			},
+		},
+		{
+			name:  "invalidated in store by another replica",
+			store: true,
+			ttl:   time.Hour,
+			setup: func(mc *MetadataCache) {
+				mc.store.InvalidateListing(scope)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := NewMetadataCache(time.Minute, 10)
+			mc.SetListingTTL(tt.ttl)
+			if tt.store {
+				mc.SetStore(newMemoryStore())
+			}
+			generation, err := mc.ListingGeneration(scope)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := mc.PutListing(&Listing{Scope: scope, Generation: generation, Objects: []ObjectMetadata{{Key: "data/a", Size: 1}}}); err != nil {
+				t.Fatal(err)
+			}
+			if tt.setup != nil {
+				tt.setup(mc)
+			}
+
+			_, ok := mc.GetListing(scope)
+			if ok != tt.wantOK {
+				t.Fatalf("GetListing() ok = %v, want %v", ok, tt.wantOK)
+			}
+			stats := mc.Stats()
+			if !tt.wantOK && stats.ListingMisses != 1 {
+				t.Fatalf("expected a listing miss so the destination is listed again, got %+v", stats)
+			}
+		})
+	}
+}
+
+func TestPutListingDiscardsListingInvalidatedWhileListing(t *testing.T) {
+	const scope = "|dest|"
+	mc := NewMetadataCache(time.Minute, 10)
+	mc.SetListingTTL(time.Hour)
+
+	generation, _ := mc.ListingGeneration(scope)
+	mc.InvalidateListing(scope) // An object was written while listing
+	mc.PutListing(&Listing{Scope: scope, Generation: generation})
+
+	if _, ok := mc.GetListing(scope); ok {
+		t.Fatal("listing taken before an invalidation must not be reused")
+	}
+}
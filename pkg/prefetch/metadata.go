@@ -28,21 +28,32 @@ type MetadataCache struct {
 	hits      uint64
 	misses    uint64
 	evictions uint64
+
+	// Whole bucket/prefix listings, kept apart from per-object entries so
+	// eviction never leaves a partial listing
+	listings      map[string]*Listing
+	generations   map[string]int64 // Invalidation counts when there is no store
+	listingTTL    time.Duration
+	listingHits   uint64
+	listingMisses uint64
+	store         ListingStore
 }
 
 // NewMetadataCache creates a new metadata cache
 func NewMetadataCache(ttl time.Duration, maxSize int) *MetadataCache {
 	return &MetadataCache{
-		cache:   make(map[string]*ObjectMetadata),
-		ttl:     ttl,
-		maxSize: maxSize,
+		cache:       make(map[string]*ObjectMetadata),
+		ttl:         ttl,
+		maxSize:     maxSize,
+		listings:    make(map[string]*Listing),
+		generations: make(map[string]int64),
 	}
 }
 
 // Get retrieves metadata from cache
 func (mc *MetadataCache) Get(key string) (*ObjectMetadata, bool) {
-	mc.mu.RLock()
-	defer mc.mu.RUnlock()
+	mc.mu.Lock() // Write lock: hit/miss counters are updated
+	defer mc.mu.Unlock()
 
 	metadata, exists := mc.cache[key]
 	if !exists {
@@ -98,16 +109,20 @@ func (mc *MetadataCache) Clear() {
 	defer mc.mu.Unlock()
 
 	mc.cache = make(map[string]*ObjectMetadata)
+	mc.listings = make(map[string]*Listing)
 }
 
 // Stats returns cache statistics
 type CacheStats struct {
-	Size      int
-	MaxSize   int
-	Hits      uint64
-	Misses    uint64
-	Evictions uint64
-	HitRate   float64
+	Size          int
+	MaxSize       int
+	Hits          uint64
+	Misses        uint64
+	Evictions     uint64
+	HitRate       float64
+	Listings      int
+	ListingHits   uint64
+	ListingMisses uint64
 }
 
 func (mc *MetadataCache) Stats() CacheStats {
@@ -121,12 +136,15 @@ func (mc *MetadataCache) Stats() CacheStats {
 	}
 
 	return CacheStats{
-		Size:      len(mc.cache),
-		MaxSize:   mc.maxSize,
-		Hits:      mc.hits,
-		Misses:    mc.misses,
-		Evictions: mc.evictions,
-		HitRate:   hitRate,
+		Size:          len(mc.cache),
+		MaxSize:       mc.maxSize,
+		Hits:          mc.hits,
+		Misses:        mc.misses,
+		Evictions:     mc.evictions,
+		HitRate:       hitRate,
+		Listings:      len(mc.listings),
+		ListingHits:   mc.listingHits,
+		ListingMisses: mc.listingMisses,
 	}
 }
 
@@ -0,0 +1,33 @@
+package prefetch
+
+import "time"
+
+// TaskListingPartSize is the number of objects in one stored part of a task listing
+const TaskListingPartSize = 100000
+
+// TaskListing describes the destination listing a task left when it completed.
+// Its objects are stored in parts of up to TaskListingPartSize, in key order, so
+// a listing of any size is written and read without holding it whole in memory.
+type TaskListing struct {
+	TaskID   string    `json:"task_id"`
+	Endpoint string    `json:"endpoint,omitempty"` // Destination endpoint ("" for AWS)
+	Bucket   string    `json:"bucket"`
+	Prefix   string    `json:"prefix"`
+	Objects  int64     `json:"objects"`
+	Bytes    int64     `json:"bytes"`
+	Parts    int       `json:"parts"`
+	ListedAt time.Time `json:"listed_at"`
+}
+
+// TaskListingStore keeps the listings of tasks
+type TaskListingStore interface {
+	// SaveTaskListingPart stores part (from 0) of a task's listing
+	SaveTaskListingPart(taskID string, part int, objects []ObjectMetadata) error
+	// CompleteTaskListing makes a listing whose parts are all saved readable,
+	// replacing an earlier listing of the task
+	CompleteTaskListing(listing TaskListing) error
+	// LoadTaskListing returns the completed listing of a task, or nil if there is none
+	LoadTaskListing(taskID string) (*TaskListing, error)
+	// LoadTaskListingPart returns one part of a completed listing
+	LoadTaskListingPart(taskID string, part int) ([]ObjectMetadata, error)
+}
@@ -0,0 +1,178 @@
+package progress
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"s3migration/pkg/models"
+)
+
+const (
+	// etaSampleInterval is the window over which one throughput sample is measured
+	etaSampleInterval = 2 * time.Second
+	// etaSmoothing is the EWMA weight of the newest sample
+	etaSmoothing = 0.3
+	// etaMinRateFraction keeps the pessimistic byte rate from collapsing to zero
+	etaMinRateFraction = 0.25
+)
+
+// ETAEstimator estimates time remaining from the bytes and objects still pending.
+// Throughput is sampled in short windows and smoothed with an exponentially weighted
+// average, so the estimate follows the actual size mix instead of an average file size.
+type ETAEstimator struct {
+	mu               sync.Mutex
+	remainingBytes   int64
+	remainingObjects int64
+	started          time.Time
+	doneBytes        int64
+	doneObjects      int64
+
+	windowStart   time.Time
+	windowBytes   int64
+	windowObjects int64
+
+	samples    int
+	byteRate   float64 // EWMA bytes/s
+	byteVar    float64 // EW variance of bytes/s
+	objectRate float64 // EWMA objects/s
+}
+
+// NewETAEstimator creates an estimator for the pending job set
+func NewETAEstimator(pendingObjects, pendingBytes int64) *ETAEstimator {
+	now := time.Now()
+	return &ETAEstimator{
+		remainingBytes:   pendingBytes,
+		remainingObjects: pendingObjects,
+		started:          now,
+		windowStart:      now,
+	}
+}
+
+// Observe records one finished object (copied or failed); size is the bytes not
+// already reported through ObservePartial
+func (e *ETAEstimator) Observe(size int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.remainingObjects > 0 {
+		e.remainingObjects--
+	}
+	e.doneObjects++
+	e.windowObjects++
+	e.observeBytes(size)
+}
+
+// ObservePartial records bytes of an object that is still transferring
+func (e *ETAEstimator) ObservePartial(n int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.observeBytes(n)
+}
+
+// observeBytes counts transferred bytes and closes the sample window when due
+func (e *ETAEstimator) observeBytes(n int64) {
+	e.remainingBytes -= n
+	if e.remainingBytes < 0 {
+		e.remainingBytes = 0
+	}
+	e.doneBytes += n
+	e.windowBytes += n
+
+	now := time.Now()
+	elapsed := now.Sub(e.windowStart).Seconds()
+	if elapsed < etaSampleInterval.Seconds() {
+		return
+	}
+
+	byteRate := float64(e.windowBytes) / elapsed
+	objectRate := float64(e.windowObjects) / elapsed
+	if e.samples == 0 {
+		e.byteRate = byteRate
+		e.objectRate = objectRate
+	} else {
+		diff := byteRate - e.byteRate
+		e.byteRate += etaSmoothing * diff
+		e.byteVar = (1 - etaSmoothing) * (e.byteVar + etaSmoothing*diff*diff)
+		e.objectRate += etaSmoothing * (objectRate - e.objectRate)
+	}
+	e.samples++
+	e.windowStart = now
+	e.windowBytes = 0
+	e.windowObjects = 0
+}
+
+// Estimate returns the current estimate; false until there is throughput to go on
+func (e *ETAEstimator) Estimate() (models.ETAEstimate, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	estimate := models.ETAEstimate{
+		RemainingBytes:   e.remainingBytes,
+		RemainingObjects: e.remainingObjects,
+	}
+	if e.remainingObjects == 0 {
+		return estimate, true
+	}
+
+	byteRate, objectRate, spread := e.byteRate, e.objectRate, math.Sqrt(e.byteVar)
+	if e.samples == 0 {
+		// No full window yet: use the average since start with wide bounds
+		elapsed := time.Since(e.started).Seconds()
+		if elapsed <= 0 || (e.doneObjects == 0 && e.doneBytes == 0) {
+			return estimate, false
+		}
+		byteRate = float64(e.doneBytes) / elapsed
+		objectRate = float64(e.doneObjects) / elapsed
+		spread = byteRate / 2
+	}
+	if byteRate <= 0 && objectRate <= 0 {
+		return estimate, false
+	}
+	estimate.ThroughputBytesPerSec = byteRate
+	estimate.ObjectsPerSec = objectRate
+
+	// Time by object count covers per-request overhead when small files remain
+	objectSeconds := math.Inf(1)
+	if objectRate > 0 {
+		objectSeconds = float64(e.remainingObjects) / objectRate
+	}
+	if byteRate <= 0 || e.remainingBytes == 0 {
+		estimate.Seconds = objectSeconds
+		estimate.OptimisticSeconds = objectSeconds
+		estimate.PessimisticSeconds = objectSeconds
+		return estimate, !math.IsInf(objectSeconds, 1)
+	}
+
+	remaining := float64(e.remainingBytes)
+	estimate.Seconds = remaining / byteRate
+	estimate.OptimisticSeconds = math.Min(remaining/(byteRate+spread), objectSeconds)
+	pessimistic := remaining / math.Max(byteRate-spread, byteRate*etaMinRateFraction)
+	if !math.IsInf(objectSeconds, 1) {
+		pessimistic = math.Max(pessimistic, objectSeconds)
+	}
+	estimate.PessimisticSeconds = pessimistic
+	return estimate, true
+}
+
+// etaMaxSeconds caps displayed estimates; anything longer, or infinite, is shown as ">99999h"
+const etaMaxSeconds = 99999 * 3600
+
+// FormatETA renders seconds remaining as "45s", "12m" or "3h5m"
+func FormatETA(seconds float64) string {
+	if math.IsNaN(seconds) || seconds < 0 {
+		seconds = 0
+	}
+	if seconds >= etaMaxSeconds {
+		return ">99999h"
+	}
+	s := int64(seconds)
+	if s < 60 {
+		return fmt.Sprintf("%ds", s)
+	} else if s < 3600 {
+		return fmt.Sprintf("%dm", s/60)
+	}
+	return fmt.Sprintf("%dh%dm", s/3600, s/60%60)
+}
@@ -0,0 +1,71 @@
+package progress
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormatETA(t *testing.T) {
+	tests := []struct {
+		seconds float64
+		want    string
+	}{
+		{0, "0s"},
+		{-5, "0s"},
+		{math.NaN(), "0s"},
+		{45.9, "45s"},
+		{12 * 60, "12m"},
+		{3*3600 + 5*60, "3h5m"},
+		{1e10, ">99999h"}, // Overflows time.Duration
+		{math.Inf(1), ">99999h"},
+	}
+	for _, tt := range tests {
+		if got := FormatETA(tt.seconds); got != tt.want {
+			t.Errorf("FormatETA(%v) = %q, want %q", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateBounds(t *testing.T) {
+	tests := []struct {
+		name       string
+		byteRate   float64
+		byteVar    float64
+		objectRate float64
+		objects    int64
+		bytes      int64
+	}{
+		{name: "steady", byteRate: 1000, byteVar: 100, objectRate: 1, objects: 10, bytes: 100000},
+		{name: "spread wider than rate", byteRate: 1000, byteVar: 4e6, objectRate: 1, objects: 10, bytes: 100000},
+		{name: "many small objects", byteRate: 1e6, byteVar: 1e6, objectRate: 2, objects: 10000, bytes: 1000},
+		{name: "only objects left", objectRate: 5, objects: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewETAEstimator(tt.objects, tt.bytes)
+			e.samples = 3
+			e.byteRate, e.byteVar, e.objectRate = tt.byteRate, tt.byteVar, tt.objectRate
+
+			estimate, ok := e.Estimate()
+			if !ok {
+				t.Fatal("expected an estimate")
+			}
+			if estimate.OptimisticSeconds > estimate.Seconds || estimate.Seconds > estimate.PessimisticSeconds {
+				t.Fatalf("bounds out of order: optimistic %v, estimate %v, pessimistic %v",
+					estimate.OptimisticSeconds, estimate.Seconds, estimate.PessimisticSeconds)
+			}
+			if math.IsInf(estimate.PessimisticSeconds, 0) || estimate.OptimisticSeconds < 0 {
+				t.Fatalf("bounds not finite and positive: %+v", estimate)
+			}
+		})
+	}
+}
+
+func TestEstimateWithoutThroughput(t *testing.T) {
+	if _, ok := NewETAEstimator(10, 1000).Estimate(); ok {
+		t.Fatal("no estimate expected before anything was transferred")
+	}
+	if estimate, ok := NewETAEstimator(0, 0).Estimate(); !ok || estimate.Seconds != 0 {
+		t.Fatalf("nothing pending should estimate zero, got %+v, %v", estimate, ok)
+	}
+}
@@ -0,0 +1,208 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"s3migration/pkg/models"
+)
+
+// Update is a snapshot of a migration's progress
+type Update struct {
+	Time          time.Time           `json:"time"`
+	Phase         string              `json:"phase,omitempty"`
+	Progress      float64             `json:"progress"` // Percent of objects, counting finished parts of in-flight ones
+	CopiedObjects int64               `json:"copied_objects"`
+	TotalObjects  int64               `json:"total_objects"`
+	CopiedBytes   int64               `json:"copied_bytes"`
+	TotalBytes    int64               `json:"total_bytes"`
+	SpeedMBps     float64             `json:"speed_mbps"` // Average since the copy started
+	ETA           string              `json:"eta"`
+	Estimate      *models.ETAEstimate `json:"eta_estimate,omitempty"` // Nil until enough bytes were copied
+}
+
+// Reporter receives the progress of a migration. Calls come from the
+// migration's goroutines, one at a time, and should return quickly.
+type Reporter interface {
+	Phase(phase string) // models.PhaseDiscovering, PhaseUploading or PhaseVerifying
+	Progress(update Update)
+}
+
+// Callbacks is a Reporter calling functions; nil functions are skipped
+type Callbacks struct {
+	OnPhase    func(phase string)
+	OnProgress func(update Update)
+}
+
+// Phase implements Reporter
+func (c Callbacks) Phase(phase string) {
+	if c.OnPhase != nil {
+		c.OnPhase(phase)
+	}
+}
+
+// Progress implements Reporter
+func (c Callbacks) Progress(update Update) {
+	if c.OnProgress != nil {
+		c.OnProgress(update)
+	}
+}
+
+// multi reports to several reporters in turn
+type multi []Reporter
+
+// Multi returns a Reporter passing every call to each of reporters; nil ones are left out
+func Multi(reporters ...Reporter) Reporter {
+	var all multi
+	for _, reporter := range reporters {
+		if reporter != nil {
+			all = append(all, reporter)
+		}
+	}
+	return all
+}
+
+func (m multi) Phase(phase string) {
+	for _, reporter := range m {
+		reporter.Phase(phase)
+	}
+}
+
+func (m multi) Progress(update Update) {
+	for _, reporter := range m {
+		reporter.Progress(update)
+	}
+}
+
+// throttle lets through one update per interval, and always the last one of a
+// copy; a zero interval lets every update through
+type throttle struct {
+	interval time.Duration
+	last     time.Time
+}
+
+func (t *throttle) allow(update Update) bool {
+	done := update.TotalObjects > 0 && update.CopiedObjects >= update.TotalObjects
+	if t.interval > 0 && update.Time.Sub(t.last) < t.interval && !done {
+		return false
+	}
+	t.last = update.Time
+	return true
+}
+
+// Printer writes a one-line summary at most once per interval, and a line per phase change
+type Printer struct {
+	mu       sync.Mutex
+	w        io.Writer
+	label    string
+	throttle throttle
+}
+
+// NewPrinter returns a Printer writing to w, e.g. os.Stdout; label (such as the
+// task ID) starts each line when set
+func NewPrinter(w io.Writer, label string, interval time.Duration) *Printer {
+	if label != "" {
+		label += ": "
+	}
+	return &Printer{w: w, label: label, throttle: throttle{interval: interval}}
+}
+
+// Phase implements Reporter
+func (p *Printer) Phase(phase string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "%sPhase: %s\n", p.label, phase)
+}
+
+// Progress implements Reporter
+func (p *Printer) Progress(update Update) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.throttle.allow(update) {
+		return
+	}
+	fmt.Fprintf(p.w, "%sProgress: %.1f%% (%d/%d objects, %.1f/%.1f MB) | Speed: %.1f MB/s | ETA: %s\n",
+		p.label,
+		update.Progress,
+		update.CopiedObjects,
+		update.TotalObjects,
+		float64(update.CopiedBytes)/(1024*1024),
+		float64(update.TotalBytes)/(1024*1024),
+		update.SpeedMBps,
+		update.ETA,
+	)
+}
+
+// JSONLines writes each update as one JSON object per line, at most once per
+// interval; phase changes are written as {"time": ..., "phase": ...}
+type JSONLines struct {
+	mu       sync.Mutex
+	w        io.Writer
+	closer   io.Closer
+	throttle throttle
+	phase    string
+	err      error // First write error; later updates are dropped
+}
+
+// NewJSONLines returns a JSONLines reporter writing to w
+func NewJSONLines(w io.Writer, interval time.Duration) *JSONLines {
+	return &JSONLines{w: w, throttle: throttle{interval: interval}}
+}
+
+// CreateJSONLinesFile appends the updates to the file at path, creating it if needed
+func CreateJSONLinesFile(path string, interval time.Duration) (*JSONLines, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	reporter := NewJSONLines(file, interval)
+	reporter.closer = file
+	return reporter, nil
+}
+
+// Phase implements Reporter
+func (j *JSONLines) Phase(phase string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.phase = phase
+	j.write(Update{Time: time.Now(), Phase: phase})
+}
+
+// Progress implements Reporter
+func (j *JSONLines) Progress(update Update) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.throttle.allow(update) {
+		return
+	}
+	if update.Phase == "" {
+		update.Phase = j.phase
+	}
+	j.write(update)
+}
+
+func (j *JSONLines) write(update Update) {
+	if j.err != nil {
+		return
+	}
+	line, err := json.Marshal(update)
+	if err == nil {
+		_, err = j.w.Write(append(line, '\n'))
+	}
+	if err != nil {
+		j.err = err
+		fmt.Printf("⚠️  Progress log stopped: %v\n", err)
+	}
+}
+
+// Close closes the file of a reporter from CreateJSONLinesFile
+func (j *JSONLines) Close() error {
+	if j.closer == nil {
+		return nil
+	}
+	return j.closer.Close()
+}
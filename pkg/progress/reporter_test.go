@@ -0,0 +1,139 @@
+package progress
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"s3migration/pkg/models"
+)
+
+func TestThrottle(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		interval time.Duration
+		after    time.Duration // Since the first update
+		copied   int64         // Of 10 objects
+		want     bool
+	}{
+		{name: "no interval", interval: 0, after: time.Millisecond, copied: 1, want: true},
+		{name: "within interval", interval: time.Second, after: 500 * time.Millisecond, copied: 1, want: false},
+		{name: "interval passed", interval: time.Second, after: time.Second, copied: 1, want: true},
+		{name: "last update within interval", interval: time.Second, after: time.Millisecond, copied: 10, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := throttle{interval: tt.interval}
+			if !th.allow(Update{Time: start, TotalObjects: 10}) {
+				t.Fatal("first update was held back")
+			}
+			if got := th.allow(Update{Time: start.Add(tt.after), CopiedObjects: tt.copied, TotalObjects: 10}); got != tt.want {
+				t.Errorf("allow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	var out strings.Builder
+	p := NewPrinter(&out, "task-1", time.Minute)
+	now := time.Now()
+	p.Phase(models.PhaseUploading)
+	p.Progress(Update{Time: now, Progress: 50, CopiedObjects: 1, TotalObjects: 2, CopiedBytes: 1 << 20, TotalBytes: 2 << 20, SpeedMBps: 1.5, ETA: "1s"})
+	p.Progress(Update{Time: now.Add(time.Second), Progress: 60, CopiedObjects: 1, TotalObjects: 2}) // Held back
+
+	want := "task-1: Phase: uploading\n" +
+		"task-1: Progress: 50.0% (1/2 objects, 1.0/2.0 MB) | Speed: 1.5 MB/s | ETA: 1s\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestJSONLinesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.jsonl")
+	j, err := CreateJSONLinesFile(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	j.Phase(models.PhaseUploading)
+	j.Progress(Update{Time: now, Progress: 50, CopiedObjects: 1, TotalObjects: 2, Estimate: &models.ETAEstimate{Seconds: 3}})
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var lines []Update
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var update Update
+		if err := json.Unmarshal(scanner.Bytes(), &update); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, update)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	if lines[0].Phase != models.PhaseUploading || lines[0].TotalObjects != 0 {
+		t.Errorf("phase line = %+v", lines[0])
+	}
+	if lines[1].Phase != models.PhaseUploading || lines[1].CopiedObjects != 1 || lines[1].Estimate == nil || lines[1].Estimate.Seconds != 3 {
+		t.Errorf("progress line = %+v", lines[1])
+	}
+}
+
+func TestMulti(t *testing.T) {
+	var phases []string
+	var updates int
+	counting := Callbacks{
+		OnPhase:    func(phase string) { phases = append(phases, phase) },
+		OnProgress: func(Update) { updates++ },
+	}
+	r := Multi(counting, nil, Callbacks{}, counting)
+	r.Phase(models.PhaseDiscovering)
+	r.Progress(Update{})
+	if len(phases) != 2 || updates != 2 {
+		t.Errorf("got %d phases and %d updates, want 2 of each", len(phases), updates)
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeline := NewTimeline(time.Second)
+	timeline.Phase("uploading")
+	const updates = 1000
+	for i := 1; i <= updates; i++ {
+		timeline.Progress(Update{
+			Time:          start.Add(time.Duration(i) * time.Second),
+			CopiedObjects: int64(i),
+			TotalObjects:  updates,
+			CopiedBytes:   int64(i) * 1024,
+		})
+	}
+
+	points := timeline.Points()
+	if len(points) > maxTimelinePoints || len(points) < maxTimelinePoints/2 {
+		t.Fatalf("%d points kept, want %d to %d", len(points), maxTimelinePoints/2, maxTimelinePoints)
+	}
+	if points[0].Phase != "uploading" || points[0].CopiedObjects != 0 {
+		t.Errorf("first point = %+v, want the phase change", points[0])
+	}
+	if last := points[len(points)-1]; last.CopiedObjects != updates || last.Phase != "uploading" {
+		t.Errorf("last point = %+v, want the final update", last)
+	}
+	for i := 1; i < len(points); i++ {
+		if points[i].CopiedObjects <= points[i-1].CopiedObjects {
+			t.Fatalf("points %d and %d out of order: %+v, %+v", i-1, i, points[i-1], points[i])
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package progress
+
+import (
+	"sync"
+	"time"
+)
+
+// maxTimelinePoints bounds the points a Timeline keeps, however long the migration runs
+const maxTimelinePoints = 240
+
+// Point is one sample of a Timeline
+type Point struct {
+	Time          time.Time `json:"time"`
+	Phase         string    `json:"phase,omitempty"`
+	CopiedObjects int64     `json:"copied_objects"`
+	CopiedBytes   int64     `json:"copied_bytes"`
+}
+
+// Timeline keeps a bounded history of a migration's progress for charts. It
+// samples at most once per interval; when full, every other point is dropped
+// and the interval doubles, so the history always spans the whole run.
+type Timeline struct {
+	mu       sync.Mutex
+	throttle throttle
+	phase    string
+	points   []Point
+}
+
+// NewTimeline returns an empty Timeline sampling at most once per interval
+func NewTimeline(interval time.Duration) *Timeline {
+	return &Timeline{throttle: throttle{interval: interval}}
+}
+
+// Phase implements Reporter; a phase change is always sampled
+func (t *Timeline) Phase(phase string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.phase = phase
+	point := Point{Time: time.Now(), Phase: phase}
+	if n := len(t.points); n > 0 {
+		point.CopiedObjects = t.points[n-1].CopiedObjects
+		point.CopiedBytes = t.points[n-1].CopiedBytes
+	}
+	t.add(point)
+}
+
+// Progress implements Reporter
+func (t *Timeline) Progress(update Update) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.throttle.allow(update) {
+		return
+	}
+	phase := update.Phase
+	if phase == "" {
+		phase = t.phase
+	}
+	t.add(Point{Time: update.Time, Phase: phase, CopiedObjects: update.CopiedObjects, CopiedBytes: update.CopiedBytes})
+}
+
+func (t *Timeline) add(point Point) {
+	t.points = append(t.points, point)
+	if len(t.points) <= maxTimelinePoints {
+		return
+	}
+	// Keep the first, every other one and the newest point
+	kept := t.points[:0]
+	for i, p := range t.points {
+		if i%2 == 0 || i == len(t.points)-1 {
+			kept = append(kept, p)
+		}
+	}
+	t.points = kept
+	if t.throttle.interval == 0 {
+		t.throttle.interval = point.Time.Sub(t.points[0].Time) / maxTimelinePoints
+	}
+	t.throttle.interval *= 2
+}
+
+// Points returns a copy of the samples, oldest first
+func (t *Timeline) Points() []Point {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Point(nil), t.points...)
+}
@@ -2,58 +2,40 @@ package progress
 
 import (
 	"fmt"
-	"sync"
 	"sync/atomic"
 	"time"
 )
 
 // Tracker tracks migration progress
 type Tracker struct {
-	totalObjects   int64
-	totalSize      int64
-	copiedObjects  atomic.Int64
-	copiedSize     atomic.Int64
-	failedObjects  atomic.Int64
-	startTime      time.Time
-	lastUpdateTime time.Time
-	transferSpeeds []float64
-	mu             sync.RWMutex
+	totalObjects  int64
+	totalSize     int64
+	copiedObjects atomic.Int64
+	copiedSize    atomic.Int64
+	failedObjects atomic.Int64
+	startTime     time.Time
+	eta           *ETAEstimator
 }
 
 // NewTracker creates a new progress tracker
 func NewTracker(totalObjects int64, totalSize int64) *Tracker {
 	return &Tracker{
-		totalObjects:   totalObjects,
-		totalSize:      totalSize,
-		startTime:      time.Now(),
-		lastUpdateTime: time.Now(),
-		transferSpeeds: make([]float64, 0, 10),
+		totalObjects: totalObjects,
+		totalSize:    totalSize,
+		startTime:    time.Now(),
+		eta:          NewETAEstimator(totalObjects, totalSize),
 	}
 }
 
 // Update updates progress with a new transfer result
 func (t *Tracker) Update(objectSize int64, success bool) {
-	now := time.Now()
-
 	if success {
 		t.copiedObjects.Add(1)
 		t.copiedSize.Add(objectSize)
 	} else {
 		t.failedObjects.Add(1)
 	}
-
-	// Calculate transfer speed
-	t.mu.Lock()
-	elapsed := now.Sub(t.lastUpdateTime).Seconds()
-	if elapsed > 0 && objectSize > 0 {
-		speed := float64(objectSize) / elapsed
-		t.transferSpeeds = append(t.transferSpeeds, speed)
-		if len(t.transferSpeeds) > 10 {
-			t.transferSpeeds = t.transferSpeeds[1:]
-		}
-	}
-	t.lastUpdateTime = now
-	t.mu.Unlock()
+	t.eta.Observe(objectSize)
 }
 
 // Stats returns current progress statistics
@@ -71,33 +53,18 @@ type Stats struct {
 
 // GetStats returns current progress statistics
 func (t *Tracker) GetStats() Stats {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
-
 	copiedObjects := t.copiedObjects.Load()
 	copiedSize := t.copiedSize.Load()
 	failedObjects := t.failedObjects.Load()
 
 	elapsed := time.Since(t.startTime)
 
-	// Calculate average speed
+	// ETA from remaining bytes and weighted recent throughput
 	var avgSpeed float64
-	if len(t.transferSpeeds) > 0 {
-		var sum float64
-		for _, speed := range t.transferSpeeds {
-			sum += speed
-		}
-		avgSpeed = sum / float64(len(t.transferSpeeds))
-	}
-
-	// Calculate ETA
-	remainingSize := t.totalSize - copiedSize
-	var eta string
-	if avgSpeed > 0 {
-		etaSeconds := float64(remainingSize) / avgSpeed
-		eta = time.Duration(etaSeconds * float64(time.Second)).String()
-	} else {
-		eta = "calculating..."
+	eta := "calculating..."
+	if estimate, ok := t.eta.Estimate(); ok {
+		avgSpeed = estimate.ThroughputBytesPerSec
+		eta = FormatETA(estimate.Seconds)
 	}
 
 	progressPct := 0.0
@@ -0,0 +1,63 @@
+package box
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// OAuthConfig holds OAuth configuration
+type OAuthConfig struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RedirectURL  string `json:"redirect_url"`
+}
+
+// TokenResponse represents the response from OAuth token exchange
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// AuthHandler handles Box OAuth authentication
+type AuthHandler struct {
+	config *oauth2.Config
+	ctx    context.Context
+}
+
+// NewAuthHandler creates a new Box auth handler
+func NewAuthHandler(ctx context.Context, oauthConfig OAuthConfig) *AuthHandler {
+	return &AuthHandler{
+		config: &oauth2.Config{
+			ClientID:     oauthConfig.ClientID,
+			ClientSecret: oauthConfig.ClientSecret,
+			RedirectURL:  oauthConfig.RedirectURL,
+			Endpoint:     Endpoint,
+		},
+		ctx: ctx,
+	}
+}
+
+// GetAuthURL generates the OAuth authorization URL
+func (h *AuthHandler) GetAuthURL(state string) string {
+	return h.config.AuthCodeURL(state)
+}
+
+// ExchangeCodeForToken exchanges an authorization code for an access token
+func (h *AuthHandler) ExchangeCodeForToken(code string) (*TokenResponse, error) {
+	token, err := h.config.Exchange(h.ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code for token: %w", err)
+	}
+
+	return &TokenResponse{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		TokenType:    token.TokenType,
+		ExpiresIn:    int64(time.Until(token.Expiry).Seconds()),
+	}, nil
+}
@@ -0,0 +1,340 @@
+package box
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	apiBaseURL = "https://api.box.com/2.0"
+
+	// DefaultChunkSize is the size of each ranged download request for large files
+	DefaultChunkSize = 32 * 1024 * 1024 // 32MB
+
+	maxRetries = 5
+)
+
+// Endpoint is the Box OAuth2 endpoint
+var Endpoint = oauth2.Endpoint{
+	AuthURL:  "https://account.box.com/api/oauth2/authorize",
+	TokenURL: "https://api.box.com/oauth2/token",
+}
+
+// Client wraps the Box content API
+type Client struct {
+	httpClient *http.Client
+	ctx        context.Context
+}
+
+// Config holds Box client configuration
+type Config struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// FileInfo represents a Box file or folder
+type FileInfo struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Size         int64     `json:"size"`
+	SHA1         string    `json:"sha1,omitempty"`
+	ModifiedTime time.Time `json:"modified_time"`
+	IsFolder     bool      `json:"is_folder"`
+}
+
+// item is a Box API folder item
+type item struct {
+	Type       string `json:"type"`
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Size       int64  `json:"size"`
+	SHA1       string `json:"sha1"`
+	ModifiedAt string `json:"modified_at"`
+}
+
+// itemsResponse is the Box API response for folder item listings
+type itemsResponse struct {
+	Entries    []item `json:"entries"`
+	NextMarker string `json:"next_marker"`
+}
+
+// NewClient creates a new Box client with automatic token refresh
+func NewClient(ctx context.Context, config Config) (*Client, error) {
+	clientID := config.ClientID
+	clientSecret := config.ClientSecret
+	if clientID == "" {
+		// Use OAuth app credentials from environment for token refresh
+		clientID = os.Getenv("BOX_CLIENT_ID")
+		clientSecret = os.Getenv("BOX_CLIENT_SECRET")
+		if clientID == "" || clientSecret == "" {
+			return nil, fmt.Errorf("Box OAuth not configured: BOX_CLIENT_ID and BOX_CLIENT_SECRET environment variables must be set")
+		}
+	}
+	if config.AccessToken == "" && config.RefreshToken == "" {
+		return nil, fmt.Errorf("access_token or refresh_token is required")
+	}
+
+	oauthConfig := &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Endpoint:     Endpoint,
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  config.AccessToken,
+		RefreshToken: config.RefreshToken,
+	}
+
+	baseClient := &http.Client{
+		Transport: &http.Transport{
+			MaxIdleConns:        4,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		// No overall timeout: chunk downloads are bounded by the request context
+	}
+	tokenCtx := context.WithValue(ctx, oauth2.HTTPClient, baseClient)
+
+	fmt.Printf("🔐 Creating Box client with automatic token refresh\n")
+
+	return &Client{
+		httpClient: oauthConfig.Client(tokenCtx, token),
+		ctx:        ctx,
+	}, nil
+}
+
+// ListFolderItems lists one page of items in a Box folder ("0" is the root folder)
+func (c *Client) ListFolderItems(folderID string, limit int, marker string) ([]FileInfo, string, error) {
+	if folderID == "" {
+		folderID = "0"
+	}
+
+	query := url.Values{}
+	query.Set("fields", "id,type,name,size,sha1,modified_at")
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("usemarker", "true")
+	if marker != "" {
+		query.Set("marker", marker)
+	}
+	endpoint := fmt.Sprintf("%s/folders/%s/items?%s", apiBaseURL, url.PathEscape(folderID), query.Encode())
+
+	var result itemsResponse
+	err := c.doWithRetry(func() error {
+		resp, err := c.get(endpoint, nil)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		return json.NewDecoder(resp.Body).Decode(&result)
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list folder %s: %w", folderID, err)
+	}
+
+	var files []FileInfo
+	for _, entry := range result.Entries {
+		// Web links have no content to migrate
+		if entry.Type != "file" && entry.Type != "folder" {
+			continue
+		}
+
+		fileInfo := FileInfo{
+			ID:       entry.ID,
+			Name:     entry.Name,
+			Size:     entry.Size,
+			SHA1:     entry.SHA1,
+			IsFolder: entry.Type == "folder",
+		}
+		if entry.ModifiedAt != "" {
+			if modifiedTime, err := time.Parse(time.RFC3339, entry.ModifiedAt); err == nil {
+				fileInfo.ModifiedTime = modifiedTime
+			}
+		}
+		files = append(files, fileInfo)
+	}
+
+	return files, result.NextMarker, nil
+}
+
+// ListFolders lists the subfolders of a Box folder
+func (c *Client) ListFolders(parentFolderID string) ([]FileInfo, error) {
+	var folders []FileInfo
+	marker := ""
+	for {
+		items, nextMarker, err := c.ListFolderItems(parentFolderID, 1000, marker)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range items {
+			if entry.IsFolder {
+				folders = append(folders, entry)
+			}
+		}
+		if nextMarker == "" {
+			break
+		}
+		marker = nextMarker
+	}
+	return folders, nil
+}
+
+// GetFileRange downloads bytes [start, end] (inclusive) of a Box file
+func (c *Client) GetFileRange(fileID string, start, end int64) ([]byte, error) {
+	endpoint := fmt.Sprintf("%s/files/%s/content", apiBaseURL, url.PathEscape(fileID))
+	headers := map[string]string{
+		"Range": fmt.Sprintf("bytes=%d-%d", start, end),
+	}
+
+	var data []byte
+	err := c.doWithRetry(func() error {
+		resp, err := c.get(endpoint, headers)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		data, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return &retryableError{err: fmt.Errorf("failed to read chunk: %w", err)}
+		}
+		if int64(len(data)) != end-start+1 {
+			return &retryableError{err: fmt.Errorf("short chunk: got %d bytes, expected %d", len(data), end-start+1)}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file %s range %d-%d: %w", fileID, start, end, err)
+	}
+	return data, nil
+}
+
+// NewChunkedReader returns a reader that downloads a file in ranged chunks,
+// retrying each chunk independently so a dropped connection doesn't restart the file
+func (c *Client) NewChunkedReader(file FileInfo, chunkSize int64) io.Reader {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	return &chunkedReader{
+		client:    c,
+		fileID:    file.ID,
+		size:      file.Size,
+		chunkSize: chunkSize,
+	}
+}
+
+// chunkedReader streams a Box file one ranged request at a time
+type chunkedReader struct {
+	client    *Client
+	fileID    string
+	size      int64
+	chunkSize int64
+	offset    int64
+	buf       []byte
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.offset >= r.size {
+			return 0, io.EOF
+		}
+		end := r.offset + r.chunkSize - 1
+		if end >= r.size {
+			end = r.size - 1
+		}
+		data, err := r.client.GetFileRange(r.fileID, r.offset, end)
+		if err != nil {
+			return 0, err
+		}
+		r.buf = data
+		r.offset = end + 1
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// retryableError marks errors worth retrying (throttling, 5xx, transport failures)
+type retryableError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// get issues an authenticated GET request and converts error statuses
+func (c *Client) get(endpoint string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if c.ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, &retryableError{err: err}
+	}
+
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusPartialContent {
+		return resp, nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+	statusErr := fmt.Errorf("box API returned %d: %s", resp.StatusCode, string(body))
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		retryErr := &retryableError{err: statusErr}
+		if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+			retryErr.retryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, retryErr
+	}
+	return nil, statusErr
+}
+
+// doWithRetry runs fn, retrying retryable errors with exponential backoff
+func (c *Client) doWithRetry(fn func() error) error {
+	var err error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+
+		retryErr, ok := err.(*retryableError)
+		if !ok {
+			return err
+		}
+
+		delay := retryErr.retryAfter
+		if delay == 0 {
+			delay = time.Duration(1<<uint(attempt)) * time.Second
+		}
+
+		select {
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	return err
+}
@@ -0,0 +1,317 @@
+package box
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/buckets"
+	"s3migration/pkg/config"
+)
+
+// BoxMigrator handles migration from Box to S3
+type BoxMigrator struct {
+	client   *Client
+	s3Client *s3.Client
+	ctx      context.Context
+}
+
+// MigrationInput contains parameters for Box to S3 migration
+type MigrationInput struct {
+	SourceFolderID   string // Box folder ID (empty or "0" = root folder)
+	DestBucket       string // S3 destination bucket
+	DestPrefix       string // S3 destination prefix
+	DryRun           bool   // If true, only simulate the migration
+	Workers          int    // Concurrent uploads (default: 4)
+	ChunkSize        int64  // Ranged download chunk size (default: DefaultChunkSize)
+	ProgressCallback func(progress float64, copied, total int64, copiedSize, totalSize int64, speed float64, eta string)
+}
+
+// MigrationResult contains the result of a migration
+type MigrationResult struct {
+	TotalFiles  int64         `json:"total_files"`
+	CopiedFiles int64         `json:"copied_files"`
+	FailedFiles int64         `json:"failed_files"`
+	TotalSize   int64         `json:"total_size"`
+	CopiedSize  int64         `json:"copied_size"`
+	Errors      []string      `json:"errors,omitempty"`
+	StartTime   time.Time     `json:"start_time"`
+	EndTime     time.Time     `json:"end_time"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// fileToUpload is a discovered file with its path relative to the source folder
+type fileToUpload struct {
+	Info FileInfo
+	Path string
+}
+
+// maxReportedErrors caps the number of per-file errors kept in the result
+const maxReportedErrors = 100
+
+// NewBoxMigrator creates a new Box migrator
+func NewBoxMigrator(ctx context.Context, client *Client, s3Client *s3.Client) *BoxMigrator {
+	return &BoxMigrator{
+		client:   client,
+		s3Client: s3Client,
+		ctx:      ctx,
+	}
+}
+
+// Migrate performs the migration from Box to S3
+func (m *BoxMigrator) Migrate(input MigrationInput) (*MigrationResult, error) {
+	result := &MigrationResult{
+		StartTime: time.Now(),
+	}
+
+	fmt.Printf("Starting Box to S3 migration...\n")
+	fmt.Printf("Source Folder ID: %s\n", input.SourceFolderID)
+	fmt.Printf("Destination: s3://%s/%s\n", input.DestBucket, input.DestPrefix)
+	fmt.Printf("Dry Run: %v\n", input.DryRun)
+
+	if !input.DryRun {
+		if err := m.ensureDestinationBucketExists(input.DestBucket); err != nil {
+			return nil, fmt.Errorf("failed to ensure destination bucket exists: %w", err)
+		}
+	}
+
+	// Phase 1: discover all files
+	fmt.Printf("📋 Phase 1: Discovering files...\n")
+	files, err := m.discover(input)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		result.TotalFiles++
+		result.TotalSize += f.Info.Size
+	}
+	fmt.Printf("✅ Discovery complete! Found %d files (%.2f GB)\n",
+		result.TotalFiles, float64(result.TotalSize)/(1024*1024*1024))
+
+	if input.ProgressCallback != nil {
+		input.ProgressCallback(0.0, 0, result.TotalFiles, 0, result.TotalSize, 0.0, "starting upload...")
+	}
+
+	// Phase 2: upload
+	workers := input.Workers
+	if workers <= 0 {
+		workers = 4
+	}
+	fmt.Printf("🚀 Phase 2: Uploading files with %d concurrent workers...\n", workers)
+
+	jobs := make(chan fileToUpload)
+	var wg sync.WaitGroup
+	var resultMu sync.Mutex
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				key := joinKey(input.DestPrefix, f.Path)
+
+				var copyErr error
+				if !input.DryRun {
+					copyErr = m.copyFileToS3(f.Info, input.DestBucket, key, input.ChunkSize)
+				}
+
+				resultMu.Lock()
+				if copyErr != nil {
+					result.FailedFiles++
+					if len(result.Errors) < maxReportedErrors {
+						result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", f.Path, copyErr))
+					}
+					fmt.Printf("  [ERROR] %s: %v\n", f.Path, copyErr)
+				} else {
+					result.CopiedFiles++
+					result.CopiedSize += f.Info.Size
+				}
+				done := result.CopiedFiles + result.FailedFiles
+				copied, copiedSize := result.CopiedFiles, result.CopiedSize
+				resultMu.Unlock()
+
+				if input.ProgressCallback != nil {
+					progress := float64(done) / float64(result.TotalFiles) * 100
+					input.ProgressCallback(progress, copied, result.TotalFiles, copiedSize, result.TotalSize,
+						calculateSpeed(result.StartTime, copiedSize),
+						calculateETA(result.StartTime, done, result.TotalFiles))
+				}
+			}
+		}()
+	}
+
+	for _, f := range files {
+		select {
+		case jobs <- f:
+		case <-m.ctx.Done():
+		}
+		if m.ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+
+	if err := m.ctx.Err(); err != nil {
+		return result, fmt.Errorf("migration interrupted: %w", err)
+	}
+
+	if input.ProgressCallback != nil {
+		input.ProgressCallback(100.0, result.CopiedFiles, result.TotalFiles,
+			result.CopiedSize, result.TotalSize,
+			calculateSpeed(result.StartTime, result.CopiedSize), "Completed")
+	}
+
+	fmt.Printf("✅ Box migration completed: %d copied, %d failed, %.2f GB in %v\n",
+		result.CopiedFiles, result.FailedFiles, float64(result.CopiedSize)/(1024*1024*1024), result.Duration)
+
+	return result, nil
+}
+
+// discover walks the folder tree breadth-first and returns every file with its relative path
+func (m *BoxMigrator) discover(input MigrationInput) ([]fileToUpload, error) {
+	type folder struct {
+		ID   string
+		Path string
+	}
+
+	var files []fileToUpload
+	queue := []folder{{ID: input.SourceFolderID, Path: ""}}
+	visited := make(map[string]bool)
+	foldersScanned := 0
+
+	for len(queue) > 0 {
+		if err := m.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		current := queue[0]
+		queue = queue[1:]
+		if visited[current.ID] {
+			continue
+		}
+		visited[current.ID] = true
+
+		marker := ""
+		for {
+			items, nextMarker, err := m.client.ListFolderItems(current.ID, 1000, marker)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, entry := range items {
+				itemPath := entry.Name
+				if current.Path != "" {
+					itemPath = current.Path + "/" + entry.Name
+				}
+
+				if entry.IsFolder {
+					queue = append(queue, folder{ID: entry.ID, Path: itemPath})
+					continue
+				}
+				files = append(files, fileToUpload{Info: entry, Path: itemPath})
+			}
+
+			if nextMarker == "" {
+				break
+			}
+			marker = nextMarker
+		}
+
+		foldersScanned++
+		if foldersScanned%10 == 0 {
+			fmt.Printf("   📁 Scanned %d folders, %d files so far...\n", foldersScanned, len(files))
+		}
+	}
+
+	return files, nil
+}
+
+// copyFileToS3 streams a Box file to S3 using chunked ranged downloads, checking the
+// streamed bytes against the SHA-1 Box reports for the file
+func (m *BoxMigrator) copyFileToS3(file FileInfo, bucket, key string, chunkSize int64) error {
+	metadata := map[string]string{
+		"source":         "box",
+		"source-file-id": file.ID,
+		"migrated-at":    time.Now().Format(time.RFC3339),
+	}
+	if file.SHA1 != "" {
+		metadata["source-sha1"] = file.SHA1
+	}
+
+	if file.Size == 0 {
+		// Omit ContentLength for empty files (some S3-compatible storage rejects 0)
+		_, err := m.s3Client.PutObject(m.ctx, &s3.PutObjectInput{
+			Bucket:   &bucket,
+			Key:      &key,
+			Metadata: metadata,
+			Body:     bytes.NewReader([]byte{}),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upload %s to S3 (bucket: %s, key: %s, size: 0 bytes): %w",
+				file.Name, bucket, key, err)
+		}
+		return nil
+	}
+
+	hash := sha1.New()
+	body := io.TeeReader(m.client.NewChunkedReader(file, chunkSize), hash)
+	if err := uploadStream(m.ctx, m.s3Client, config.DefaultMultipartSettings(), bucket, key, metadata, body, file.Size); err != nil {
+		return fmt.Errorf("failed to upload %s to S3 (bucket: %s, key: %s, size: %d bytes): %w",
+			file.Name, bucket, key, file.Size, err)
+	}
+
+	if file.SHA1 != "" {
+		if sum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(sum, file.SHA1) {
+			m.s3Client.DeleteObject(m.ctx, &s3.DeleteObjectInput{Bucket: &bucket, Key: &key})
+			return fmt.Errorf("checksum mismatch for %s: Box reports SHA-1 %s, downloaded %s", file.Name, file.SHA1, sum)
+		}
+	}
+	return nil
+}
+
+// ensureDestinationBucketExists ensures the S3 bucket exists
+func (m *BoxMigrator) ensureDestinationBucketExists(bucket string) error {
+	_, err := buckets.Ensure(m.ctx, m.s3Client, &s3.CreateBucketInput{
+		Bucket: &bucket,
+	})
+	return err
+}
+
+// joinKey builds the S3 key for a file path under the destination prefix
+func joinKey(prefix, filePath string) string {
+	if prefix == "" {
+		return filePath
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + filePath
+}
+
+// calculateSpeed returns the average transfer speed in MB/s
+func calculateSpeed(startTime time.Time, bytesTransferred int64) float64 {
+	elapsed := time.Since(startTime).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(bytesTransferred) / elapsed / (1024 * 1024)
+}
+
+// calculateETA estimates the remaining time from the file completion rate
+func calculateETA(startTime time.Time, completed, total int64) string {
+	if completed == 0 {
+		return "calculating..."
+	}
+	elapsed := time.Since(startTime)
+	remaining := time.Duration(float64(elapsed) / float64(completed) * float64(total-completed))
+	return remaining.Round(time.Second).String()
+}
@@ -0,0 +1,102 @@
+package box
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"s3migration/pkg/config"
+)
+
+// uploadAPI is the part of the S3 client used to upload Box files
+type uploadAPI interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// uploadStream writes size bytes from body to bucket/key, in parts once size passes the
+// multipart threshold so files over the 5 GiB PutObject limit can be migrated
+func uploadStream(ctx context.Context, api uploadAPI, settings config.MultipartSettings, bucket, key string, metadata map[string]string, body io.Reader, size int64) error {
+	if size <= settings.ThresholdBytes {
+		_, err := api.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:        &bucket,
+			Key:           &key,
+			Metadata:      metadata,
+			Body:          body,
+			ContentLength: &size,
+		})
+		return err
+	}
+
+	partSize, partCount, err := settings.PartSize(size)
+	if err != nil {
+		return err
+	}
+	created, err := api.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:   &bucket,
+		Key:      &key,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initiate multipart upload: %w", err)
+	}
+	uploadID := created.UploadId
+
+	parts, err := uploadParts(ctx, api, bucket, key, uploadID, body, size, partSize, partCount)
+	if err == nil {
+		_, err = api.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          &bucket,
+			Key:             &key,
+			UploadId:        uploadID,
+			MultipartUpload: &s3Types.CompletedMultipartUpload{Parts: parts},
+		})
+	}
+	if err != nil {
+		// Abort with a fresh context so a cancelled migration doesn't leave parts behind
+		api.AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{
+			Bucket:   &bucket,
+			Key:      &key,
+			UploadId: uploadID,
+		})
+		return fmt.Errorf("multipart upload failed: %w", err)
+	}
+	return nil
+}
+
+// uploadParts reads body one part at a time into a reused buffer, so each part can be
+// signed and retried by the SDK without downloading it from Box again
+func uploadParts(ctx context.Context, api uploadAPI, bucket, key string, uploadID *string, body io.Reader, size, partSize, partCount int64) ([]s3Types.CompletedPart, error) {
+	buf := make([]byte, partSize)
+	parts := make([]s3Types.CompletedPart, 0, partCount)
+	for partNumber := int32(1); int64(partNumber) <= partCount; partNumber++ {
+		n := partSize
+		if remaining := size - int64(partNumber-1)*partSize; remaining < n {
+			n = remaining
+		}
+		if _, err := io.ReadFull(body, buf[:n]); err != nil {
+			return nil, fmt.Errorf("failed to read part %d: %w", partNumber, err)
+		}
+		number := partNumber
+		out, err := api.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        &bucket,
+			Key:           &key,
+			UploadId:      uploadID,
+			PartNumber:    &number,
+			Body:          bytes.NewReader(buf[:n]),
+			ContentLength: &n,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+		}
+		parts = append(parts, s3Types.CompletedPart{ETag: out.ETag, PartNumber: &number})
+	}
+	return parts, nil
+}
@@ -0,0 +1,112 @@
+package box
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/config"
+)
+
+// fakeUploader records what reaches S3 and can fail a given part
+type fakeUploader struct {
+	put       []byte
+	parts     map[int32][]byte
+	completed int
+	aborted   bool
+	failPart  int32
+}
+
+func (f *fakeUploader) PutObject(ctx context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(in.Body)
+	f.put = data
+	return &s3.PutObjectOutput{}, err
+}
+
+func (f *fakeUploader) CreateMultipartUpload(ctx context.Context, in *s3.CreateMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	f.parts = map[int32][]byte{}
+	id := "upload-1"
+	return &s3.CreateMultipartUploadOutput{UploadId: &id}, nil
+}
+
+func (f *fakeUploader) UploadPart(ctx context.Context, in *s3.UploadPartInput, _ ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	if *in.PartNumber == f.failPart {
+		return nil, errors.New("part rejected")
+	}
+	data, err := io.ReadAll(in.Body)
+	f.parts[*in.PartNumber] = data
+	etag := fmt.Sprintf("etag-%d", *in.PartNumber)
+	return &s3.UploadPartOutput{ETag: &etag}, err
+}
+
+func (f *fakeUploader) CompleteMultipartUpload(ctx context.Context, in *s3.CompleteMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	f.completed = len(in.MultipartUpload.Parts)
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (f *fakeUploader) AbortMultipartUpload(ctx context.Context, in *s3.AbortMultipartUploadInput, _ ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	f.aborted = true
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (f *fakeUploader) DeleteObject(ctx context.Context, in *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestUploadStream(t *testing.T) {
+	settings := config.MultipartSettings{
+		ThresholdBytes: config.MinPartSize,
+		PartSizeBytes:  config.MinPartSize,
+		MaxParts:       config.DefaultMaxParts,
+	}
+	small := bytes.Repeat([]byte("a"), 1024)
+	large := bytes.Repeat([]byte("0123456789"), int(2*config.MinPartSize/10)+7)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		failPart  int32
+		wantParts int
+		wantErr   bool
+	}{
+		{name: "below threshold uses PutObject", data: small},
+		{name: "above threshold uses parts", data: large, wantParts: 3},
+		{name: "failed part aborts", data: large, failPart: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeUploader{failPart: tt.failPart}
+			err := uploadStream(context.Background(), api, settings, "bucket", "key", nil, bytes.NewReader(tt.data), int64(len(tt.data)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("uploadStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !api.aborted {
+					t.Fatal("failed multipart upload was not aborted")
+				}
+				return
+			}
+			if tt.wantParts == 0 {
+				if !bytes.Equal(api.put, tt.data) {
+					t.Fatal("PutObject body differs from source")
+				}
+				return
+			}
+			if api.completed != tt.wantParts {
+				t.Fatalf("completed %d parts, want %d", api.completed, tt.wantParts)
+			}
+			var got []byte
+			for i := int32(1); i <= int32(tt.wantParts); i++ {
+				got = append(got, api.parts[i]...)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Fatal("reassembled parts differ from source")
+			}
+		})
+	}
+}
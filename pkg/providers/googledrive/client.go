@@ -6,7 +6,6 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -20,6 +19,8 @@ type Client struct {
 	ctx         context.Context
 	oauthConfig *oauth2.Config
 	token       *oauth2.Token
+	limiter     *rateLimiter
+	httpClient  *http.Client // Authorized client for export links outside the Drive API
 }
 
 // FileInfo represents a Google Drive file
@@ -31,15 +32,29 @@ type FileInfo struct {
 	ModifiedTime time.Time `json:"modified_time"`
 	Parents      []string  `json:"parents"`
 	IsFolder     bool      `json:"is_folder"`
+
+	// Shortcut target (set only for application/vnd.google-apps.shortcut entries)
+	ShortcutTargetID       string `json:"shortcut_target_id,omitempty"`
+	ShortcutTargetMimeType string `json:"shortcut_target_mime_type,omitempty"`
+
+	// Additional paths under which the same file was discovered (shared via multiple folders or shortcuts)
+	AliasPaths []string `json:"alias_paths,omitempty"`
 }
 
+// Google Drive mime types with special handling
+const (
+	folderMimeType   = "application/vnd.google-apps.folder"
+	shortcutMimeType = "application/vnd.google-apps.shortcut"
+)
+
 // Config holds Google Drive client configuration
 type Config struct {
-	ClientID     string `json:"client_id"`
-	ClientSecret string `json:"client_secret"`
-	RedirectURL  string `json:"redirect_url"`
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	ClientID     string  `json:"client_id"`
+	ClientSecret string  `json:"client_secret"`
+	RedirectURL  string  `json:"redirect_url"`
+	AccessToken  string  `json:"access_token"`
+	RefreshToken string  `json:"refresh_token"`
+	MaxQPS       float64 `json:"max_qps,omitempty"` // Drive API request budget (default: DefaultMaxQPS)
 }
 
 // NewClient creates a new Google Drive client
@@ -87,18 +102,18 @@ func NewClient(ctx context.Context, config Config) (*Client, error) {
 	// Even 3 workers exceeded 2Gi limit
 	httpClient := &http.Client{
 		Transport: &http.Transport{
-			MaxIdleConns:        4,                 // Absolute minimum for 1 worker
-			MaxIdleConnsPerHost: 2,                 // Absolute minimum
-			IdleConnTimeout:     90 * time.Second,  // Keep connections alive longer
-			TLSHandshakeTimeout: 10 * time.Second,  // Faster TLS handshake
-			DisableCompression:  false,             // Enable compression for efficiency
+			MaxIdleConns:        4,                // Absolute minimum for 1 worker
+			MaxIdleConnsPerHost: 2,                // Absolute minimum
+			IdleConnTimeout:     90 * time.Second, // Keep connections alive longer
+			TLSHandshakeTimeout: 10 * time.Second, // Faster TLS handshake
+			DisableCompression:  false,            // Enable compression for efficiency
 		},
 		Timeout: 30 * time.Second, // Reasonable timeout for API calls
 	}
-	
+
 	// Create context with optimized HTTP client
 	tokenCtx := context.WithValue(ctx, oauth2.HTTPClient, httpClient)
-	
+
 	// Create HTTP client with token - this will automatically refresh tokens
 	client := oauthConfig.Client(tokenCtx, token)
 
@@ -113,6 +128,8 @@ func NewClient(ctx context.Context, config Config) (*Client, error) {
 		ctx:         ctx,
 		oauthConfig: oauthConfig,
 		token:       token,
+		limiter:     newRateLimiter(config.MaxQPS),
+		httpClient:  client,
 	}, nil
 }
 
@@ -124,7 +141,7 @@ func (c *Client) refreshToken() error {
 
 	// Create a new token source
 	tokenSource := c.oauthConfig.TokenSource(c.ctx, c.token)
-	
+
 	// Get a fresh token
 	newToken, err := tokenSource.Token()
 	if err != nil {
@@ -138,7 +155,7 @@ func (c *Client) refreshToken() error {
 
 	// Update the stored token
 	c.token = newToken
-	
+
 	// Token refreshed successfully (removed verbose logging for better UX)
 	return nil
 }
@@ -164,7 +181,7 @@ func (c *Client) ListFilesWithTokenAndOptions(folderID string, pageSize int64, p
 		// Only include files owned by user (not shared files)
 		query = "trashed=false and 'me' in owners"
 	}
-	
+
 	if folderID != "" {
 		query += fmt.Sprintf(" and '%s' in parents", folderID)
 	}
@@ -172,7 +189,7 @@ func (c *Client) ListFilesWithTokenAndOptions(folderID string, pageSize int64, p
 	// Create list call
 	call := c.service.Files.List().
 		Q(query).
-		Fields("nextPageToken, files(id, name, size, mimeType, modifiedTime, parents)").
+		Fields("nextPageToken, files(id, name, size, mimeType, modifiedTime, parents, shortcutDetails(targetId, targetMimeType))").
 		PageSize(pageSize)
 
 	// Add page token if provided
@@ -180,36 +197,14 @@ func (c *Client) ListFilesWithTokenAndOptions(folderID string, pageSize int64, p
 		call = call.PageToken(pageToken)
 	}
 
-	// Execute the call with retry logic for auth errors
+	// Execute the call with retry logic for auth and rate-limit errors
 	var result *drive.FileList
-	var err error
-	maxRetries := 3
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		result, err = call.Do()
-		if err == nil {
-			break // Success!
-		}
-
-		// Check if it's an auth error (token expired)
-		if attempt < maxRetries && (strings.Contains(err.Error(), "401") || 
-			strings.Contains(err.Error(), "Invalid Credentials") ||
-			strings.Contains(err.Error(), "authError")) {
-			
-			// Try to refresh the token manually (silent retry for better UX)
-			if refreshErr := c.refreshToken(); refreshErr != nil {
-				// If refresh token is expired, don't retry - fail immediately with clear message
-				if strings.Contains(refreshErr.Error(), "please re-authenticate") {
-					fmt.Printf("❌ Authentication expired: %v\n", refreshErr)
-					return nil, "", fmt.Errorf("authentication expired - %w", refreshErr)
-				}
-				// Continue with retry for other refresh errors
-			}
-			
-			time.Sleep(time.Duration(attempt) * time.Second)
-			continue
-		}
-
-		// Non-auth error or max retries reached
+	err := c.call(func() error {
+		var callErr error
+		result, callErr = call.Do()
+		return callErr
+	})
+	if err != nil {
 		return nil, "", fmt.Errorf("failed to list files: %w", err)
 	}
 
@@ -234,7 +229,13 @@ func (c *Client) ListFilesWithTokenAndOptions(folderID string, pageSize int64, p
 		}
 
 		// Check if it's a folder
-		fileInfo.IsFolder = file.MimeType == "application/vnd.google-apps.folder"
+		fileInfo.IsFolder = file.MimeType == folderMimeType
+
+		// Record shortcut target so discovery can resolve it
+		if file.MimeType == shortcutMimeType && file.ShortcutDetails != nil {
+			fileInfo.ShortcutTargetID = file.ShortcutDetails.TargetId
+			fileInfo.ShortcutTargetMimeType = file.ShortcutDetails.TargetMimeType
+		}
 
 		files = append(files, fileInfo)
 	}
@@ -246,32 +247,12 @@ func (c *Client) ListFilesWithTokenAndOptions(folderID string, pageSize int64, p
 func (c *Client) GetFile(fileID string) (io.ReadCloser, error) {
 	// First, get file metadata to check mime type with retry logic
 	var file *drive.File
-	var err error
-	maxRetries := 3
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		file, err = c.service.Files.Get(fileID).Fields("id, mimeType").Do()
-		if err == nil {
-			break // Success!
-		}
-
-		// Check if it's an auth error (token expired)
-		if attempt < maxRetries && (strings.Contains(err.Error(), "401") || 
-			strings.Contains(err.Error(), "Invalid Credentials") ||
-			strings.Contains(err.Error(), "authError")) {
-			
-			// Try to refresh the token manually (silent retry for better UX)
-			if refreshErr := c.refreshToken(); refreshErr != nil {
-				// If refresh token is expired, don't retry - fail immediately with clear message
-				if strings.Contains(refreshErr.Error(), "please re-authenticate") {
-					return nil, fmt.Errorf("authentication expired - %w", refreshErr)
-				}
-			}
-			
-			time.Sleep(time.Duration(attempt) * time.Second)
-			continue
-		}
-
-		// Non-auth error or max retries reached
+	err := c.call(func() error {
+		var callErr error
+		file, callErr = c.service.Files.Get(fileID).Fields("id, mimeType").Do()
+		return callErr
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to get file metadata: %w", err)
 	}
 
@@ -280,63 +261,41 @@ func (c *Client) GetFile(fileID string) (io.ReadCloser, error) {
 	if exportMimeType != "" {
 		// Export Google Workspace file with retry logic
 		var resp *http.Response
-		maxRetries := 3
-		for attempt := 1; attempt <= maxRetries; attempt++ {
-			resp, err = c.service.Files.Export(fileID, exportMimeType).Download()
-			if err == nil {
-				break // Success!
-			}
+		err = c.call(func() error {
+			var callErr error
+			resp, callErr = c.service.Files.Export(fileID, exportMimeType).Download()
+			return callErr
+		})
+		if err == nil {
+			return resp.Body, nil
+		}
 
-			// Check if it's an auth error (token expired)
-			if attempt < maxRetries && (strings.Contains(err.Error(), "401") || 
-				strings.Contains(err.Error(), "Invalid Credentials") ||
-				strings.Contains(err.Error(), "authError")) {
-				
-				// Try to refresh the token manually (silent retry for better UX)
-				if refreshErr := c.refreshToken(); refreshErr != nil {
-					// If refresh token is expired, don't retry - fail immediately with clear message
-					if strings.Contains(refreshErr.Error(), "please re-authenticate") {
-						return nil, fmt.Errorf("authentication expired - %w", refreshErr)
-					}
-				}
-				
-				time.Sleep(time.Duration(attempt) * time.Second)
-				continue
+		reason := unexportableReason(err)
+		if reason == ReasonExportSizeExceeded {
+			// Too large for files.export; the export link serves larger files
+			body, linkErr := c.exportViaLink(fileID, exportMimeType)
+			if linkErr == nil {
+				return body, nil
 			}
-
-			// Non-auth error or max retries reached
-			return nil, fmt.Errorf("failed to export file: %w", err)
+			return nil, &UnexportableError{Reason: reason, Err: fmt.Errorf("export link fallback failed: %w", linkErr)}
 		}
-		return resp.Body, nil
+		if reason != "" {
+			return nil, &UnexportableError{Reason: reason, Err: err}
+		}
+		return nil, fmt.Errorf("failed to export file: %w", err)
 	}
 
 	// Regular file - download directly with retry logic
 	var resp *http.Response
-	maxRetries = 3
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		resp, err = c.service.Files.Get(fileID).Download()
-		if err == nil {
-			break // Success!
-		}
-
-		// Check if it's an auth error (token expired)
-		if attempt < maxRetries && (strings.Contains(err.Error(), "401") || 
-			strings.Contains(err.Error(), "Invalid Credentials") ||
-			strings.Contains(err.Error(), "authError")) {
-			
-			// Try to refresh the token manually (silent retry for better UX)
-			if refreshErr := c.refreshToken(); refreshErr != nil {
-				// If refresh token is expired, don't retry - fail immediately with clear message
-				if strings.Contains(refreshErr.Error(), "please re-authenticate") {
-					return nil, fmt.Errorf("authentication expired - %w", refreshErr)
-				}
-			}
-			
-			time.Sleep(time.Duration(attempt) * time.Second)
-			continue
+	err = c.call(func() error {
+		var callErr error
+		resp, callErr = c.service.Files.Get(fileID).Download()
+		return callErr
+	})
+	if err != nil {
+		if reason := unexportableReason(err); reason != "" {
+			return nil, &UnexportableError{Reason: reason, Err: err}
 		}
-
-		// Non-auth error or max retries reached
 		return nil, fmt.Errorf("failed to download file: %w", err)
 	}
 	return resp.Body, nil
@@ -364,34 +323,14 @@ func (c *Client) getExportMimeType(mimeType string) string {
 func (c *Client) GetFileInfo(fileID string) (*FileInfo, error) {
 	// Get file info with retry logic
 	var file *drive.File
-	var err error
-	maxRetries := 3
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		file, err = c.service.Files.Get(fileID).
+	err := c.call(func() error {
+		var callErr error
+		file, callErr = c.service.Files.Get(fileID).
 			Fields("id, name, size, mimeType, modifiedTime, parents").
 			Do()
-		if err == nil {
-			break // Success!
-		}
-
-		// Check if it's an auth error (token expired)
-		if attempt < maxRetries && (strings.Contains(err.Error(), "401") || 
-			strings.Contains(err.Error(), "Invalid Credentials") ||
-			strings.Contains(err.Error(), "authError")) {
-			
-			// Try to refresh the token manually (silent retry for better UX)
-			if refreshErr := c.refreshToken(); refreshErr != nil {
-				// If refresh token is expired, don't retry - fail immediately with clear message
-				if strings.Contains(refreshErr.Error(), "please re-authenticate") {
-					return nil, fmt.Errorf("authentication expired - %w", refreshErr)
-				}
-			}
-			
-			time.Sleep(time.Duration(attempt) * time.Second)
-			continue
-		}
-
-		// Non-auth error or max retries reached
+		return callErr
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to get file info: %w", err)
 	}
 
@@ -402,8 +341,8 @@ func (c *Client) GetFileInfo(fileID string) (*FileInfo, error) {
 		Parents:  file.Parents,
 	}
 
-		// Set size (Google Drive API returns size as int64)
-		fileInfo.Size = file.Size
+	// Set size (Google Drive API returns size as int64)
+	fileInfo.Size = file.Size
 
 	// Parse modified time
 	if file.ModifiedTime != "" {
@@ -413,7 +352,7 @@ func (c *Client) GetFileInfo(fileID string) (*FileInfo, error) {
 	}
 
 	// Check if it's a folder
-	fileInfo.IsFolder = file.MimeType == "application/vnd.google-apps.folder"
+	fileInfo.IsFolder = file.MimeType == folderMimeType
 
 	return fileInfo, nil
 }
@@ -431,7 +370,12 @@ func (c *Client) ListFolders(parentFolderID string) ([]FileInfo, error) {
 		Fields("files(id, name, mimeType, modifiedTime, parents)").
 		PageSize(1000) // Folders are usually fewer
 
-	result, err := call.Do()
+	var result *drive.FileList
+	err := c.call(func() error {
+		var callErr error
+		result, callErr = call.Do()
+		return callErr
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list folders: %w", err)
 	}
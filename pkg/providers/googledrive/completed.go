@@ -0,0 +1,183 @@
+package googledrive
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"s3migration/pkg/structures"
+)
+
+// A completed log is written next to the discovery manifest: log.json when the
+// upload phase of a task starts, then numbered chunks of the keys uploaded since
+const (
+	completedFlushKeys     = 500              // Keys per chunk at most
+	completedFlushInterval = 30 * time.Second // A chunk is written at least this often while uploading
+)
+
+// completedLogHeader is stored as log.json
+type completedLogHeader struct {
+	StartedAt time.Time `json:"started_at"`
+	// The task skipped objects already in the bucket, so keys missing from the
+	// log may still have been uploaded by an earlier run
+	SkipExisting bool `json:"skip_existing"`
+}
+
+// completedLogPrefix is where the completed log of a task with this discovery manifest is kept
+func completedLogPrefix(manifestKey string) string {
+	return strings.TrimSuffix(manifestKey, ".json.gz") + ".completed/"
+}
+
+// completedLog records the keys a task has uploaded, so that a resumed run
+// filters them out in memory instead of issuing a HEAD per file
+type completedLog struct {
+	m      *GoogleDriveMigrator
+	bucket string
+	prefix string
+	keys   *structures.KeySet
+	// Keys missing from the log still need a HEAD check before they are skipped
+	checkUnlogged bool
+
+	mu        sync.Mutex
+	next      int // Number of the next chunk
+	lastFlush time.Time
+}
+
+// openCompletedLog starts the completed log of a fresh task or loads the one of a
+// resumed task. Nil without a manifest, for dry runs, and for resumed tasks whose
+// log is missing (started before it existed); those fall back to HEAD checks.
+func (m *GoogleDriveMigrator) openCompletedLog(input MigrationInput) *completedLog {
+	if input.ManifestKey == "" || input.DryRun {
+		return nil
+	}
+	log := &completedLog{
+		m:         m,
+		bucket:    input.DestBucket,
+		prefix:    completedLogPrefix(input.ManifestKey),
+		keys:      structures.NewKeySet(),
+		lastFlush: time.Now(),
+	}
+
+	if !input.ResumeFromManifest {
+		header, _ := json.Marshal(completedLogHeader{StartedAt: time.Now().UTC(), SkipExisting: input.SkipExisting})
+		if err := log.put("log.json", header); err != nil {
+			fmt.Printf("⚠️  %v (a restart will check uploaded files one by one)\n", err)
+			return nil
+		}
+		log.checkUnlogged = input.SkipExisting
+		return log
+	}
+
+	data, err := log.get("log.json")
+	if err != nil {
+		if !isNotFound(err) {
+			fmt.Printf("⚠️  %v (checking uploaded files one by one)\n", err)
+		}
+		return nil
+	}
+	var header completedLogHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		fmt.Printf("⚠️  Invalid completed log %slog.json: %v (checking uploaded files one by one)\n", log.prefix, err)
+		return nil
+	}
+	log.checkUnlogged = header.SkipExisting
+	for ; ; log.next++ {
+		chunk, err := log.get(completedChunkName(log.next))
+		if isNotFound(err) {
+			break
+		}
+		if err == nil {
+			err = log.keys.LoadChunk(chunk)
+		}
+		if err != nil {
+			// Later chunks cannot be trusted to follow on; keys missing from the log are re-uploaded
+			fmt.Printf("⚠️  Completed log %s read up to chunk %d: %v\n", log.prefix, log.next, err)
+			break
+		}
+	}
+	return log
+}
+
+func completedChunkName(n int) string {
+	return fmt.Sprintf("%06d.bin", n)
+}
+
+// done reports whether a key is in the log
+func (l *completedLog) done(key string) bool {
+	return l != nil && l.keys.Contains(key)
+}
+
+// add records an uploaded key, writing a chunk when enough keys or time accumulated
+func (l *completedLog) add(key string) {
+	if l == nil {
+		return
+	}
+	l.keys.Add(key)
+	if l.keys.Pending() >= completedFlushKeys || time.Since(l.lastFlush) >= completedFlushInterval {
+		if err := l.flush(); err != nil {
+			fmt.Printf("⚠️  %v (will retry with the next chunk)\n", err)
+		}
+	}
+}
+
+// flush writes the keys recorded since the last chunk as a new chunk
+func (l *completedLog) flush() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lastFlush = time.Now()
+	chunk := l.keys.TakeChunk()
+	if chunk == nil {
+		return nil
+	}
+	if err := l.put(completedChunkName(l.next), chunk); err != nil {
+		l.keys.Requeue(chunk) // Part of the next chunk
+		return err
+	}
+	l.next++
+	return nil
+}
+
+func (l *completedLog) put(name string, data []byte) error {
+	_, err := l.m.s3Client.PutObject(l.m.ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(l.bucket),
+		Key:         aws.String(l.prefix + name),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/octet-stream"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write completed log %s%s: %w", l.prefix, name, err)
+	}
+	return nil
+}
+
+func (l *completedLog) get(name string) ([]byte, error) {
+	output, err := l.m.s3Client.GetObject(l.m.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(l.bucket),
+		Key:    aws.String(l.prefix + name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read completed log %s%s: %w", l.prefix, name, err)
+	}
+	defer output.Body.Close()
+	return io.ReadAll(output.Body)
+}
+
+// isNotFound reports whether err is S3's answer for a missing key
+func isNotFound(err error) bool {
+	var noSuchKey *types.NoSuchKey
+	var response interface{ HTTPStatusCode() int }
+	return errors.As(err, &noSuchKey) || (errors.As(err, &response) && response.HTTPStatusCode() == http.StatusNotFound)
+}
@@ -0,0 +1,78 @@
+package googledrive
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCompletedLogResume(t *testing.T) {
+	tests := []struct {
+		name              string
+		skipExisting      bool
+		wantCheckUnlogged bool
+	}{
+		{name: "full copy", wantCheckUnlogged: false},
+		{name: "incremental", skipExisting: true, wantCheckUnlogged: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := &fakeBucket{}
+			m := bucket.migrator(t)
+			input := MigrationInput{DestBucket: "dest", ManifestKey: DiscoveryManifestKey("task-1"), SkipExisting: tt.skipExisting}
+
+			log := m.openCompletedLog(input)
+			if log == nil {
+				t.Fatal("no completed log for a fresh task")
+			}
+			for i := 0; i < completedFlushKeys+10; i++ {
+				log.add(fmt.Sprintf("Docs/%d.txt", i))
+			}
+			if err := log.flush(); err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range []string{"log.json", "000000.bin", "000001.bin"} {
+				if _, ok := bucket.objects["/dest/manifests/drive-discovery/task-1.completed/"+key]; !ok {
+					t.Fatalf("%s not written: %v", key, bucket.objects)
+				}
+			}
+
+			// After a restart every logged key is known without a request per file
+			input.ResumeFromManifest, input.SkipExisting = true, true
+			resumed := m.openCompletedLog(input)
+			if resumed == nil || resumed.keys.Len() != completedFlushKeys+10 || resumed.next != 2 {
+				t.Fatalf("resumed log = %+v", resumed)
+			}
+			if !resumed.done("Docs/0.txt") || !resumed.done(fmt.Sprintf("Docs/%d.txt", completedFlushKeys+9)) || resumed.done("Docs/new.txt") {
+				t.Fatal("resumed log does not match the uploads")
+			}
+			if resumed.checkUnlogged != tt.wantCheckUnlogged {
+				t.Fatalf("checkUnlogged = %v, want %v", resumed.checkUnlogged, tt.wantCheckUnlogged)
+			}
+
+			// Uploads after the restart continue the log
+			resumed.add("Docs/new.txt")
+			resumed.flush()
+			if _, ok := bucket.objects["/dest/manifests/drive-discovery/task-1.completed/000002.bin"]; !ok {
+				t.Fatal("resumed log not continued")
+			}
+		})
+	}
+}
+
+func TestCompletedLogMissing(t *testing.T) {
+	bucket := &fakeBucket{}
+	m := bucket.migrator(t)
+
+	// Tasks started before the log existed fall back to HEAD checks
+	if log := m.openCompletedLog(MigrationInput{DestBucket: "dest", ManifestKey: DiscoveryManifestKey("old"), ResumeFromManifest: true}); log != nil {
+		t.Fatalf("log for a task without one: %+v", log)
+	}
+	if log := m.openCompletedLog(MigrationInput{DestBucket: "dest", ManifestKey: DiscoveryManifestKey("dry"), DryRun: true}); log != nil {
+		t.Fatal("log for a dry run")
+	}
+	var none *completedLog
+	none.add("a.txt")
+	if none.done("a.txt") || none.flush() != nil {
+		t.Fatal("nil log is not a no-op")
+	}
+}
@@ -0,0 +1,108 @@
+package googledrive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// Drive error reasons for files that cannot be handed over as bytes
+const (
+	ReasonExportSizeExceeded  = "exportSizeExceeded"  // Workspace file above the files.export size limit (10MB)
+	ReasonFileNotDownloadable = "fileNotDownloadable" // No binary content and no export format (forms, sites, maps, ...)
+	ReasonCannotExportFile    = "cannotExportFile"    // Export disabled by the owner or not supported for the file
+)
+
+// UnexportableError is returned for a file Drive will not export or download
+type UnexportableError struct {
+	Reason string
+	Err    error
+}
+
+func (e *UnexportableError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Reason, e.Err)
+}
+
+func (e *UnexportableError) Unwrap() error {
+	return e.Err
+}
+
+// UnexportableFile is a file left out of a migration because Drive cannot provide its content
+type UnexportableFile struct {
+	FileID   string `json:"file_id"`
+	Path     string `json:"path"`
+	MimeType string `json:"mime_type"`
+	Reason   string `json:"reason"`
+	Error    string `json:"error"`
+}
+
+// unexportableReason returns the Drive reason when err means the file can never be
+// downloaded as-is, or "" for other (possibly transient) errors
+func unexportableReason(err error) string {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return ""
+	}
+	for _, item := range apiErr.Errors {
+		switch item.Reason {
+		case ReasonExportSizeExceeded, ReasonFileNotDownloadable, ReasonCannotExportFile:
+			return item.Reason
+		}
+	}
+	// Download responses carry no structured error items
+	text := apiErr.Message + " " + apiErr.Body
+	switch {
+	case strings.Contains(text, ReasonExportSizeExceeded), strings.Contains(text, "too large to be exported"):
+		return ReasonExportSizeExceeded
+	case strings.Contains(text, ReasonFileNotDownloadable), strings.Contains(text, "Only files with binary content"):
+		return ReasonFileNotDownloadable
+	case strings.Contains(text, ReasonCannotExportFile):
+		return ReasonCannotExportFile
+	}
+	return ""
+}
+
+// exportViaLink downloads a Workspace file through its exportLinks entry, which
+// is not bound by the size limit of files.export
+func (c *Client) exportViaLink(fileID, exportMimeType string) (io.ReadCloser, error) {
+	var file *drive.File
+	err := c.call(func() error {
+		var callErr error
+		file, callErr = c.service.Files.Get(fileID).Fields("exportLinks").Do()
+		return callErr
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get export links: %w", err)
+	}
+	link := file.ExportLinks[exportMimeType]
+	if link == "" {
+		return nil, fmt.Errorf("no export link for %s", exportMimeType)
+	}
+
+	var resp *http.Response
+	err = c.call(func() error {
+		req, reqErr := http.NewRequestWithContext(c.ctx, http.MethodGet, link, nil)
+		if reqErr != nil {
+			return reqErr
+		}
+		var callErr error
+		resp, callErr = c.httpClient.Do(req)
+		if callErr != nil {
+			return callErr
+		}
+		if callErr = googleapi.CheckResponse(resp); callErr != nil {
+			resp.Body.Close()
+			return callErr
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download export link: %w", err)
+	}
+	return resp.Body, nil
+}
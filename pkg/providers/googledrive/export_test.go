@@ -0,0 +1,94 @@
+package googledrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+const docxMimeType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+// fakeDrive serves a Google Doc whose files.export is rejected as too large
+func fakeDrive(t *testing.T, exportLink bool) *Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("/files/doc", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fields") == "exportLinks" {
+			links := "{}"
+			if exportLink {
+				links = fmt.Sprintf(`{%q: %q}`, docxMimeType, server.URL+"/export-link")
+			}
+			fmt.Fprintf(w, `{"exportLinks": %s}`, links)
+			return
+		}
+		fmt.Fprint(w, `{"id": "doc", "mimeType": "application/vnd.google-apps.document"}`)
+	})
+	mux.HandleFunc("/files/doc/export", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"error": {"code": 403, "message": "This file is too large to be exported.", "errors": [{"reason": "exportSizeExceeded"}]}}`)
+	})
+	mux.HandleFunc("/export-link", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "docx bytes")
+	})
+
+	ctx := context.Background()
+	service, err := drive.NewService(ctx, option.WithHTTPClient(server.Client()), option.WithEndpoint(server.URL+"/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{service: service, ctx: ctx, limiter: newRateLimiter(1000), httpClient: server.Client()}
+}
+
+func TestGetFileFallsBackToExportLink(t *testing.T) {
+	client := fakeDrive(t, true)
+	body, err := client.GetFile("doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	data, _ := io.ReadAll(body)
+	if string(data) != "docx bytes" {
+		t.Fatalf("body = %q", data)
+	}
+}
+
+func TestGetFileReportsUnexportable(t *testing.T) {
+	client := fakeDrive(t, false)
+	_, err := client.GetFile("doc")
+	var unexportable *UnexportableError
+	if !errors.As(err, &unexportable) || unexportable.Reason != ReasonExportSizeExceeded {
+		t.Fatalf("GetFile() error = %v, want an exportSizeExceeded UnexportableError", err)
+	}
+}
+
+func TestUnexportableReason(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"structured", &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "exportSizeExceeded"}}}, ReasonExportSizeExceeded},
+		{"download body", &googleapi.Error{Code: 403, Body: "Only files with binary content can be downloaded"}, ReasonFileNotDownloadable},
+		{"cannot export", &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "cannotExportFile"}}}, ReasonCannotExportFile},
+		{"rate limit", &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "userRateLimitExceeded"}}}, ""},
+		{"not an API error", errors.New("connection reset"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unexportableReason(fmt.Errorf("wrapped: %w", tt.err)); got != tt.want {
+				t.Fatalf("unexportableReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package googledrive
+
+import (
+	"path"
+	"strings"
+)
+
+// Filter restricts which Drive folders and files a migration enumerates.
+// Path patterns are matched segment by segment against the path relative to
+// the source folder using path.Match syntax ("Photos/2023", "*/Archive").
+// Mime type patterns may end in "/*" to match a whole family ("video/*").
+type Filter struct {
+	IncludePaths     []string
+	ExcludePaths     []string
+	IncludeMimeTypes []string
+	ExcludeMimeTypes []string
+}
+
+// IsEmpty returns true if the filter does not restrict anything
+func (f *Filter) IsEmpty() bool {
+	return f == nil ||
+		(len(f.IncludePaths) == 0 && len(f.ExcludePaths) == 0 &&
+			len(f.IncludeMimeTypes) == 0 && len(f.ExcludeMimeTypes) == 0)
+}
+
+// ShouldDescend reports whether the folder at folderPath needs to be listed.
+// Excluded subtrees and folders that cannot contain an included path are pruned
+// so their contents are never enumerated.
+func (f *Filter) ShouldDescend(folderPath string) bool {
+	if f.IsEmpty() {
+		return true
+	}
+
+	segments := splitPath(folderPath)
+	if matchesAnyPrefix(f.ExcludePaths, segments) {
+		return false
+	}
+	if len(f.IncludePaths) == 0 {
+		return true
+	}
+
+	for _, pattern := range f.IncludePaths {
+		patternSegments := splitPath(pattern)
+		if len(segments) <= len(patternSegments) {
+			// Folder is an ancestor of (or equal to) a possible match
+			if matchSegments(patternSegments[:len(segments)], segments) {
+				return true
+			}
+		} else if matchSegments(patternSegments, segments[:len(patternSegments)]) {
+			// Folder lies inside an included subtree
+			return true
+		}
+	}
+	return false
+}
+
+// ShouldInclude reports whether a file should be migrated
+func (f *Filter) ShouldInclude(filePath, mimeType string) bool {
+	if f.IsEmpty() {
+		return true
+	}
+
+	segments := splitPath(filePath)
+	if matchesAnyPrefix(f.ExcludePaths, segments) {
+		return false
+	}
+	if len(f.IncludePaths) > 0 && !matchesAnyPrefix(f.IncludePaths, segments) {
+		return false
+	}
+
+	if matchesAnyMimeType(f.ExcludeMimeTypes, mimeType) {
+		return false
+	}
+	if len(f.IncludeMimeTypes) > 0 && !matchesAnyMimeType(f.IncludeMimeTypes, mimeType) {
+		return false
+	}
+	return true
+}
+
+// matchesAnyPrefix returns true if some pattern matches the path or one of its ancestors
+func matchesAnyPrefix(patterns []string, segments []string) bool {
+	for _, pattern := range patterns {
+		patternSegments := splitPath(pattern)
+		if len(patternSegments) == 0 || len(patternSegments) > len(segments) {
+			continue
+		}
+		if matchSegments(patternSegments, segments[:len(patternSegments)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches path segments pairwise; both slices must have equal length
+func matchSegments(patternSegments, segments []string) bool {
+	for i, pattern := range patternSegments {
+		matched, err := path.Match(pattern, segments[i])
+		if err != nil || !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAnyMimeType returns true if mimeType matches one of the patterns
+func matchesAnyMimeType(patterns []string, mimeType string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "/*") {
+			if strings.HasPrefix(mimeType, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		} else if pattern == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// splitPath splits a slash-separated path into its non-empty segments
+func splitPath(p string) []string {
+	var segments []string
+	for _, segment := range strings.Split(strings.Trim(p, "/"), "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
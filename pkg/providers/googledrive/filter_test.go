@@ -0,0 +1,56 @@
+package googledrive
+
+import "testing"
+
+func TestShouldDescend(t *testing.T) {
+	filter := &Filter{
+		IncludePaths: []string{"Photos/2023", "*/Archive"},
+		ExcludePaths: []string{"Photos/2023/Private"},
+	}
+	tests := []struct {
+		folder string
+		want   bool
+	}{
+		{"", true},                          // Root is an ancestor of every include
+		{"Photos", true},                    // Ancestor of Photos/2023
+		{"Photos/2023", true},               // Included
+		{"Photos/2023/Trips", true},         // Inside an included subtree
+		{"Photos/2023/Private", false},      // Excluded subtree
+		{"Photos/2023/Private/Deep", false}, // Inside an excluded subtree
+		{"Photos/2022", false},              // Cannot contain an included path
+		{"Work/Archive", true},              // Wildcard segment
+		{"Work/Current", false},
+	}
+	for _, tt := range tests {
+		if got := filter.ShouldDescend(tt.folder); got != tt.want {
+			t.Errorf("ShouldDescend(%q) = %v, want %v", tt.folder, got, tt.want)
+		}
+	}
+}
+
+func TestShouldInclude(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   *Filter
+		path     string
+		mimeType string
+		want     bool
+	}{
+		{"nil filter", nil, "a.txt", "text/plain", true},
+		{"inside include", &Filter{IncludePaths: []string{"Docs"}}, "Docs/a.txt", "text/plain", true},
+		{"outside include", &Filter{IncludePaths: []string{"Docs"}}, "Other/a.txt", "text/plain", false},
+		{"excluded path", &Filter{ExcludePaths: []string{"*/tmp"}}, "Docs/tmp/a.txt", "text/plain", false},
+		{"mime family", &Filter{IncludeMimeTypes: []string{"video/*"}}, "clip.mp4", "video/mp4", true},
+		{"mime family miss", &Filter{IncludeMimeTypes: []string{"video/*"}}, "a.txt", "text/plain", false},
+		{"excluded mime wins", &Filter{IncludeMimeTypes: []string{"image/*"}, ExcludeMimeTypes: []string{"image/gif"}}, "a.gif", "image/gif", false},
+		{"exact mime", &Filter{ExcludeMimeTypes: []string{"application/pdf"}}, "a.pdf", "application/pdf", false},
+		{"slashes ignored", &Filter{IncludePaths: []string{"/Docs/"}}, "/Docs//a.txt", "text/plain", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.ShouldInclude(tt.path, tt.mimeType); got != tt.want {
+				t.Fatalf("ShouldInclude(%q, %q) = %v, want %v", tt.path, tt.mimeType, got, tt.want)
+			}
+		})
+	}
+}
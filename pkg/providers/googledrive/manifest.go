@@ -0,0 +1,103 @@
+package googledrive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// DiscoveredFile is one file found during discovery and the path it is migrated under
+type DiscoveredFile struct {
+	Info FileInfo `json:"info"`
+	Path string   `json:"path"`
+}
+
+// DiscoveryManifest is the result of a discovery, stored so a resumed migration
+// does not have to walk the whole Drive again
+type DiscoveryManifest struct {
+	FolderID     string           `json:"folder_id"`
+	DiscoveredAt time.Time        `json:"discovered_at"`
+	TotalSize    int64            `json:"total_size"`
+	Files        []DiscoveredFile `json:"files"`
+}
+
+// DiscoveryManifestKey is where the discovery manifest of a task is stored in the destination bucket
+func DiscoveryManifestKey(taskID string) string {
+	return "manifests/drive-discovery/" + taskID + ".json.gz"
+}
+
+// writeDiscoveryManifest stores the discovered files as gzipped JSON
+func (m *GoogleDriveMigrator) writeDiscoveryManifest(bucket, key string, manifest *DiscoveryManifest) error {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(writer).Encode(manifest); err != nil {
+		return fmt.Errorf("failed to encode discovery manifest: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to compress discovery manifest: %w", err)
+	}
+
+	_, err := m.s3Client.PutObject(m.ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(buf.Bytes()),
+		ContentType: aws.String("application/gzip"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write discovery manifest %s: %w", key, err)
+	}
+	return nil
+}
+
+// loadDiscoveryManifest reads a manifest written by writeDiscoveryManifest
+func (m *GoogleDriveMigrator) loadDiscoveryManifest(bucket, key string) (*DiscoveryManifest, error) {
+	output, err := m.s3Client.GetObject(m.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read discovery manifest %s: %w", key, err)
+	}
+	defer output.Body.Close()
+
+	reader, err := gzip.NewReader(output.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress discovery manifest: %w", err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress discovery manifest: %w", err)
+	}
+
+	var manifest DiscoveryManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode discovery manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// alreadyMigrated reports whether key already holds this Drive file from an earlier run:
+// same source file ID, same size (exported Google docs have no size) and not older than the file
+func (m *GoogleDriveMigrator) alreadyMigrated(file FileInfo, bucket, key string) bool {
+	head, err := m.s3Client.HeadObject(m.ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return false
+	}
+	if head.Metadata["source-file-id"] != file.ID {
+		return false
+	}
+	if file.Size > 0 && aws.ToInt64(head.ContentLength) != file.Size {
+		return false
+	}
+	return !aws.ToTime(head.LastModified).Before(file.ModifiedTime)
+}
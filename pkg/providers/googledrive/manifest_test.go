@@ -0,0 +1,117 @@
+package googledrive
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeBucket keeps uploaded objects in memory and serves them back
+type fakeBucket struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	headers map[string]http.Header
+}
+
+func (f *fakeBucket) migrator(t *testing.T) *GoogleDriveMigrator {
+	t.Helper()
+	f.objects = make(map[string][]byte)
+	f.headers = make(map[string]http.Header)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			f.objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodGet, http.MethodHead:
+			body, ok := f.objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			for k, v := range f.headers[r.URL.Path] {
+				w.Header()[k] = v
+			}
+			w.Write(body)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+	})
+	return NewGoogleDriveMigrator(context.Background(), nil, client)
+}
+
+func TestDiscoveryManifestRoundTrip(t *testing.T) {
+	bucket := &fakeBucket{}
+	m := bucket.migrator(t)
+	key := DiscoveryManifestKey("task-1")
+	written := &DiscoveryManifest{
+		FolderID:  "root",
+		TotalSize: 30,
+		Files: []DiscoveredFile{
+			{Info: FileInfo{ID: "f1", Name: "a.txt", Size: 10, AliasPaths: []string{"Shared/a.txt"}}, Path: "Docs/a.txt"},
+			{Info: FileInfo{ID: "f2", Name: "b.bin", Size: 20}, Path: "b.bin"},
+		},
+	}
+
+	if err := m.writeDiscoveryManifest("dest", key, written); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := m.loadDiscoveryManifest("dest", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.FolderID != "root" || loaded.TotalSize != 30 || len(loaded.Files) != 2 {
+		t.Fatalf("unexpected manifest: %+v", loaded)
+	}
+	if f := loaded.Files[0]; f.Path != "Docs/a.txt" || f.Info.ID != "f1" || len(f.Info.AliasPaths) != 1 {
+		t.Fatalf("unexpected first file: %+v", f)
+	}
+
+	if _, err := m.loadDiscoveryManifest("dest", DiscoveryManifestKey("missing")); err == nil {
+		t.Fatal("expected an error for a missing manifest")
+	}
+}
+
+func TestAlreadyMigrated(t *testing.T) {
+	bucket := &fakeBucket{}
+	m := bucket.migrator(t)
+	modified := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	bucket.objects["/dest/a.txt"] = []byte("0123456789")
+	bucket.headers["/dest/a.txt"] = http.Header{
+		"X-Amz-Meta-Source-File-Id": {"f1"},
+		"Last-Modified":             {modified.Add(time.Hour).Format(http.TimeFormat)},
+	}
+
+	tests := []struct {
+		name string
+		file FileInfo
+		key  string
+		want bool
+	}{
+		{"same file", FileInfo{ID: "f1", Size: 10, ModifiedTime: modified}, "a.txt", true},
+		{"exported doc without size", FileInfo{ID: "f1", ModifiedTime: modified}, "a.txt", true},
+		{"other file at key", FileInfo{ID: "f2", Size: 10, ModifiedTime: modified}, "a.txt", false},
+		{"size changed", FileInfo{ID: "f1", Size: 11, ModifiedTime: modified}, "a.txt", false},
+		{"modified since upload", FileInfo{ID: "f1", Size: 10, ModifiedTime: modified.Add(2 * time.Hour)}, "a.txt", false},
+		{"not uploaded", FileInfo{ID: "f1", Size: 10}, "b.txt", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.alreadyMigrated(tt.file, "dest", tt.key); got != tt.want {
+				t.Fatalf("alreadyMigrated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
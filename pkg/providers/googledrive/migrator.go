@@ -3,6 +3,7 @@ package googledrive
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"runtime"
@@ -13,6 +14,8 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"s3migration/pkg/buckets"
 )
 
 // GoogleDriveMigrator handles migration from Google Drive to S3
@@ -20,35 +23,45 @@ type GoogleDriveMigrator struct {
 	driveClient *Client
 	s3Client    *s3.Client
 	ctx         context.Context
-	
+
 	// Performance monitoring
-	startTime     time.Time
-	totalBytes    int64
+	startTime      time.Time
+	totalBytes     int64
 	bytesPerSecond float64
-	lastUpdate    time.Time
+	lastUpdate     time.Time
 }
 
 // MigrationInput contains parameters for Google Drive to S3 migration
 type MigrationInput struct {
-	SourceFolderID   string // Google Drive folder ID (empty = root folder)
-	DestBucket       string // S3 destination bucket
-	DestPrefix       string // S3 destination prefix
-	DryRun           bool   // If true, only simulate the migration
-	IncludeSharedFiles bool  // If true, include files shared with me (default: false)
-	ProgressCallback func(progress float64, copied, total int64, copiedSize, totalSize int64, speed float64, eta string)
+	SourceFolderID     string  // Google Drive folder ID (empty = root folder)
+	DestBucket         string  // S3 destination bucket
+	DestPrefix         string  // S3 destination prefix
+	DryRun             bool    // If true, only simulate the migration
+	IncludeSharedFiles bool    // If true, include files shared with me (default: false)
+	Filter             *Filter // Optional path and mime type filters applied during discovery
+	ProgressCallback   func(progress float64, copied, total int64, copiedSize, totalSize int64, speed float64, eta string)
+	// Called while discovering, before totals are known; ProgressCallback starts with the upload phase
+	DiscoveryCallback func(files, bytes, folders int64)
+
+	ManifestKey        string // Discovery manifest key in DestBucket (empty = not stored)
+	ResumeFromManifest bool   // Reuse the manifest at ManifestKey instead of discovering again
+	SkipExisting       bool   // Skip files an earlier run already uploaded
 }
 
 // MigrationResult contains the result of a migration
 type MigrationResult struct {
-	TotalFiles    int64 `json:"total_files"`
-	CopiedFiles   int64 `json:"copied_files"`
-	SkippedFiles  int64 `json:"skipped_files"`
-	FailedFiles   int64 `json:"failed_files"`
-	TotalSize     int64 `json:"total_size"`
-	CopiedSize    int64 `json:"copied_size"`
-	StartTime     time.Time `json:"start_time"`
-	EndTime       time.Time `json:"end_time"`
-	Duration      time.Duration `json:"duration"`
+	TotalFiles   int64         `json:"total_files"`
+	CopiedFiles  int64         `json:"copied_files"`
+	SkippedFiles int64         `json:"skipped_files"`
+	FailedFiles  int64         `json:"failed_files"`
+	TotalSize    int64         `json:"total_size"`
+	CopiedSize   int64         `json:"copied_size"`
+	StartTime    time.Time     `json:"start_time"`
+	EndTime      time.Time     `json:"end_time"`
+	Duration     time.Duration `json:"duration"`
+
+	// Files Drive cannot provide (too large to export, no binary content), included in SkippedFiles
+	SkippedUnexportable []UnexportableFile `json:"skipped_unexportable,omitempty"`
 }
 
 // NewGoogleDriveMigrator creates a new Google Drive migrator
@@ -71,6 +84,10 @@ func (m *GoogleDriveMigrator) Migrate(input MigrationInput) (*MigrationResult, e
 	fmt.Printf("Source Folder ID: %s\n", input.SourceFolderID)
 	fmt.Printf("Destination: s3://%s/%s\n", input.DestBucket, input.DestPrefix)
 	fmt.Printf("Dry Run: %v\n", input.DryRun)
+	if !input.Filter.IsEmpty() {
+		fmt.Printf("Filters: include=%v exclude=%v include_mime=%v exclude_mime=%v\n",
+			input.Filter.IncludePaths, input.Filter.ExcludePaths, input.Filter.IncludeMimeTypes, input.Filter.ExcludeMimeTypes)
+	}
 
 	// Ensure destination bucket exists
 	if !input.DryRun {
@@ -82,108 +99,156 @@ func (m *GoogleDriveMigrator) Migrate(input MigrationInput) (*MigrationResult, e
 	// Process files with streaming approach - optimized for 750 GB/day Google Drive limit
 	// Target: 31.25 MB/s sustained (750 GB/day)
 	fmt.Printf("⚡ Optimizing for Google Drive 750 GB/day limit (31.25 MB/s target)...\n")
-	
+
 	// Initialize performance monitoring
 	m.startTime = time.Now()
 	m.totalBytes = 0
 	m.bytesPerSecond = 0
 	m.lastUpdate = time.Now()
-	
+
 	// CRITICAL: Single worker mode - even 3 workers exceeded 2Gi limit
 	// 50 workers → 25 → 10 → 3 all caused OOM
 	// This is the absolute minimum - one file at a time
 	numCopyWorkers := 1 // Single worker - absolute minimum
-	
+
 	fmt.Printf("📋 Phase 1: Discovering all files (fast discovery without upload throttling)...\n")
-	
+
 	// Phase 1: Discover all files first (no uploads yet)
-	type FileToUpload struct {
-		Info FileInfo
-		Path string
-	}
-	var filesToUpload []FileToUpload
+	var filesToUpload []DiscoveredFile
 	var discoveryMu sync.Mutex
 	totalFiles := int64(0)
 	totalSize := int64(0)
-	
-	err := m.processFilesStreaming(input.SourceFolderID, input.IncludeSharedFiles, func(file FileInfo, filePath string) error {
-		// Skip folders
-		if file.IsFolder {
-			return nil
+	seenFiles := make(map[string]int) // file ID -> index in filesToUpload
+	duplicateFiles := int64(0)
+	foldersFound := int64(0)
+
+	// A resumed task reuses the files found before the restart
+	resumed := false
+	if input.ResumeFromManifest && input.ManifestKey != "" {
+		manifest, err := m.loadDiscoveryManifest(input.DestBucket, input.ManifestKey)
+		if err != nil {
+			fmt.Printf("⚠️  Cannot resume from discovery manifest, discovering again: %v\n", err)
+		} else {
+			filesToUpload = manifest.Files
+			totalFiles = int64(len(manifest.Files))
+			totalSize = manifest.TotalSize
+			resumed = true
+			fmt.Printf("📋 Resuming with %d files from discovery manifest %s\n", totalFiles, input.ManifestKey)
 		}
-		
-		// Just collect file metadata (no upload yet in Phase 1)
-		discoveryMu.Lock()
-		filesToUpload = append(filesToUpload, FileToUpload{Info: file, Path: filePath})
-		totalFiles++
-		totalSize += file.Size
-		
-		// Log discovery progress every 1000 files and send progress updates
-		if totalFiles%1000 == 0 {
-			fmt.Printf("🔍 Discovered %d files, total size: %.1f GB\n", 
-				totalFiles, float64(totalSize)/(1024*1024*1024))
+	}
+
+	if !resumed {
+		err := m.processFilesStreaming(input.SourceFolderID, input.IncludeSharedFiles, input.Filter, func(file FileInfo, filePath string) error {
+			// Skip folders
+			if file.IsFolder {
+				atomic.AddInt64(&foldersFound, 1)
+				return nil
+			}
+
+			// Just collect file metadata (no upload yet in Phase 1)
+			discoveryMu.Lock()
+
+			// Same file reached via another folder or a shortcut - record the alias, copy once
+			if index, seen := seenFiles[file.ID]; seen {
+				filesToUpload[index].Info.AliasPaths = append(filesToUpload[index].Info.AliasPaths, filePath)
+				duplicateFiles++
+				discoveryMu.Unlock()
+				return nil
+			}
+			seenFiles[file.ID] = len(filesToUpload)
+			filesToUpload = append(filesToUpload, DiscoveredFile{Info: file, Path: filePath})
+			totalFiles++
+			totalSize += file.Size
+
+			// Log discovery progress every 1000 files and send progress updates
+			if totalFiles%1000 == 0 {
+				fmt.Printf("🔍 Discovered %d files, total size: %.1f GB\n",
+					totalFiles, float64(totalSize)/(1024*1024*1024))
+			}
+
+			// Report discovery counts every 100 files; the total is unknown until discovery ends
+			if totalFiles%100 == 0 && input.DiscoveryCallback != nil {
+				input.DiscoveryCallback(totalFiles, totalSize, atomic.LoadInt64(&foldersFound))
+			}
+
+			discoveryMu.Unlock()
+
+			return nil
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to process files: %w", err)
 		}
-		
-		// Send discovery progress updates every 100 files for better UX
-		if totalFiles%100 == 0 && input.ProgressCallback != nil {
-			// Calculate discovery progress (assume we're discovering files)
-			discoveryProgress := float64(totalFiles) / float64(totalFiles+1000) * 100 // Estimate progress
-			eta := "discovering..."
-			speed := 0.0 // No upload speed during discovery
-			
-			input.ProgressCallback(discoveryProgress, totalFiles, totalFiles+1000, totalSize, totalSize+1024*1024*1024, speed, eta)
+
+		if input.ManifestKey != "" && !input.DryRun {
+			manifest := &DiscoveryManifest{FolderID: input.SourceFolderID, DiscoveredAt: time.Now().UTC(), TotalSize: totalSize, Files: filesToUpload}
+			if err := m.writeDiscoveryManifest(input.DestBucket, input.ManifestKey, manifest); err != nil {
+				fmt.Printf("⚠️  %v (a restart will discover again)\n", err)
+			}
 		}
-		
-		discoveryMu.Unlock()
-		
-		return nil
-	})
-	
-	if err != nil {
-		return result, fmt.Errorf("failed to process files: %w", err)
 	}
-	
+
 	// Update result with discovery totals
 	result.TotalFiles = totalFiles
 	result.TotalSize = totalSize
-	
+
 	fmt.Printf("✅ Discovery complete! Found %d files (%.2f GB)\n", totalFiles, float64(totalSize)/(1024*1024*1024))
+	if duplicateFiles > 0 {
+		fmt.Printf("🔗 Skipped %d duplicate entries (shared folders/shortcuts), recorded as alias paths\n", duplicateFiles)
+	}
 	fmt.Printf("🚀 Phase 2: Uploading files with %d concurrent workers (maximum throughput)...\n", numCopyWorkers)
-	
+
 	// Send discovery completion update
+	if input.DiscoveryCallback != nil {
+		input.DiscoveryCallback(totalFiles, totalSize, foldersFound)
+	}
 	if input.ProgressCallback != nil {
 		input.ProgressCallback(0.0, 0, totalFiles, 0, totalSize, 0.0, "starting upload...")
 	}
-	
+
+	// Files a resumed task uploaded before the restart are left out without a HEAD each
+	completed := m.openCompletedLog(input)
+	if completed != nil && completed.keys.Len() > 0 {
+		pending := make([]DiscoveredFile, 0, len(filesToUpload))
+		for _, file := range filesToUpload {
+			if completed.done(m.generateS3KeyWithPath(file.Path, file.Info.MimeType, input.DestPrefix)) {
+				result.SkippedFiles++
+				continue
+			}
+			pending = append(pending, file)
+		}
+		fmt.Printf("📋 %d files already uploaded before the restart, %d left\n", result.SkippedFiles, len(pending))
+		filesToUpload = pending
+	}
+
 	// Phase 2: Upload all discovered files with maximum throughput
 	semaphore := make(chan struct{}, numCopyWorkers)
 	var copyWg sync.WaitGroup
 	var resultMu sync.Mutex
-	
+
 	for fileIndex, fileToUpload := range filesToUpload {
 		copyWg.Add(1)
 		go func(index int, f FileInfo, path string) {
 			defer copyWg.Done()
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
-			
+
 			// Update progress
 			resultMu.Lock()
 			progress := float64(result.CopiedFiles+result.FailedFiles+result.SkippedFiles) / float64(result.TotalFiles) * 100
-		eta := m.calculateETA(result.StartTime, int64(result.CopiedFiles+result.FailedFiles+result.SkippedFiles), result.TotalFiles)
-		speed := m.calculateSpeed(result.StartTime, result.CopiedSize)
-		currentCount := result.CopiedFiles + result.FailedFiles + result.SkippedFiles
-		copiedSize := result.CopiedSize
-		totalSize := result.TotalSize
-		resultMu.Unlock()
-		
-		if input.ProgressCallback != nil {
-			input.ProgressCallback(progress, int64(currentCount), result.TotalFiles, copiedSize, totalSize, speed, eta)
-		}
+			eta := m.calculateETA(result.StartTime, int64(result.CopiedFiles+result.FailedFiles+result.SkippedFiles), result.TotalFiles)
+			speed := m.calculateSpeed(result.StartTime, result.CopiedSize)
+			currentCount := result.CopiedFiles + result.FailedFiles + result.SkippedFiles
+			copiedSize := result.CopiedSize
+			totalSize := result.TotalSize
+			resultMu.Unlock()
+
+			if input.ProgressCallback != nil {
+				input.ProgressCallback(progress, int64(currentCount), result.TotalFiles, copiedSize, totalSize, speed, eta)
+			}
 
 			// Log every 100 files or first 50
 			if index%100 == 0 || index <= 50 {
-				fmt.Printf("Processing [%d/%d] %s (%.2f MB)\n", 
+				fmt.Printf("Processing [%d/%d] %s (%.2f MB)\n",
 					index, result.TotalFiles, path, float64(f.Size)/(1024*1024))
 			}
 
@@ -198,12 +263,30 @@ func (m *GoogleDriveMigrator) Migrate(input MigrationInput) (*MigrationResult, e
 				return
 			}
 
+			// Uploaded before a restart (or by an earlier incremental run); not
+			// needed when the completed log accounts for every upload of the task
+			if input.SkipExisting && (completed == nil || completed.checkUnlogged) && m.alreadyMigrated(f, input.DestBucket, s3Key) {
+				completed.add(s3Key)
+				resultMu.Lock()
+				result.SkippedFiles++
+				resultMu.Unlock()
+				return
+			}
+
 			// Copy file to S3
 			if err := m.copyFileToS3(f, input.DestBucket, s3Key); err != nil {
-				if strings.Contains(err.Error(), "fileNotDownloadable") || 
-				   strings.Contains(err.Error(), "Only files with binary content") {
+				var unexportable *UnexportableError
+				if errors.As(err, &unexportable) {
+					fmt.Printf("  [SKIPPED] %s: %v\n", path, unexportable)
 					resultMu.Lock()
 					result.SkippedFiles++
+					result.SkippedUnexportable = append(result.SkippedUnexportable, UnexportableFile{
+						FileID:   f.ID,
+						Path:     path,
+						MimeType: f.MimeType,
+						Reason:   unexportable.Reason,
+						Error:    unexportable.Err.Error(),
+					})
 					resultMu.Unlock()
 				} else {
 					if index%100 == 0 || index <= 50 {
@@ -216,6 +299,7 @@ func (m *GoogleDriveMigrator) Migrate(input MigrationInput) (*MigrationResult, e
 				return
 			}
 
+			completed.add(s3Key)
 			resultMu.Lock()
 			result.CopiedFiles++
 			result.CopiedSize += f.Size
@@ -225,19 +309,22 @@ func (m *GoogleDriveMigrator) Migrate(input MigrationInput) (*MigrationResult, e
 			resultMu.Unlock()
 		}(fileIndex, fileToUpload.Info, fileToUpload.Path)
 	}
-	
+
 	// Wait for all uploads
 	copyWg.Wait()
-	
+	if err := completed.flush(); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	}
+
 	fmt.Printf("Found %d files total\n", result.TotalFiles)
 	fmt.Printf("Total size: %.2f MB\n", float64(result.TotalSize)/(1024*1024))
-	
+
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
 
 	// Final progress update
 	if input.ProgressCallback != nil {
-		input.ProgressCallback(100.0, result.CopiedFiles, result.TotalFiles, 
+		input.ProgressCallback(100.0, result.CopiedFiles, result.TotalFiles,
 			result.CopiedSize, result.TotalSize,
 			m.calculateSpeed(result.StartTime, result.CopiedSize), "Completed")
 	}
@@ -253,16 +340,16 @@ func (m *GoogleDriveMigrator) Migrate(input MigrationInput) (*MigrationResult, e
 	fmt.Printf("📦 Total size: %.2f GB\n", float64(result.TotalSize)/(1024*1024*1024))
 	fmt.Printf("✅ Copied size: %.2f GB\n", float64(result.CopiedSize)/(1024*1024*1024))
 	fmt.Printf("============================================\n\n")
-	
+
 	// Performance analysis for Google Drive 750 GB/day limit
 	if result.Duration > 0 {
-		avgSpeedMBps := float64(result.CopiedSize) / (1024*1024) / result.Duration.Seconds()
+		avgSpeedMBps := float64(result.CopiedSize) / (1024 * 1024) / result.Duration.Seconds()
 		dailyThroughput := avgSpeedMBps * 86400 / (1024 * 1024) // Convert to GB/day
-		
+
 		fmt.Printf("🚀 Performance Analysis:\n")
 		fmt.Printf("   Average Speed: %.2f MB/s\n", avgSpeedMBps)
 		fmt.Printf("   Daily Throughput: %.1f GB/day\n", dailyThroughput)
-		
+
 		if dailyThroughput >= 700 {
 			fmt.Printf("   ✅ Excellent: Near Google Drive 750 GB/day limit!\n")
 		} else if dailyThroughput >= 500 {
@@ -272,13 +359,13 @@ func (m *GoogleDriveMigrator) Migrate(input MigrationInput) (*MigrationResult, e
 		} else {
 			fmt.Printf("   ❌ Low: %.0f%% of Google Drive limit - consider optimizing\n", (dailyThroughput/750)*100)
 		}
-		
+
 		// Calculate time to complete full migration at this speed
 		if result.TotalSize > result.CopiedSize && avgSpeedMBps > 0 {
-			remainingMB := float64(result.TotalSize - result.CopiedSize) / (1024 * 1024)
+			remainingMB := float64(result.TotalSize-result.CopiedSize) / (1024 * 1024)
 			remainingHours := remainingMB / avgSpeedMBps / 3600
-			fmt.Printf("   📊 ETA for remaining %.1f GB: %.1f hours\n", 
-				float64(result.TotalSize - result.CopiedSize)/(1024*1024*1024), remainingHours)
+			fmt.Printf("   📊 ETA for remaining %.1f GB: %.1f hours\n",
+				float64(result.TotalSize-result.CopiedSize)/(1024*1024*1024), remainingHours)
 		}
 	}
 
@@ -292,13 +379,13 @@ func sanitizeMetadataValue(value string) string {
 	if len(value) > 1024 {
 		value = value[:1024] // S3 metadata value limit
 	}
-	
+
 	// Remove or replace characters that might cause issues with MinIO and other S3-compatible services
 	value = strings.ReplaceAll(value, "\n", " ")
 	value = strings.ReplaceAll(value, "\r", " ")
 	value = strings.ReplaceAll(value, "\t", " ")
 	value = strings.ReplaceAll(value, "\x00", "") // Remove null bytes
-	
+
 	// Remove non-printable characters
 	var result strings.Builder
 	for _, r := range value {
@@ -308,7 +395,7 @@ func sanitizeMetadataValue(value string) string {
 			result.WriteString("?") // Replace non-printable with safe character
 		}
 	}
-	
+
 	return strings.TrimSpace(result.String())
 }
 
@@ -357,7 +444,7 @@ func (m *GoogleDriveMigrator) getAllFilesRecursively(folderID string, includeSha
 			activeWorkers--
 			workersMu.Unlock()
 		}()
-		
+
 		for {
 			// Get next folder from queue
 			queueMu.Lock()
@@ -391,7 +478,7 @@ func (m *GoogleDriveMigrator) getAllFilesRecursively(folderID string, includeSha
 
 			// Log progress (only from worker 0 to avoid spam)
 			if workerID == 0 || currentFolderCount%10 == 0 {
-				fmt.Printf("📂 [%d/%d] Worker-%d: %s (Queue: %d, Files: %d)\n", 
+				fmt.Printf("📂 [%d/%d] Worker-%d: %s (Queue: %d, Files: %d)\n",
 					currentFolderCount, currentFolderCount+currentQueueSize, workerID, folderName, currentQueueSize, fileCount)
 			}
 
@@ -453,7 +540,7 @@ func (m *GoogleDriveMigrator) getAllFilesRecursively(folderID string, includeSha
 				queueSize := len(queue)
 				queueMu.Unlock()
 				estimatedRemaining := float64(queueSize) / rate
-				
+
 				fmt.Printf("\n💡 Progress Summary (Worker-%d):\n", workerID)
 				fmt.Printf("   Folders scanned: %d/%d (%.1f%%)\n", currentFolderCount, estimatedTotal, float64(currentFolderCount)/float64(estimatedTotal)*100)
 				fmt.Printf("   Files found: %d\n", fileCount)
@@ -466,33 +553,33 @@ func (m *GoogleDriveMigrator) getAllFilesRecursively(folderID string, includeSha
 	// Dynamic worker spawning based on queue size (similar to S3 approach)
 	// Start with 1 worker, spawn more as queue grows
 	fmt.Printf("🚀 Starting with adaptive worker pool (max: %d workers)...\n", maxWorkers)
-	
+
 	// Start first worker
 	wg.Add(1)
 	workersMu.Lock()
 	activeWorkers = 1
 	workersMu.Unlock()
 	go workerFunc(0)
-	
+
 	// Monitor queue and spawn additional workers as needed
 	go func() {
 		workerID := 1
 		checkInterval := 100 * time.Millisecond
-		
+
 		for {
 			time.Sleep(checkInterval)
-			
+
 			queueMu.Lock()
 			queueSize := len(queue)
 			queueMu.Unlock()
-			
+
 			// Exit if queue is empty and no workers active
 			workersMu.Lock()
 			if queueSize == 0 && activeWorkers == 0 {
 				workersMu.Unlock()
 				return
 			}
-			
+
 			// Smart worker scaling based on queue size
 			var desiredWorkers int
 			if queueSize == 0 {
@@ -506,14 +593,14 @@ func (m *GoogleDriveMigrator) getAllFilesRecursively(folderID string, includeSha
 			} else {
 				desiredWorkers = maxWorkers // Very large queue: max workers
 			}
-			
+
 			// Spawn new workers if needed
 			if activeWorkers < desiredWorkers && workerID < maxWorkers {
 				newWorkers := desiredWorkers - activeWorkers
 				if activeWorkers+newWorkers > maxWorkers {
 					newWorkers = maxWorkers - activeWorkers
 				}
-				
+
 				for i := 0; i < newWorkers; i++ {
 					if workerID >= maxWorkers {
 						break
@@ -521,7 +608,7 @@ func (m *GoogleDriveMigrator) getAllFilesRecursively(folderID string, includeSha
 					wg.Add(1)
 					currentWorkerID := workerID
 					activeWorkers++
-					fmt.Printf("⚡ Scaling up: Spawned Worker-%d (Queue: %d, Active: %d/%d)\n", 
+					fmt.Printf("⚡ Scaling up: Spawned Worker-%d (Queue: %d, Active: %d/%d)\n",
 						currentWorkerID, queueSize, activeWorkers, maxWorkers)
 					go workerFunc(currentWorkerID)
 					workerID++
@@ -535,7 +622,7 @@ func (m *GoogleDriveMigrator) getAllFilesRecursively(folderID string, includeSha
 	wg.Wait()
 
 	elapsed := time.Since(startTime)
-	fmt.Printf("✅ File listing completed: %d files, %d folders scanned in %v (%.1f folders/sec)\n", 
+	fmt.Printf("✅ File listing completed: %d files, %d folders scanned in %v (%.1f folders/sec)\n",
 		fileCount, folderCount, elapsed.Round(time.Second), float64(folderCount)/elapsed.Seconds())
 
 	return allFiles, nil
@@ -579,7 +666,7 @@ func (m *GoogleDriveMigrator) generateS3Key(file FileInfo, destPrefix string, al
 // generateS3KeyWithExtension generates an S3 key with proper extension for Google Workspace files
 func (m *GoogleDriveMigrator) generateS3KeyWithExtension(file FileInfo, destPrefix string, allFiles []FileInfo) string {
 	path := m.generateS3Key(file, destPrefix, allFiles)
-	
+
 	// Add appropriate extension for Google Workspace files
 	switch file.MimeType {
 	case "application/vnd.google-apps.document":
@@ -603,47 +690,85 @@ func (m *GoogleDriveMigrator) generateS3KeyWithExtension(file FileInfo, destPref
 			path += ".json"
 		}
 	}
-	
+
 	return path
 }
 
+// fileMetadata builds the S3 object metadata recorded for a migrated Drive file
+func fileMetadata(file FileInfo) map[string]string {
+	metadata := map[string]string{
+		"source":         "google-drive",
+		"source-file-id": file.ID,
+		"original-name":  sanitizeMetadataValue(file.Name),
+		"mime-type":      sanitizeMetadataValue(file.MimeType),
+		"migrated-at":    time.Now().Format(time.RFC3339),
+	}
+	if len(file.AliasPaths) > 0 {
+		metadata["alias-paths"] = sanitizeMetadataValue(strings.Join(file.AliasPaths, ";"))
+	}
+	return metadata
+}
+
+// resolveShortcut replaces a shortcut entry with its target, keeping the shortcut's name
+// so the target lands at the path where the shortcut was found
+func (m *GoogleDriveMigrator) resolveShortcut(shortcut FileInfo) (FileInfo, bool) {
+	if shortcut.ShortcutTargetID == "" {
+		fmt.Printf("⚠️  Skipping shortcut %s: no target\n", shortcut.Name)
+		return FileInfo{}, false
+	}
+
+	if shortcut.ShortcutTargetMimeType == folderMimeType {
+		return FileInfo{
+			ID:           shortcut.ShortcutTargetID,
+			Name:         shortcut.Name,
+			MimeType:     folderMimeType,
+			ModifiedTime: shortcut.ModifiedTime,
+			IsFolder:     true,
+		}, true
+	}
+
+	target, err := m.driveClient.GetFileInfo(shortcut.ShortcutTargetID)
+	if err != nil {
+		// Target may be trashed or not shared with us
+		fmt.Printf("⚠️  Skipping shortcut %s: failed to resolve target %s: %v\n",
+			shortcut.Name, shortcut.ShortcutTargetID, err)
+		return FileInfo{}, false
+	}
+	target.Name = shortcut.Name
+	return *target, true
+}
+
 // copyFileToS3 downloads a file from Google Drive and uploads it to S3 using streaming
 func (m *GoogleDriveMigrator) copyFileToS3(file FileInfo, bucket, key string) error {
 	// Special handling for 0-byte files (empty files)
 	if file.Size == 0 {
 		// Create an empty file directly without downloading
 		emptyBody := bytes.NewReader([]byte{})
-		
+
 		putInput := &s3.PutObjectInput{
-			Bucket: &bucket,
-			Key:    &key,
-			Body:   emptyBody,
-			Metadata: map[string]string{
-				"source":         "google-drive",
-				"source-file-id": file.ID,
-				"original-name":  sanitizeMetadataValue(file.Name),
-				"mime-type":      sanitizeMetadataValue(file.MimeType),
-				"migrated-at":    time.Now().Format(time.RFC3339),
-			},
+			Bucket:   &bucket,
+			Key:      &key,
+			Body:     emptyBody,
+			Metadata: fileMetadata(file),
 		}
-		
+
 		// For 0-byte files, explicitly do NOT set ContentLength
 		// Some S3-compatible storage systems reject ContentLength: 0
 		// They expect the header to be omitted entirely for empty files
-		
+
 		if file.MimeType != "" {
 			putInput.ContentType = &file.MimeType
 		}
-		
+
 		_, err := m.s3Client.PutObject(m.ctx, putInput)
 		if err != nil {
-			return fmt.Errorf("failed to upload empty file %s to S3 (bucket: %s, key: %s): %w", 
+			return fmt.Errorf("failed to upload empty file %s to S3 (bucket: %s, key: %s): %w",
 				file.Name, bucket, key, err)
 		}
-		
+
 		return nil
 	}
-	
+
 	// Download from Google Drive (returns io.ReadCloser)
 	reader, err := m.driveClient.GetFile(file.ID)
 	if err != nil {
@@ -656,39 +781,33 @@ func (m *GoogleDriveMigrator) copyFileToS3(file FileInfo, bucket, key string) er
 	// Disabling ALL buffering to use pure streaming mode
 	var body io.Reader
 	var actualSize int64
-	
+
 	// Force streaming for ALL files (no buffering at all)
 	body = reader
 	actualSize = file.Size
-	
+
 	// Note: This sacrifices retry capability for memory safety
 	// If uploads fail, they'll need to be retried as new migrations
 
 	// Prepare PutObject input with S3-compatible optimizations
 	putInput := &s3.PutObjectInput{
-		Bucket: &bucket,
-		Key:    &key,
-		Body:   body,
-		Metadata: map[string]string{
-			"source":         "google-drive",
-			"source-file-id": file.ID,
-			"original-name":  sanitizeMetadataValue(file.Name),
-			"mime-type":      sanitizeMetadataValue(file.MimeType),
-			"migrated-at":    time.Now().Format(time.RFC3339),
-		},
-	}
-	
+		Bucket:   &bucket,
+		Key:      &key,
+		Body:     body,
+		Metadata: fileMetadata(file),
+	}
+
 	// Set ContentLength with actual size (required by some S3 implementations)
 	// This fixes 411 MissingContentLength errors
 	if actualSize > 0 {
 		putInput.ContentLength = &actualSize
 	}
-	
+
 	// Set content type for better caching and performance
 	if file.MimeType != "" {
 		putInput.ContentType = &file.MimeType
 	}
-	
+
 	// Note: StorageClass and ServerSideEncryption removed for S3-compatible storage compatibility
 	// These parameters can cause UnknownError 400 with MinIO and other S3-compatible services
 
@@ -696,10 +815,10 @@ func (m *GoogleDriveMigrator) copyFileToS3(file FileInfo, bucket, key string) er
 	uploadStart := time.Now()
 	_, err = m.s3Client.PutObject(m.ctx, putInput)
 	uploadDuration := time.Since(uploadStart)
-	
+
 	if err != nil {
 		// Enhanced error reporting for S3-compatible storage debugging
-		return fmt.Errorf("failed to upload %s to S3 (bucket: %s, key: %s, size: %d bytes): %w", 
+		return fmt.Errorf("failed to upload %s to S3 (bucket: %s, key: %s, size: %d bytes): %w",
 			file.Name, bucket, key, file.Size, err)
 	}
 
@@ -708,24 +827,24 @@ func (m *GoogleDriveMigrator) copyFileToS3(file FileInfo, bucket, key string) er
 	if uploadDuration > 0 {
 		instantaneousSpeed := float64(actualSize) / uploadDuration.Seconds()
 		m.bytesPerSecond = (m.bytesPerSecond + instantaneousSpeed) / 2 // Running average
-		
+
 		// Log performance every 100MB transferred
 		if m.totalBytes%(100*1024*1024) < actualSize {
 			currentSpeed := m.bytesPerSecond / (1024 * 1024) // Convert to MB/s
-			
+
 			// EMERGENCY: Log memory usage to debug OOM
 			var memStats runtime.MemStats
 			runtime.ReadMemStats(&memStats)
 			memUsageMB := float64(memStats.Alloc) / (1024 * 1024)
-			
-			fmt.Printf("📊 Bandwidth: %.1f MB/s | Memory: %.1f MB | Total: %.1f GB transferred\n", 
+
+			fmt.Printf("📊 Bandwidth: %.1f MB/s | Memory: %.1f MB | Total: %.1f GB transferred\n",
 				currentSpeed, memUsageMB, float64(m.totalBytes)/(1024*1024*1024))
-			
+
 			// Check if we're approaching the 750 GB/day limit
 			if currentSpeed > 35.0 { // 35 MB/s = ~3TB/day (safety margin)
 				fmt.Printf("⚠️  High bandwidth detected (%.1f MB/s) - approaching Google Drive limits\n", currentSpeed)
 			}
-			
+
 			// Force garbage collection if memory usage is high
 			if memUsageMB > 1000 { // Over 1GB
 				runtime.GC()
@@ -740,17 +859,13 @@ func (m *GoogleDriveMigrator) copyFileToS3(file FileInfo, bucket, key string) er
 
 // ensureDestinationBucketExists ensures the S3 bucket exists
 func (m *GoogleDriveMigrator) ensureDestinationBucketExists(bucket string) error {
-	_, err := m.s3Client.HeadBucket(m.ctx, &s3.HeadBucketInput{
+	created, err := buckets.Ensure(m.ctx, m.s3Client, &s3.CreateBucketInput{
 		Bucket: &bucket,
 	})
 	if err != nil {
-		// Try to create the bucket
-		_, createErr := m.s3Client.CreateBucket(m.ctx, &s3.CreateBucketInput{
-			Bucket: &bucket,
-		})
-		if createErr != nil {
-			return fmt.Errorf("failed to create bucket: %w", createErr)
-		}
+		return err
+	}
+	if created {
 		fmt.Printf("Created destination bucket: %s\n", bucket)
 	}
 	return nil
@@ -765,14 +880,14 @@ func (m *GoogleDriveMigrator) calculateETA(startTime time.Time, completed, total
 	elapsed := time.Since(startTime)
 	rate := float64(completed) / elapsed.Seconds()
 	remaining := total - completed
-	
+
 	if rate <= 0 {
 		return "Unknown"
 	}
 
 	etaSeconds := float64(remaining) / rate
 	eta := time.Duration(etaSeconds) * time.Second
-	
+
 	if eta < time.Minute {
 		return fmt.Sprintf("%.0fs", eta.Seconds())
 	} else if eta < time.Hour {
@@ -792,11 +907,11 @@ func (m *GoogleDriveMigrator) calculateSpeed(startTime time.Time, bytesTransferr
 }
 
 // processFilesStreaming processes files without loading all into memory
-// Also builds folder paths as we go
-func (m *GoogleDriveMigrator) processFilesStreaming(folderID string, includeShared bool, callback func(FileInfo, string) error) error {
-	visited := &sync.Map{} // Thread-safe visited map
+// Also builds folder paths as we go; subtrees and files rejected by filter are never enumerated
+func (m *GoogleDriveMigrator) processFilesStreaming(folderID string, includeShared bool, filter *Filter, callback func(FileInfo, string) error) error {
+	visited := &sync.Map{}     // Thread-safe visited map
 	folderPaths := &sync.Map{} // Thread-safe folder paths map
-	
+
 	// Initialize starting folder
 	startFolderID := folderID
 	if folderID == "" {
@@ -813,23 +928,23 @@ func (m *GoogleDriveMigrator) processFilesStreaming(folderID string, includeShar
 	var discoveryErr error
 	var errMu sync.Mutex
 	var activeWorkers sync.WaitGroup // Track active folder processing
-	var foldersProcessed int64 = 0 // Track progress
-	
+	var foldersProcessed int64 = 0   // Track progress
+
 	fmt.Printf("🚀 Starting concurrent folder discovery with %d workers...\n", maxConcurrentFolders)
-	
+
 	// Start worker goroutines
 	for i := 0; i < maxConcurrentFolders; i++ {
 		wg.Add(1)
 		go func(workerID int) {
 			defer wg.Done()
-			
+
 			for currentFolderID := range folderQueue {
 				// Check if already visited
 				if _, loaded := visited.LoadOrStore(currentFolderID, true); loaded {
 					activeWorkers.Done() // Mark this folder as done
 					continue
 				}
-				
+
 				// Get current path
 				currentPathInterface, _ := folderPaths.Load(currentFolderID)
 				currentPath := currentPathInterface.(string)
@@ -856,19 +971,35 @@ func (m *GoogleDriveMigrator) processFilesStreaming(folderID string, includeShar
 						} else {
 							filePath = currentPath + "/" + file.Name
 						}
-						
+
+						// Resolve shortcuts to their targets so we copy real content, not a 0-byte stub
+						if file.MimeType == shortcutMimeType {
+							resolved, ok := m.resolveShortcut(file)
+							if !ok {
+								continue
+							}
+							file = resolved
+						}
+
 						if file.IsFolder {
-							// Store folder path and add to queue
-							folderPaths.Store(file.ID, filePath)
-							
+							// Prune filtered subtrees before they are listed
+							if !filter.ShouldDescend(filePath) {
+								continue
+							}
+
+							// Store folder path and add to queue (first path wins for folders reachable twice)
+							folderPaths.LoadOrStore(file.ID, filePath)
+
 							// Add folder to queue using goroutine to avoid deadlock
 							// Track this new folder and queue it without blocking
+							activeWorkers.Add(1)
 							go func(folderID string) {
-								activeWorkers.Add(1)
 								folderQueue <- folderID
 							}(file.ID)
+						} else if !filter.ShouldInclude(filePath, file.MimeType) {
+							continue
 						}
-						
+
 						// Process each file immediately (streaming) with its path
 						if err := callback(file, filePath); err != nil {
 							errMu.Lock()
@@ -886,7 +1017,7 @@ func (m *GoogleDriveMigrator) processFilesStreaming(folderID string, includeShar
 					}
 					pageToken = nextPageToken
 				}
-				
+
 				// Mark this folder as fully processed
 				processed := atomic.AddInt64(&foldersProcessed, 1)
 				if processed%10 == 0 {
@@ -896,20 +1027,20 @@ func (m *GoogleDriveMigrator) processFilesStreaming(folderID string, includeShar
 			}
 		}(i)
 	}
-	
+
 	// Seed the queue with the starting folder
 	activeWorkers.Add(1)
 	folderQueue <- startFolderID
-	
+
 	// Wait for all folders to be processed, then close the queue
 	go func() {
 		activeWorkers.Wait()
 		close(folderQueue)
 	}()
-	
+
 	// Wait for all workers to finish
 	wg.Wait()
-	
+
 	// Return any error encountered during discovery
 	if discoveryErr != nil {
 		return discoveryErr
@@ -944,7 +1075,7 @@ func (m *GoogleDriveMigrator) generateS3KeyWithPath(filePath, mimeType, destPref
 			path += ".json"
 		}
 	}
-	
+
 	// Combine with destination prefix
 	if destPrefix != "" {
 		return strings.TrimSuffix(destPrefix, "/") + "/" + path
@@ -0,0 +1,197 @@
+package googledrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	// DefaultMaxQPS is the Drive API request budget shared by all workers of a client
+	DefaultMaxQPS = 10.0
+
+	maxAuthRetries      = 3
+	maxRateLimitRetries = 8
+	baseBackoff         = 1 * time.Second
+	maxBackoff          = 64 * time.Second
+)
+
+// rateLimiter spaces out Drive API calls so discovery and download workers
+// sharing one client stay under a single QPS budget
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter creates a limiter allowing qps requests per second
+func newRateLimiter(qps float64) *rateLimiter {
+	if qps <= 0 {
+		qps = DefaultMaxQPS
+	}
+	return &rateLimiter{
+		interval: time.Duration(float64(time.Second) / qps),
+	}
+}
+
+// Wait blocks until the caller may issue the next request
+func (r *rateLimiter) Wait(ctx context.Context) error {
+	r.mu.Lock()
+	now := time.Now()
+	if r.next.Before(now) {
+		r.next = now
+	}
+	wait := r.next.Sub(now)
+	r.next = r.next.Add(r.interval)
+	r.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Pause delays every caller by at least d, so a rate-limit response seen by
+// one worker slows down all the others as well
+func (r *rateLimiter) Pause(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if resume := time.Now().Add(d); resume.After(r.next) {
+		r.next = resume
+	}
+}
+
+// call runs fn under the client's rate limiter, refreshing the token on auth
+// errors and backing off exponentially on 403 rate-limit and 429 responses
+func (c *Client) call(fn func() error) error {
+	authAttempts := 0
+	rateLimitAttempts := 0
+
+	for {
+		if err := c.limiter.Wait(c.ctx); err != nil {
+			return err
+		}
+
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if isRateLimitError(err) && rateLimitAttempts < maxRateLimitRetries {
+			delay := retryAfter(err)
+			if delay == 0 {
+				delay = backoffDelay(rateLimitAttempts)
+			}
+			rateLimitAttempts++
+			fmt.Printf("⏳ Google Drive rate limit hit, backing off %v (attempt %d/%d)\n",
+				delay.Round(time.Millisecond), rateLimitAttempts, maxRateLimitRetries)
+			c.limiter.Pause(delay)
+			continue
+		}
+
+		if isAuthError(err) && authAttempts < maxAuthRetries-1 {
+			authAttempts++
+
+			// Try to refresh the token manually (silent retry for better UX)
+			if refreshErr := c.refreshToken(); refreshErr != nil {
+				// If refresh token is expired, don't retry - fail immediately with clear message
+				if strings.Contains(refreshErr.Error(), "please re-authenticate") {
+					return fmt.Errorf("authentication expired - %w", refreshErr)
+				}
+			}
+
+			select {
+			case <-c.ctx.Done():
+				return c.ctx.Err()
+			case <-time.After(time.Duration(authAttempts) * time.Second):
+			}
+			continue
+		}
+
+		return err
+	}
+}
+
+// isAuthError reports whether err means the access token has expired
+func isAuthError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "401") ||
+		strings.Contains(msg, "Invalid Credentials") ||
+		strings.Contains(msg, "authError")
+}
+
+// isRateLimitError reports whether err is a Drive quota response that should be retried
+func isRateLimitError(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	if apiErr.Code == http.StatusTooManyRequests {
+		return true
+	}
+	if apiErr.Code == http.StatusForbidden {
+		for _, item := range apiErr.Errors {
+			if item.Reason == "userRateLimitExceeded" || item.Reason == "rateLimitExceeded" {
+				return true
+			}
+		}
+		// Download responses carry no structured error items
+		return strings.Contains(apiErr.Message, "Rate Limit Exceeded") ||
+			strings.Contains(apiErr.Body, "userRateLimitExceeded") ||
+			strings.Contains(apiErr.Body, "rateLimitExceeded")
+	}
+
+	return false
+}
+
+// retryAfter returns the delay requested by the server's Retry-After header, if any
+func retryAfter(err error) time.Duration {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Header == nil {
+		return 0
+	}
+
+	value := apiErr.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, parseErr := strconv.Atoi(value); parseErr == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, parseErr := http.ParseTime(value); parseErr == nil {
+		if delay := time.Until(when); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
+
+// backoffDelay returns the exponential backoff (with jitter) for the given attempt
+func backoffDelay(attempt int) time.Duration {
+	delay := baseBackoff << uint(attempt)
+	if delay > maxBackoff || delay <= 0 {
+		delay = maxBackoff
+	}
+	// Up to 1s of jitter so workers don't retry in lockstep
+	return delay + time.Duration(rand.Int63n(int64(time.Second)))
+}
@@ -0,0 +1,164 @@
+package googledrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter := newRateLimiter(100) // One request every 10ms
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("5 requests at 100 QPS took %v, want at least 40ms", elapsed)
+	}
+
+	if got := newRateLimiter(0).interval; got != time.Duration(float64(time.Second)/DefaultMaxQPS) {
+		t.Errorf("interval without a QPS = %v, want the default", got)
+	}
+}
+
+func TestRateLimiterPause(t *testing.T) {
+	limiter := newRateLimiter(1000)
+	limiter.Pause(50 * time.Millisecond)
+	start := time.Now()
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 45*time.Millisecond {
+		t.Errorf("Wait after a 50ms pause returned after %v", elapsed)
+	}
+
+	// A shorter pause does not bring the next slot forward
+	limiter.Pause(time.Hour)
+	limiter.Pause(time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait during an hour pause = %v, want the context error", err)
+	}
+}
+
+func TestIsRateLimitError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"429", &googleapi.Error{Code: http.StatusTooManyRequests}, true},
+		{"403 user rate limit", &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "userRateLimitExceeded"}}}, true},
+		{"403 rate limit", &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}}, true},
+		{"403 download body", &googleapi.Error{Code: http.StatusForbidden, Body: `{"error": {"errors": [{"reason": "userRateLimitExceeded"}]}}`}, true},
+		{"403 download message", &googleapi.Error{Code: http.StatusForbidden, Message: "User Rate Limit Exceeded"}, true},
+		{"403 permission", &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "insufficientFilePermissions"}}}, false},
+		{"404", &googleapi.Error{Code: http.StatusNotFound}, false},
+		{"wrapped 429", fmt.Errorf("list: %w", &googleapi.Error{Code: http.StatusTooManyRequests}), true},
+		{"not an API error", errors.New("429 too many requests"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRateLimitError(tt.err); got != tt.want {
+				t.Errorf("isRateLimitError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	header := func(value string) http.Header { return http.Header{"Retry-After": []string{value}} }
+	tests := []struct {
+		name string
+		err  error
+		min  time.Duration
+		max  time.Duration
+	}{
+		{"seconds", &googleapi.Error{Header: header("3")}, 3 * time.Second, 3 * time.Second},
+		{"date", &googleapi.Error{Header: header(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))}, 58 * time.Second, time.Minute},
+		{"past date", &googleapi.Error{Header: header(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))}, 0, 0},
+		{"invalid", &googleapi.Error{Header: header("soon")}, 0, 0},
+		{"zero seconds", &googleapi.Error{Header: header("0")}, 0, 0},
+		{"no header", &googleapi.Error{}, 0, 0},
+		{"not an API error", errors.New("slow down"), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.err); got < tt.min || got > tt.max {
+				t.Errorf("retryAfter() = %v, want %v to %v", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{3, 8 * time.Second},
+		{6, maxBackoff},
+		{10, maxBackoff},
+		{100, maxBackoff}, // The shift overflows
+	}
+	for _, tt := range tests {
+		if got := backoffDelay(tt.attempt); got < tt.base || got >= tt.base+time.Second {
+			t.Errorf("backoffDelay(%d) = %v, want %v plus under 1s of jitter", tt.attempt, got, tt.base)
+		}
+	}
+}
+
+func TestCall(t *testing.T) {
+	rateLimited := &googleapi.Error{Code: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"1"}}}
+	tests := []struct {
+		name      string
+		responses []error // Returned by successive attempts; the last one repeats
+		wantErr   bool
+		wantCalls int
+	}{
+		{name: "success", responses: []error{nil}, wantCalls: 1},
+		{name: "rate limited once", responses: []error{rateLimited, nil}, wantCalls: 2},
+		{name: "auth error once", responses: []error{errors.New("googleapi: Error 401: Invalid Credentials"), nil}, wantCalls: 2},
+		{name: "not retried", responses: []error{&googleapi.Error{Code: http.StatusNotFound}}, wantErr: true, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{ctx: context.Background(), limiter: newRateLimiter(1000)}
+			calls := 0
+			err := client.call(func() error {
+				calls++
+				return tt.responses[min(calls, len(tt.responses))-1]
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("call() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("fn called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCallStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &Client{ctx: ctx, limiter: newRateLimiter(1000)}
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := client.call(func() error {
+		return &googleapi.Error{Code: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"60"}}}
+	})
+	if !errors.Is(err, context.Canceled) || time.Since(start) > 5*time.Second {
+		t.Errorf("call() = %v after %v, want the context error without waiting out Retry-After", err, time.Since(start))
+	}
+}
@@ -0,0 +1,60 @@
+package httpsource
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// checkAddress rejects connections to addresses inside the host's own network:
+// loopback, link-local (including the 169.254.169.254 metadata service),
+// private (RFC 1918, fc00::/7), shared (RFC 6598) and unspecified addresses.
+// URLs come from API callers, so without this they could read internal
+// services, such as instance credentials, into a bucket of their choice.
+func checkAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("refusing to connect to unresolved address %q", host)
+	}
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", ip)
+	}
+	return nil
+}
+
+// sharedAddressSpace is the RFC 6598 carrier-grade NAT range, used inside some clouds
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// publicDialer connects only to public addresses. The check runs on the
+// resolved address of every connection, so redirects and DNS answers that
+// change between lookups are covered too.
+var publicDialer = &net.Dialer{
+	Timeout:   30 * time.Second,
+	KeepAlive: 30 * time.Second,
+	Control: func(network, address string, _ syscall.RawConn) error {
+		return checkAddress(address)
+	},
+}
+
+// NewPublicTransport returns a transport that only reaches public addresses.
+// Proxies are not used: the proxy would connect on our behalf, unchecked.
+// Webhooks and transformation hooks, whose URLs also come from API callers,
+// use it too.
+func NewPublicTransport(maxIdleConnsPerHost int) *http.Transport {
+	return &http.Transport{
+		DialContext:         publicDialer.DialContext,
+		MaxIdleConnsPerHost: maxIdleConnsPerHost,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+}
+
+// manifestClient fetches manifests under the same address restrictions as objects
+var manifestClient = &http.Client{Transport: NewPublicTransport(1)}
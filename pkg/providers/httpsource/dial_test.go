@@ -0,0 +1,47 @@
+package httpsource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"169.254.169.254:80", false},
+		{"[fe80::1]:80", false},
+		{"10.0.0.5:80", false},
+		{"172.16.3.4:80", false},
+		{"192.168.1.1:80", false},
+		{"[fd00::1]:80", false},
+		{"100.64.0.1:80", false},
+		{"0.0.0.0:80", false},
+	}
+	for _, tt := range tests {
+		err := checkAddress(tt.address)
+		if (err == nil) != tt.allowed {
+			t.Errorf("checkAddress(%s) = %v, allowed %v", tt.address, err, tt.allowed)
+		}
+	}
+}
+
+func TestFetchManifestRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("https://example.com/a.txt\n"))
+	}))
+	defer server.Close()
+
+	_, err := FetchManifest(context.Background(), server.URL)
+	if err == nil || !strings.Contains(err.Error(), "non-public address") {
+		t.Fatalf("FetchManifest(%s) error = %v, want a refused connection", server.URL, err)
+	}
+}
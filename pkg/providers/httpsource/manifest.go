@@ -0,0 +1,115 @@
+package httpsource
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Entry is a single URL to ingest
+type Entry struct {
+	URL    string `json:"url"`
+	Key    string `json:"key,omitempty"`    // Destination key relative to the prefix (default: derived from URL)
+	SHA256 string `json:"sha256,omitempty"` // Expected hex SHA-256 of the content (optional)
+}
+
+// maxManifestSize bounds how much of a manifest we read
+const maxManifestSize = 64 * 1024 * 1024
+
+// ParseManifest parses a manifest with one entry per line in the form
+// "url[,key[,sha256]]". Blank lines and lines starting with '#' are ignored.
+func ParseManifest(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		entry := Entry{URL: strings.TrimSpace(fields[0])}
+		if len(fields) > 1 {
+			entry.Key = strings.TrimSpace(fields[1])
+		}
+		if len(fields) > 2 {
+			entry.SHA256 = strings.ToLower(strings.TrimSpace(fields[2]))
+		}
+
+		if err := validateURL(entry.URL); err != nil {
+			return nil, fmt.Errorf("manifest line %d: %w", lineNumber, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	return entries, nil
+}
+
+// FetchManifest downloads and parses a manifest from a URL
+func FetchManifest(ctx context.Context, manifestURL string) ([]Entry, error) {
+	if err := validateURL(manifestURL); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, manifestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manifest request: %w", err)
+	}
+	resp, err := manifestClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch manifest: HTTP %d", resp.StatusCode)
+	}
+
+	return ParseManifest(io.LimitReader(resp.Body, maxManifestSize))
+}
+
+// validateURL ensures the URL is an absolute http(s) URL
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid URL %q: only http and https are supported", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid URL %q: missing host", raw)
+	}
+	return nil
+}
+
+// keyForEntry returns the destination key for an entry under destPrefix
+func keyForEntry(entry Entry, destPrefix string) string {
+	key := entry.Key
+	if key == "" {
+		// Default: host plus URL path, e.g. cdn.example.com/assets/logo.png
+		u, _ := url.Parse(entry.URL)
+		key = u.Host + path.Clean("/"+u.Path)
+		if strings.HasSuffix(u.Path, "/") || u.Path == "" {
+			key = strings.TrimSuffix(key, "/") + "/index.html"
+		}
+	}
+	key = strings.TrimPrefix(key, "/")
+
+	if destPrefix == "" {
+		return key
+	}
+	return strings.TrimSuffix(destPrefix, "/") + "/" + key
+}
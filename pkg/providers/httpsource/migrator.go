@@ -0,0 +1,315 @@
+package httpsource
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	// DefaultConcurrency is the number of URLs fetched in parallel
+	DefaultConcurrency = 8
+	// DefaultMaxRetries is the number of attempts per URL
+	DefaultMaxRetries = 3
+
+	// maxBufferedSize bounds in-memory buffering for responses without Content-Length
+	maxBufferedSize = 256 * 1024 * 1024
+	// maxReportedErrors caps the number of per-URL errors kept in the result
+	maxReportedErrors = 100
+)
+
+// Migrator streams HTTP(S) URLs into S3
+type Migrator struct {
+	httpClient *http.Client
+	s3Client   *s3.Client
+	ctx        context.Context
+}
+
+// MigrationInput contains parameters for URL-list ingestion
+type MigrationInput struct {
+	Entries     []Entry
+	DestBucket  string
+	DestPrefix  string
+	DryRun      bool
+	Concurrency int               // Parallel downloads (default: DefaultConcurrency)
+	MaxRetries  int               // Attempts per URL (default: DefaultMaxRetries)
+	Headers     map[string]string // Extra request headers (e.g. Authorization for a CMS)
+	// totalSize is the summed Content-Length, reported once every URL has been fetched (0 while unknown)
+	ProgressCallback func(progress float64, copied, total int64, copiedSize, totalSize int64, speed float64, eta string)
+}
+
+// ObjectResult records the outcome for one URL
+type ObjectResult struct {
+	URL    string `json:"url"`
+	Key    string `json:"key"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+	ETag   string `json:"etag,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	declaredSize int64 // Content-Length of the response, -1 when not sent
+}
+
+// MigrationResult contains the result of an ingestion run
+type MigrationResult struct {
+	TotalFiles  int64          `json:"total_files"`
+	CopiedFiles int64          `json:"copied_files"`
+	FailedFiles int64          `json:"failed_files"`
+	CopiedSize  int64          `json:"copied_size"`
+	Objects     []ObjectResult `json:"objects"`
+	Errors      []string       `json:"errors,omitempty"`
+	StartTime   time.Time      `json:"start_time"`
+	EndTime     time.Time      `json:"end_time"`
+	Duration    time.Duration  `json:"duration"`
+}
+
+// permanentError marks failures that retrying won't fix (4xx, checksum mismatch)
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// NewMigrator creates a new URL-list migrator
+func NewMigrator(ctx context.Context, s3Client *s3.Client) *Migrator {
+	return &Migrator{
+		httpClient: &http.Client{Transport: NewPublicTransport(DefaultConcurrency)},
+		s3Client:   s3Client,
+		ctx:        ctx,
+	}
+}
+
+// Migrate fetches every entry and uploads it to S3
+func (m *Migrator) Migrate(input MigrationInput) (*MigrationResult, error) {
+	result := &MigrationResult{
+		TotalFiles: int64(len(input.Entries)),
+		Objects:    make([]ObjectResult, len(input.Entries)),
+		StartTime:  time.Now(),
+	}
+
+	concurrency := input.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	maxRetries := input.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = DefaultMaxRetries
+	}
+
+	fmt.Printf("Starting URL ingestion of %d URLs into s3://%s/%s (concurrency: %d)\n",
+		len(input.Entries), input.DestBucket, input.DestPrefix, concurrency)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var resultMu sync.Mutex
+	// Sum of Content-Length over fetched URLs; the total is only known once every URL declared one
+	var declaredSize int64
+	sizeKnown := true
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				entry := input.Entries[index]
+				object := ObjectResult{
+					URL:          entry.URL,
+					Key:          keyForEntry(entry, input.DestPrefix),
+					declaredSize: -1,
+				}
+
+				var err error
+				for attempt := 1; attempt <= maxRetries; attempt++ {
+					err = m.ingest(input, entry, &object)
+					var permErr *permanentError
+					if err == nil || errors.As(err, &permErr) || m.ctx.Err() != nil {
+						break
+					}
+					if attempt < maxRetries {
+						time.Sleep(time.Duration(attempt) * time.Second)
+					}
+				}
+
+				resultMu.Lock()
+				if err != nil {
+					object.Error = err.Error()
+					result.FailedFiles++
+					if len(result.Errors) < maxReportedErrors {
+						result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", entry.URL, err))
+					}
+					fmt.Printf("  [ERROR] %s: %v\n", entry.URL, err)
+				} else {
+					result.CopiedFiles++
+					result.CopiedSize += object.Size
+				}
+				result.Objects[index] = object
+				if object.declaredSize >= 0 {
+					declaredSize += object.declaredSize
+				} else {
+					sizeKnown = false
+				}
+				done := result.CopiedFiles + result.FailedFiles
+				copied, copiedSize := result.CopiedFiles, result.CopiedSize
+				var totalSize int64
+				if sizeKnown && done == result.TotalFiles {
+					totalSize = declaredSize
+				}
+				resultMu.Unlock()
+
+				if input.ProgressCallback != nil {
+					elapsed := time.Since(result.StartTime).Seconds()
+					speed := 0.0
+					if elapsed > 0 {
+						speed = float64(copiedSize) / elapsed / (1024 * 1024)
+					}
+					eta := "calculating..."
+					if done > 0 {
+						remaining := time.Duration(float64(time.Since(result.StartTime)) / float64(done) * float64(result.TotalFiles-done))
+						eta = remaining.Round(time.Second).String()
+					}
+					input.ProgressCallback(float64(done)/float64(result.TotalFiles)*100,
+						copied, result.TotalFiles, copiedSize, totalSize, speed, eta)
+				}
+			}
+		}()
+	}
+
+	for index := range input.Entries {
+		if m.ctx.Err() != nil {
+			break
+		}
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+
+	if err := m.ctx.Err(); err != nil {
+		return result, fmt.Errorf("ingestion interrupted: %w", err)
+	}
+
+	fmt.Printf("✅ URL ingestion completed: %d copied, %d failed, %.2f MB in %v\n",
+		result.CopiedFiles, result.FailedFiles, float64(result.CopiedSize)/(1024*1024), result.Duration)
+
+	return result, nil
+}
+
+// ingest fetches one URL and streams it into S3, capturing its SHA-256
+func (m *Migrator) ingest(input MigrationInput, entry Entry, object *ObjectResult) error {
+	req, err := http.NewRequestWithContext(m.ctx, http.MethodGet, entry.URL, nil)
+	if err != nil {
+		return &permanentError{err: fmt.Errorf("failed to create request: %w", err)}
+	}
+	for name, value := range input.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		statusErr := fmt.Errorf("unexpected HTTP status %d", resp.StatusCode)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return statusErr
+		}
+		return &permanentError{err: statusErr}
+	}
+
+	hasher := sha256.New()
+	var body io.Reader = io.TeeReader(resp.Body, hasher)
+	size := resp.ContentLength
+	object.declaredSize = size
+
+	if size < 0 {
+		// Unknown length: buffer so we can send Content-Length to S3
+		buffered, readErr := io.ReadAll(io.LimitReader(body, maxBufferedSize+1))
+		if readErr != nil {
+			return fmt.Errorf("failed to read response: %w", readErr)
+		}
+		if len(buffered) > maxBufferedSize {
+			return &permanentError{err: fmt.Errorf("response without Content-Length exceeds %d bytes", maxBufferedSize)}
+		}
+		size = int64(len(buffered))
+		body = bytes.NewReader(buffered)
+	}
+
+	if input.DryRun {
+		n, copyErr := io.Copy(io.Discard, body)
+		if copyErr != nil {
+			return fmt.Errorf("failed to read response: %w", copyErr)
+		}
+		object.Size = n
+		object.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+		return checkChecksum(entry, object)
+	}
+
+	putInput := &s3.PutObjectInput{
+		Bucket: &input.DestBucket,
+		Key:    &object.Key,
+		Body:   body,
+		Metadata: map[string]string{
+			"source":      "http",
+			"source-url":  truncate(entry.URL, 1024),
+			"migrated-at": time.Now().Format(time.RFC3339),
+		},
+	}
+	if size > 0 {
+		putInput.ContentLength = &size
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		putInput.ContentType = &contentType
+	}
+
+	output, err := m.s3Client.PutObject(m.ctx, putInput)
+	if err != nil {
+		return fmt.Errorf("failed to upload to s3://%s/%s: %w", input.DestBucket, object.Key, err)
+	}
+
+	object.Size = size
+	object.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+	if output.ETag != nil {
+		object.ETag = strings.Trim(*output.ETag, "\"")
+	}
+
+	if err := checkChecksum(entry, object); err != nil {
+		// Don't leave corrupt content behind
+		m.s3Client.DeleteObject(m.ctx, &s3.DeleteObjectInput{
+			Bucket: &input.DestBucket,
+			Key:    &object.Key,
+		})
+		return err
+	}
+	return nil
+}
+
+// checkChecksum compares the captured SHA-256 with the manifest's expected value
+func checkChecksum(entry Entry, object *ObjectResult) error {
+	if entry.SHA256 == "" || entry.SHA256 == object.SHA256 {
+		return nil
+	}
+	return &permanentError{err: fmt.Errorf("checksum mismatch: expected sha256 %s, got %s", entry.SHA256, object.SHA256)}
+}
+
+// truncate limits metadata values to n bytes
+func truncate(value string, n int) string {
+	if len(value) > n {
+		return value[:n]
+	}
+	return value
+}
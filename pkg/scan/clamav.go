@@ -0,0 +1,95 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamavChunkSize is the size of each INSTREAM chunk sent to clamd
+const clamavChunkSize = 64 * 1024
+
+// ClamAV scans with clamd over TCP using the INSTREAM command
+type ClamAV struct {
+	address string
+	timeout time.Duration
+}
+
+// NewClamAV creates a scanner for the clamd at address (host:port)
+func NewClamAV(address string, timeout time.Duration) *ClamAV {
+	return &ClamAV{address: address, timeout: timeout}
+}
+
+// Name implements Scanner
+func (c *ClamAV) Name() string { return "clamav:" + c.address }
+
+// Scan implements Scanner
+func (c *ClamAV) Scan(ctx context.Context, r io.Reader) (Verdict, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Verdict{}, fmt.Errorf("failed to start clamd stream: %w", err)
+	}
+
+	// Each chunk is a 4-byte big-endian length followed by the data; a zero length ends the stream
+	buf := make([]byte, clamavChunkSize)
+	var header [4]byte
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(header[:], uint32(n))
+			if _, err := conn.Write(header[:]); err != nil {
+				return Verdict{}, fmt.Errorf("failed to send to clamd: %w", err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return Verdict{}, fmt.Errorf("failed to send to clamd: %w", err)
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return Verdict{}, fmt.Errorf("failed to read object: %w", readErr)
+		}
+	}
+	binary.BigEndian.PutUint32(header[:], 0)
+	if _, err := conn.Write(header[:]); err != nil {
+		return Verdict{}, fmt.Errorf("failed to end clamd stream: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return Verdict{}, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseClamAVReply(reply)
+}
+
+// parseClamAVReply reads "stream: OK", "stream: <signature> FOUND" or "<message> ERROR"
+func parseClamAVReply(reply string) (Verdict, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return Verdict{Clean: true}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return Verdict{Threat: strings.TrimSuffix(reply, " FOUND")}, nil
+	default:
+		return Verdict{}, fmt.Errorf("clamd: %s", reply)
+	}
+}
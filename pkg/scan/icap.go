@@ -0,0 +1,133 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// icapDefaultPort is the registered ICAP port
+const icapDefaultPort = "1344"
+
+// ICAP scans by sending each object to an ICAP server (RFC 3507) as a RESPMOD
+// request. 204 No Content means clean; a 200 with a rewritten response means
+// the server blocked the content.
+type ICAP struct {
+	service *url.URL
+	address string
+	timeout time.Duration
+}
+
+// NewICAP creates a scanner for an icap://host[:port]/service URL
+func NewICAP(serviceURL string, timeout time.Duration) (*ICAP, error) {
+	u, err := url.Parse(serviceURL)
+	if err != nil || u.Scheme != "icap" || u.Host == "" {
+		return nil, fmt.Errorf("invalid ICAP service URL %q", serviceURL)
+	}
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), icapDefaultPort)
+	}
+	return &ICAP{service: u, address: address, timeout: timeout}, nil
+}
+
+// Name implements Scanner
+func (s *ICAP) Name() string { return s.service.String() }
+
+// Scan implements Scanner
+func (s *ICAP) Scan(ctx context.Context, r io.Reader) (Verdict, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to connect to ICAP server: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// Encapsulated HTTP response header; the body follows in chunked encoding
+	httpHeader := "HTTP/1.1 200 OK\r\nContent-Type: application/octet-stream\r\n\r\n"
+	writer := bufio.NewWriter(conn)
+	fmt.Fprintf(writer, "RESPMOD %s ICAP/1.0\r\n", s.service.String())
+	fmt.Fprintf(writer, "Host: %s\r\n", s.service.Host)
+	fmt.Fprintf(writer, "Allow: 204\r\n")
+	fmt.Fprintf(writer, "Encapsulated: res-hdr=0, res-body=%d\r\n\r\n", len(httpHeader))
+	writer.WriteString(httpHeader)
+
+	buf := make([]byte, clamavChunkSize)
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			fmt.Fprintf(writer, "%x\r\n", n)
+			writer.Write(buf[:n])
+			writer.WriteString("\r\n")
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return Verdict{}, fmt.Errorf("failed to read object: %w", readErr)
+		}
+	}
+	writer.WriteString("0\r\n\r\n")
+	if err := writer.Flush(); err != nil {
+		return Verdict{}, fmt.Errorf("failed to send to ICAP server: %w", err)
+	}
+
+	reader := textproto.NewReader(bufio.NewReader(conn))
+	statusLine, err := reader.ReadLine()
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to read ICAP reply: %w", err)
+	}
+	header, err := reader.ReadMIMEHeader()
+	if err != nil && len(header) == 0 {
+		return Verdict{}, fmt.Errorf("failed to read ICAP reply headers: %w", err)
+	}
+	return parseICAPReply(statusLine, header)
+}
+
+// parseICAPReply turns an ICAP status and headers into a verdict
+func parseICAPReply(statusLine string, header textproto.MIMEHeader) (Verdict, error) {
+	fields := strings.Fields(statusLine)
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "ICAP/") {
+		return Verdict{}, fmt.Errorf("malformed ICAP status line %q", statusLine)
+	}
+	code, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Verdict{}, fmt.Errorf("malformed ICAP status line %q", statusLine)
+	}
+
+	switch code {
+	case 204:
+		return Verdict{Clean: true}, nil
+	case 200:
+		// Servers name the threat in one of several vendor headers
+		threat := header.Get("X-Virus-ID")
+		if threat == "" {
+			threat = header.Get("X-Infection-Found")
+			if i := strings.Index(threat, "Threat="); i >= 0 {
+				threat = strings.TrimSuffix(threat[i+len("Threat="):], ";")
+			}
+		}
+		if threat == "" {
+			threat = header.Get("X-Violations-Found")
+		}
+		if threat == "" {
+			threat = "blocked by ICAP server"
+		}
+		return Verdict{Threat: strings.TrimSpace(threat)}, nil
+	default:
+		return Verdict{}, fmt.Errorf("ICAP server returned %s", strings.Join(fields[1:], " "))
+	}
+}
@@ -0,0 +1,143 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Verdict is the outcome of scanning one object
+type Verdict struct {
+	Clean  bool
+	Threat string // Signature or reason reported by the scanner when not clean
+}
+
+// Scanner scans an object stream. Scan reads r to the end unless it fails.
+type Scanner interface {
+	Name() string
+	Scan(ctx context.Context, r io.Reader) (Verdict, error)
+}
+
+// Quarantine modes for infected objects
+const (
+	// QuarantineSkip leaves infected objects out and reports them (default)
+	QuarantineSkip = "skip"
+	// QuarantinePrefix copies infected objects under the quarantine prefix instead of their key
+	QuarantinePrefix = "prefix"
+)
+
+// Error policies for objects that cannot be scanned
+const (
+	// OnErrorFail records the object as failed (default)
+	OnErrorFail = "fail"
+	// OnErrorSkip leaves the object out and reports it
+	OnErrorSkip = "skip"
+	// OnErrorCopy copies the object unscanned
+	OnErrorCopy = "copy"
+)
+
+// DefaultTimeout bounds scanning one object
+const DefaultTimeout = 5 * time.Minute
+
+// DefaultMaxSize is the largest object sent for scanning; clamd's default
+// StreamMaxLength is 25 MB, so larger objects need a raised server limit
+const DefaultMaxSize int64 = 25 * 1024 * 1024
+
+// Config selects a scanner and what happens to what it finds
+type Config struct {
+	Type             string // "clamav" or "icap"
+	Address          string // clamav: host:port of clamd; icap: icap://host[:port]/service
+	Timeout          time.Duration
+	MaxSize          int64  // Larger objects are handled by OnError; 0 = DefaultMaxSize
+	Quarantine       string // QuarantineSkip or QuarantinePrefix
+	QuarantinePrefix string // Destination prefix for QuarantinePrefix
+	OnError          string // OnErrorFail, OnErrorSkip or OnErrorCopy
+}
+
+// Policy is a configured scanner with its quarantine and error handling
+type Policy struct {
+	Scanner          Scanner
+	MaxSize          int64
+	Quarantine       string
+	QuarantinePrefix string
+	OnError          string
+}
+
+// Validate checks a scanner configuration without contacting the scanner
+func (c Config) Validate() error {
+	switch c.Type {
+	case "clamav":
+		if _, _, err := net.SplitHostPort(c.Address); err != nil {
+			return fmt.Errorf("scan address must be host:port of clamd")
+		}
+	case "icap":
+		u, err := url.Parse(c.Address)
+		if err != nil || u.Scheme != "icap" || u.Host == "" {
+			return fmt.Errorf("scan address must be an icap://host[:port]/service URL")
+		}
+	default:
+		return fmt.Errorf("unknown scan type %q (expected clamav or icap)", c.Type)
+	}
+
+	switch c.Quarantine {
+	case "", QuarantineSkip:
+	case QuarantinePrefix:
+		if strings.Trim(c.QuarantinePrefix, "/") == "" {
+			return fmt.Errorf("quarantine_prefix is required with quarantine: prefix")
+		}
+	default:
+		return fmt.Errorf("unknown quarantine mode %q (expected skip or prefix)", c.Quarantine)
+	}
+	switch c.OnError {
+	case "", OnErrorFail, OnErrorSkip, OnErrorCopy:
+	default:
+		return fmt.Errorf("unknown scan on_error %q (expected fail, skip or copy)", c.OnError)
+	}
+	if c.MaxSize < 0 {
+		return fmt.Errorf("scan max size must not be negative")
+	}
+	return nil
+}
+
+// New builds the policy of a configuration
+func New(c Config) (*Policy, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	policy := &Policy{
+		MaxSize:          c.MaxSize,
+		Quarantine:       c.Quarantine,
+		QuarantinePrefix: strings.Trim(c.QuarantinePrefix, "/"),
+		OnError:          c.OnError,
+	}
+	if policy.MaxSize == 0 {
+		policy.MaxSize = DefaultMaxSize
+	}
+	if policy.Quarantine == "" {
+		policy.Quarantine = QuarantineSkip
+	}
+	if policy.OnError == "" {
+		policy.OnError = OnErrorFail
+	}
+
+	switch c.Type {
+	case "clamav":
+		policy.Scanner = NewClamAV(c.Address, timeout)
+	case "icap":
+		scanner, err := NewICAP(c.Address, timeout)
+		if err != nil {
+			return nil, err
+		}
+		policy.Scanner = scanner
+	}
+	return policy, nil
+}
@@ -0,0 +1,153 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"clamav", Config{Type: "clamav", Address: "localhost:3310"}, false},
+		{"clamav without port", Config{Type: "clamav", Address: "localhost"}, true},
+		{"icap", Config{Type: "icap", Address: "icap://scanner/avscan"}, false},
+		{"icap wrong scheme", Config{Type: "icap", Address: "http://scanner/avscan"}, true},
+		{"unknown type", Config{Type: "sophos", Address: "localhost:1"}, true},
+		{"quarantine prefix", Config{Type: "clamav", Address: "h:1", Quarantine: QuarantinePrefix, QuarantinePrefix: "quarantine/"}, false},
+		{"quarantine without prefix", Config{Type: "clamav", Address: "h:1", Quarantine: QuarantinePrefix, QuarantinePrefix: "/"}, true},
+		{"unknown quarantine", Config{Type: "clamav", Address: "h:1", Quarantine: "delete"}, true},
+		{"unknown on_error", Config{Type: "clamav", Address: "h:1", OnError: "retry"}, true},
+		{"negative max size", Config{Type: "clamav", Address: "h:1", MaxSize: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewDefaults(t *testing.T) {
+	policy, err := New(Config{Type: "clamav", Address: "localhost:3310", Quarantine: QuarantinePrefix, QuarantinePrefix: "/infected/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.MaxSize != DefaultMaxSize || policy.OnError != OnErrorFail || policy.QuarantinePrefix != "infected" {
+		t.Fatalf("unexpected defaults: %+v", policy)
+	}
+}
+
+func TestParseClamAVReply(t *testing.T) {
+	tests := []struct {
+		reply   string
+		want    Verdict
+		wantErr bool
+	}{
+		{"stream: OK\x00", Verdict{Clean: true}, false},
+		{"stream: Eicar-Signature FOUND\x00", Verdict{Threat: "Eicar-Signature"}, false},
+		{"INSTREAM size limit exceeded. ERROR\x00", Verdict{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseClamAVReply(tt.reply)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseClamAVReply(%q) = %+v, %v", tt.reply, got, err)
+		}
+	}
+}
+
+func TestParseICAPReply(t *testing.T) {
+	tests := []struct {
+		status  string
+		header  textproto.MIMEHeader
+		want    Verdict
+		wantErr bool
+	}{
+		{"ICAP/1.0 204 No Content", nil, Verdict{Clean: true}, false},
+		{"ICAP/1.0 200 OK", textproto.MIMEHeader{"X-Virus-Id": {"EICAR"}}, Verdict{Threat: "EICAR"}, false},
+		{"ICAP/1.0 200 OK", textproto.MIMEHeader{"X-Infection-Found": {"Type=0; Resolution=2; Threat=Win.Test;"}}, Verdict{Threat: "Win.Test"}, false},
+		{"ICAP/1.0 200 OK", textproto.MIMEHeader{}, Verdict{Threat: "blocked by ICAP server"}, false},
+		{"ICAP/1.0 500 Server Error", nil, Verdict{}, true},
+		{"HTTP/1.1 200 OK", nil, Verdict{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseICAPReply(tt.status, tt.header)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseICAPReply(%q) = %+v, %v", tt.status, got, err)
+		}
+	}
+}
+
+// fakeClamd answers one INSTREAM session, flagging streams that contain "EICAR"
+func fakeClamd(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		if _, err := reader.ReadString(0); err != nil {
+			return
+		}
+		var data []byte
+		var header [4]byte
+		for {
+			if _, err := io.ReadFull(reader, header[:]); err != nil {
+				return
+			}
+			n := binary.BigEndian.Uint32(header[:])
+			if n == 0 {
+				break
+			}
+			chunk := make([]byte, n)
+			if _, err := io.ReadFull(reader, chunk); err != nil {
+				return
+			}
+			data = append(data, chunk...)
+		}
+		if strings.Contains(string(data), "EICAR") {
+			conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+		} else {
+			conn.Write([]byte("stream: OK\x00"))
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestClamAVScan(t *testing.T) {
+	tests := []struct {
+		body string
+		want Verdict
+	}{
+		{"hello world", Verdict{Clean: true}},
+		{strings.Repeat("x", 3*clamavChunkSize) + "EICAR", Verdict{Threat: "Eicar-Signature"}},
+	}
+	for _, tt := range tests {
+		scanner := NewClamAV(fakeClamd(t), 5*time.Second)
+		got, err := scanner.Scan(context.Background(), strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Scan() = %+v, want %+v", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Health states of a schedule
+const (
+	HealthOK       = "ok"
+	HealthMissed   = "missed"   // Enabled, but its next run is overdue by more than the grace period
+	HealthFailing  = "failing"  // The last runs all failed
+	HealthDisabled = "disabled" // Not checked
+)
+
+// HealthPolicy sets when a schedule counts as unhealthy
+type HealthPolicy struct {
+	MissedRunGrace   time.Duration // How long a run may be overdue before the schedule counts as missed
+	FailureThreshold int           // Consecutive failed runs that make a schedule failing (1 to maxRecentRuns)
+}
+
+// DefaultHealthPolicy allows a few minutes of delay and alerts on three failures in a row
+var DefaultHealthPolicy = HealthPolicy{MissedRunGrace: 5 * time.Minute, FailureThreshold: 3}
+
+// ScheduleHealth is the health of one schedule
+type ScheduleHealth struct {
+	ID                  string    `json:"id"`
+	Name                string    `json:"name"`
+	Type                string    `json:"type"`
+	Status              string    `json:"status"`           // HealthOK, HealthMissed, HealthFailing or HealthDisabled
+	Reason              string    `json:"reason,omitempty"` // Why the schedule is unhealthy
+	LastRun             time.Time `json:"last_run"`
+	NextRun             time.Time `json:"next_run"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+}
+
+// Healthy reports whether the schedule needs no attention
+func (h ScheduleHealth) Healthy() bool {
+	return h.Status == HealthOK || h.Status == HealthDisabled
+}
+
+// HealthSummary is the health of every schedule, for dashboards
+type HealthSummary struct {
+	CheckedAt time.Time        `json:"checked_at"`
+	Healthy   int              `json:"healthy"`
+	Missed    int              `json:"missed"`
+	Failing   int              `json:"failing"`
+	Disabled  int              `json:"disabled"`
+	Schedules []ScheduleHealth `json:"schedules"`
+}
+
+// Health checks every schedule at now, ordered by ID
+func (s *Scheduler) Health(now time.Time, policy HealthPolicy) HealthSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summary := HealthSummary{CheckedAt: now, Schedules: make([]ScheduleHealth, 0, len(s.schedules))}
+	for _, schedule := range s.schedules {
+		health := healthOf(schedule, now, policy)
+		switch health.Status {
+		case HealthMissed:
+			summary.Missed++
+		case HealthFailing:
+			summary.Failing++
+		case HealthDisabled:
+			summary.Disabled++
+		default:
+			summary.Healthy++
+		}
+		summary.Schedules = append(summary.Schedules, health)
+	}
+	sort.Slice(summary.Schedules, func(i, j int) bool {
+		return summary.Schedules[i].ID < summary.Schedules[j].ID
+	})
+	return summary
+}
+
+// healthOf checks one schedule; the caller holds the scheduler lock
+func healthOf(schedule *Schedule, now time.Time, policy HealthPolicy) ScheduleHealth {
+	health := ScheduleHealth{
+		ID:      schedule.ID,
+		Name:    schedule.Name,
+		Type:    schedule.Type,
+		Status:  HealthOK,
+		LastRun: schedule.LastRun,
+		NextRun: schedule.NextRun,
+	}
+	for i := len(schedule.RecentRuns) - 1; i >= 0; i-- {
+		run := schedule.RecentRuns[i]
+		if run.Overlap == OverlapSkipped {
+			continue // Dropped triggers neither failed nor succeeded
+		}
+		if run.Error == "" {
+			break
+		}
+		if health.ConsecutiveFailures == 0 {
+			health.LastError = run.Error
+		}
+		health.ConsecutiveFailures++
+	}
+
+	threshold := min(max(policy.FailureThreshold, 1), maxRecentRuns)
+	switch {
+	case !schedule.Enabled:
+		health.Status = HealthDisabled
+	case !schedule.NextRun.IsZero() && now.Sub(schedule.NextRun) > policy.MissedRunGrace:
+		health.Status = HealthMissed
+		health.Reason = fmt.Sprintf("run due at %s has not started", schedule.NextRun.Format(time.RFC3339))
+	case health.ConsecutiveFailures >= threshold:
+		health.Status = HealthFailing
+		health.Reason = fmt.Sprintf("last %d runs failed", health.ConsecutiveFailures)
+	}
+	return health
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// scriptedExecutor fails the runs whose index is in fail
+type scriptedExecutor struct {
+	runs int
+	fail map[int]bool
+}
+
+func (e *scriptedExecutor) Execute(ctx context.Context, schedule *Schedule) error {
+	defer func() { e.runs++ }()
+	if e.fail[e.runs] {
+		return errors.New("destination unreachable")
+	}
+	return nil
+}
+
+func TestScheduleHealth(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	policy := HealthPolicy{MissedRunGrace: 5 * time.Minute, FailureThreshold: 2}
+	failed := RunOutcome{Error: "boom"}
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		status   string
+		failures int
+	}{
+		{"on time", Schedule{Enabled: true, NextRun: now.Add(time.Hour)}, HealthOK, 0},
+		{"overdue within grace", Schedule{Enabled: true, NextRun: now.Add(-time.Minute)}, HealthOK, 0},
+		{"overdue", Schedule{Enabled: true, NextRun: now.Add(-time.Hour)}, HealthMissed, 0},
+		{"one failure", Schedule{Enabled: true, NextRun: now.Add(time.Hour), RecentRuns: []RunOutcome{{}, failed}}, HealthOK, 1},
+		{"recovered", Schedule{Enabled: true, NextRun: now.Add(time.Hour), RecentRuns: []RunOutcome{failed, failed, {}}}, HealthOK, 0},
+		{"failing", Schedule{Enabled: true, NextRun: now.Add(time.Hour), RecentRuns: []RunOutcome{{}, failed, failed}}, HealthFailing, 2},
+		{"disabled", Schedule{NextRun: now.Add(-time.Hour), RecentRuns: []RunOutcome{failed, failed}}, HealthDisabled, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := healthOf(&tt.schedule, now, policy)
+			if health.Status != tt.status || health.ConsecutiveFailures != tt.failures {
+				t.Errorf("got %s with %d failures, want %s with %d", health.Status, health.ConsecutiveFailures, tt.status, tt.failures)
+			}
+			if health.Healthy() != (health.Reason == "") {
+				t.Errorf("reason %q for status %s", health.Reason, health.Status)
+			}
+		})
+	}
+}
+
+func TestRecentRunsKeepsLastOutcomes(t *testing.T) {
+	executor := &scriptedExecutor{fail: map[int]bool{10: true, 11: true, 12: true}}
+	s := NewScheduler(executor)
+	if err := s.AddSchedule(&Schedule{ID: "nightly", CronExpr: "0 3 * * *"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 13; i++ {
+		s.executeSchedule("nightly")
+	}
+
+	schedule, _ := s.GetSchedule("nightly")
+	if len(schedule.RecentRuns) != maxRecentRuns || schedule.RunCount != 13 || schedule.FailCount != 3 {
+		t.Fatalf("got %d recent runs, %d runs, %d failures", len(schedule.RecentRuns), schedule.RunCount, schedule.FailCount)
+	}
+	schedule.Enabled = true // Checked as enabled without a cron entry
+	summary := s.Health(time.Now(), HealthPolicy{MissedRunGrace: time.Hour, FailureThreshold: 3})
+	if summary.Failing != 1 || summary.Schedules[0].LastError != "destination unreachable" {
+		t.Errorf("got %+v", summary)
+	}
+}
@@ -2,12 +2,14 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
 
+	"s3migration/pkg/secrets"
 	pkgSync "s3migration/pkg/sync"
 )
 
@@ -26,21 +28,91 @@ const (
 	ConflictRename = pkgSync.ConflictRename
 )
 
+// Schedule types: what a run of the schedule does
+const (
+	TypeSync   = "sync"   // Copy changes from the source to the destination
+	TypeVerify = "verify" // Only compare source and destination and report drift
+)
+
+// OverlapMode is the overlap policy of a schedule: what a trigger does while
+// an earlier run of the schedule is still in progress
+type OverlapMode string
+
+const (
+	OverlapSkip     OverlapMode = "skip"     // Default: the trigger is dropped
+	OverlapQueue    OverlapMode = "queue"    // One run starts once the current runs end; later triggers are dropped
+	OverlapParallel OverlapMode = "parallel" // The trigger starts another run
+)
+
+// How a trigger met a run still in progress, recorded in RunOutcome.Overlap
+const (
+	OverlapSkipped    = "skipped"  // Dropped; nothing ran
+	OverlapQueued     = "queued"   // Ran once the earlier runs ended
+	OverlapInParallel = "parallel" // Ran alongside them
+)
+
+// ValidateOverlapMode checks an overlap mode; empty means OverlapSkip
+func ValidateOverlapMode(mode OverlapMode) error {
+	switch mode {
+	case "", OverlapSkip, OverlapQueue, OverlapParallel:
+		return nil
+	}
+	return fmt.Errorf("unsupported overlap_policy %q (expected skip, queue or parallel)", mode)
+}
+
+// What a run does when it exceeds the MaxDuration of its schedule
+const (
+	TimeoutCancel = "cancel" // Default: cancel the task at once
+	TimeoutDrain  = "drain"  // Start no new objects and let in-flight transfers finish (S3 copies)
+)
+
+// ValidateRunLimit checks the max_run_duration and on_timeout of a schedule;
+// an empty duration means runs are not limited
+func ValidateRunLimit(maxDuration, onTimeout string) error {
+	if maxDuration != "" {
+		if d, err := time.ParseDuration(maxDuration); err != nil || d <= 0 {
+			return fmt.Errorf("invalid max_run_duration %q (expected a positive duration such as 4h)", maxDuration)
+		}
+	}
+	if onTimeout != "" && onTimeout != TimeoutCancel && onTimeout != TimeoutDrain {
+		return fmt.Errorf("unsupported on_timeout %q (expected cancel or drain)", onTimeout)
+	}
+	return nil
+}
+
 // Schedule represents a scheduled migration task
 type Schedule struct {
-	ID          string        `json:"id"`
-	Name        string        `json:"name"`
-	CronExpr    string        `json:"cron_expr"`
-	Enabled     bool          `json:"enabled"`
-	Source      SourceConfig  `json:"source"`
-	Destination DestConfig    `json:"destination"`
-	Options     SyncOptions   `json:"options"`
-	LastRun     time.Time     `json:"last_run"`
-	NextRun     time.Time     `json:"next_run"`
-	RunCount    int           `json:"run_count"`
-	FailCount   int           `json:"fail_count"`
-	CreatedAt   time.Time     `json:"created_at"`
-	UpdatedAt   time.Time     `json:"updated_at"`
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Type        string       `json:"type"` // TypeSync (default) or TypeVerify
+	CronExpr    string       `json:"cron_expr"`
+	Enabled     bool         `json:"enabled"`
+	Source      SourceConfig `json:"source"`
+	Destination DestConfig   `json:"destination"`
+	Options     SyncOptions  `json:"options"`
+	VerifyMode  string       `json:"verify_mode,omitempty"`      // Comparison of verify schedules: "count", "size" or "etag"
+	Overlap     OverlapMode  `json:"overlap_policy,omitempty"`   // What a trigger does while a run is in progress
+	MaxDuration string       `json:"max_run_duration,omitempty"` // Runs taking longer are stopped, e.g. "4h"; empty for no limit
+	OnTimeout   string       `json:"on_timeout,omitempty"`       // How a run is stopped: TimeoutCancel (default) or TimeoutDrain
+	LastRun     time.Time    `json:"last_run"`
+	NextRun     time.Time    `json:"next_run"`
+	RunCount    int          `json:"run_count"`
+	FailCount   int          `json:"fail_count"`
+	RecentRuns  []RunOutcome `json:"recent_runs,omitempty"` // The last maxRecentRuns runs, oldest first
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// maxRecentRuns is how many run outcomes a schedule keeps
+const maxRecentRuns = 10
+
+// RunOutcome records how one run of a schedule ended, or that a trigger was
+// dropped as a run was still in progress
+type RunOutcome struct {
+	StartedAt time.Time `json:"started_at"`
+	Error     string    `json:"error,omitempty"`     // Empty for a successful run
+	Overlap   string    `json:"overlap,omitempty"`   // OverlapSkipped, OverlapQueued or OverlapInParallel when the trigger found a run in progress
+	TimedOut  bool      `json:"timed_out,omitempty"` // Stopped for exceeding the schedule's max_run_duration
 }
 
 // SourceConfig holds source bucket configuration
@@ -59,6 +131,15 @@ type DestConfig struct {
 	Credentials map[string]string `json:"credentials,omitempty"`
 }
 
+// Redacted returns a copy safe to return from the API, with credential values masked.
+// Stored credentials are encrypted at rest.
+func (s *Schedule) Redacted() *Schedule {
+	redacted := *s
+	redacted.Source.Credentials = secrets.RedactMap(s.Source.Credentials)
+	redacted.Destination.Credentials = secrets.RedactMap(s.Destination.Credentials)
+	return &redacted
+}
+
 // Scheduler manages scheduled migration tasks
 type Scheduler struct {
 	mu        sync.RWMutex
@@ -67,6 +148,8 @@ type Scheduler struct {
 	entries   map[string]cron.EntryID
 	executor  TaskExecutor
 	running   bool
+	active    map[string]int  // Runs in progress by schedule
+	queued    map[string]bool // Schedules with a run waiting for the current ones (OverlapQueue)
 }
 
 // TaskExecutor interface for executing migrations
@@ -81,6 +164,8 @@ func NewScheduler(executor TaskExecutor) *Scheduler {
 		schedules: make(map[string]*Schedule),
 		entries:   make(map[string]cron.EntryID),
 		executor:  executor,
+		active:    make(map[string]int),
+		queued:    make(map[string]bool),
 	}
 }
 
@@ -127,6 +212,12 @@ func (s *Scheduler) AddSchedule(schedule *Schedule) error {
 	if err != nil {
 		return fmt.Errorf("invalid cron expression: %w", err)
 	}
+	if _, err := schedule.Resolved(time.Now()); err != nil {
+		return err
+	}
+	if err := ValidateRunLimit(schedule.MaxDuration, schedule.OnTimeout); err != nil {
+		return err
+	}
 
 	// Set metadata
 	now := time.Now()
@@ -166,6 +257,7 @@ func (s *Scheduler) RemoveSchedule(id string) error {
 	}
 
 	delete(s.schedules, id)
+	delete(s.queued, id)
 	return nil
 }
 
@@ -178,11 +270,18 @@ func (s *Scheduler) UpdateSchedule(schedule *Schedule) error {
 	if !exists {
 		return fmt.Errorf("schedule %s not found", schedule.ID)
 	}
+	if _, err := schedule.Resolved(time.Now()); err != nil {
+		return err
+	}
+	if err := ValidateRunLimit(schedule.MaxDuration, schedule.OnTimeout); err != nil {
+		return err
+	}
 
 	// Preserve metadata
 	schedule.CreatedAt = oldSchedule.CreatedAt
 	schedule.RunCount = oldSchedule.RunCount
 	schedule.FailCount = oldSchedule.FailCount
+	schedule.RecentRuns = oldSchedule.RecentRuns
 	schedule.UpdatedAt = time.Now()
 
 	// Remove old cron entry
@@ -291,6 +390,8 @@ func (s *Scheduler) RunNow(id string) error {
 	return nil
 }
 
+// executeSchedule runs a schedule on a trigger, unless a run in progress
+// holds the trigger back under the schedule's overlap policy
 func (s *Scheduler) executeSchedule(id string) {
 	s.mu.Lock()
 	schedule, exists := s.schedules[id]
@@ -299,26 +400,98 @@ func (s *Scheduler) executeSchedule(id string) {
 		return
 	}
 
-	schedule.LastRun = time.Now()
+	overlap := ""
+	if s.active[id] > 0 {
+		switch schedule.Overlap {
+		case OverlapParallel:
+			overlap = OverlapInParallel
+		case OverlapQueue:
+			if !s.queued[id] {
+				s.queued[id] = true
+				s.mu.Unlock()
+				return
+			}
+			fallthrough
+		default:
+			// Move the next run time on, as the trigger counts as handled
+			now := time.Now()
+			if cronSchedule, err := cron.ParseStandard(schedule.CronExpr); err == nil {
+				schedule.NextRun = cronSchedule.Next(now)
+			}
+			s.recordOutcome(schedule, RunOutcome{StartedAt: now, Overlap: OverlapSkipped})
+			s.mu.Unlock()
+			return
+		}
+	}
+	s.active[id]++
+	s.mu.Unlock()
+
+	s.run(schedule, overlap)
+}
+
+// run executes one run of a schedule counted in s.active
+func (s *Scheduler) run(schedule *Schedule, overlap string) {
+	s.mu.Lock()
+	startedAt := time.Now()
+	schedule.LastRun = startedAt
 	schedule.RunCount++
+	// Move the next run time on now, so a long run does not look like a missed one
+	cronSchedule, parseErr := cron.ParseStandard(schedule.CronExpr)
+	if parseErr == nil {
+		schedule.NextRun = cronSchedule.Next(startedAt)
+	}
+	// The run gets the prefixes with their template variables resolved
+	run, err := schedule.Resolved(startedAt)
+	maxDuration, _ := time.ParseDuration(schedule.MaxDuration)
 	s.mu.Unlock()
 
-	// Execute migration
+	// Execute migration; the executor stops its task once ctx ends
 	ctx := context.Background()
-	err := s.executor.Execute(ctx, schedule)
+	if maxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, maxDuration)
+		defer cancel()
+	}
+	if err == nil {
+		err = s.executor.Execute(ctx, run)
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	outcome := RunOutcome{StartedAt: startedAt, Overlap: overlap, TimedOut: errors.Is(ctx.Err(), context.DeadlineExceeded)}
 	if err != nil {
 		schedule.FailCount++
+		outcome.Error = err.Error()
 	}
+	s.recordOutcome(schedule, outcome)
 
 	// Update next run time
-	cronSchedule, parseErr := cron.ParseStandard(schedule.CronExpr)
 	if parseErr == nil {
 		schedule.NextRun = cronSchedule.Next(time.Now())
 	}
+
+	id := schedule.ID
+	if s.active[id]--; s.active[id] > 0 {
+		return
+	}
+	delete(s.active, id)
+	if s.queued[id] {
+		delete(s.queued, id)
+		if current, exists := s.schedules[id]; exists {
+			s.active[id]++
+			go s.run(current, OverlapQueued)
+		}
+	}
+}
+
+// recordOutcome adds an outcome to the recent runs of a schedule; the caller
+// holds the scheduler lock
+func (s *Scheduler) recordOutcome(schedule *Schedule, outcome RunOutcome) {
+	schedule.RecentRuns = append(schedule.RecentRuns, outcome)
+	if len(schedule.RecentRuns) > maxRecentRuns {
+		schedule.RecentRuns = append([]RunOutcome(nil), schedule.RecentRuns[len(schedule.RecentRuns)-maxRecentRuns:]...)
+	}
 }
 
 // GetStats returns scheduler statistics
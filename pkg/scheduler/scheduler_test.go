@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// blockingExecutor holds every run until release is closed
+type blockingExecutor struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (e *blockingExecutor) Execute(ctx context.Context, schedule *Schedule) error {
+	e.started <- struct{}{}
+	<-e.release
+	return nil
+}
+
+func TestOverlapPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     OverlapMode
+		parallel bool     // Triggers while the first run is in progress start runs of their own
+		want     []string // Overlap of the recorded outcomes, sorted
+		runs     int
+	}{
+		{name: "skip by default", mode: "", want: []string{"", OverlapSkipped, OverlapSkipped}, runs: 1},
+		{name: "queue", mode: OverlapQueue, want: []string{"", OverlapQueued, OverlapSkipped}, runs: 2},
+		{name: "parallel", mode: OverlapParallel, parallel: true, want: []string{"", OverlapInParallel, OverlapInParallel}, runs: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &blockingExecutor{started: make(chan struct{}, 3), release: make(chan struct{})}
+			s := NewScheduler(executor)
+			if err := s.AddSchedule(&Schedule{ID: "hourly", CronExpr: "0 * * * *", Overlap: tt.mode}); err != nil {
+				t.Fatal(err)
+			}
+
+			go s.executeSchedule("hourly")
+			<-executor.started
+			for i := 0; i < 2; i++ {
+				if tt.parallel {
+					go s.executeSchedule("hourly")
+					<-executor.started
+				} else {
+					s.executeSchedule("hourly") // Returns at once: the trigger is held back
+				}
+			}
+			close(executor.release)
+
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				s.mu.RLock()
+				schedule := s.schedules["hourly"]
+				outcomes := append([]RunOutcome(nil), schedule.RecentRuns...)
+				runs, active := schedule.RunCount, len(s.active)
+				s.mu.RUnlock()
+				if len(outcomes) == len(tt.want) && active == 0 {
+					var got []string
+					for _, outcome := range outcomes {
+						got = append(got, outcome.Overlap)
+					}
+					sort.Strings(got)
+					if !reflect.DeepEqual(got, tt.want) || runs != tt.runs {
+						t.Errorf("outcomes %q after %d runs, want %q after %d", got, runs, tt.want, tt.runs)
+					}
+					return
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("runs did not finish: %d runs, %d active, outcomes %+v", runs, active, outcomes)
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestSkippedTriggersDoNotHideFailures(t *testing.T) {
+	failed := RunOutcome{Error: "destination unreachable"}
+	skipped := RunOutcome{Overlap: OverlapSkipped}
+	schedule := &Schedule{Enabled: true, RecentRuns: []RunOutcome{{}, failed, skipped, failed, skipped}}
+	health := healthOf(schedule, time.Now(), HealthPolicy{MissedRunGrace: time.Hour, FailureThreshold: 2})
+	if health.Status != HealthFailing || health.ConsecutiveFailures != 2 {
+		t.Errorf("got %s with %d failures, want failing with 2", health.Status, health.ConsecutiveFailures)
+	}
+}
+
+// stuckExecutor runs until its context ends
+type stuckExecutor struct{}
+
+func (stuckExecutor) Execute(ctx context.Context, schedule *Schedule) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestMaxRunDuration(t *testing.T) {
+	s := NewScheduler(stuckExecutor{})
+	for _, invalid := range []*Schedule{
+		{ID: "zero", CronExpr: "0 3 * * *", MaxDuration: "0s"},
+		{ID: "unparsable", CronExpr: "0 3 * * *", MaxDuration: "overnight"},
+		{ID: "on_timeout", CronExpr: "0 3 * * *", MaxDuration: "4h", OnTimeout: "kill"},
+	} {
+		if err := s.AddSchedule(invalid); err == nil {
+			t.Errorf("schedule %s was added", invalid.ID)
+		}
+	}
+	if err := s.AddSchedule(&Schedule{ID: "nightly", CronExpr: "0 3 * * *", MaxDuration: "20ms"}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.executeSchedule("nightly")
+	schedule, _ := s.GetSchedule("nightly")
+	if len(schedule.RecentRuns) != 1 || !schedule.RecentRuns[0].TimedOut || schedule.RecentRuns[0].Error == "" || schedule.FailCount != 1 {
+		t.Errorf("recent runs %+v, %d failures; want one timed-out failure", schedule.RecentRuns, schedule.FailCount)
+	}
+}
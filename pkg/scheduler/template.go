@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// prefixVariables are the template variables of schedule prefixes: each is
+// the run time (UTC) moved back by an offset, with the layout used when the
+// variable gives none
+var prefixVariables = map[string]struct {
+	offset time.Duration
+	layout string
+}{
+	"now":       {0, "2006/01/02/15"},
+	"last_hour": {-time.Hour, "2006/01/02/15"},
+	"today":     {0, "2006/01/02"},
+	"yesterday": {-24 * time.Hour, "2006/01/02"},
+}
+
+// ResolvePrefix replaces the template variables of a schedule prefix with
+// dates of the run at runAt. A variable is written {{name}} or
+// {{name:layout}}, where layout is a Go time layout, e.g.
+// "logs/{{yesterday:2006/01/02}}/" is "logs/2024/03/14/" for a run on
+// 2024-03-15.
+func ResolvePrefix(prefix string, runAt time.Time) (string, error) {
+	var resolved strings.Builder
+	rest := prefix
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			resolved.WriteString(rest)
+			return resolved.String(), nil
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			return "", fmt.Errorf("unclosed template variable in %q", prefix)
+		}
+		resolved.WriteString(rest[:start])
+		name, layout, hasLayout := strings.Cut(strings.TrimSpace(rest[start+2:start+end]), ":")
+		variable, ok := prefixVariables[name]
+		if !ok {
+			return "", fmt.Errorf("unknown template variable %q in %q (expected now, last_hour, today or yesterday)", name, prefix)
+		}
+		if !hasLayout {
+			layout = variable.layout
+		} else if layout == "" {
+			return "", fmt.Errorf("empty layout for %q in %q", name, prefix)
+		}
+		resolved.WriteString(runAt.UTC().Add(variable.offset).Format(layout))
+		rest = rest[start+end+2:]
+	}
+}
+
+// Resolved returns a copy of the schedule with the template variables of its
+// prefixes resolved for a run at runAt
+func (s *Schedule) Resolved(runAt time.Time) (*Schedule, error) {
+	resolved := *s
+	var err error
+	if resolved.Source.Prefix, err = ResolvePrefix(s.Source.Prefix, runAt); err != nil {
+		return nil, fmt.Errorf("source prefix: %w", err)
+	}
+	if resolved.Destination.Prefix, err = ResolvePrefix(s.Destination.Prefix, runAt); err != nil {
+		return nil, fmt.Errorf("destination prefix: %w", err)
+	}
+	return &resolved, nil
+}
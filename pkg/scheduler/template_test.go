@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestResolvePrefix(t *testing.T) {
+	runAt := time.Date(2024, 3, 1, 0, 30, 0, 0, time.FixedZone("CET", 3600))
+	tests := []struct {
+		name    string
+		prefix  string
+		want    string
+		wantErr bool
+	}{
+		{name: "no variables", prefix: "logs/", want: "logs/"},
+		{name: "yesterday with layout", prefix: "logs/{{yesterday:2006/01/02}}/", want: "logs/2024/02/28/"},
+		{name: "default layouts", prefix: "{{today}}/{{now}}/{{last_hour}}", want: "2024/02/29/2024/02/29/23/2024/02/29/22"},
+		{name: "partition layout", prefix: "events/dt={{ yesterday:2006-01-02 }}/", want: "events/dt=2024-02-28/"},
+		{name: "unknown variable", prefix: "logs/{{tomorrow}}/", wantErr: true},
+		{name: "unclosed", prefix: "logs/{{today/", wantErr: true},
+		{name: "empty layout", prefix: "logs/{{today:}}/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolvePrefix(tt.prefix, runAt)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ResolvePrefix(%q) = %q, %v; want %q, error %v", tt.prefix, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// recordingExecutor keeps the schedules it was asked to run
+type recordingExecutor struct {
+	runs []*Schedule
+}
+
+func (e *recordingExecutor) Execute(ctx context.Context, schedule *Schedule) error {
+	e.runs = append(e.runs, schedule)
+	return nil
+}
+
+func TestScheduleRunsWithResolvedPrefixes(t *testing.T) {
+	executor := &recordingExecutor{}
+	s := NewScheduler(executor)
+	if err := s.AddSchedule(&Schedule{ID: "bad", CronExpr: "0 3 * * *", Source: SourceConfig{Prefix: "{{someday}}/"}}); err == nil {
+		t.Fatal("schedule with an unknown template variable was added")
+	}
+	schedule := &Schedule{
+		ID:          "daily",
+		CronExpr:    "0 3 * * *",
+		Source:      SourceConfig{Prefix: "logs/{{yesterday:2006/01/02}}/"},
+		Destination: DestConfig{Prefix: "archive/{{yesterday:2006}}/"},
+	}
+	if err := s.AddSchedule(schedule); err != nil {
+		t.Fatal(err)
+	}
+
+	s.executeSchedule("daily")
+	if len(executor.runs) != 1 {
+		t.Fatalf("%d runs, want 1", len(executor.runs))
+	}
+	yesterday := time.Now().UTC().Add(-24 * time.Hour)
+	run := executor.runs[0]
+	if want := "logs/" + yesterday.Format("2006/01/02") + "/"; run.Source.Prefix != want {
+		t.Errorf("source prefix = %q, want %q", run.Source.Prefix, want)
+	}
+	if want := "archive/" + yesterday.Format("2006") + "/"; run.Destination.Prefix != want {
+		t.Errorf("destination prefix = %q, want %q", run.Destination.Prefix, want)
+	}
+	if schedule.Source.Prefix != "logs/{{yesterday:2006/01/02}}/" {
+		t.Errorf("stored source prefix changed to %q", schedule.Source.Prefix)
+	}
+}
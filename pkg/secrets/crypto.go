@@ -0,0 +1,258 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// encryptedPrefix marks values produced by Encrypt so they are never encrypted
+// twice or mistaken for plaintext
+const encryptedPrefix = "enc:v1:"
+
+// Redacted replaces secret values in API responses
+const Redacted = "[REDACTED]"
+
+// defaultKeyFile stores the generated key when ENCRYPTION_KEY is not set
+const defaultKeyFile = "/app/data/encryption.key"
+
+var (
+	aead     cipher.AEAD
+	aeadErr  error
+	aeadOnce sync.Once
+	rawKey   bool // The configured key is used as-is, as earlier versions used it
+)
+
+// getOrGenerateKey returns the configured key with multiple fallback options
+func getOrGenerateKey() (string, error) {
+	// Priority 1: Environment variable
+	if envKey := os.Getenv("ENCRYPTION_KEY"); envKey != "" {
+		return envKey, nil
+	}
+
+	// Priority 2: Key file in data directory
+	if key, err := loadKeyFromFile(defaultKeyFile); err == nil && key != "" {
+		return key, nil
+	}
+
+	// Priority 3: Generate and save new key
+	return generateAndSaveKey(defaultKeyFile)
+}
+
+// loadKeyFromFile reads an encryption key from file
+func loadKeyFromFile(keyFile string) (string, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return "", err
+	}
+
+	key := strings.TrimSpace(string(data))
+	if len(key) < 16 {
+		return "", fmt.Errorf("key too short")
+	}
+
+	return key, nil
+}
+
+// generateAndSaveKey creates a random key and saves it for later restarts
+func generateAndSaveKey(keyFile string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0755); err != nil {
+		return "", fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	keyBytes := make([]byte, 32)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return "", fmt.Errorf("failed to generate random key: %w", err)
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	if err := os.WriteFile(keyFile, []byte(key), 0600); err != nil {
+		return "", fmt.Errorf("failed to save key file: %w", err)
+	}
+
+	return key, nil
+}
+
+// deriveKey turns the configured key string into an AES key. Raw 16/24/32-byte
+// keys are used as-is, base64 keys (openssl rand -base64 32) are decoded, and
+// anything else is hashed to 32 bytes.
+func deriveKey(keyStr string) []byte {
+	switch len(keyStr) {
+	case 16, 24, 32:
+		return []byte(keyStr)
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(keyStr); err == nil {
+		switch len(decoded) {
+		case 16, 24, 32:
+			return decoded
+		}
+	}
+	sum := sha256.Sum256([]byte(keyStr))
+	return sum[:]
+}
+
+// getAEAD returns the AES-GCM cipher, loading the key once
+func getAEAD() (cipher.AEAD, error) {
+	aeadOnce.Do(func() {
+		keyStr, err := getOrGenerateKey()
+		if err != nil {
+			aeadErr = fmt.Errorf("failed to load encryption key: %w", err)
+			return
+		}
+
+		switch len(keyStr) {
+		case 16, 24, 32:
+			rawKey = true
+		}
+		block, err := aes.NewCipher(deriveKey(keyStr))
+		if err != nil {
+			aeadErr = err
+			return
+		}
+
+		aead, aeadErr = cipher.NewGCM(block)
+	})
+	return aead, aeadErr
+}
+
+// IsEncrypted reports whether value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// Encrypt encrypts a secret with AES-GCM. Empty and already encrypted values are returned unchanged.
+func Encrypt(plaintext string) (string, error) {
+	if plaintext == "" || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+
+	gcm, err := getAEAD()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt reverses Encrypt. Empty values are returned unchanged.
+func Decrypt(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if !IsEncrypted(value) {
+		return "", fmt.Errorf("value is not encrypted")
+	}
+
+	gcm, err := getAEAD()
+	if err != nil {
+		return "", err
+	}
+	return open(gcm, strings.TrimPrefix(value, encryptedPrefix))
+}
+
+// DecryptStored decrypts a stored value: one produced by Encrypt, or one that
+// earlier versions wrote without the enc:v1: prefix. Those encrypted with
+// AES-GCM under the raw configured key, which only works for keys of 16, 24 or
+// 32 bytes, and stored the plaintext when the key had any other length. So an
+// unprefixed value is decrypted as such under a raw key, and an error when that
+// fails; under any other key it is the plaintext.
+func DecryptStored(value string) (string, error) {
+	if value == "" || IsEncrypted(value) {
+		return Decrypt(value)
+	}
+
+	gcm, err := getAEAD()
+	if err != nil {
+		return "", err
+	}
+	if !rawKey {
+		return value, nil
+	}
+	plaintext, err := open(gcm, value)
+	if err != nil {
+		return "", fmt.Errorf("value without the %s prefix is not a ciphertext of the configured key: %w", encryptedPrefix, err)
+	}
+	return plaintext, nil
+}
+
+// open decrypts a base64 nonce and ciphertext
+func open(gcm cipher.AEAD, encoded string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// EncryptMap returns a copy of a credentials map with every value encrypted
+func EncryptMap(values map[string]string) (map[string]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+	encrypted := make(map[string]string, len(values))
+	for key, value := range values {
+		enc, err := Encrypt(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt %s: %w", key, err)
+		}
+		encrypted[key] = enc
+	}
+	return encrypted, nil
+}
+
+// DecryptMap returns a copy of a credentials map with every value decrypted
+func DecryptMap(values map[string]string) (map[string]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+	decrypted := make(map[string]string, len(values))
+	for key, value := range values {
+		dec, err := Decrypt(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", key, err)
+		}
+		decrypted[key] = dec
+	}
+	return decrypted, nil
+}
+
+// RedactMap returns a copy of a credentials map with every non-empty value replaced by Redacted
+func RedactMap(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(values))
+	for key, value := range values {
+		if value != "" {
+			value = Redacted
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
@@ -0,0 +1,144 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// useKey configures ENCRYPTION_KEY for a test and drops the cipher built for the previous one
+func useKey(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv("ENCRYPTION_KEY", key)
+	aead, aeadErr, aeadOnce, rawKey = nil, nil, sync.Once{}, false
+	t.Cleanup(func() { aead, aeadErr, aeadOnce, rawKey = nil, nil, sync.Once{}, false })
+}
+
+// legacyEncrypt encrypts as earlier versions stored credentials: AES-GCM under
+// the raw key, base64 without a prefix
+func legacyEncrypt(t *testing.T, key, plaintext string) string {
+	t.Helper()
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	rand.Read(nonce)
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+const rawTestKey = "0123456789abcdef0123456789abcdef"
+
+func TestEncryptRoundTrip(t *testing.T) {
+	keys := map[string]string{
+		"raw":    rawTestKey,
+		"base64": "q83vEjRWeJCrze8SNFZ4kKvN7xI0VniQq83vEjRWeJA=",
+		"phrase": "a passphrase of any length",
+	}
+	for name, key := range keys {
+		t.Run(name, func(t *testing.T) {
+			useKey(t, key)
+			encrypted, err := Encrypt("AKIAEXAMPLE")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !IsEncrypted(encrypted) || strings.Contains(encrypted, "AKIAEXAMPLE") {
+				t.Fatalf("Encrypt() = %q", encrypted)
+			}
+			if again, _ := Encrypt(encrypted); again != encrypted {
+				t.Error("an encrypted value was encrypted again")
+			}
+			for _, decrypt := range []func(string) (string, error){Decrypt, DecryptStored} {
+				if plaintext, err := decrypt(encrypted); err != nil || plaintext != "AKIAEXAMPLE" {
+					t.Errorf("decrypted %q, %v", plaintext, err)
+				}
+			}
+		})
+	}
+
+	useKey(t, rawTestKey)
+	if encrypted, err := Encrypt(""); encrypted != "" || err != nil {
+		t.Errorf("Encrypt(\"\") = %q, %v", encrypted, err)
+	}
+	if plaintext, err := DecryptStored(""); plaintext != "" || err != nil {
+		t.Errorf("DecryptStored(\"\") = %q, %v", plaintext, err)
+	}
+}
+
+func TestDecryptRejectsTamperingAndOtherKeys(t *testing.T) {
+	useKey(t, rawTestKey)
+	encrypted, err := Encrypt("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, encryptedPrefix))
+	data[len(data)-1] ^= 1
+	tampered := encryptedPrefix + base64.StdEncoding.EncodeToString(data)
+
+	tests := map[string]string{
+		"tampered":   tampered,
+		"truncated":  encryptedPrefix + base64.StdEncoding.EncodeToString(data[:4]),
+		"not base64": encryptedPrefix + "%%%",
+	}
+	for name, value := range tests {
+		if plaintext, err := DecryptStored(value); err == nil {
+			t.Errorf("%s: decrypted to %q", name, plaintext)
+		}
+	}
+	if _, err := Decrypt("plaintext"); err == nil {
+		t.Error("Decrypt accepted a value without the prefix")
+	}
+
+	useKey(t, "fedcba9876543210fedcba9876543210")
+	if plaintext, err := DecryptStored(encrypted); err == nil {
+		t.Errorf("decrypted with another key: %q", plaintext)
+	}
+}
+
+func TestDecryptStoredLegacyValues(t *testing.T) {
+	useKey(t, rawTestKey)
+	legacy := legacyEncrypt(t, rawTestKey, "AKIALEGACY")
+	if plaintext, err := DecryptStored(legacy); err != nil || plaintext != "AKIALEGACY" {
+		t.Errorf("DecryptStored(legacy) = %q, %v", plaintext, err)
+	}
+	// Under a raw key earlier versions always encrypted, so an unprefixed value that does not decrypt is an error
+	if plaintext, err := DecryptStored("AKIAPLAINTEXT"); err == nil {
+		t.Errorf("undecryptable legacy value returned as %q", plaintext)
+	}
+	if plaintext, err := DecryptStored(legacyEncrypt(t, "fedcba9876543210fedcba9876543210", "AKIAOTHER")); err == nil {
+		t.Errorf("legacy value of another key returned as %q", plaintext)
+	}
+
+	// Other keys could not encrypt in earlier versions, which stored the plaintext
+	useKey(t, "a passphrase of any length")
+	if plaintext, err := DecryptStored("AKIAPLAINTEXT"); err != nil || plaintext != "AKIAPLAINTEXT" {
+		t.Errorf("DecryptStored(plaintext) = %q, %v", plaintext, err)
+	}
+}
+
+func TestEncryptMapRoundTrip(t *testing.T) {
+	useKey(t, rawTestKey)
+	values := map[string]string{"access_key": "AKIAEXAMPLE", "secret_key": "secret", "region": ""}
+	encrypted, err := EncryptMap(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encrypted["region"] != "" || !IsEncrypted(encrypted["access_key"]) {
+		t.Fatalf("EncryptMap() = %v", encrypted)
+	}
+	decrypted, err := DecryptMap(encrypted)
+	if err != nil || len(decrypted) != 3 || decrypted["secret_key"] != "secret" {
+		t.Errorf("DecryptMap() = %v, %v", decrypted, err)
+	}
+	if redacted := RedactMap(values); redacted["access_key"] != Redacted || redacted["region"] != "" {
+		t.Errorf("RedactMap() = %v", redacted)
+	}
+}
@@ -0,0 +1,355 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// Credential references resolved at task start instead of storing keys:
+//
+//	arn:aws:secretsmanager:<region>:<account>:secret:<name>  AWS Secrets Manager secret
+//	vault:<path>                                             Vault secret at /v1/<path> (KV v1 or v2)
+//
+// The secret must be a JSON object with access_key/secret_key (AWS-style
+// AccessKeyId/SecretAccessKey and aws_access_key_id/aws_secret_access_key are
+// also accepted) and optionally session_token, region and endpoint_url.
+const (
+	awsSecretPrefix   = "arn:aws:secretsmanager:"
+	vaultSecretPrefix = "vault:"
+)
+
+// defaultSecretCacheTTL is how long a resolved secret is reused before it is fetched again
+const defaultSecretCacheTTL = 5 * time.Minute
+
+// secretFetchTimeout bounds a single Secrets Manager or Vault request
+const secretFetchTimeout = 15 * time.Second
+
+// ResolvedCredentials are the S3 credentials read from a secret
+type ResolvedCredentials struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Region       string
+	EndpointURL  string
+	Version      string // Secrets Manager VersionId or Vault KV version, used to detect rotation
+	FetchedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+// Resolver fetches credential references and caches them for a TTL
+type Resolver struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	cache      map[string]*ResolvedCredentials
+	httpClient *http.Client
+}
+
+var (
+	defaultResolver     *Resolver
+	defaultResolverOnce sync.Once
+)
+
+// NewResolver creates a resolver; ttl <= 0 uses the default
+func NewResolver(ttl time.Duration) *Resolver {
+	if ttl <= 0 {
+		ttl = defaultSecretCacheTTL
+	}
+	return &Resolver{
+		ttl:        ttl,
+		cache:      make(map[string]*ResolvedCredentials),
+		httpClient: &http.Client{Timeout: secretFetchTimeout},
+	}
+}
+
+// DefaultResolver returns the shared resolver, with the TTL from SECRETS_CACHE_TTL (e.g. "5m")
+func DefaultResolver() *Resolver {
+	defaultResolverOnce.Do(func() {
+		var ttl time.Duration
+		if value := os.Getenv("SECRETS_CACHE_TTL"); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				fmt.Printf("⚠️  Invalid SECRETS_CACHE_TTL=%q, using %v\n", value, defaultSecretCacheTTL)
+			}
+			ttl = parsed
+		}
+		defaultResolver = NewResolver(ttl)
+	})
+	return defaultResolver
+}
+
+// IsReference reports whether ref is a supported credential reference
+func IsReference(ref string) bool {
+	return strings.HasPrefix(ref, awsSecretPrefix) || strings.HasPrefix(ref, vaultSecretPrefix)
+}
+
+// ValidateReference checks the syntax of a reference without fetching it
+func ValidateReference(ref string) error {
+	switch {
+	case strings.HasPrefix(ref, awsSecretPrefix):
+		_, err := regionFromARN(ref)
+		return err
+	case strings.HasPrefix(ref, vaultSecretPrefix):
+		if vaultPath(ref) == "" {
+			return fmt.Errorf("vault reference needs a path, e.g. vault:secret/data/s3/source")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported secret reference, expected a Secrets Manager ARN or vault:<path>")
+	}
+}
+
+// Resolve returns the credentials for ref, from cache while still fresh
+func (r *Resolver) Resolve(ctx context.Context, ref string) (*ResolvedCredentials, error) {
+	r.mu.Lock()
+	cached, ok := r.cache[ref]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.ExpiresAt) {
+		return cached, nil
+	}
+	return r.Refresh(ctx, ref)
+}
+
+// Refresh fetches ref from its backend, bypassing the cache
+func (r *Resolver) Refresh(ctx context.Context, ref string) (*ResolvedCredentials, error) {
+	var (
+		values  map[string]interface{}
+		version string
+		ttl     = r.ttl
+		err     error
+	)
+	switch {
+	case strings.HasPrefix(ref, awsSecretPrefix):
+		values, version, err = r.fetchAWSSecret(ctx, ref)
+	case strings.HasPrefix(ref, vaultSecretPrefix):
+		var lease time.Duration
+		values, version, lease, err = r.fetchVaultSecret(ctx, ref)
+		if lease > 0 && lease < ttl {
+			ttl = lease // Dynamic secrets: never reuse past the lease
+		}
+	default:
+		err = ValidateReference(ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := credentialsFromSecret(values)
+	if resolved.AccessKey == "" || resolved.SecretKey == "" {
+		return nil, fmt.Errorf("secret %s has no access_key/secret_key", ref)
+	}
+	now := time.Now()
+	resolved.Version = version
+	resolved.FetchedAt = now
+	resolved.ExpiresAt = now.Add(ttl)
+
+	r.mu.Lock()
+	if previous, ok := r.cache[ref]; ok && previous.AccessKey != resolved.AccessKey {
+		fmt.Printf("🔄 Secret %s rotated (version %s -> %s)\n", ref, previous.Version, version)
+	}
+	r.cache[ref] = resolved
+	r.mu.Unlock()
+	return resolved, nil
+}
+
+// Invalidate drops ref from the cache so the next Resolve fetches it again
+func (r *Resolver) Invalidate(ref string) {
+	r.mu.Lock()
+	delete(r.cache, ref)
+	r.mu.Unlock()
+}
+
+// CredentialsProvider returns an AWS credentials provider backed by ref. Credentials
+// expire with the cache entry, so the SDK re-resolves them and long-running tasks
+// pick up rotated keys without a restart.
+func (r *Resolver) CredentialsProvider(ref string) aws.CredentialsProvider {
+	return aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		resolved, err := r.Resolve(ctx, ref)
+		if err != nil {
+			return aws.Credentials{}, err
+		}
+		return aws.Credentials{
+			AccessKeyID:     resolved.AccessKey,
+			SecretAccessKey: resolved.SecretKey,
+			SessionToken:    resolved.SessionToken,
+			Source:          "SecretReference",
+			CanExpire:       true,
+			Expires:         resolved.ExpiresAt,
+		}, nil
+	})
+}
+
+// credentialsFromSecret maps the secret's JSON fields onto credentials
+func credentialsFromSecret(values map[string]interface{}) *ResolvedCredentials {
+	pick := func(keys ...string) string {
+		for _, key := range keys {
+			if value, ok := values[key].(string); ok && value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+	return &ResolvedCredentials{
+		AccessKey:    pick("access_key", "AccessKeyId", "aws_access_key_id", "accessKey"),
+		SecretKey:    pick("secret_key", "SecretAccessKey", "aws_secret_access_key", "secretKey"),
+		SessionToken: pick("session_token", "SessionToken", "aws_session_token", "sessionToken"),
+		Region:       pick("region", "Region"),
+		EndpointURL:  pick("endpoint_url", "EndpointURL", "endpoint"),
+	}
+}
+
+// regionFromARN extracts the region from a Secrets Manager ARN
+func regionFromARN(arn string) (string, error) {
+	parts := strings.SplitN(arn, ":", 7)
+	if len(parts) < 7 || parts[3] == "" || parts[5] != "secret" || parts[6] == "" {
+		return "", fmt.Errorf("invalid Secrets Manager ARN %q", arn)
+	}
+	return parts[3], nil
+}
+
+// fetchAWSSecret calls Secrets Manager GetSecretValue with the service's own
+// default credential chain (IAM role, IRSA, environment)
+func (r *Resolver) fetchAWSSecret(ctx context.Context, arn string) (map[string]interface{}, string, error) {
+	region, err := regionFromARN(arn)
+	if err != nil {
+		return nil, "", err
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load AWS config for Secrets Manager: %w", err)
+	}
+	creds, err := awsCfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("no AWS credentials to read secret: %w", err)
+	}
+
+	endpoint := os.Getenv("SECRETS_MANAGER_ENDPOINT")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+	}
+	body, _ := json.Marshal(map[string]string{"SecretId": arn})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	payloadHash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(payloadHash[:]), "secretsmanager", region, time.Now()); err != nil {
+		return nil, "", fmt.Errorf("failed to sign Secrets Manager request: %w", err)
+	}
+
+	var out struct {
+		SecretString string `json:"SecretString"`
+		VersionID    string `json:"VersionId"`
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+	}
+	status, err := r.doJSON(req, &out)
+	if err != nil {
+		return nil, "", fmt.Errorf("Secrets Manager request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, "", fmt.Errorf("Secrets Manager returned %d: %s %s", status, out.Type, out.Message)
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(out.SecretString), &values); err != nil {
+		return nil, "", fmt.Errorf("secret %s is not a JSON object", arn)
+	}
+	return values, out.VersionID, nil
+}
+
+// vaultPath returns the API path of a vault: reference
+func vaultPath(ref string) string {
+	return strings.Trim(strings.TrimPrefix(strings.TrimPrefix(ref, vaultSecretPrefix), "//"), "/")
+}
+
+// fetchVaultSecret reads a Vault secret using VAULT_ADDR and VAULT_TOKEN (or
+// VAULT_TOKEN_FILE, e.g. a Vault Agent sink). Returns the lease for dynamic secrets.
+func (r *Resolver) fetchVaultSecret(ctx context.Context, ref string) (map[string]interface{}, string, time.Duration, error) {
+	addr := strings.TrimRight(os.Getenv("VAULT_ADDR"), "/")
+	if addr == "" {
+		return nil, "", 0, fmt.Errorf("VAULT_ADDR is not set")
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if tokenFile := os.Getenv("VAULT_TOKEN_FILE"); token == "" && tokenFile != "" {
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return nil, "", 0, fmt.Errorf("failed to read VAULT_TOKEN_FILE: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token == "" {
+		return nil, "", 0, fmt.Errorf("VAULT_TOKEN or VAULT_TOKEN_FILE is required")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr+"/v1/"+vaultPath(ref), nil)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	var out struct {
+		Data          map[string]interface{} `json:"data"`
+		LeaseDuration int64                  `json:"lease_duration"`
+		Errors        []string               `json:"errors"`
+	}
+	status, err := r.doJSON(req, &out)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("Vault request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, "", 0, fmt.Errorf("Vault returned %d: %s", status, strings.Join(out.Errors, "; "))
+	}
+
+	values := out.Data
+	version := ""
+	// KV v2 nests the secret under data.data with metadata alongside
+	if nested, ok := out.Data["data"].(map[string]interface{}); ok {
+		values = nested
+		if metadata, ok := out.Data["metadata"].(map[string]interface{}); ok {
+			if v, ok := metadata["version"].(float64); ok {
+				version = fmt.Sprintf("%d", int64(v))
+			}
+		}
+	}
+	return values, version, time.Duration(out.LeaseDuration) * time.Second, nil
+}
+
+// doJSON sends req and decodes the JSON response body into out
+func (r *Resolver) doJSON(req *http.Request, out interface{}) (int, error) {
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil && resp.StatusCode == http.StatusOK {
+			return resp.StatusCode, fmt.Errorf("invalid JSON response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,152 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AuditManager handles database operations for the audit log
+type AuditManager struct {
+	db *sql.DB
+}
+
+// NewAuditManager creates a new audit manager
+func NewAuditManager(db *sql.DB) *AuditManager {
+	return &AuditManager{db: db}
+}
+
+// AuditEntry is one recorded mutating API call
+type AuditEntry struct {
+	ID             int64     `json:"id"`
+	Timestamp      time.Time `json:"timestamp"`
+	Actor          string    `json:"actor"` // Hashed API key or "ip:<addr>"
+	ClientIP       string    `json:"client_ip"`
+	UserAgent      string    `json:"user_agent,omitempty"`
+	Action         string    `json:"action"` // e.g. "migration.start", "schedule.delete"
+	Method         string    `json:"method"`
+	Path           string    `json:"path"`
+	ResourceID     string    `json:"resource_id,omitempty"`     // Task or schedule ID
+	RequestSummary string    `json:"request_summary,omitempty"` // Request body with secrets redacted
+	StatusCode     int       `json:"status_code"`
+	Outcome        string    `json:"outcome"` // success or failure
+	Error          string    `json:"error,omitempty"`
+	DurationMs     int64     `json:"duration_ms"`
+}
+
+// AuditFilter selects audit entries; zero fields match everything
+type AuditFilter struct {
+	Actor      string
+	Action     string // Exact action, or a prefix ending in "." (e.g. "schedule.")
+	Outcome    string
+	ResourceID string
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+	Offset     int
+}
+
+// RecordAudit stores one audit entry
+func (am *AuditManager) RecordAudit(entry *AuditEntry) error {
+	query := `
+		INSERT INTO audit_log
+		(timestamp, actor, client_ip, user_agent, action, method, path,
+		 resource_id, request_summary, status_code, outcome, error_message, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id
+	`
+
+	err := am.db.QueryRow(query,
+		entry.Timestamp, entry.Actor, entry.ClientIP, entry.UserAgent, entry.Action, entry.Method, entry.Path,
+		entry.ResourceID, entry.RequestSummary, entry.StatusCode, entry.Outcome, entry.Error, entry.DurationMs,
+	).Scan(&entry.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// ListAudit returns matching entries, newest first, and the total number of matches
+func (am *AuditManager) ListAudit(filter AuditFilter) ([]AuditEntry, int64, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(clause string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.Actor != "" {
+		addCondition("actor = $%d", filter.Actor)
+	}
+	if filter.Action != "" {
+		if strings.HasSuffix(filter.Action, ".") {
+			addCondition("action LIKE $%d", filter.Action+"%")
+		} else {
+			addCondition("action = $%d", filter.Action)
+		}
+	}
+	if filter.Outcome != "" {
+		addCondition("outcome = $%d", filter.Outcome)
+	}
+	if filter.ResourceID != "" {
+		addCondition("resource_id = $%d", filter.ResourceID)
+	}
+	if !filter.Since.IsZero() {
+		addCondition("timestamp >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		addCondition("timestamp < $%d", filter.Until)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := am.db.QueryRow("SELECT COUNT(*) FROM audit_log "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	args = append(args, limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT id, timestamp, actor, client_ip, user_agent, action, method, path,
+		       resource_id, request_summary, status_code, outcome, error_message, duration_ms
+		FROM audit_log
+		%s
+		ORDER BY timestamp DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+
+	rows, err := am.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]AuditEntry, 0)
+	for rows.Next() {
+		var entry AuditEntry
+		var userAgent, resourceID, summary, errorMessage sql.NullString
+		if err := rows.Scan(
+			&entry.ID, &entry.Timestamp, &entry.Actor, &entry.ClientIP, &userAgent, &entry.Action, &entry.Method, &entry.Path,
+			&resourceID, &summary, &entry.StatusCode, &entry.Outcome, &errorMessage, &entry.DurationMs,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entry.UserAgent = userAgent.String
+		entry.ResourceID = resourceID.String
+		entry.RequestSummary = summary.String
+		entry.Error = errorMessage.String
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
@@ -16,13 +16,15 @@ CREATE TABLE IF NOT EXISTS migration_tasks (
     current_speed FLOAT NOT NULL DEFAULT 0,
     eta VARCHAR(255),
     duration VARCHAR(255),
-    errors TEXT,
+    errors JSONB,                 -- First errors as a JSON array (capped)
+    errors_gz BYTEA,              -- Full error list, gzip-compressed JSON, when over the inline cap
     start_time TIMESTAMP NOT NULL,
     end_time TIMESTAMP,
     migration_type VARCHAR(50),
     dry_run BOOLEAN DEFAULT FALSE,
     sync_mode BOOLEAN DEFAULT FALSE,
-    original_request TEXT,
+    original_request JSONB,
+    notes JSONB,                  -- Operator notes as a JSON array of {time, author, text}
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     
@@ -38,6 +40,8 @@ CREATE TABLE IF NOT EXISTS migration_tasks (
 CREATE INDEX IF NOT EXISTS idx_tasks_status ON migration_tasks(status);
 CREATE INDEX IF NOT EXISTS idx_tasks_created_at ON migration_tasks(created_at);
 CREATE INDEX IF NOT EXISTS idx_tasks_updated_at ON migration_tasks(updated_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_active ON migration_tasks(created_at) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_tasks_finished_created_at ON migration_tasks(created_at) WHERE status IN ('completed', 'failed', 'cancelled');
 
 -- ============================================================================
 -- INTEGRITY VERIFICATION TABLE
@@ -100,6 +104,129 @@ SELECT
 FROM integrity_results
 GROUP BY task_id;
 
+-- ============================================================================
+-- AUDIT LOG TABLE
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    timestamp TIMESTAMP NOT NULL,
+    actor VARCHAR(255) NOT NULL,          -- Hashed API key or client IP
+    client_ip VARCHAR(64) NOT NULL,
+    user_agent VARCHAR(512),
+    action VARCHAR(100) NOT NULL,         -- e.g. migration.start, schedule.delete
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(1024) NOT NULL,
+    resource_id VARCHAR(255),             -- Task or schedule ID
+    request_summary TEXT,                 -- Request body with secrets redacted
+    status_code INT NOT NULL,
+    outcome VARCHAR(20) NOT NULL,         -- success or failure
+    error_message TEXT,
+    duration_ms BIGINT NOT NULL DEFAULT 0
+);
+
+-- Indexes for audit_log
+CREATE INDEX IF NOT EXISTS idx_audit_timestamp ON audit_log(timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_actor ON audit_log(actor);
+CREATE INDEX IF NOT EXISTS idx_audit_action ON audit_log(action);
+CREATE INDEX IF NOT EXISTS idx_audit_resource_id ON audit_log(resource_id);
+
+-- ============================================================================
+-- LISTING CACHE TABLE
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS listing_cache (
+    scope TEXT PRIMARY KEY,               -- endpoint|bucket|prefix
+    bucket VARCHAR(255) NOT NULL,
+    prefix TEXT NOT NULL DEFAULT '',
+    generation BIGINT NOT NULL DEFAULT 0, -- Bumped on invalidation
+    object_count BIGINT NOT NULL DEFAULT 0,
+    objects BYTEA,                        -- Gzipped JSON listing; NULL after invalidation
+    cached_at TIMESTAMP
+);
+
+-- ============================================================================
+-- GOOGLE DRIVE TASKS TABLE
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS google_drive_tasks (
+    task_id VARCHAR(255) PRIMARY KEY,
+    status VARCHAR(50) NOT NULL,          -- running until the migration ends; running tasks resume on startup
+    folder_id VARCHAR(255) NOT NULL DEFAULT '',
+    dest_bucket VARCHAR(255) NOT NULL,
+    manifest_key TEXT NOT NULL DEFAULT '', -- Discovery manifest in dest_bucket
+    request TEXT NOT NULL DEFAULT '',     -- Request JSON with tokens encrypted; cleared when the task ends
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_google_drive_tasks_status ON google_drive_tasks(status);
+
+-- ============================================================================
+-- TASK LISTINGS TABLES
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS task_listings (
+    task_id VARCHAR(255) PRIMARY KEY,
+    endpoint TEXT NOT NULL DEFAULT '',    -- Destination endpoint; empty for AWS
+    bucket VARCHAR(255) NOT NULL,
+    prefix TEXT NOT NULL DEFAULT '',
+    object_count BIGINT NOT NULL DEFAULT 0,
+    total_bytes BIGINT NOT NULL DEFAULT 0,
+    parts INT NOT NULL DEFAULT 0,
+    listed_at TIMESTAMP NOT NULL          -- When the task finished listing its destination
+);
+
+CREATE TABLE IF NOT EXISTS task_listing_parts (
+    task_id VARCHAR(255) NOT NULL,
+    part INT NOT NULL,
+    objects BYTEA NOT NULL,               -- Gzipped JSON of up to 100000 objects, in key order
+    PRIMARY KEY (task_id, part)
+);
+
+-- ============================================================================
+-- LISTING CHECKPOINTS TABLES
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS listing_checkpoints (
+    task_id VARCHAR(255) PRIMARY KEY,
+    endpoint TEXT NOT NULL DEFAULT '',    -- Source endpoint; empty for AWS
+    bucket VARCHAR(255) NOT NULL,
+    prefix TEXT NOT NULL DEFAULT '',
+    marker TEXT NOT NULL DEFAULT '',      -- Where the interrupted listing continues
+    parts INT NOT NULL DEFAULT 0,
+    object_count BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS listing_checkpoint_parts (
+    task_id VARCHAR(255) NOT NULL,
+    part INT NOT NULL,
+    objects BYTEA NOT NULL,               -- Gzipped JSON of the objects listed before the marker
+    PRIMARY KEY (task_id, part)
+);
+
+-- ============================================================================
+-- OBJECT SYNC STATES TABLE
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS object_sync_states (
+    scope TEXT PRIMARY KEY,               -- Source endpoint|bucket|prefix|destination endpoint|bucket|prefix
+    object_count BIGINT NOT NULL DEFAULT 0,
+    states BYTEA NOT NULL,                -- Gzipped JSON of the source ETag, last modified and copy ETag by source key
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- ============================================================================
+-- TASK LOCKS TABLE
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS task_locks (
+    lock_key VARCHAR(64) PRIMARY KEY,     -- SHA-256 of the source endpoint|bucket|prefix|destination endpoint|bucket|prefix
+    task_id VARCHAR(255) NOT NULL,        -- Task copying that route
+    acquired_at TIMESTAMP NOT NULL
+);
+
 -- ============================================================================
 -- VERIFICATION
 -- ============================================================================
@@ -111,12 +238,40 @@ SELECT
 UNION ALL
 SELECT 
     'integrity_results' as table_name,
-    EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'integrity_results') as exists;
+    EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'integrity_results') as exists
+UNION ALL
+SELECT 
+    'audit_log' as table_name,
+    EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'audit_log') as exists
+UNION ALL
+SELECT 
+    'listing_cache' as table_name,
+    EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'listing_cache') as exists
+UNION ALL
+SELECT 
+    'google_drive_tasks' as table_name,
+    EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'google_drive_tasks') as exists
+UNION ALL
+SELECT 
+    'task_listings' as table_name,
+    EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'task_listings') as exists
+UNION ALL
+SELECT 
+    'listing_checkpoints' as table_name,
+    EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'listing_checkpoints') as exists
+UNION ALL
+SELECT 
+    'object_sync_states' as table_name,
+    EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'object_sync_states') as exists
+UNION ALL
+SELECT 
+    'task_locks' as table_name,
+    EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'task_locks') as exists;
 
 -- Check that all indexes were created
 SELECT schemaname, tablename, indexname 
 FROM pg_indexes 
-WHERE tablename IN ('migration_tasks', 'integrity_results')
+WHERE tablename IN ('migration_tasks', 'integrity_results', 'audit_log', 'listing_cache', 'google_drive_tasks')
 ORDER BY tablename, indexname;
 
 -- Check that view was created
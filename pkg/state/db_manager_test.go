@@ -0,0 +1,22 @@
+package state
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaskUpsertQuery(t *testing.T) {
+	query := taskUpsertQuery(2)
+	for _, want := range []string{"($1, $2,", "$20), ($21, $22,", "$40) ON CONFLICT (id) DO UPDATE"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query does not contain %q:\n%s", want, query)
+		}
+	}
+	if strings.Contains(query, "$41") {
+		t.Errorf("query has placeholders beyond two rows:\n%s", query)
+	}
+	if got := len(taskRow(&TaskState{ID: "t1"}, time.Now())); got != taskUpsertParams {
+		t.Errorf("taskRow has %d values, want %d", got, taskUpsertParams)
+	}
+}
@@ -0,0 +1,84 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GoogleDriveTaskState is what a Google Drive migration needs to resume after a restart
+type GoogleDriveTaskState struct {
+	TaskID      string    `json:"task_id"`
+	Status      string    `json:"status"` // running until the migration ends
+	FolderID    string    `json:"folder_id"`
+	DestBucket  string    `json:"dest_bucket"`
+	ManifestKey string    `json:"manifest_key"` // Discovery manifest in DestBucket
+	Request     string    `json:"-"`            // Request JSON with tokens and keys encrypted
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// GoogleDriveTaskManager persists resumable Google Drive task state
+type GoogleDriveTaskManager struct {
+	db *sql.DB
+}
+
+// NewGoogleDriveTaskManager creates a new Google Drive task manager
+func NewGoogleDriveTaskManager(db *sql.DB) *GoogleDriveTaskManager {
+	return &GoogleDriveTaskManager{db: db}
+}
+
+// SaveTask inserts or replaces the state of a Google Drive task
+func (gm *GoogleDriveTaskManager) SaveTask(task *GoogleDriveTaskState) error {
+	_, err := gm.db.Exec(`
+		INSERT INTO google_drive_tasks (task_id, status, folder_id, dest_bucket, manifest_key, request, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (task_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			folder_id = EXCLUDED.folder_id,
+			dest_bucket = EXCLUDED.dest_bucket,
+			manifest_key = EXCLUDED.manifest_key,
+			request = EXCLUDED.request,
+			updated_at = EXCLUDED.updated_at
+	`, task.TaskID, task.Status, task.FolderID, task.DestBucket, task.ManifestKey, task.Request, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save Google Drive task: %w", err)
+	}
+	return nil
+}
+
+// UpdateStatus records how a Google Drive task ended. The stored request, with
+// its tokens, is cleared since a finished task is never resumed.
+func (gm *GoogleDriveTaskManager) UpdateStatus(taskID, status string) error {
+	_, err := gm.db.Exec(`
+		UPDATE google_drive_tasks SET status = $2, request = '', updated_at = $3 WHERE task_id = $1
+	`, taskID, status, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update Google Drive task: %w", err)
+	}
+	return nil
+}
+
+// ListRunning returns the Google Drive tasks that were running when the process stopped
+func (gm *GoogleDriveTaskManager) ListRunning() ([]GoogleDriveTaskState, error) {
+	rows, err := gm.db.Query(`
+		SELECT task_id, status, folder_id, dest_bucket, manifest_key, request, created_at, updated_at
+		FROM google_drive_tasks
+		WHERE status = 'running'
+		ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Google Drive tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []GoogleDriveTaskState
+	for rows.Next() {
+		var task GoogleDriveTaskState
+		if err := rows.Scan(&task.TaskID, &task.Status, &task.FolderID, &task.DestBucket, &task.ManifestKey, &task.Request, &task.CreatedAt, &task.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan Google Drive task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
@@ -0,0 +1,165 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of IntegrityJournalConfig
+const (
+	defaultJournalBatchSize     = 1000
+	defaultJournalFlushInterval = time.Second
+	defaultJournalMaxPending    = 20000
+)
+
+// journalRetryDelays are the waits before retrying a batch that failed to store
+var journalRetryDelays = []time.Duration{200 * time.Millisecond, 2 * time.Second}
+
+// IntegrityJournalConfig sizes the write-behind buffer of an IntegrityJournal
+type IntegrityJournalConfig struct {
+	BatchSize     int           // Records stored per batch (default 1000)
+	FlushInterval time.Duration // Longest a record waits before its batch is stored (default 1s)
+	MaxPending    int           // Records buffered before Record blocks (default 20000)
+}
+
+// IntegrityJournal persists the per-object integrity records of a task in
+// batches. Workers hand records to an in-memory buffer and carry on; one
+// writer stores them with a COPY per batch. When the database falls behind
+// and the buffer is full, Record blocks, slowing the workers to the pace
+// the database keeps.
+type IntegrityJournal struct {
+	store   func(records []IntegrityRecord) error
+	config  IntegrityJournalConfig
+	records chan IntegrityRecord
+	done    chan struct{}
+
+	mu     sync.RWMutex // Held for writing by Close, so no Record sends on a closed buffer
+	closed bool
+	err    error // First batch that could not be stored
+
+	written atomic.Int64
+	failed  atomic.Int64
+}
+
+// NewIntegrityJournal starts a journal storing batches with store, which must
+// not keep the slice it is given
+func NewIntegrityJournal(store func(records []IntegrityRecord) error, config IntegrityJournalConfig) *IntegrityJournal {
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultJournalBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultJournalFlushInterval
+	}
+	if config.MaxPending <= 0 {
+		config.MaxPending = defaultJournalMaxPending
+	}
+	config.MaxPending = max(config.MaxPending, config.BatchSize)
+	j := &IntegrityJournal{
+		store:   store,
+		config:  config,
+		records: make(chan IntegrityRecord, config.MaxPending),
+		done:    make(chan struct{}),
+	}
+	go j.run()
+	return j
+}
+
+// NewJournal starts a journal storing into the integrity_results table
+func (im *IntegrityManager) NewJournal(config IntegrityJournalConfig) *IntegrityJournal {
+	return NewIntegrityJournal(im.StoreIntegrityRecords, config)
+}
+
+// Record buffers a record, blocking while the buffer is full; it fails when ctx
+// ends first or the journal is closed
+func (j *IntegrityJournal) Record(ctx context.Context, record IntegrityRecord) error {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if j.closed {
+		return fmt.Errorf("integrity journal is closed")
+	}
+	select {
+	case j.records <- record:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stores the buffered records and stops the journal. It returns the
+// first error of a batch that could not be stored after retries.
+func (j *IntegrityJournal) Close() error {
+	j.mu.Lock()
+	if !j.closed {
+		j.closed = true
+		close(j.records)
+	}
+	j.mu.Unlock()
+	<-j.done
+	return j.err
+}
+
+// Written returns the number of records stored
+func (j *IntegrityJournal) Written() int64 {
+	return j.written.Load()
+}
+
+// Failed returns the number of records dropped because their batch could not be stored
+func (j *IntegrityJournal) Failed() int64 {
+	return j.failed.Load()
+}
+
+// run collects records into batches, storing a batch when it is full or has
+// waited FlushInterval, until the buffer is closed and drained
+func (j *IntegrityJournal) run() {
+	defer close(j.done)
+	ticker := time.NewTicker(j.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]IntegrityRecord, 0, j.config.BatchSize)
+	for {
+		select {
+		case record, ok := <-j.records:
+			if !ok {
+				j.flush(batch)
+				return
+			}
+			batch = append(batch, record)
+			if len(batch) < j.config.BatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		j.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+// flush stores a batch, retrying after journalRetryDelays
+func (j *IntegrityJournal) flush(batch []IntegrityRecord) {
+	if len(batch) == 0 {
+		return
+	}
+	err := j.store(batch)
+	for _, delay := range journalRetryDelays {
+		if err == nil {
+			break
+		}
+		time.Sleep(delay)
+		err = j.store(batch)
+	}
+	if err != nil {
+		j.failed.Add(int64(len(batch)))
+		if j.err == nil {
+			j.err = err
+		}
+		fmt.Printf("Warning: dropped %d integrity records: %v\n", len(batch), err)
+		return
+	}
+	j.written.Add(int64(len(batch)))
+}
@@ -0,0 +1,138 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchRecorder stores the sizes of the batches it is given
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches []int
+	keys    map[string]bool
+}
+
+func (r *batchRecorder) store(records []IntegrityRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, len(records))
+	for _, record := range records {
+		r.keys[record.ObjectKey] = true
+	}
+	return nil
+}
+
+func TestIntegrityJournalBatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		records int
+		config  IntegrityJournalConfig
+		want    []int
+	}{
+		{name: "full batches and the rest on close", records: 250, config: IntegrityJournalConfig{BatchSize: 100, FlushInterval: time.Hour}, want: []int{100, 100, 50}},
+		{name: "one partial batch", records: 3, config: IntegrityJournalConfig{BatchSize: 100, FlushInterval: time.Hour}, want: []int{3}},
+		{name: "nothing recorded", records: 0, config: IntegrityJournalConfig{}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &batchRecorder{keys: map[string]bool{}}
+			journal := NewIntegrityJournal(recorder.store, tt.config)
+			for i := 0; i < tt.records; i++ {
+				if err := journal.Record(context.Background(), IntegrityRecord{ObjectKey: fmt.Sprintf("key-%d", i)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := journal.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(recorder.batches) != fmt.Sprint(tt.want) || len(recorder.keys) != tt.records || journal.Written() != int64(tt.records) {
+				t.Errorf("batches %v with %d keys, %d written; want %v", recorder.batches, len(recorder.keys), journal.Written(), tt.want)
+			}
+			if err := journal.Record(context.Background(), IntegrityRecord{}); err == nil {
+				t.Error("record accepted after Close")
+			}
+		})
+	}
+}
+
+func TestIntegrityJournalFlushesOnInterval(t *testing.T) {
+	recorder := &batchRecorder{keys: map[string]bool{}}
+	journal := NewIntegrityJournal(recorder.store, IntegrityJournalConfig{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	defer journal.Close()
+	journal.Record(context.Background(), IntegrityRecord{ObjectKey: "a"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for journal.Written() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("record was not stored before Close")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestIntegrityJournalBackpressure(t *testing.T) {
+	release := make(chan struct{})
+	store := func(records []IntegrityRecord) error {
+		<-release
+		return nil
+	}
+	journal := NewIntegrityJournal(store, IntegrityJournalConfig{BatchSize: 2, FlushInterval: time.Hour, MaxPending: 4})
+
+	// The writer holds one batch in a store that does not return; the buffer
+	// takes MaxPending more
+	for i := 0; i < 6; i++ {
+		if err := journal.Record(context.Background(), IntegrityRecord{}); err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := journal.Record(ctx, IntegrityRecord{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("record into a full buffer = %v, want it to block until the deadline", err)
+	}
+
+	close(release)
+	if err := journal.Close(); err != nil || journal.Written() != 6 {
+		t.Errorf("Close() = %v with %d written, want 6", err, journal.Written())
+	}
+}
+
+func TestIntegrityJournalRetries(t *testing.T) {
+	previous := journalRetryDelays
+	journalRetryDelays = []time.Duration{time.Millisecond, time.Millisecond}
+	t.Cleanup(func() { journalRetryDelays = previous })
+
+	tests := []struct {
+		name        string
+		failures    int // Failed attempts before a store succeeds
+		wantWritten int64
+		wantErr     bool
+	}{
+		{name: "recovers", failures: 2, wantWritten: 3},
+		{name: "gives up", failures: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			store := func(records []IntegrityRecord) error {
+				attempts++
+				if attempts <= tt.failures {
+					return errors.New("connection reset")
+				}
+				return nil
+			}
+			journal := NewIntegrityJournal(store, IntegrityJournalConfig{BatchSize: 10, FlushInterval: time.Hour})
+			for i := 0; i < 3; i++ {
+				journal.Record(context.Background(), IntegrityRecord{})
+			}
+			err := journal.Close()
+			if (err != nil) != tt.wantErr || journal.Written() != tt.wantWritten || journal.Failed() != 3-tt.wantWritten {
+				t.Errorf("Close() = %v, %d written, %d failed", err, journal.Written(), journal.Failed())
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
+
 	"s3migration/pkg/integrity"
 )
 
@@ -20,26 +22,26 @@ func NewIntegrityManager(db *sql.DB) *IntegrityManager {
 
 // IntegrityRecord represents a database record for integrity verification
 type IntegrityRecord struct {
-	ID              int64
-	TaskID          string
-	ObjectKey       string
-	SourceETag      string
-	SourceSize      int64
-	SourceProvider  string
-	DestETag        string
-	DestSize        int64
-	DestProvider    string
-	CalculatedMD5   string
-	CalculatedSHA1  string
+	ID               int64
+	TaskID           string
+	ObjectKey        string
+	SourceETag       string
+	SourceSize       int64
+	SourceProvider   string
+	DestETag         string
+	DestSize         int64
+	DestProvider     string
+	CalculatedMD5    string
+	CalculatedSHA1   string
 	CalculatedSHA256 string
-	CalculatedCRC32 string
-	ETagMatch       bool
-	SizeMatch       bool
-	MD5Match        bool
-	SHA1Match       bool
-	IsValid         bool
-	ErrorMessage    string
-	CreatedAt       time.Time
+	CalculatedCRC32  string
+	ETagMatch        bool
+	SizeMatch        bool
+	MD5Match         bool
+	SHA1Match        bool
+	IsValid          bool
+	ErrorMessage     string
+	CreatedAt        time.Time
 }
 
 // IntegritySummary represents aggregated integrity metrics
@@ -87,6 +89,83 @@ func (im *IntegrityManager) StoreIntegrityResult(
 	return nil
 }
 
+// NewIntegrityRecord builds the journal record of one verified object
+func NewIntegrityRecord(
+	taskID, objectKey string,
+	result *integrity.IntegrityResult,
+	sourceProvider, destProvider string,
+) IntegrityRecord {
+	return IntegrityRecord{
+		TaskID:           taskID,
+		ObjectKey:        objectKey,
+		SourceETag:       result.SourceETag,
+		SourceSize:       result.SourceSize,
+		SourceProvider:   sourceProvider,
+		DestETag:         result.DestETag,
+		DestSize:         result.DestSize,
+		DestProvider:     destProvider,
+		CalculatedMD5:    result.CalculatedMD5,
+		CalculatedSHA1:   result.CalculatedSHA1,
+		CalculatedSHA256: result.CalculatedSHA256,
+		CalculatedCRC32:  result.CalculatedCRC32,
+		ETagMatch:        result.ETagMatch,
+		SizeMatch:        result.SizeMatch,
+		MD5Match:         result.MD5Match,
+		SHA1Match:        result.SHA1Match,
+		IsValid:          result.IsValid,
+		ErrorMessage:     result.ErrorMessage,
+		CreatedAt:        time.Now(),
+	}
+}
+
+// StoreIntegrityRecords stores a batch of records with one COPY in a
+// transaction, so that a batch is stored whole or not at all
+func (im *IntegrityManager) StoreIntegrityRecords(records []IntegrityRecord) error {
+	tx, err := im.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin integrity batch: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(pq.CopyIn("integrity_results",
+		"task_id", "object_key",
+		"source_etag", "source_size", "source_provider",
+		"dest_etag", "dest_size", "dest_provider",
+		"calculated_md5", "calculated_sha1", "calculated_sha256", "calculated_crc32",
+		"etag_match", "size_match", "md5_match", "sha1_match",
+		"is_valid", "error_message", "created_at",
+	))
+	if err != nil {
+		return fmt.Errorf("failed to prepare integrity batch: %w", err)
+	}
+	for _, record := range records {
+		_, err := stmt.Exec(
+			record.TaskID, record.ObjectKey,
+			record.SourceETag, record.SourceSize, record.SourceProvider,
+			record.DestETag, record.DestSize, record.DestProvider,
+			record.CalculatedMD5, record.CalculatedSHA1, record.CalculatedSHA256, record.CalculatedCRC32,
+			record.ETagMatch, record.SizeMatch, record.MD5Match, record.SHA1Match,
+			record.IsValid, record.ErrorMessage, record.CreatedAt,
+		)
+		if err != nil {
+			stmt.Close()
+			return fmt.Errorf("failed to copy integrity record for %s: %w", record.ObjectKey, err)
+		}
+	}
+	// The final Exec without values sends the buffered rows
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return fmt.Errorf("failed to store %d integrity records: %w", len(records), err)
+	}
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("failed to store %d integrity records: %w", len(records), err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit integrity batch: %w", err)
+	}
+	return nil
+}
+
 // GetIntegritySummary retrieves integrity summary for a task
 func (im *IntegrityManager) GetIntegritySummary(taskID string) (*IntegritySummary, error) {
 	query := `
@@ -228,10 +307,10 @@ func (im *IntegrityManager) GetIntegrityReport(taskID string) (map[string]interf
 	}
 
 	report := map[string]interface{}{
-		"summary":         summary,
-		"failed_objects":  failedObjects,
-		"failed_count":    len(failedObjects),
-		"has_failures":    summary.FailedObjects > 0,
+		"summary":          summary,
+		"failed_objects":   failedObjects,
+		"failed_count":     len(failedObjects),
+		"has_failures":     summary.FailedObjects > 0,
 		"integrity_passed": summary.IntegrityRate >= 99.9,
 	}
 
@@ -248,3 +327,91 @@ func (im *IntegrityManager) DeleteIntegrityResults(taskID string) error {
 	return nil
 }
 
+// ListIntegrityRecords retrieves every integrity record of a task, oldest first
+func (im *IntegrityManager) ListIntegrityRecords(taskID string) ([]IntegrityRecord, error) {
+	query := `
+		SELECT 
+			id, task_id, object_key,
+			source_etag, source_size, source_provider,
+			dest_etag, dest_size, dest_provider,
+			calculated_md5, calculated_sha1, calculated_sha256, calculated_crc32,
+			etag_match, size_match, md5_match, sha1_match,
+			is_valid, error_message, created_at
+		FROM integrity_results
+		WHERE task_id = $1
+		ORDER BY id
+	`
+
+	rows, err := im.db.Query(query, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list integrity records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []IntegrityRecord
+	for rows.Next() {
+		var record IntegrityRecord
+		err := rows.Scan(
+			&record.ID, &record.TaskID, &record.ObjectKey,
+			&record.SourceETag, &record.SourceSize, &record.SourceProvider,
+			&record.DestETag, &record.DestSize, &record.DestProvider,
+			&record.CalculatedMD5, &record.CalculatedSHA1, &record.CalculatedSHA256, &record.CalculatedCRC32,
+			&record.ETagMatch, &record.SizeMatch, &record.MD5Match, &record.SHA1Match,
+			&record.IsValid, &record.ErrorMessage, &record.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan integrity record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// ImportIntegrityRecords stores records exported from another deployment under taskID,
+// keeping their original timestamps
+func (im *IntegrityManager) ImportIntegrityRecords(taskID string, records []IntegrityRecord) error {
+	tx, err := im.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin integrity import: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO integrity_results 
+		(task_id, object_key, 
+		 source_etag, source_size, source_provider,
+		 dest_etag, dest_size, dest_provider,
+		 calculated_md5, calculated_sha1, calculated_sha256, calculated_crc32,
+		 etag_match, size_match, md5_match, sha1_match,
+		 is_valid, error_message, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare integrity import: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, record := range records {
+		createdAt := record.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		_, err := stmt.Exec(
+			taskID, record.ObjectKey,
+			record.SourceETag, record.SourceSize, record.SourceProvider,
+			record.DestETag, record.DestSize, record.DestProvider,
+			record.CalculatedMD5, record.CalculatedSHA1, record.CalculatedSHA256, record.CalculatedCRC32,
+			record.ETagMatch, record.SizeMatch, record.MD5Match, record.SHA1Match,
+			record.IsValid, record.ErrorMessage, createdAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to import integrity record for %s: %w", record.ObjectKey, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit integrity import: %w", err)
+	}
+	return nil
+}
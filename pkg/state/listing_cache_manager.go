@@ -0,0 +1,196 @@
+package state
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"s3migration/pkg/prefetch"
+)
+
+// defaultListingCacheMaxObjects bounds the size of one stored listing
+const defaultListingCacheMaxObjects = 2000000
+
+// ListingCacheManager persists bucket/prefix listings for prefetch.MetadataCache
+type ListingCacheManager struct {
+	db         *sql.DB
+	maxObjects int
+}
+
+// NewListingCacheManager creates a new listing cache manager
+func NewListingCacheManager(db *sql.DB) *ListingCacheManager {
+	maxObjects := defaultListingCacheMaxObjects
+	if v, err := strconv.Atoi(os.Getenv("LISTING_CACHE_MAX_OBJECTS")); err == nil && v > 0 {
+		maxObjects = v
+	}
+	return &ListingCacheManager{db: db, maxObjects: maxObjects}
+}
+
+// ListingCacheEntry describes one stored listing without its objects
+type ListingCacheEntry struct {
+	Scope       string     `json:"scope"`
+	Bucket      string     `json:"bucket"`
+	Prefix      string     `json:"prefix"`
+	Generation  int64      `json:"generation"`
+	ObjectCount int64      `json:"object_count"`
+	CachedAt    *time.Time `json:"cached_at,omitempty"` // Nil once invalidated
+}
+
+// LoadListing returns the stored listing for scope, or nil if there is none
+func (lm *ListingCacheManager) LoadListing(scope string) (*prefetch.Listing, error) {
+	var (
+		listing  prefetch.Listing
+		objects  []byte
+		cachedAt sql.NullTime
+	)
+	err := lm.db.QueryRow(`
+		SELECT scope, bucket, prefix, generation, objects, cached_at
+		FROM listing_cache WHERE scope = $1
+	`, scope).Scan(&listing.Scope, &listing.Bucket, &listing.Prefix, &listing.Generation, &objects, &cachedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load listing: %w", err)
+	}
+	if objects == nil || !cachedAt.Valid {
+		return nil, nil // Invalidated
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(objects))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress listing: %w", err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress listing: %w", err)
+	}
+	if err := json.Unmarshal(data, &listing.Objects); err != nil {
+		return nil, fmt.Errorf("failed to decode listing: %w", err)
+	}
+	listing.CachedAt = cachedAt.Time
+	return &listing, nil
+}
+
+// SaveListing stores a listing. The write is dropped when the scope was
+// invalidated after the listing was taken, so a stale listing never wins.
+func (lm *ListingCacheManager) SaveListing(listing *prefetch.Listing) error {
+	if len(listing.Objects) > lm.maxObjects {
+		return fmt.Errorf("listing of %d objects exceeds LISTING_CACHE_MAX_OBJECTS (%d)", len(listing.Objects), lm.maxObjects)
+	}
+
+	data, err := json.Marshal(listing.Objects)
+	if err != nil {
+		return fmt.Errorf("failed to encode listing: %w", err)
+	}
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return fmt.Errorf("failed to compress listing: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to compress listing: %w", err)
+	}
+
+	_, err = lm.db.Exec(`
+		INSERT INTO listing_cache (scope, bucket, prefix, generation, object_count, objects, cached_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (scope) DO UPDATE SET
+			object_count = EXCLUDED.object_count,
+			objects = EXCLUDED.objects,
+			cached_at = EXCLUDED.cached_at
+		WHERE listing_cache.generation = EXCLUDED.generation
+	`, listing.Scope, listing.Bucket, listing.Prefix, listing.Generation, len(listing.Objects), buf.Bytes(), listing.CachedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save listing: %w", err)
+	}
+	return nil
+}
+
+// InvalidateListing drops the stored objects of scope and bumps its generation.
+// A row is created for a scope never cached, so a listing already in progress is
+// still rejected when it is saved.
+func (lm *ListingCacheManager) InvalidateListing(scope string) (int64, error) {
+	bucket, prefix := "", ""
+	if parts := strings.SplitN(scope, "|", 3); len(parts) == 3 {
+		bucket, prefix = parts[1], parts[2]
+	}
+
+	var generation int64
+	err := lm.db.QueryRow(`
+		INSERT INTO listing_cache (scope, bucket, prefix, generation)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (scope) DO UPDATE SET
+			generation = listing_cache.generation + 1,
+			objects = NULL,
+			object_count = 0,
+			cached_at = NULL
+		RETURNING generation
+	`, scope, bucket, prefix).Scan(&generation)
+	if err != nil {
+		return 0, fmt.Errorf("failed to invalidate listing: %w", err)
+	}
+	return generation, nil
+}
+
+// ListingGeneration returns the current generation of scope (0 if never stored)
+func (lm *ListingCacheManager) ListingGeneration(scope string) (int64, error) {
+	var generation int64
+	err := lm.db.QueryRow(`SELECT generation FROM listing_cache WHERE scope = $1`, scope).Scan(&generation)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read listing generation: %w", err)
+	}
+	return generation, nil
+}
+
+// ListListings returns stored listings for a bucket ("" for all), newest first
+func (lm *ListingCacheManager) ListListings(bucket string) ([]ListingCacheEntry, error) {
+	rows, err := lm.db.Query(`
+		SELECT scope, bucket, prefix, generation, object_count, cached_at
+		FROM listing_cache
+		WHERE $1 = '' OR bucket = $1
+		ORDER BY cached_at DESC NULLS LAST
+	`, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cached listings: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []ListingCacheEntry
+	for rows.Next() {
+		var entry ListingCacheEntry
+		var cachedAt sql.NullTime
+		if err := rows.Scan(&entry.Scope, &entry.Bucket, &entry.Prefix, &entry.Generation, &entry.ObjectCount, &cachedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan cached listing: %w", err)
+		}
+		if cachedAt.Valid {
+			entry.CachedAt = &cachedAt.Time
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// InvalidateBucket invalidates every stored listing of a bucket, whatever the prefix
+func (lm *ListingCacheManager) InvalidateBucket(bucket string) (int64, error) {
+	result, err := lm.db.Exec(`
+		UPDATE listing_cache
+		SET generation = generation + 1, objects = NULL, object_count = 0, cached_at = NULL
+		WHERE bucket = $1
+	`, bucket)
+	if err != nil {
+		return 0, fmt.Errorf("failed to invalidate listings: %w", err)
+	}
+	return result.RowsAffected()
+}
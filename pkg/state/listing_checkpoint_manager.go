@@ -0,0 +1,172 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"s3migration/pkg/prefetch"
+)
+
+// ListingCheckpointManager stores the listing checkpoints of tasks in the
+// listing_checkpoints and listing_checkpoint_parts tables
+type ListingCheckpointManager struct {
+	db *sql.DB
+}
+
+// NewListingCheckpointManager creates a new listing checkpoint manager
+func NewListingCheckpointManager(db *sql.DB) *ListingCheckpointManager {
+	return &ListingCheckpointManager{db: db}
+}
+
+// SaveListingCheckpointPart stores one gzip-compressed part; part 0 drops the task's earlier checkpoint
+func (cm *ListingCheckpointManager) SaveListingCheckpointPart(taskID string, part int, objects []prefetch.ObjectMetadata) error {
+	if part == 0 {
+		if err := cm.DeleteListingCheckpoint(taskID); err != nil {
+			return err
+		}
+	}
+	data, err := encodeListingObjects(objects)
+	if err != nil {
+		return err
+	}
+	_, err = cm.db.Exec(`
+		INSERT INTO listing_checkpoint_parts (task_id, part, objects) VALUES ($1, $2, $3)
+		ON CONFLICT (task_id, part) DO UPDATE SET objects = EXCLUDED.objects
+	`, taskID, part, data)
+	if err != nil {
+		return fmt.Errorf("failed to save listing checkpoint part: %w", err)
+	}
+	return nil
+}
+
+// SaveListingCheckpoint records the marker a listing reached
+func (cm *ListingCheckpointManager) SaveListingCheckpoint(checkpoint prefetch.ListingCheckpoint) error {
+	_, err := cm.db.Exec(`
+		INSERT INTO listing_checkpoints (task_id, endpoint, bucket, prefix, marker, parts, object_count, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (task_id) DO UPDATE SET
+			endpoint = EXCLUDED.endpoint,
+			bucket = EXCLUDED.bucket,
+			prefix = EXCLUDED.prefix,
+			marker = EXCLUDED.marker,
+			parts = EXCLUDED.parts,
+			object_count = EXCLUDED.object_count,
+			updated_at = EXCLUDED.updated_at
+	`, checkpoint.TaskID, checkpoint.Endpoint, checkpoint.Bucket, checkpoint.Prefix, checkpoint.Marker, checkpoint.Parts, checkpoint.Objects, checkpoint.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save listing checkpoint: %w", err)
+	}
+	return nil
+}
+
+// LoadListingCheckpoint returns the checkpoint of a task, or nil if there is none
+func (cm *ListingCheckpointManager) LoadListingCheckpoint(taskID string) (*prefetch.ListingCheckpoint, error) {
+	checkpoint := prefetch.ListingCheckpoint{TaskID: taskID}
+	err := cm.db.QueryRow(`
+		SELECT endpoint, bucket, prefix, marker, parts, object_count, updated_at
+		FROM listing_checkpoints WHERE task_id = $1
+	`, taskID).Scan(&checkpoint.Endpoint, &checkpoint.Bucket, &checkpoint.Prefix, &checkpoint.Marker, &checkpoint.Parts, &checkpoint.Objects, &checkpoint.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load listing checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+// LoadListingCheckpointPart returns one part of a task's checkpoint
+func (cm *ListingCheckpointManager) LoadListingCheckpointPart(taskID string, part int) ([]prefetch.ObjectMetadata, error) {
+	var data []byte
+	err := cm.db.QueryRow(`SELECT objects FROM listing_checkpoint_parts WHERE task_id = $1 AND part = $2`, taskID, part).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("part %d of the listing checkpoint of task %s is missing", part, taskID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load listing checkpoint part: %w", err)
+	}
+	return decodeListingObjects(data)
+}
+
+// DeleteListingCheckpoint drops the checkpoint of a task and its parts
+func (cm *ListingCheckpointManager) DeleteListingCheckpoint(taskID string) error {
+	if _, err := cm.db.Exec(`DELETE FROM listing_checkpoints WHERE task_id = $1`, taskID); err != nil {
+		return fmt.Errorf("failed to delete listing checkpoint: %w", err)
+	}
+	if _, err := cm.db.Exec(`DELETE FROM listing_checkpoint_parts WHERE task_id = $1`, taskID); err != nil {
+		return fmt.Errorf("failed to delete listing checkpoint: %w", err)
+	}
+	return nil
+}
+
+// MemoryListingCheckpointStore keeps listing checkpoints in memory, compressed
+// as in the database. It is meant for tests and local runs without a database.
+type MemoryListingCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string]prefetch.ListingCheckpoint
+	parts       map[string][][]byte
+}
+
+// NewMemoryListingCheckpointStore creates an empty in-memory listing checkpoint store
+func NewMemoryListingCheckpointStore() *MemoryListingCheckpointStore {
+	return &MemoryListingCheckpointStore{checkpoints: map[string]prefetch.ListingCheckpoint{}, parts: map[string][][]byte{}}
+}
+
+// SaveListingCheckpointPart implements prefetch.ListingCheckpointStore
+func (s *MemoryListingCheckpointStore) SaveListingCheckpointPart(taskID string, part int, objects []prefetch.ObjectMetadata) error {
+	data, err := encodeListingObjects(objects)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if part == 0 {
+		delete(s.checkpoints, taskID)
+		s.parts[taskID] = nil
+	}
+	for len(s.parts[taskID]) <= part {
+		s.parts[taskID] = append(s.parts[taskID], nil)
+	}
+	s.parts[taskID][part] = data
+	return nil
+}
+
+// SaveListingCheckpoint implements prefetch.ListingCheckpointStore
+func (s *MemoryListingCheckpointStore) SaveListingCheckpoint(checkpoint prefetch.ListingCheckpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoints[checkpoint.TaskID] = checkpoint
+	return nil
+}
+
+// LoadListingCheckpoint implements prefetch.ListingCheckpointStore
+func (s *MemoryListingCheckpointStore) LoadListingCheckpoint(taskID string) (*prefetch.ListingCheckpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	checkpoint, ok := s.checkpoints[taskID]
+	if !ok {
+		return nil, nil
+	}
+	return &checkpoint, nil
+}
+
+// LoadListingCheckpointPart implements prefetch.ListingCheckpointStore
+func (s *MemoryListingCheckpointStore) LoadListingCheckpointPart(taskID string, part int) ([]prefetch.ObjectMetadata, error) {
+	s.mu.Lock()
+	parts := s.parts[taskID]
+	s.mu.Unlock()
+	if part < 0 || part >= len(parts) || parts[part] == nil {
+		return nil, fmt.Errorf("part %d of the listing checkpoint of task %s is missing", part, taskID)
+	}
+	return decodeListingObjects(parts[part])
+}
+
+// DeleteListingCheckpoint implements prefetch.ListingCheckpointStore
+func (s *MemoryListingCheckpointStore) DeleteListingCheckpoint(taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.checkpoints, taskID)
+	delete(s.parts, taskID)
+	return nil
+}
@@ -0,0 +1,50 @@
+package state
+
+import (
+	"testing"
+
+	"s3migration/pkg/prefetch"
+)
+
+func TestMemoryListingCheckpointStore(t *testing.T) {
+	store := NewMemoryListingCheckpointStore()
+	if checkpoint, err := store.LoadListingCheckpoint("task-1"); checkpoint != nil || err != nil {
+		t.Fatalf("checkpoint of a new task = %+v, %v", checkpoint, err)
+	}
+
+	parts := [][]prefetch.ObjectMetadata{{{Key: "a", Size: 1}, {Key: "b", Size: 2}}, {{Key: "c", Size: 3}}}
+	for i, part := range parts {
+		if err := store.SaveListingCheckpointPart("task-1", i, part); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.SaveListingCheckpoint(prefetch.ListingCheckpoint{TaskID: "task-1", Bucket: "src", Marker: "c", Parts: 2, Objects: 3}); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint, err := store.LoadListingCheckpoint("task-1")
+	if err != nil || checkpoint == nil || checkpoint.Marker != "c" || checkpoint.Parts != 2 {
+		t.Fatalf("checkpoint = %+v, %v", checkpoint, err)
+	}
+	second, err := store.LoadListingCheckpointPart("task-1", 1)
+	if err != nil || len(second) != 1 || second[0].Key != "c" {
+		t.Fatalf("part 1 = %+v, %v", second, err)
+	}
+
+	// A listing started over drops the old checkpoint from its first part
+	if err := store.SaveListingCheckpointPart("task-1", 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint, _ := store.LoadListingCheckpoint("task-1"); checkpoint != nil {
+		t.Errorf("old checkpoint still readable: %+v", checkpoint)
+	}
+	if _, err := store.LoadListingCheckpointPart("task-1", 1); err == nil {
+		t.Error("part of the old checkpoint still readable")
+	}
+
+	if err := store.DeleteListingCheckpoint("task-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.LoadListingCheckpointPart("task-1", 0); err == nil {
+		t.Error("part of a deleted checkpoint still readable")
+	}
+}
@@ -2,6 +2,8 @@ package state
 
 import (
 	"time"
+
+	"s3migration/pkg/models"
 )
 
 // TaskState represents the persisted state of a migration task
@@ -23,11 +25,13 @@ type TaskState struct {
 	DryRun          bool                   `json:"dry_run"`
 	SyncMode        bool                   `json:"sync_mode"`
 	OriginalRequest map[string]interface{} `json:"original_request"`
+	Notes           []models.TaskNote      `json:"notes,omitempty"`
 }
 
 // StateManager interface for state persistence
 type StateManager interface {
 	SaveTask(task *TaskState) error
+	SaveTasks(tasks []*TaskState) error // Saves several tasks in as few writes as possible
 	LoadTask(taskID string) (*TaskState, error)
 	ListTasks() ([]*TaskState, error)
 	DeleteTask(taskID string) error
@@ -0,0 +1,113 @@
+package state
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"s3migration/pkg/models"
+)
+
+// MemoryStateManager keeps task state in memory. It behaves like DBStateManager
+// for a single process and is meant for tests and local runs without a database.
+type MemoryStateManager struct {
+	mu      sync.RWMutex
+	tasks   map[string]*TaskState
+	created map[string]time.Time
+}
+
+// NewMemoryStateManager creates an empty in-memory state manager
+func NewMemoryStateManager() *MemoryStateManager {
+	return &MemoryStateManager{
+		tasks:   make(map[string]*TaskState),
+		created: make(map[string]time.Time),
+	}
+}
+
+// SaveTask stores a copy of task, replacing an earlier state with the same ID
+func (m *MemoryStateManager) SaveTask(task *TaskState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.created[task.ID]; !exists {
+		m.created[task.ID] = time.Now()
+	}
+	m.tasks[task.ID] = copyTaskState(task)
+	return nil
+}
+
+// SaveTasks stores copies of several tasks
+func (m *MemoryStateManager) SaveTasks(tasks []*TaskState) error {
+	for _, task := range tasks {
+		m.SaveTask(task)
+	}
+	return nil
+}
+
+// LoadTask returns a copy of a task, or nil if it does not exist
+func (m *MemoryStateManager) LoadTask(taskID string) (*TaskState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	task, exists := m.tasks[taskID]
+	if !exists {
+		return nil, nil // Task not found
+	}
+	return copyTaskState(task), nil
+}
+
+// ListTasks returns copies of all tasks, newest first
+func (m *MemoryStateManager) ListTasks() ([]*TaskState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tasks := make([]*TaskState, 0, len(m.tasks))
+	for _, task := range m.tasks {
+		tasks = append(tasks, copyTaskState(task))
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return m.created[tasks[i].ID].After(m.created[tasks[j].ID])
+	})
+	return tasks, nil
+}
+
+// DeleteTask removes a task
+func (m *MemoryStateManager) DeleteTask(taskID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tasks, taskID)
+	delete(m.created, taskID)
+	return nil
+}
+
+// CleanupOldTasks removes finished tasks created before the cutoff
+func (m *MemoryStateManager) CleanupOldTasks(olderThan time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cutoffTime := time.Now().Add(-olderThan)
+	for id, task := range m.tasks {
+		switch task.Status {
+		case "completed", "failed", "cancelled":
+			if m.created[id].Before(cutoffTime) {
+				delete(m.tasks, id)
+				delete(m.created, id)
+			}
+		}
+	}
+	return nil
+}
+
+// copyTaskState copies the slices and maps of a task so callers cannot change stored state
+func copyTaskState(task *TaskState) *TaskState {
+	copied := *task
+	copied.Errors = append([]string(nil), task.Errors...)
+	copied.Notes = append([]models.TaskNote(nil), task.Notes...)
+	if task.EndTime != nil {
+		endTime := *task.EndTime
+		copied.EndTime = &endTime
+	}
+	if task.OriginalRequest != nil {
+		copied.OriginalRequest = make(map[string]interface{}, len(task.OriginalRequest))
+		for k, v := range task.OriginalRequest {
+			copied.OriginalRequest[k] = v
+		}
+	}
+	return &copied
+}
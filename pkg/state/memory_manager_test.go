@@ -0,0 +1,38 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStateManager(t *testing.T) {
+	m := NewMemoryStateManager()
+	task := &TaskState{ID: "t1", Status: "running", Errors: []string{"first"}}
+	if err := m.SaveTask(task); err != nil {
+		t.Fatal(err)
+	}
+	task.Errors[0] = "changed after save"
+
+	loaded, _ := m.LoadTask("t1")
+	if loaded == nil || loaded.Errors[0] != "first" {
+		t.Fatalf("LoadTask() = %+v, want the state as saved", loaded)
+	}
+	if missing, err := m.LoadTask("nope"); missing != nil || err != nil {
+		t.Fatalf("LoadTask(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+
+	m.SaveTask(&TaskState{ID: "t2", Status: "completed"})
+	if tasks, _ := m.ListTasks(); len(tasks) != 2 || tasks[0].ID != "t2" {
+		t.Fatalf("ListTasks() = %+v, want newest first", tasks)
+	}
+
+	// Only finished tasks are cleaned up
+	m.CleanupOldTasks(-time.Second)
+	if tasks, _ := m.ListTasks(); len(tasks) != 1 || tasks[0].ID != "t1" {
+		t.Fatalf("after cleanup: %+v", tasks)
+	}
+	m.DeleteTask("t1")
+	if tasks, _ := m.ListTasks(); len(tasks) != 0 {
+		t.Fatalf("after delete: %+v", tasks)
+	}
+}
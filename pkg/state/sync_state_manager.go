@@ -0,0 +1,128 @@
+package state
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	pkgSync "s3migration/pkg/sync"
+)
+
+// SyncStateManager stores the object sync states of copy routes in the
+// object_sync_states table, one gzip-compressed row per route
+type SyncStateManager struct {
+	db *sql.DB
+}
+
+// NewSyncStateManager creates a new sync state manager
+func NewSyncStateManager(db *sql.DB) *SyncStateManager {
+	return &SyncStateManager{db: db}
+}
+
+// LoadObjectStates returns the states of a route, or nil if there are none
+func (sm *SyncStateManager) LoadObjectStates(scope string) (map[string]pkgSync.ObjectSyncState, error) {
+	var data []byte
+	err := sm.db.QueryRow(`SELECT states FROM object_sync_states WHERE scope = $1`, scope).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sync state: %w", err)
+	}
+	return decodeObjectStates(data)
+}
+
+// SaveObjectStates replaces the states of a route
+func (sm *SyncStateManager) SaveObjectStates(scope string, states map[string]pkgSync.ObjectSyncState) error {
+	data, err := encodeObjectStates(states)
+	if err != nil {
+		return err
+	}
+	_, err = sm.db.Exec(`
+		INSERT INTO object_sync_states (scope, object_count, states, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (scope) DO UPDATE SET
+			object_count = EXCLUDED.object_count,
+			states = EXCLUDED.states,
+			updated_at = EXCLUDED.updated_at
+	`, scope, len(states), data, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+	return nil
+}
+
+// encodeObjectStates gzips the JSON of a route's states
+func encodeObjectStates(states map[string]pkgSync.ObjectSyncState) ([]byte, error) {
+	data, err := json.Marshal(states)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode sync state: %w", err)
+	}
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress sync state: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress sync state: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeObjectStates reverses encodeObjectStates
+func decodeObjectStates(data []byte) (map[string]pkgSync.ObjectSyncState, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress sync state: %w", err)
+	}
+	defer reader.Close()
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress sync state: %w", err)
+	}
+	var states map[string]pkgSync.ObjectSyncState
+	if err := json.Unmarshal(raw, &states); err != nil {
+		return nil, fmt.Errorf("failed to decode sync state: %w", err)
+	}
+	return states, nil
+}
+
+// MemorySyncStateStore keeps object sync states in memory, compressed as in
+// the database. It is meant for tests and local runs without a database.
+type MemorySyncStateStore struct {
+	mu     sync.Mutex
+	scopes map[string][]byte
+}
+
+// NewMemorySyncStateStore creates an empty in-memory sync state store
+func NewMemorySyncStateStore() *MemorySyncStateStore {
+	return &MemorySyncStateStore{scopes: map[string][]byte{}}
+}
+
+// LoadObjectStates implements pkgSync.ObjectStateStore
+func (s *MemorySyncStateStore) LoadObjectStates(scope string) (map[string]pkgSync.ObjectSyncState, error) {
+	s.mu.Lock()
+	data, ok := s.scopes[scope]
+	s.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	return decodeObjectStates(data)
+}
+
+// SaveObjectStates implements pkgSync.ObjectStateStore
+func (s *MemorySyncStateStore) SaveObjectStates(scope string, states map[string]pkgSync.ObjectSyncState) error {
+	data, err := encodeObjectStates(states)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scopes[scope] = data
+	return nil
+}
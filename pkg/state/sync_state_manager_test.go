@@ -0,0 +1,35 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	pkgSync "s3migration/pkg/sync"
+)
+
+func TestMemorySyncStateStore(t *testing.T) {
+	store := NewMemorySyncStateStore()
+	scope := pkgSync.ObjectStateScope("", "src", "", "https://dest.example.com/", "dst", "backup")
+	if states, err := store.LoadObjectStates(scope); states != nil || err != nil {
+		t.Fatalf("states of a new route = %v, %v", states, err)
+	}
+
+	saved := map[string]pkgSync.ObjectSyncState{
+		"a.txt": {SourceETag: `"a1"`, SourceLastModified: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), DestETag: `"a2"`},
+		"b.txt": {SourceETag: `"b1"`, SourceLastModified: time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC), DestETag: `"b2"`},
+	}
+	if err := store.SaveObjectStates(scope, saved); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := store.LoadObjectStates(scope)
+	if err != nil || !reflect.DeepEqual(loaded, saved) {
+		t.Fatalf("loaded %v, %v; want %v", loaded, err, saved)
+	}
+
+	// Another route does not see them
+	other := pkgSync.ObjectStateScope("", "src", "", "https://dest.example.com", "dst", "")
+	if states, _ := store.LoadObjectStates(other); states != nil {
+		t.Errorf("states of another route = %v", states)
+	}
+}
@@ -0,0 +1,201 @@
+package state
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"s3migration/pkg/prefetch"
+)
+
+// TaskListingManager stores the destination listings of tasks in the
+// task_listings and task_listing_parts tables
+type TaskListingManager struct {
+	db *sql.DB
+}
+
+// NewTaskListingManager creates a new task listing manager
+func NewTaskListingManager(db *sql.DB) *TaskListingManager {
+	return &TaskListingManager{db: db}
+}
+
+// SaveTaskListingPart stores one gzip-compressed part; part 0 drops the task's earlier listing
+func (tm *TaskListingManager) SaveTaskListingPart(taskID string, part int, objects []prefetch.ObjectMetadata) error {
+	if part == 0 {
+		if err := tm.deleteTaskListing(taskID); err != nil {
+			return err
+		}
+	}
+	data, err := encodeListingObjects(objects)
+	if err != nil {
+		return err
+	}
+	_, err = tm.db.Exec(`
+		INSERT INTO task_listing_parts (task_id, part, objects) VALUES ($1, $2, $3)
+		ON CONFLICT (task_id, part) DO UPDATE SET objects = EXCLUDED.objects
+	`, taskID, part, data)
+	if err != nil {
+		return fmt.Errorf("failed to save task listing part: %w", err)
+	}
+	return nil
+}
+
+// CompleteTaskListing records a listing whose parts are saved
+func (tm *TaskListingManager) CompleteTaskListing(listing prefetch.TaskListing) error {
+	_, err := tm.db.Exec(`
+		INSERT INTO task_listings (task_id, endpoint, bucket, prefix, object_count, total_bytes, parts, listed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (task_id) DO UPDATE SET
+			endpoint = EXCLUDED.endpoint,
+			bucket = EXCLUDED.bucket,
+			prefix = EXCLUDED.prefix,
+			object_count = EXCLUDED.object_count,
+			total_bytes = EXCLUDED.total_bytes,
+			parts = EXCLUDED.parts,
+			listed_at = EXCLUDED.listed_at
+	`, listing.TaskID, listing.Endpoint, listing.Bucket, listing.Prefix, listing.Objects, listing.Bytes, listing.Parts, listing.ListedAt)
+	if err != nil {
+		return fmt.Errorf("failed to complete task listing: %w", err)
+	}
+	return nil
+}
+
+// LoadTaskListing returns the completed listing of a task, or nil if there is none
+func (tm *TaskListingManager) LoadTaskListing(taskID string) (*prefetch.TaskListing, error) {
+	listing := prefetch.TaskListing{TaskID: taskID}
+	err := tm.db.QueryRow(`
+		SELECT endpoint, bucket, prefix, object_count, total_bytes, parts, listed_at
+		FROM task_listings WHERE task_id = $1
+	`, taskID).Scan(&listing.Endpoint, &listing.Bucket, &listing.Prefix, &listing.Objects, &listing.Bytes, &listing.Parts, &listing.ListedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task listing: %w", err)
+	}
+	return &listing, nil
+}
+
+// LoadTaskListingPart returns one part of a task's listing
+func (tm *TaskListingManager) LoadTaskListingPart(taskID string, part int) ([]prefetch.ObjectMetadata, error) {
+	var data []byte
+	err := tm.db.QueryRow(`SELECT objects FROM task_listing_parts WHERE task_id = $1 AND part = $2`, taskID, part).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("part %d of the listing of task %s is missing", part, taskID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task listing part: %w", err)
+	}
+	return decodeListingObjects(data)
+}
+
+func (tm *TaskListingManager) deleteTaskListing(taskID string) error {
+	if _, err := tm.db.Exec(`DELETE FROM task_listings WHERE task_id = $1`, taskID); err != nil {
+		return fmt.Errorf("failed to delete task listing: %w", err)
+	}
+	if _, err := tm.db.Exec(`DELETE FROM task_listing_parts WHERE task_id = $1`, taskID); err != nil {
+		return fmt.Errorf("failed to delete task listing: %w", err)
+	}
+	return nil
+}
+
+// encodeListingObjects gzips the JSON of a listing's objects
+func encodeListingObjects(objects []prefetch.ObjectMetadata) ([]byte, error) {
+	data, err := json.Marshal(objects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode listing: %w", err)
+	}
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress listing: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress listing: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeListingObjects reverses encodeListingObjects
+func decodeListingObjects(data []byte) ([]prefetch.ObjectMetadata, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress listing: %w", err)
+	}
+	defer reader.Close()
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress listing: %w", err)
+	}
+	var objects []prefetch.ObjectMetadata
+	if err := json.Unmarshal(raw, &objects); err != nil {
+		return nil, fmt.Errorf("failed to decode listing: %w", err)
+	}
+	return objects, nil
+}
+
+// MemoryTaskListingStore keeps task listings in memory, compressed as in the
+// database. It is meant for tests and local runs without a database.
+type MemoryTaskListingStore struct {
+	mu       sync.Mutex
+	listings map[string]prefetch.TaskListing
+	parts    map[string][][]byte
+}
+
+// NewMemoryTaskListingStore creates an empty in-memory task listing store
+func NewMemoryTaskListingStore() *MemoryTaskListingStore {
+	return &MemoryTaskListingStore{listings: map[string]prefetch.TaskListing{}, parts: map[string][][]byte{}}
+}
+
+// SaveTaskListingPart implements prefetch.TaskListingStore
+func (s *MemoryTaskListingStore) SaveTaskListingPart(taskID string, part int, objects []prefetch.ObjectMetadata) error {
+	data, err := encodeListingObjects(objects)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if part == 0 {
+		delete(s.listings, taskID)
+		s.parts[taskID] = nil
+	}
+	for len(s.parts[taskID]) <= part {
+		s.parts[taskID] = append(s.parts[taskID], nil)
+	}
+	s.parts[taskID][part] = data
+	return nil
+}
+
+// CompleteTaskListing implements prefetch.TaskListingStore
+func (s *MemoryTaskListingStore) CompleteTaskListing(listing prefetch.TaskListing) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listings[listing.TaskID] = listing
+	return nil
+}
+
+// LoadTaskListing implements prefetch.TaskListingStore
+func (s *MemoryTaskListingStore) LoadTaskListing(taskID string) (*prefetch.TaskListing, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	listing, ok := s.listings[taskID]
+	if !ok {
+		return nil, nil
+	}
+	return &listing, nil
+}
+
+// LoadTaskListingPart implements prefetch.TaskListingStore
+func (s *MemoryTaskListingStore) LoadTaskListingPart(taskID string, part int) ([]prefetch.ObjectMetadata, error) {
+	s.mu.Lock()
+	parts := s.parts[taskID]
+	s.mu.Unlock()
+	if part < 0 || part >= len(parts) || parts[part] == nil {
+		return nil, fmt.Errorf("part %d of the listing of task %s is missing", part, taskID)
+	}
+	return decodeListingObjects(parts[part])
+}
@@ -0,0 +1,51 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"s3migration/pkg/prefetch"
+)
+
+func TestMemoryTaskListingStore(t *testing.T) {
+	store := NewMemoryTaskListingStore()
+	modified := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	parts := [][]prefetch.ObjectMetadata{
+		{{Key: "a", Size: 1, ETag: "\"aa\"", LastModified: modified}, {Key: "b", Size: 2}},
+		{{Key: "c", Size: 3}},
+	}
+	for i, part := range parts {
+		if err := store.SaveTaskListingPart("task-1", i, part); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if listing, _ := store.LoadTaskListing("task-1"); listing != nil {
+		t.Fatalf("listing readable before it is complete: %+v", listing)
+	}
+	if err := store.CompleteTaskListing(prefetch.TaskListing{TaskID: "task-1", Bucket: "dest", Objects: 3, Bytes: 6, Parts: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	listing, err := store.LoadTaskListing("task-1")
+	if err != nil || listing == nil || listing.Parts != 2 || listing.Objects != 3 {
+		t.Fatalf("listing = %+v, %v", listing, err)
+	}
+	first, err := store.LoadTaskListingPart("task-1", 0)
+	if err != nil || len(first) != 2 || first[0].ETag != "\"aa\"" || !first[0].LastModified.Equal(modified) || first[1].Key != "b" {
+		t.Fatalf("part 0 = %+v, %v", first, err)
+	}
+	if _, err := store.LoadTaskListingPart("task-1", 2); err == nil {
+		t.Error("missing part loaded")
+	}
+
+	// A new listing of the task replaces the old one from its first part
+	if err := store.SaveTaskListingPart("task-1", 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	if listing, _ := store.LoadTaskListing("task-1"); listing != nil {
+		t.Errorf("replaced listing still readable: %+v", listing)
+	}
+	if _, err := store.LoadTaskListingPart("task-1", 1); err == nil {
+		t.Error("part of the replaced listing still readable")
+	}
+}
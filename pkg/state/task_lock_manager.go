@@ -0,0 +1,95 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TaskLockStore holds the locks that keep two tasks from copying the same
+// source to the same destination at once. A lock is keyed by a hash of the
+// route and held by one task ID.
+type TaskLockStore interface {
+	// AcquireTaskLock takes the lock of key for taskID unless another task
+	// holds it, and returns the holder: taskID when the lock was taken
+	AcquireTaskLock(key, taskID string) (string, error)
+	// ReleaseTaskLock drops the lock of key if taskID holds it
+	ReleaseTaskLock(key, taskID string) error
+}
+
+// taskLockGrace is how long a lock is kept for a task that has no active row in
+// migration_tasks yet; after it, such a lock is stale (its pod went away)
+const taskLockGrace = time.Minute
+
+// TaskLockManager keeps task locks in the task_locks table, so that API
+// replicas sharing the database see each other's locks
+type TaskLockManager struct {
+	db *sql.DB
+}
+
+// NewTaskLockManager creates a new task lock manager
+func NewTaskLockManager(db *sql.DB) *TaskLockManager {
+	return &TaskLockManager{db: db}
+}
+
+// AcquireTaskLock implements TaskLockStore. A lock whose task is no longer
+// pending or running is taken over.
+func (lm *TaskLockManager) AcquireTaskLock(key, taskID string) (string, error) {
+	now := time.Now()
+	_, err := lm.db.Exec(`
+		INSERT INTO task_locks (lock_key, task_id, acquired_at) VALUES ($1, $2, $3)
+		ON CONFLICT (lock_key) DO UPDATE SET task_id = EXCLUDED.task_id, acquired_at = EXCLUDED.acquired_at
+		WHERE task_locks.task_id = EXCLUDED.task_id OR (task_locks.acquired_at < $4 AND NOT EXISTS (
+			SELECT 1 FROM migration_tasks WHERE id = task_locks.task_id AND status IN ('pending', 'running')))
+	`, key, taskID, now, now.Add(-taskLockGrace))
+	if err != nil {
+		return "", fmt.Errorf("failed to acquire task lock: %w", err)
+	}
+	var holder string
+	if err := lm.db.QueryRow(`SELECT task_id FROM task_locks WHERE lock_key = $1`, key).Scan(&holder); err != nil {
+		return "", fmt.Errorf("failed to read task lock: %w", err)
+	}
+	return holder, nil
+}
+
+// ReleaseTaskLock implements TaskLockStore
+func (lm *TaskLockManager) ReleaseTaskLock(key, taskID string) error {
+	if _, err := lm.db.Exec(`DELETE FROM task_locks WHERE lock_key = $1 AND task_id = $2`, key, taskID); err != nil {
+		return fmt.Errorf("failed to release task lock: %w", err)
+	}
+	return nil
+}
+
+// MemoryTaskLockStore keeps task locks in memory. It is meant for tests and
+// local runs without a database, where every task runs in this process.
+type MemoryTaskLockStore struct {
+	mu      sync.Mutex
+	holders map[string]string
+}
+
+// NewMemoryTaskLockStore creates an empty in-memory task lock store
+func NewMemoryTaskLockStore() *MemoryTaskLockStore {
+	return &MemoryTaskLockStore{holders: map[string]string{}}
+}
+
+// AcquireTaskLock implements TaskLockStore
+func (s *MemoryTaskLockStore) AcquireTaskLock(key, taskID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if holder, held := s.holders[key]; held {
+		return holder, nil
+	}
+	s.holders[key] = taskID
+	return taskID, nil
+}
+
+// ReleaseTaskLock implements TaskLockStore
+func (s *MemoryTaskLockStore) ReleaseTaskLock(key, taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.holders[key] == taskID {
+		delete(s.holders, key)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package state
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"s3migration/pkg/models"
+)
+
+// Limits on the errors and request payload stored with a task
+const (
+	maxInlineErrors = 100       // Errors kept in the errors JSONB column; the full list goes to errors_gz
+	maxStoredErrors = 10000     // Errors kept at all
+	maxErrorLength  = 2048      // Longer error messages are cut
+	maxRequestBytes = 64 * 1024 // Larger original_request payloads are replaced by a marker
+)
+
+// encodeTaskErrors returns the errors column value of a task (a JSON array, nil
+// for no errors) and, for more than maxInlineErrors errors, the gzip-compressed
+// full list stored in errors_gz
+func encodeTaskErrors(errs []string) (interface{}, []byte) {
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	stored := capErrors(errs, maxStoredErrors)
+	inline, _ := json.Marshal(capErrors(stored, maxInlineErrors))
+	if len(stored) <= maxInlineErrors {
+		return string(inline), nil
+	}
+
+	full, _ := json.Marshal(stored)
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	writer.Write(full)
+	writer.Close()
+	return string(inline), buf.Bytes()
+}
+
+// capErrors returns at most limit errors of at most maxErrorLength bytes; the
+// last one says how many were left out
+func capErrors(errs []string, limit int) []string {
+	capped := make([]string, 0, min(len(errs), limit))
+	for i, msg := range errs {
+		if i == limit-1 && len(errs) > limit {
+			capped = append(capped, fmt.Sprintf("... %d more errors not stored", len(errs)-i))
+			break
+		}
+		if len(msg) > maxErrorLength {
+			msg = msg[:maxErrorLength] + "... (truncated)"
+		}
+		capped = append(capped, msg)
+	}
+	return capped
+}
+
+// decodeTaskErrors returns the errors of a stored task, preferring the full list in errors_gz
+func decodeTaskErrors(inline sql.NullString, compressed []byte) []string {
+	var errs []string
+	if len(compressed) > 0 {
+		reader, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err == nil {
+			full, err := io.ReadAll(reader)
+			if err == nil && json.Unmarshal(full, &errs) == nil {
+				return errs
+			}
+		}
+	}
+	if inline.Valid {
+		json.Unmarshal([]byte(inline.String), &errs)
+	}
+	return errs
+}
+
+// encodeTaskRequest returns the original_request column value of a task
+func encodeTaskRequest(request map[string]interface{}) interface{} {
+	if request == nil {
+		return nil
+	}
+	payload, err := json.Marshal(request)
+	if err != nil || len(payload) > maxRequestBytes {
+		payload, _ = json.Marshal(map[string]interface{}{"truncated": true, "size": len(payload)})
+	}
+	return string(payload)
+}
+
+// decodeTaskRequest returns the original request of a stored task
+func decodeTaskRequest(payload sql.NullString) map[string]interface{} {
+	var request map[string]interface{}
+	if payload.Valid {
+		json.Unmarshal([]byte(payload.String), &request)
+	}
+	return request
+}
+
+// encodeTaskNotes returns the notes column value of a task
+func encodeTaskNotes(notes []models.TaskNote) interface{} {
+	if len(notes) == 0 {
+		return nil
+	}
+	payload, _ := json.Marshal(notes)
+	return string(payload)
+}
+
+// decodeTaskNotes returns the notes of a stored task
+func decodeTaskNotes(payload sql.NullString) []models.TaskNote {
+	var notes []models.TaskNote
+	if payload.Valid {
+		json.Unmarshal([]byte(payload.String), &notes)
+	}
+	return notes
+}
@@ -0,0 +1,73 @@
+package state
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTaskErrorsRoundTrip(t *testing.T) {
+	many := func(n int) []string {
+		errs := make([]string, n)
+		for i := range errs {
+			errs[i] = fmt.Sprintf("object %d: access denied", i)
+		}
+		return errs
+	}
+	tests := []struct {
+		name           string
+		errs           []string
+		wantCompressed bool
+		wantInline     int // Entries in the errors column
+		wantDecoded    int
+		wantLast       string // Suffix of the last decoded error
+	}{
+		{name: "none"},
+		{name: "few", errs: many(3), wantInline: 3, wantDecoded: 3, wantLast: "object 2: access denied"},
+		{name: "over the inline cap", errs: many(maxInlineErrors + 1), wantCompressed: true, wantInline: maxInlineErrors, wantDecoded: maxInlineErrors + 1, wantLast: "access denied"},
+		{name: "over the stored cap", errs: many(maxStoredErrors + 5), wantCompressed: true, wantInline: maxInlineErrors, wantDecoded: maxStoredErrors, wantLast: "6 more errors not stored"},
+		{name: "long message", errs: []string{strings.Repeat("x", maxErrorLength+10)}, wantInline: 1, wantDecoded: 1, wantLast: "... (truncated)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inline, compressed := encodeTaskErrors(tt.errs)
+			if (compressed != nil) != tt.wantCompressed {
+				t.Fatalf("compressed = %v, want %v", compressed != nil, tt.wantCompressed)
+			}
+			column := sql.NullString{}
+			if inline != nil {
+				column = sql.NullString{String: inline.(string), Valid: true}
+				var inlineErrs []string
+				if err := json.Unmarshal([]byte(column.String), &inlineErrs); err != nil || len(inlineErrs) != tt.wantInline {
+					t.Fatalf("errors column has %d entries (%v), want %d", len(inlineErrs), err, tt.wantInline)
+				}
+			} else if tt.wantInline != 0 {
+				t.Fatal("errors column is NULL")
+			}
+
+			decoded := decodeTaskErrors(column, compressed)
+			if len(decoded) != tt.wantDecoded {
+				t.Fatalf("decoded %d errors, want %d", len(decoded), tt.wantDecoded)
+			}
+			if tt.wantDecoded > 0 && !strings.HasSuffix(decoded[len(decoded)-1], tt.wantLast) {
+				t.Fatalf("last error = %q, want suffix %q", decoded[len(decoded)-1], tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestTaskRequestCap(t *testing.T) {
+	small := map[string]interface{}{"source_bucket": "logs", "dry_run": true}
+	if got := decodeTaskRequest(sql.NullString{String: encodeTaskRequest(small).(string), Valid: true}); got["source_bucket"] != "logs" {
+		t.Fatalf("small request = %v", got)
+	}
+	large := map[string]interface{}{"filter": strings.Repeat("a", maxRequestBytes)}
+	if got := decodeTaskRequest(sql.NullString{String: encodeTaskRequest(large).(string), Valid: true}); got["truncated"] != true {
+		t.Fatalf("large request = %v", got)
+	}
+	if encodeTaskRequest(nil) != nil || decodeTaskRequest(sql.NullString{}) != nil {
+		t.Fatal("nil request not stored as NULL")
+	}
+}
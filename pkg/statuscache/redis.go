@@ -0,0 +1,210 @@
+// Package statuscache shares the live status of running tasks between API
+// replicas. The database stays the durable record; the cache only spares it
+// status polling.
+package statuscache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Cache holds the latest status of tasks, encoded by the caller
+type Cache interface {
+	Set(taskID string, status []byte) error
+	Get(taskID string) ([]byte, error) // nil without error when the task is not cached
+	Delete(taskID string) error
+}
+
+const (
+	keyPrefix      = "s3migration:status:"
+	defaultTTL     = 24 * time.Hour // Finished tasks expire; running ones are rewritten on each update
+	commandTimeout = 500 * time.Millisecond
+	retryAfter     = 5 * time.Second // Commands fail fast this long after the server was unreachable
+	maxIdleConns   = 8
+)
+
+// ErrUnavailable is returned while the server is considered unreachable
+var ErrUnavailable = errors.New("redis unavailable")
+
+// RedisConfig configures a Redis status cache
+type RedisConfig struct {
+	Addr     string // host:port
+	Password string
+	DB       int
+	TTL      time.Duration // 0 = 24h
+}
+
+// Redis is a Cache on a Redis server. It speaks just the commands it needs
+// (AUTH, SELECT, GET, SET with expiry and DEL) over a small pool of connections.
+type Redis struct {
+	cfg       RedisConfig
+	idle      chan *redisConn
+	downUntil atomic.Int64 // Unix nanoseconds
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewRedis returns a Redis status cache; connections are made on first use
+func NewRedis(cfg RedisConfig) *Redis {
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultTTL
+	}
+	return &Redis{cfg: cfg, idle: make(chan *redisConn, maxIdleConns)}
+}
+
+// Set stores the status of a task
+func (r *Redis) Set(taskID string, status []byte) error {
+	_, err := r.do("SET", keyPrefix+taskID, string(status), "PX", strconv.FormatInt(r.cfg.TTL.Milliseconds(), 10))
+	return err
+}
+
+// Get returns the status of a task, nil if it is not cached
+func (r *Redis) Get(taskID string) ([]byte, error) {
+	reply, err := r.do("GET", keyPrefix+taskID)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	return reply.([]byte), nil
+}
+
+// Delete removes the status of a task
+func (r *Redis) Delete(taskID string) error {
+	_, err := r.do("DEL", keyPrefix+taskID)
+	return err
+}
+
+// Close closes the idle connections
+func (r *Redis) Close() error {
+	for {
+		select {
+		case c := <-r.idle:
+			c.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do runs a command and returns its reply: []byte for bulk and simple strings,
+// int64 for integers, nil for a missing value
+func (r *Redis) do(args ...string) (interface{}, error) {
+	if time.Now().UnixNano() < r.downUntil.Load() {
+		return nil, ErrUnavailable
+	}
+	c, err := r.conn()
+	if err != nil {
+		r.downUntil.Store(time.Now().Add(retryAfter).UnixNano())
+		return nil, err
+	}
+	reply, err := c.command(args...)
+	var replyErr redisError
+	switch {
+	case errors.As(err, &replyErr):
+		r.release(c) // The connection is still in sync
+	case err != nil:
+		c.conn.Close()
+		r.downUntil.Store(time.Now().Add(retryAfter).UnixNano())
+	default:
+		r.release(c)
+	}
+	return reply, err
+}
+
+// conn returns an idle connection or dials, authenticates and selects the database
+func (r *Redis) conn() (*redisConn, error) {
+	select {
+	case c := <-r.idle:
+		return c, nil
+	default:
+	}
+	conn, err := net.DialTimeout("tcp", r.cfg.Addr, commandTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	c := &redisConn{conn: conn, reader: bufio.NewReader(conn)}
+	if r.cfg.Password != "" {
+		if _, err := c.command("AUTH", r.cfg.Password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if r.cfg.DB != 0 {
+		if _, err := c.command("SELECT", strconv.Itoa(r.cfg.DB)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// release returns a connection to the idle pool, closing it if the pool is full
+func (r *Redis) release(c *redisConn) {
+	select {
+	case r.idle <- c:
+	default:
+		c.conn.Close()
+	}
+}
+
+// redisError is an error reply of the server
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// command writes a command and reads its reply
+func (c *redisConn) command(args ...string) (interface{}, error) {
+	c.conn.SetDeadline(time.Now().Add(commandTimeout))
+	var request strings.Builder
+	fmt.Fprintf(&request, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&request, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, request.String()); err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	return c.readReply()
+}
+
+// readReply reads a simple string, error, integer or bulk string reply
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: bad reply %q", line)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(c.reader, value); err != nil {
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+		return value[:size], nil
+	}
+	return nil, fmt.Errorf("redis: unsupported reply %q", line)
+}
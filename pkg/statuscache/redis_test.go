@@ -0,0 +1,164 @@
+package statuscache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves GET, SET, DEL, AUTH and SELECT from memory and records the commands
+type fakeRedis struct {
+	listener net.Listener
+	password string
+
+	mu       sync.Mutex
+	values   map[string]string
+	commands []string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{listener: listener, password: password, values: make(map[string]string)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, strings.Join(args, " "))
+		switch name := strings.ToUpper(args[0]); {
+		case name == "AUTH":
+			authed = args[1] == f.password
+			if authed {
+				io.WriteString(conn, "+OK\r\n")
+			} else {
+				io.WriteString(conn, "-WRONGPASS invalid password\r\n")
+			}
+		case !authed:
+			io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+		case name == "SELECT":
+			io.WriteString(conn, "+OK\r\n")
+		case name == "SET":
+			f.values[args[1]] = args[2]
+			io.WriteString(conn, "+OK\r\n")
+		case name == "GET":
+			if value, ok := f.values[args[1]]; ok {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+			} else {
+				io.WriteString(conn, "$-1\r\n")
+			}
+		case name == "DEL":
+			_, ok := f.values[args[1]]
+			delete(f.values, args[1])
+			if ok {
+				io.WriteString(conn, ":1\r\n")
+			} else {
+				io.WriteString(conn, ":0\r\n")
+			}
+		default:
+			io.WriteString(conn, "-ERR unknown command\r\n")
+		}
+		f.mu.Unlock()
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, count)
+	for i := range args {
+		if _, err := reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func (f *fakeRedis) recorded() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.commands...)
+}
+
+func TestRedisCache(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	cache := NewRedis(RedisConfig{Addr: server.listener.Addr().String(), Password: "secret", DB: 2, TTL: time.Minute})
+	defer cache.Close()
+
+	if status, err := cache.Get("t1"); err != nil || status != nil {
+		t.Fatalf("Get of an uncached task = %q, %v", status, err)
+	}
+	if err := cache.Set("t1", []byte(`{"status":"running"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := cache.Get("t1"); err != nil || string(status) != `{"status":"running"}` {
+		t.Fatalf("Get = %q, %v", status, err)
+	}
+	if err := cache.Delete("t1"); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := cache.Get("t1"); status != nil {
+		t.Fatalf("Get after Delete = %q", status)
+	}
+
+	commands := server.recorded()
+	want := []string{"AUTH secret", "SELECT 2", "GET s3migration:status:t1", `SET s3migration:status:t1 {"status":"running"} PX 60000`}
+	for i, command := range want {
+		if i >= len(commands) || commands[i] != command {
+			t.Fatalf("commands = %q, want prefix %q", commands, want)
+		}
+	}
+}
+
+func TestRedisCacheErrors(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	cache := NewRedis(RedisConfig{Addr: server.listener.Addr().String(), Password: "wrong"})
+	if err := cache.Set("t1", []byte("{}")); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Fatalf("Set with a wrong password: %v", err)
+	}
+
+	// An unreachable server is not dialled again for every update
+	server.listener.Close()
+	down := NewRedis(RedisConfig{Addr: server.listener.Addr().String()})
+	if err := down.Set("t1", []byte("{}")); err == nil || err == ErrUnavailable {
+		t.Fatalf("first Set against a closed server: %v", err)
+	}
+	if _, err := down.Get("t1"); err != ErrUnavailable {
+		t.Fatalf("Get right after a failure: %v, want %v", err, ErrUnavailable)
+	}
+}
@@ -52,6 +52,8 @@ type StreamCopyInput struct {
 	DestBucket   string
 	DestKey      string
 	ObjectSize   int64
+	// OnPartCopied is called with the size of each part as it completes (may be concurrent)
+	OnPartCopied func(partBytes int64)
 }
 
 // StreamCopyResult contains the result of a stream copy
@@ -149,6 +151,10 @@ func (s *Streamer) multipartCopy(ctx context.Context, input StreamCopyInput) (*S
 				return
 			}
 
+			if input.OnPartCopied != nil {
+				input.OnPartCopied(endByte - startByte + 1)
+			}
+
 			results <- partResult{
 				partNum: pn,
 				etag:    *uploadResp.CopyPartResult.ETag,
@@ -203,6 +209,11 @@ func (s *Streamer) multipartCopy(ctx context.Context, input StreamCopyInput) (*S
 	})
 
 	if err != nil {
+		s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(input.DestBucket),
+			Key:      aws.String(input.DestKey),
+			UploadId: aws.String(uploadID),
+		})
 		return nil, fmt.Errorf("failed to complete multipart upload: %w", err)
 	}
 
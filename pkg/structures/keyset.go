@@ -0,0 +1,104 @@
+package structures
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// KeySet is a set of object keys kept as 64-bit FNV-1a hashes, 8 bytes per key
+// whatever its length. Two keys share a hash with negligible probability (about
+// 1 in 30 million for a set of a million keys), in which case the second reads
+// as present. Keys added since the last TakeChunk can be persisted incrementally.
+type KeySet struct {
+	mu      sync.Mutex
+	hashes  map[uint64]struct{}
+	pending []uint64
+}
+
+// NewKeySet creates an empty key set
+func NewKeySet() *KeySet {
+	return &KeySet{hashes: make(map[uint64]struct{})}
+}
+
+// fnvHash is the hash a KeySet stores; FNV-1a spreads similar path-like keys better than hashKey
+func fnvHash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64()
+}
+
+// Add adds a key
+func (s *KeySet) Add(key string) {
+	hash := fnvHash(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.hashes[hash]; exists {
+		return
+	}
+	s.hashes[hash] = struct{}{}
+	s.pending = append(s.pending, hash)
+}
+
+// Contains reports whether a key was added
+func (s *KeySet) Contains(key string) bool {
+	hash := fnvHash(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exists := s.hashes[hash]
+	return exists
+}
+
+// Len returns the number of keys
+func (s *KeySet) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.hashes)
+}
+
+// Pending returns the number of keys added since the last TakeChunk
+func (s *KeySet) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// TakeChunk encodes the keys added since the last call; nil if there are none.
+// Chunks passed to LoadChunk restore the keys.
+func (s *KeySet) TakeChunk() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) == 0 {
+		return nil
+	}
+	chunk := make([]byte, 8*len(s.pending))
+	for i, hash := range s.pending {
+		binary.LittleEndian.PutUint64(chunk[8*i:], hash)
+	}
+	s.pending = s.pending[:0]
+	return chunk
+}
+
+// Requeue makes the keys of a chunk from TakeChunk pending again, e.g. after
+// persisting it failed
+func (s *KeySet) Requeue(chunk []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i+8 <= len(chunk); i += 8 {
+		s.pending = append(s.pending, binary.LittleEndian.Uint64(chunk[i:]))
+	}
+}
+
+// LoadChunk adds the keys of a chunk from TakeChunk; they are not pending again
+func (s *KeySet) LoadChunk(chunk []byte) error {
+	if len(chunk)%8 != 0 {
+		return fmt.Errorf("key set chunk of %d bytes is not a multiple of 8", len(chunk))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < len(chunk); i += 8 {
+		s.hashes[binary.LittleEndian.Uint64(chunk[i:])] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package structures
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestKeySetChunks(t *testing.T) {
+	set := NewKeySet()
+	if set.TakeChunk() != nil {
+		t.Fatal("chunk of an empty set")
+	}
+	for i := 0; i < 1000; i++ {
+		set.Add(fmt.Sprintf("photos/2026/%04d.jpg", i))
+	}
+	set.Add("photos/2026/0000.jpg") // Already present: not pending twice
+	first := set.TakeChunk()
+	set.Add("docs/report.pdf")
+	second := set.TakeChunk()
+	if len(first) != 8000 || len(second) != 8 || set.Pending() != 0 {
+		t.Fatalf("chunks of %d and %d bytes, %d pending", len(first), len(second), set.Pending())
+	}
+
+	restored := NewKeySet()
+	for _, chunk := range [][]byte{first, second} {
+		if err := restored.LoadChunk(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if restored.Len() != 1001 || restored.Pending() != 0 {
+		t.Fatalf("restored %d keys, %d pending", restored.Len(), restored.Pending())
+	}
+	for _, key := range []string{"photos/2026/0999.jpg", "docs/report.pdf"} {
+		if !restored.Contains(key) {
+			t.Errorf("%s missing after restore", key)
+		}
+	}
+	if restored.Contains("photos/2026/1000.jpg") {
+		t.Error("key never added reads as present")
+	}
+	if err := restored.LoadChunk(first[:12]); err == nil {
+		t.Error("truncated chunk accepted")
+	}
+}
@@ -0,0 +1,34 @@
+package sync
+
+import (
+	"strings"
+	"time"
+)
+
+// ObjectSyncState records the source version of an object as it was last
+// copied and the ETag its copy was written with. A later sync of the same
+// route sends them as If-None-Match/If-Modified-Since, so an object unchanged
+// at the source is not downloaded again while its copy is still in place.
+type ObjectSyncState struct {
+	SourceETag         string    `json:"source_etag"`
+	SourceLastModified time.Time `json:"source_last_modified"`
+	DestETag           string    `json:"dest_etag"`
+}
+
+// ObjectStateStore keeps the object sync states of copy routes
+type ObjectStateStore interface {
+	// LoadObjectStates returns the states of a route by source key, or nil if there are none
+	LoadObjectStates(scope string) (map[string]ObjectSyncState, error)
+	// SaveObjectStates replaces the states of a route
+	SaveObjectStates(scope string, states map[string]ObjectSyncState) error
+}
+
+// ObjectStateScope builds the key of a copy route from the source
+// bucket/prefix at one endpoint to the destination bucket/prefix at another
+// ("" for AWS)
+func ObjectStateScope(sourceEndpoint, sourceBucket, sourcePrefix, destEndpoint, destBucket, destPrefix string) string {
+	return strings.Join([]string{
+		strings.TrimRight(sourceEndpoint, "/"), sourceBucket, sourcePrefix,
+		strings.TrimRight(destEndpoint, "/"), destBucket, destPrefix,
+	}, "|")
+}
@@ -0,0 +1,138 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrorPolicy decides what happens to an object whose transformation fails
+type ErrorPolicy string
+
+const (
+	// OnErrorFail records the object as failed (default)
+	OnErrorFail ErrorPolicy = "fail"
+	// OnErrorSkip leaves the object out of the destination
+	OnErrorSkip ErrorPolicy = "skip"
+	// OnErrorPassThrough copies the original, untransformed object
+	OnErrorPassThrough ErrorPolicy = "passthrough"
+)
+
+// Object describes the object being transformed
+type Object struct {
+	Bucket      string
+	Key         string
+	Size        int64
+	ContentType string
+}
+
+// Result is a transformed object stream
+type Result struct {
+	Body        io.ReadCloser
+	Size        int64  // -1 when unknown; the body is then spooled to learn it
+	ContentType string // Empty keeps the source content type
+}
+
+// Hook transforms one object stream. Implementations must not keep body after
+// Transform returns unless they return a Result reading from it.
+type Hook interface {
+	Name() string
+	Transform(ctx context.Context, obj Object, body io.Reader) (*Result, error)
+}
+
+// PassThrough returns every object unchanged
+type PassThrough struct{}
+
+// Name implements Hook
+func (PassThrough) Name() string { return "passthrough" }
+
+// Transform implements Hook
+func (PassThrough) Transform(_ context.Context, obj Object, body io.Reader) (*Result, error) {
+	return &Result{Body: io.NopCloser(body), Size: obj.Size, ContentType: obj.ContentType}, nil
+}
+
+// Config selects and configures a hook
+type Config struct {
+	Type    string            // "http"
+	URL     string            // Transformation endpoint
+	Timeout time.Duration     // Per object; 0 = DefaultTimeout
+	Headers map[string]string // Extra request headers (e.g. auth)
+	OnError ErrorPolicy       // Empty = OnErrorFail
+	Match   []string          // Glob patterns on the key's base name (e.g. "*.jpg"); empty = all objects
+}
+
+// DefaultTimeout bounds one transformation call
+const DefaultTimeout = 5 * time.Minute
+
+// Policy is a configured hook with its object filter and error policy
+type Policy struct {
+	Hook    Hook
+	OnError ErrorPolicy
+	Match   []string
+}
+
+// Validate checks a hook configuration without contacting the hook
+func (c Config) Validate() error {
+	switch c.Type {
+	case "http":
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("transform url must be an http(s) URL")
+		}
+	default:
+		return fmt.Errorf("unknown transform type %q (expected http)", c.Type)
+	}
+
+	switch c.OnError {
+	case "", OnErrorFail, OnErrorSkip, OnErrorPassThrough:
+	default:
+		return fmt.Errorf("unknown transform on_error %q (expected fail, skip or passthrough)", c.OnError)
+	}
+	for _, pattern := range c.Match {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid transform match pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// New builds the policy of a validated configuration
+func New(c Config) (*Policy, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	policy := &Policy{OnError: c.OnError, Match: c.Match}
+	if policy.OnError == "" {
+		policy.OnError = OnErrorFail
+	}
+	policy.Hook = NewHTTPHook(c.URL, timeout, c.Headers)
+	return policy, nil
+}
+
+// Matches reports whether key is transformed by the policy
+func (p *Policy) Matches(key string) bool {
+	if p == nil || p.Hook == nil {
+		return false
+	}
+	if len(p.Match) == 0 {
+		return true
+	}
+	base := path.Base(key)
+	for _, pattern := range p.Match {
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(base)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "http", config: Config{Type: "http", URL: "https://hooks.example.com/resize"}},
+		{name: "fail policy", config: Config{Type: "http", URL: "https://hooks.example.com/resize", OnError: OnErrorFail}},
+		{name: "skip policy", config: Config{Type: "http", URL: "https://hooks.example.com/resize", OnError: OnErrorSkip}},
+		{name: "passthrough policy", config: Config{Type: "http", URL: "https://hooks.example.com/resize", OnError: OnErrorPassThrough}},
+		{name: "unknown policy", config: Config{Type: "http", URL: "https://hooks.example.com/resize", OnError: "retry"}, wantErr: true},
+		{name: "not an http url", config: Config{Type: "http", URL: "ftp://hooks.example.com/resize"}, wantErr: true},
+		{name: "no url", config: Config{Type: "http"}, wantErr: true},
+		{name: "wasm", config: Config{Type: "wasm"}, wantErr: true},
+		{name: "bad pattern", config: Config{Type: "http", URL: "https://hooks.example.com/resize", Match: []string{"[a-"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	tests := []struct {
+		onError ErrorPolicy
+		want    ErrorPolicy
+	}{
+		{onError: "", want: OnErrorFail},
+		{onError: OnErrorFail, want: OnErrorFail},
+		{onError: OnErrorSkip, want: OnErrorSkip},
+		{onError: OnErrorPassThrough, want: OnErrorPassThrough},
+	}
+	for _, tt := range tests {
+		policy, err := New(Config{Type: "http", URL: "https://hooks.example.com/resize", OnError: tt.onError})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if policy.OnError != tt.want || policy.Hook.Name() != "http:https://hooks.example.com/resize" {
+			t.Errorf("New(on_error %q) = %+v, want policy %q", tt.onError, policy, tt.want)
+		}
+	}
+}
+
+func TestPolicyMatches(t *testing.T) {
+	policy := &Policy{Hook: PassThrough{}, Match: []string{"*.jpg"}}
+	for key, want := range map[string]bool{"photos/a.jpg": true, "photos/B.JPG": true, "photos/a.png": false} {
+		if got := policy.Matches(key); got != want {
+			t.Errorf("Matches(%q) = %v, want %v", key, got, want)
+		}
+	}
+	if !(&Policy{Hook: PassThrough{}}).Matches("any") || (*Policy)(nil).Matches("any") {
+		t.Error("a policy without patterns matches every key, a nil policy none")
+	}
+}
@@ -0,0 +1,76 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"s3migration/pkg/providers/httpsource"
+)
+
+// HTTPHook sends each object to an external endpoint and stores what it returns.
+//
+// The object is POSTed as the request body with X-Object-Bucket, X-Object-Key,
+// X-Object-Size and Content-Type headers. A 200 response body replaces the
+// object (its Content-Type, if set, replaces the source content type); any other
+// status is a transformation error, with the first part of the body as message.
+type HTTPHook struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewHTTPHook creates a hook calling url with timeout per object. Hook URLs
+// come from API callers, so only public addresses are reached.
+func NewHTTPHook(url string, timeout time.Duration, headers map[string]string) *HTTPHook {
+	return &HTTPHook{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Transport: httpsource.NewPublicTransport(1), Timeout: timeout},
+	}
+}
+
+// Name implements Hook
+func (h *HTTPHook) Name() string { return "http:" + h.url }
+
+// Transform implements Hook
+func (h *HTTPHook) Transform(ctx context.Context, obj Object, body io.Reader) (*Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build transform request: %w", err)
+	}
+	if obj.Size >= 0 {
+		req.ContentLength = obj.Size
+	}
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Object-Bucket", obj.Bucket)
+	req.Header.Set("X-Object-Key", obj.Key)
+	req.Header.Set("X-Object-Size", strconv.FormatInt(obj.Size, 10))
+	for name, value := range h.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("transform request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("transform endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	return &Result{
+		Body:        resp.Body,
+		Size:        resp.ContentLength, // -1 for chunked responses
+		ContentType: resp.Header.Get("Content-Type"),
+	}, nil
+}
@@ -0,0 +1,69 @@
+package transform
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// localHook returns a hook calling a loopback test server, which the public
+// transport of NewHTTPHook refuses
+func localHook(url string, headers map[string]string) *HTTPHook {
+	hook := NewHTTPHook(url, time.Second, headers)
+	hook.client = &http.Client{Timeout: time.Second}
+	return hook
+}
+
+func TestHTTPHookTransform(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("X-Object-Bucket") != "src" || r.Header.Get("X-Object-Key") != "docs/a.txt" ||
+			r.Header.Get("X-Object-Size") != "5" || r.Header.Get("Content-Type") != "text/plain" || r.Header.Get("Authorization") != "Bearer hook" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/markdown")
+		w.Write([]byte(strings.ToUpper(string(body))))
+	}))
+	defer server.Close()
+
+	hook := localHook(server.URL, map[string]string{"Authorization": "Bearer hook"})
+	result, err := hook.Transform(context.Background(), Object{Bucket: "src", Key: "docs/a.txt", Size: 5, ContentType: "text/plain"}, strings.NewReader("alpha"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer result.Body.Close()
+	body, _ := io.ReadAll(result.Body)
+	if string(body) != "ALPHA" || result.Size != 5 || result.ContentType != "text/markdown" {
+		t.Fatalf("result = %q, size %d, content type %q", body, result.Size, result.ContentType)
+	}
+}
+
+func TestHTTPHookErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unsupported format", http.StatusUnprocessableEntity)
+	}))
+	defer server.Close()
+
+	_, err := localHook(server.URL, nil).Transform(context.Background(), Object{Key: "a.bin", Size: 1}, strings.NewReader("x"))
+	if err == nil || !strings.Contains(err.Error(), "returned 422: unsupported format") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestHTTPHookRefusesNonPublicAddress(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	_, err := NewHTTPHook(server.URL, time.Second, nil).Transform(context.Background(), Object{Key: "a.txt", Size: 1}, strings.NewReader("x"))
+	if err == nil || !strings.Contains(err.Error(), "non-public address") || called {
+		t.Fatalf("error = %v, hook called %v", err, called)
+	}
+}
@@ -8,7 +8,6 @@ import (
 
 	"s3migration/pkg/adaptive"
 	"s3migration/pkg/models"
-	"s3migration/pkg/network"
 )
 
 // WorkerConfig defines worker count configuration for a pattern
@@ -33,8 +32,8 @@ type Tuner struct {
 	currentWorkers      atomic.Int32
 	minWorkers          int
 	maxWorkers          int
-	networkMonitor      *network.Monitor
-	memoryManager       *adaptive.MemoryManager // Memory-aware worker management
+	networkMonitor      *adaptive.NetworkMonitor // Measured from the migration's completed transfers
+	memoryManager       *adaptive.MemoryManager  // Memory-aware worker management
 	performanceSamples  []PerformanceSample
 	sizeDistribution    []int64
 	adjustmentThreshold int
@@ -50,36 +49,36 @@ type Tuner struct {
 func NewTuner() *Tuner {
 	// Create memory manager for dynamic worker adjustment
 	memMgr := adaptive.NewMemoryManager()
-	
+
 	// Get memory-aware max workers
 	maxWorkers := memMgr.GetMaxWorkers()
 	fmt.Printf("🔧 Memory Manager: max workers = %d\n", maxWorkers)
-	
+
 	// All patterns now use memory-aware limits (no hardcoded differences)
 	// Start with aggressive defaults - memory manager will limit if needed
 	defaultWorkers := maxWorkers / 2 // Start with 50% of max workers
 	if defaultWorkers < 50 {
 		defaultWorkers = min(50, maxWorkers) // At least 50 workers
 	}
-	
+
 	// Ensure large files default is at least 5
 	largeFilesDefault := defaultWorkers / 2
 	if largeFilesDefault < 5 {
 		largeFilesDefault = min(5, maxWorkers)
 	}
-	
+
 	configs := map[models.WorkloadPattern]WorkerConfig{
 		models.PatternManySmall:  {Min: 10, Max: maxWorkers, Default: defaultWorkers},
 		models.PatternMixed:      {Min: 10, Max: maxWorkers, Default: defaultWorkers},
 		models.PatternLargeFiles: {Min: 5, Max: maxWorkers, Default: largeFilesDefault},
 		models.PatternUnknown:    {Min: 10, Max: maxWorkers, Default: defaultWorkers},
 	}
-	
+
 	fmt.Printf("🔧 Worker config: default=%d, max=%d\n", defaultWorkers, maxWorkers)
 
 	t := &Tuner{
 		currentPattern:      models.PatternUnknown,
-		networkMonitor:      network.NewMonitor(),
+		networkMonitor:      adaptive.NewNetworkMonitor(),
 		memoryManager:       memMgr, // Memory-aware management
 		performanceSamples:  make([]PerformanceSample, 0),
 		sizeDistribution:    make([]int64, 0),
@@ -91,14 +90,14 @@ func NewTuner() *Tuner {
 	config := configs[models.PatternUnknown]
 	t.minWorkers = config.Min
 	t.maxWorkers = config.Max
-	
+
 	// FORCE high worker count - bypass gradual ramping
 	initialWorkers := config.Default
 	if initialWorkers < 100 {
 		initialWorkers = min(100, maxWorkers) // Force at least 100 workers
 	}
 	t.currentWorkers.Store(int32(initialWorkers))
-	
+
 	fmt.Printf("📊 Tuner initialized: workers=%d (forced min 100), max=%d\n", initialWorkers, maxWorkers)
 
 	return t
@@ -129,7 +128,7 @@ func (t *Tuner) AnalyzeWorkload(fileSizes []int64) {
 	largeFiles := 0
 	var smallFilesBytes int64
 	var largeFilesBytes int64
-	
+
 	for _, size := range fileSizes {
 		if size < 1024*1024 { // < 1MB
 			smallFiles++
@@ -146,22 +145,22 @@ func (t *Tuner) AnalyzeWorkload(fileSizes []int64) {
 	largeFileSizeRatio := float64(largeFilesBytes) / float64(total)
 
 	var newPattern models.WorkloadPattern
-	
+
 	// If large files account for >20% of total data, treat as large files
 	// even if they're few in count
 	if largeFileSizeRatio > 0.2 {
 		newPattern = models.PatternLargeFiles
-		fmt.Printf("Pattern detection: Large files (%.1f%% of data in %d large files)\n", 
+		fmt.Printf("Pattern detection: Large files (%.1f%% of data in %d large files)\n",
 			largeFileSizeRatio*100, largeFiles)
 	} else if smallFileSizeRatio > 0.8 && smallFileCountRatio > 0.8 {
 		// If >80% of files AND data are small, use many small pattern
 		newPattern = models.PatternManySmall
-		fmt.Printf("Pattern detection: Many small files (%.1f%% of data in %d small files)\n", 
+		fmt.Printf("Pattern detection: Many small files (%.1f%% of data in %d small files)\n",
 			smallFileSizeRatio*100, smallFiles)
 	} else {
 		// Mixed workload
 		newPattern = models.PatternMixed
-		fmt.Printf("Pattern detection: Mixed sizes (small: %.1f%% data, large: %.1f%% data)\n", 
+		fmt.Printf("Pattern detection: Mixed sizes (small: %.1f%% data, large: %.1f%% data)\n",
 			smallFileSizeRatio*100, largeFileSizeRatio*100)
 	}
 
@@ -172,6 +171,7 @@ func (t *Tuner) AnalyzeWorkload(fileSizes []int64) {
 		t.minWorkers = config.Min
 		t.maxWorkers = config.Max
 		t.currentWorkers.Store(int32(config.Default))
+		t.memoryManager.SetWorkloadPattern(newPattern)
 
 		fmt.Printf("\nWorkload pattern detected: %s\n", newPattern)
 		fmt.Printf("Adjusting worker range to %d-%d\n", t.minWorkers, t.maxWorkers)
@@ -203,6 +203,18 @@ func (t *Tuner) RecordPerformance(transferSpeedMB float64, activeWorkers int, fi
 	t.performanceSamples = filtered
 }
 
+// RecordTransfer feeds a completed transfer to the network monitor and passes
+// the measured condition on to the memory manager; err is nil on success
+func (t *Tuner) RecordTransfer(size int64, elapsed time.Duration, err error) {
+	t.networkMonitor.RecordTransfer(size, elapsed, err)
+	t.memoryManager.SetNetworkCondition(t.networkMonitor.GetCurrentCondition())
+}
+
+// GetNetworkMonitor returns the tuner's network monitor
+func (t *Tuner) GetNetworkMonitor() *adaptive.NetworkMonitor {
+	return t.networkMonitor
+}
+
 // ShouldAdjust determines if it's time to adjust workers
 func (t *Tuner) ShouldAdjust() bool {
 	t.mu.RLock()
@@ -224,13 +236,13 @@ func (t *Tuner) GetOptimalWorkers() int {
 	// PRIORITY 1: Check memory constraints first!
 	current := int(t.currentWorkers.Load())
 	t.memoryManager.RecordMemoryUsage(current)
-	
+
 	// Get memory-safe worker count
 	memorySafeWorkers := t.memoryManager.GetOptimalWorkers()
-	
+
 	// Force GC if memory is high
 	t.memoryManager.ForceGCIfNeeded()
-	
+
 	if !t.ShouldAdjust() {
 		// Even if not adjusting for performance, respect memory limits
 		if memorySafeWorkers < current {
@@ -253,13 +265,12 @@ func (t *Tuner) GetOptimalWorkers() int {
 	// Start with current workers and let memory manager adjust
 	optimalWorkers := int(t.currentWorkers.Load())
 
-	// Apply network recommendations
-	networkRecommended := t.networkMonitor.GetRecommendedWorkers(optimalWorkers)
-	condition := t.networkMonitor.GetCurrentCondition()
-
-	if condition == models.NetworkPoor || condition == models.NetworkFair {
+	// Apply network recommendations once transfers have measured the network
+	networkRecommended := t.networkMonitor.GetOptimalConcurrency(optimalWorkers)
+	switch t.networkMonitor.GetCurrentCondition() {
+	case adaptive.ConditionPoor, adaptive.ConditionFair:
 		optimalWorkers = networkRecommended
-	} else {
+	case adaptive.ConditionGood, adaptive.ConditionExcellent:
 		optimalWorkers = (optimalWorkers + networkRecommended) / 2
 	}
 
@@ -317,3 +328,8 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+// GetMemoryManager returns the tuner's memory manager
+func (t *Tuner) GetMemoryManager() *adaptive.MemoryManager {
+	return t.memoryManager
+}
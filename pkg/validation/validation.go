@@ -0,0 +1,490 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"s3migration/pkg/core"
+	"s3migration/pkg/logging"
+	"s3migration/pkg/models"
+	"s3migration/pkg/secrets"
+	pkgSync "s3migration/pkg/sync"
+)
+
+// Error codes returned in FieldError.Code
+const (
+	CodeRequired      = "required"       // Field must be set
+	CodeInvalidFormat = "invalid_format" // Value is malformed (bucket name, URL, pattern)
+	CodeInvalidValue  = "invalid_value"  // Value is not one of the allowed values or out of range
+	CodeConflict      = "conflict"       // Field cannot be combined with another field
+	CodeIncomplete    = "incomplete"     // Related fields must be set together
+	CodeInvalidJSON   = "invalid_json"   // Body is not valid JSON
+	CodeInvalidType   = "invalid_type"   // Field has the wrong JSON type
+	CodeTooLarge      = "too_large"      // Body exceeds the server's size limit
+)
+
+// FieldError describes one problem with one request field
+type FieldError struct {
+	Field   string `json:"field"` // JSON path, e.g. "source_credentials.secret_key"
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors is a list of field errors; it is returned as an error only when non-empty
+type Errors []FieldError
+
+// Error joins all field messages
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// add appends a field error
+func (e *Errors) add(field, code, format string, args ...interface{}) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns nil for an empty list so callers can use the usual err != nil check
+func (e Errors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// FromBindError converts a JSON binding error into field errors
+func FromBindError(err error) Errors {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var sizeErr *http.MaxBytesError
+	var errs Errors
+
+	switch {
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		errs.add(field, CodeInvalidType, "expected %s, got %s", typeErr.Type.String(), typeErr.Value)
+	case errors.As(err, &syntaxErr):
+		errs.add("body", CodeInvalidJSON, "malformed JSON at offset %d: %v", syntaxErr.Offset, syntaxErr)
+	case errors.As(err, &sizeErr):
+		errs.add("body", CodeTooLarge, "request body exceeds %d bytes", sizeErr.Limit)
+	case errors.Is(err, io.EOF):
+		errs.add("body", CodeRequired, "request body is empty")
+	default:
+		errs.add("body", CodeInvalidJSON, "%v", err)
+	}
+	return errs
+}
+
+var (
+	// S3 bucket naming rules: 3-63 chars, lowercase letters, digits, dots and hyphens,
+	// starting and ending with a letter or digit
+	bucketNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+	// S3-compatible providers and legacy us-east-1 buckets are more permissive (uppercase, underscores)
+	customBucketNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,254}$`)
+)
+
+// maxPrefixLength is the S3 key length limit
+const maxPrefixLength = 1024
+
+// validateBucketName checks a bucket name; custom endpoints and legacy buckets get the permissive rules
+func validateBucketName(errs *Errors, field, name string, permissive bool) {
+	if permissive {
+		if !customBucketNamePattern.MatchString(name) {
+			errs.add(field, CodeInvalidFormat, "bucket name %q may only contain letters, digits, '.', '_' and '-'", name)
+		}
+		return
+	}
+
+	switch {
+	case len(name) < 3 || len(name) > 63:
+		errs.add(field, CodeInvalidFormat, "bucket name must be 3-63 characters long")
+	case !bucketNamePattern.MatchString(name):
+		errs.add(field, CodeInvalidFormat, "bucket name %q may only contain lowercase letters, digits, '.' and '-', and must start and end with a letter or digit", name)
+	case strings.Contains(name, ".."):
+		errs.add(field, CodeInvalidFormat, "bucket name must not contain consecutive dots")
+	case net.ParseIP(name) != nil:
+		errs.add(field, CodeInvalidFormat, "bucket name must not be formatted as an IP address")
+	}
+}
+
+// NormalizePrefix removes leading slashes and collapses repeated slashes.
+// keepTrailingSlash preserves a trailing "/" (meaningful when matching source keys);
+// destination prefixes are joined with "/" by the migrator, so it is trimmed there.
+func NormalizePrefix(prefix string, keepTrailingSlash bool) string {
+	prefix = strings.TrimSpace(prefix)
+	for strings.Contains(prefix, "//") {
+		prefix = strings.ReplaceAll(prefix, "//", "/")
+	}
+	prefix = strings.TrimLeft(prefix, "/")
+	if !keepTrailingSlash {
+		prefix = strings.TrimRight(prefix, "/")
+	}
+	return prefix
+}
+
+// validatePrefix normalizes a prefix in place and checks its length and segments
+func validatePrefix(errs *Errors, field string, prefix *string, keepTrailingSlash bool) {
+	*prefix = NormalizePrefix(*prefix, keepTrailingSlash)
+	if len(*prefix) > maxPrefixLength {
+		errs.add(field, CodeInvalidValue, "prefix must be at most %d bytes", maxPrefixLength)
+	}
+	for _, segment := range strings.Split(*prefix, "/") {
+		if segment == ".." {
+			errs.add(field, CodeInvalidFormat, "prefix must not contain '..' segments")
+			break
+		}
+	}
+}
+
+// validateCredentials checks S3 credentials are complete and the endpoint is a URL
+func validateCredentials(errs *Errors, field string, creds *models.Credentials) {
+	if creds == nil {
+		return
+	}
+	if creds.AccessKey != "" && creds.SecretKey == "" {
+		errs.add(field+".secret_key", CodeIncomplete, "secret_key is required when access_key is set")
+	}
+	if creds.SecretKey != "" && creds.AccessKey == "" {
+		errs.add(field+".access_key", CodeIncomplete, "access_key is required when secret_key is set")
+	}
+	if creds.SessionToken != "" && creds.AccessKey == "" {
+		errs.add(field+".session_token", CodeIncomplete, "session_token requires access_key and secret_key")
+	}
+	if creds.SecretRef != "" {
+		if creds.AccessKey != "" || creds.SecretKey != "" {
+			errs.add(field+".secret_ref", CodeConflict, "secret_ref cannot be combined with access_key/secret_key")
+		} else if err := secrets.ValidateReference(creds.SecretRef); err != nil {
+			errs.add(field+".secret_ref", CodeInvalidFormat, "%s", err.Error())
+		}
+	}
+	if creds.EndpointURL != "" {
+		u, err := url.Parse(creds.EndpointURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.add(field+".endpoint_url", CodeInvalidFormat, "endpoint_url must be an http(s) URL, e.g. https://s3.example.com")
+		}
+	}
+}
+
+// validateMigrationMode checks the migration mode is known
+func validateMigrationMode(errs *Errors, mode string) {
+	switch mode {
+	case "", "full_rewrite", "incremental":
+	default:
+		errs.add("migration_mode", CodeInvalidValue, "migration_mode must be full_rewrite or incremental")
+	}
+}
+
+// validateTimeout checks the timeout in seconds is not negative
+func validateTimeout(errs *Errors, timeout int) {
+	if timeout < 0 {
+		errs.add("timeout", CodeInvalidValue, "timeout must be zero (default) or a positive number of seconds")
+	}
+}
+
+// hasCustomEndpoint reports whether credentials point at a non-AWS endpoint
+func hasCustomEndpoint(creds *models.Credentials) bool {
+	return creds != nil && creds.EndpointURL != ""
+}
+
+// allowsLegacyBucketNames reports whether a source bucket may have a legacy us-east-1 name.
+// Buckets created there before March 2018 can have uppercase letters and underscores.
+func allowsLegacyBucketNames(creds *models.Credentials) bool {
+	return creds == nil || creds.Region == "" || creds.Region == "us-east-1"
+}
+
+// sameCredentials reports whether two credential sets are identical
+func sameCredentials(a, b *models.Credentials) bool {
+	return a.AccessKey == b.AccessKey && a.SecretKey == b.SecretKey && a.SessionToken == b.SessionToken &&
+		a.Region == b.Region && a.EndpointURL == b.EndpointURL && a.SecretRef == b.SecretRef
+}
+
+// ValidateMigrationRequest checks an S3 migration request and normalizes its prefixes in place
+func ValidateMigrationRequest(req *models.MigrationRequest) error {
+	var errs Errors
+
+	// Bucket combinations: both empty migrates every bucket
+	if req.SourceBucket == "" && req.DestBucket != "" {
+		errs.add("dest_bucket", CodeConflict, "dest_bucket must be empty when source_bucket is empty (all buckets)")
+	}
+	if req.SourceBucket != "" && req.DestBucket == "" {
+		errs.add("dest_bucket", CodeRequired, "dest_bucket is required when source_bucket is set")
+	}
+
+	// Deprecated credentials field
+	if req.Credentials != nil && req.SourceCredentials != nil && !sameCredentials(req.Credentials, req.SourceCredentials) {
+		errs.add("credentials", CodeConflict, "credentials is deprecated; set only source_credentials")
+	}
+	sourceCreds := req.SourceCredentials
+	if sourceCreds == nil {
+		sourceCreds = req.Credentials
+	}
+	if req.SourceCredentials != nil {
+		validateCredentials(&errs, "source_credentials", req.SourceCredentials)
+	} else {
+		validateCredentials(&errs, "credentials", req.Credentials)
+	}
+	validateCredentials(&errs, "dest_credentials", req.DestCredentials)
+
+	destCreds := req.DestCredentials
+	if destCreds == nil {
+		destCreds = sourceCreds
+	}
+	if req.SourceBucket != "" {
+		validateBucketName(&errs, "source_bucket", req.SourceBucket, hasCustomEndpoint(sourceCreds) || allowsLegacyBucketNames(sourceCreds))
+	}
+	if req.DestBucket != "" {
+		validateBucketName(&errs, "dest_bucket", req.DestBucket, hasCustomEndpoint(destCreds))
+	}
+
+	validatePrefix(&errs, "source_prefix", &req.SourcePrefix, true)
+	validatePrefix(&errs, "dest_prefix", &req.DestPrefix, false)
+	if req.SourcePrefix != "" && req.SourceBucket == "" {
+		errs.add("source_prefix", CodeConflict, "source_prefix requires source_bucket")
+	}
+	if req.FilesFrom != nil && req.SourceBucket == "" {
+		errs.add("files_from", CodeConflict, "files_from requires source_bucket")
+	}
+	for i, file := range req.FilesFrom {
+		if strings.Trim(file, "/") == "" {
+			errs.add(fmt.Sprintf("files_from[%d]", i), CodeInvalidValue, "files_from entries must name a key")
+			break
+		}
+	}
+	if req.SourceInventory != nil {
+		if req.SourceBucket == "" {
+			errs.add("source_inventory", CodeConflict, "source_inventory requires source_bucket")
+		}
+		validateCredentials(&errs, "source_inventory.credentials", req.SourceInventory.Credentials)
+		if _, err := core.SourceInventoryFor(req.SourceInventory); err != nil {
+			errs.add("source_inventory", CodeInvalidValue, "%v", err)
+		}
+	}
+	if req.Reconcile != nil && req.Reconcile.Enabled && req.SourceBucket == "" {
+		errs.add("reconcile", CodeConflict, "reconcile requires source_bucket")
+	}
+	if req.Webhook != nil {
+		u, err := url.Parse(req.Webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.add("webhook.url", CodeInvalidFormat, "webhook url must be an http(s) URL")
+		}
+		if req.SourceBucket == "" {
+			errs.add("webhook", CodeConflict, "webhook requires source_bucket")
+		}
+		if req.Webhook.ManifestBucket != "" {
+			validateBucketName(&errs, "webhook.manifest_bucket", req.Webhook.ManifestBucket, hasCustomEndpoint(destCreds))
+		}
+		if _, err := core.FailureManifestPolicyFor(req.Webhook, ""); err != nil {
+			errs.add("webhook", CodeInvalidValue, "%v", err)
+		}
+	}
+	if req.AuditLog != nil && req.AuditLog.Enabled {
+		if req.AuditLog.Bucket != "" {
+			validateBucketName(&errs, "audit_log.bucket", req.AuditLog.Bucket, hasCustomEndpoint(destCreds))
+		}
+		if _, err := core.AuditLogPolicyFor(req.AuditLog, ""); err != nil {
+			errs.add("audit_log.prefix", CodeInvalidFormat, "%v", err)
+		}
+	}
+	if _, err := core.ACLPolicyFor(req.ACL); err != nil {
+		errs.add("acl.mapping", CodeInvalidValue, "%v", err)
+	}
+	if _, err := core.LabelPolicyFor(req.Labels, ""); err != nil {
+		errs.add("labels.tags", CodeInvalidValue, "%v", err)
+	}
+	if _, err := core.NetworkLinkFor(req.Network); err != nil {
+		errs.add("network", CodeInvalidValue, "%v", err)
+	} else if req.Network != nil && req.Network.Measure && req.SourceBucket == "" {
+		errs.add("network.measure", CodeConflict, "the link cannot be measured when all buckets are migrated")
+	}
+	if req.Cutover != nil && req.Cutover.Enabled {
+		if req.SourceBucket == "" {
+			errs.add("cutover", CodeConflict, "cutover requires source_bucket")
+		}
+		if req.DryRun {
+			errs.add("cutover", CodeConflict, "cutover cannot be a dry run")
+		}
+		if req.Cutover.MaxDeltaPasses < 0 || req.Cutover.DeltaThreshold < 0 {
+			errs.add("cutover", CodeInvalidValue, "cutover max_delta_passes and delta_threshold must not be negative")
+		}
+	}
+
+	if req.Verify != nil {
+		if req.SourceBucket == "" {
+			errs.add("verify", CodeConflict, "verify requires source_bucket")
+		}
+		if req.Cutover != nil && req.Cutover.Enabled {
+			errs.add("verify", CodeConflict, "verify cannot be combined with cutover")
+		}
+		if err := core.ValidateVerifyMode(core.VerifyMode(req.Verify.Mode)); err != nil {
+			errs.add("verify.mode", CodeInvalidValue, "%v", err)
+		}
+	}
+
+	// Copying a prefix onto itself would overwrite every object with itself
+	sameEndpoint := (sourceCreds == nil && destCreds == nil) ||
+		(sourceCreds != nil && destCreds != nil && sourceCreds.EndpointURL == destCreds.EndpointURL)
+	if req.SourceBucket != "" && req.SourceBucket == req.DestBucket && sameEndpoint &&
+		req.DestPrefix == "" {
+		errs.add("dest_bucket", CodeConflict, "destination is the same bucket as the source; set dest_prefix or choose another bucket")
+	}
+	if req.Reorganize != nil && req.Reorganize.Enabled {
+		if req.SourceBucket == "" || req.DestBucket != req.SourceBucket || !sameEndpoint {
+			errs.add("reorganize", CodeConflict, "reorganize moves objects within one bucket; dest_bucket must be source_bucket, on the same endpoint")
+		} else if req.DestPrefix != "" {
+			if err := core.ValidateReorganizePrefixes(req.SourcePrefix, req.DestPrefix); err != nil {
+				errs.add("dest_prefix", CodeConflict, "%v", err)
+			}
+		}
+		if req.Verify != nil || req.Cutover != nil && req.Cutover.Enabled {
+			errs.add("reorganize", CodeConflict, "reorganize cannot be combined with verify or cutover")
+		}
+	}
+
+	validateMigrationMode(&errs, req.MigrationMode)
+	validateTimeout(&errs, req.Timeout)
+
+	if err := core.ValidateConflictStrategy(pkgSync.ConflictStrategy(req.ConflictStrategy)); err != nil {
+		errs.add("conflict_strategy", CodeInvalidValue, "%v", err)
+	}
+	if err := core.ValidateObjectOrder(core.ObjectOrder(req.ObjectOrder)); err != nil {
+		errs.add("object_order", CodeInvalidValue, "%v", err)
+	}
+	if err := core.ValidateFolderMarkerMode(core.FolderMarkerMode(req.FolderMarkers)); err != nil {
+		errs.add("folder_markers", CodeInvalidValue, "%v", err)
+	}
+	if err := core.ValidateNeverOverwriteMode(core.NeverOverwriteMode(req.NeverOverwrite)); err != nil {
+		errs.add("never_overwrite", CodeInvalidValue, "%v", err)
+	} else if req.NeverOverwrite != "" && (req.ConflictStrategy == string(pkgSync.ConflictSource) || req.ConflictStrategy == string(pkgSync.ConflictNewest)) {
+		errs.add("never_overwrite", CodeConflict, "never_overwrite cannot be combined with conflict_strategy %q, which replaces existing objects", req.ConflictStrategy)
+	}
+	if req.DryRunDiff && !req.DryRun {
+		errs.add("dry_run_diff", CodeConflict, "dry_run_diff requires dry_run")
+	}
+	if req.Multipart != nil {
+		if req.Multipart.ThresholdMB < 0 || req.Multipart.PartSizeMB < 0 || req.Multipart.MaxParts < 0 || req.Multipart.PartConcurrency < 0 {
+			errs.add("multipart", CodeInvalidValue, "multipart values must not be negative")
+		} else if _, err := core.MultipartSettingsFor(req.Multipart, ""); err != nil {
+			errs.add("multipart", CodeInvalidValue, "%v", err)
+		}
+	}
+
+	if _, err := core.TransformPolicyFor(req.Transform); err != nil {
+		errs.add("transform", CodeInvalidValue, "%v", err)
+	}
+	if _, err := core.ScanPolicyFor(req.Scan); err != nil {
+		errs.add("scan", CodeInvalidValue, "%v", err)
+	}
+	if _, err := core.DedupePolicyFor(req.Dedupe); err != nil {
+		errs.add("dedupe", CodeInvalidValue, "%v", err)
+	}
+	if _, err := core.SnapshotPolicyFor(req.Snapshot, ""); err != nil {
+		errs.add("snapshot.prefix", CodeInvalidFormat, "%v", err)
+	}
+	if _, err := core.PartitionPolicyFor(req.Partition); err != nil {
+		errs.add("partition", CodeInvalidValue, "%v", err)
+	}
+	if _, err := core.KeySanitizePolicyFor(req.KeySanitize); err != nil {
+		errs.add("key_sanitize", CodeInvalidValue, "%v", err)
+	}
+	if _, err := core.PrefixShardPolicyFor(req.PrefixShards); err != nil {
+		errs.add("prefix_shards", CodeInvalidValue, "%v", err)
+	}
+	if req.DestFetch != nil {
+		if _, err := core.DestFetchPolicyFor(req.DestFetch); err != nil {
+			errs.add("dest_fetch", CodeInvalidValue, "%v", err)
+		}
+		if req.DestCredentials == nil {
+			errs.add("dest_fetch", CodeConflict, "dest_fetch requires dest_credentials; within one endpoint objects are already copied server-side")
+		}
+	}
+	if req.ConditionalGet && req.DestCredentials == nil {
+		errs.add("conditional_get", CodeConflict, "conditional_get requires dest_credentials; within one endpoint objects are copied server-side without downloading them")
+	}
+
+	if _, err := logging.ParseLevel(req.LogLevel); err != nil {
+		errs.add("log_level", CodeInvalidValue, "log_level must be error, info or debug")
+	}
+	if req.DebugSampleRate < 0 {
+		errs.add("debug_sample_rate", CodeInvalidValue, "debug_sample_rate must not be negative")
+	}
+
+	return errs.err()
+}
+
+// ValidateGoogleDriveMigrationRequest checks a Google Drive migration request and normalizes its prefix in place
+func ValidateGoogleDriveMigrationRequest(req *models.GoogleDriveMigrationRequest) error {
+	var errs Errors
+
+	if req.SourceCredentials == nil {
+		errs.add("source_credentials", CodeRequired, "source_credentials is required")
+	} else {
+		creds := req.SourceCredentials
+		if creds.AccessToken == "" && creds.RefreshToken == "" {
+			errs.add("source_credentials.access_token", CodeRequired, "access_token or refresh_token is required")
+		}
+		if creds.RefreshToken != "" && (creds.ClientID == "" || creds.ClientSecret == "") {
+			errs.add("source_credentials.client_id", CodeIncomplete, "client_id and client_secret are required to use refresh_token")
+		}
+	}
+
+	if req.DestBucket == "" {
+		errs.add("dest_bucket", CodeRequired, "dest_bucket is required")
+	} else {
+		validateBucketName(&errs, "dest_bucket", req.DestBucket, hasCustomEndpoint(req.DestCredentials))
+	}
+	validateGoogleDriveDestination(&errs, req)
+	validatePrefix(&errs, "dest_prefix", &req.DestPrefix, false)
+
+	validateMigrationMode(&errs, req.MigrationMode)
+	validateTimeout(&errs, req.Timeout)
+
+	validatePatterns(&errs, "include_paths", req.IncludePaths)
+	validatePatterns(&errs, "exclude_paths", req.ExcludePaths)
+	validatePatterns(&errs, "include_mime_types", req.IncludeMimeTypes)
+	validatePatterns(&errs, "exclude_mime_types", req.ExcludeMimeTypes)
+
+	return errs.err()
+}
+
+// validateGoogleDriveDestination requires explicit destination S3 credentials: keys, a
+// secret reference or a named profile. Drive tokens are never usable as S3 keys.
+func validateGoogleDriveDestination(errs *Errors, req *models.GoogleDriveMigrationRequest) {
+	creds := req.DestCredentials
+	hasKeys := creds != nil && (creds.AccessKey != "" || creds.SecretKey != "" || creds.SecretRef != "")
+	switch {
+	case req.DestProfile != "" && hasKeys:
+		errs.add("dest_profile", CodeConflict, "dest_profile cannot be combined with dest_credentials keys or secret_ref")
+	case req.DestProfile == "" && creds == nil:
+		errs.add("dest_credentials", CodeRequired, "dest_credentials (or dest_profile) is required")
+	case req.DestProfile == "" && !hasKeys:
+		errs.add("dest_credentials.access_key", CodeRequired, "access_key and secret_key (or secret_ref) are required unless dest_profile is set")
+	}
+	validateCredentials(errs, "dest_credentials", creds)
+}
+
+// validatePatterns checks glob patterns used by Drive filters
+func validatePatterns(errs *Errors, field string, patterns []string) {
+	for i, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			errs.add(fmt.Sprintf("%s[%d]", field, i), CodeInvalidValue, "pattern must not be empty")
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs.add(fmt.Sprintf("%s[%d]", field, i), CodeInvalidFormat, "invalid glob pattern %q", pattern)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"s3migration/pkg/models"
+)
+
+// fields returns the field and code of every error, in order
+func fields(err error) []FieldError {
+	var errs Errors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+	out := make([]FieldError, len(errs))
+	for i, fe := range errs {
+		out[i] = FieldError{Field: fe.Field, Code: fe.Code}
+	}
+	return out
+}
+
+func TestValidateMigrationRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  models.MigrationRequest
+		want []FieldError
+	}{
+		{
+			name: "valid",
+			req:  models.MigrationRequest{SourceBucket: "source", DestBucket: "dest"},
+		},
+		{
+			name: "dest bucket required",
+			req:  models.MigrationRequest{SourceBucket: "source"},
+			want: []FieldError{{Field: "dest_bucket", Code: CodeRequired}},
+		},
+		{
+			name: "legacy us-east-1 source bucket",
+			req:  models.MigrationRequest{SourceBucket: "Legacy_Bucket", DestBucket: "dest"},
+		},
+		{
+			name: "legacy name rejected outside us-east-1",
+			req: models.MigrationRequest{
+				SourceBucket:      "Legacy_Bucket",
+				DestBucket:        "dest",
+				SourceCredentials: &models.Credentials{Region: "eu-west-1"},
+			},
+			want: []FieldError{{Field: "source_bucket", Code: CodeInvalidFormat}},
+		},
+		{
+			name: "legacy name rejected as destination",
+			req:  models.MigrationRequest{SourceBucket: "source", DestBucket: "Legacy_Bucket"},
+			want: []FieldError{{Field: "dest_bucket", Code: CodeInvalidFormat}},
+		},
+		{
+			name: "incomplete credentials",
+			req: models.MigrationRequest{
+				SourceBucket:      "source",
+				DestBucket:        "dest",
+				SourceCredentials: &models.Credentials{AccessKey: "AKIA"},
+			},
+			want: []FieldError{{Field: "source_credentials.secret_key", Code: CodeIncomplete}},
+		},
+		{
+			name: "same bucket without prefix",
+			req:  models.MigrationRequest{SourceBucket: "data", DestBucket: "data"},
+			want: []FieldError{{Field: "dest_bucket", Code: CodeConflict}},
+		},
+		{
+			name: "unknown mode and negative timeout",
+			req:  models.MigrationRequest{SourceBucket: "source", DestBucket: "dest", MigrationMode: "mirror", Timeout: -1},
+			want: []FieldError{{Field: "migration_mode", Code: CodeInvalidValue}, {Field: "timeout", Code: CodeInvalidValue}},
+		},
+		{
+			name: "unknown folder marker mode",
+			req:  models.MigrationRequest{SourceBucket: "source", DestBucket: "dest", FolderMarkers: "keep"},
+			want: []FieldError{{Field: "folder_markers", Code: CodeInvalidValue}},
+		},
+		{
+			name: "never overwrite with a strategy replacing existing objects",
+			req:  models.MigrationRequest{SourceBucket: "source", DestBucket: "dest", NeverOverwrite: "skip", ConflictStrategy: "newest"},
+			want: []FieldError{{Field: "never_overwrite", Code: CodeConflict}},
+		},
+		{
+			name: "unknown never overwrite mode",
+			req:  models.MigrationRequest{SourceBucket: "source", DestBucket: "dest", NeverOverwrite: "true"},
+			want: []FieldError{{Field: "never_overwrite", Code: CodeInvalidValue}},
+		},
+		{
+			name: "webhook",
+			req: models.MigrationRequest{SourceBucket: "source", DestBucket: "dest",
+				Webhook: &models.WebhookOptions{URL: "https://hooks.example.com/s3", ManifestBucket: "reports", URLExpiry: 3600}},
+		},
+		{
+			name: "webhook without a URL and with a link past the SigV4 limit",
+			req: models.MigrationRequest{SourceBucket: "source", DestBucket: "dest",
+				Webhook: &models.WebhookOptions{URL: "hooks.example.com", URLExpiry: 8 * 24 * 3600}},
+			want: []FieldError{{Field: "webhook.url", Code: CodeInvalidFormat}, {Field: "webhook", Code: CodeInvalidValue}},
+		},
+		{
+			name: "audit log in another bucket with a prefix escaping it",
+			req: models.MigrationRequest{SourceBucket: "source", DestBucket: "dest",
+				AuditLog: &models.AuditLogOptions{Enabled: true, Bucket: "Audit_Bucket", Prefix: "logs/../x"}},
+			want: []FieldError{{Field: "audit_log.bucket", Code: CodeInvalidFormat}, {Field: "audit_log.prefix", Code: CodeInvalidFormat}},
+		},
+		{
+			name: "ACL mapping that applies explicit grants",
+			req: models.MigrationRequest{SourceBucket: "source", DestBucket: "dest",
+				ACL: &models.ACLOptions{Enabled: true, Mapping: map[string]string{"grants": "apply"}}},
+			want: []FieldError{{Field: "acl.mapping", Code: CodeInvalidValue}},
+		},
+		{
+			name: "verify with an unknown mode and a cutover",
+			req: models.MigrationRequest{SourceBucket: "source", DestBucket: "dest",
+				Verify: &models.VerifyOptions{Mode: "md5"}, Cutover: &models.CutoverOptions{Enabled: true}},
+			want: []FieldError{{Field: "verify", Code: CodeConflict}, {Field: "verify.mode", Code: CodeInvalidValue}},
+		},
+		{
+			name: "labels with a reserved tag key",
+			req: models.MigrationRequest{SourceBucket: "source", DestBucket: "dest",
+				Labels: &models.LabelOptions{Enabled: true, Tags: map[string]string{"aws:migrated-by": "{task_id}"}}},
+			want: []FieldError{{Field: "labels.tags", Code: CodeInvalidValue}},
+		},
+		{
+			name: "reorganize into another bucket",
+			req: models.MigrationRequest{SourceBucket: "source", DestBucket: "dest", DestPrefix: "archive",
+				Reorganize: &models.ReorganizeOptions{Enabled: true}},
+			want: []FieldError{{Field: "reorganize", Code: CodeConflict}},
+		},
+		{
+			name: "reorganize into a prefix inside the source prefix, with verify",
+			req: models.MigrationRequest{SourceBucket: "data", SourcePrefix: "logs/", DestBucket: "data", DestPrefix: "logs/2024",
+				Reorganize: &models.ReorganizeOptions{Enabled: true}, Verify: &models.VerifyOptions{}},
+			want: []FieldError{{Field: "dest_prefix", Code: CodeConflict}, {Field: "reorganize", Code: CodeConflict}},
+		},
+		{
+			name: "destination fetch within one endpoint, with a negative size",
+			req: models.MigrationRequest{SourceBucket: "source", DestBucket: "dest",
+				DestFetch: &models.DestFetchOptions{URL: "https://fetch.internal", MinSizeMB: -1}},
+			want: []FieldError{{Field: "dest_fetch", Code: CodeInvalidValue}, {Field: "dest_fetch", Code: CodeConflict}},
+		},
+		{
+			name: "conditional GET within one endpoint",
+			req:  models.MigrationRequest{SourceBucket: "source", DestBucket: "dest", ConditionalGet: true},
+			want: []FieldError{{Field: "conditional_get", Code: CodeConflict}},
+		},
+		{
+			name: "unknown key sanitization rule",
+			req:  models.MigrationRequest{SourceBucket: "source", DestBucket: "dest", KeySanitize: []string{"trim_trailing_spaces", "lowercase"}},
+			want: []FieldError{{Field: "key_sanitize", Code: CodeInvalidValue}},
+		},
+		{
+			name: "prefix escaping the bucket",
+			req:  models.MigrationRequest{SourceBucket: "source", DestBucket: "dest", DestPrefix: "a/../b"},
+			want: []FieldError{{Field: "dest_prefix", Code: CodeInvalidFormat}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fields(ValidateMigrationRequest(&tt.req))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got errors %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("error %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSecretRefMessageIsNotAFormat(t *testing.T) {
+	ref := "arn:aws:secretsmanager::123:secret:100%done"
+	var errs Errors
+	validateCredentials(&errs, "source_credentials", &models.Credentials{SecretRef: ref})
+	if len(errs) != 1 || errs[0].Field != "source_credentials.secret_ref" {
+		t.Fatalf("expected one secret_ref error, got %+v", errs)
+	}
+	if !strings.Contains(errs[0].Message, ref) {
+		t.Fatalf("message %q does not quote the reference verbatim", errs[0].Message)
+	}
+}
+
+func TestValidateGoogleDriveDestination(t *testing.T) {
+	drive := &models.GoogleDriveCredentials{AccessToken: "ya29"}
+	tests := []struct {
+		name string
+		req  models.GoogleDriveMigrationRequest
+		want []FieldError
+	}{
+		{
+			name: "keys",
+			req:  models.GoogleDriveMigrationRequest{DestBucket: "dest", SourceCredentials: drive, DestCredentials: &models.Credentials{AccessKey: "AKIA", SecretKey: "s"}},
+		},
+		{
+			name: "profile with region only",
+			req:  models.GoogleDriveMigrationRequest{DestBucket: "dest", SourceCredentials: drive, DestProfile: "backup", DestCredentials: &models.Credentials{Region: "eu-west-1"}},
+		},
+		{
+			name: "no destination credentials",
+			req:  models.GoogleDriveMigrationRequest{DestBucket: "dest", SourceCredentials: drive},
+			want: []FieldError{{Field: "dest_credentials", Code: CodeRequired}},
+		},
+		{
+			name: "region without keys",
+			req:  models.GoogleDriveMigrationRequest{DestBucket: "dest", SourceCredentials: drive, DestCredentials: &models.Credentials{Region: "us-east-1"}},
+			want: []FieldError{{Field: "dest_credentials.access_key", Code: CodeRequired}},
+		},
+		{
+			name: "profile and keys",
+			req:  models.GoogleDriveMigrationRequest{DestBucket: "dest", SourceCredentials: drive, DestProfile: "backup", DestCredentials: &models.Credentials{AccessKey: "AKIA", SecretKey: "s"}},
+			want: []FieldError{{Field: "dest_profile", Code: CodeConflict}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fields(ValidateGoogleDriveMigrationRequest(&tt.req))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got errors %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("error %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
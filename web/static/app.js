@@ -2,6 +2,26 @@
 
 const API_BASE = '';
 
+// Send the API key (asked for on the first 401 when the server sets API_KEYS) with every API call
+const nativeFetch = window.fetch.bind(window);
+function withApiKey(options, apiKey) {
+    return apiKey ? { ...options, headers: { ...(options.headers || {}), 'X-API-Key': apiKey } } : options;
+}
+window.fetch = async (url, options = {}) => {
+    if (!String(url).includes('/api/')) {
+        return nativeFetch(url, options);
+    }
+    const response = await nativeFetch(url, withApiKey(options, localStorage.getItem('apiKey')));
+    if (response.status === 401) {
+        const entered = (prompt('This server requires an API key:') || '').trim();
+        if (entered) {
+            localStorage.setItem('apiKey', entered);
+            return nativeFetch(url, withApiKey(options, entered));
+        }
+    }
+    return response;
+};
+
 // Utility function to format bytes
 function formatBytes(bytes) {
     if (bytes === 0) return '0 B';
@@ -474,7 +494,7 @@ async function testConnection(event) {
             console.log('Connection details:', data);
         } else {
             alert('❌ Connection failed: ' + data.message + 
-                  (data.hint ? '\n\nHint: ' + data.hint : ''));
+                  (data.details && data.details.hint ? '\n\nHint: ' + data.details.hint : ''));
             console.error('Connection error:', data);
         }
         
@@ -732,16 +752,27 @@ async function handleMigrationSubmit(event) {
         };
     }
     
+    const startMigration = () => fetch(`${API_BASE}/api/migrate`, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json'
+        },
+        body: JSON.stringify(migrationData)
+    });
+    
     try {
-        const response = await fetch(`${API_BASE}/api/migrate`, {
-            method: 'POST',
-            headers: {
-                'Content-Type': 'application/json'
-            },
-            body: JSON.stringify(migrationData)
-        });
-        
-        const result = await response.json();
+        let response = await startMigration();
+        let result = await response.json();
+        
+        // Migrations above the server's size or cost guardrail need a second confirmation
+        const estimate = result.code === 'confirmation_required' && result.details.estimate;
+        if (estimate &&
+            confirm(`⚠️ This migration is large: ${estimate.exceeds.join('; ')}.\n\n` +
+                `${estimate.objects} objects, ${estimate.total_size_gb} GB, estimated ${estimate.estimated_cost} ${estimate.currency}. Start it anyway?`)) {
+            migrationData.confirm_large_migration = true;
+            response = await startMigration();
+            result = await response.json();
+        }
         
         if (response.ok) {
             showResult('migrationResult', 'success', `
@@ -1087,16 +1118,16 @@ function createTaskCard(task) {
                 </div>
                 ` : ''}
             </div>
-            ${(task.eta === 'discovering...' || task.eta === 'starting upload...') ? `
+            ${(task.phase === 'discovering' || task.eta === 'starting upload...') ? `
                 <div class="discovery-status">
                     <div class="discovery-indicator">
                         <div class="discovery-spinner"></div>
                         <span class="discovery-text">
-                            ${task.eta === 'discovering...' ? '🔍 Discovering files in Google Drive...' : '🚀 Preparing to upload files...'}
+                            ${task.phase === 'discovering' ? `🔍 ${(task.discovery?.files_discovered || 0).toLocaleString()} files discovered` : '🚀 Preparing to upload files...'}
                         </span>
                     </div>
                     <div class="discovery-note">
-                        ${task.eta === 'discovering...' ? 'This may take a few minutes for large folders' : 'Upload will begin shortly'}
+                        ${task.phase === 'discovering' ? `${formatBytes(task.discovery?.bytes_discovered || 0)} in ${(task.discovery?.folders_found || 0).toLocaleString()} folders so far` : 'Upload will begin shortly'}
                     </div>
                 </div>
             ` : ''}
@@ -1107,6 +1138,9 @@ function createTaskCard(task) {
                     <button class="btn btn-danger btn-small" onclick="cancelTask('${task.task_id}')">
                         Cancel
                     </button>
+                    <button class="btn btn-secondary btn-small" onclick="shareTask('${task.task_id}')">
+                        Share
+                    </button>
                 </div>
             ` : task.status === 'failed' ? `
                 <div class="task-actions">
@@ -1230,6 +1264,22 @@ async function cancelTask(taskId) {
     }
 }
 
+// Share Task: read-only status link for people without an API key
+async function shareTask(taskId) {
+    try {
+        const response = await fetch(`${API_BASE}/api/tasks/${taskId}/share`, { method: 'POST' });
+        const data = await response.json();
+        if (!response.ok) {
+            alert(`Failed to share task: ${data.error}`);
+            return;
+        }
+        const link = `${window.location.origin}${data.url}`;
+        prompt(`Read-only link, valid until ${new Date(data.expires_at).toLocaleString()}:`, link);
+    } catch (error) {
+        alert(`Error: ${error.message}`);
+    }
+}
+
 // Retry removed - credentials not persisted for security
 // Users should start a new migration to resume (already copied files will be skipped)
 